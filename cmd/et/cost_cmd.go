@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/textutil"
+)
+
+// cmdCost implements "et cost": aggregates _cost.json files across run log
+// directories and prints spend broken down by role, provider, and model.
+func cmdCost(args []string) error {
+	fs := flag.NewFlagSet("cost", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to config file used to locate log_dir (optional)")
+	logDir := fs.String("log-dir", "", "log directory to scan (default: log_dir from config, then ~/Documents/electrictown-logs)")
+	sinceStr := fs.String("since", "", "only include requests on or after this date (YYYY-MM-DD)")
+	asJSON := fs.Bool("json", false, "print the aggregate summary as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	dir := *logDir
+	if dir == "" {
+		// Use the configured log_dir when a config is available; otherwise the
+		// zero-value config resolves to the default location.
+		cfg := &provider.Config{}
+		if resolved, err := findConfig(*configPath); err == nil {
+			if loaded, err := provider.LoadConfig(resolved); err == nil {
+				cfg = loaded
+			} else if *configPath != "" {
+				return fmt.Errorf("loading config: %w", err)
+			}
+		} else if *configPath != "" {
+			return err
+		}
+		resolvedDir, err := cfg.ResolveLogDir()
+		if err != nil {
+			return fmt.Errorf("resolving log_dir: %w", err)
+		}
+		dir = resolvedDir
+	}
+
+	var since time.Time
+	if *sinceStr != "" {
+		t, err := time.ParseInLocation("2006-01-02", *sinceStr, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --since %q (expected YYYY-MM-DD): %w", *sinceStr, err)
+		}
+		since = t
+	}
+
+	records, runs, err := cost.LoadDir(dir, since)
+	if err != nil {
+		return fmt.Errorf("reading cost files in %s: %w", dir, err)
+	}
+	sum := cost.Summarize(records)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sum)
+	}
+
+	fmt.Printf("Log dir: %s\n", dir)
+	fmt.Printf("Runs:    %d\n", runs)
+	if !since.IsZero() {
+		fmt.Printf("Since:   %s\n", since.Format("2006-01-02"))
+	}
+	if sum.TotalRequests == 0 {
+		fmt.Println("\nNo recorded requests.")
+		return nil
+	}
+
+	roles := make(map[string]costRow, len(sum.ByRole))
+	for k, v := range sum.ByRole {
+		roles[k] = costRow{v.Requests, v.Tokens, v.Cost, v.Estimated}
+	}
+	providers := make(map[string]costRow, len(sum.ByProvider))
+	for k, v := range sum.ByProvider {
+		providers[k] = costRow{v.Requests, v.Tokens, v.Cost, v.Estimated}
+	}
+	models := make(map[string]costRow, len(sum.ByModel))
+	for k, v := range sum.ByModel {
+		models[k] = costRow{v.Requests, v.Tokens, v.Cost, v.Estimated}
+	}
+
+	printCostTable("ROLE", roles)
+	printCostTable("PROVIDER", providers)
+	printCostTable("MODEL", models)
+
+	fmt.Printf("\n%-32s %8d %12s %10s\n", "TOTAL", sum.TotalRequests, formatEstToks(sum.TotalTokens, sum.Estimated), fmt.Sprintf("$%.4f", sum.TotalCost))
+	if sum.Estimated {
+		fmt.Println("\n~ marks token counts estimated from message text (provider reported no usage)")
+	}
+	return nil
+}
+
+// costRow is one line of a cost breakdown table.
+type costRow struct {
+	requests  int
+	tokens    int
+	cost      float64
+	estimated bool
+}
+
+// printCostTable prints a breakdown table sorted by descending cost, then name.
+func printCostTable(label string, rows map[string]costRow) {
+	keys := make([]string, 0, len(rows))
+	for k := range rows {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if rows[keys[i]].cost != rows[keys[j]].cost {
+			return rows[keys[i]].cost > rows[keys[j]].cost
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Printf("\n%-32s %8s %12s %10s\n", label, "REQUESTS", "TOKENS", "COST")
+	fmt.Printf("%-32s %8s %12s %10s\n", "-----", "--------", "------", "----")
+	for _, k := range keys {
+		name := k
+		if name == "" {
+			name = "(unknown)"
+		}
+		r := rows[k]
+		fmt.Printf("%-32s %8d %12s %10s\n", textutil.Truncate(name, 32), r.requests, formatEstToks(r.tokens, r.estimated), fmt.Sprintf("$%.4f", r.cost))
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/doctor"
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// cmdDoctor implements "et doctor": checks auth env vars, config validity,
+// provider reachability, and role resolution, printing a ✓/✗ checklist.
+// Returns an error when any critical check fails.
+func cmdDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to config file (default: ./electrictown.yaml, then $HOME/electrictown.yaml)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolvedConfig, err := findConfig(*configPath)
+	if err != nil {
+		fmt.Printf("✗ config file found\n    %v\n", err)
+		return fmt.Errorf("doctor: no config file")
+	}
+	data, err := provider.ReadConfig(resolvedConfig)
+	if err != nil {
+		fmt.Printf("✗ config file readable\n    %v\n", err)
+		return fmt.Errorf("doctor: cannot read %s", resolvedConfig)
+	}
+	fmt.Printf("✓ config file found (%s)\n", resolvedConfig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	report := doctor.Run(ctx, data, buildFactories())
+	for _, c := range report.Checks {
+		mark := "✓"
+		if !c.OK {
+			mark = "✗"
+		}
+		line := mark + " " + c.Name
+		if !c.OK && !c.Critical {
+			line += " (warning)"
+		}
+		if c.OK && c.Detail != "" {
+			line += " (" + c.Detail + ")"
+		}
+		fmt.Println(line)
+		if !c.OK && c.Detail != "" {
+			fmt.Printf("    %s\n", c.Detail)
+		}
+	}
+
+	if n := report.Failed(); n > 0 {
+		return fmt.Errorf("doctor: %d critical check(s) failed", n)
+	}
+	fmt.Println("\nAll critical checks passed.")
+	return nil
+}
@@ -4,39 +4,50 @@
 // Usage:
 //
 //	et run [--config path] [--role name] "task description"
-//	et models [--config path]
+//	et models [--config path] [--refresh] [--cache-ttl 5m] [--verbose] [--debug]
+//	et cost [--log-dir path] [--since YYYY-MM-DD] [--json]
 //	et version
 package main
 
 import (
+	"bufio"
+	"cmp"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/meganerd/electrictown/internal/build"
-	"github.com/meganerd/electrictown/internal/cache"
 	"github.com/meganerd/electrictown/internal/cost"
 	"github.com/meganerd/electrictown/internal/decision"
+	"github.com/meganerd/electrictown/internal/fileblock"
 	"github.com/meganerd/electrictown/internal/fileutil"
-	"github.com/meganerd/electrictown/internal/jina"
+	"github.com/meganerd/electrictown/internal/interrupt"
+	"github.com/meganerd/electrictown/internal/manifest"
+	"github.com/meganerd/electrictown/internal/pipeline"
 	"github.com/meganerd/electrictown/internal/pool"
 	"github.com/meganerd/electrictown/internal/provider"
 	"github.com/meganerd/electrictown/internal/provider/anthropic"
 	"github.com/meganerd/electrictown/internal/provider/gemini"
+	"github.com/meganerd/electrictown/internal/provider/mistral"
 	"github.com/meganerd/electrictown/internal/provider/ollama"
 	"github.com/meganerd/electrictown/internal/provider/openai"
-	"github.com/meganerd/electrictown/internal/rag"
 	"github.com/meganerd/electrictown/internal/role"
-	"github.com/meganerd/electrictown/internal/validate"
+	"github.com/meganerd/electrictown/internal/runevent"
+	"github.com/meganerd/electrictown/internal/runlog"
+	"github.com/meganerd/electrictown/internal/runreport"
+	"github.com/meganerd/electrictown/internal/runstate"
+	"github.com/meganerd/electrictown/internal/textutil"
 )
 
 var version = "dev"
@@ -52,6 +63,17 @@ func main() {
 	case "run":
 		if err := cmdRun(os.Args[2:]); err != nil {
 			fmt.Fprintf(os.Stderr, "error: %s\n", friendlyError(err))
+			if errors.Is(err, interrupt.ErrInterrupted) {
+				os.Exit(130)
+			}
+			os.Exit(1)
+		}
+	case "replay":
+		if err := cmdReplay(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", friendlyError(err))
+			if errors.Is(err, interrupt.ErrInterrupted) {
+				os.Exit(130)
+			}
 			os.Exit(1)
 		}
 	case "models":
@@ -69,6 +91,16 @@ func main() {
 			fmt.Fprintf(os.Stderr, "error: %s\n", err)
 			os.Exit(1)
 		}
+	case "cost":
+		if err := cmdCost(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", friendlyError(err))
+			os.Exit(1)
+		}
+	case "doctor":
+		if err := cmdDoctor(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", friendlyError(err))
+			os.Exit(1)
+		}
 	case "session":
 		if err := cmdSession(os.Args[2:]); err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -90,18 +122,24 @@ func printUsage() {
 
 Usage:
   et run [--config path] [--role name] "task description"
+  et replay  <run-id> [--config path] [--no-tester]
   et session <spawn|list|attach|kill|send> [args]
   et rag     <ingest|query|stats> [flags] [args]
-  et models  [--config path]
-  et nodes   [--config path]
+  et models  [--config path] [--refresh] [--cache-ttl 5m] [--verbose] [--debug]
+  et nodes   [--config path] [--watch [--interval 5s] | --pull model]
+  et cost    [--log-dir path] [--since YYYY-MM-DD] [--json]
+  et doctor  [--config path]
   et version
 
 Commands:
   run      Execute supervisor→worker flow for a task
+  replay   Synthesize a pooled run's saved worker outputs again, without the workers
   session  Manage interactive agent sessions in tmux
   rag      Manage RAG knowledge base (ingest, query, stats)
   models   List all available models from configured providers
-  nodes    Ping Ollama nodes, list models, show availability
+  nodes    Ping Ollama nodes, list models, show availability and loaded models
+  cost     Report aggregate spend from run log directories
+  doctor   Check config, auth env vars, provider reachability, and roles
   version  Print version information
 
 Flags (run):
@@ -112,7 +150,8 @@ Flags (run):
   --no-tester       Skip Phase 4 tester polish of synthesized output
   --iterate         Enable Phase 5 iterative build/fix loop (requires --output-dir)
   --max-iterations  Max build/fix iterations for --iterate (default: 3)
-  --max-subtasks    Max subtasks for decomposition (0 = Mayor default of 10)
+  --max-subtasks    Max subtasks for decomposition, 1-50 (0 = defaults.max_subtasks, else 10)
+  --max-context-tokens  Synthesis prompt budget; larger worker output is synthesized in batches (0 = no limit)
   --timeout         Total timeout in minutes for the entire run (default: 30)
   --output-dir      Directory to write output files (default: stdout only)
   --rag-url         Qdrant server URL for RAG context injection (empty = disabled)
@@ -123,16 +162,51 @@ Flags (run):
   --guardrail-retries   Max retries for workers scoring below guardrail threshold (default: 1)
   --guardrail-threshold Minimum reviewer score (1-10) before triggering retry (default: 6)
   --no-specialists      Disable specialist routing (ignore specialists config)
+  --trace-fallbacks     Log each fallback (role, from, to, reason) and summarize them at the end
+  --debug               Log each provider request, routed model, failure and fallback to stderr
+  --git-meta            Record git commit/branch/dirty state in _manifest.json (default: true; --git-meta=false to disable)
+  --no-banner           Suppress the run header block; ">>> phase=<name>" markers are always printed
+  --synthesis-format    Print the synthesis between banners (banner, the default), as bare markdown, as plain text, or not at all (files-only)
+  --subtask-file        Skip supervisor decomposition; read subtasks from a file (one per line, or a JSON array)
+  --breaker-failures    Consecutive transient failures that open a model's circuit breaker (default: 3; 0 = disabled)
+  --breaker-cooldown    How long an open circuit skips its model before probing again (default: 1m)
+  --workers             Max concurrent workers (default: 0 = one per pool member)
+  --fix-workers         Max concurrent Phase 5/5.5 fix workers (default: 0 = same as --workers)
+  --subtask-timeout     Cancel a worker still running after this long, leaving other workers running (default: 0 = no limit)
+  --subtask-retries     Retry a failed subtask on up to N other pool members (default: 0 = one retry on the same member)
+  --language            Target language for Phase 5 (go); scaffolds go.mod if missing (default: inferred)
+  --reviewer-panel      Score Phase 2.5 with several model aliases (comma-separated) and use the median score
+  --redo-flagged        Re-dispatch each still-flagged subtask once to another pool member and keep the higher-scoring output
+  --json                Suppress human output; print one JSON report on stdout when the run ends
+  --stream-json         Suppress human output; print one JSON event per line on stdout as the run progresses
+  --deterministic       Derive the run ID from the task and config file; reruns get a -2, -3, ... suffix
+  --resume              Continue a failed pooled run by ID from its _state.json, skipping decomposition and workers
+  --dry-run             Decompose, print each subtask's pool member and a token/cost estimate, then stop before Phase 2
+  --diff                Print a unified diff against each existing --output-dir file before overwriting it
+  --confirm             Show each new or changed file's diff and ask y/N before writing it (implies --diff)
+  --no-write            Parse worker output and list the files and sizes --output-dir would get, without writing them
+  --read-files          Give workers a read_file tool for existing --output-dir files (needs models with tool support)
+  --run-tests           Run the project's tests after a successful Phase 5 build and dispatch fixes for failures (implies --iterate)
+  --max-test-iterations Max test/fix iterations for --run-tests (default: 3)
 
 Flags (models, nodes):
   --config   Path to config file (default: ./electrictown.yaml, then $HOME/electrictown.yaml)
+  --watch    (nodes) Redraw a live node status table until Ctrl-C
+  --interval (nodes) Refresh interval for --watch (default: 5s)
+  --pull     (nodes) Pull a model onto every reachable node that lacks it, then exit
+
+Flags (cost):
+  --config   Path to config file used to locate log_dir (optional)
+  --log-dir  Log directory to scan (default: log_dir from config, then ~/Documents/electrictown-logs)
+  --since    Only include requests on or after this date (YYYY-MM-DD)
+  --json     Print the aggregate summary as JSON
 
 Run 'et session --help' for session management details.
 Run 'et rag ingest --help', 'et rag query --help', or 'et rag stats --help' for RAG details.
 `)
 }
 
-// buildFactories returns the provider factory map wiring all four adapters.
+// buildFactories returns the provider factory map wiring every adapter.
 func buildFactories() map[string]provider.ProviderFactory {
 	return map[string]provider.ProviderFactory{
 		"openai": func(pc provider.ProviderConfig) (provider.Provider, error) {
@@ -140,13 +214,27 @@ func buildFactories() map[string]provider.ProviderFactory {
 			if pc.BaseURL != "" {
 				opts = append(opts, openai.WithBaseURL(pc.BaseURL))
 			}
+			if len(pc.Headers) > 0 {
+				opts = append(opts, openai.WithDefaultHeaders(pc.Headers))
+			}
 			return openai.New(pc.APIKey, opts...), nil
 		},
+		openai.CompatibleName: openai.CompatibleFactory,
 		"anthropic": func(pc provider.ProviderConfig) (provider.Provider, error) {
 			var opts []anthropic.Option
 			if pc.BaseURL != "" {
 				opts = append(opts, anthropic.WithBaseURL(pc.BaseURL))
 			}
+			if len(pc.Headers) > 0 {
+				opts = append(opts, anthropic.WithDefaultHeaders(pc.Headers))
+			}
+			if pc.AuthType == provider.AuthAWSSigV4 {
+				signer, err := provider.NewAWSSigV4SignerFromEnv(provider.AWSRegionFromURL(pc.BaseURL), "bedrock")
+				if err != nil {
+					return nil, err
+				}
+				return anthropic.New("", append(opts, anthropic.WithRequestSigner(signer), anthropic.WithBedrock())...), nil
+			}
 			return anthropic.New(pc.APIKey, opts...), nil
 		},
 		"ollama": func(pc provider.ProviderConfig) (provider.Provider, error) {
@@ -158,6 +246,16 @@ func buildFactories() map[string]provider.ProviderFactory {
 			if pc.AuthType != "" {
 				opts = append(opts, ollama.WithAuthType(pc.AuthType))
 			}
+			if pc.KeepAlive != "" {
+				keepAlive, err := provider.ParseKeepAlive(pc.KeepAlive)
+				if err != nil {
+					return nil, err
+				}
+				opts = append(opts, ollama.WithKeepAlive(keepAlive))
+			}
+			if len(pc.Headers) > 0 {
+				opts = append(opts, ollama.WithDefaultHeaders(pc.Headers))
+			}
 			return ollama.New(baseURL, pc.APIKey, opts...), nil
 		},
 		"gemini": func(pc provider.ProviderConfig) (provider.Provider, error) {
@@ -165,8 +263,21 @@ func buildFactories() map[string]provider.ProviderFactory {
 			if pc.BaseURL != "" {
 				opts = append(opts, gemini.WithBaseURL(pc.BaseURL))
 			}
+			if len(pc.Headers) > 0 {
+				opts = append(opts, gemini.WithDefaultHeaders(pc.Headers))
+			}
 			return gemini.New(pc.APIKey, opts...), nil
 		},
+		"mistral": func(pc provider.ProviderConfig) (provider.Provider, error) {
+			var opts []mistral.Option
+			if pc.BaseURL != "" {
+				opts = append(opts, mistral.WithBaseURL(pc.BaseURL))
+			}
+			if len(pc.Headers) > 0 {
+				opts = append(opts, mistral.WithDefaultHeaders(pc.Headers))
+			}
+			return mistral.New(pc.APIKey, opts...), nil
+		},
 	}
 }
 
@@ -174,7 +285,7 @@ func buildFactories() map[string]provider.ProviderFactory {
 // When the worker role has a pool configured, it uses a three-phase pipeline:
 // decompose → parallel execute → synthesize. Otherwise, it falls back to the
 // original single-worker streaming flow.
-func cmdRun(args []string) error {
+func cmdRun(args []string) (err error) {
 	fs := flag.NewFlagSet("run", flag.ExitOnError)
 	configPath := fs.String("config", "", "path to config file (default: ./electrictown.yaml, then $HOME/electrictown.yaml)")
 	supervisorRole := fs.String("role", "mayor", "supervisor role name")
@@ -183,7 +294,10 @@ func cmdRun(args []string) error {
 	noTester := fs.Bool("no-tester", false, "skip Phase 4 tester polish of synthesized output")
 	iterate := fs.Bool("iterate", false, "enable Phase 5 iterative build/fix loop (requires --output-dir)")
 	maxIterations := fs.Int("max-iterations", 3, "max build/fix iterations for --iterate (default: 3)")
-	maxSubtasks := fs.Int("max-subtasks", 0, "max subtasks (0 = use Mayor default of 10)")
+	runTests := fs.Bool("run-tests", false, "enable Phase 5.5 test/fix loop after a successful build (implies --iterate)")
+	maxTestIterations := fs.Int("max-test-iterations", 3, "max test/fix iterations for --run-tests (default: 3)")
+	maxSubtasks := fs.Int("max-subtasks", 0, "max subtasks, 1-50 (0 = defaults.max_subtasks from the config, else 10)")
+	maxContextTokens := fs.Int("max-context-tokens", 0, "estimated-token budget for the synthesis prompt; worker output over it is synthesized in batches, then the batch summaries (0 = no limit)")
 	timeoutMins := fs.Int("timeout", 45, "total timeout in minutes for the entire run")
 	outputDir := fs.String("output-dir", "", "directory to write output files (default: stdout only)")
 	ragURL := fs.String("rag-url", "", "Qdrant server URL for RAG context injection (empty = disabled)")
@@ -193,21 +307,155 @@ func cmdRun(args []string) error {
 	noCoordinate := fs.Bool("no-coordinate", false, "skip Phase 1.5 coordination brief generation")
 	guardrailRetries := fs.Int("guardrail-retries", 1, "max retries for workers scoring below guardrail threshold")
 	guardrailThreshold := fs.Int("guardrail-threshold", 6, "minimum reviewer score (1-10) before triggering guardrail retry")
+	reviewerPanel := fs.String("reviewer-panel", "", "comma-separated model aliases that score Phase 2.5 together; the median score is used")
+	redoFlagged := fs.Bool("redo-flagged", false, "after Phase 2.5, re-dispatch each flagged subtask once to another pool member and keep the higher-scoring output")
 	noSpecialists := fs.Bool("no-specialists", false, "disable specialist routing (ignore specialists config)")
+	traceFallbacks := fs.Bool("trace-fallbacks", false, "log every fallback activation and print a summary at the end of the run")
+	debug := fs.Bool("debug", false, "log every provider request, routing decision and fallback to stderr")
+	gitMeta := fs.Bool("git-meta", true, "record git commit/branch/dirty state of --output-dir (or cwd) in the run manifest")
+	noBanner := fs.Bool("no-banner", false, "suppress the run header block (phase markers are always printed)")
+	synthesisFormat := fs.String("synthesis-format", "banner", "how to print the synthesized output: banner, markdown, plain, or files-only (only _synthesis.md)")
+	workers := fs.Int("workers", 0, "max concurrent workers (0 = one per pool member)")
+	fixWorkers := fs.Int("fix-workers", 0, "max concurrent Phase 5/5.5 fix workers (0 = same as --workers)")
+	subtaskRetries := fs.Int("subtask-retries", 0, "retry a subtask that fails with a retryable error on up to this many other pool members (0 = one retry on the same member)")
+	subtaskTimeout := fs.Duration("subtask-timeout", 0, "cancel a worker still running after this long without stopping the others (0 = bounded only by --timeout)")
+	breakerFailures := fs.Int("breaker-failures", 3, "consecutive transient failures that open a model's circuit breaker (0 = disabled)")
+	breakerCooldown := fs.Duration("breaker-cooldown", time.Minute, "how long an open circuit skips its model before probing it again")
+	subtaskFile := fs.String("subtask-file", "", "use this pre-written decomposition (one subtask per line, or a JSON array) instead of asking the supervisor")
+	language := fs.String("language", "", "target language for Phase 5 build detection (go; default: inferred from the task and output files)")
+	jsonOut := fs.Bool("json", false, "suppress the human output and print one JSON report on stdout when the run ends")
+	streamJSON := fs.Bool("stream-json", false, "suppress the human output and print one JSON event per line on stdout as the run progresses")
+	deterministic := fs.Bool("deterministic", false, "derive the run ID from a hash of the task and config file instead of picking one at random")
+	resumeID := fs.String("resume", "", "continue a failed pooled run with this run ID from its _state.json, at synthesis or the build loop")
+	dryRun := fs.Bool("dry-run", false, "decompose the task, print where each subtask would run and a token/cost estimate, then stop before the workers")
+	diffFlag := fs.Bool("diff", false, "print a unified diff against the existing file before each worker file in --output-dir is overwritten")
+	confirmFlag := fs.Bool("confirm", false, "show each new or changed --output-dir file's diff and ask before writing it (implies --diff)")
+	readFiles := fs.Bool("read-files", false, "give workers a read_file tool for files already in --output-dir (needs models with tool support)")
+	noWriteFlag := fs.Bool("no-write", false, "parse worker output and list the files and byte counts that would be written to --output-dir without writing them")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
 	task := strings.Join(fs.Args(), " ")
-	if task == "" {
-		return fmt.Errorf("task description required\n\nUsage: et run [--config path] [--role name] \"task description\"")
+	if task == "" && *resumeID == "" {
+		// echo "task" | et run: take the task from piped stdin.
+		piped, ok, err := fileutil.ReadPiped(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("reading task from stdin: %w", err)
+		}
+		if ok && piped != "" && *confirmFlag {
+			return fmt.Errorf("--confirm reads its answers from stdin; pass the task as an argument")
+		}
+		task = piped
+	}
+	switch {
+	case *resumeID != "" && task != "":
+		return fmt.Errorf("--resume continues the saved task; do not pass a task description")
+	case *resumeID == "" && task == "":
+		return fmt.Errorf("task description required\n\nUsage: et run [--config path] [--role name] \"task description\"\n       echo \"task description\" | et run [--config path] [--role name]")
+	}
+	if *jsonOut && *streamJSON {
+		return fmt.Errorf("--json and --stream-json are mutually exclusive")
+	}
+	if *confirmFlag && (*jsonOut || *streamJSON) {
+		return fmt.Errorf("--confirm prompts on the terminal; it cannot be combined with --json or --stream-json")
+	}
+	if *resumeID != "" && (*subtaskFile != "" || *dryRun) {
+		return fmt.Errorf("--resume cannot be combined with --subtask-file or --dry-run")
+	}
+	if *noWriteFlag {
+		switch {
+		case *outputDir == "":
+			return fmt.Errorf("--no-write requires --output-dir")
+		case *iterate || *runTests:
+			return fmt.Errorf("--no-write cannot be combined with --iterate or --run-tests, which build the written files")
+		}
+	}
+	if err := role.CheckMaxSubtasks(*maxSubtasks); err != nil {
+		return fmt.Errorf("--max-subtasks: %w", err)
+	}
+	synthFormat, err := runlog.ParseSynthesisFormat(*synthesisFormat)
+	if err != nil {
+		return fmt.Errorf("--synthesis-format: %w", err)
+	}
+	lang, err := build.ParseLanguage(*language)
+	if err != nil {
+		return fmt.Errorf("--language: %w", err)
+	}
+	if *maxContextTokens < 0 {
+		return fmt.Errorf("--max-context-tokens must not be negative")
+	}
+	if *confirmFlag && *noWriteFlag {
+		return fmt.Errorf("--confirm cannot be combined with --no-write, which writes nothing")
+	}
+	writes := writeMode{dryRun: *noWriteFlag, diff: *diffFlag || *confirmFlag, confirm: *confirmFlag}
+
+	// With --json, everything normally printed to stdout is dropped and the
+	// report is the only thing written there, even when the run fails.
+	report := runreport.New(task)
+	if *jsonOut {
+		restore, serr := silenceStdout()
+		if serr != nil {
+			return serr
+		}
+		spinnersOff = true
+		defer func() {
+			restore()
+			report.Finish(err)
+			if werr := report.Write(os.Stdout); werr != nil && err == nil {
+				err = werr
+			}
+		}()
+	}
+	// With --stream-json, events go to the real stdout as they happen. A nil
+	// emitter discards them.
+	var events *runevent.Emitter
+	if *streamJSON {
+		events = runevent.New(os.Stdout)
+		restore, serr := silenceStdout()
+		if serr != nil {
+			return serr
+		}
+		spinnersOff = true
+		defer restore()
 	}
 
 	workerRole := "polecat"
 
+	// Load a pre-written decomposition up front so a bad file fails before
+	// any model call.
+	var presetSubtasks []string
+	if *subtaskFile != "" {
+		loaded, err := pool.ReadSubtaskFile(*subtaskFile)
+		if err != nil {
+			return err
+		}
+		presetSubtasks = loaded
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeoutMins)*time.Minute)
 	defer cancel()
 
+	// Ctrl-C cancels the run instead of killing the process, so in-flight
+	// work unwinds and the cost and the files written so far are still
+	// reported. A second Ctrl-C exits at once.
+	ctx, stopSignals := interrupt.NotifyContext(ctx, func() {
+		fmt.Fprintf(os.Stderr, "\ninterrupted again — exiting without cleanup\n")
+		os.Exit(130)
+	}, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	context.AfterFunc(ctx, func() {
+		if interrupt.Interrupted(ctx) {
+			fmt.Fprintf(os.Stderr, "\ninterrupted — stopping after in-flight requests (Ctrl-C again to exit now)\n")
+		}
+	})
+	defer func() {
+		if interrupt.Interrupted(ctx) {
+			printInterrupted(report)
+			err = interrupt.ErrInterrupted
+		}
+	}()
+
 	// Resolve config path (explicit or auto-discover).
 	resolvedConfig, err := findConfig(*configPath)
 	if err != nil {
@@ -219,625 +467,607 @@ func cmdRun(args []string) error {
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
+	if *maxSubtasks == 0 {
+		if err := role.CheckMaxSubtasks(cfg.Defaults.MaxSubtasks); err != nil {
+			return fmt.Errorf("defaults.max_subtasks in %s: %w", resolvedConfig, err)
+		}
+		*maxSubtasks = cfg.Defaults.MaxSubtasks
+	}
 
-	router, err := provider.NewRouter(cfg, buildFactories())
+	panelAliases, err := parseReviewerPanel(*reviewerPanel, cfg)
+	if err != nil {
+		return err
+	}
+
+	// The circuit breaker sends requests for a hard-down model straight to its
+	// fallbacks instead of waiting out a timeout on every subtask.
+	routerOpts := []provider.RouterOption{provider.WithCircuitBreaker(*breakerFailures, *breakerCooldown)}
+	if *debug {
+		routerOpts = append(routerOpts, provider.WithLogger(debugLogger()))
+	}
+	router, err := provider.NewRouter(cfg, buildFactories(), routerOpts...)
 	if err != nil {
 		return fmt.Errorf("creating router: %w", err)
 	}
+	if *traceFallbacks {
+		ft := &fallbackTrace{}
+		router.SetFallbackHook(ft.record)
+		defer ft.printSummary()
+	}
 
-	// Build the per-run log directory: {log_dir}/{YYYY-MM-DD}_{shortID}.
+	// Run log directories are {log_dir}/{YYYY-MM-DD}_{runID}.
 	baseLogDir, err := cfg.ResolveLogDir()
 	if err != nil {
 		return fmt.Errorf("resolving log_dir: %w", err)
 	}
-	runID, err := generateShortID()
-	if err != nil {
+
+	// --resume reloads the checkpoint of an earlier run. The resumed run gets
+	// its own log directory (and cost file) and carries on with the saved
+	// task, writing to the saved output directory unless --output-dir is set.
+	var resume *runstate.State
+	if *resumeID != "" {
+		resumeDir, err := runstate.FindRunDir(baseLogDir, *resumeID)
+		if err != nil {
+			return err
+		}
+		if resume, err = runstate.Load(resumeDir); err != nil {
+			return err
+		}
+		task = resume.Task
+		report.Task = task
+		if *outputDir == "" {
+			*outputDir = resume.OutputDir
+		}
+	}
+	// --deterministic derives the run ID from the task and the merged
+	// config, so reruns of a task are easy to find (abcd1234, abcd1234-2, ...).
+	day := time.Now().Format("2006-01-02")
+	var runID, runLogDir string
+	if *deterministic {
+		configData, err := provider.ReadConfig(resolvedConfig)
+		if err != nil {
+			return fmt.Errorf("reading config: %w", err)
+		}
+		if runID, runLogDir, err = runstate.FreeRunID(baseLogDir, day, runstate.TaskRunID(task, configData)); err != nil {
+			return err
+		}
+	} else if runID, err = generateShortID(); err != nil {
 		return fmt.Errorf("generating run ID: %w", err)
+	} else {
+		runLogDir = filepath.Join(baseLogDir, day+"_"+runID)
 	}
-	runLogDir := filepath.Join(baseLogDir, time.Now().Format("2006-01-02")+"_"+runID)
+	report.RunID, report.LogDir = runID, runLogDir
 	if err := os.MkdirAll(runLogDir, 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "  warning: cannot create log directory %s: %s — continuing without logs\n", runLogDir, classifyFSError(err))
 	}
 
-	fmt.Printf("electrictown %s\n", version)
-	fmt.Printf("============\n")
-	fmt.Printf("Config: %s\n", resolvedConfig)
-	fmt.Printf("Task:   %s\n", task)
-	fmt.Printf("Logs:   %s\n", runLogDir)
-	fmt.Printf("Start:  %s\n\n", time.Now().Format("15:04:05"))
+	// Record what this run is operating on for later traceability.
+	m := &manifest.Manifest{
+		RunID:     runID,
+		Version:   version,
+		Task:      task,
+		Config:    resolvedConfig,
+		OutputDir: *outputDir,
+		StartedAt: time.Now(),
+	}
+	if resume != nil {
+		m.ResumedFrom = resume.RunID
+	}
+	if *gitMeta {
+		gitDir := *outputDir
+		if gitDir == "" {
+			gitDir = "."
+		}
+		m.Git = manifest.ReadGit(ctx, gitDir)
+	}
+	if err := m.Write(runLogDir); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: %v\n", err)
+	}
+
+	logOpts := []runlog.Option{runlog.WithSynthesisFormat(synthFormat)}
+	if *noBanner {
+		logOpts = append(logOpts, runlog.WithoutBanner())
+	}
+	if events != nil {
+		logOpts = append(logOpts, runlog.WithPhaseHook(events.PhaseStart))
+	}
+	rl := runlog.New(os.Stdout, logOpts...)
+	rl.Banner(runlog.Header{
+		Version: version,
+		Config:  resolvedConfig,
+		Task:    task,
+		LogDir:  runLogDir,
+		Start:   time.Now(),
+	})
 
 	// Check if the worker role has a pool configured.
 	poolAliases := cfg.PoolForRole(workerRole)
 	if len(poolAliases) > 0 {
-		return cmdRunParallel(ctx, router, cfg, task, *supervisorRole, poolAliases, *noSynthesize, *noReviewer, *noTester, *iterate, *maxIterations, *maxSubtasks, *outputDir, runLogDir, *ragURL, *ragCollection, *ragEmbedURL, *jinaKey, *noCoordinate, *guardrailRetries, *guardrailThreshold, *noSpecialists)
+		opts := []pipeline.Option{
+			pipeline.WithSupervisorRole(*supervisorRole),
+			pipeline.WithPool(poolAliases),
+			pipeline.WithMaxSubtasks(*maxSubtasks),
+			pipeline.WithMaxContextTokens(*maxContextTokens),
+			pipeline.WithOutputDir(*outputDir),
+			pipeline.WithGuardrail(*guardrailRetries, *guardrailThreshold),
+			pipeline.WithReviewerPanel(panelAliases),
+			pipeline.WithWorkers(*workers),
+			pipeline.WithSubtaskTimeout(*subtaskTimeout),
+			pipeline.WithSubtaskRetries(*subtaskRetries),
+			pipeline.WithSubtasks(presetSubtasks),
+			pipeline.WithResume(resume),
+		}
+		// With --output-dir, each worker's raw output can be followed in
+		// the run log directory while it generates.
+		if *outputDir != "" {
+			opts = append(opts, pipeline.WithLiveOutput(runLogDir))
+		}
+		if *ragURL != "" {
+			opts = append(opts, pipeline.WithRAG(*ragURL, *ragCollection, *ragEmbedURL))
+		}
+		// Phase 0.5 runs when --jina-key or JINA_API_KEY is set.
+		if key := cmp.Or(*jinaKey, os.Getenv("JINA_API_KEY")); key != "" {
+			opts = append(opts, pipeline.WithJina(key))
+		}
+		if *noReviewer {
+			opts = append(opts, pipeline.WithoutReviewer())
+		}
+		if *noTester {
+			opts = append(opts, pipeline.WithoutTester())
+		}
+		if *noCoordinate {
+			opts = append(opts, pipeline.WithoutCoordination())
+		}
+		if *noSpecialists {
+			opts = append(opts, pipeline.WithoutSpecialists())
+		}
+		if *readFiles {
+			opts = append(opts, pipeline.WithFileReads())
+		}
+		if writes.dryRun || writes.diff {
+			// Commands, and the files staged for them, could change
+			// --output-dir behind the preview, the diffs or the prompts.
+			opts = append(opts, pipeline.WithoutCommands())
+		}
+		if *redoFlagged {
+			opts = append(opts, pipeline.WithRedoFlagged())
+		}
+		if *dryRun {
+			opts = append(opts, pipeline.WithDryRun())
+		}
+		return cmdRunParallel(ctx, &pooledRun{
+			router:            router,
+			cfg:               cfg,
+			task:              task,
+			opts:              opts,
+			noSynthesize:      *noSynthesize,
+			iterate:           *iterate || *runTests,
+			maxIterations:     *maxIterations,
+			runTests:          *runTests,
+			maxTestIterations: *maxTestIterations,
+			fixWorkers:        *fixWorkers,
+			language:          lang,
+			outputDir:         *outputDir,
+			writes:            writes,
+			runLogDir:         runLogDir,
+			resume:            resume,
+			manifest:          m,
+			rl:                rl,
+			report:            report,
+			events:            events,
+		})
+	}
+	if presetSubtasks != nil {
+		return fmt.Errorf("--subtask-file requires a worker pool (roles.%s.pool in the config)", workerRole)
+	}
+	if *dryRun {
+		return fmt.Errorf("--dry-run requires a worker pool (roles.%s.pool in the config)", workerRole)
+	}
+	if resume != nil {
+		return fmt.Errorf("--resume requires a worker pool (roles.%s.pool in the config)", workerRole)
 	}
 
 	// Legacy single-worker flow (no pool configured).
-	return cmdRunSingle(ctx, router, task, *supervisorRole, workerRole, *outputDir, runLogDir)
+	return cmdRunSingle(ctx, router, cfg, task, *supervisorRole, workerRole, *outputDir, writes, runLogDir, rl, report, events)
 }
 
-// cmdRunParallel implements the multi-phase pipeline:
-//
-//	0. RAG (optional)  0.5. Jina fetch (optional)  1. Decompose  2. Parallel workers
-//	2.5. Reviewer (optional)  3. Synthesize  4. Tester (optional)
-//	5. Build/fix loop (optional, requires --iterate)
-func cmdRunParallel(ctx context.Context, router *provider.Router, cfg *provider.Config, task, supervisorRole string, poolAliases []string, noSynthesize, noReviewer, noTester, iterate bool, maxIterations, maxSubtasks int, outputDir, runLogDir, ragURL, ragCollection, ragEmbedURL, jinaKey string, noCoordinate bool, guardrailRetries, guardrailThreshold int, noSpecialists bool) error {
+// pooledRun is a pooled "et run": the pipeline's options and what the CLI
+// does around it.
+type pooledRun struct {
+	router *provider.Router
+	cfg    *provider.Config
+	task   string
+	opts   []pipeline.Option
+
+	noSynthesize      bool
+	iterate           bool // Phase 5 build/fix loop
+	maxIterations     int
+	runTests          bool // Phase 5.5 test/fix loop
+	maxTestIterations int
+	fixWorkers        int
+	language          string
+
+	outputDir string
+	writes    writeMode
+	runLogDir string
+	resume    *runstate.State
+	manifest  *manifest.Manifest
+	rl        *runlog.Logger
+	report    *runreport.Report
+	events    *runevent.Emitter
+
+	// Set by cmdRunParallel for writeAndBuild.
+	history *build.WriteHistory
+	state   *runstate.State
+	decLog  *decision.Logger
+}
+
+// cmdRunParallel runs the pooled pipeline (see package pipeline for Phases
+// 0-4) with terminal output and writeAndBuild as its build step.
+func cmdRunParallel(ctx context.Context, pr *pooledRun) error {
+	runStart := time.Now()
+
 	// Shared cost tracker for all roles in this run.
 	tracker := cost.NewTracker(cost.DefaultPricing())
-
-	// Phase timing tracker.
-	pt := newPhaseTracker()
+	defer func() {
+		pr.report.Cost = tracker.Summary()
+		pr.events.CostSummary(pr.report.Cost)
+		if err := tracker.WriteFile(filepath.Join(pr.runLogDir, cost.FileName)); err != nil {
+			fmt.Fprintf(os.Stderr, "  warning: could not write %s: %v\n", cost.FileName, err)
+		}
+	}()
 
 	// Decision logger for observability.
-	decLog, decErr := decision.NewLogger(filepath.Join(runLogDir, "_decisions.jsonl"))
+	decLog, decErr := decision.NewLogger(filepath.Join(pr.runLogDir, "_decisions.jsonl"))
 	if decErr != nil {
 		fmt.Fprintf(os.Stderr, "  warning: decision logger: %v — continuing without\n", decErr)
 	}
 	defer decLog.Close()
 
-	// Build Mayor with options.
-	var mayorOpts []role.MayorOption
-	mayorOpts = append(mayorOpts, role.WithMayorRole(supervisorRole))
-	mayorOpts = append(mayorOpts, role.WithMayorCostTracker(tracker))
-	if maxSubtasks > 0 {
-		mayorOpts = append(mayorOpts, role.WithMayorMaxSubtasks(maxSubtasks))
-	}
-	// Inject specialist config into mayor for routing-aware decomposition.
-	hasSpecialists := !noSpecialists && len(cfg.Specialists) > 0
-	if hasSpecialists {
-		mayorOpts = append(mayorOpts, role.WithMayorSpecialists(cfg.Specialists))
-	}
-	mayor := role.NewMayor(router, mayorOpts...)
-
-	// Phase 0: RAG context retrieval (optional — only when --rag-url is set).
-	ragContext := ""
-	workerRAGContext := ""
-	if ragURL != "" {
-		fmt.Printf("Phase 0: RAG context retrieval from %s (collection: %s)...\n", ragURL, ragCollection)
-		ragClient := rag.NewClient(ragURL, ragCollection)
-		ragEmbedder := rag.NewEmbedder(ragEmbedURL, rag.DefaultEmbedModel)
-		retriever := rag.NewRetriever(ragClient, ragEmbedder)
-		results, err := retriever.Retrieve(ctx, task, 3)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "  warning: RAG retrieval failed: %v — continuing without context\n", err)
-		} else {
-			ragContext = retriever.FormatContext(results)
-			workerRAGContext = ragContext
-			fmt.Printf("  Retrieved %d chunks\n", len(results))
+	// Record which worker wrote each file during output writing (used by
+	// Phase 5 to send build errors to the right worker), and checkpoint the
+	// run so a later failure can be resumed with --resume instead of paying
+	// for the workers again.
+	history := build.NewWriteHistory()
+	state := runstate.New(pr.manifest.RunID, pr.task, pr.outputDir)
+	state.Files = history.Owners()
+	pr.history, pr.state, pr.decLog = history, state, decLog
+
+	var lp *liveProgress
+	var lpOnce sync.Once
+	opts := pr.opts
+	if pr.noSynthesize {
+		opts = append(opts, pipeline.WithoutSynthesis())
+	}
+	opts = append(opts,
+		pipeline.WithCostTracker(tracker),
+		pipeline.WithOutput(os.Stdout, os.Stderr),
+		pipeline.WithSpinner(func(label string) func() { return startSpinner(spinLabelWithToks(label, tracker)) }),
+		pipeline.WithPhaseHook(pr.rl.Phase),
+		pipeline.WithEvents(pr.events),
+		pipeline.WithDecisionLogger(decLog),
+		pipeline.WithProgressHook(func(idx, n int, r role.WorkerResult) {
+			lpOnce.Do(func() { lp = newLiveProgress(n) })
+			lp.update(idx, workerLine(idx, n, r))
+		}),
+		pipeline.WithCheckpoint(func(res *pipeline.Result) {
+			state.Subtasks, state.WorkerSystemPrompt, state.Synthesis = res.Subtasks, res.WorkerSystemPrompt, res.Synthesis
+			state.SetResults(res.Workers)
+			saveState(state, pr.runLogDir)
+		}),
+		pipeline.WithBuild(pr.writeAndBuild),
+	)
+	res, err := pipeline.New(pr.router, pr.cfg, opts...).Run(ctx, pr.task)
+	if res != nil {
+		pr.report.Subtasks = res.Subtasks
+		if res.Workers != nil {
+			pr.report.SetWorkers(res.Workers)
 		}
-		fmt.Println()
 	}
-
-	// Augment the task with RAG context for the mayor decompose call.
-	decomposeTask := task
-	if ragContext != "" {
-		decomposeTask = ragContext + "\n---\n\n" + task
+	if err != nil {
+		return err
 	}
 
-	// Phase 0.5: Mayor staleness assessment + Jina Reader URL fetch (optional).
-	// Runs when --jina-key is set (or JINA_API_KEY env var). Skipped when no key.
-	resolvedJinaKey := jinaKey
-	if resolvedJinaKey == "" {
-		resolvedJinaKey = os.Getenv("JINA_API_KEY")
-	}
-	if resolvedJinaKey != "" {
-		fmt.Printf("Phase 0.5: Mayor assessing knowledge staleness...\n")
-		pt.start("Phase 0.5 assess")
-		stopSpin05 := startSpinner(spinLabelWithToks("  assessing", tracker))
-		assess, assessErr := mayor.Assess(ctx, task)
-		stopSpin05()
-		if assessErr != nil {
-			fmt.Fprintf(os.Stderr, "  warning: mayor assess failed: %v — continuing without Jina fetch\n", assessErr)
-		} else {
-			fmt.Printf("  Staleness risk: %s\n", assess.StalenessRisk)
-			if len(assess.FetchURLs) > 0 {
-				fmt.Printf("  Fetching %d URL(s) via Jina Reader...\n", len(assess.FetchURLs))
-				jinaClient := jina.New(resolvedJinaKey)
-				var jinaBuilder strings.Builder
-				for _, u := range assess.FetchURLs {
-					content, fetchErr := jinaClient.FetchURL(ctx, u)
-					if fetchErr != nil {
-						fmt.Fprintf(os.Stderr, "  warning: Jina fetch %s: %v\n", u, fetchErr)
-						continue
-					}
-					if len(content) > 8192 {
-						content = content[:8192]
-					}
-					jinaBuilder.WriteString(fmt.Sprintf("=== Fetched: %s ===\n%s\n\n", u, content))
-					fmt.Printf("  ✓ fetched %s (%d chars)\n", u, len(content))
-				}
-				if jinaBuilder.Len() > 0 {
-					fetched := jinaBuilder.String()
-					decomposeTask = fetched + "\n---\n\n" + decomposeTask
-					workerRAGContext = fetched + "\n\n" + workerRAGContext
-				}
-			}
-		}
-		pt.stop()
-		fmt.Println()
+	// --dry-run stops before the workers.
+	if res.Plan != nil {
+		res.Plan.Write(os.Stdout)
+		return nil
 	}
 
-	// Phase 1: Decompose (with spinner showing live token count).
-	fmt.Printf("Phase 1: Supervisor (%s) decomposing task...\n", supervisorRole)
-	pt.start("Phase 1 decompose")
-	stopSpin1 := startSpinner(spinLabelWithToks("  decomposing", tracker))
-	subtasks, err := mayor.Decompose(ctx, decomposeTask)
-	stopSpin1()
-	if err != nil {
-		return fmt.Errorf("supervisor decompose failed: %w", err)
-	}
-	// Parse dependency markers from subtasks.
-	deps := pool.ParseDependencies(subtasks)
-	hasDeps := pool.HasDependencies(deps)
-
-	decLog.Log(decision.Decision{
-		Phase:   "decompose",
-		Agent:   supervisorRole,
-		Intent:  "split task into parallel subtasks",
-		Action:  fmt.Sprintf("produced %d subtasks", len(subtasks)),
-		Outcome: "success",
-		Detail:  truncate(task, 120),
-	})
-
-	fmt.Printf("  Subtasks: %d\n", len(subtasks))
-	for i, st := range subtasks {
-		fmt.Printf("  [%d] %s\n", i+1, truncate(st, 100))
-	}
-	if hasDeps {
-		fmt.Printf("  Dependencies detected — will execute in waves\n")
-	}
-	pt.stop()
-	fmt.Println()
-
-	// Phase 1.25: Specialist resolution (when specialists are configured).
-	var resolvedModels []string
-	var resolvedFallbacks [][]string
-	if hasSpecialists {
-		fmt.Printf("Phase 1.25: Resolving specialist assignments...\n")
-		specialistNames := cfg.SpecialistNames()
-		resolvedModels = make([]string, len(subtasks))
-		resolvedFallbacks = make([][]string, len(subtasks))
-		specialistBalancers := make(map[string]*provider.Balancer)
-
-		for i, st := range subtasks {
-			assigned := pool.ParseSpecialistAssignment(st)
-			if assigned == "" {
-				// No marker — use default pool via balancer (empty override).
-				resolvedModels[i] = ""
-				fmt.Printf("  [%d] → general-default\n", i+1)
-				continue
-			}
-
-			// Check if specialist exists in config.
-			spec, ok := cfg.Specialists[assigned]
-			if !ok {
-				// Try fuzzy match.
-				if match, found := pool.FuzzyMatchSpecialist(assigned, specialistNames); found {
-					fmt.Fprintf(os.Stderr, "  ⚠ [%d] specialist %q not found, using fuzzy match %q\n", i+1, assigned, match)
-					assigned = match
-					spec = cfg.Specialists[match]
-				} else {
-					fmt.Fprintf(os.Stderr, "  ⚠ [%d] specialist %q not found, falling back to general-default\n", i+1, assigned)
-					resolvedModels[i] = ""
-
-					decLog.Log(decision.Decision{
-						Phase:   "specialist-resolve",
-						Agent:   "orchestrator",
-						Intent:  fmt.Sprintf("resolve specialist %q for subtask %d", assigned, i+1),
-						Action:  "fell back to general-default",
-						Outcome: "warning",
-						Detail:  fmt.Sprintf("specialist %q not in config", assigned),
-					})
-					continue
-				}
-			}
-
-			// Resolve model alias for this specialist.
-			if len(spec.Pool) > 0 {
-				if _, exists := specialistBalancers[assigned]; !exists {
-					specialistBalancers[assigned] = provider.NewBalancer(provider.StrategyRoundRobin)
-				}
-				resolvedModels[i] = specialistBalancers[assigned].Select(assigned, spec.Pool)
-			} else {
-				resolvedModels[i] = spec.Model
-			}
-
-			// Wire specialist fallback chain.
-			if len(spec.Fallbacks) > 0 {
-				resolvedFallbacks[i] = spec.Fallbacks
-			}
+	// --no-synthesize shows the workers' output instead of the summaries.
+	if pr.noSynthesize {
+		return nil
+	}
 
-			fmt.Printf("  [%d] → %s (%s)\n", i+1, assigned, resolvedModels[i])
-
-			decLog.Log(decision.Decision{
-				Phase:   "specialist-resolve",
-				Agent:   "orchestrator",
-				Intent:  fmt.Sprintf("assign subtask %d to specialist", i+1),
-				Action:  fmt.Sprintf("routed to %s via %s", assigned, resolvedModels[i]),
-				Outcome: "success",
-				Detail:  truncate(st, 120),
-			})
-		}
-		fmt.Println()
-	}
-
-	// Phase 1.5: Coordination brief (optional — skipped if --no-coordinate).
-	workerSystemPrompt := workerPrompt(outputDir)
-	if workerRAGContext != "" {
-		workerSystemPrompt = workerRAGContext + "\n---\n\n" + workerSystemPrompt
-	}
-	if !noCoordinate && len(subtasks) > 1 {
-		fmt.Printf("Phase 1.5: Mayor producing coordination brief...\n")
-		pt.start("Phase 1.5 coordinate")
-		stopSpin15 := startSpinner(spinLabelWithToks("  coordinating", tracker))
-		brief, coordErr := mayor.Coordinate(ctx, task, subtasks)
-		stopSpin15()
-		if coordErr != nil {
-			fmt.Fprintf(os.Stderr, "  warning: coordination brief failed: %v — continuing without\n", coordErr)
-		} else if brief != "" {
-			workerSystemPrompt = "## Project Coordination\n" + brief + "\n---\n\n" + workerSystemPrompt
-			fmt.Printf("  ✓ coordination brief injected (%d chars)\n", len(brief))
-		}
-		pt.stop()
-		fmt.Println()
-	}
-
-	// Initialize response cache for deduplication in build/fix iterations.
-	responseCache := cache.New()
-	_ = responseCache // used in Phase 5
-
-	// Phase 2: Worker execution (parallel or DAG-ordered).
-	n := len(subtasks)
-	balancer := provider.NewBalancer(provider.StrategyRoundRobin)
-	wp := pool.New(router, balancer, poolAliases)
-
-	lp := newLiveProgress(n)
-	wp.SetProgressHook(func(idx int, r role.WorkerResult) {
-		status := "✓"
-		if strings.HasPrefix(r.Response, "error:") {
-			status = "✗"
-		}
-		toks := fmt.Sprintf("%d tok", r.Tokens)
-		tps := ""
-		if r.Elapsed > 0 && r.Tokens > 0 {
-			tps = fmt.Sprintf(", %.0f tok/s", float64(r.Tokens)/r.Elapsed.Seconds())
-		}
-		lp.update(idx, fmt.Sprintf("  [%d/%d] %-18s %s (%s%s, %.1fs)",
-			idx+1, n, truncate(r.Role, 18), status, toks, tps, r.Elapsed.Seconds()))
-	})
+	// Phase timing summary.
+	wall := time.Since(runStart)
+	fmt.Printf("\n--- Phase Timing ---\n")
+	fmt.Print(phaseSummary(res.Phases, wall))
+	fmt.Printf("--------------------\n")
 
-	var results []role.WorkerResult
-	pt.start("Phase 2 workers")
-	if hasDeps {
-		fmt.Printf("Phase 2: Workers executing with dependency ordering (%d subtasks, %d pool members)...\n", n, len(poolAliases))
-		var dagErr error
-		if resolvedModels != nil {
-			results, dagErr = wp.ExecuteDAGWithModels(ctx, subtasks, deps, resolvedModels, resolvedFallbacks, workerSystemPrompt)
-		} else {
-			results, dagErr = wp.ExecuteDAG(ctx, subtasks, deps, workerSystemPrompt)
-		}
-		if dagErr != nil {
-			return fmt.Errorf("DAG execution failed: %w", dagErr)
+	// Throughput and worker latency.
+	fmt.Printf("\n--- Throughput ---\n")
+	fmt.Print(throughputSummary(tracker.Summary(), res, wall))
+	fmt.Printf("------------------\n")
+
+	// Per-model reliability summary, also recorded in the manifest.
+	if rel := res.Pool.Reliability(); len(rel) > 0 {
+		fmt.Printf("\n--- Model Reliability ---\n")
+		fmt.Print(reliabilitySummary(rel))
+		fmt.Printf("-------------------------\n")
+		pr.manifest.Reliability = make([]manifest.ModelReliability, len(rel))
+		for i, r := range rel {
+			pr.manifest.Reliability[i] = manifest.ModelReliability(r)
 		}
-	} else {
-		fmt.Printf("Phase 2: Workers executing in parallel (%d subtasks, %d pool members)...\n", n, len(poolAliases))
-		if resolvedModels != nil {
-			results = wp.ExecuteAllWithModels(ctx, subtasks, resolvedModels, resolvedFallbacks, workerSystemPrompt)
-		} else {
-			results = wp.ExecuteAll(ctx, subtasks, workerSystemPrompt)
+		if err := pr.manifest.Write(pr.runLogDir); err != nil {
+			fmt.Fprintf(os.Stderr, "  warning: %v\n", err)
 		}
 	}
-	pt.stop()
-	fmt.Println()
 
-	// Phase 2.25: Structured output validation (when --output-dir is set).
-	if outputDir != "" {
-		validationRetried := 0
-		for i := range results {
-			if strings.HasPrefix(results[i].Response, "error:") {
-				continue
-			}
-			ok, valErrs := validate.ValidateFileBlocks(results[i].Response)
-			if ok {
-				continue
-			}
-			validationRetried++
-			fmt.Printf("  ⚠ worker[%d] output validation failed: %s\n", i+1, strings.Join(valErrs, "; "))
-			// Retry once with validation feedback.
-			retryPrompt := fmt.Sprintf(
-				"Your previous output had format errors:\n%s\n\nOriginal subtask: %s\n\nPlease output corrected files using ===FILE: path=== ... ===ENDFILE=== format.",
-				strings.Join(valErrs, "\n"), results[i].Subtask,
-			)
-			retryReq := &provider.ChatRequest{
-				Model: results[i].Role,
-				Messages: []provider.Message{
-					{Role: provider.RoleSystem, Content: workerSystemPrompt},
-					{Role: provider.RoleUser, Content: retryPrompt},
-				},
-			}
-			resp, retryErr := router.ChatCompletion(ctx, retryReq)
-			if retryErr != nil {
-				fmt.Fprintf(os.Stderr, "  validation retry[%d] failed: %v\n", i+1, retryErr)
-				continue
+	// Token summary by role.
+	sum := tracker.Summary()
+	if sum.TotalTokens > 0 {
+		fmt.Printf("\n--- Token Usage ---\n")
+		for _, roleName := range []string{"mayor", "reviewer", "tester"} {
+			if rs, ok := sum.ByRole[roleName]; ok {
+				fmt.Printf("  %-12s %s tok\n", roleName+":", formatEstToks(rs.Tokens, rs.Estimated))
 			}
-			results[i].Response = resp.Message.Content
-			results[i].Tokens += resp.Usage.TotalTokens
-			fmt.Printf("  ✓ worker[%d] re-submitted after validation fix\n", i+1)
 		}
-		if validationRetried > 0 {
-			fmt.Println()
+		fmt.Printf("  %-12s %s tok\n", "total:", formatEstToks(sum.TotalTokens, sum.Estimated))
+		if sum.Estimated {
+			fmt.Printf("  (~ = estimated; provider reported no usage)\n")
 		}
+		fmt.Printf("-------------------\n")
 	}
 
-	// Phase 2.5: Reviewer + guardrail retries (optional).
-	if !noReviewer {
-		if _, ok := cfg.Roles["reviewer"]; ok {
-			fmt.Printf("Phase 2.5: Reviewer scoring worker outputs...\n")
-			pt.start("Phase 2.5 reviewer")
-			reviewer := role.NewReviewer(router, role.WithWitnessCostTracker(tracker))
-			for i := range results {
-				if strings.HasPrefix(results[i].Response, "error:") {
-					continue
-				}
-				score, note, scoreErr := reviewer.Score(ctx, results[i].Subtask, results[i].Response)
-				if scoreErr != nil {
-					fmt.Fprintf(os.Stderr, "  reviewer[%d]: %v\n", i+1, scoreErr)
-					continue
-				}
-				results[i].ReviewScore = score
-				results[i].ReviewNote = note
-				results[i].Flagged = score > 0 && score < guardrailThreshold
-
-				decLog.Log(decision.Decision{
-					Phase:     "review",
-					Agent:     "reviewer",
-					Intent:    fmt.Sprintf("score worker %d output", i+1),
-					Action:    fmt.Sprintf("scored %d/10", score),
-					Outcome:   map[bool]string{true: "flagged", false: "passed"}[results[i].Flagged],
-					Detail:    truncate(note, 120),
-					TokenCost: results[i].Tokens,
-				})
-
-				// Guardrail retry loop: re-dispatch workers scoring below threshold.
-				guardDoom := pool.NewDoomLoop()
-				guardDoom.Check(results[i].Response) // seed with original response
-				retryCount := 0
-				for results[i].Flagged && retryCount < guardrailRetries {
-					retryCount++
-					fmt.Printf("  [%d/%d] score=%d/10 ⚑ retrying (%d/%d): %s\n",
-						i+1, len(results), score, retryCount, guardrailRetries, truncate(note, 60))
-					retryPrompt := fmt.Sprintf(
-						"Your previous output scored %d/10. Reviewer feedback: %s\n\nOriginal subtask: %s\n\nPlease revise your output to address the reviewer's feedback.",
-						score, note, results[i].Subtask,
-					)
-					retryReq := &provider.ChatRequest{
-						Model: results[i].Role,
-						Messages: []provider.Message{
-							{Role: provider.RoleSystem, Content: workerSystemPrompt},
-							{Role: provider.RoleUser, Content: retryPrompt},
-						},
-					}
-					resp, retryErr := router.ChatCompletion(ctx, retryReq)
-					if retryErr != nil {
-						fmt.Fprintf(os.Stderr, "  guardrail retry[%d]: %v\n", i+1, retryErr)
-						break
-					}
-					results[i].Response = resp.Message.Content
-					results[i].Tokens += resp.Usage.TotalTokens
-
-					// Doom-loop detection: abort if worker produces identical output.
-					if guardDoom.Check(results[i].Response) {
-						fmt.Fprintf(os.Stderr, "  ⚠ worker[%d] doom loop: identical output after retry — aborting\n", i+1)
-						decLog.Log(decision.Decision{
-							Phase:   "guardrail",
-							Agent:   results[i].Role,
-							Intent:  "improve output via retry",
-							Action:  "doom loop detected — aborted",
-							Outcome: "failure",
-							Detail:  "identical response after feedback retry",
-						})
-						break
-					}
-
-					// Re-score the revised output.
-					score, note, scoreErr = reviewer.Score(ctx, results[i].Subtask, results[i].Response)
-					if scoreErr != nil {
-						fmt.Fprintf(os.Stderr, "  guardrail re-score[%d]: %v\n", i+1, scoreErr)
-						break
-					}
-					results[i].ReviewScore = score
-					results[i].ReviewNote = note
-					results[i].Flagged = score > 0 && score < guardrailThreshold
-
-					decLog.Log(decision.Decision{
-						Phase:   "guardrail",
-						Agent:   results[i].Role,
-						Intent:  "improve output via retry",
-						Action:  fmt.Sprintf("re-scored %d/10 after retry %d", score, retryCount),
-						Outcome: map[bool]string{true: "still-flagged", false: "improved"}[results[i].Flagged],
-						Detail:  truncate(note, 120),
-					})
-				}
-
-				flag := "✓"
-				if results[i].Flagged {
-					flag = "⚑"
-				}
-				fmt.Printf("  [%d/%d] score=%d/10 %s %s\n", i+1, len(results), results[i].ReviewScore, flag, truncate(results[i].ReviewNote, 80))
-			}
-			pt.stop()
-			fmt.Println()
-		} else {
-			fmt.Fprintf(os.Stderr, "  note: reviewer role not configured — skipping Phase 2.5\n")
-		}
-	}
+	return nil
+}
 
-	// Phase 3: Synthesize (unless --no-synthesize).
-	// Collect file→worker map during output writing (used by Phase 5).
-	fileWorkerMap := make(map[string]int)
-	if noSynthesize {
+// writeAndBuild is the pipeline's build step: it writes the worker files
+// and runs the optional Phase 5 build/fix loop and Phase 5.5 test/fix loop.
+func (pr *pooledRun) writeAndBuild(ctx context.Context, res *pipeline.Result, pass func(pipeline.BuildPass)) error {
+	results := res.Workers
+	if pr.noSynthesize {
 		for i, r := range results {
 			fmt.Printf("--- Worker %d (%s: subtask %d) ---\n", i+1, r.Role, i+1)
-			fmt.Println(r.Response)
-			files := parseMultiFileOutput(r.Response)
-			written := writeWorkerFiles(files, i, outputDir, runLogDir)
+			if r.Err != nil {
+				fmt.Printf("error: %v\n", r.Err)
+			} else {
+				fmt.Println(r.Response)
+			}
+			files := fileblock.Parse(r.Response)
+			written := writeWorkerFiles(files, i, pr.outputDir, pr.runLogDir, pr.writes)
 			for f := range written {
-				fileWorkerMap[f] = i
+				pr.history.Record(f, i)
+				pr.report.AddFiles(filepath.Join(pr.outputDir, f))
 			}
 		}
 		return nil
 	}
 
-	fmt.Printf("Phase 3: Supervisor synthesizing results...\n")
-	pt.start("Phase 3 synthesize")
-	stopSpin3 := startSpinner(spinLabelWithToks("  synthesizing", tracker))
-	synthesis, err := mayor.Synthesize(ctx, task, results)
-	stopSpin3()
-	if err != nil {
-		return fmt.Errorf("supervisor synthesize failed (during %s): %w", pt.currentPhase(), err)
-	}
-	pt.stop()
-
-	// Phase 4: Tester polish (optional — skipped if --no-tester or role not configured).
-	if !noTester {
-		if _, ok := cfg.Roles["tester"]; ok {
-			fmt.Printf("Phase 4: Tester polishing synthesized output...\n")
-			pt.start("Phase 4 tester")
-			stopSpin4 := startSpinner(spinLabelWithToks("  refining", tracker))
-			tester := role.NewTester(router, role.WithRefineryCostTracker(tracker))
-			refined, err := tester.Refine(ctx, synthesis)
-			stopSpin4()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "  tester failed: %v — using raw synthesis\n", err)
-			} else {
-				synthesis = refined.Message.Content
-				fmt.Printf("  Tester refined output (%d tokens)\n", refined.Usage.TotalTokens)
-			}
-			pt.stop()
-			fmt.Println()
-		} else {
-			fmt.Fprintf(os.Stderr, "  note: tester role not configured — skipping Phase 4\n")
-		}
-	}
-
-	fmt.Printf("\n--- Final Output ---\n")
-	fmt.Println(synthesis)
-	fmt.Printf("--------------------\n")
+	synthesis := res.Synthesis
+	pr.report.Synthesis = synthesis
+	pr.rl.Synthesis(synthesis)
 
-	// Write code files to output-dir; logs and synthesis to run log dir.
-	for i, r := range results {
-		files := parseMultiFileOutput(r.Response)
-		written := writeWorkerFiles(files, i, outputDir, runLogDir)
-		for f := range written {
-			fileWorkerMap[f] = i
+	// Write code files to output-dir; logs and synthesis to run log dir. A
+	// resumed run whose files are already in the same output dir leaves them
+	// as they are, keeping any build fixes made before it stopped.
+	if pr.resume != nil && len(pr.resume.Files) > 0 && pr.resume.OutputDir == pr.outputDir {
+		for f, i := range pr.resume.Files {
+			pr.history.Record(f, i)
+			pr.report.AddFiles(filepath.Join(pr.outputDir, f))
 		}
-	}
-	if err := writeOutputFile(runLogDir, "_synthesis.md", synthesis); err != nil {
-		fmt.Fprintf(os.Stderr, "  warning: could not write _synthesis.md: %v\n", err)
 	} else {
-		fmt.Printf("  → logged %s\n", filepath.Join(runLogDir, "_synthesis.md"))
+		for i, r := range results {
+			files := fileblock.Parse(r.Response)
+			written := writeWorkerFiles(files, i, pr.outputDir, pr.runLogDir, pr.writes)
+			for f := range written {
+				pr.history.Record(f, i)
+				pr.report.AddFiles(filepath.Join(pr.outputDir, f))
+			}
+		}
+	}
+	saveState(pr.state, pr.runLogDir)
+	if err := pr.rl.SaveSynthesis(pr.runLogDir, synthesis); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: %v\n", err)
 	}
 
 	// Phase 5: Iterative build/fix loop (optional).
-	if iterate && outputDir != "" {
-		runner := build.DetectRunner(outputDir)
+	if pr.iterate && pr.outputDir != "" {
+		language := pr.language
+		if language == "" {
+			language = build.InferLanguage(pr.task)
+		}
+		runner, scaffolded, err := build.DetectRunnerFor(pr.outputDir, language)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  warning: %v\n", err)
+		}
+		if scaffolded != "" {
+			fmt.Printf("  no build file found; scaffolded %s\n", scaffolded)
+		}
 		if runner == nil {
-			fmt.Fprintf(os.Stderr, "  note: no build system detected in %s — skipping Phase 5\n", outputDir)
+			fmt.Fprintf(os.Stderr, "  note: no build system detected in %s — skipping Phase 5\n", pr.outputDir)
 		} else {
-			fmt.Printf("Phase 5: Iterative build/fix loop (%s, max %d iterations)...\n", runner.Name(), maxIterations)
-			buildDoom := pool.NewDoomLoop()
-			buildOK := false
-			for iter := 1; iter <= maxIterations; iter++ {
-				fmt.Printf("  [iter %d/%d] building...\n", iter, maxIterations)
-				stdout, stderr, buildErr := runner.Run(ctx, outputDir)
-				_ = stdout
-
-				// Log full build output.
-				logContent := "=== stdout ===\n" + stdout + "\n=== stderr ===\n" + stderr
-				if err := writeOutputFile(runLogDir, fmt.Sprintf("_build_iter%d.log", iter), logContent); err != nil {
-					fmt.Fprintf(os.Stderr, "  warning: could not write build log: %v\n", err)
-				}
-
-				if buildErr == nil {
-					fmt.Printf("  ✓ Build succeeded on iteration %d\n", iter)
-					buildOK = true
-					break
-				}
-
-				fmt.Printf("  ✗ Build failed:\n")
-				fmt.Println(build.ErrorSummary(stderr, 20))
-
-				// Doom-loop detection: abort if identical errors repeat.
-				if buildDoom.Check(stderr) {
-					fmt.Fprintf(os.Stderr, "  ⚠ build doom loop: identical errors after fix — aborting\n")
-					decLog.Log(decision.Decision{
-						Phase:   "build-fix",
-						Agent:   "builder",
-						Intent:  "fix build errors",
-						Action:  "doom loop detected — aborted",
-						Outcome: "failure",
-						Detail:  "identical build errors after worker fix attempt",
-					})
-					break
-				}
-
-				if iter == maxIterations {
-					break
-				}
-
-				// Parse errors, attribute to workers, dispatch targeted fixes.
-				buildErrors := build.NormalizeErrorPaths(build.ParseBuildErrors(stderr), outputDir)
-				workerErrors := build.MapFilesToWorkers(buildErrors, fileWorkerMap)
-
-				if len(workerErrors) == 0 {
-					fmt.Fprintf(os.Stderr, "  could not attribute errors to workers — skipping fix dispatch\n")
-					break
-				}
-
-				fmt.Printf("  Dispatching fix subtasks to %d worker(s)...\n", len(workerErrors))
-				fixSubtasks := buildFixSubtasks(workerErrors, outputDir)
-
-				fixResults := wp.ExecuteAll(ctx, fixSubtasks, workerSystemPrompt)
-				for workerIdx, fixResult := range fixResults {
-					fixFiles := parseMultiFileOutput(fixResult.Response)
-					written := writeWorkerFiles(fixFiles, workerIdx, outputDir, runLogDir)
-					for f := range written {
-						fileWorkerMap[f] = workerIdx
+			// fixWith returns the FixLoop.Fix for a loop: it sends each
+			// worker the errors in its files and writes back the fixes.
+			fixWith := func(testFailures bool) func(context.Context, map[int][]build.BuildError) {
+				return func(ctx context.Context, workerErrors map[int][]build.BuildError) {
+					fmt.Printf("  Dispatching fix subtasks to %d worker(s)...\n", len(workerErrors))
+					fixSubtasks, fixWorkerIdx := buildFixSubtasks(workerErrors, pr.outputDir, testFailures)
+
+					// Fixes honor --fix-workers (default: the --workers cap) so the
+					// loop doesn't thrash a small pool.
+					if pr.fixWorkers > 0 {
+						res.Pool.SetMaxWorkers(pr.fixWorkers)
 					}
+					fixResults := res.Pool.ExecuteAll(ctx, fixSubtasks, res.WorkerSystemPrompt)
+					for i, fixResult := range fixResults {
+						workerIdx := fixWorkerIdx[i]
+						if fixResult.Err != nil {
+							// Send its files' next errors to their earlier writers.
+							pr.history.Retire(workerIdx)
+							continue
+						}
+						fixFiles := fileblock.Parse(fixResult.Response)
+						written := writeWorkerFiles(fixFiles, workerIdx, pr.outputDir, pr.runLogDir, pr.writes)
+						for f := range written {
+							pr.history.Record(f, workerIdx)
+							pr.report.AddFiles(filepath.Join(pr.outputDir, f))
+						}
+					}
+					saveState(pr.state, pr.runLogDir)
 				}
 			}
 
-			if !buildOK {
-				fmt.Printf("  ✗ Max iterations reached — build still failing\n")
+			pr.rl.Phase("iterate", "runner", runner.Name(), "max", strconv.Itoa(pr.maxIterations))
+			fmt.Printf("Phase 5: Iterative build/fix loop (%s, max %d iterations)...\n", runner.Name(), pr.maxIterations)
+			buildLoop := &build.FixLoop{
+				Step:          runner.Run,
+				Dir:           pr.outputDir,
+				MaxIterations: pr.maxIterations,
+				History:       pr.history,
+				Parse:         build.ParserFor(runner),
+				Fix:           fixWith(false),
+				Repeated:      pool.NewDoomLoop().Check,
+				Before: func(iter int) {
+					fmt.Printf("  [iter %d/%d] building...\n", iter, pr.maxIterations)
+				},
+				After: func(p build.Pass) {
+					logFixPass(pr.runLogDir, "_build_iter%d.log", p)
+					pr.events.BuildIter(p.Iteration, pr.maxIterations, p.Err == nil, len(p.Errors))
+					pass(pipeline.BuildPass{Step: "build", Iteration: p.Iteration, Max: pr.maxIterations, OK: p.Err == nil, Errors: len(p.Errors)})
+					if p.Err == nil {
+						fmt.Printf("  ✓ Build succeeded on iteration %d\n", p.Iteration)
+						return
+					}
+					fmt.Printf("  ✗ Build failed:\n")
+					fmt.Println(build.ErrorSummary(p.Stderr, 20))
+				},
 			}
+			buildOutcome, _ := buildLoop.Run(ctx)
+			reportFixLoop(buildOutcome, "build", pr.decLog)
 			fmt.Println()
+
+			// Phase 5.5: Test/fix loop (optional, after a successful build).
+			switch {
+			case !pr.runTests:
+			case buildOutcome != build.Passed:
+				fmt.Fprintf(os.Stderr, "  note: build still failing — skipping Phase 5.5 tests\n")
+			default:
+				pr.rl.Phase("run-tests", "runner", runner.Name(), "max", strconv.Itoa(pr.maxTestIterations))
+				fmt.Printf("Phase 5.5: Test/fix loop (%s, max %d iterations)...\n", runner.Name(), pr.maxTestIterations)
+				testLoop := &build.FixLoop{
+					Step:          runner.Test,
+					Dir:           pr.outputDir,
+					MaxIterations: pr.maxTestIterations,
+					History:       pr.history,
+					Parse:         build.ParserFor(runner),
+					Fix:           fixWith(true),
+					Repeated:      pool.NewDoomLoop().Check,
+					Before: func(iter int) {
+						fmt.Printf("  [iter %d/%d] testing...\n", iter, pr.maxTestIterations)
+					},
+					After: func(p build.Pass) {
+						logFixPass(pr.runLogDir, "_test_iter%d.log", p)
+						pr.events.TestIter(p.Iteration, pr.maxTestIterations, p.Err == nil, len(p.Errors))
+						pass(pipeline.BuildPass{Step: "test", Iteration: p.Iteration, Max: pr.maxTestIterations, OK: p.Err == nil, Errors: len(p.Errors)})
+						if p.Err == nil {
+							fmt.Printf("  ✓ Tests passed on iteration %d\n", p.Iteration)
+							return
+						}
+						fmt.Printf("  ✗ Tests failed:\n")
+						fmt.Println(build.ErrorSummary(p.Stderr, 20))
+					},
+				}
+				testOutcome, _ := testLoop.Run(ctx)
+				if testOutcome == build.Unsupported {
+					fmt.Fprintf(os.Stderr, "  note: no test command for %s — skipping Phase 5.5\n", runner.Name())
+				}
+				reportFixLoop(testOutcome, "tests", pr.decLog)
+				fmt.Println()
+			}
 		}
 	}
+	return nil
+}
 
-	// Phase timing summary.
-	fmt.Printf("\n--- Phase Timing ---\n")
-	fmt.Print(pt.summary())
-	fmt.Printf("--------------------\n")
+// printInterrupted tells the user what an interrupted run left behind: the
+// tokens it used and the files it finished writing. Files are written
+// atomically, so none is half-written.
+func printInterrupted(report *runreport.Report) {
+	if sum := report.Cost; sum != nil && sum.TotalTokens > 0 {
+		fmt.Fprintf(os.Stderr, "  used %s tok ($%.4f) before stopping\n", formatEstToks(sum.TotalTokens, sum.Estimated), sum.TotalCost)
+	}
+	if len(report.Files) == 0 {
+		fmt.Fprintf(os.Stderr, "  no files were written\n")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  %d file(s) written before stopping:\n", len(report.Files))
+	for _, f := range report.Files {
+		fmt.Fprintf(os.Stderr, "    %s\n", f)
+	}
+}
 
-	// Token summary by role.
-	sum := tracker.Summary()
-	if sum.TotalTokens > 0 {
-		fmt.Printf("\n--- Token Usage ---\n")
-		for _, roleName := range []string{"mayor", "reviewer", "tester"} {
-			if rs, ok := sum.ByRole[roleName]; ok {
-				fmt.Printf("  %-12s %s tok\n", roleName+":", formatToks(rs.Tokens))
-			}
-		}
-		fmt.Printf("  %-12s %s tok\n", "total:", formatToks(sum.TotalTokens))
-		fmt.Printf("-------------------\n")
+// saveState checkpoints a pooled run for --resume. A failed write only warns:
+// the run itself can still finish.
+func saveState(s *runstate.State, dir string) {
+	if err := s.Save(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: %v\n", err)
 	}
+}
 
-	return nil
+// logFixPass writes one pass of a build or test loop to the run log
+// directory; nameFormat takes the iteration number.
+func logFixPass(runLogDir, nameFormat string, p build.Pass) {
+	name := fmt.Sprintf(nameFormat, p.Iteration)
+	logContent := "=== stdout ===\n" + p.Stdout + "\n=== stderr ===\n" + p.Stderr
+	if err := writeOutputFile(runLogDir, name, logContent); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: could not write %s: %v\n", name, err)
+	}
+}
+
+// reportFixLoop prints how a build or test loop ended when it did not pass,
+// recording doom loops as decisions. what is "build" or "tests".
+func reportFixLoop(outcome build.Outcome, what string, decLog *decision.Logger) {
+	switch outcome {
+	case build.Passed, build.Unsupported:
+		return
+	case build.Stuck:
+		fmt.Fprintf(os.Stderr, "  ⚠ %s doom loop: identical errors after fix — aborting\n", what)
+		phase, intent := "build-fix", "fix build errors"
+		if what == "tests" {
+			phase, intent = "test-fix", "fix failing tests"
+		}
+		decLog.Log(decision.Decision{
+			Phase:   phase,
+			Agent:   "builder",
+			Intent:  intent,
+			Action:  "doom loop detected — aborted",
+			Outcome: "failure",
+			Detail:  "identical " + what + " errors after worker fix attempt",
+		})
+	case build.Unattributed:
+		fmt.Fprintf(os.Stderr, "  could not attribute errors to workers — skipping fix dispatch\n")
+	case build.Exhausted:
+		fmt.Printf("  ✗ Max iterations reached — %s still failing\n", what)
+		return
+	}
+	fmt.Printf("  ✗ %s still failing\n", what)
 }
 
 // cmdRunSingle implements the legacy single-worker streaming flow.
-func cmdRunSingle(ctx context.Context, router *provider.Router, task, supervisorRole, workerRole, outputDir, runLogDir string) error {
+func cmdRunSingle(ctx context.Context, router *provider.Router, cfg *provider.Config, task, supervisorRole, workerRole, outputDir string, writes writeMode, runLogDir string, rl *runlog.Logger, report *runreport.Report, events *runevent.Emitter) error {
+	tracker := cost.NewTracker(cost.DefaultPricing())
+	defer func() {
+		report.Cost = tracker.Summary()
+		events.CostSummary(report.Cost)
+		if err := tracker.WriteFile(filepath.Join(runLogDir, cost.FileName)); err != nil {
+			fmt.Fprintf(os.Stderr, "  warning: could not write %s: %v\n", cost.FileName, err)
+		}
+	}()
+
 	// Phase 1: Supervisor generates subtask via ChatCompletion.
+	rl.Phase("decompose", "role", supervisorRole)
 	fmt.Printf("Phase 1: Supervisor (%s) analyzing task...\n", supervisorRole)
 
 	supervisorReq := &provider.ChatRequest{
@@ -858,18 +1088,27 @@ func cmdRunSingle(ctx context.Context, router *provider.Router, task, supervisor
 		return fmt.Errorf("supervisor request failed: %w", err)
 	}
 
+	tracker.Record(roleProvider(router, supervisorRole, supervisorResp.Model), supervisorResp.Model, supervisorRole, costUsage(supervisorResp.Usage))
+
 	subtask := strings.TrimSpace(supervisorResp.Message.Content)
+	report.Subtasks = []string{subtask}
+	events.Subtasks(report.Subtasks)
 	fmt.Printf("  model=%s (%d tokens)\n", supervisorResp.Model, supervisorResp.Usage.TotalTokens)
-	fmt.Printf("  Subtask: %s\n\n", truncate(subtask, 120))
+	fmt.Printf("  Subtask: %s\n\n", textutil.Truncate(subtask, 120))
 
 	// Phase 2: Worker executes subtask via StreamChatCompletion.
+	rl.Phase("execute", "mode", "single", "role", workerRole)
 	fmt.Printf("Phase 2: Worker (%s) executing subtask (streaming)...\n", workerRole)
 
+	workerSystem, err := pipeline.WorkerPrompt(cfg, provider.PromptData{Task: task, OutputDir: outputDir, Subtask: subtask, SubtaskCount: 1})
+	if err != nil {
+		return err
+	}
 	workerReq := &provider.ChatRequest{
 		Messages: []provider.Message{
 			{
 				Role:    provider.RoleSystem,
-				Content: workerPrompt(outputDir),
+				Content: workerSystem,
 			},
 			{
 				Role:    provider.RoleUser,
@@ -878,13 +1117,45 @@ func cmdRunSingle(ctx context.Context, router *provider.Router, task, supervisor
 		},
 	}
 
-	stream, err := router.StreamChatCompletionForRole(ctx, workerRole, workerReq)
+	// Long local generations survive a dropped connection by resuming from
+	// the text received so far.
+	openWorker := func(ctx context.Context, req *provider.ChatRequest) (provider.ChatStream, error) {
+		return router.StreamChatCompletionForRole(ctx, workerRole, req)
+	}
+	stream, err := provider.NewResumingStream(ctx, openWorker, workerReq, provider.DefaultMaxResumes)
 	if err != nil {
 		return fmt.Errorf("worker stream request failed: %w", err)
 	}
+	stream.OnResume = func(attempt int, err error) {
+		fmt.Fprintf(os.Stderr, "\n  ⚠ worker stream dropped (%v) — resuming (%d/%d)\n", err, attempt, provider.DefaultMaxResumes)
+	}
 	defer stream.Close()
 
+	// Record the worker's usage however the stream ends, including Ctrl-C.
+	// A cancelled stream never delivers its final usage chunk, so Stats
+	// fills in what it can.
+	var workerModel string
 	var totalContent strings.Builder
+	workerStart := time.Now()
+	defer func() {
+		st := stream.Stats()
+		tracker.Record(roleProvider(router, workerRole, workerModel), workerModel, workerRole, costUsage(st.Usage))
+		result := role.WorkerResult{
+			Role:      workerRole,
+			Subtask:   subtask,
+			Response:  totalContent.String(),
+			Tokens:    st.Usage.TotalTokens,
+			TokensEst: st.Usage.Estimated,
+			Elapsed:   time.Since(workerStart),
+		}
+		report.SetWorkers([]role.WorkerResult{result})
+		events.WorkerUpdate(0, result)
+		if !st.Complete {
+			fmt.Fprintf(os.Stderr, "\n  worker stream stopped early; recorded %s tokens of partial usage\n",
+				formatEstToks(st.Usage.TotalTokens, st.Usage.Estimated))
+		}
+	}()
+
 	firstChunk := true
 	for {
 		chunk, err := stream.Next()
@@ -895,6 +1166,9 @@ func cmdRunSingle(ctx context.Context, router *provider.Router, task, supervisor
 			return fmt.Errorf("worker stream error: %w", err)
 		}
 
+		if workerModel == "" {
+			workerModel = chunk.Model
+		}
 		if firstChunk && chunk.Model != "" {
 			fmt.Printf("  model=%s\n\n", chunk.Model)
 			fmt.Printf("--- Worker Output ---\n")
@@ -919,8 +1193,10 @@ func cmdRunSingle(ctx context.Context, router *provider.Router, task, supervisor
 	}
 
 	// Write output: named files → output-dir; unnamed → log dir.
-	files := parseMultiFileOutput(totalContent.String())
-	writeWorkerFiles(files, 0, outputDir, runLogDir)
+	files := fileblock.Parse(totalContent.String())
+	for f := range writeWorkerFiles(files, 0, outputDir, runLogDir, writes) {
+		report.AddFiles(filepath.Join(outputDir, f))
+	}
 
 	// Usage summary.
 	fmt.Printf("\nDone: supervisor→worker round-trip complete\n")
@@ -932,6 +1208,10 @@ func cmdRunSingle(ctx context.Context, router *provider.Router, task, supervisor
 func cmdModels(args []string) error {
 	fs := flag.NewFlagSet("models", flag.ExitOnError)
 	configPath := fs.String("config", "", "path to config file (default: ./electrictown.yaml, then $HOME/electrictown.yaml)")
+	refresh := fs.Bool("refresh", false, "ask every provider again instead of using cached model lists")
+	cacheTTL := fs.Duration("cache-ttl", 5*time.Minute, "how long a provider's model list is reused (0 disables the cache)")
+	verbose := fs.Bool("verbose", false, "show each model's context length and capabilities (asks Ollama about every model)")
+	debug := fs.Bool("debug", false, "log provider activity to stderr")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -946,7 +1226,19 @@ func cmdModels(args []string) error {
 		return fmt.Errorf("loading config: %w", err)
 	}
 
-	router, err := provider.NewRouter(cfg, buildFactories())
+	// Model lists are cached in log_dir so repeated calls skip slow
+	// providers.
+	opts := []provider.RouterOption{provider.WithModelCache(*cacheTTL)}
+	if logDir, err := cfg.ResolveLogDir(); err == nil {
+		opts = append(opts, provider.WithModelCacheFile(filepath.Join(logDir, "_models_cache.json")))
+	}
+	if *verbose {
+		opts = append(opts, provider.WithModelDetails())
+	}
+	if *debug {
+		opts = append(opts, provider.WithLogger(debugLogger()))
+	}
+	router, err := provider.NewRouter(cfg, buildFactories(), opts...)
 	if err != nil {
 		return fmt.Errorf("creating router: %w", err)
 	}
@@ -954,23 +1246,70 @@ func cmdModels(args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	models, err := router.ListAllModels(ctx)
-	if err != nil {
+	list := router.ListAllModels
+	if *refresh {
+		list = router.RefreshModels
+	}
+	models, err := list(ctx)
+	// Providers that failed are reported as warnings as long as one listed.
+	if err := writeModels(os.Stdout, os.Stderr, models, *verbose, err); err != nil {
 		return fmt.Errorf("listing models: %w", err)
 	}
+	return nil
+}
 
-	if len(models) == 0 {
-		fmt.Println("No models available.")
-		return nil
+// writeModels prints the result of ListAllModels: a table of models on out,
+// with context length and capability columns when verbose, and, when some
+// providers failed, a warning section on warn naming each with its
+// friendlyError hint. It returns err unless it is a *provider.ModelListError
+// from a listing in which at least one provider succeeded.
+func writeModels(out, warn io.Writer, models []provider.Model, verbose bool, err error) error {
+	var listErr *provider.ModelListError
+	if !errors.As(err, &listErr) && err != nil {
+		return err
+	}
+	if listErr != nil && listErr.Listed == 0 {
+		return err
 	}
 
-	// Print formatted table.
-	fmt.Printf("%-15s %s\n", "PROVIDER", "MODEL ID")
-	fmt.Printf("%-15s %s\n", "--------", "--------")
-	for _, m := range models {
-		fmt.Printf("%-15s %s\n", m.Provider, m.ID)
+	if len(models) == 0 {
+		fmt.Fprintln(out, "No models available.")
+	} else if verbose {
+		width := len("MODEL ID")
+		for _, m := range models {
+			width = max(width, len(m.ID))
+		}
+		fmt.Fprintf(out, "%-15s %-*s %-8s %s\n", "PROVIDER", width, "MODEL ID", "CONTEXT", "CAPABILITIES")
+		fmt.Fprintf(out, "%-15s %-*s %-8s %s\n", "--------", width, "--------", "-------", "------------")
+		for _, m := range models {
+			ctxLen, caps := "-", "-"
+			if m.ContextLength > 0 {
+				ctxLen = strconv.Itoa(m.ContextLength)
+			}
+			if len(m.Capabilities) > 0 {
+				caps = strings.Join(m.Capabilities, ",")
+			}
+			fmt.Fprintf(out, "%-15s %-*s %-8s %s\n", m.Provider, width, m.ID, ctxLen, caps)
+		}
+	} else {
+		fmt.Fprintf(out, "%-15s %s\n", "PROVIDER", "MODEL ID")
+		fmt.Fprintf(out, "%-15s %s\n", "--------", "--------")
+		for _, m := range models {
+			fmt.Fprintf(out, "%-15s %s\n", m.Provider, m.ID)
+		}
 	}
 
+	if listErr != nil {
+		names := make([]string, 0, len(listErr.Errs))
+		for name := range listErr.Errs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(warn, "\nWarning: %d provider(s) could not list models:\n", len(names))
+		for _, name := range names {
+			fmt.Fprintf(warn, "  %s: %s\n", name, friendlyError(listErr.Errs[name]))
+		}
+	}
 	return nil
 }
 
@@ -986,6 +1325,8 @@ func friendlyError(err error) string {
 		return msg + "\n  hint: the request timed out — increase --timeout or use --no-reviewer/--no-tester to skip slow phases"
 	case strings.Contains(msg, "x-api-key") || strings.Contains(msg, "authentication") || strings.Contains(msg, "Unauthorized") || strings.Contains(msg, "unauthorized"):
 		return msg + "\n  hint: check that your API key environment variable is exported in your shell"
+	case strings.Contains(msg, "unsupported feature"):
+		return msg + "\n  hint: the routed model cannot honour part of the request — point the role at a model that supports it in your config"
 	case strings.Contains(msg, "permission denied"):
 		return msg + "\n  hint: check file/directory ownership and permissions"
 	case strings.Contains(msg, "read-only file system"):
@@ -1021,109 +1362,45 @@ func findConfig(explicit string) (string, error) {
 	return "", fmt.Errorf("no config file found; tried ./%s and %s — use --config to specify a path", name, p)
 }
 
-func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen-3] + "..."
+// writeOutputFile writes content to path/filename atomically (temp + rename).
+func writeOutputFile(dir, filename, content string) error {
+	fullPath := filepath.Join(dir, filename)
+	return fileutil.AtomicWrite(fullPath, []byte(content), 0644)
 }
 
-// FileOutput holds a single parsed file from a worker response.
-type FileOutput struct {
-	Name    string // relative path; empty means unnamed (goes to log dir)
-	Content string
+// writeMode is how writeWorkerFiles treats --output-dir.
+type writeMode struct {
+	dryRun  bool // --no-write: list the files instead of writing them
+	diff    bool // --diff: print a unified diff for each changed file
+	confirm bool // --confirm: ask before writing each new or changed file
 }
 
-// workerPrompt returns the system prompt for workers.
-// When outputDir is set, instructs multi-file output with ===FILE: === delimiters.
-func workerPrompt(outputDir string) string {
-	base := "You are a coding worker. Implement exactly what is asked."
-	if outputDir != "" {
-		return base + `
-
-Output all required source files using this exact format — one block per file:
-
-===FILE: relative/path/to/file.go===
-<complete file content here>
-===ENDFILE===
+// stdinLines reads answers to confirmWrite prompts.
+var stdinLines = bufio.NewReader(os.Stdin)
 
-Rules:
-- Output ONLY file content — no explanations, no commentary.
-- Each file must be complete and standalone (proper package declaration, all imports).
-- Use relative paths from the project root.
-- You may output as many files as the subtask requires.`
+// confirmWrite asks on stderr whether to write path and reads a y/N answer
+// from stdin. Anything but "y" or "yes", including end of input, declines.
+func confirmWrite(path string) bool {
+	fmt.Fprintf(os.Stderr, "  write %s? [y/N] ", path)
+	answer, _ := stdinLines.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
 	}
-	return base + " Output ONLY the code — no explanations, no markdown fences unless specifically requested."
+	return false
 }
 
-// parseMultiFileOutput parses worker response into a slice of FileOutput.
-// Handles three formats (in priority order):
-//  1. Multi-file: ===FILE: path=== ... ===ENDFILE===
-//  2. Single-file legacy: FILENAME: path\n<content>
-//  3. Unnamed fallback: entire response as unnamed content
-func parseMultiFileOutput(response string) []FileOutput {
-	// Try multi-file format first.
-	if strings.Contains(response, "===FILE:") {
-		return parseMultiFileBlocks(response)
-	}
-
-	// Try legacy single-file FILENAME: header.
-	const prefix = "FILENAME: "
-	idx := strings.Index(response, "\n")
-	if idx >= 0 {
-		firstLine := strings.TrimSpace(response[:idx])
-		if strings.HasPrefix(firstLine, prefix) {
-			name := strings.TrimPrefix(firstLine, prefix)
-			name = strings.TrimPrefix(strings.TrimSpace(name), "/")
-			return []FileOutput{{Name: name, Content: response[idx+1:]}}
-		}
-	}
-
-	// Unnamed fallback.
-	return []FileOutput{{Name: "", Content: response}}
-}
-
-// fileBlockPattern matches ===FILE: path=== ... ===ENDFILE=== blocks.
-var fileBlockPattern = regexp.MustCompile(`(?s)===FILE:\s*([^\n=]+?)===\s*\n(.*?)(?:===ENDFILE===|(?:===FILE:))`)
-
-func parseMultiFileBlocks(response string) []FileOutput {
-	// Append a sentinel so the last block is caught by the non-lookahead pattern.
-	text := response + "\n===FILE: __sentinel__==="
-	matches := fileBlockPattern.FindAllStringSubmatch(text, -1)
-	if len(matches) == 0 {
-		return []FileOutput{{Name: "", Content: response}}
-	}
-	files := make([]FileOutput, 0, len(matches))
-	for _, m := range matches {
-		name := strings.TrimSpace(m[1])
-		if name == "__sentinel__" {
-			continue
-		}
-		name = strings.TrimPrefix(name, "/")
-		content := strings.TrimLeft(m[2], "\n")
-		// Strip trailing ===ENDFILE=== if present.
-		content = strings.TrimSuffix(strings.TrimRight(content, "\n\r\t "), "===ENDFILE===")
-		content = strings.TrimRight(content, "\n\r\t ")
-		if name != "" {
-			files = append(files, FileOutput{Name: name, Content: content})
-		}
-	}
-	if len(files) == 0 {
-		return []FileOutput{{Name: "", Content: response}}
-	}
-	return files
-}
-
-// writeOutputFile writes content to path/filename atomically (temp + rename).
-func writeOutputFile(dir, filename, content string) error {
-	fullPath := filepath.Join(dir, filename)
-	return fileutil.AtomicWrite(fullPath, []byte(content), 0644)
-}
+// spinnersOff disables startSpinner; --json sets it so stderr carries only
+// warnings.
+var spinnersOff bool
 
 // startSpinner launches an animated spinner on stderr. labelFn is called on
 // each tick to get the current label (allowing live cost/token updates).
 // Returns a stop function that stops the spinner and clears the line.
 func startSpinner(labelFn func() string) func() {
+	if spinnersOff {
+		return func() {}
+	}
 	frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 	stop := make(chan struct{})
 	var wg sync.WaitGroup
@@ -1148,6 +1425,38 @@ func startSpinner(labelFn func() string) func() {
 	}
 }
 
+// silenceStdout points os.Stdout at the null device, dropping the human
+// output of a --json run, and returns a function that restores it.
+func silenceStdout() (restore func(), err error) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", os.DevNull, err)
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	return func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}, nil
+}
+
+// parseReviewerPanel splits the --reviewer-panel value into model aliases,
+// checking each against the config. An empty value means no panel.
+func parseReviewerPanel(value string, cfg *provider.Config) ([]string, error) {
+	var aliases []string
+	for _, a := range strings.Split(value, ",") {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		if _, ok := cfg.Models[a]; !ok {
+			return nil, fmt.Errorf("--reviewer-panel: unknown model alias %q", a)
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, nil
+}
+
 // spinLabel returns a static label function for startSpinner.
 func spinLabel(s string) func() string { return func() string { return s } }
 
@@ -1156,11 +1465,11 @@ func spinLabelWithToks(base string, tracker *cost.Tracker) func() string {
 	start := time.Now()
 	return func() string {
 		elapsed := time.Since(start).Seconds()
-		total := tracker.Summary().TotalTokens
-		if total == 0 {
+		sum := tracker.Summary()
+		if sum.TotalTokens == 0 {
 			return fmt.Sprintf("%s [%.0fs]", base, elapsed)
 		}
-		return fmt.Sprintf("%s [%s tok, %.0fs]", base, formatToks(total), elapsed)
+		return fmt.Sprintf("%s [%s tok, %.0fs]", base, formatEstToks(sum.TotalTokens, sum.Estimated), elapsed)
 	}
 }
 
@@ -1172,6 +1481,44 @@ func formatToks(n int) string {
 	return fmt.Sprintf("%d", n)
 }
 
+// roleProvider returns the provider that served model for role: the one
+// whose primary or fallback model matches, else the primary's provider.
+func roleProvider(router *provider.Router, role, model string) string {
+	prov, primary, fallbacks, err := router.ModelFor(role)
+	if err != nil {
+		return ""
+	}
+	if model != primary {
+		for _, fb := range fallbacks {
+			if fb.Model == model {
+				return fb.Provider
+			}
+		}
+	}
+	return prov
+}
+
+// costUsage converts provider-reported usage for the cost tracker.
+func costUsage(u provider.Usage) cost.Usage {
+	return cost.Usage{
+		PromptTokens:        u.PromptTokens,
+		CompletionTokens:    u.CompletionTokens,
+		TotalTokens:         u.TotalTokens,
+		CacheCreationTokens: u.CacheCreationTokens,
+		CacheReadTokens:     u.CacheReadTokens,
+		ReasoningTokens:     u.ReasoningTokens,
+		Estimated:           u.Estimated,
+	}
+}
+
+// formatEstToks is formatToks with a "~" prefix when the count was estimated.
+func formatEstToks(n int, estimated bool) string {
+	if estimated {
+		return "~" + formatToks(n)
+	}
+	return formatToks(n)
+}
+
 // liveProgress renders per-worker status lines in-place using ANSI cursor moves.
 type liveProgress struct {
 	mu      sync.Mutex
@@ -1210,40 +1557,37 @@ func (lp *liveProgress) update(idx int, line string) {
 
 // writeWorkerFiles writes parsed file outputs from a single worker.
 // Named files go to outputDir (when set); unnamed fallback goes to logDir as workerN.out.
+// Names that are absolute or would escape outputDir are skipped with a warning.
 // Returns a map of written named file paths (relative) to confirm what was written.
-func writeWorkerFiles(files []FileOutput, workerIdx int, outputDir, logDir string) map[string]struct{} {
-	written := make(map[string]struct{})
-	for _, f := range files {
-		if f.Name != "" && outputDir != "" {
-			if err := writeOutputFile(outputDir, f.Name, f.Content); err != nil {
-				fmt.Fprintf(os.Stderr, "  warning: could not write %s: %v\n", f.Name, err)
-			} else {
-				fmt.Printf("  → wrote %s\n", filepath.Join(outputDir, f.Name))
-				written[f.Name] = struct{}{}
-			}
-		}
-	}
-	// If no named files were written (or outputDir unset), log the raw response.
-	if len(written) == 0 {
-		logFile := fmt.Sprintf("worker-%d.out", workerIdx+1)
-		raw := files[0].Content
-		if err := writeOutputFile(logDir, logFile, raw); err != nil {
-			fmt.Fprintf(os.Stderr, "  warning: could not write log %s: %v\n", logFile, err)
-		} else {
-			fmt.Printf("  → logged %s\n", filepath.Join(logDir, logFile))
-		}
-	}
-	return written
+// With --no-write, files are listed with their sizes instead and none are returned.
+// With --diff, changes to existing files are printed as unified diffs first; with
+// --confirm, each new or changed file is written only if the user agrees.
+func writeWorkerFiles(files []fileblock.File, workerIdx int, outputDir, logDir string, mode writeMode) map[string]struct{} {
+	w := &fileblock.Writer{Dir: outputDir, LogDir: logDir, DryRun: mode.dryRun, Diff: mode.diff, Out: os.Stdout, Warn: os.Stderr}
+	if mode.confirm {
+		w.Confirm = confirmWrite
+	}
+	return w.Write(files, workerIdx)
 }
 
-// buildFixSubtasks builds targeted fix subtask prompts for workers with build errors.
-// Each prompt includes the failing file's current content and the attributed errors.
-func buildFixSubtasks(workerErrors map[int][]build.BuildError, outputDir string) []string {
-	subtasks := make([]string, 0, len(workerErrors))
-	for _, errs := range workerErrors {
+// buildFixSubtasks builds targeted fix subtask prompts for workers with build
+// errors, or with failing tests when testFailures is set. Each prompt includes
+// the failing file's current content and the attributed errors. workerIdx[i]
+// is the worker subtasks[i] is for, in ascending order.
+func buildFixSubtasks(workerErrors map[int][]build.BuildError, outputDir string, testFailures bool) (subtasks []string, workerIdx []int) {
+	header, label := "Your previous output had build errors.", "Build error"
+	if testFailures {
+		header, label = "Your previous output builds, but its tests fail.", "Test failure"
+	}
+	for idx := range workerErrors {
+		workerIdx = append(workerIdx, idx)
+	}
+	sort.Ints(workerIdx)
+	subtasks = make([]string, 0, len(workerErrors))
+	for _, idx := range workerIdx {
 		var sb strings.Builder
-		sb.WriteString("Your previous output had build errors. Fix ONLY the files listed below.\n\n")
-		for _, e := range errs {
+		sb.WriteString(header + " Fix ONLY the files listed below.\n\n")
+		for _, e := range workerErrors[idx] {
 			sb.WriteString(fmt.Sprintf("File: %s\n", e.File))
 			content, readErr := os.ReadFile(filepath.Join(outputDir, e.File))
 			if readErr == nil {
@@ -1251,70 +1595,119 @@ func buildFixSubtasks(workerErrors map[int][]build.BuildError, outputDir string)
 				sb.Write(content)
 				sb.WriteString("\n```\n")
 			}
-			sb.WriteString(fmt.Sprintf("Build error (line %d): %s\n\n", e.Line, e.Message))
+			sb.WriteString(fmt.Sprintf("%s (line %d): %s\n\n", label, e.Line, e.Message))
 		}
 		sb.WriteString("Output the corrected file(s) using ===FILE: path=== ... ===ENDFILE=== format.")
 		subtasks = append(subtasks, sb.String())
 	}
-	return subtasks
+	return subtasks, workerIdx
+}
+
+// phaseSummary formats the timed phases of a run as a table, with the run's
+// total time.
+func phaseSummary(phases []pipeline.PhaseTime, total time.Duration) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-20s %s\n", "PHASE", "ELAPSED"))
+	sb.WriteString(fmt.Sprintf("%-20s %s\n", "-----", "-------"))
+	for _, p := range phases {
+		sb.WriteString(fmt.Sprintf("%-20s %.1fs\n", p.Name, p.Elapsed.Seconds()))
+	}
+	sb.WriteString(fmt.Sprintf("%-20s %.1fs\n", "TOTAL", total.Seconds()))
+	return sb.String()
+}
+
+// throughputSummary formats the run's completion tokens per second of wall
+// time, its workers' latency percentiles, and its slowest phase.
+func throughputSummary(sum *cost.Summary, res *pipeline.Result, total time.Duration) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  %-14s %.0f tok/s (%s completion tok in %.1fs)\n", "throughput:",
+		pipeline.Throughput(sum.TotalCompletionTokens, total), formatEstToks(sum.TotalCompletionTokens, sum.Estimated), total.Seconds()))
+	if wl := res.WorkerLatency(); wl.Workers > 0 {
+		sb.WriteString(fmt.Sprintf("  %-14s p50 %.1fs, p95 %.1fs, max %.1fs (%d workers)\n", "worker time:",
+			wl.P50.Seconds(), wl.P95.Seconds(), wl.Max.Seconds(), wl.Workers))
+	}
+	var slowest pipeline.PhaseTime
+	for _, p := range res.Phases {
+		if p.Elapsed > slowest.Elapsed {
+			slowest = p
+		}
+	}
+	if slowest.Name != "" && total > 0 {
+		sb.WriteString(fmt.Sprintf("  %-14s %s, %.1fs (%.0f%% of the run)\n", "slowest phase:",
+			slowest.Name, slowest.Elapsed.Seconds(), 100*slowest.Elapsed.Seconds()/total.Seconds()))
+	}
+	return sb.String()
 }
 
-// phaseTracker tracks elapsed time per phase and cumulative run time.
-type phaseTracker struct {
-	runStart   time.Time
-	phaseStart time.Time
-	phaseName  string
-	phases     []phaseRecord
-	mu         sync.Mutex
+// workerLine formats worker idx's live progress line.
+func workerLine(idx, n int, r role.WorkerResult) string {
+	status := "✓"
+	if errors.Is(r.Err, pool.ErrSubtaskTimeout) {
+		status = "⏱ timeout"
+	} else if r.Err != nil {
+		status = "✗"
+	}
+	toks := formatEstToks(r.Tokens, r.TokensEst) + " tok"
+	tps := ""
+	if r.Elapsed > 0 && r.Tokens > 0 {
+		tps = fmt.Sprintf(", %.0f tok/s", float64(r.Tokens)/r.Elapsed.Seconds())
+	}
+	return fmt.Sprintf("  [%d/%d] %-18s %s (%s%s, %.1fs)",
+		idx+1, n, textutil.Truncate(r.Role, 18), status, toks, tps, r.Elapsed.Seconds())
 }
 
-type phaseRecord struct {
-	name    string
-	elapsed time.Duration
+// reliabilitySummary formats per-model worker outcomes as a table.
+func reliabilitySummary(rel []pool.ModelReliability) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-20s %5s %5s %5s %5s %5s %5s %8s\n", "MODEL", "TRIES", "OK", "RETRY", "T/O", "EMPTY", "FAIL", "AVG"))
+	for _, r := range rel {
+		sb.WriteString(fmt.Sprintf("%-20s %5d %5d %5d %5d %5d %5d %7.1fs\n",
+			textutil.Truncate(r.Alias, 20), r.Attempts, r.Successes, r.Retryable, r.Timeouts, r.Empty, r.Failures, r.AvgLatency().Seconds()))
+	}
+	return sb.String()
 }
 
-func newPhaseTracker() *phaseTracker {
-	now := time.Now()
-	return &phaseTracker{runStart: now}
+// debugLogger returns the --debug logger: every router event, as
+// key=value lines on stderr.
+func debugLogger() provider.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
 }
 
-func (pt *phaseTracker) start(name string) {
-	pt.mu.Lock()
-	defer pt.mu.Unlock()
-	pt.phaseName = name
-	pt.phaseStart = time.Now()
+// fallbackTrace collects router fallback events for --trace-fallbacks.
+type fallbackTrace struct {
+	mu     sync.Mutex
+	events []provider.FallbackEvent
 }
 
-func (pt *phaseTracker) stop() time.Duration {
-	pt.mu.Lock()
-	defer pt.mu.Unlock()
-	elapsed := time.Since(pt.phaseStart)
-	if pt.phaseName != "" {
-		pt.phases = append(pt.phases, phaseRecord{name: pt.phaseName, elapsed: elapsed})
-	}
-	cumulative := time.Since(pt.runStart)
-	fmt.Printf("  done (%.1fs, cumulative %.1fs)\n", elapsed.Seconds(), cumulative.Seconds())
-	return elapsed
+// record logs a fallback as it happens and keeps it for the summary.
+func (ft *fallbackTrace) record(ev provider.FallbackEvent) {
+	ft.mu.Lock()
+	ft.events = append(ft.events, ev)
+	ft.mu.Unlock()
+	fmt.Fprintf(os.Stderr, "  ↪ fallback %s: %s → %s (%s: %s)\n",
+		fallbackRole(ev.Role), ev.From, ev.To, ev.Code, textutil.Truncate(ev.Reason, 120))
 }
 
-func (pt *phaseTracker) currentPhase() string {
-	pt.mu.Lock()
-	defer pt.mu.Unlock()
-	return pt.phaseName
+// printSummary prints the Fallback Activity table at the end of a run.
+func (ft *fallbackTrace) printSummary() {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	fmt.Printf("\n--- Fallback Activity ---\n")
+	if len(ft.events) == 0 {
+		fmt.Printf("  none (all requests served by their requested model)\n")
+	}
+	for _, ev := range ft.events {
+		fmt.Printf("  %-12s %s → %s  [%s]\n", fallbackRole(ev.Role)+":", ev.From, ev.To, ev.Code)
+	}
+	fmt.Printf("-------------------------\n")
 }
 
-func (pt *phaseTracker) summary() string {
-	pt.mu.Lock()
-	defer pt.mu.Unlock()
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("%-20s %s\n", "PHASE", "ELAPSED"))
-	sb.WriteString(fmt.Sprintf("%-20s %s\n", "-----", "-------"))
-	for _, p := range pt.phases {
-		sb.WriteString(fmt.Sprintf("%-20s %.1fs\n", p.name, p.elapsed.Seconds()))
+// fallbackRole labels alias-routed (pool) requests, which carry no role.
+func fallbackRole(role string) string {
+	if role == "" {
+		return "pool"
 	}
-	total := time.Since(pt.runStart)
-	sb.WriteString(fmt.Sprintf("%-20s %.1fs\n", "TOTAL", total.Seconds()))
-	return sb.String()
+	return role
 }
 
 // classifyFSError returns a user-friendly description of filesystem errors.
@@ -2,26 +2,29 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/meganerd/electrictown/internal/nodes"
 	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/provider/ollama"
+	"github.com/meganerd/electrictown/internal/textutil"
 )
 
-// ollamaTagsResponse is the JSON payload from GET /api/tags.
-type ollamaTagsResponse struct {
-	Models []struct {
-		Name string `json:"name"`
-	} `json:"models"`
-}
-
-// cmdNodes implements "et nodes": pings each Ollama provider and lists models.
+// cmdNodes implements "et nodes": pings each Ollama provider and lists models,
+// marking those already loaded in memory.
+// With --watch it keeps redrawing a live status table until interrupted.
 func cmdNodes(args []string) error {
 	fs := flag.NewFlagSet("nodes", flag.ExitOnError)
 	configPath := fs.String("config", "", "path to config file (default: ./electrictown.yaml, then $HOME/electrictown.yaml)")
+	watch := fs.Bool("watch", false, "continuously monitor nodes, redrawing a live table until Ctrl-C")
+	interval := fs.Duration("interval", 5*time.Second, "refresh interval for --watch")
+	pull := fs.String("pull", "", "pull this model onto every reachable Ollama node that lacks it, then exit")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -37,61 +40,175 @@ func cmdNodes(args []string) error {
 	}
 
 	client := &http.Client{Timeout: 5 * time.Second}
+
+	if *pull != "" {
+		return pullModel(client, cfg, *pull)
+	}
+
+	if *watch {
+		if *interval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+		return watchNodes(client, cfg, *interval)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	_ = ctx // ctx reserved for future use with request cancellation
 
 	fmt.Printf("%-20s %-40s %s\n", "NODE", "URL", "STATUS / MODELS")
 	fmt.Printf("%-20s %-40s %s\n", "----", "---", "---------------")
 
-	for name, pc := range cfg.Providers {
-		if pc.Type != "ollama" {
-			continue
-		}
-		baseURL := pc.BaseURL
-		if baseURL == "" {
-			baseURL = "http://localhost:11434"
+	statuses := nodes.ProbeAll(ctx, client, cfg)
+	for _, st := range statuses {
+		switch {
+		case !st.Online:
+			fmt.Printf("%-20s %-40s ✗ %s\n", st.Name, st.URL, st.Err)
+		case len(st.Models) == 0:
+			fmt.Printf("%-20s %-40s ✓ online (no models)\n", st.Name, st.URL)
+		default:
+			// Print first model on the same line, remaining models indented.
+			fmt.Printf("%-20s %-40s ✓ %s%s\n", st.Name, st.URL, st.Models[0], loadedNote(st, st.Models[0]))
+			for _, m := range st.Models[1:] {
+				fmt.Printf("%-20s %-40s   %s%s\n", "", "", m, loadedNote(st, m))
+			}
 		}
+	}
 
-		tagsURL := baseURL + "/api/tags"
-		resp, err := client.Get(tagsURL)
-		if err != nil {
-			fmt.Printf("%-20s %-40s ✗ offline (%v)\n", name, baseURL, trimErr(err))
-			continue
-		}
-		defer resp.Body.Close()
+	printModelGaps(nodes.FindGaps(cfg, statuses))
+	return nil
+}
 
-		if resp.StatusCode != http.StatusOK {
-			fmt.Printf("%-20s %-40s ✗ HTTP %d\n", name, baseURL, resp.StatusCode)
-			continue
-		}
+// pullModel pulls model onto every reachable Ollama node that does not have it
+// yet, one node at a time, redrawing a progress line for the current node.
+// Offline nodes and nodes that already have the model are reported and
+// skipped. Returns an error if any pull failed or no node ends up with it.
+func pullModel(client *http.Client, cfg *provider.Config, model string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	probeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	statuses := nodes.ProbeAll(probeCtx, client, cfg)
+	cancel()
+	if len(statuses) == 0 {
+		return fmt.Errorf("no Ollama providers configured")
+	}
 
-		var tags ollamaTagsResponse
-		if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
-			fmt.Printf("%-20s %-40s ✗ parse error: %v\n", name, baseURL, err)
+	fmt.Printf("Pulling %s onto %d node(s)...\n", model, len(statuses))
+	failed, ready := 0, 0
+	for _, st := range statuses {
+		switch {
+		case !st.Online:
+			fmt.Printf("  %-20s ✗ skipped: %s\n", st.Name, st.Err)
 			continue
-		}
-
-		if len(tags.Models) == 0 {
-			fmt.Printf("%-20s %-40s ✓ online (no models)\n", name, baseURL)
+		case st.HasModel(model):
+			fmt.Printf("  %-20s ✓ already present\n", st.Name)
+			ready++
 			continue
 		}
 
-		// Print first model on the same line, remaining models indented.
-		fmt.Printf("%-20s %-40s ✓ %s\n", name, baseURL, tags.Models[0].Name)
-		for _, m := range tags.Models[1:] {
-			fmt.Printf("%-20s %-40s   %s\n", "", "", m.Name)
+		pc := cfg.Providers[st.Name]
+		p := ollama.New(st.URL, pc.APIKey, ollama.WithAuthType(pc.AuthType))
+		err := p.Pull(ctx, model, func(status string, completed, total int64) {
+			line := status
+			if total > 0 {
+				line = fmt.Sprintf("%s %3d%% (%s / %s)", status, completed*100/total, nodes.FormatBytes(completed), nodes.FormatBytes(total))
+			}
+			fmt.Printf("\r\033[K  %-20s %s", st.Name, textutil.Truncate(line, 70))
+		})
+		if err != nil {
+			failed++
+			fmt.Printf("\r\033[K  %-20s ✗ %v\n", st.Name, err)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
 		}
+		fmt.Printf("\r\033[K  %-20s ✓ pulled\n", st.Name)
+		ready++
 	}
 
+	if failed > 0 {
+		return fmt.Errorf("pull failed on %d node(s)", failed)
+	}
+	if ready == 0 {
+		return fmt.Errorf("no reachable node to pull %s onto", model)
+	}
 	return nil
 }
 
-// trimErr shortens common connection error messages for table display.
-func trimErr(err error) string {
-	msg := err.Error()
-	if len(msg) > 60 {
-		return msg[:57] + "..."
+// loadedNote describes a model that is resident in memory on the node (warm),
+// or returns "" when it is cold.
+func loadedNote(st nodes.Status, model string) string {
+	rm, ok := st.LoadedModel(model)
+	if !ok {
+		return ""
+	}
+	note := "  [loaded"
+	if rm.SizeVRAM > 0 {
+		note += ", " + nodes.FormatBytes(rm.SizeVRAM) + " VRAM"
+	} else {
+		note += ", " + nodes.FormatBytes(rm.Size) + " CPU"
+	}
+	if !rm.ExpiresAt.IsZero() {
+		note += ", until " + rm.ExpiresAt.Local().Format("15:04")
+	}
+	return note + "]"
+}
+
+// printModelGaps warns, in red, about role models no reachable node has pulled.
+func printModelGaps(gaps []nodes.Gap) {
+	if len(gaps) == 0 {
+		return
+	}
+	fmt.Printf("\n\033[31m⚠ %d role model(s) not available on any reachable node:\033[0m\n", len(gaps))
+	for _, g := range gaps {
+		kind := "primary"
+		if g.Fallback {
+			kind = "fallback"
+		}
+		fmt.Printf("\033[31m  %-12s → %s (%s, %s)\033[0m\n", g.Role, g.Alias, g.Model, kind)
+	}
+	fmt.Printf("Pull the missing models (ollama pull <model>) or update the role config before running.\n")
+}
+
+// watchNodes re-probes all Ollama nodes every interval and redraws the table
+// in place, in the same way liveProgress redraws worker status lines. The
+// cursor is hidden while watching and restored on Ctrl-C.
+func watchNodes(client *http.Client, cfg *provider.Config, interval time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Print("\033[?25l")       // hide cursor
+	defer fmt.Print("\033[?25h") // restore cursor
+
+	drawn := 0
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		statuses := nodes.ProbeAll(ctx, client, cfg)
+		if ctx.Err() != nil {
+			break // interrupted mid-probe; don't draw a half-failed frame
+		}
+		lines := nodes.WatchTable(statuses)
+		lines = append(lines, "", fmt.Sprintf("updated %s · every %s · Ctrl-C to exit", time.Now().Format("15:04:05"), interval))
+
+		// Cursor up over the previous frame, reprint, and clear any leftovers.
+		if drawn > 0 {
+			fmt.Printf("\033[%dA", drawn)
+		}
+		for _, l := range lines {
+			fmt.Printf("\r\033[K%s\n", l)
+		}
+		fmt.Print("\033[J")
+		drawn = len(lines)
+
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return nil
+		case <-ticker.C:
+		}
 	}
-	return msg
+	fmt.Println()
+	return nil
 }
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/interrupt"
+	"github.com/meganerd/electrictown/internal/manifest"
+	"github.com/meganerd/electrictown/internal/pipeline"
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/runlog"
+	"github.com/meganerd/electrictown/internal/runstate"
+)
+
+// cmdReplay implements "et replay": it runs Phase 3 synthesis (and Phase 4
+// unless --no-tester) again on the worker results checkpointed by an earlier
+// pooled run, so a changed synthesis prompt can be tried without paying for
+// the workers. The replay gets its own run log directory, like --resume.
+// Only _state.json is read; worker-N.out files hold just unnamed output.
+func cmdReplay(args []string) (err error) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to config file (default: ./electrictown.yaml, then $HOME/electrictown.yaml)")
+	supervisorRole := fs.String("role", "mayor", "supervisor role name")
+	noTester := fs.Bool("no-tester", false, "skip Phase 4 tester polish of the new synthesis")
+	maxContextTokens := fs.Int("max-context-tokens", 0, "synthesis prompt budget in estimated tokens; larger worker output is synthesized in batches (0 = no limit)")
+	timeoutMins := fs.Int("timeout", 30, "total timeout in minutes")
+	synthesisFormat := fs.String("synthesis-format", "banner", "how to print the new synthesis: banner, markdown, plain, or files-only (only _synthesis.md)")
+
+	// The run ID may come before the flags: et replay <run-id> --no-tester.
+	var runID string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		runID, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if runID == "" && fs.NArg() > 0 {
+		runID = fs.Arg(0)
+	} else if fs.NArg() > 0 {
+		return fmt.Errorf("usage: et replay [flags] <run-id>")
+	}
+	if runID == "" {
+		return fmt.Errorf("usage: et replay [flags] <run-id>")
+	}
+	if *maxContextTokens < 0 {
+		return fmt.Errorf("--max-context-tokens must not be negative")
+	}
+	synthFormat, err := runlog.ParseSynthesisFormat(*synthesisFormat)
+	if err != nil {
+		return fmt.Errorf("--synthesis-format: %w", err)
+	}
+
+	resolvedConfig, err := findConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	cfg, err := provider.LoadConfig(resolvedConfig)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	router, err := provider.NewRouter(cfg, buildFactories())
+	if err != nil {
+		return fmt.Errorf("creating router: %w", err)
+	}
+
+	baseLogDir, err := cfg.ResolveLogDir()
+	if err != nil {
+		return fmt.Errorf("resolving log_dir: %w", err)
+	}
+	savedDir, err := runstate.FindRunDir(baseLogDir, runID)
+	if err != nil {
+		return err
+	}
+	saved, err := runstate.Load(savedDir)
+	if err != nil {
+		return err
+	}
+
+	newID, err := generateShortID()
+	if err != nil {
+		return fmt.Errorf("generating run ID: %w", err)
+	}
+	runLogDir := filepath.Join(baseLogDir, time.Now().Format("2006-01-02")+"_"+newID)
+	if err := os.MkdirAll(runLogDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: cannot create log directory %s: %s — continuing without logs\n", runLogDir, classifyFSError(err))
+	}
+	m := &manifest.Manifest{
+		RunID:     newID,
+		Version:   version,
+		Task:      saved.Task,
+		Config:    resolvedConfig,
+		OutputDir: saved.OutputDir,
+		StartedAt: time.Now(),
+		ReplayOf:  saved.RunID,
+	}
+	if err := m.Write(runLogDir); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: %v\n", err)
+	}
+
+	tracker := cost.NewTracker(cost.DefaultPricing())
+	defer func() {
+		if err := tracker.WriteFile(filepath.Join(runLogDir, cost.FileName)); err != nil {
+			fmt.Fprintf(os.Stderr, "  warning: could not write %s: %v\n", cost.FileName, err)
+		}
+	}()
+
+	opts := []pipeline.Option{
+		pipeline.WithSupervisorRole(*supervisorRole),
+		pipeline.WithMaxContextTokens(*maxContextTokens),
+		pipeline.WithCostTracker(tracker),
+		pipeline.WithOutput(os.Stdout, os.Stderr),
+		pipeline.WithSpinner(func(label string) func() { return startSpinner(spinLabelWithToks(label, tracker)) }),
+	}
+	if *noTester {
+		opts = append(opts, pipeline.WithoutTester())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeoutMins)*time.Minute)
+	defer cancel()
+
+	// Ctrl-C stops the replay as it stops "et run" (exit status 130).
+	ctx, stopSignals := interrupt.NotifyContext(ctx, func() {
+		fmt.Fprintf(os.Stderr, "\ninterrupted again — exiting without cleanup\n")
+		os.Exit(130)
+	}, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	context.AfterFunc(ctx, func() {
+		if interrupt.Interrupted(ctx) {
+			fmt.Fprintf(os.Stderr, "\ninterrupted — stopping after in-flight requests (Ctrl-C again to exit now)\n")
+		}
+	})
+	defer func() {
+		if interrupt.Interrupted(ctx) {
+			if sum := tracker.Summary(); sum.TotalTokens > 0 {
+				fmt.Fprintf(os.Stderr, "  used %s tok ($%.4f) before stopping\n", formatEstToks(sum.TotalTokens, sum.Estimated), sum.TotalCost)
+			}
+			err = interrupt.ErrInterrupted
+		}
+	}()
+
+	fmt.Printf("Logs: %s\n", runLogDir)
+	res, err := pipeline.New(router, cfg, opts...).Replay(ctx, saved)
+	if err != nil {
+		return err
+	}
+	rl := runlog.New(os.Stdout, runlog.WithSynthesisFormat(synthFormat))
+	rl.Synthesis(res.Synthesis)
+	if err := rl.SaveSynthesis(runLogDir, res.Synthesis); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: %v\n", err)
+	}
+	if sum := tracker.Summary(); sum.TotalTokens > 0 {
+		fmt.Printf("  replay used %s tok ($%.4f); the workers were not run again\n", formatEstToks(sum.TotalTokens, sum.Estimated), sum.TotalCost)
+	}
+	return nil
+}
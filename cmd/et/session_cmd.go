@@ -5,11 +5,14 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/meganerd/electrictown/internal/provider"
 	"github.com/meganerd/electrictown/internal/session"
+	"github.com/meganerd/electrictown/internal/textutil"
 	"github.com/meganerd/electrictown/internal/tmux"
 )
 
@@ -48,24 +51,33 @@ func sessionUsageText() string {
 	return `et session - Manage interactive agent sessions in tmux
 
 Usage:
-  et session spawn [--role name] [--dir path] [--config path] "prompt"
-  et session list
+  et session spawn [--agent name] [--role name] [--dir path] [--config path] "prompt"
+  et session list [--config path]
   et session attach <session-name>
-  et session kill <session-name>
+  et session kill [--config path] <session-name>
   et session send <session-name> "text"
 
 Commands:
   spawn    Create a new tmux session for an agent
-  list     List active et-* tmux sessions
+  list     List sessions started with spawn, and any other et-* tmux sessions
   attach   Attach to a tmux session
   kill     Kill a tmux session
   send     Send text input to a tmux session
+
+spawn --agent picks the agent CLI the session runs: electrictown (et run,
+the default), claude (Claude Code), or gemini (Gemini CLI). claude and gemini
+take their model from ET_<AGENT>_MODEL_<ROLE>, then ANTHROPIC_MODEL or
+GEMINI_MODEL.
+
+Spawned sessions are recorded in sessions.json under log_dir, so list sees
+them from any shell; a session whose tmux session has exited lists as done.
 `
 }
 
 // cmdSessionSpawn creates a new tmux session for an agent.
 func cmdSessionSpawn(args []string) error {
 	fs := flag.NewFlagSet("session spawn", flag.ExitOnError)
+	agent := fs.String("agent", "electrictown", "agent CLI to run: electrictown, claude, or gemini")
 	role := fs.String("role", "polecat", "agent role name")
 	workDir := fs.String("dir", ".", "working directory")
 	configPath := fs.String("config", "", "path to config file (default: ./electrictown.yaml, then $HOME/electrictown.yaml)")
@@ -75,21 +87,31 @@ func cmdSessionSpawn(args []string) error {
 
 	prompt := strings.Join(fs.Args(), " ")
 	if prompt == "" {
-		return fmt.Errorf("prompt required\n\nUsage: et session spawn [--role name] [--dir path] \"prompt\"")
+		return fmt.Errorf("prompt required\n\nUsage: et session spawn [--agent name] [--role name] [--dir path] \"prompt\"")
 	}
 
-	// Resolve config path then load.
-	resolvedConfig, err := findConfig(*configPath)
-	if err != nil {
+	// Only electrictown sessions need a config; the others use it for
+	// log_dir when there is one.
+	var cfg *provider.Config
+	var err error
+	if *agent == "electrictown" {
+		resolvedConfig, err := findConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		cfg, err = provider.LoadConfig(resolvedConfig)
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+	} else if cfg, err = loadOptionalConfig(*configPath); err != nil {
 		return err
 	}
-	cfg, err := provider.LoadConfig(resolvedConfig)
+
+	adapter, err := newSessionAdapter(*agent, cfg, *configPath)
 	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
+		return err
 	}
 
-	adapter := session.NewElectrictownAdapter(cfg, *configPath)
-
 	// Resolve session config.
 	sessCfg, err := adapter.ResolveConfig(*role)
 	if err != nil {
@@ -98,23 +120,37 @@ func cmdSessionSpawn(args []string) error {
 	sessCfg.WorkDir = *workDir
 	sessCfg.OutputDir = *workDir
 
-	// Build the shell-quoted command to send into the session.
+	if err := adapter.ProvisionHooks(*workDir, *role); err != nil {
+		return fmt.Errorf("provision hooks for role %q: %w", *role, err)
+	}
+
+	// Build the shell-quoted command to send into the session, prefixed
+	// with the session's environment.
 	// Using send-keys avoids all shell quoting issues with arbitrary prompt text.
 	cmdName, cmdArgs := adapter.BuildCommand(sessCfg, prompt)
-	parts := make([]string, 0, 1+len(cmdArgs))
+	parts := make([]string, 0, len(sessCfg.Env)+1+len(cmdArgs))
+	envKeys := make([]string, 0, len(sessCfg.Env))
+	for k := range sessCfg.Env {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		parts = append(parts, k+"="+shellQuote(sessCfg.Env[k]))
+	}
 	parts = append(parts, cmdName)
 	for _, arg := range cmdArgs {
 		parts = append(parts, shellQuote(arg))
 	}
 	innerCmd := strings.Join(parts, " ")
 
-	// Generate session name.
+	// Generate session name, et-{role}-{first 4 hex chars of ID} as
+	// TmuxExecutor names them.
 	runner := tmux.NewAutoRunner()
-	shortID, err := generateShortID()
+	id, err := generateSessionID()
 	if err != nil {
 		return fmt.Errorf("generate session ID: %w", err)
 	}
-	sessionName := fmt.Sprintf("et-%s-%s", *role, shortID)
+	sessionName := fmt.Sprintf("et-%s-%s", *role, id[:4])
 
 	// Start the session with a plain bash shell (stays alive after command completes).
 	if err := runner.NewSession(sessionName, "bash", *workDir); err != nil {
@@ -126,39 +162,89 @@ func cmdSessionSpawn(args []string) error {
 		return fmt.Errorf("send command to session: %w", err)
 	}
 
+	store, err := openSessionStore(cfg, runner)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(*workDir)
+	if err != nil {
+		dir = *workDir
+	}
+	if err := store.Put(session.Record{
+		ID:        id,
+		Role:      *role,
+		TmuxName:  sessionName,
+		Status:    session.StatusRunning,
+		WorkDir:   dir,
+		StartedAt: time.Now(),
+	}); err != nil {
+		// The session is running; only "et session list" misses it.
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
 	fmt.Printf("Created session: %s\n", sessionName)
+	fmt.Printf("  Agent:   %s\n", adapter.Name())
 	fmt.Printf("  Role:    %s\n", *role)
 	fmt.Printf("  Dir:     %s\n", *workDir)
-	fmt.Printf("  Prompt:  %s\n", truncate(prompt, 80))
+	fmt.Printf("  Prompt:  %s\n", textutil.Truncate(prompt, 80))
 	fmt.Printf("\nAttach with: et session attach %s\n", sessionName)
 	return nil
 }
 
-// cmdSessionList lists active et-* tmux sessions.
-func cmdSessionList(_ []string) error {
+// cmdSessionList lists the sessions recorded in the session store, with
+// their status reconciled against tmux, followed by any other live et-*
+// tmux sessions.
+func cmdSessionList(args []string) error {
+	fs := flag.NewFlagSet("session list", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to config file used to locate log_dir (optional)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadOptionalConfig(*configPath)
+	if err != nil {
+		return err
+	}
 	runner := tmux.NewAutoRunner()
-
-	sessions, err := runner.ListSessions()
+	store, err := openSessionStore(cfg, runner)
+	if err != nil {
+		return err
+	}
+	records, err := store.Load()
 	if err != nil {
-		return fmt.Errorf("list sessions: %w", err)
+		return err
 	}
 
-	var etSessions []string
-	for _, name := range sessions {
-		if strings.HasPrefix(name, "et-") {
-			etSessions = append(etSessions, name)
+	// tmux not running at all just means there are no live sessions.
+	live, _ := runner.ListSessions()
+	known := make(map[string]bool, len(records))
+	for _, r := range records {
+		known[r.TmuxName] = true
+	}
+	for _, name := range live {
+		if strings.HasPrefix(name, "et-") && !known[name] {
+			records = append(records, session.Record{TmuxName: name, Status: session.StatusRunning})
 		}
 	}
 
-	if len(etSessions) == 0 {
-		fmt.Println("No active et sessions.")
+	if len(records) == 0 {
+		fmt.Println("No et sessions.")
 		return nil
 	}
 
-	fmt.Printf("%-30s\n", "SESSION NAME")
-	fmt.Printf("%-30s\n", "------------")
-	for _, name := range etSessions {
-		fmt.Printf("%-30s\n", name)
+	fmt.Printf("%-24s %-10s %-8s %-16s %s\n", "SESSION NAME", "ROLE", "STATUS", "STARTED", "DIR")
+	fmt.Printf("%-24s %-10s %-8s %-16s %s\n", "------------", "----", "------", "-------", "---")
+	for _, r := range records {
+		name, role, started := r.TmuxName, r.Role, "-"
+		if name == "" {
+			name = r.ID
+		}
+		if role == "" {
+			role = "-"
+		}
+		if !r.StartedAt.IsZero() {
+			started = r.StartedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%-24s %-10s %-8s %-16s %s\n", name, role, r.Status, started, r.WorkDir)
 	}
 	return nil
 }
@@ -171,32 +257,36 @@ func cmdSessionAttach(args []string) error {
 
 	name := args[0]
 
-	// Prefer byobu when available so the user gets byobu decorations on attach.
-	binary := "tmux"
-	if tmux.DetectByobu() {
-		binary = "byobu"
-	}
-
-	cmd := exec.Command(binary, "attach-session", "-t", name)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	// The auto runner prefers byobu when available so the user gets byobu
+	// decorations on attach.
+	return tmux.NewAutoRunner().AttachSession(name)
 }
 
-// cmdSessionKill kills a tmux session.
+// cmdSessionKill kills a tmux session and marks it done in the session
+// store.
 func cmdSessionKill(args []string) error {
-	if len(args) < 1 {
-		return fmt.Errorf("session name required\n\nUsage: et session kill <session-name>")
+	fs := flag.NewFlagSet("session kill", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to config file used to locate log_dir (optional)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		return fmt.Errorf("session name required\n\nUsage: et session kill [--config path] <session-name>")
 	}
 
-	name := args[0]
+	name := fs.Arg(0)
 	runner := tmux.NewAutoRunner()
 
 	if err := runner.KillSession(name); err != nil {
 		return fmt.Errorf("kill session %q: %w", name, err)
 	}
 
+	if cfg, err := loadOptionalConfig(*configPath); err == nil {
+		if store, err := openSessionStore(cfg, nil); err == nil {
+			markSessionDone(store, name)
+		}
+	}
+
 	fmt.Printf("Killed session: %s\n", name)
 	return nil
 }
@@ -216,7 +306,7 @@ func cmdSessionSend(args []string) error {
 		return fmt.Errorf("send to session %q: %w", name, err)
 	}
 
-	fmt.Printf("Sent to %s: %s\n", name, truncate(text, 80))
+	fmt.Printf("Sent to %s: %s\n", name, textutil.Truncate(text, 80))
 	return nil
 }
 
@@ -225,7 +315,7 @@ func shellQuote(s string) string {
 	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
 }
 
-// generateShortID produces a 4-character hex string for session naming.
+// generateShortID produces a 4-character hex string for run IDs.
 func generateShortID() (string, error) {
 	b := make([]byte, 2)
 	if _, err := rand.Read(b); err != nil {
@@ -233,3 +323,75 @@ func generateShortID() (string, error) {
 	}
 	return fmt.Sprintf("%x", b), nil
 }
+
+// generateSessionID produces a random 16-character hex session ID; its first
+// four characters name the tmux session.
+func generateSessionID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", b), nil
+}
+
+// newSessionAdapter returns the ProviderAdapter for the named agent CLI.
+func newSessionAdapter(agent string, cfg *provider.Config, configPath string) (session.ProviderAdapter, error) {
+	switch agent {
+	case "electrictown":
+		return session.NewElectrictownAdapter(cfg, configPath), nil
+	case "claude":
+		return session.NewClaudeAdapter(), nil
+	case "gemini":
+		return session.NewGeminiCLIAdapter(), nil
+	default:
+		return nil, fmt.Errorf("unknown agent %q (want electrictown, claude, or gemini)", agent)
+	}
+}
+
+// loadOptionalConfig loads the config at configPath, or the default config
+// when configPath is empty. A missing or unreadable default config gives the
+// zero-value config, which resolves the default log_dir; an explicit
+// configPath must load.
+func loadOptionalConfig(configPath string) (*provider.Config, error) {
+	resolved, err := findConfig(configPath)
+	if err != nil {
+		if configPath != "" {
+			return nil, err
+		}
+		return &provider.Config{}, nil
+	}
+	cfg, err := provider.LoadConfig(resolved)
+	if err != nil {
+		if configPath != "" {
+			return nil, fmt.Errorf("loading config: %w", err)
+		}
+		return &provider.Config{}, nil
+	}
+	return cfg, nil
+}
+
+// openSessionStore returns the session store in cfg's log_dir. runner, when
+// not nil, reconciles stored statuses against live tmux sessions.
+func openSessionStore(cfg *provider.Config, runner tmux.Runner) (*session.Store, error) {
+	dir, err := cfg.ResolveLogDir()
+	if err != nil {
+		return nil, fmt.Errorf("resolving log_dir: %w", err)
+	}
+	return session.NewStore(filepath.Join(dir, session.StoreFile), runner), nil
+}
+
+// markSessionDone marks the stored record for tmux session name done, if
+// there is one.
+func markSessionDone(store *session.Store, name string) {
+	records, err := store.Load()
+	if err != nil {
+		return
+	}
+	for _, r := range records {
+		if r.TmuxName == name {
+			r.Status = session.StatusDone
+			_ = store.Put(r)
+			return
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the optional per-project file that overrides build
+// system detection for an output directory.
+const ConfigFileName = ".electrictown-build.yaml"
+
+// Config is the contents of ConfigFileName:
+//
+//	build_cmd: make ci
+//	test_cmd: make test-ci
+//	error_format: go
+//
+// Commands run with "sh -c" in the output directory. Either may be omitted,
+// in which case the detected runner's own command is used. ErrorFormat is
+// one of "go", "pytest", "node", or "cargo", or a regular expression with
+// named groups "file", "line", and optionally "message"; when empty, errors
+// are parsed with ParseBuildErrors.
+type Config struct {
+	BuildCmd    string `yaml:"build_cmd"`
+	TestCmd     string `yaml:"test_cmd"`
+	ErrorFormat string `yaml:"error_format"`
+}
+
+// namedFormats maps the ErrorFormat names to their parsers.
+var namedFormats = map[string]func(string) []BuildError{
+	"go":     ParseGoErrors,
+	"pytest": ParsePytestErrors,
+	"node":   ParseNodeErrors,
+	"cargo":  ParseCargoErrors,
+}
+
+// LoadConfig reads dir/.electrictown-build.yaml. It returns nil and no
+// error when the file does not exist.
+func LoadConfig(dir string) (*Config, error) {
+	path := filepath.Join(dir, ConfigFileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("build: read %s: %w", path, err)
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("build: parse %s: %w", path, err)
+	}
+	if c.BuildCmd == "" && c.TestCmd == "" {
+		return nil, fmt.Errorf("build: %s sets neither build_cmd nor test_cmd", path)
+	}
+	if _, err := c.parser(); err != nil {
+		return nil, fmt.Errorf("build: %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// parser returns the error parser ErrorFormat selects.
+func (c *Config) parser() (func(string) []BuildError, error) {
+	if c.ErrorFormat == "" {
+		return ParseBuildErrors, nil
+	}
+	if p, ok := namedFormats[c.ErrorFormat]; ok {
+		return p, nil
+	}
+	re, err := regexp.Compile(c.ErrorFormat)
+	if err != nil {
+		return nil, fmt.Errorf("error_format: %w", err)
+	}
+	if re.SubexpIndex("file") < 0 || re.SubexpIndex("line") < 0 {
+		return nil, fmt.Errorf("error_format %q is not a known format and has no (?P<file>...) and (?P<line>...) groups", c.ErrorFormat)
+	}
+	return func(output string) []BuildError { return parsePattern(output, re) }, nil
+}
+
+// parsePattern extracts one BuildError per line of output matching re,
+// which has named groups "file", "line", and optionally "message".
+func parsePattern(output string, re *regexp.Regexp) []BuildError {
+	var errs []BuildError
+	seen := map[string]bool{}
+	file, line, message := re.SubexpIndex("file"), re.SubexpIndex("line"), re.SubexpIndex("message")
+	for _, l := range strings.Split(output, "\n") {
+		m := re.FindStringSubmatch(strings.TrimSpace(l))
+		if m == nil {
+			continue
+		}
+		n, err := strconv.Atoi(m[line])
+		if err != nil {
+			continue
+		}
+		e := BuildError{File: filepath.Clean(m[file]), Line: n}
+		if message >= 0 {
+			e.Message = m[message]
+		}
+		key := e.File + ":" + strconv.Itoa(e.Line) + ":" + e.Message
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// CustomRunner runs the commands from a project's .electrictown-build.yaml,
+// falling back to the detected runner, if any, for a command it omits.
+// Output from both streams comes back as stderr, since the commands are
+// arbitrary.
+type CustomRunner struct {
+	Config   Config
+	Fallback Runner // may be nil
+	parse    func(string) []BuildError
+}
+
+func (r *CustomRunner) Name() string { return "custom" }
+
+func (r *CustomRunner) Run(ctx context.Context, dir string) (string, string, error) {
+	if r.Config.BuildCmd == "" {
+		if r.Fallback == nil {
+			return "", "", fmt.Errorf("build: %s has no build_cmd and no build system was detected", ConfigFileName)
+		}
+		return r.Fallback.Run(ctx, dir)
+	}
+	return runCombined(ctx, dir, "sh", "-c", r.Config.BuildCmd)
+}
+
+func (r *CustomRunner) Test(ctx context.Context, dir string) (string, string, error) {
+	if r.Config.TestCmd == "" {
+		if r.Fallback == nil {
+			return "", "", ErrTestsNotSupported
+		}
+		return r.Fallback.Test(ctx, dir)
+	}
+	return runCombined(ctx, dir, "sh", "-c", r.Config.TestCmd)
+}
+
+// ParseErrors parses output in the configured error format.
+func (r *CustomRunner) ParseErrors(output string) []BuildError {
+	return r.parse(output)
+}
+
+// ErrorParser is implemented by runners that know the format of their own
+// output; see ParserFor.
+type ErrorParser interface {
+	ParseErrors(output string) []BuildError
+}
+
+// ParserFor returns r's own error parser when it has one, and
+// ParseBuildErrors otherwise.
+func ParserFor(r Runner) func(string) []BuildError {
+	if p, ok := r.(ErrorParser); ok {
+		return p.ParseErrors
+	}
+	return ParseBuildErrors
+}
+
+// customRunner returns a CustomRunner for dir's .electrictown-build.yaml, or
+// nil if dir has none.
+func customRunner(dir string) (Runner, error) {
+	c, err := LoadConfig(dir)
+	if c == nil || err != nil {
+		return nil, err
+	}
+	parse, _ := c.parser() // validated by LoadConfig
+	return &CustomRunner{Config: *c, Fallback: detectRunner(dir), parse: parse}, nil
+}
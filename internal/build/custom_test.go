@@ -0,0 +1,100 @@
+package build
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestDetectRunner_ConfigOverridesDetection checks that a project asking for
+// "make ci" gets it instead of the plain "make" auto-detection would run.
+func TestDetectRunner_ConfigOverridesDetection(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Makefile":     "all:\n\t@echo plain make\n\t@exit 1\nci:\n\t@echo running ci\n",
+		ConfigFileName: "build_cmd: make ci\n",
+	})
+
+	r := DetectRunner(dir)
+	if r == nil || r.Name() != "custom" {
+		t.Fatalf("DetectRunner = %v, want the custom runner", r)
+	}
+	_, out, err := r.Run(context.Background(), dir)
+	if err != nil || !strings.Contains(out, "running ci") {
+		t.Errorf("Run = %q, %v; want make ci to succeed", out, err)
+	}
+
+	// No test_cmd: tests fall back to the detected make runner, whose
+	// Makefile has no test target.
+	if _, _, err := r.Test(context.Background(), dir); err != ErrTestsNotSupported {
+		t.Errorf("Test err = %v, want ErrTestsNotSupported from the fallback", err)
+	}
+
+	via, _, err := DetectRunnerFor(dir, "")
+	if err != nil || via == nil || via.Name() != "custom" {
+		t.Errorf("DetectRunnerFor = %v, %v; want the custom runner", via, err)
+	}
+}
+
+func TestCustomRunner_ErrorFormat(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		ConfigFileName: `test_cmd: "echo 'lint: src/app.c line 12: missing semicolon'; exit 1"` + "\n" +
+			`error_format: '(?P<file>\S+\.c) line (?P<line>\d+): (?P<message>.+)$'` + "\n",
+	})
+	r := DetectRunner(dir)
+	if r == nil {
+		t.Fatal("DetectRunner = nil, want the custom runner")
+	}
+	if _, _, err := r.Run(context.Background(), dir); err == nil {
+		t.Error("Run with no build_cmd and nothing detected succeeded")
+	}
+
+	loop := &FixLoop{Step: r.Test, Dir: dir, MaxIterations: 1, Parse: ParserFor(r)}
+	outcome, p := loop.Run(context.Background())
+	if outcome != Exhausted {
+		t.Fatalf("outcome = %v, want exhausted", outcome)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].File != "src/app.c" || p.Errors[0].Line != 12 || p.Errors[0].Message != "missing semicolon" {
+		t.Errorf("errors = %+v, want src/app.c:12", p.Errors)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	if c, err := LoadConfig(dir); c != nil || err != nil {
+		t.Errorf("LoadConfig without a file = %v, %v; want nil, nil", c, err)
+	}
+
+	writeFiles(t, dir, map[string]string{ConfigFileName: "build_cmd: make ci\ntest_cmd: make check\nerror_format: cargo\n"})
+	c, err := LoadConfig(dir)
+	if err != nil || c.BuildCmd != "make ci" || c.TestCmd != "make check" || c.ErrorFormat != "cargo" {
+		t.Errorf("LoadConfig = %+v, %v", c, err)
+	}
+
+	for name, body := range map[string]string{
+		"no commands":    "error_format: go\n",
+		"bad yaml":       "build_cmd: [\n",
+		"bad regexp":     "build_cmd: make\nerror_format: '(unclosed'\n",
+		"missing groups": "build_cmd: make\nerror_format: '(?P<file>.+)'\n",
+	} {
+		writeFiles(t, dir, map[string]string{ConfigFileName: body})
+		if _, err := LoadConfig(dir); err == nil {
+			t.Errorf("%s: LoadConfig succeeded", name)
+		}
+		if _, _, err := DetectRunnerFor(dir, ""); err == nil {
+			t.Errorf("%s: DetectRunnerFor succeeded", name)
+		}
+	}
+}
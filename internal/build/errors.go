@@ -19,9 +19,20 @@ type BuildError struct {
 // Also handles "path/file.go:line: message" (no column).
 var goErrorPattern = regexp.MustCompile(`^([^:\n]+\.go):(\d+)(?::\d+)?:\s+(.+)$`)
 
-// ParseBuildErrors extracts file-attributed errors from compiler stderr output.
-// Currently handles Go compiler format; other formats produce file-less entries.
+// ParseBuildErrors extracts file-attributed errors from build output.
+// Handles the Go compiler format (see ParseGoErrors), pytest reports (see
+// ParsePytestErrors), tsc and eslint output (see ParseNodeErrors), and
+// cargo's JSON diagnostics (see ParseCargoErrors); lines in other formats are
+// ignored.
 func ParseBuildErrors(stderr string) []BuildError {
+	errs := ParseGoErrors(stderr)
+	errs = append(errs, ParsePytestErrors(stderr)...)
+	errs = append(errs, ParseNodeErrors(stderr)...)
+	return append(errs, ParseCargoErrors(stderr)...)
+}
+
+// ParseGoErrors extracts file-attributed errors in the Go compiler format.
+func ParseGoErrors(stderr string) []BuildError {
 	var errs []BuildError
 	seen := map[string]bool{}
 
@@ -66,7 +77,8 @@ func NormalizeErrorPaths(errs []BuildError, outputDir string) []BuildError {
 
 // MapFilesToWorkers returns a map from worker index to the build errors
 // attributed to files that worker produced. fileWorkerMap maps relative
-// file paths to worker indices (0-based).
+// file paths to worker indices (0-based). When several workers may write the
+// same file, WriteHistory.Attribute keeps the full write order instead.
 func MapFilesToWorkers(errs []BuildError, fileWorkerMap map[string]int) map[int][]BuildError {
 	result := make(map[int][]BuildError)
 	for _, e := range errs {
@@ -0,0 +1,80 @@
+package build
+
+import "path/filepath"
+
+// WriteHistory records which workers wrote each output file, in order, so
+// that errors are attributed to the right worker when several workers
+// write the same path. Paths are cleaned, so "./a.go" and "a.go" are the
+// same file.
+type WriteHistory struct {
+	writers map[string][]int // file → worker indices, oldest write first
+	last    map[string]int   // file → last writer
+	retired map[int]bool
+}
+
+// NewWriteHistory creates an empty WriteHistory.
+func NewWriteHistory() *WriteHistory {
+	return &WriteHistory{
+		writers: make(map[string][]int),
+		last:    make(map[string]int),
+		retired: make(map[int]bool),
+	}
+}
+
+// Record notes that worker (0-based) wrote file, a path relative to the
+// output directory.
+func (h *WriteHistory) Record(file string, worker int) {
+	file = filepath.Clean(file)
+	h.writers[file] = append(h.writers[file], worker)
+	h.last[file] = worker
+}
+
+// Owners returns each file's last writer. The map is live: later Record
+// calls update it, so it can be saved as-is with a run's checkpoint.
+func (h *WriteHistory) Owners() map[string]int {
+	return h.last
+}
+
+// Writers returns the distinct workers that wrote file, most recent first.
+func (h *WriteHistory) Writers(file string) []int {
+	ws := h.writers[filepath.Clean(file)]
+	out := make([]int, 0, len(ws))
+	seen := map[int]bool{}
+	for i := len(ws) - 1; i >= 0; i-- {
+		if !seen[ws[i]] {
+			seen[ws[i]] = true
+			out = append(out, ws[i])
+		}
+	}
+	return out
+}
+
+// Retire marks a worker that can no longer be sent fixes, such as one whose
+// fix subtask failed. Attribute passes its files to their earlier writers.
+func (h *WriteHistory) Retire(worker int) {
+	h.retired[worker] = true
+}
+
+// Attribute returns a map from worker index to the errors in files that
+// worker is responsible for: the file's last writer, or, when that worker
+// is retired, the most recent writer of the file that is not. A file whose
+// writers are all retired stays with its last writer. Errors in files no
+// worker wrote are left out.
+func (h *WriteHistory) Attribute(errs []BuildError) map[int][]BuildError {
+	result := make(map[int][]BuildError)
+	for _, e := range errs {
+		writers := h.Writers(e.File)
+		if len(writers) == 0 {
+			continue
+		}
+		owner := writers[0]
+		for _, w := range writers {
+			if !h.retired[w] {
+				owner = w
+				break
+			}
+		}
+		result[owner] = append(result[owner], e)
+	}
+	return result
+}
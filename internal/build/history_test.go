@@ -0,0 +1,65 @@
+package build
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWriteHistory_OverlappingWrites(t *testing.T) {
+	h := NewWriteHistory()
+	// Workers 0 and 2 both emit main.go; worker 2 writes last. Worker 1's
+	// "./util.go" and worker 0's later "util.go" are the same file.
+	h.Record("main.go", 0)
+	h.Record("./util.go", 1)
+	h.Record("util.go", 0)
+	h.Record("main.go", 2)
+	h.Record("api/server.go", 1)
+
+	errs := []BuildError{
+		{File: "main.go", Line: 3, Message: "undefined: run"},
+		{File: "util.go", Line: 9, Message: "unused import"},
+		{File: "api/server.go", Line: 1, Message: "syntax error"},
+		{File: "gen/missing.go", Line: 1, Message: "no writer"},
+	}
+	got := h.Attribute(errs)
+	want := map[int][]BuildError{
+		0: {errs[1]},
+		1: {errs[2]},
+		2: {errs[0]},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Attribute = %+v, want %+v", got, want)
+	}
+
+	owners := map[string]int{"main.go": 2, "util.go": 0, "api/server.go": 1}
+	if !reflect.DeepEqual(h.Owners(), owners) {
+		t.Errorf("Owners = %v, want %v", h.Owners(), owners)
+	}
+	if w := h.Writers("./main.go"); !reflect.DeepEqual(w, []int{2, 0}) {
+		t.Errorf("Writers(main.go) = %v, want [2 0]", w)
+	}
+}
+
+func TestWriteHistory_RetiredWriterFallsBack(t *testing.T) {
+	h := NewWriteHistory()
+	h.Record("main.go", 0)
+	h.Record("main.go", 1)
+	h.Record("main.go", 0) // rewrites by an earlier writer count once
+	h.Record("main.go", 2)
+	h.Record("lib.go", 2)
+	h.Retire(2)
+
+	errs := []BuildError{{File: "main.go", Line: 1, Message: "x"}, {File: "lib.go", Line: 1, Message: "y"}}
+	got := h.Attribute(errs)
+	if len(got[0]) != 1 || got[0][0].File != "main.go" {
+		t.Errorf("main.go errors went to %v, want worker 0, its most recent writer that is not retired", got)
+	}
+	if len(got[2]) != 1 || got[2][0].File != "lib.go" {
+		t.Errorf("lib.go errors went to %v, want worker 2, its only writer", got)
+	}
+
+	h.Retire(0)
+	if got := h.Attribute(errs[:1]); len(got[1]) != 1 {
+		t.Errorf("with 0 and 2 retired, main.go errors went to %v, want worker 1", got)
+	}
+}
@@ -0,0 +1,162 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Language names understood by InferLanguage, DetectRunnerFor and
+// ScaffoldProject.
+const (
+	LangGo   = "go"
+	LangNode = "node"
+)
+
+// fallbackGoVersion is the go directive written into a scaffolded go.mod
+// when the installed toolchain's version cannot be read.
+const fallbackGoVersion = "1.21"
+
+// goVersionPattern takes the language version (e.g. "1.25") from a
+// toolchain version such as "go1.25.3" or "go1.26rc1".
+var goVersionPattern = regexp.MustCompile(`^go(\d+\.\d+)`)
+
+// toolchainGoVersion returns the language version of the go command on
+// PATH, which is the one Phase 5 builds with, or fallbackGoVersion.
+func toolchainGoVersion() string {
+	out, err := exec.Command("go", "env", "GOVERSION").Output()
+	if err != nil {
+		return fallbackGoVersion
+	}
+	if m := goVersionPattern.FindStringSubmatch(strings.TrimSpace(string(out))); m != nil {
+		return m[1]
+	}
+	return fallbackGoVersion
+}
+
+// taskLanguagePatterns map phrases in a task description to a language. Bare
+// "go" is too common an English word to count on its own, so Go needs a
+// qualifying phrase.
+var taskLanguagePatterns = []struct {
+	lang string
+	re   *regexp.Regexp
+}{
+	{LangGo, regexp.MustCompile(`(?i)\bgolang\b|\bgo (?:module|program|package|cli|service|server|library|code|project|app(?:lication)?)\b|\b(?:in|using|with|written in) go\b`)},
+	{LangNode, regexp.MustCompile(`(?i)\bnode(?:\.?js)?\b|\btypescript\b|\bjavascript\b|\bnpm\b|\bbun\b`)},
+}
+
+// ParseLanguage returns the canonical name of a --language value. Only
+// languages ScaffoldProject can scaffold are accepted ("golang" is taken for
+// Go); an empty s is returned unchanged and means "infer".
+func ParseLanguage(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return "", nil
+	case LangGo, "golang":
+		return LangGo, nil
+	}
+	return "", fmt.Errorf("build: unsupported language %q (want go)", s)
+}
+
+// InferLanguage guesses the target language from a task description.
+// It returns "" when the task names no supported language.
+func InferLanguage(task string) string {
+	for _, p := range taskLanguagePatterns {
+		if p.re.MatchString(task) {
+			return p.lang
+		}
+	}
+	return ""
+}
+
+// languageFromFiles guesses the language from source files at the top of dir
+// and one level below, returning "" if none are recognised.
+func languageFromFiles(dir string) string {
+	var matches []string
+	for _, pattern := range []string{"*", "*/*"} {
+		m, _ := filepath.Glob(filepath.Join(dir, pattern))
+		matches = append(matches, m...)
+	}
+	for _, m := range matches {
+		switch filepath.Ext(m) {
+		case ".go":
+			return LangGo
+		case ".js", ".mjs", ".ts":
+			return LangNode
+		}
+	}
+	return ""
+}
+
+// DetectRunnerFor is DetectRunner with a fallback for output directories that
+// have source files but no build file yet, as happens when no worker wrote
+// one. The language is taken from lang, or from the files in dir when lang
+// is empty; if a project file can be scaffolded for it, that file is written
+// and its path returned in scaffolded. It returns a nil Runner when no build
+// system can be found or made, and an error when dir's
+// .electrictown-build.yaml is malformed.
+func DetectRunnerFor(dir, lang string) (r Runner, scaffolded string, err error) {
+	if r, err := customRunner(dir); r != nil || err != nil {
+		return r, "", err
+	}
+	if r := detectRunner(dir); r != nil {
+		return r, "", nil
+	}
+	if lang == "" {
+		lang = languageFromFiles(dir)
+	}
+	if lang == "" {
+		return nil, "", nil
+	}
+	scaffolded, err = ScaffoldProject(dir, lang)
+	if err != nil || scaffolded == "" {
+		return nil, "", err
+	}
+	return DetectRunner(dir), scaffolded, nil
+}
+
+// ScaffoldProject writes a minimal project file for lang into dir so that
+// DetectRunner finds a build system: for Go, a go.mod named after dir whose
+// go directive is the installed toolchain's version. It
+// returns the path written, or "" if lang has no scaffold. An existing file
+// is never overwritten.
+func ScaffoldProject(dir, lang string) (string, error) {
+	switch strings.ToLower(lang) {
+	case LangGo, "golang":
+		path := filepath.Join(dir, "go.mod")
+		if fileExists(path) {
+			return "", nil
+		}
+		content := fmt.Sprintf("module %s\n\ngo %s\n", moduleName(dir), toolchainGoVersion())
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return "", fmt.Errorf("build: scaffolding go.mod: %w", err)
+		}
+		return path, nil
+	}
+	return "", nil
+}
+
+// moduleName derives a valid module path from dir's base name.
+func moduleName(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '-'
+	}, filepath.Base(abs))
+	name = strings.Trim(name, "-.")
+	if name == "" {
+		return "app"
+	}
+	return name
+}
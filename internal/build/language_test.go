@@ -0,0 +1,124 @@
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInferLanguage(t *testing.T) {
+	tests := []struct {
+		task string
+		want string
+	}{
+		{"write a REST server in Go", LangGo},
+		{"Build a golang CLI that counts words", LangGo},
+		{"create a Go module for parsing TOML", LangGo},
+		{"write a TypeScript library for dates", LangNode},
+		{"a Node.js script to resize images", LangNode},
+		{"go ahead and write a poem", ""},
+		{"explain how DNS works", ""},
+	}
+	for _, tt := range tests {
+		if got := InferLanguage(tt.task); got != tt.want {
+			t.Errorf("InferLanguage(%q) = %q, want %q", tt.task, got, tt.want)
+		}
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	for in, want := range map[string]string{"": "", "go": LangGo, "Golang": LangGo} {
+		got, err := ParseLanguage(in)
+		if err != nil || got != want {
+			t.Errorf("ParseLanguage(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"node", "rust", "gp"} {
+		if _, err := ParseLanguage(in); err == nil {
+			t.Errorf("ParseLanguage(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestDetectRunnerFor_ScaffoldsGoModule(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Word Counter")
+	if err := os.MkdirAll(filepath.Join(dir, "cmd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmd", "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, scaffolded, err := DetectRunnerFor(dir, "")
+	if err != nil {
+		t.Fatalf("DetectRunnerFor: %v", err)
+	}
+	if r == nil || r.Name() != "go" {
+		t.Fatalf("want go runner, got %v", r)
+	}
+	if scaffolded != filepath.Join(dir, "go.mod") {
+		t.Errorf("scaffolded = %q, want go.mod in dir", scaffolded)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatalf("go.mod not written: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "module word-counter\n") {
+		t.Errorf("go.mod = %q, want module word-counter", data)
+	}
+	if want := "\ngo " + toolchainGoVersion() + "\n"; !strings.HasSuffix(string(data), want) {
+		t.Errorf("go.mod = %q, want it to end in %q", data, want)
+	}
+}
+
+func TestToolchainGoVersion(t *testing.T) {
+	out, err := exec.Command("go", "env", "GOVERSION").Output()
+	if err != nil {
+		t.Skip("no go command on PATH")
+	}
+	got := toolchainGoVersion()
+	if !strings.HasPrefix(strings.TrimSpace(string(out)), "go"+got) || strings.Count(got, ".") != 1 {
+		t.Errorf("toolchainGoVersion() = %q for toolchain %s", got, out)
+	}
+}
+
+func TestDetectRunnerFor_LanguageHint(t *testing.T) {
+	dir := t.TempDir()
+	r, scaffolded, err := DetectRunnerFor(dir, "go")
+	if err != nil {
+		t.Fatalf("DetectRunnerFor: %v", err)
+	}
+	if r == nil || r.Name() != "go" || scaffolded == "" {
+		t.Errorf("want scaffolded go runner for empty dir with hint, got %v %q", r, scaffolded)
+	}
+}
+
+func TestDetectRunnerFor_ExistingBuildFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, scaffolded, err := DetectRunnerFor(dir, "go")
+	if err != nil {
+		t.Fatalf("DetectRunnerFor: %v", err)
+	}
+	if r == nil || r.Name() != "node" {
+		t.Errorf("detected build file should win over hint, got %v", r)
+	}
+	if scaffolded != "" {
+		t.Errorf("nothing should be scaffolded, got %q", scaffolded)
+	}
+}
+
+func TestDetectRunnerFor_Unknown(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, scaffolded, err := DetectRunnerFor(dir, "")
+	if err != nil || r != nil || scaffolded != "" {
+		t.Errorf("want no runner, got %v %q %v", r, scaffolded, err)
+	}
+}
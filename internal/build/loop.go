@@ -0,0 +1,123 @@
+package build
+
+import (
+	"context"
+	"errors"
+)
+
+// Step is one pass of a FixLoop, such as a Runner's Run or Test method.
+type Step func(ctx context.Context, dir string) (stdout, stderr string, err error)
+
+// Pass is the result of one pass of a FixLoop.
+type Pass struct {
+	Iteration int // 1-based
+	Stdout    string
+	Stderr    string
+	Err       error        // nil when the pass succeeded
+	Errors    []BuildError // parsed from both streams, relative to the loop's Dir
+}
+
+// Outcome says how a FixLoop ended.
+type Outcome int
+
+const (
+	// Passed means the step succeeded.
+	Passed Outcome = iota
+	// Exhausted means the step still failed after MaxIterations passes.
+	Exhausted
+	// Stuck means the step failed with the same output as an earlier pass,
+	// so the fixes are not making progress.
+	Stuck
+	// Unattributed means none of the errors could be traced to a worker's
+	// files, so there was no one to send fixes to.
+	Unattributed
+	// Unsupported means the step returned ErrTestsNotSupported.
+	Unsupported
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Passed:
+		return "passed"
+	case Exhausted:
+		return "exhausted"
+	case Stuck:
+		return "stuck"
+	case Unattributed:
+		return "unattributed"
+	case Unsupported:
+		return "unsupported"
+	}
+	return "unknown"
+}
+
+// FixLoop runs Step in Dir until it succeeds, sending the errors of each
+// failed pass to the workers that wrote the affected files and running Step
+// again once they are fixed. It backs both the Phase 5 build loop and the
+// Phase 5.5 test loop.
+type FixLoop struct {
+	Step          Step
+	Dir           string
+	MaxIterations int
+
+	// Parse extracts errors from a failed pass's output; nil means
+	// ParseBuildErrors. Use ParserFor to get the runner's own.
+	Parse func(output string) []BuildError
+
+	// History records which workers wrote the files in Dir. Fix should
+	// record the files it rewrites, and retire workers whose fixes fail.
+	History *WriteHistory
+
+	// Fix dispatches fix subtasks for the errors attributed to each worker
+	// and writes the results before returning.
+	Fix func(ctx context.Context, workerErrors map[int][]BuildError)
+
+	// Repeated, when set, reports whether a failed pass's output has been
+	// seen before, e.g. pool.DoomLoop.Check. The loop then ends as Stuck.
+	Repeated func(output string) bool
+
+	// Before and After, when set, are called around each pass. After is
+	// not called for an Unsupported step.
+	Before func(iteration int)
+	After  func(p Pass)
+}
+
+// Run runs the loop and reports how it ended, along with the last pass.
+func (l *FixLoop) Run(ctx context.Context) (Outcome, Pass) {
+	var p Pass
+	for iter := 1; ; iter++ {
+		if l.Before != nil {
+			l.Before(iter)
+		}
+		stdout, stderr, err := l.Step(ctx, l.Dir)
+		if errors.Is(err, ErrTestsNotSupported) {
+			return Unsupported, Pass{Iteration: iter, Err: err}
+		}
+		p = Pass{Iteration: iter, Stdout: stdout, Stderr: stderr, Err: err}
+		if err != nil {
+			parse := l.Parse
+			if parse == nil {
+				parse = ParseBuildErrors
+			}
+			p.Errors = NormalizeErrorPaths(parse(stdout+"\n"+stderr), l.Dir)
+		}
+		if l.After != nil {
+			l.After(p)
+		}
+
+		switch {
+		case err == nil:
+			return Passed, p
+		case l.Repeated != nil && l.Repeated(stderr):
+			return Stuck, p
+		case iter >= l.MaxIterations:
+			return Exhausted, p
+		}
+
+		workerErrors := l.History.Attribute(p.Errors)
+		if len(workerErrors) == 0 {
+			return Unattributed, p
+		}
+		l.Fix(ctx, workerErrors)
+	}
+}
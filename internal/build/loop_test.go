@@ -0,0 +1,171 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFixLoop_GoTestsFailThenPass drives the Phase 5.5 loop over a real Go
+// module whose test fails until the "worker" that wrote calc.go fixes it.
+func TestFixLoop_GoTestsFailThenPass(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module calc\n\ngo 1.21\n",
+		"calc.go":      "package calc\n\nfunc Add(a, b int) int { return a - b }\n",
+		"calc_test.go": "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif got := Add(2, 2); got != 4 {\n\t\tt.Errorf(\"Add(2, 2) = %d, want 4\", got)\n\t}\n}\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var fixes []map[int][]BuildError
+	var passes []Pass
+	loop := &FixLoop{
+		Step:          DetectRunner(dir).Test,
+		Dir:           dir,
+		MaxIterations: 3,
+		// The test file is the one the failure points at, so the worker
+		// that owns it gets the fix, as it would in a real run.
+		History: history(map[string]int{"calc.go": 0, "calc_test.go": 1}),
+		Fix: func(_ context.Context, workerErrors map[int][]BuildError) {
+			fixes = append(fixes, workerErrors)
+			fixed := "package calc\n\nfunc Add(a, b int) int { return a + b }\n"
+			if err := os.WriteFile(filepath.Join(dir, "calc.go"), []byte(fixed), 0644); err != nil {
+				t.Fatal(err)
+			}
+		},
+		After: func(p Pass) { passes = append(passes, p) },
+	}
+
+	outcome, last := loop.Run(context.Background())
+	if outcome != Passed {
+		t.Fatalf("outcome = %v, want passed; last output:\n%s", outcome, last.Stderr)
+	}
+	if len(passes) != 2 || passes[0].Err == nil || passes[1].Err != nil {
+		t.Fatalf("passes = %+v, want a failure then a success", passes)
+	}
+	if len(fixes) != 1 {
+		t.Fatalf("fixes dispatched %d times, want 1", len(fixes))
+	}
+	errs := fixes[0][1]
+	if len(errs) != 1 || errs[0].File != "calc_test.go" || errs[0].Line != 7 || !strings.Contains(errs[0].Message, "want 4") {
+		t.Errorf("fix errors = %+v, want calc_test.go:7", fixes[0])
+	}
+}
+
+// history returns a WriteHistory in which files[f] wrote f.
+func history(files map[string]int) *WriteHistory {
+	h := NewWriteHistory()
+	for f, w := range files {
+		h.Record(f, w)
+	}
+	return h
+}
+
+// scriptedStep fails with outputs[i] on the i-th pass and passes once they
+// run out.
+func scriptedStep(outputs ...string) Step {
+	i := 0
+	return func(context.Context, string) (string, string, error) {
+		if i >= len(outputs) {
+			return "ok", "", nil
+		}
+		i++
+		return "", outputs[i-1], errors.New("exit status 1")
+	}
+}
+
+func TestFixLoop_Outcomes(t *testing.T) {
+	tests := []struct {
+		name      string
+		step      Step
+		max       int
+		repeated  bool
+		want      Outcome
+		wantFixes int
+		wantIter  int
+	}{
+		{"passes first time", scriptedStep(), 3, false, Passed, 0, 1},
+		{"passes after fixes", scriptedStep("a.go:1: x", "a.go:2: y"), 3, false, Passed, 2, 3},
+		{"exhausted", scriptedStep("a.go:1: x", "a.go:2: y", "a.go:3: z"), 2, false, Exhausted, 1, 2},
+		{"unattributed", scriptedStep("b.go:1: x"), 3, false, Unattributed, 0, 1},
+		{"stuck", scriptedStep("a.go:1: x", "a.go:1: x"), 3, true, Stuck, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixes := 0
+			loop := &FixLoop{
+				Step:          tt.step,
+				Dir:           "/out",
+				MaxIterations: tt.max,
+				History:       history(map[string]int{"a.go": 0}),
+				Fix:           func(context.Context, map[int][]BuildError) { fixes++ },
+			}
+			if tt.repeated {
+				seen := map[string]bool{}
+				loop.Repeated = func(out string) bool {
+					r := seen[out]
+					seen[out] = true
+					return r
+				}
+			}
+			outcome, last := loop.Run(context.Background())
+			if outcome != tt.want || fixes != tt.wantFixes || last.Iteration != tt.wantIter {
+				t.Errorf("outcome %v after %d passes and %d fixes, want %v after %d and %d",
+					outcome, last.Iteration, fixes, tt.want, tt.wantIter, tt.wantFixes)
+			}
+		})
+	}
+}
+
+func TestNoTests(t *testing.T) {
+	var r struct{ NoTests }
+	if _, _, err := r.Test(context.Background(), t.TempDir()); !errors.Is(err, ErrTestsNotSupported) {
+		t.Errorf("err = %v, want ErrTestsNotSupported", err)
+	}
+	loop := &FixLoop{
+		Step:          r.Test,
+		MaxIterations: 3,
+		After:         func(Pass) { t.Error("After called for an unsupported step") },
+	}
+	if outcome, _ := loop.Run(context.Background()); outcome != Unsupported {
+		t.Errorf("outcome = %v, want unsupported", outcome)
+	}
+}
+
+func TestRunnerTest_Unsupported(t *testing.T) {
+	tests := map[string]struct {
+		file, body string
+	}{
+		"make without test target":  {"Makefile", "all:\n\tcc main.c\n"},
+		"npm init placeholder":      {"package.json", `{"scripts": {"test": "echo \"Error: no test specified\" && exit 1"}}`},
+		"package.json without test": {"package.json", `{"scripts": {"build": "tsc"}}`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.body), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := DetectRunner(dir).Test(context.Background(), dir); !errors.Is(err, ErrTestsNotSupported) {
+				t.Errorf("err = %v, want ErrTestsNotSupported", err)
+			}
+		})
+	}
+}
+
+func TestHasMakeTarget(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Makefile"), []byte(".PHONY: test\nbuild:\n\tgo build\ntest: build\n\tgo test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !hasMakeTarget(dir, "test") || hasMakeTarget(dir, "lint") {
+		t.Error("hasMakeTarget misread the Makefile")
+	}
+}
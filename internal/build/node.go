@@ -0,0 +1,165 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NodeRunner builds a JavaScript/TypeScript project. When package.json has a
+// build script it runs it, preferring bun if available and falling back to
+// npm; a project with a tsconfig.json and no build script is type-checked
+// with "tsc --noEmit" instead. Test runs the package.json test script.
+//
+// npm and tsc report diagnostics on stdout, so Run captures both streams
+// together and returns them as stderr, where the build loop looks for errors.
+type NodeRunner struct{}
+
+func (r *NodeRunner) Name() string { return "node" }
+
+func (r *NodeRunner) Run(ctx context.Context, dir string) (string, string, error) {
+	if !hasBuildScript(dir) && fileExists(filepath.Join(dir, "tsconfig.json")) {
+		if _, err := exec.LookPath("tsc"); err == nil {
+			return runCombined(ctx, dir, "tsc", "--noEmit", "--pretty", "false")
+		}
+		return runCombined(ctx, dir, "npx", "--no-install", "tsc", "--noEmit", "--pretty", "false")
+	}
+	// Prefer bun if available.
+	if _, err := exec.LookPath("bun"); err == nil {
+		return runCombined(ctx, dir, "bun", "run", "build")
+	}
+	return runCombined(ctx, dir, "npm", "run", "build")
+}
+
+func (r *NodeRunner) Test(ctx context.Context, dir string) (string, string, error) {
+	if !hasTestScript(dir) {
+		return "", "", ErrTestsNotSupported
+	}
+	if _, err := exec.LookPath("bun"); err == nil {
+		return runCombined(ctx, dir, "bun", "run", "test")
+	}
+	return runCombined(ctx, dir, "npm", "test")
+}
+
+// npmInitTestScript is the placeholder test script "npm init" writes.
+const npmInitTestScript = `echo "Error: no test specified" && exit 1`
+
+// hasBuildScript reports whether dir/package.json defines a "build" script.
+func hasBuildScript(dir string) bool {
+	return packageScripts(dir)["build"] != ""
+}
+
+// hasTestScript reports whether dir/package.json defines a "test" script
+// other than the npm init placeholder.
+func hasTestScript(dir string) bool {
+	script := packageScripts(dir)["test"]
+	return script != "" && script != npmInitTestScript
+}
+
+// packageScripts returns the scripts in dir/package.json, or nil.
+func packageScripts(dir string) map[string]string {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+	return pkg.Scripts
+}
+
+// jsExt matches the source file extensions tsc and eslint report on.
+const jsExt = `\.[cm]?[jt]sx?`
+
+// tscErrorPattern matches tsc diagnostics: "path/file.ts(line,col): error
+// TS2304: message", or "path/file.ts:line:col - error TS2304: message" with
+// --pretty.
+var tscErrorPattern = regexp.MustCompile(`^([^\s()]+` + jsExt + `)(?:\((\d+),\d+\):|:(\d+):\d+ -) error (TS\d+: .+)$`)
+
+// eslintFilePattern matches the file header lines of eslint's default
+// "stylish" format, which are followed by indented problem lines.
+var eslintFilePattern = regexp.MustCompile(`^(\S[^:]*` + jsExt + `)$`)
+
+// eslintProblemPattern matches an eslint "stylish" problem line:
+// "  12:5  error  'x' is defined but never used  no-unused-vars".
+var eslintProblemPattern = regexp.MustCompile(`^\s+(\d+):\d+\s+(error|warning)\s+(.+?)(?:\s{2,}(\S+))?$`)
+
+// nodeStackPattern matches a stack frame in a failing test's trace, as jest
+// and node --test print them: "at Object.<anonymous> (src/sum.test.js:5:20)",
+// "at src/sum.test.js:5:20", or with a file:// URL.
+var nodeStackPattern = regexp.MustCompile(`^at (?:.+ \()?(?:file://)?([^\s()]+` + jsExt + `):(\d+):\d+\)?$`)
+
+// ParseNodeErrors extracts file-attributed errors from tsc diagnostics,
+// eslint "stylish" reports, and the stack traces of failing tests. eslint
+// warnings are skipped since they do not fail a build; rule names are
+// appended to messages in parentheses. Stack frames carry the name of the
+// failing jest test ("● suite › test") when there is one.
+func ParseNodeErrors(output string) []BuildError {
+	var errs []BuildError
+	seen := map[string]bool{}
+	add := func(e BuildError) {
+		key := e.File + ":" + strconv.Itoa(e.Line) + ":" + e.Message
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		errs = append(errs, e)
+	}
+
+	eslintFile := ""           // file whose eslint problems are being listed
+	testName := "test failure" // message for stack frames
+	for _, raw := range strings.Split(output, "\n") {
+		line := strings.TrimRight(raw, " \t\r")
+		if title, ok := strings.CutPrefix(strings.TrimSpace(line), "● "); ok {
+			testName = title
+			continue
+		}
+		if m := nodeStackPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			add(BuildError{File: filepath.Clean(m[1]), Line: n, Message: testName})
+			continue
+		}
+		if m := tscErrorPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			lineNum := m[2]
+			if lineNum == "" {
+				lineNum = m[3]
+			}
+			n, _ := strconv.Atoi(lineNum)
+			add(BuildError{File: filepath.Clean(m[1]), Line: n, Message: m[4]})
+			eslintFile = ""
+			continue
+		}
+		if m := eslintFilePattern.FindStringSubmatch(line); m != nil {
+			eslintFile = filepath.Clean(m[1])
+			continue
+		}
+		if eslintFile == "" {
+			continue
+		}
+		m := eslintProblemPattern.FindStringSubmatch(line)
+		if m == nil {
+			if strings.TrimSpace(line) == "" {
+				eslintFile = ""
+			}
+			continue
+		}
+		if m[2] != "error" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		msg := m[3]
+		if m[4] != "" {
+			msg += " (" + m[4] + ")"
+		}
+		add(BuildError{File: eslintFile, Line: n, Message: msg})
+	}
+	return errs
+}
@@ -0,0 +1,158 @@
+package build
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// npmBuildOutput is "npm run build" output for a project whose build script
+// is "tsc && eslint src".
+const npmBuildOutput = `
+> web@1.0.0 build
+> tsc && eslint src
+
+src/api/client.ts(14,7): error TS2322: Type 'string' is not assignable to type 'number'.
+src/components/App.tsx(3,24): error TS2307: Cannot find module './Header' or its corresponding type declarations.
+src/components/App.tsx(3,24): error TS2307: Cannot find module './Header' or its corresponding type declarations.
+
+/home/dev/web/src/util/format.js
+   2:10  error    'pad' is defined but never used  no-unused-vars
+   9:3   warning  Unexpected console statement     no-console
+  11:1   error    Parsing error: Unexpected token }
+
+/home/dev/web/src/index.js
+  5:1  error  Expected indentation of 2 spaces but found 4  indent
+
+✖ 4 problems (3 errors, 1 warning)
+  1 error and 0 warnings potentially fixable with the ` + "`--fix`" + ` option.
+
+npm ERR! code ELIFECYCLE
+`
+
+func TestParseNodeErrors(t *testing.T) {
+	errs := ParseNodeErrors(npmBuildOutput)
+
+	tests := []struct {
+		file    string
+		line    int
+		message string
+	}{
+		{"src/api/client.ts", 14, "TS2322: Type 'string' is not assignable to type 'number'."},
+		{"src/components/App.tsx", 3, "TS2307: Cannot find module './Header' or its corresponding type declarations."},
+		{"/home/dev/web/src/util/format.js", 2, "'pad' is defined but never used (no-unused-vars)"},
+		{"/home/dev/web/src/util/format.js", 11, "Parsing error: Unexpected token }"},
+		{"/home/dev/web/src/index.js", 5, "Expected indentation of 2 spaces but found 4 (indent)"},
+	}
+	if len(errs) != len(tests) {
+		t.Fatalf("want %d errors, got %d: %+v", len(tests), len(errs), errs)
+	}
+	for i, tt := range tests {
+		if errs[i].File != tt.file || errs[i].Line != tt.line || errs[i].Message != tt.message {
+			t.Errorf("[%d] = %+v, want %s:%d: %s", i, errs[i], tt.file, tt.line, tt.message)
+		}
+	}
+}
+
+func TestParseNodeErrors_TscPretty(t *testing.T) {
+	out := "src/main.ts:8:3 - error TS2304: Cannot find name 'foo'.\n\n8   foo();\n    ~~~\n\nFound 1 error in src/main.ts:8\n"
+	errs := ParseNodeErrors(out)
+	if len(errs) != 1 || errs[0].File != "src/main.ts" || errs[0].Line != 8 || errs[0].Message != "TS2304: Cannot find name 'foo'." {
+		t.Errorf("errors = %+v", errs)
+	}
+}
+
+func TestParseBuildErrors_Node(t *testing.T) {
+	outputDir := "/home/dev/web"
+	errs := NormalizeErrorPaths(ParseBuildErrors(npmBuildOutput), outputDir)
+	byWorker := MapFilesToWorkers(errs, map[string]int{"src/api/client.ts": 0, "src/util/format.js": 1})
+	if len(byWorker[0]) != 1 || byWorker[0][0].Line != 14 {
+		t.Errorf("worker 0 errors = %+v, want src/api/client.ts:14", byWorker[0])
+	}
+	if len(byWorker[1]) != 2 {
+		t.Errorf("worker 1 errors = %+v, want the two eslint errors in src/util/format.js", byWorker[1])
+	}
+}
+
+func TestNodeRunner_Run(t *testing.T) {
+	if _, err := exec.LookPath("npm"); err != nil {
+		t.Skip("npm not installed")
+	}
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node not installed")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"package.json": `{"name": "web", "version": "1.0.0", "scripts": {"build": "node build.js"}}`,
+		// Stands in for tsc, which prints diagnostics on stdout.
+		"build.js": `console.log("src/app.ts(2,5): error TS2304: Cannot find name 'x'."); process.exit(2);`,
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := DetectRunner(dir)
+	if r == nil || r.Name() != "node" {
+		t.Fatalf("DetectRunner = %v, want node", r)
+	}
+	_, stderr, err := r.Run(context.Background(), dir)
+	if err == nil {
+		t.Fatalf("want a failing build, got success:\n%s", stderr)
+	}
+	errs := ParseBuildErrors(stderr)
+	if len(errs) != 1 || errs[0].File != "src/app.ts" || errs[0].Line != 2 {
+		t.Errorf("errors = %+v, want src/app.ts:2\n%s", errs, stderr)
+	}
+}
+
+func TestHasBuildScript(t *testing.T) {
+	dir := t.TempDir()
+	if hasBuildScript(dir) {
+		t.Error("hasBuildScript with no package.json = true")
+	}
+	for body, want := range map[string]bool{
+		`{"scripts": {"build": "tsc"}}`: true,
+		`{"scripts": {"test": "jest"}}`: false,
+		`{`:                             false,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := hasBuildScript(dir); got != want {
+			t.Errorf("hasBuildScript(%s) = %v, want %v", body, got, want)
+		}
+	}
+}
+
+func TestParseNodeErrors_TestFailures(t *testing.T) {
+	out := `FAIL src/sum.test.js
+  ● sum › adds two numbers
+
+    expect(received).toBe(expected) // Object.is equality
+
+    Expected: 4
+    Received: 0
+
+      3 | test('adds two numbers', () => {
+    > 4 |   expect(sum(2, 2)).toBe(4);
+        |                     ^
+
+      at Object.<anonymous> (src/sum.test.js:4:21)
+
+✖ failing tests:
+  at TestContext.<anonymous> (file:///home/dev/web/test/util.test.mjs:9:10)
+`
+	errs := ParseNodeErrors(out)
+	if len(errs) != 2 {
+		t.Fatalf("want 2 errors, got %d: %+v", len(errs), errs)
+	}
+	if errs[0].File != "src/sum.test.js" || errs[0].Line != 4 || errs[0].Message != "sum › adds two numbers" {
+		t.Errorf("errs[0] = %+v", errs[0])
+	}
+	if errs[1].File != "/home/dev/web/test/util.test.mjs" || errs[1].Line != 9 {
+		t.Errorf("errs[1] = %+v", errs[1])
+	}
+}
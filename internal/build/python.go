@@ -0,0 +1,125 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PythonRunner runs a Python project's tests with pytest. Prefers a pytest
+// executable on PATH, falls back to "python3 -m pytest" and then
+// "python -m pytest".
+//
+// pytest reports failures on stdout, so Run captures both streams together
+// and returns them as stderr, where the build loop looks for errors. A
+// project with no tests yet is not a failed build: Run succeeds and Test
+// returns ErrTestsNotSupported.
+type PythonRunner struct{}
+
+func (r *PythonRunner) Name() string { return "python" }
+
+func (r *PythonRunner) Run(ctx context.Context, dir string) (string, string, error) {
+	stdout, stderr, err := r.pytest(ctx, dir)
+	if noTestsCollected(err) {
+		err = nil
+	}
+	return stdout, stderr, err
+}
+
+// Test is Run, except that finding no tests is reported as
+// ErrTestsNotSupported: pytest is both the build and the test step.
+func (r *PythonRunner) Test(ctx context.Context, dir string) (string, string, error) {
+	stdout, stderr, err := r.pytest(ctx, dir)
+	if noTestsCollected(err) {
+		err = ErrTestsNotSupported
+	}
+	return stdout, stderr, err
+}
+
+// pytest runs pytest quietly in dir.
+func (r *PythonRunner) pytest(ctx context.Context, dir string) (string, string, error) {
+	args := []string{"-q", "--color=no"}
+	if _, err := exec.LookPath("pytest"); err == nil {
+		return runCombined(ctx, dir, "pytest", args...)
+	}
+	python := "python3"
+	if _, err := exec.LookPath(python); err != nil {
+		python = "python"
+	}
+	return runCombined(ctx, dir, python, append([]string{"-m", "pytest"}, args...)...)
+}
+
+// pytestNoTests is the exit status pytest uses when it collected no tests.
+const pytestNoTests = 5
+
+// noTestsCollected reports whether err is pytest exiting with pytestNoTests.
+func noTestsCollected(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == pytestNoTests
+}
+
+// pytestLocationPattern matches the frame locations pytest prints at the end
+// of each traceback entry: "path/file.py:line: in func" for intermediate
+// frames, "path/file.py:line: ExceptionType" for the failing one, and a
+// bare "path/file.py:line:" where a test calls into the failing code.
+var pytestLocationPattern = regexp.MustCompile(`^([^:\s]+\.py):(\d+):(?:\s+(.+))?$`)
+
+// pythonFramePattern matches native Python traceback frames, which pytest
+// uses for collection errors and --tb=native:
+// `File "path/file.py", line 12, in func`.
+var pythonFramePattern = regexp.MustCompile(`^File "([^"]+\.py)", line (\d+)`)
+
+// pytestSectionPattern matches the "____ test_name ____" headers that
+// separate failures in a pytest report.
+var pytestSectionPattern = regexp.MustCompile(`^_{3,}\s.*\s_{3,}$`)
+
+// ParsePytestErrors extracts file-attributed errors from a pytest report.
+// Every frame of a failure's traceback becomes a BuildError, so both the
+// test and the code under test are attributed; each carries the failure's
+// first "E   " line as its message, or the frame's own trailer when the
+// failure has none.
+func ParsePytestErrors(output string) []BuildError {
+	var errs []BuildError
+	seen := map[string]bool{}
+
+	var frames []BuildError // locations in the current failure section
+	explanation := ""       // its first "E   " line
+	flush := func() {
+		for _, f := range frames {
+			if explanation != "" {
+				f.Message = explanation
+			}
+			key := f.File + ":" + strconv.Itoa(f.Line) + ":" + f.Message
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			errs = append(errs, f)
+		}
+		frames, explanation = nil, ""
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case pytestSectionPattern.MatchString(line), strings.HasPrefix(line, "====="):
+			flush()
+		case strings.HasPrefix(line, "E ") && explanation == "":
+			explanation = strings.TrimSpace(line[2:])
+		default:
+			if m := pytestLocationPattern.FindStringSubmatch(line); m != nil {
+				n, _ := strconv.Atoi(m[2])
+				frames = append(frames, BuildError{File: filepath.Clean(m[1]), Line: n, Message: m[3]})
+			} else if m := pythonFramePattern.FindStringSubmatch(line); m != nil {
+				n, _ := strconv.Atoi(m[2])
+				frames = append(frames, BuildError{File: filepath.Clean(m[1]), Line: n, Message: line})
+			}
+		}
+	}
+	flush()
+	return errs
+}
@@ -0,0 +1,159 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pytestReport is "pytest -q" output for a failing assertion, an exception
+// raised inside the code under test, and a test module that fails to import.
+const pytestReport = `FF
+==================================== ERRORS ====================================
+____________________ ERROR collecting tests/test_cli.py ____________________
+ImportError while importing test module '/tmp/out/tests/test_cli.py'.
+Hint: make sure your test modules/packages have valid Python names.
+Traceback:
+/usr/lib/python3.11/importlib/__init__.py:126: in import_module
+    return _bootstrap._gcd_import(name[level:], package, level)
+tests/test_cli.py:3: in <module>
+    from calc.cli import main
+E   ModuleNotFoundError: No module named 'calc.cli'
+=================================== FAILURES ===================================
+___________________________________ test_add ___________________________________
+
+    def test_add():
+>       assert add(2, 2) == 5
+E       assert 4 == 5
+E        +  where 4 = add(2, 2)
+
+tests/test_calc.py:5: AssertionError
+___________________________________ test_div ___________________________________
+
+    def test_div():
+>       assert div(1, 0) == 0
+
+tests/test_calc.py:9:
+_ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _ _
+
+a = 1, b = 0
+
+    def div(a, b):
+>       return a / b
+E       ZeroDivisionError: division by zero
+
+calc/ops.py:12: ZeroDivisionError
+=========================== short test summary info ============================
+FAILED tests/test_calc.py::test_add - assert 4 == 5
+FAILED tests/test_calc.py::test_div - ZeroDivisionError: division by zero
+ERROR tests/test_cli.py
+2 failed, 1 error in 0.04s
+`
+
+func TestParsePytestErrors(t *testing.T) {
+	errs := ParsePytestErrors(pytestReport)
+
+	tests := []struct {
+		file    string
+		line    int
+		message string
+	}{
+		{"/usr/lib/python3.11/importlib/__init__.py", 126, "ModuleNotFoundError: No module named 'calc.cli'"},
+		{"tests/test_cli.py", 3, "ModuleNotFoundError: No module named 'calc.cli'"},
+		{"tests/test_calc.py", 5, "assert 4 == 5"},
+		{"tests/test_calc.py", 9, "ZeroDivisionError: division by zero"},
+		{"calc/ops.py", 12, "ZeroDivisionError: division by zero"},
+	}
+	if len(errs) != len(tests) {
+		t.Fatalf("want %d errors, got %d: %+v", len(tests), len(errs), errs)
+	}
+	for i, tt := range tests {
+		if errs[i].File != tt.file || errs[i].Line != tt.line || errs[i].Message != tt.message {
+			t.Errorf("[%d] = %+v, want %s:%d: %s", i, errs[i], tt.file, tt.line, tt.message)
+		}
+	}
+}
+
+func TestParsePytestErrors_NativeTraceback(t *testing.T) {
+	out := `___________________________________ test_load ___________________________________
+Traceback (most recent call last):
+  File "tests/test_store.py", line 8, in test_load
+    store.load("x")
+  File "store.py", line 21, in load
+    raise KeyError(key)
+KeyError: 'x'
+`
+	errs := ParsePytestErrors(out)
+	if len(errs) != 2 {
+		t.Fatalf("want 2 errors, got %d: %+v", len(errs), errs)
+	}
+	if errs[0].File != "tests/test_store.py" || errs[0].Line != 8 {
+		t.Errorf("errs[0] = %+v", errs[0])
+	}
+	if errs[1].File != "store.py" || errs[1].Line != 21 || !strings.Contains(errs[1].Message, "in load") {
+		t.Errorf("errs[1] = %+v", errs[1])
+	}
+}
+
+func TestParseBuildErrors_Pytest(t *testing.T) {
+	errs := ParseBuildErrors(pytestReport)
+	byWorker := MapFilesToWorkers(errs, map[string]int{"calc/ops.py": 0, "tests/test_calc.py": 1})
+	if len(byWorker[0]) != 1 || byWorker[0][0].Line != 12 {
+		t.Errorf("worker 0 errors = %+v, want calc/ops.py:12", byWorker[0])
+	}
+	if len(byWorker[1]) != 2 || byWorker[1][0].Line != 5 || byWorker[1][1].Line != 9 {
+		t.Errorf("worker 1 errors = %+v, want tests/test_calc.py:5 and :9", byWorker[1])
+	}
+}
+
+func TestPythonRunner_Run(t *testing.T) {
+	if exec.Command("python3", "-m", "pytest", "--version").Run() != nil {
+		if _, err := exec.LookPath("pytest"); err != nil {
+			t.Skip("pytest not installed")
+		}
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"requirements.txt": "",
+		"calc.py":          "def add(a, b):\n    return a - b\n",
+		"test_calc.py":     "from calc import add\n\n\ndef test_add():\n    assert add(2, 2) == 4\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, stderr, err := DetectRunner(dir).Run(context.Background(), dir)
+	if err == nil {
+		t.Fatalf("want a failing run, got success:\n%s", stderr)
+	}
+	errs := ParseBuildErrors(stderr)
+	if len(errs) == 0 || errs[0].File != "test_calc.py" || errs[0].Line != 5 {
+		t.Errorf("errors = %+v, want test_calc.py:5 first\n%s", errs, stderr)
+	}
+}
+
+func TestPythonRunner_NoTestsCollected(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	// A stand-in pytest that collects nothing, as on a fresh project.
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho 'no tests ran in 0.01s'\nexit 5\n"
+	if err := os.WriteFile(filepath.Join(bin, "pytest"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	r := &PythonRunner{}
+	if _, stderr, err := r.Run(context.Background(), t.TempDir()); err != nil {
+		t.Errorf("Run with no tests = %v, want success\n%s", err, stderr)
+	}
+	if _, _, err := r.Test(context.Background(), t.TempDir()); !errors.Is(err, ErrTestsNotSupported) {
+		t.Errorf("Test with no tests = %v, want ErrTestsNotSupported", err)
+	}
+}
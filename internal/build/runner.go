@@ -5,10 +5,12 @@ package build
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
 // Runner executes a build command in a directory and reports results.
@@ -16,19 +18,51 @@ type Runner interface {
 	// Run executes the build in dir. Returns captured stdout, stderr,
 	// and a non-nil error if the build failed (non-zero exit or exec error).
 	Run(ctx context.Context, dir string) (stdout, stderr string, err error)
+	// Test runs the project's tests in dir, reporting like Run. Runners
+	// with no test command return ErrTestsNotSupported; embedding NoTests
+	// provides that default.
+	Test(ctx context.Context, dir string) (stdout, stderr string, err error)
 	// Name returns a human-readable label for the runner (e.g. "go", "node").
 	Name() string
 }
 
+// ErrTestsNotSupported is returned by Runner.Test when the build system
+// has no test command, or the project defines no tests.
+var ErrTestsNotSupported = errors.New("build: tests not supported")
+
+// NoTests is the default Runner.Test, for embedding in runners with no
+// test command.
+type NoTests struct{}
+
+func (NoTests) Test(context.Context, string) (string, string, error) {
+	return "", "", ErrTestsNotSupported
+}
+
 // DetectRunner inspects dir and returns an appropriate Runner, or nil if no
-// supported build system is found.
+// supported build system is found. A .electrictown-build.yaml in dir takes
+// precedence over auto-detection; if it is malformed it is ignored here, and
+// DetectRunnerFor reports the error.
 func DetectRunner(dir string) Runner {
+	if r, err := customRunner(dir); r != nil && err == nil {
+		return r
+	}
+	return detectRunner(dir)
+}
+
+// detectRunner is DetectRunner without the .electrictown-build.yaml override.
+func detectRunner(dir string) Runner {
 	if fileExists(filepath.Join(dir, "go.mod")) {
 		return &GoRunner{}
 	}
-	if fileExists(filepath.Join(dir, "package.json")) {
+	if fileExists(filepath.Join(dir, "package.json")) || fileExists(filepath.Join(dir, "tsconfig.json")) {
 		return &NodeRunner{}
 	}
+	if fileExists(filepath.Join(dir, "pyproject.toml")) || fileExists(filepath.Join(dir, "requirements.txt")) {
+		return &PythonRunner{}
+	}
+	if fileExists(filepath.Join(dir, "Cargo.toml")) {
+		return &CargoRunner{}
+	}
 	if fileExists(filepath.Join(dir, "Makefile")) || fileExists(filepath.Join(dir, "makefile")) {
 		return &MakeRunner{}
 	}
@@ -58,7 +92,24 @@ func runCmd(ctx context.Context, dir string, name string, args ...string) (stdou
 	return stdout, stderr, err
 }
 
-// GoRunner builds a Go module with "go build ./...".
+// runCombined is runCmd with stdout and stderr interleaved into the returned
+// stderr, for tools that print their diagnostics on stdout.
+func runCombined(ctx context.Context, dir string, name string, args ...string) (stdout, stderr string, err error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+
+	if runErr := cmd.Run(); runErr != nil {
+		err = fmt.Errorf("%s: %w", name, runErr)
+	}
+	return "", buf.String(), err
+}
+
+// GoRunner builds a Go module with "go build ./..." and tests it with
+// "go test ./...".
 type GoRunner struct{}
 
 func (r *GoRunner) Name() string { return "go" }
@@ -67,20 +118,13 @@ func (r *GoRunner) Run(ctx context.Context, dir string) (string, string, error)
 	return runCmd(ctx, dir, "go", "build", "./...")
 }
 
-// NodeRunner builds a Node.js project. Prefers bun if available, falls back to npm.
-type NodeRunner struct{}
-
-func (r *NodeRunner) Name() string { return "node" }
-
-func (r *NodeRunner) Run(ctx context.Context, dir string) (string, string, error) {
-	// Prefer bun if available.
-	if _, err := exec.LookPath("bun"); err == nil {
-		return runCmd(ctx, dir, "bun", "run", "build")
-	}
-	return runCmd(ctx, dir, "npm", "run", "build")
+// Test reports failures on stdout, so both streams come back as stderr.
+// -fullpath makes the failure locations attributable to files.
+func (r *GoRunner) Test(ctx context.Context, dir string) (string, string, error) {
+	return runCombined(ctx, dir, "go", "test", "-fullpath", "./...")
 }
 
-// MakeRunner runs the default make target.
+// MakeRunner runs the default make target, and the "test" target for tests.
 type MakeRunner struct{}
 
 func (r *MakeRunner) Name() string { return "make" }
@@ -88,3 +132,26 @@ func (r *MakeRunner) Name() string { return "make" }
 func (r *MakeRunner) Run(ctx context.Context, dir string) (string, string, error) {
 	return runCmd(ctx, dir, "make")
 }
+
+func (r *MakeRunner) Test(ctx context.Context, dir string) (string, string, error) {
+	if !hasMakeTarget(dir, "test") {
+		return "", "", ErrTestsNotSupported
+	}
+	return runCombined(ctx, dir, "make", "test")
+}
+
+// hasMakeTarget reports whether dir's makefile defines target.
+func hasMakeTarget(dir, target string) bool {
+	for _, name := range []string{"Makefile", "makefile"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, target+":") {
+				return true
+			}
+		}
+	}
+	return false
+}
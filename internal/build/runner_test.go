@@ -17,6 +17,12 @@ func TestDetectRunner(t *testing.T) {
 		{"package.json detected", []string{"package.json"}, "node", false},
 		{"Makefile detected", []string{"Makefile"}, "make", false},
 		{"go.mod wins over Makefile", []string{"go.mod", "Makefile"}, "go", false},
+		{"tsconfig.json detected", []string{"tsconfig.json"}, "node", false},
+		{"Cargo.toml detected", []string{"Cargo.toml"}, "cargo", false},
+		{"Cargo.toml wins over Makefile", []string{"Cargo.toml", "Makefile"}, "cargo", false},
+		{"pyproject.toml detected", []string{"pyproject.toml"}, "python", false},
+		{"requirements.txt detected", []string{"requirements.txt"}, "python", false},
+		{"requirements.txt wins over Makefile", []string{"requirements.txt", "Makefile"}, "python", false},
 		{"empty dir returns nil", []string{}, "", true},
 	}
 
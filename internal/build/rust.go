@@ -0,0 +1,138 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CargoRunner builds a Rust crate with "cargo build --message-format=json"
+// and tests it with "cargo test --message-format=json".
+//
+// cargo then writes its diagnostics to stdout as JSON lines, which
+// ParseCargoErrors reads; the returned stderr gets their human-readable
+// rendering and any other stdout lines, such as test results, ahead of
+// cargo's own stderr so build logs and summaries stay readable.
+type CargoRunner struct{}
+
+func (r *CargoRunner) Name() string { return "cargo" }
+
+func (r *CargoRunner) Run(ctx context.Context, dir string) (string, string, error) {
+	return runCargo(ctx, dir, "build")
+}
+
+func (r *CargoRunner) Test(ctx context.Context, dir string) (string, string, error) {
+	return runCargo(ctx, dir, "test")
+}
+
+// runCargo runs a cargo subcommand with JSON diagnostics; see CargoRunner.
+func runCargo(ctx context.Context, dir, subcommand string) (string, string, error) {
+	stdout, stderr, err := runCmd(ctx, dir, "cargo", subcommand, "--message-format=json")
+	var readable strings.Builder
+	for _, line := range strings.Split(stdout, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+			if strings.TrimSpace(line) != "" {
+				readable.WriteString(line + "\n")
+			}
+			continue
+		}
+		if msgs := cargoMessages(line); len(msgs) == 1 {
+			readable.WriteString(msgs[0].Rendered)
+		}
+	}
+	return stdout, readable.String() + stderr, err
+}
+
+// cargoDiagnostic is the part of a rustc diagnostic that ParseCargoErrors
+// uses. See https://doc.rust-lang.org/rustc/json.html.
+type cargoDiagnostic struct {
+	Message string `json:"message"`
+	Level   string `json:"level"`
+	Code    *struct {
+		Code string `json:"code"`
+	} `json:"code"`
+	Spans []struct {
+		FileName  string `json:"file_name"`
+		LineStart int    `json:"line_start"`
+		IsPrimary bool   `json:"is_primary"`
+	} `json:"spans"`
+	Rendered string `json:"rendered"`
+}
+
+// cargoMessages returns the compiler diagnostics among the JSON lines of
+// cargo's --message-format=json output. Other lines are skipped.
+func cargoMessages(output string) []cargoDiagnostic {
+	var msgs []cargoDiagnostic
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var m struct {
+			Reason  string           `json:"reason"`
+			Message *cargoDiagnostic `json:"message"`
+		}
+		if json.Unmarshal([]byte(line), &m) != nil || m.Reason != "compiler-message" || m.Message == nil {
+			continue
+		}
+		msgs = append(msgs, *m.Message)
+	}
+	return msgs
+}
+
+// rustPanicPattern matches the location of a test panic: "thread 'name'
+// panicked at src/lib.rs:10:9:" with the message on the next line (Rust
+// 1.73 and later), or "panicked at 'message', src/lib.rs:10:9" before that.
+var rustPanicPattern = regexp.MustCompile(`panicked at (?:'(.*)', )?([^\s:]+\.rs):(\d+):\d+:?$`)
+
+// ParseCargoErrors extracts file-attributed errors from the JSON lines of
+// "cargo build --message-format=json". Each error-level diagnostic yields
+// one BuildError per primary span, with the error code, if any, prefixed to
+// its message; warnings and span-less summaries ("aborting due to ...") are
+// skipped. Test panics in "cargo test" output are reported at the location
+// they name.
+func ParseCargoErrors(output string) []BuildError {
+	var errs []BuildError
+	seen := map[string]bool{}
+	add := func(e BuildError) {
+		key := e.File + ":" + strconv.Itoa(e.Line) + ":" + e.Message
+		if !seen[key] {
+			seen[key] = true
+			errs = append(errs, e)
+		}
+	}
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		m := rustPanicPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		msg := m[1]
+		if msg == "" && i+1 < len(lines) {
+			msg = strings.TrimSpace(lines[i+1])
+		}
+		n, _ := strconv.Atoi(m[3])
+		add(BuildError{File: filepath.Clean(m[2]), Line: n, Message: "panic: " + msg})
+	}
+
+	for _, d := range cargoMessages(output) {
+		if d.Level != "error" {
+			continue
+		}
+		msg := d.Message
+		if d.Code != nil && d.Code.Code != "" {
+			msg = d.Code.Code + ": " + msg
+		}
+		for _, s := range d.Spans {
+			if !s.IsPrimary {
+				continue
+			}
+			add(BuildError{File: filepath.Clean(s.FileName), Line: s.LineStart, Message: msg})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,115 @@
+package build
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testdata/cargo_build.jsonl is "cargo build --message-format=json" stdout
+// for a crate with three errors across two files, preceded by an
+// unused-variable warning captured from an earlier build.
+func TestParseCargoErrors(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "cargo_build.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := ParseCargoErrors(string(data))
+
+	tests := []struct {
+		file    string
+		line    int
+		message string
+	}{
+		{"src/main.rs", 5, "E0425: cannot find value `missing` in this scope"},
+		{"src/util.rs", 2, "E0308: mismatched types"},
+		{"src/main.rs", 6, "E0061: this function takes 0 arguments but 1 argument was supplied"},
+	}
+	if len(errs) != len(tests) {
+		t.Fatalf("want %d errors, got %d: %+v", len(tests), len(errs), errs)
+	}
+	for i, tt := range tests {
+		if errs[i].File != tt.file || errs[i].Line != tt.line || errs[i].Message != tt.message {
+			t.Errorf("[%d] = %+v, want %s:%d: %s", i, errs[i], tt.file, tt.line, tt.message)
+		}
+	}
+
+	// ParseBuildErrors finds the same errors among other build output.
+	if got := ParseBuildErrors("   Compiling demo v0.1.0\n" + string(data)); len(got) != len(tests) {
+		t.Errorf("ParseBuildErrors found %d errors, want %d", len(got), len(tests))
+	}
+}
+
+func TestParseCargoErrors_IgnoresOtherLines(t *testing.T) {
+	out := `{"reason":"compiler-artifact","package_id":"demo 0.1.0"}
+{"reason":"compiler-message","message":{"message":"aborting due to 1 previous error","level":"error","code":null,"spans":[]}}
+{not json
+   Compiling demo v0.1.0
+{"reason":"build-finished","success":false}
+`
+	if errs := ParseCargoErrors(out); len(errs) != 0 {
+		t.Errorf("want no errors, got %+v", errs)
+	}
+}
+
+func TestCargoRunner_Run(t *testing.T) {
+	if _, err := exec.LookPath("cargo"); err != nil {
+		t.Skip("cargo not installed")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"Cargo.toml":  "[package]\nname = \"demo\"\nversion = \"0.1.0\"\nedition = \"2021\"\n",
+		"src/main.rs": "fn main() {\n    let x: u32 = \"one\";\n}\n",
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stdout, stderr, err := DetectRunner(dir).Run(context.Background(), dir)
+	if err == nil {
+		t.Fatalf("want a failing build, got success:\n%s", stderr)
+	}
+	errs := ParseBuildErrors(stdout + "\n" + stderr)
+	if len(errs) != 1 || errs[0].File != "src/main.rs" || errs[0].Line != 2 {
+		t.Errorf("errors = %+v, want src/main.rs:2", errs)
+	}
+	if !strings.Contains(stderr, "mismatched types") {
+		t.Errorf("stderr lacks the rendered diagnostic:\n%s", stderr)
+	}
+}
+
+func TestParseCargoErrors_TestPanics(t *testing.T) {
+	out := `running 2 tests
+test tests::adds ... FAILED
+test tests::old ... FAILED
+
+failures:
+
+---- tests::adds stdout ----
+thread 'tests::adds' panicked at src/lib.rs:10:9:
+assertion ` + "`left == right`" + ` failed
+  left: 0
+ right: 4
+
+---- tests::old stdout ----
+thread 'tests::old' panicked at 'not yet', src/old.rs:3:5
+`
+	errs := ParseCargoErrors(out)
+	if len(errs) != 2 {
+		t.Fatalf("want 2 errors, got %d: %+v", len(errs), errs)
+	}
+	if errs[0].File != "src/lib.rs" || errs[0].Line != 10 || errs[0].Message != "panic: assertion `left == right` failed" {
+		t.Errorf("errs[0] = %+v", errs[0])
+	}
+	if errs[1].File != "src/old.rs" || errs[1].Line != 3 || errs[1].Message != "panic: not yet" {
+		t.Errorf("errs[1] = %+v", errs[1])
+	}
+}
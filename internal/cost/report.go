@@ -0,0 +1,90 @@
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/fileutil"
+)
+
+// FileName is the name of the per-run cost file written into each run log
+// directory.
+const FileName = "_cost.json"
+
+// costFile is the on-disk layout of a _cost.json file.
+type costFile struct {
+	Records []RequestRecord `json:"records"`
+}
+
+// WriteFile writes all recorded requests to path as JSON. The write is atomic
+// so a concurrent `et cost` never observes a partial file.
+func (t *Tracker) WriteFile(path string) error {
+	data, err := json.MarshalIndent(costFile{Records: t.Records()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cost: marshal: %w", err)
+	}
+	return fileutil.AtomicWrite(path, data, 0644)
+}
+
+// LoadFile reads the request records stored in a cost file.
+func LoadFile(path string) ([]RequestRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cf costFile
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return nil, fmt.Errorf("cost: parse %s: %w", path, err)
+	}
+	return cf.Records, nil
+}
+
+// LoadDir walks dir for cost files and returns every record with a timestamp
+// at or after since (a zero since includes all records), along with the number
+// of cost files (runs) that contributed at least one of them. Records are
+// returned in timestamp order.
+func LoadDir(dir string, since time.Time) ([]RequestRecord, int, error) {
+	var all []RequestRecord
+	files := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != FileName {
+			return nil
+		}
+		recs, err := LoadFile(path)
+		if err != nil {
+			return err
+		}
+		kept := len(all)
+		for _, r := range recs {
+			if !since.IsZero() && r.Timestamp.Before(since) {
+				continue
+			}
+			all = append(all, r)
+		}
+		if len(all) > kept {
+			files++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Timestamp.Before(all[j].Timestamp)
+	})
+	return all, files, nil
+}
+
+// Summarize computes an aggregate Summary from an arbitrary set of records,
+// such as those returned by LoadDir.
+func Summarize(records []RequestRecord) *Summary {
+	return buildSummary(records)
+}
@@ -0,0 +1,99 @@
+package cost
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFileLoadFile(t *testing.T) {
+	tr := NewTracker(testPricing())
+	tr.Record("openai", "gpt-4o", "mayor", Usage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150})
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := tr.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	recs, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(recs))
+	}
+	if recs[0].Model != "gpt-4o" || recs[0].TotalTokens != 150 {
+		t.Errorf("unexpected record: %+v", recs[0])
+	}
+}
+
+func TestLoadDir_Aggregates(t *testing.T) {
+	dir := t.TempDir()
+
+	run1 := NewTracker(testPricing())
+	run1.Record("openai", "gpt-4o", "mayor", Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500})
+	run1.Record("ollama", "llama3", "reviewer", Usage{PromptTokens: 200, CompletionTokens: 100, TotalTokens: 300})
+	if err := run1.WriteFile(filepath.Join(dir, "2025-01-01_aaaa", FileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	run2 := NewTracker(testPricing())
+	run2.Record("openai", "gpt-4o", "mayor", Usage{PromptTokens: 2000, CompletionTokens: 1000, TotalTokens: 3000})
+	if err := run2.WriteFile(filepath.Join(dir, "2025-01-02_bbbb", FileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unrelated files are ignored.
+	if err := os.WriteFile(filepath.Join(dir, "2025-01-02_bbbb", "_synthesis.md"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recs, files, err := LoadDir(dir, time.Time{})
+	if err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	if files != 2 {
+		t.Errorf("files = %d, want 2", files)
+	}
+	s := Summarize(recs)
+	if s.TotalRequests != 3 {
+		t.Errorf("TotalRequests = %d, want 3", s.TotalRequests)
+	}
+	if s.TotalTokens != 4800 {
+		t.Errorf("TotalTokens = %d, want 4800", s.TotalTokens)
+	}
+	if s.ByRole["mayor"].Tokens != 4500 {
+		t.Errorf("mayor tokens = %d, want 4500", s.ByRole["mayor"].Tokens)
+	}
+	if s.ByProvider["ollama"].Requests != 1 {
+		t.Errorf("ollama requests = %d, want 1", s.ByProvider["ollama"].Requests)
+	}
+	// gpt-4o: (3000/1M)*2.50 + (1500/1M)*10.00 = 0.0075 + 0.015 = 0.0225
+	if math.Abs(s.ByModel["gpt-4o"].Cost-0.0225) > 1e-10 {
+		t.Errorf("gpt-4o cost = %f, want 0.0225", s.ByModel["gpt-4o"].Cost)
+	}
+	if math.Abs(s.TotalCost-0.0225) > 1e-10 {
+		t.Errorf("TotalCost = %f, want 0.0225", s.TotalCost)
+	}
+}
+
+func TestLoadDir_Since(t *testing.T) {
+	dir := t.TempDir()
+	tr := NewTracker(testPricing())
+	tr.Record("openai", "gpt-4o", "mayor", Usage{TotalTokens: 10})
+	if err := tr.WriteFile(filepath.Join(dir, FileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	recs, runs, err := LoadDir(dir, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	if len(recs) != 0 {
+		t.Errorf("expected records before --since to be excluded, got %d", len(recs))
+	}
+	if runs != 0 {
+		t.Errorf("runs = %d, want 0 when every record is before --since", runs)
+	}
+}
@@ -13,58 +13,101 @@ import (
 type ModelPricing struct {
 	PromptCostPer1M     float64 // cost per 1M prompt/input tokens
 	CompletionCostPer1M float64 // cost per 1M completion/output tokens
+
+	// Prompt cache rates. Zero means the Anthropic default relative to
+	// PromptCostPer1M: DefaultCacheWriteMultiplier for writes,
+	// DefaultCacheReadMultiplier for reads.
+	CacheWriteCostPer1M float64
+	CacheReadCostPer1M  float64
+}
+
+// Default prompt cache rates as multiples of the uncached prompt rate.
+const (
+	DefaultCacheWriteMultiplier = 1.25
+	DefaultCacheReadMultiplier  = 0.10
+)
+
+// promptCost prices usage's prompt tokens, billing the cached parts at the
+// cache rates.
+func (p ModelPricing) promptCost(usage Usage) float64 {
+	write, read := p.CacheWriteCostPer1M, p.CacheReadCostPer1M
+	if write == 0 {
+		write = p.PromptCostPer1M * DefaultCacheWriteMultiplier
+	}
+	if read == 0 {
+		read = p.PromptCostPer1M * DefaultCacheReadMultiplier
+	}
+	uncached := usage.PromptTokens - usage.CacheCreationTokens - usage.CacheReadTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+	return (float64(uncached)/1_000_000)*p.PromptCostPer1M +
+		(float64(usage.CacheCreationTokens)/1_000_000)*write +
+		(float64(usage.CacheReadTokens)/1_000_000)*read
 }
 
 // Usage mirrors provider.Usage for decoupling.
 type Usage struct {
-	PromptTokens     int
-	CompletionTokens int
-	TotalTokens      int
+	PromptTokens        int
+	CompletionTokens    int
+	TotalTokens         int
+	CacheCreationTokens int  // part of PromptTokens written to the prompt cache
+	CacheReadTokens     int  // part of PromptTokens served from the prompt cache
+	ReasoningTokens     int  // part of CompletionTokens spent on hidden reasoning
+	Estimated           bool // counts were estimated, not reported by the provider
 }
 
 // RequestRecord captures the cost of a single LLM request.
 type RequestRecord struct {
-	Timestamp        time.Time
-	Provider         string
-	Model            string
-	PromptTokens     int
-	CompletionTokens int
-	TotalTokens      int
-	EstimatedCost    float64 // in USD
-	Role             string  // which role made this request
+	Timestamp        time.Time `json:"timestamp"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	CacheCreation    int       `json:"cache_creation_tokens,omitempty"`
+	CacheRead        int       `json:"cache_read_tokens,omitempty"`
+	Reasoning        int       `json:"reasoning_tokens,omitempty"`
+	EstimatedCost    float64   `json:"estimated_cost"`      // in USD
+	Role             string    `json:"role"`                // which role made this request
+	Estimated        bool      `json:"estimated,omitempty"` // token counts were estimated
 }
 
 // Summary provides aggregate cost stats.
 type Summary struct {
-	TotalRequests         int
-	TotalTokens           int
-	TotalPromptTokens     int
-	TotalCompletionTokens int
-	TotalCost             float64
-	ByProvider            map[string]*ProviderSummary
-	ByModel               map[string]*ModelSummary
-	ByRole                map[string]*RoleSummary
+	TotalRequests         int                         `json:"total_requests"`
+	TotalTokens           int                         `json:"total_tokens"`
+	TotalPromptTokens     int                         `json:"total_prompt_tokens"`
+	TotalCompletionTokens int                         `json:"total_completion_tokens"`
+	TotalCost             float64                     `json:"total_cost"`
+	ByProvider            map[string]*ProviderSummary `json:"by_provider"`
+	ByModel               map[string]*ModelSummary    `json:"by_model"`
+	ByRole                map[string]*RoleSummary     `json:"by_role"`
+	Estimated             bool                        `json:"estimated,omitempty"` // any record was estimated
 }
 
 // ProviderSummary aggregates stats for a single provider.
 type ProviderSummary struct {
-	Requests int
-	Tokens   int
-	Cost     float64
+	Requests  int     `json:"requests"`
+	Tokens    int     `json:"tokens"`
+	Cost      float64 `json:"cost"`
+	Estimated bool    `json:"estimated,omitempty"`
 }
 
 // ModelSummary aggregates stats for a single model.
 type ModelSummary struct {
-	Requests int
-	Tokens   int
-	Cost     float64
+	Requests  int     `json:"requests"`
+	Tokens    int     `json:"tokens"`
+	Cost      float64 `json:"cost"`
+	Estimated bool    `json:"estimated,omitempty"`
 }
 
 // RoleSummary aggregates stats for a single role.
 type RoleSummary struct {
-	Requests int
-	Tokens   int
-	Cost     float64
+	Requests  int     `json:"requests"`
+	Tokens    int     `json:"tokens"`
+	Cost      float64 `json:"cost"`
+	Estimated bool    `json:"estimated,omitempty"`
 }
 
 // Tracker records LLM request costs and provides aggregated summaries.
@@ -90,11 +133,7 @@ func NewTracker(pricing map[string]ModelPricing) *Tracker {
 // stores it, and returns the record. If the model has no configured pricing,
 // EstimatedCost is 0.0.
 func (t *Tracker) Record(provider, model, role string, usage Usage) *RequestRecord {
-	var estimatedCost float64
-	if p, ok := t.pricing[model]; ok {
-		estimatedCost = (float64(usage.PromptTokens)/1_000_000)*p.PromptCostPer1M +
-			(float64(usage.CompletionTokens)/1_000_000)*p.CompletionCostPer1M
-	}
+	estimatedCost, _ := t.Price(model, usage)
 
 	rec := RequestRecord{
 		Timestamp:        time.Now(),
@@ -103,8 +142,12 @@ func (t *Tracker) Record(provider, model, role string, usage Usage) *RequestReco
 		PromptTokens:     usage.PromptTokens,
 		CompletionTokens: usage.CompletionTokens,
 		TotalTokens:      usage.TotalTokens,
+		CacheCreation:    usage.CacheCreationTokens,
+		CacheRead:        usage.CacheReadTokens,
+		Reasoning:        usage.ReasoningTokens,
 		EstimatedCost:    estimatedCost,
 		Role:             role,
+		Estimated:        usage.Estimated,
 	}
 
 	t.mu.Lock()
@@ -114,6 +157,22 @@ func (t *Tracker) Record(provider, model, role string, usage Usage) *RequestReco
 	return &rec
 }
 
+// Price returns what usage would cost on model without recording it. ok is
+// false, and the cost 0.0, when the model has no configured pricing.
+func (t *Tracker) Price(model string, usage Usage) (cost float64, ok bool) {
+	p, ok := t.pricing[model]
+	if !ok {
+		return 0, false
+	}
+	// Reasoning is billed as output. It should already be counted in
+	// CompletionTokens; never bill less than the reasoning alone.
+	output := usage.CompletionTokens
+	if usage.ReasoningTokens > output {
+		output = usage.ReasoningTokens
+	}
+	return p.promptCost(usage) + (float64(output)/1_000_000)*p.CompletionCostPer1M, true
+}
+
 // Summary returns an aggregated summary across all recorded requests.
 func (t *Tracker) Summary() *Summary {
 	t.mu.RLock()
@@ -167,6 +226,7 @@ func buildSummary(records []RequestRecord) *Summary {
 		s.TotalPromptTokens += r.PromptTokens
 		s.TotalCompletionTokens += r.CompletionTokens
 		s.TotalCost += r.EstimatedCost
+		s.Estimated = s.Estimated || r.Estimated
 
 		// Provider
 		ps, ok := s.ByProvider[r.Provider]
@@ -177,6 +237,7 @@ func buildSummary(records []RequestRecord) *Summary {
 		ps.Requests++
 		ps.Tokens += r.TotalTokens
 		ps.Cost += r.EstimatedCost
+		ps.Estimated = ps.Estimated || r.Estimated
 
 		// Model
 		ms, ok := s.ByModel[r.Model]
@@ -187,6 +248,7 @@ func buildSummary(records []RequestRecord) *Summary {
 		ms.Requests++
 		ms.Tokens += r.TotalTokens
 		ms.Cost += r.EstimatedCost
+		ms.Estimated = ms.Estimated || r.Estimated
 
 		// Role
 		rs, ok := s.ByRole[r.Role]
@@ -197,6 +259,7 @@ func buildSummary(records []RequestRecord) *Summary {
 		rs.Requests++
 		rs.Tokens += r.TotalTokens
 		rs.Cost += r.EstimatedCost
+		rs.Estimated = rs.Estimated || r.Estimated
 	}
 
 	return s
@@ -87,6 +87,21 @@ func TestRecord_NilUsage(t *testing.T) {
 	}
 }
 
+func TestPrice(t *testing.T) {
+	tr := NewTracker(testPricing())
+
+	got, ok := tr.Price("gpt-4o", Usage{PromptTokens: 1_000_000, CompletionTokens: 100_000})
+	if !ok || math.Abs(got-3.50) > 1e-9 {
+		t.Errorf("Price = %f, %v; want 3.50, true", got, ok)
+	}
+	if got, ok := tr.Price("llama3", Usage{PromptTokens: 1000}); ok || got != 0 {
+		t.Errorf("Price for unpriced model = %f, %v; want 0, false", got, ok)
+	}
+	if n := len(tr.Records()); n != 0 {
+		t.Errorf("Price recorded %d requests, want none", n)
+	}
+}
+
 func TestSummary(t *testing.T) {
 	tr := NewTracker(testPricing())
 
@@ -371,3 +386,62 @@ func TestCostCalculation(t *testing.T) {
 		t.Errorf("EstimatedCost = %.10f, want %.10f", rec3.EstimatedCost, expected3)
 	}
 }
+
+func TestCostCalculation_PromptCache(t *testing.T) {
+	tr := NewTracker(testPricing())
+
+	// 1M prompt tokens on claude-sonnet ($3.00/1M): 200k uncached, 300k
+	// written to the cache at 1.25x, 500k read from it at 0.1x.
+	// 0.6 + 1.125 + 0.15 = 1.875
+	rec := tr.Record("anthropic", "claude-sonnet-4-20250514", "polecat", Usage{
+		PromptTokens:        1_000_000,
+		TotalTokens:         1_000_000,
+		CacheCreationTokens: 300_000,
+		CacheReadTokens:     500_000,
+	})
+	if math.Abs(rec.EstimatedCost-1.875) > 1e-10 {
+		t.Errorf("EstimatedCost = %.10f, want 1.875", rec.EstimatedCost)
+	}
+	if rec.CacheCreation != 300_000 || rec.CacheRead != 500_000 {
+		t.Errorf("cache tokens = %d/%d, want 300000/500000", rec.CacheCreation, rec.CacheRead)
+	}
+
+	// Explicit cache rates override the defaults.
+	tr = NewTracker(map[string]ModelPricing{
+		"m": {PromptCostPer1M: 1.00, CacheWriteCostPer1M: 2.00, CacheReadCostPer1M: 0.50},
+	})
+	rec = tr.Record("", "m", "", Usage{
+		PromptTokens:        3_000_000,
+		CacheCreationTokens: 1_000_000,
+		CacheReadTokens:     1_000_000,
+	})
+	if math.Abs(rec.EstimatedCost-3.50) > 1e-10 {
+		t.Errorf("EstimatedCost = %.10f, want 3.50", rec.EstimatedCost)
+	}
+}
+
+func TestCostCalculation_ReasoningTokens(t *testing.T) {
+	tr := NewTracker(testPricing())
+
+	// Reasoning counted inside completion tokens is billed once.
+	rec := tr.Record("openai", "gpt-4o", "polecat", Usage{
+		CompletionTokens: 1_000_000,
+		ReasoningTokens:  800_000,
+	})
+	if math.Abs(rec.EstimatedCost-10.00) > 1e-10 {
+		t.Errorf("EstimatedCost = %.10f, want 10.00", rec.EstimatedCost)
+	}
+	if rec.Reasoning != 800_000 {
+		t.Errorf("Reasoning = %d, want 800000", rec.Reasoning)
+	}
+
+	// Reasoning exceeding completion tokens (reported outside them) is
+	// billed at least in full.
+	rec = tr.Record("openai", "gpt-4o", "polecat", Usage{
+		CompletionTokens: 100_000,
+		ReasoningTokens:  1_000_000,
+	})
+	if math.Abs(rec.EstimatedCost-10.00) > 1e-10 {
+		t.Errorf("EstimatedCost = %.10f, want 10.00", rec.EstimatedCost)
+	}
+}
@@ -0,0 +1,191 @@
+// Package diff renders line-based unified diffs, used to show what a worker
+// changes in an existing output file before it is overwritten.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines shown around each change.
+const Context = 3
+
+// maxCells bounds the LCS table. Past it, the differing middle of the two
+// texts is shown as one removal followed by one addition.
+const maxCells = 4 << 20
+
+// op is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type op struct {
+	kind byte
+	line string
+}
+
+// Unified returns a unified diff turning oldText into newText, with
+// oldName and newName in the --- and +++ headers, or "" when the texts are
+// equal.
+func Unified(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	a, b := splitLines(oldText), splitLines(newText)
+	ops := editScript(a, b)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk: changes closer
+		// than 2*Context kept lines apart share a hunk.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		end, kept := first, 0
+		for i := first; i < len(ops) && kept <= 2*Context; i++ {
+			if ops[i].kind == ' ' {
+				kept++
+			} else {
+				kept, end = 0, i+1
+			}
+		}
+		lo := max(first-Context, start)
+		hi := min(end+Context, len(ops))
+		writeHunk(&sb, ops, lo, hi)
+		start = hi
+	}
+	return sb.String()
+}
+
+// writeHunk writes ops[lo:hi] as one hunk.
+func writeHunk(sb *strings.Builder, ops []op, lo, hi int) {
+	// Line numbers are 1-based positions in each text of the hunk's
+	// first line.
+	oldLine, newLine := 1, 1
+	for _, o := range ops[:lo] {
+		if o.kind != '+' {
+			oldLine++
+		}
+		if o.kind != '-' {
+			newLine++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, o := range ops[lo:hi] {
+		if o.kind != '+' {
+			oldCount++
+		}
+		if o.kind != '-' {
+			newCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+	for _, o := range ops[lo:hi] {
+		sb.WriteByte(o.kind)
+		if line, ok := strings.CutSuffix(o.line, "\n"); ok {
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		} else {
+			sb.WriteString(line)
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a hunk header range. An empty range names the line
+// before it, as diff(1) does.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits s into lines that keep their "\n"; only the last line
+// may lack one.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editScript returns a shortest edit script from a to b, found with a
+// longest common subsequence over the lines between their common prefix
+// and suffix.
+func editScript(a, b []string) []op {
+	var ops []op
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		ops = append(ops, op{' ', a[pre]})
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	ops = append(ops, lcsScript(ma, mb)...)
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, op{' ', l})
+	}
+	return ops
+}
+
+// lcsScript diffs a and b with a dynamic-programming LCS table, or as a
+// whole replacement when the table would exceed maxCells.
+func lcsScript(a, b []string) []op {
+	n, m := len(a), len(b)
+	var ops []op
+	if n*m > maxCells {
+		for _, l := range a {
+			ops = append(ops, op{'-', l})
+		}
+		for _, l := range b {
+			ops = append(ops, op{'+', l})
+		}
+		return ops
+	}
+	// lcs[i*(m+1)+j] is the LCS length of a[i:] and b[j:].
+	w := m + 1
+	lcs := make([]int32, (n+1)*w)
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else {
+				lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, op{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,74 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numbered returns lines "1\n" through "n\n", with line k replaced by
+// edits[k] when present.
+func numbered(n int, edits map[int]string) string {
+	var sb strings.Builder
+	for k := 1; k <= n; k++ {
+		line, ok := edits[k]
+		if !ok {
+			line = strconv.Itoa(k)
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name, old, new, want string
+	}{
+		{
+			name: "added line",
+			old:  "a\nb\nc\n",
+			new:  "a\nb\nx\nc\n",
+			want: "@@ -1,3 +1,4 @@\n a\n b\n+x\n c\n",
+		},
+		{
+			name: "removed lines",
+			old:  "a\nb\nc\nd\n",
+			new:  "a\nd\n",
+			want: "@@ -1,4 +1,2 @@\n a\n-b\n-c\n d\n",
+		},
+		{
+			name: "changed line without final newline",
+			old:  "package main\n\nfunc main() {}\n",
+			new:  "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}",
+			want: "@@ -1,3 +1,5 @@\n package main\n \n-func main() {}\n+func main() {\n+\tprintln(\"hi\")\n+}\n\\ No newline at end of file\n",
+		},
+		{
+			name: "separate hunks",
+			old:  numbered(20, nil),
+			new:  numbered(20, map[int]string{2: "two", 18: "eighteen"}),
+			want: "@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n",
+		},
+		{
+			name: "new content",
+			old:  "",
+			new:  "x\n",
+			want: "@@ -0,0 +1 @@\n+x\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("a/f", "b/f", tt.old, tt.new)
+			want := "--- a/f\n+++ b/f\n" + tt.want
+			if got != want {
+				t.Errorf("Unified =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestUnified_Equal(t *testing.T) {
+	if got := Unified("a", "b", "same\n", "same\n"); got != "" {
+		t.Errorf("Unified of equal texts = %q, want empty", got)
+	}
+}
@@ -0,0 +1,141 @@
+// Package doctor checks an electrictown config for the problems new users
+// hit most often: missing auth environment variables, unreachable providers,
+// and roles whose models do not resolve. It backs "et doctor".
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// pingTimeout bounds each provider reachability check.
+const pingTimeout = 10 * time.Second
+
+// Check is the outcome of a single diagnostic.
+type Check struct {
+	Name     string // what was checked
+	OK       bool   // true when the check passed
+	Critical bool   // a failure makes the config unusable
+	Detail   string // failure reason, or extra context on success
+}
+
+// Report collects the checks from one Run.
+type Report struct {
+	Checks []Check
+}
+
+// Failed returns the number of critical checks that did not pass.
+func (r *Report) Failed() int {
+	n := 0
+	for _, c := range r.Checks {
+		if !c.OK && c.Critical {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Report) add(c Check) {
+	r.Checks = append(r.Checks, c)
+}
+
+// Run checks the raw config data: auth environment variables, config
+// validity, provider reachability via ListModels, and role resolution.
+// Later stages are skipped when the config cannot be parsed or the router
+// cannot be built. A provider that is unreachable only counts as critical
+// when it serves some role's primary model or the default model.
+func Run(ctx context.Context, data []byte, factories map[string]provider.ProviderFactory) *Report {
+	r := &Report{}
+
+	missing, err := provider.MissingAuthEnv(data)
+	if err != nil {
+		r.add(Check{Name: "config parses", Critical: true, Detail: err.Error()})
+		return r
+	}
+	if len(missing) == 0 {
+		r.add(Check{Name: "auth environment variables set", OK: true})
+	}
+	for _, name := range sortedKeys(missing) {
+		for _, v := range missing[name] {
+			r.add(Check{
+				Name:     fmt.Sprintf("provider %s: $%s set", name, v),
+				Critical: true,
+				Detail:   fmt.Sprintf("$%s is unset or empty; export it before running et", v),
+			})
+		}
+	}
+
+	cfg, err := provider.ParseConfig(data)
+	if err != nil {
+		r.add(Check{Name: "config valid", Critical: true, Detail: err.Error()})
+		return r
+	}
+	r.add(Check{Name: "config valid", OK: true})
+
+	router, err := provider.NewRouter(cfg, factories)
+	if err != nil {
+		r.add(Check{Name: "providers initialize", Critical: true, Detail: err.Error()})
+		return r
+	}
+	r.add(Check{Name: "providers initialize", OK: true})
+
+	primaries := primaryProviders(cfg, router)
+	for _, name := range sortedKeys(cfg.Providers) {
+		c := Check{Name: fmt.Sprintf("provider %s reachable", name), Critical: primaries[name]}
+		pctx, cancel := context.WithTimeout(ctx, pingTimeout)
+		start := time.Now()
+		err := router.PingProvider(pctx, name)
+		cancel()
+		if err != nil {
+			c.Detail = err.Error()
+			if !c.Critical {
+				c.Detail += " (only used as a fallback or pool member)"
+			}
+		} else {
+			c.OK = true
+			c.Detail = time.Since(start).Round(time.Millisecond).String()
+		}
+		r.add(c)
+	}
+
+	for _, role := range sortedKeys(cfg.Roles) {
+		c := Check{Name: fmt.Sprintf("role %s resolves", role), Critical: true}
+		p, model, _, err := router.ModelFor(role)
+		if err != nil {
+			c.Detail = err.Error()
+		} else {
+			c.OK = true
+			c.Detail = p + "/" + model
+		}
+		r.add(c)
+	}
+	return r
+}
+
+// primaryProviders returns the providers that serve a role's primary model,
+// as the router resolves it, or the default model.
+func primaryProviders(cfg *provider.Config, router *provider.Router) map[string]bool {
+	out := make(map[string]bool)
+	if mc, ok := cfg.Models[cfg.Defaults.Model]; ok {
+		out[mc.Provider] = true
+	}
+	for role := range cfg.Roles {
+		if p, _, _, err := router.ModelFor(role); err == nil {
+			out[p] = true
+		}
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
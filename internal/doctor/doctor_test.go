@@ -0,0 +1,161 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// mockProvider answers ListModels according to up.
+type mockProvider struct {
+	name string
+	up   bool
+}
+
+func (m *mockProvider) Name() string { return m.name }
+
+func (m *mockProvider) ChatCompletion(context.Context, *provider.ChatRequest) (*provider.ChatResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockProvider) StreamChatCompletion(context.Context, *provider.ChatRequest) (provider.ChatStream, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockProvider) ListModels(context.Context) ([]provider.Model, error) {
+	if !m.up {
+		return nil, fmt.Errorf("dial tcp: connection refused")
+	}
+	return []provider.Model{{ID: "m", Provider: m.name}}, nil
+}
+
+// factories returns provider factories keyed by type, where each type's
+// providers report up according to up[type].
+func factories(up map[string]bool) map[string]provider.ProviderFactory {
+	f := make(map[string]provider.ProviderFactory)
+	for typ, ok := range up {
+		typ, ok := typ, ok
+		f[typ] = func(provider.ProviderConfig) (provider.Provider, error) {
+			return &mockProvider{name: typ, up: ok}, nil
+		}
+	}
+	return f
+}
+
+const testConfig = `
+providers:
+  main:
+    type: mock-main
+    base_url: http://main
+  spare:
+    type: mock-spare
+    base_url: http://spare
+models:
+  big:
+    provider: main
+    model: big-model
+  small:
+    provider: spare
+    model: small-model
+roles:
+  mayor:
+    model: big
+    fallbacks: [small]
+  polecat:
+    model: big
+defaults:
+  model: big
+`
+
+func findCheck(t *testing.T, r *Report, name string) Check {
+	t.Helper()
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no check named %q in %+v", name, r.Checks)
+	return Check{}
+}
+
+func TestRun_AllPassing(t *testing.T) {
+	r := Run(context.Background(), []byte(testConfig), factories(map[string]bool{"mock-main": true, "mock-spare": true}))
+	for _, c := range r.Checks {
+		if !c.OK {
+			t.Errorf("check %q failed: %s", c.Name, c.Detail)
+		}
+	}
+	if r.Failed() != 0 {
+		t.Errorf("Failed() = %d, want 0", r.Failed())
+	}
+	if c := findCheck(t, r, "role mayor resolves"); c.Detail != "main/big-model" {
+		t.Errorf("mayor detail = %q, want main/big-model", c.Detail)
+	}
+}
+
+func TestRun_UnreachablePrimaryIsCritical(t *testing.T) {
+	r := Run(context.Background(), []byte(testConfig), factories(map[string]bool{"mock-main": false, "mock-spare": true}))
+	c := findCheck(t, r, "provider main reachable")
+	if c.OK || !c.Critical {
+		t.Errorf("main check = %+v, want critical failure", c)
+	}
+	if !findCheck(t, r, "provider spare reachable").OK {
+		t.Error("spare should be reachable")
+	}
+	if r.Failed() != 1 {
+		t.Errorf("Failed() = %d, want 1", r.Failed())
+	}
+}
+
+func TestRun_UnreachableFallbackIsWarning(t *testing.T) {
+	r := Run(context.Background(), []byte(testConfig), factories(map[string]bool{"mock-main": true, "mock-spare": false}))
+	c := findCheck(t, r, "provider spare reachable")
+	if c.OK || c.Critical {
+		t.Errorf("spare check = %+v, want non-critical failure", c)
+	}
+	if !strings.Contains(c.Detail, "fallback") {
+		t.Errorf("spare detail = %q, want mention of fallback", c.Detail)
+	}
+	if r.Failed() != 0 {
+		t.Errorf("Failed() = %d, want 0", r.Failed())
+	}
+}
+
+func TestRun_PingsProviderByName(t *testing.T) {
+	// An alias named after a provider must not redirect that provider's ping.
+	cfg := strings.Replace(testConfig, "  small:\n", "  spare:\n    provider: main\n    model: big-model\n  small:\n", 1)
+	r := Run(context.Background(), []byte(cfg), factories(map[string]bool{"mock-main": true, "mock-spare": false}))
+	if c := findCheck(t, r, "provider spare reachable"); c.OK {
+		t.Errorf("spare check = %+v, want it to ping the unreachable spare provider", c)
+	}
+}
+
+func TestRun_MissingAuthEnv(t *testing.T) {
+	t.Setenv("ET_DOCTOR_KEY", "")
+	cfg := strings.Replace(testConfig, "base_url: http://main", "base_url: http://main\n    api_key: ${ET_DOCTOR_KEY}\n    auth_type: bearer", 1)
+	r := Run(context.Background(), []byte(cfg), factories(map[string]bool{"mock-main": true, "mock-spare": true}))
+
+	c := findCheck(t, r, "provider main: $ET_DOCTOR_KEY set")
+	if c.OK || !c.Critical {
+		t.Errorf("env check = %+v, want critical failure", c)
+	}
+	if findCheck(t, r, "config valid").OK {
+		t.Error("config with unset bearer key should not be valid")
+	}
+	if r.Failed() != 2 {
+		t.Errorf("Failed() = %d, want 2", r.Failed())
+	}
+}
+
+func TestRun_InvalidYAML(t *testing.T) {
+	r := Run(context.Background(), []byte("providers: [unterminated"), nil)
+	if len(r.Checks) != 1 || r.Checks[0].OK {
+		t.Fatalf("expected a single failing check, got %+v", r.Checks)
+	}
+	if r.Failed() != 1 {
+		t.Errorf("Failed() = %d, want 1", r.Failed())
+	}
+}
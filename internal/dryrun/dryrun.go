@@ -0,0 +1,106 @@
+// Package dryrun previews the worker phase of "et run --dry-run": which pool
+// member each subtask would be routed to and a token and cost range for it,
+// without sending any worker requests.
+package dryrun
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// Completion length bounds assumed per subtask. Prompt tokens are estimated
+// from the text actually sent; completions can only be guessed.
+const (
+	MinCompletionTokens = 256
+	MaxCompletionTokens = 4096
+)
+
+// Subtask is the preview of one worker dispatch.
+type Subtask struct {
+	Index        int // 1-based
+	Subtask      string
+	Alias        string // pool member or model override it would run on
+	Model        string // model ID at the provider; empty when unresolvable
+	PromptTokens int    // estimated from the system prompt and subtask
+	MinCost      float64
+	MaxCost      float64
+	Priced       bool // the model has pricing; otherwise costs are 0
+}
+
+// Plan is the preview of a whole worker phase.
+type Plan struct {
+	Subtasks  []Subtask
+	MinTokens int
+	MaxTokens int
+	MinCost   float64
+	MaxCost   float64
+}
+
+// New previews dispatching subtasks[i] to aliases[i] with systemPrompt.
+// Aliases are resolved to model IDs through cfg and priced through tracker
+// without recording anything.
+func New(cfg *provider.Config, tracker *cost.Tracker, subtasks, aliases []string, systemPrompt string) *Plan {
+	p := &Plan{Subtasks: make([]Subtask, len(subtasks))}
+	system := provider.EstimateTokens(systemPrompt)
+	for i, st := range subtasks {
+		s := Subtask{
+			Index:        i + 1,
+			Subtask:      st,
+			Alias:        aliases[i],
+			Model:        modelID(cfg, aliases[i]),
+			PromptTokens: system + provider.EstimateTokens(st),
+		}
+		lo := cost.Usage{PromptTokens: s.PromptTokens, CompletionTokens: MinCompletionTokens}
+		hi := cost.Usage{PromptTokens: s.PromptTokens, CompletionTokens: MaxCompletionTokens}
+		s.MinCost, s.Priced = tracker.Price(s.Model, lo)
+		s.MaxCost, _ = tracker.Price(s.Model, hi)
+
+		p.Subtasks[i] = s
+		p.MinTokens += s.PromptTokens + MinCompletionTokens
+		p.MaxTokens += s.PromptTokens + MaxCompletionTokens
+		p.MinCost += s.MinCost
+		p.MaxCost += s.MaxCost
+	}
+	return p
+}
+
+// modelID resolves a model alias, or a direct "provider/model" reference, to
+// the model ID at the provider.
+func modelID(cfg *provider.Config, alias string) string {
+	if _, model, err := cfg.ResolveModel(alias); err == nil {
+		return model
+	}
+	if _, model, ok := strings.Cut(alias, "/"); ok {
+		return model
+	}
+	return ""
+}
+
+// Write prints the plan as a routing table followed by the totals.
+func (p *Plan) Write(w io.Writer) {
+	unpriced := 0
+	for _, s := range p.Subtasks {
+		model := s.Alias
+		if s.Model != "" && s.Model != s.Alias {
+			model = fmt.Sprintf("%s (%s)", s.Alias, s.Model)
+		}
+		price := "unpriced"
+		if s.Priced {
+			price = fmt.Sprintf("$%.4f–$%.4f", s.MinCost, s.MaxCost)
+		} else {
+			unpriced++
+		}
+		fmt.Fprintf(w, "  [%d] → %s: ~%d prompt tok, %s\n", s.Index, model, s.PromptTokens, price)
+	}
+	fmt.Fprintf(w, "  Estimated worker tokens: %d–%d (%d–%d completion tokens per subtask)\n",
+		p.MinTokens, p.MaxTokens, MinCompletionTokens, MaxCompletionTokens)
+	fmt.Fprintf(w, "  Estimated worker cost:   $%.4f–$%.4f", p.MinCost, p.MaxCost)
+	if unpriced > 0 {
+		fmt.Fprintf(w, " (%d subtask(s) on unpriced models counted as $0)", unpriced)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,134 @@
+package dryrun
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/pool"
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// countingProvider answers decomposition requests and records every model
+// it is asked for.
+type countingProvider struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (p *countingProvider) Name() string { return "mock" }
+
+func (p *countingProvider) ChatCompletion(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	p.mu.Lock()
+	p.calls = append(p.calls, req.Model)
+	p.mu.Unlock()
+	return &provider.ChatResponse{
+		Model:   req.Model,
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "1. write the lexer\n2. write the parser\n3. write the docs [tag: docs]"},
+		Usage:   provider.Usage{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30},
+		Done:    true,
+	}, nil
+}
+
+func (p *countingProvider) StreamChatCompletion(context.Context, *provider.ChatRequest) (provider.ChatStream, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *countingProvider) ListModels(context.Context) ([]provider.Model, error) { return nil, nil }
+
+func testConfig() *provider.Config {
+	return &provider.Config{
+		Providers: map[string]provider.ProviderConfig{"local": {Type: "mock"}},
+		Models: map[string]provider.ModelConfig{
+			"boss":  {Provider: "local", Model: "boss-model"},
+			"big":   {Provider: "local", Model: "gpt-4o"},
+			"small": {Provider: "local", Model: "llama3"},
+		},
+		Roles:    map[string]provider.RoleConfig{"mayor": {Model: "boss"}},
+		Defaults: provider.DefaultsConfig{Model: "big"},
+	}
+}
+
+// TestDryRun_NoWorkerCalls runs decomposition and the dry-run preview the
+// way "et run --dry-run" does and checks that only the supervisor was asked.
+func TestDryRun_NoWorkerCalls(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	mock := &countingProvider{}
+	router, err := provider.NewRouter(cfg, map[string]provider.ProviderFactory{
+		"mock": func(provider.ProviderConfig) (provider.Provider, error) { return mock, nil },
+	})
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	tracker := cost.NewTracker(cost.DefaultPricing())
+
+	subtasks, err := role.NewMayor(router, role.WithMayorCostTracker(tracker)).Decompose(ctx, "build a compiler")
+	if err != nil {
+		t.Fatalf("Decompose: %v", err)
+	}
+	wp := pool.New(router, provider.NewBalancer(provider.StrategyRoundRobin), []string{"big", "small"},
+		pool.WithTagRoutes(map[string]string{"docs": "small"}))
+	plan := New(cfg, tracker, subtasks, wp.Preview(subtasks, nil), "you are a worker")
+
+	if len(mock.calls) != 1 || mock.calls[0] != "boss-model" {
+		t.Fatalf("provider calls = %v, want only the supervisor's decomposition", mock.calls)
+	}
+	if n := len(tracker.Records()); n != 1 {
+		t.Errorf("tracker has %d records, want 1", n)
+	}
+
+	if len(plan.Subtasks) != 3 {
+		t.Fatalf("plan has %d subtasks, want 3", len(plan.Subtasks))
+	}
+	wantAlias := []string{"big", "small", "small"}
+	for i, s := range plan.Subtasks {
+		if s.Alias != wantAlias[i] {
+			t.Errorf("subtask %d routed to %q, want %q", i+1, s.Alias, wantAlias[i])
+		}
+	}
+
+	big := plan.Subtasks[0]
+	if big.Model != "gpt-4o" || !big.Priced || big.PromptTokens == 0 {
+		t.Errorf("subtask 1 = %+v", big)
+	}
+	if !(big.MinCost > 0 && big.MinCost < big.MaxCost) {
+		t.Errorf("subtask 1 cost range = %f–%f", big.MinCost, big.MaxCost)
+	}
+	if plan.Subtasks[1].Priced || plan.Subtasks[1].MaxCost != 0 {
+		t.Errorf("llama3 subtask should be unpriced: %+v", plan.Subtasks[1])
+	}
+	if math.Abs(plan.MaxCost-big.MaxCost) > 1e-12 {
+		t.Errorf("MaxCost = %f, want the priced subtask's %f", plan.MaxCost, big.MaxCost)
+	}
+	var prompt int
+	for _, s := range plan.Subtasks {
+		prompt += s.PromptTokens
+	}
+	if plan.MinTokens != prompt+3*MinCompletionTokens || plan.MaxTokens != prompt+3*MaxCompletionTokens {
+		t.Errorf("token range = %d–%d", plan.MinTokens, plan.MaxTokens)
+	}
+
+	var out bytes.Buffer
+	plan.Write(&out)
+	for _, want := range []string{"[1] → big (gpt-4o)", "[3] → small (llama3)", "unpriced", "Estimated worker cost", "2 subtask(s) on unpriced models"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestModelID(t *testing.T) {
+	cfg := testConfig()
+	for alias, want := range map[string]string{"big": "gpt-4o", "openai/gpt-4o-mini": "gpt-4o-mini", "nope": ""} {
+		if got := modelID(cfg, alias); got != want {
+			t.Errorf("modelID(%q) = %q, want %q", alias, got, want)
+		}
+	}
+}
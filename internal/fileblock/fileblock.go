@@ -0,0 +1,178 @@
+// Package fileblock parses worker responses into files and writes them to
+// the output directory. Workers asked for files answer with
+// ===FILE: path=== ... ===ENDFILE=== blocks, often wrapped in markdown fences
+// or surrounded by prose, which this package strips.
+package fileblock
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// File holds a single parsed file from a worker response.
+type File struct {
+	Name    string // relative path; empty means unnamed (goes to log dir)
+	Content string
+}
+
+// Parse parses a worker response into files. Handles three formats (in
+// priority order):
+//  1. Multi-file: ===FILE: path=== ... ===ENDFILE===
+//  2. Single-file legacy: FILENAME: path\n<content>
+//  3. Unnamed fallback: entire response as unnamed content
+//
+// CRLF line endings are normalized to LF first.
+func Parse(response string) []File {
+	response = strings.ReplaceAll(response, "\r\n", "\n")
+
+	// Try multi-file format first.
+	if strings.Contains(response, "===FILE:") {
+		return ParseBlocks(response)
+	}
+
+	// Try legacy single-file FILENAME: header.
+	const prefix = "FILENAME: "
+	idx := strings.Index(response, "\n")
+	if idx >= 0 {
+		firstLine := strings.TrimSpace(response[:idx])
+		if strings.HasPrefix(firstLine, prefix) {
+			name := cleanName(strings.TrimPrefix(firstLine, prefix))
+			return []File{{Name: name, Content: unfence(name, response[idx+1:])}}
+		}
+	}
+
+	// Unnamed fallback.
+	return []File{{Name: "", Content: response}}
+}
+
+// headerPattern matches a block's ===FILE: path=== header line.
+var headerPattern = regexp.MustCompile(`===FILE:\s*([^\n=]+?)===[ \t]*(?:\n|$)`)
+
+// endMarker closes a block.
+const endMarker = "===ENDFILE==="
+
+// ParseBlocks parses ===FILE: path=== ... ===ENDFILE=== blocks. Anything
+// before the first block, between blocks, or after the last ===ENDFILE===
+// is ignored. Block contents are unwrapped from a markdown fence, and a block
+// with no ===ENDFILE=== ends at the next header or at a closing fence. A
+// response with no named blocks comes back whole as one unnamed file.
+func ParseBlocks(response string) []File {
+	response = strings.ReplaceAll(response, "\r\n", "\n")
+	headers := headerPattern.FindAllStringSubmatchIndex(response, -1)
+	var files []File
+	for i, h := range headers {
+		name := cleanName(response[h[2]:h[3]])
+		if name == "" {
+			continue
+		}
+		next := len(response)
+		if i+1 < len(headers) {
+			next = headers[i+1][0]
+		}
+		content := response[h[1]:next]
+		if end := strings.Index(content, endMarker); end >= 0 {
+			content = content[:end]
+		} else if !isMarkdown(name) {
+			// An unterminated block runs to the next header or the end of
+			// the response; cut it at the fence closing a wrapped response
+			// so trailing prose stays out of the file.
+			content = cutAtClosingFence(content)
+		}
+		files = append(files, File{Name: name, Content: unfence(name, content)})
+	}
+	if len(files) == 0 {
+		return []File{{Name: "", Content: response}}
+	}
+	return files
+}
+
+// cleanName trims a block's file name of whitespace, markdown emphasis and
+// code quotes. An absolute name keeps its leading slash so that SafePath
+// refuses it rather than quietly writing it into the output directory.
+func cleanName(name string) string {
+	name = strings.TrimSpace(name)
+	return strings.Trim(name, "`*\"' ")
+}
+
+// unfence trims blank lines around content and, unless name is a markdown
+// file, removes a fence wrapping the whole content: an opening ``` line
+// (with an optional language tag) and a closing ``` line.
+func unfence(name, content string) string {
+	content = strings.TrimLeft(content, "\n")
+	content = strings.TrimRight(content, "\n\t ")
+	if isMarkdown(name) {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	if len(lines) >= 2 && isFence(lines[0]) && strings.TrimSpace(lines[len(lines)-1]) == "```" {
+		content = strings.Join(lines[1:len(lines)-1], "\n")
+		content = strings.TrimRight(content, "\n\t ")
+	}
+	return content
+}
+
+// cutAtClosingFence returns content up to its first bare ``` line that is
+// not the opening fence of the content itself.
+func cutAtClosingFence(content string) string {
+	lines := strings.Split(strings.TrimLeft(content, "\n"), "\n")
+	for i, l := range lines {
+		if strings.TrimSpace(l) != "```" {
+			continue
+		}
+		if i == 0 {
+			continue // an opening fence; unfence handles it
+		}
+		if isFence(lines[0]) {
+			// The content is fenced: keep its closing fence for unfence.
+			return strings.Join(lines[:i+1], "\n")
+		}
+		return strings.Join(lines[:i], "\n")
+	}
+	return content
+}
+
+// isFence reports whether line opens or closes a markdown code fence.
+func isFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+// isMarkdown reports whether name is a markdown file, whose fences are
+// content rather than wrapping.
+func isMarkdown(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// SafePath resolves a parsed file name against outputDir and returns the
+// resulting path. Names that are absolute, contain a ".." segment, or
+// otherwise resolve outside outputDir are refused, so a confused or
+// malicious model cannot write anywhere but the output directory.
+func SafePath(outputDir, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("fileblock: empty file name")
+	}
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) {
+		return "", fmt.Errorf("fileblock: %q is an absolute path", name)
+	}
+	for _, seg := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if seg == ".." {
+			return "", fmt.Errorf("fileblock: %q contains a \"..\" segment", name)
+		}
+	}
+	root, err := filepath.Abs(outputDir)
+	if err != nil {
+		return "", fmt.Errorf("fileblock: resolve %s: %w", outputDir, err)
+	}
+	full := filepath.Join(root, name)
+	rel, err := filepath.Rel(root, full)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("fileblock: %q resolves outside %s", name, outputDir)
+	}
+	return full, nil
+}
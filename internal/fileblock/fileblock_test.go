@@ -0,0 +1,113 @@
+package fileblock
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     []File
+	}{
+		{
+			name:     "plain blocks",
+			response: "===FILE: main.go===\npackage main\n===ENDFILE===\n===FILE: util/x.go===\npackage util\n===ENDFILE===\n",
+			want:     []File{{"main.go", "package main"}, {"util/x.go", "package util"}},
+		},
+		{
+			name:     "fenced contents",
+			response: "===FILE: main.go===\n```go\npackage main\n\nfunc main() {}\n```\n===ENDFILE===\n",
+			want:     []File{{"main.go", "package main\n\nfunc main() {}"}},
+		},
+		{
+			name:     "fenced response",
+			response: "```\n===FILE: a.py===\nprint(1)\n===ENDFILE===\n===FILE: b.py===\nprint(2)\n===ENDFILE===\n```\n",
+			want:     []File{{"a.py", "print(1)"}, {"b.py", "print(2)"}},
+		},
+		{
+			name:     "fenced response without ENDFILE",
+			response: "```text\n===FILE: a.py===\nprint(1)\n===FILE: b.py===\nprint(2)\n```\nThat's both files.",
+			want:     []File{{"a.py", "print(1)"}, {"b.py", "print(2)"}},
+		},
+		{
+			name: "prose around blocks",
+			response: "Sure! Here are the files you asked for.\n\n" +
+				"===FILE: main.go===\npackage main\n===ENDFILE===\n\n" +
+				"And the helper:\n\n" +
+				"===FILE: `util.go`===\npackage main\n===ENDFILE===\n\n" +
+				"Let me know if you need anything else. ===ENDFILE=== is the marker I used.",
+			want: []File{{"main.go", "package main"}, {"util.go", "package main"}},
+		},
+		{
+			name:     "CRLF",
+			response: "Files:\r\n===FILE: main.go===\r\n```go\r\npackage main\r\n\r\nfunc main() {}\r\n```\r\n===ENDFILE===\r\n",
+			want:     []File{{"main.go", "package main\n\nfunc main() {}"}},
+		},
+		{
+			name:     "markdown keeps its fences",
+			response: "===FILE: README.md===\n```sh\nmake\n```\n===ENDFILE===\n",
+			want:     []File{{"README.md", "```sh\nmake\n```"}},
+		},
+		{
+			name:     "legacy FILENAME header",
+			response: "FILENAME: main.go\r\n```go\r\npackage main\r\n```\r\n",
+			want:     []File{{"main.go", "package main"}},
+		},
+		{
+			name:     "unnamed",
+			response: "just some text",
+			want:     []File{{"", "just some text"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.response); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafePath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "cmd/et/main.go", "./b.go"} {
+		got, err := SafePath(dir, name)
+		if err != nil {
+			t.Errorf("SafePath(%q): %v", name, err)
+			continue
+		}
+		if want := filepath.Join(dir, name); got != want {
+			t.Errorf("SafePath(%q) = %s, want %s", name, got, want)
+		}
+	}
+	for _, name := range []string{
+		"",
+		".",
+		"..",
+		"../escape.go",
+		"../../etc/cron.d/job",
+		"src/../../escape.go",
+		"a/../b.go",
+		`..\windows\system.ini`,
+		"/etc/passwd",
+		`\\server\share\x`,
+		filepath.Join(dir, "main.go"),
+	} {
+		if got, err := SafePath(dir, name); err == nil {
+			t.Errorf("SafePath(%q) = %s, want it refused", name, got)
+		}
+	}
+}
+
+func TestParse_AbsoluteNameRefused(t *testing.T) {
+	files := Parse("===FILE: /etc/cron.d/job===\n* * * * * root true\n===ENDFILE===\n")
+	if len(files) != 1 || files[0].Name != "/etc/cron.d/job" {
+		t.Fatalf("Parse = %q, want the absolute name kept", files)
+	}
+	if got, err := SafePath(t.TempDir(), files[0].Name); err == nil {
+		t.Errorf("SafePath(%q) = %s, want it refused", files[0].Name, got)
+	}
+}
@@ -0,0 +1,95 @@
+package fileblock
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/meganerd/electrictown/internal/diff"
+	"github.com/meganerd/electrictown/internal/fileutil"
+)
+
+// Writer writes a worker's parsed files under Dir. A response with no named
+// files, or any response when Dir is empty, is logged to LogDir as
+// worker-N.out instead.
+type Writer struct {
+	Dir    string
+	LogDir string
+	// DryRun prints each file that would be written to Dir and its size
+	// without touching Dir. Unnamed output is still logged to LogDir.
+	DryRun bool
+	// Diff prints a unified diff against each existing file the worker
+	// changes, before it is written.
+	Diff bool
+	// Confirm, when set, is asked before each new or changed file is
+	// written, with the file's path; the file is kept as it is unless it
+	// returns true. Files whose content is unchanged are not asked about.
+	Confirm func(path string) bool
+	Out     io.Writer // progress lines and diffs
+	Warn    io.Writer // skipped files and failed writes
+}
+
+// Write writes worker's (0-based) files and returns the names written,
+// relative to Dir. Names SafePath refuses are skipped with a warning. In
+// DryRun mode nothing is written to Dir and the result is empty.
+func (w *Writer) Write(files []File, worker int) map[string]struct{} {
+	written := make(map[string]struct{})
+	named := 0
+	for _, f := range files {
+		if f.Name == "" || w.Dir == "" {
+			continue
+		}
+		path, err := SafePath(w.Dir, f.Name)
+		if err != nil {
+			fmt.Fprintf(w.Warn, "  warning: skipping %s: %v\n", f.Name, err)
+			continue
+		}
+		named++
+		if w.Diff || w.Confirm != nil {
+			changed := w.showChange(path, f)
+			if changed && w.Confirm != nil && !w.DryRun && !w.Confirm(filepath.Join(w.Dir, f.Name)) {
+				fmt.Fprintf(w.Out, "  → kept %s\n", filepath.Join(w.Dir, f.Name))
+				continue
+			}
+		}
+		if w.DryRun {
+			fmt.Fprintf(w.Out, "  → would write %s (%d bytes)\n", filepath.Join(w.Dir, f.Name), len(f.Content))
+			continue
+		}
+		if err := fileutil.AtomicWrite(path, []byte(f.Content), 0644); err != nil {
+			fmt.Fprintf(w.Warn, "  warning: could not write %s: %v\n", f.Name, err)
+			named--
+			continue
+		}
+		fmt.Fprintf(w.Out, "  → wrote %s\n", filepath.Join(w.Dir, f.Name))
+		written[f.Name] = struct{}{}
+	}
+	// If no named files were written (or Dir is unset), log the raw response.
+	if named == 0 && len(files) > 0 {
+		logFile := fmt.Sprintf("worker-%d.out", worker+1)
+		if err := fileutil.AtomicWrite(filepath.Join(w.LogDir, logFile), []byte(files[0].Content), 0644); err != nil {
+			fmt.Fprintf(w.Warn, "  warning: could not write log %s: %v\n", logFile, err)
+		} else {
+			fmt.Fprintf(w.Out, "  → logged %s\n", filepath.Join(w.LogDir, logFile))
+		}
+	}
+	return written
+}
+
+// showChange prints a unified diff of f against the file at path, or notes
+// that f is a new or unchanged file. It reports whether writing f would
+// change what is on disk.
+func (w *Writer) showChange(path string, f File) bool {
+	old, err := os.ReadFile(path)
+	switch {
+	case err != nil:
+		fmt.Fprintf(w.Out, "  + new file %s (%d bytes)\n", filepath.Join(w.Dir, f.Name), len(f.Content))
+		return true
+	case string(old) == f.Content:
+		fmt.Fprintf(w.Out, "  = unchanged %s\n", filepath.Join(w.Dir, f.Name))
+		return false
+	}
+	fmt.Fprint(w.Out, diff.Unified("a/"+f.Name, "b/"+f.Name, string(old), f.Content))
+	return true
+}
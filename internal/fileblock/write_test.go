@@ -0,0 +1,117 @@
+package fileblock
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriter_Write(t *testing.T) {
+	dir, logDir := t.TempDir(), t.TempDir()
+	var out, warn bytes.Buffer
+	w := &Writer{Dir: dir, LogDir: logDir, Out: &out, Warn: &warn}
+	files := Parse("===FILE: cmd/main.go===\npackage main\n===ENDFILE===\n===FILE: ../evil.go===\nx\n===ENDFILE===\n")
+
+	written := w.Write(files, 0)
+	if _, ok := written["cmd/main.go"]; !ok || len(written) != 1 {
+		t.Errorf("written = %v, want only cmd/main.go", written)
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "cmd/main.go")); err != nil || string(got) != "package main" {
+		t.Errorf("cmd/main.go = %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "evil.go")); !os.IsNotExist(err) {
+		t.Error("../evil.go was written outside the output dir")
+	}
+	if !strings.Contains(warn.String(), "skipping ../evil.go") {
+		t.Errorf("warnings = %q, want ../evil.go skipped", warn.String())
+	}
+}
+
+func TestWriter_RefusesAbsoluteName(t *testing.T) {
+	dir, logDir := t.TempDir(), t.TempDir()
+	var out, warn bytes.Buffer
+	w := &Writer{Dir: dir, LogDir: logDir, Out: &out, Warn: &warn}
+
+	written := w.Write(Parse("===FILE: /etc/cron.d/job===\n* * * * * root true\n===ENDFILE===\n"), 0)
+	if len(written) != 0 {
+		t.Errorf("written = %v, want nothing", written)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "etc", "cron.d", "job")); !os.IsNotExist(err) {
+		t.Error("/etc/cron.d/job was rewritten into the output dir")
+	}
+	if !strings.Contains(warn.String(), "skipping /etc/cron.d/job") {
+		t.Errorf("warnings = %q, want /etc/cron.d/job skipped", warn.String())
+	}
+}
+
+func TestWriter_DryRun(t *testing.T) {
+	dir, logDir := t.TempDir(), t.TempDir()
+	var out bytes.Buffer
+	w := &Writer{Dir: dir, LogDir: logDir, DryRun: true, Out: &out, Warn: &out}
+
+	written := w.Write(Parse("===FILE: main.go===\npackage main\n===ENDFILE===\n===FILE: pkg/util.go===\npackage pkg\n===ENDFILE===\n"), 0)
+	if len(written) != 0 {
+		t.Errorf("written = %v, want nothing in dry-run mode", written)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("output dir has %d entries, want none", len(entries))
+	}
+	for _, want := range []string{"would write " + filepath.Join(dir, "main.go") + " (12 bytes)", "pkg/util.go (11 bytes)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output %q does not contain %q", out.String(), want)
+		}
+	}
+
+	// A response with no named files is still logged.
+	w.Write(Parse("just prose"), 1)
+	if got, err := os.ReadFile(filepath.Join(logDir, "worker-2.out")); err != nil || string(got) != "just prose" {
+		t.Errorf("worker-2.out = %q, %v", got, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("output dir has %d entries after logging, want none", len(entries))
+	}
+}
+
+func TestWriter_DiffAndConfirm(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{"a.go": "package a\n\nvar x = 1\n", "b.go": "package a\n", "c.go": "old\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	var asked []string
+	w := &Writer{Dir: dir, LogDir: t.TempDir(), Diff: true, Out: &out, Warn: &out,
+		Confirm: func(path string) bool {
+			asked = append(asked, filepath.Base(path))
+			return filepath.Base(path) != "c.go"
+		},
+	}
+	written := w.Write([]File{
+		{"a.go", "package a\n\nvar x = 2\n"},
+		{"b.go", "package a\n"},
+		{"c.go", "new\n"},
+		{"d.go", "package a\n"},
+	}, 0)
+
+	if strings.Join(asked, " ") != "a.go c.go d.go" {
+		t.Errorf("asked about %v, want the changed and new files", asked)
+	}
+	if _, ok := written["c.go"]; ok || len(written) != 3 {
+		t.Errorf("written = %v, want a.go, b.go, and d.go", written)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "c.go")); string(got) != "old\n" {
+		t.Errorf("declined c.go was overwritten with %q", got)
+	}
+	for _, want := range []string{"--- a/a.go\n+++ b/a.go\n@@ -1,3 +1,3 @@\n package a\n \n-var x = 1\n+var x = 2\n", "unchanged " + filepath.Join(dir, "b.go"), "kept " + filepath.Join(dir, "c.go"), "new file " + filepath.Join(dir, "d.go")} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
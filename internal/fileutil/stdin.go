@@ -0,0 +1,26 @@
+package fileutil
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadPiped reads f to EOF when it is a pipe or a redirected file rather
+// than a terminal, returning the trimmed contents and true. For a terminal
+// (or a character device such as /dev/null) it reads nothing and returns
+// false, so callers can tell "nothing piped" from "empty input".
+func ReadPiped(f *os.File) (string, bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return "", false, err
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		return "", false, nil
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", true, err
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
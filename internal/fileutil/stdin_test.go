@@ -0,0 +1,52 @@
+package fileutil
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadPiped_Pipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	defer r.Close()
+	go func() {
+		w.WriteString("  refactor the parser\nand add tests\n\n")
+		w.Close()
+	}()
+
+	got, ok, err := ReadPiped(r)
+	if err != nil || !ok {
+		t.Fatalf("ReadPiped = %q, %v, %v; want piped input", got, ok, err)
+	}
+	if want := "refactor the parser\nand add tests"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadPiped_EmptyPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	defer r.Close()
+	w.Close()
+
+	got, ok, err := ReadPiped(r)
+	if err != nil || !ok || got != "" {
+		t.Errorf("ReadPiped = %q, %v, %v; want empty piped input", got, ok, err)
+	}
+}
+
+func TestReadPiped_CharDevice(t *testing.T) {
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skipf("open %s: %v", os.DevNull, err)
+	}
+	defer f.Close()
+
+	if got, ok, err := ReadPiped(f); ok || err != nil || got != "" {
+		t.Errorf("ReadPiped(%s) = %q, %v, %v; want not piped", os.DevNull, got, ok, err)
+	}
+}
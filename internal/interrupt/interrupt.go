@@ -0,0 +1,60 @@
+// Package interrupt turns the first Ctrl-C into a cancellation, so a run can
+// unwind and report what it did, and a second one into an immediate exit.
+package interrupt
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// ErrInterrupted is the cause of a context cancelled by a signal.
+var ErrInterrupted = errors.New("interrupted")
+
+// NotifyContext returns a copy of parent that is cancelled with cause
+// ErrInterrupted when one of sigs arrives. A second signal calls force,
+// which is expected to exit the process. stop releases the signals and
+// cancels the context; call it when the work is done.
+func NotifyContext(parent context.Context, force func(), sigs ...os.Signal) (ctx context.Context, stop func()) {
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, sigs...)
+	ctx, release := Watch(parent, ch, force)
+	return ctx, func() {
+		signal.Stop(ch)
+		release()
+	}
+}
+
+// Watch is NotifyContext for signals delivered on ch, so callers and tests
+// can supply their own.
+func Watch(parent context.Context, ch <-chan os.Signal, force func()) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ch:
+			cancel(ErrInterrupted)
+		case <-done:
+			return
+		}
+		select {
+		case <-ch:
+			force()
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			close(done)
+			cancel(nil)
+		})
+	}
+}
+
+// Interrupted reports whether ctx was cancelled by a signal.
+func Interrupted(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrInterrupted)
+}
@@ -0,0 +1,66 @@
+package interrupt
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWatch_FirstSignalCancels(t *testing.T) {
+	ch := make(chan os.Signal, 2)
+	forced := make(chan struct{})
+	ctx, stop := Watch(context.Background(), ch, func() { close(forced) })
+	defer stop()
+
+	if Interrupted(ctx) {
+		t.Fatal("interrupted before any signal")
+	}
+	ch <- os.Interrupt
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context not cancelled by the signal")
+	}
+	if !Interrupted(ctx) || context.Cause(ctx) != ErrInterrupted {
+		t.Errorf("cause = %v, want ErrInterrupted", context.Cause(ctx))
+	}
+	select {
+	case <-forced:
+		t.Error("first signal forced an exit")
+	default:
+	}
+
+	ch <- os.Interrupt
+	select {
+	case <-forced:
+	case <-time.After(time.Second):
+		t.Fatal("second signal did not force an exit")
+	}
+}
+
+func TestWatch_StopWithoutSignal(t *testing.T) {
+	ch := make(chan os.Signal, 2)
+	ctx, stop := Watch(context.Background(), ch, func() { t.Error("forced without a signal") })
+	stop()
+	stop() // idempotent
+
+	<-ctx.Done()
+	if Interrupted(ctx) {
+		t.Error("stop reported as an interrupt")
+	}
+	ch <- os.Interrupt // nobody is watching any more
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestWatch_ParentCancelled(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, stop := Watch(parent, make(chan os.Signal), func() {})
+	defer stop()
+
+	cancel()
+	<-ctx.Done()
+	if Interrupted(ctx) {
+		t.Error("parent cancellation reported as an interrupt")
+	}
+}
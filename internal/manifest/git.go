@@ -0,0 +1,43 @@
+package manifest
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// GitInfo captures the repository state a run operated on.
+type GitInfo struct {
+	Commit string `json:"commit,omitempty"` // HEAD SHA; empty before the first commit
+	Branch string `json:"branch,omitempty"` // empty when HEAD is detached
+	Dirty  bool   `json:"dirty"`            // uncommitted or untracked changes present
+}
+
+// ReadGit returns the git state of the working tree containing dir, or nil
+// when git is not installed or dir is not inside a git work tree.
+func ReadGit(ctx context.Context, dir string) *GitInfo {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil
+	}
+	if out, err := gitOutput(ctx, dir, "rev-parse", "--is-inside-work-tree"); err != nil || out != "true" {
+		return nil
+	}
+
+	info := &GitInfo{}
+	info.Commit, _ = gitOutput(ctx, dir, "rev-parse", "--verify", "-q", "HEAD")
+	info.Branch, _ = gitOutput(ctx, dir, "symbolic-ref", "--short", "-q", "HEAD")
+	if status, err := gitOutput(ctx, dir, "status", "--porcelain"); err == nil {
+		info.Dirty = status != ""
+	}
+	return info
+}
+
+// gitOutput runs git in dir and returns its trimmed stdout.
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,76 @@
+// Package manifest records per-run metadata (what ran, where, and against
+// which repository state) as a JSON file in the run log directory.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/fileutil"
+)
+
+// FileName is the manifest file written into each run log directory.
+const FileName = "_manifest.json"
+
+// Manifest describes a single et run.
+type Manifest struct {
+	RunID     string    `json:"run_id"`
+	Version   string    `json:"version"`
+	Task      string    `json:"task"`
+	Config    string    `json:"config"`
+	OutputDir string    `json:"output_dir,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Git       *GitInfo  `json:"git,omitempty"` // nil when disabled or not a git repo
+
+	// ResumedFrom is the ID of the run this one continued via --resume.
+	ResumedFrom string `json:"resumed_from,omitempty"`
+
+	// ReplayOf is the ID of the run whose worker results "et replay"
+	// synthesized again.
+	ReplayOf string `json:"replay_of,omitempty"`
+
+	// Reliability holds per-model worker outcomes, filled in when a pooled
+	// run finishes.
+	Reliability []ModelReliability `json:"reliability,omitempty"`
+}
+
+// ModelReliability records how one model alias behaved over a run. It
+// mirrors pool.ModelReliability.
+type ModelReliability struct {
+	Alias     string        `json:"alias"`
+	Attempts  int           `json:"attempts"`
+	Successes int           `json:"successes"`
+	Retryable int           `json:"retryable_failures"`
+	Timeouts  int           `json:"timeouts"`
+	Empty     int           `json:"empty_or_refused"`
+	Failures  int           `json:"other_failures"`
+	Latency   time.Duration `json:"total_latency_ns"`
+}
+
+// Write stores the manifest as dir/_manifest.json.
+func (m *Manifest) Write(dir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("manifest: encode: %w", err)
+	}
+	if err := fileutil.AtomicWrite(filepath.Join(dir, FileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("manifest: write: %w", err)
+	}
+	return nil
+}
+
+// Load reads a manifest previously written by Write.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("manifest: read %s: %w", path, err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("manifest: parse %s: %w", path, err)
+	}
+	return &m, nil
+}
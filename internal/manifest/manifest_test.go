@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// initRepo creates a git repository in a temp dir with one commit on branch
+// "main" and returns its path. Skips the test when git is unavailable.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "README")
+	run("commit", "-q", "-m", "initial")
+	return dir
+}
+
+func TestReadGit_Repo(t *testing.T) {
+	dir := initRepo(t)
+
+	want, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("rev-parse: %v", err)
+	}
+
+	info := ReadGit(context.Background(), dir)
+	if info == nil {
+		t.Fatal("expected git info for a git repo")
+	}
+	if info.Commit != string(want[:len(want)-1]) {
+		t.Errorf("Commit = %q, want %q", info.Commit, want)
+	}
+	if info.Branch != "main" {
+		t.Errorf("Branch = %q, want main", info.Branch)
+	}
+	if info.Dirty {
+		t.Error("expected clean work tree")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info := ReadGit(context.Background(), dir); info == nil || !info.Dirty {
+		t.Errorf("expected dirty work tree after adding a file, got %+v", info)
+	}
+}
+
+func TestReadGit_NotARepo(t *testing.T) {
+	if info := ReadGit(context.Background(), t.TempDir()); info != nil {
+		t.Errorf("expected nil git info for non-git dir, got %+v", info)
+	}
+}
+
+func TestWriteLoad(t *testing.T) {
+	dir := t.TempDir()
+	m := &Manifest{
+		RunID:     "abc123",
+		Version:   "dev",
+		Task:      "build a thing",
+		Config:    "/etc/electrictown.yaml",
+		StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Git:       &GitInfo{Commit: "deadbeef", Branch: "main", Dirty: true},
+	}
+	if err := m.Write(dir); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	got, err := Load(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.RunID != m.RunID || got.Task != m.Task || !got.StartedAt.Equal(m.StartedAt) {
+		t.Errorf("round trip mismatch: got %+v", got)
+	}
+	if got.Git == nil || *got.Git != *m.Git {
+		t.Errorf("Git = %+v, want %+v", got.Git, m.Git)
+	}
+
+	// Without git metadata the field is omitted entirely.
+	m.Git = nil
+	if err := m.Write(dir); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err = Load(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Git != nil {
+		t.Errorf("expected nil Git, got %+v", got.Git)
+	}
+}
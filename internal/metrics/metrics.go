@@ -0,0 +1,252 @@
+// Package metrics exposes electrictown's request, token, cost, and fallback
+// counters in the Prometheus text exposition format, for services that embed
+// the router and want to scrape it. Request and latency metrics come from the
+// router's trace hooks; token and cost metrics from a cost.Tracker.
+//
+//	m := metrics.New(tracker)
+//	router, err := provider.NewRouter(cfg, factories, provider.WithTraceHook(m.Observe))
+//	http.Handle("/metrics", m)
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// LatencyBuckets are the upper bounds, in seconds, of the request duration
+// histogram. LLM requests run from well under a second to minutes.
+var LatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Collector accumulates router trace events and renders them, with the cost
+// tracker's totals, as Prometheus metrics. It is an http.Handler serving the
+// exposition, and is safe for concurrent use.
+type Collector struct {
+	tracker *cost.Tracker // nil = no token or cost metrics
+
+	mu        sync.Mutex
+	requests  map[requestKey]float64
+	inFlight  map[string]float64 // by provider
+	latency   map[string]*histogram
+	fallbacks map[fallbackKey]float64
+	retries   map[string]float64 // by role
+}
+
+type requestKey struct{ provider, model, code string }
+
+type fallbackKey struct{ role, code string }
+
+type histogram struct {
+	counts []float64 // per LatencyBuckets bound, not cumulative
+	sum    float64
+	total  float64
+}
+
+// New returns a Collector reading token and cost totals from tracker, which
+// may be nil.
+func New(tracker *cost.Tracker) *Collector {
+	return &Collector{
+		tracker:   tracker,
+		requests:  make(map[requestKey]float64),
+		inFlight:  make(map[string]float64),
+		latency:   make(map[string]*histogram),
+		fallbacks: make(map[fallbackKey]float64),
+		retries:   make(map[string]float64),
+	}
+}
+
+// Observe records a router trace event. Pass it to provider.WithTraceHook.
+func (c *Collector) Observe(ev provider.TraceEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch ev.Kind {
+	case provider.TraceRequestStart:
+		c.inFlight[ev.Provider]++
+	case provider.TraceRequestEnd:
+		c.inFlight[ev.Provider]--
+		code := "ok"
+		if ev.Err != nil {
+			code = string(ev.Code)
+		}
+		c.requests[requestKey{ev.Provider, ev.Model, code}]++
+		h := c.latency[ev.Provider]
+		if h == nil {
+			h = &histogram{counts: make([]float64, len(LatencyBuckets))}
+			c.latency[ev.Provider] = h
+		}
+		h.observe(ev.Latency.Seconds())
+	case provider.TraceFallback:
+		c.fallbacks[fallbackKey{ev.Role, string(ev.Code)}]++
+	case provider.TraceRetry:
+		c.retries[ev.Role]++
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range LatencyBuckets {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.total++
+}
+
+// ServeHTTP writes the current metrics in the text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_ = c.WriteText(w) // the client went away; nothing to do
+}
+
+// WriteText writes the current metrics in the text exposition format. Series
+// are sorted by label values, so the output is stable.
+func (c *Collector) WriteText(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	c.writeRouter(w)
+	if c.tracker != nil {
+		writeCost(w, c.tracker.Records())
+	}
+	return w.Flush()
+}
+
+func (c *Collector) writeRouter(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	family(w, "electrictown_requests_total", "counter", "Requests sent to providers, by outcome (\"ok\" or the error class).")
+	for _, k := range sortedKeys(c.requests, func(k requestKey) string { return k.provider + "\x00" + k.model + "\x00" + k.code }) {
+		sample(w, "electrictown_requests_total", labels("provider", k.provider, "model", k.model, "code", k.code), c.requests[k])
+	}
+
+	family(w, "electrictown_requests_in_flight", "gauge", "Requests sent to providers and not yet answered.")
+	for _, p := range sortedKeys(c.inFlight, func(p string) string { return p }) {
+		sample(w, "electrictown_requests_in_flight", labels("provider", p), c.inFlight[p])
+	}
+
+	family(w, "electrictown_request_duration_seconds", "histogram", "Provider request latency; for streams, the time to open the stream.")
+	for _, p := range sortedKeys(c.latency, func(p string) string { return p }) {
+		h := c.latency[p]
+		cumulative := 0.0
+		for i, bound := range LatencyBuckets {
+			cumulative += h.counts[i]
+			sample(w, "electrictown_request_duration_seconds_bucket", labels("provider", p, "le", formatFloat(bound)), cumulative)
+		}
+		sample(w, "electrictown_request_duration_seconds_bucket", labels("provider", p, "le", "+Inf"), h.total)
+		sample(w, "electrictown_request_duration_seconds_sum", labels("provider", p), h.sum)
+		sample(w, "electrictown_request_duration_seconds_count", labels("provider", p), h.total)
+	}
+
+	family(w, "electrictown_fallbacks_total", "counter", "Fallback hops after a failed request, by role and the error class that caused them.")
+	for _, k := range sortedKeys(c.fallbacks, func(k fallbackKey) string { return k.role + "\x00" + k.code }) {
+		sample(w, "electrictown_fallbacks_total", labels("role", k.role, "code", k.code), c.fallbacks[k])
+	}
+
+	family(w, "electrictown_retries_total", "counter", "Retried requests, by role.")
+	for _, r := range sortedKeys(c.retries, func(r string) string { return r }) {
+		sample(w, "electrictown_retries_total", labels("role", r), c.retries[r])
+	}
+}
+
+// costKey identifies a cost series.
+type costKey struct{ provider, model, role string }
+
+type costTotals struct {
+	requests, prompt, completion, cost float64
+}
+
+// writeCost renders the tracker's records, totalled by provider, model, and
+// role.
+func writeCost(w *bufio.Writer, records []cost.RequestRecord) {
+	totals := make(map[costKey]*costTotals)
+	for _, r := range records {
+		k := costKey{r.Provider, r.Model, r.Role}
+		t := totals[k]
+		if t == nil {
+			t = &costTotals{}
+			totals[k] = t
+		}
+		t.requests++
+		t.prompt += float64(r.PromptTokens)
+		t.completion += float64(r.CompletionTokens)
+		t.cost += r.EstimatedCost
+	}
+	keys := sortedKeys(totals, func(k costKey) string { return k.provider + "\x00" + k.model + "\x00" + k.role })
+
+	family(w, "electrictown_tracked_requests_total", "counter", "Requests recorded by the cost tracker.")
+	for _, k := range keys {
+		sample(w, "electrictown_tracked_requests_total", labels("provider", k.provider, "model", k.model, "role", k.role), totals[k].requests)
+	}
+	family(w, "electrictown_tokens_total", "counter", "Tokens used, by direction (\"prompt\" or \"completion\").")
+	for _, k := range keys {
+		sample(w, "electrictown_tokens_total", labels("provider", k.provider, "model", k.model, "role", k.role, "direction", "prompt"), totals[k].prompt)
+		sample(w, "electrictown_tokens_total", labels("provider", k.provider, "model", k.model, "role", k.role, "direction", "completion"), totals[k].completion)
+	}
+	family(w, "electrictown_cost_usd_total", "counter", "Estimated cost in USD; models without pricing count as free.")
+	for _, k := range keys {
+		sample(w, "electrictown_cost_usd_total", labels("provider", k.provider, "model", k.model, "role", k.role), totals[k].cost)
+	}
+}
+
+func family(w *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+}
+
+func sample(w *bufio.Writer, name, labels string, v float64) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatFloat(v))
+}
+
+// labels renders name/value pairs as a label set.
+func labels(pairs ...string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns m's keys ordered by the string key(k).
+func sortedKeys[K comparable, V any](m map[K]V, key func(K) string) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return key(keys[i]) < key(keys[j]) })
+	return keys
+}
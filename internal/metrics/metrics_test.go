@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// flakyProvider fails every request with err, or answers when err is nil.
+type flakyProvider struct {
+	name string
+	err  error
+}
+
+func (p *flakyProvider) Name() string { return p.name }
+
+func (p *flakyProvider) ChatCompletion(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &provider.ChatResponse{Model: req.Model, Usage: provider.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}}, nil
+}
+
+func (p *flakyProvider) StreamChatCompletion(context.Context, *provider.ChatRequest) (provider.ChatStream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *flakyProvider) ListModels(context.Context) ([]provider.Model, error) { return nil, nil }
+
+func newTestRouter(t *testing.T, m *Collector) *provider.Router {
+	t.Helper()
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{
+			"down": {Type: "down"},
+			"up":   {Type: "up"},
+		},
+		Models: map[string]provider.ModelConfig{
+			"primary": {Provider: "down", Model: "big"},
+			"backup":  {Provider: "up", Model: "small"},
+		},
+		Roles: map[string]provider.RoleConfig{
+			"worker": {Model: "primary", Fallbacks: provider.Fallbacks("backup")},
+		},
+		Defaults: provider.DefaultsConfig{Model: "primary"},
+	}
+	factories := map[string]provider.ProviderFactory{
+		"down": func(provider.ProviderConfig) (provider.Provider, error) {
+			return &flakyProvider{name: "down", err: &provider.APIError{Status: 503, Message: "overloaded"}}, nil
+		},
+		"up": func(provider.ProviderConfig) (provider.Provider, error) { return &flakyProvider{name: "up"}, nil },
+	}
+	r, err := provider.NewRouter(cfg, factories, provider.WithTraceHook(m.Observe))
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r
+}
+
+func TestCollector_Exposition(t *testing.T) {
+	pricing := map[string]cost.ModelPricing{"small": {PromptCostPer1M: 1, CompletionCostPer1M: 5}}
+	tracker := cost.NewTracker(pricing)
+	m := New(tracker)
+	router := newTestRouter(t, m)
+
+	req := &provider.ChatRequest{Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	resp, err := router.ChatCompletionForRole(context.Background(), "worker", req)
+	if err != nil {
+		t.Fatalf("ChatCompletionForRole: %v", err)
+	}
+	tracker.Record("up", resp.Model, "worker", cost.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120})
+	m.Observe(provider.TraceEvent{Kind: provider.TraceRetry, Role: "worker", Attempt: 1})
+
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+	res, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentType)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	out := string(body)
+
+	for _, family := range []string{
+		"# TYPE electrictown_requests_total counter",
+		"# TYPE electrictown_requests_in_flight gauge",
+		"# TYPE electrictown_request_duration_seconds histogram",
+		"# TYPE electrictown_fallbacks_total counter",
+		"# TYPE electrictown_retries_total counter",
+		"# TYPE electrictown_tracked_requests_total counter",
+		"# TYPE electrictown_tokens_total counter",
+		"# TYPE electrictown_cost_usd_total counter",
+	} {
+		if !strings.Contains(out, family+"\n") {
+			t.Errorf("exposition missing %q", family)
+		}
+	}
+	for _, series := range []string{
+		`electrictown_requests_total{provider="down",model="big",code="server_error"} 1`,
+		`electrictown_requests_total{provider="up",model="small",code="ok"} 1`,
+		`electrictown_requests_in_flight{provider="up"} 0`,
+		`electrictown_request_duration_seconds_bucket{provider="up",le="+Inf"} 1`,
+		`electrictown_request_duration_seconds_count{provider="down"} 1`,
+		`electrictown_fallbacks_total{role="worker",code="server_error"} 1`,
+		`electrictown_retries_total{role="worker"} 1`,
+		`electrictown_tokens_total{provider="up",model="small",role="worker",direction="prompt"} 100`,
+		`electrictown_tokens_total{provider="up",model="small",role="worker",direction="completion"} 20`,
+		`electrictown_cost_usd_total{provider="up",model="small",role="worker"} 0.0002`,
+	} {
+		if !strings.Contains(out, series+"\n") {
+			t.Errorf("exposition missing %q:\n%s", series, out)
+		}
+	}
+}
+
+func TestCollector_HistogramBuckets(t *testing.T) {
+	m := New(nil)
+	for _, d := range []time.Duration{50 * time.Millisecond, 700 * time.Millisecond, 10 * time.Minute} {
+		m.Observe(provider.TraceEvent{Kind: provider.TraceRequestStart, Provider: "p"})
+		m.Observe(provider.TraceEvent{Kind: provider.TraceRequestEnd, Provider: "p", Model: "m", Latency: d})
+	}
+	var b strings.Builder
+	if err := m.WriteText(&b); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	out := b.String()
+	for _, series := range []string{
+		`electrictown_request_duration_seconds_bucket{provider="p",le="0.1"} 1`,
+		`electrictown_request_duration_seconds_bucket{provider="p",le="0.5"} 1`,
+		`electrictown_request_duration_seconds_bucket{provider="p",le="1"} 2`,
+		`electrictown_request_duration_seconds_bucket{provider="p",le="300"} 2`,
+		`electrictown_request_duration_seconds_bucket{provider="p",le="+Inf"} 3`,
+		`electrictown_request_duration_seconds_sum{provider="p"} 600.75`,
+	} {
+		if !strings.Contains(out, series+"\n") {
+			t.Errorf("exposition missing %q:\n%s", series, out)
+		}
+	}
+	if strings.Contains(out, "electrictown_tokens_total") {
+		t.Error("token metrics rendered without a tracker")
+	}
+}
+
+func TestLabels_Escaped(t *testing.T) {
+	got := labels("model", "a\"b\\c\nd")
+	if want := `{model="a\"b\\c\nd"}`; got != want {
+		t.Errorf("labels = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package nodes
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// Gap is a role model that no reachable Ollama node has pulled.
+type Gap struct {
+	Role     string // role name from config
+	Alias    string // model alias
+	Model    string // Ollama model name the alias resolves to
+	Fallback bool   // true when the alias is a fallback rather than the primary
+}
+
+// FindGaps cross-references each configured role's primary and fallback
+// models against the inventories of reachable nodes. Models served by
+// non-Ollama providers are skipped. Gaps are sorted by role, with each
+// role's primary listed before its fallbacks.
+func FindGaps(cfg *provider.Config, statuses []Status) []Gap {
+	available := make(map[string]bool)
+	for _, st := range statuses {
+		if !st.Online {
+			continue
+		}
+		for _, m := range st.Models {
+			available[normalizeModel(m)] = true
+		}
+	}
+
+	roles := make([]string, 0, len(cfg.Roles))
+	for name := range cfg.Roles {
+		roles = append(roles, name)
+	}
+	sort.Strings(roles)
+
+	var gaps []Gap
+	for _, role := range roles {
+		rc := cfg.Roles[role]
+		check := func(alias string, fallback bool) {
+			mc, ok := cfg.Models[alias]
+			if !ok || cfg.Providers[mc.Provider].Type != "ollama" {
+				return
+			}
+			if !available[normalizeModel(mc.Model)] {
+				gaps = append(gaps, Gap{Role: role, Alias: alias, Model: mc.Model, Fallback: fallback})
+			}
+		}
+		check(rc.Model, false)
+		for _, fb := range rc.Fallbacks {
+			check(fb.Model, true)
+		}
+	}
+	return gaps
+}
+
+// normalizeModel adds Ollama's implicit ":latest" tag so that "llama3" and
+// "llama3:latest" compare equal.
+func normalizeModel(name string) string {
+	if !strings.Contains(name, ":") {
+		return name + ":latest"
+	}
+	return name
+}
@@ -0,0 +1,178 @@
+// Package nodes probes configured Ollama nodes for reachability and the
+// models they have pulled, and renders the results for "et nodes".
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/provider/ollama"
+	"github.com/meganerd/electrictown/internal/textutil"
+)
+
+// DefaultBaseURL is used for Ollama providers configured without a base_url.
+const DefaultBaseURL = "http://localhost:11434"
+
+// Status is the result of probing a single node.
+type Status struct {
+	Name    string                // provider name from config
+	URL     string                // base URL probed
+	Online  bool                  // true when /api/tags answered with 200
+	Err     string                // short failure description when not online
+	Models  []string              // pulled model names
+	Latency time.Duration         // round trip of the /api/tags request
+	Loaded  []ollama.RunningModel // models resident in memory, from /api/ps
+}
+
+// HasModel reports whether model has been pulled on the node, treating a
+// missing tag as ":latest".
+func (st Status) HasModel(model string) bool {
+	want := normalizeModel(model)
+	for _, m := range st.Models {
+		if normalizeModel(m) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadedModel reports whether model is resident on the node, and its details.
+func (st Status) LoadedModel(model string) (ollama.RunningModel, bool) {
+	for _, rm := range st.Loaded {
+		if rm.Name == model {
+			return rm, true
+		}
+	}
+	return ollama.RunningModel{}, false
+}
+
+// tagsResponse is the JSON payload from GET /api/tags.
+type tagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// Probe queries baseURL/api/tags and reports reachability, models, and latency.
+func Probe(ctx context.Context, client *http.Client, name, baseURL string) Status {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	st := Status{Name: name, URL: baseURL}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/api/tags", nil)
+	if err != nil {
+		st.Err = trimErr(err)
+		return st
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	st.Latency = time.Since(start)
+	if err != nil {
+		st.Err = "offline (" + trimErr(err) + ")"
+		return st
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		st.Err = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		return st
+	}
+	var tags tagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		st.Err = "parse error: " + trimErr(err)
+		return st
+	}
+	st.Online = true
+	for _, m := range tags.Models {
+		st.Models = append(st.Models, m.Name)
+	}
+
+	// Older Ollama servers have no /api/ps; treat that as nothing loaded.
+	ps := ollama.New(baseURL, "", ollama.WithAuthType("none"), ollama.WithHTTPClient(client))
+	if loaded, err := ps.RunningModels(ctx); err == nil {
+		st.Loaded = loaded
+	}
+	return st
+}
+
+// ProbeAll probes every Ollama provider in cfg concurrently and returns the
+// results sorted by provider name.
+func ProbeAll(ctx context.Context, client *http.Client, cfg *provider.Config) []Status {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		out []Status
+	)
+	for name, pc := range cfg.Providers {
+		if pc.Type != "ollama" {
+			continue
+		}
+		wg.Add(1)
+		go func(name, baseURL string) {
+			defer wg.Done()
+			st := Probe(ctx, client, name, baseURL)
+			mu.Lock()
+			out = append(out, st)
+			mu.Unlock()
+		}(name, pc.BaseURL)
+	}
+	wg.Wait()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// WatchTable renders statuses as one line per node for the live --watch view:
+// name, URL, reachability, latency, loaded model count, and a model count with
+// the first names.
+func WatchTable(statuses []Status) []string {
+	lines := []string{
+		fmt.Sprintf("%-20s %-32s %-8s %8s %6s  %s", "NODE", "URL", "STATUS", "LATENCY", "LOADED", "MODELS"),
+		fmt.Sprintf("%-20s %-32s %-8s %8s %6s  %s", "----", "---", "------", "-------", "------", "------"),
+	}
+	for _, st := range statuses {
+		status, latency, loaded, models := "✓ up", formatLatency(st.Latency), "-", ""
+		if st.Online {
+			loaded = fmt.Sprintf("%d", len(st.Loaded))
+			models = fmt.Sprintf("%d", len(st.Models))
+			if len(st.Models) > 0 {
+				models += " (" + textutil.Truncate(strings.Join(st.Models, ", "), 60) + ")"
+			}
+		} else {
+			status = "✗ down"
+			models = st.Err
+			if st.Latency == 0 {
+				latency = "-"
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%-20s %-32s %-8s %8s %6s  %s",
+			textutil.Truncate(st.Name, 20), textutil.Truncate(st.URL, 32), status, latency, loaded, models))
+	}
+	return lines
+}
+
+// FormatBytes prints a byte count in GB or MB, as Ollama reports model sizes.
+func FormatBytes(n int64) string {
+	const mb, gb = 1 << 20, 1 << 30
+	if n >= gb {
+		return fmt.Sprintf("%.1f GB", float64(n)/gb)
+	}
+	return fmt.Sprintf("%d MB", n/mb)
+}
+
+// formatLatency prints a latency in whole milliseconds.
+func formatLatency(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// trimErr shortens common connection error messages for table display.
+func trimErr(err error) string {
+	return textutil.Truncate(err.Error(), 60)
+}
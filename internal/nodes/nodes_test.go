@@ -0,0 +1,210 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/provider/ollama"
+)
+
+func TestWatchTable(t *testing.T) {
+	statuses := []Status{
+		{Name: "ai01", URL: "http://ai01:11434", Online: true, Models: []string{"qwen3:32b", "llama3:8b"}, Latency: 12 * time.Millisecond,
+			Loaded: []ollama.RunningModel{{Name: "qwen3:32b"}}},
+		{Name: "phoenix", URL: "http://phoenix:11434", Online: true, Latency: 3 * time.Millisecond},
+		{Name: "rk3588", URL: "http://rk3588:11434", Err: "offline (connection refused)"},
+	}
+	lines := WatchTable(statuses)
+
+	if len(lines) != 5 {
+		t.Fatalf("expected header, separator and 3 rows, got %d lines:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	if !strings.HasPrefix(lines[0], "NODE") || !strings.Contains(lines[0], "LATENCY") {
+		t.Errorf("unexpected header: %q", lines[0])
+	}
+
+	checks := []struct {
+		line int
+		want []string
+	}{
+		{2, []string{"ai01", "✓ up", "12ms", "     1  2 (qwen3:32b, llama3:8b)"}},
+		{3, []string{"phoenix", "✓ up", "3ms", " 0"}},
+		{4, []string{"rk3588", "✗ down", " -", "connection refused"}},
+	}
+	for _, c := range checks {
+		for _, w := range c.want {
+			if !strings.Contains(lines[c.line], w) {
+				t.Errorf("line %d = %q, missing %q", c.line, lines[c.line], w)
+			}
+		}
+	}
+}
+
+func TestWatchTable_TruncatesLongModelLists(t *testing.T) {
+	models := make([]string, 20)
+	for i := range models {
+		models[i] = "some-very-long-model-name:latest"
+	}
+	lines := WatchTable([]Status{{Name: "n", URL: "u", Online: true, Models: models}})
+	if !strings.Contains(lines[2], "20 (") || !strings.HasSuffix(lines[2], "...)") {
+		t.Errorf("expected truncated model list, got %q", lines[2])
+	}
+}
+
+func TestProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			json.NewEncoder(w).Encode(map[string]any{
+				"models": []map[string]string{{"name": "qwen3:32b"}, {"name": "llama3:8b"}},
+			})
+		case "/api/ps":
+			w.Write([]byte(`{"models":[{"name":"qwen3:32b","size":21474836480,"size_vram":21474836480,"expires_at":"2025-06-04T14:38:31Z"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	st := Probe(context.Background(), server.Client(), "local", server.URL)
+	if !st.Online || st.Err != "" {
+		t.Fatalf("expected online, got %+v", st)
+	}
+	if len(st.Models) != 2 || st.Models[0] != "qwen3:32b" {
+		t.Errorf("Models = %v", st.Models)
+	}
+	rm, ok := st.LoadedModel("qwen3:32b")
+	if !ok || rm.SizeVRAM != 21474836480 {
+		t.Errorf("expected qwen3:32b loaded with 20 GB VRAM, got %+v (ok=%v)", rm, ok)
+	}
+	if _, ok := st.LoadedModel("llama3:8b"); ok {
+		t.Error("llama3:8b should be cold")
+	}
+}
+
+func TestProbe_NoPsEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"models": []map[string]string{{"name": "qwen3:32b"}}})
+	}))
+	defer server.Close()
+
+	st := Probe(context.Background(), server.Client(), "old", server.URL)
+	if !st.Online || len(st.Loaded) != 0 {
+		t.Errorf("expected online with nothing loaded, got %+v", st)
+	}
+}
+
+func TestStatusHasModel(t *testing.T) {
+	st := Status{Models: []string{"llama3:latest", "qwen3:8b"}}
+	if !st.HasModel("llama3") || !st.HasModel("qwen3:8b") {
+		t.Error("expected llama3 (implicit :latest) and qwen3:8b to be present")
+	}
+	if st.HasModel("qwen3:32b") {
+		t.Error("qwen3:32b should not be present")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	if got := FormatBytes(21474836480); got != "20.0 GB" {
+		t.Errorf("FormatBytes(20 GiB) = %q", got)
+	}
+	if got := FormatBytes(512 << 20); got != "512 MB" {
+		t.Errorf("FormatBytes(512 MiB) = %q", got)
+	}
+}
+
+func TestProbeAll_SkipsNonOllamaAndReportsOffline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := &provider.Config{Providers: map[string]provider.ProviderConfig{
+		"b-node": {Type: "ollama", BaseURL: server.URL},
+		"a-node": {Type: "ollama", BaseURL: "http://127.0.0.1:1"},
+		"cloud":  {Type: "openai", BaseURL: "https://api.openai.com/v1"},
+	}}
+	got := ProbeAll(context.Background(), &http.Client{Timeout: time.Second}, cfg)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 ollama nodes, got %d", len(got))
+	}
+	if got[0].Name != "a-node" || got[1].Name != "b-node" {
+		t.Errorf("expected results sorted by name, got %s, %s", got[0].Name, got[1].Name)
+	}
+	if got[0].Online || !strings.HasPrefix(got[0].Err, "offline") {
+		t.Errorf("a-node should be offline, got %+v", got[0])
+	}
+	if got[1].Online || got[1].Err != "HTTP 500" {
+		t.Errorf("b-node should report HTTP 500, got %+v", got[1])
+	}
+}
+
+func TestFindGaps(t *testing.T) {
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{
+			"ai01":   {Type: "ollama", BaseURL: "http://ai01:11434"},
+			"rk3588": {Type: "ollama", BaseURL: "http://rk3588:11434"},
+			"openai": {Type: "openai", BaseURL: "https://api.openai.com/v1"},
+		},
+		Models: map[string]provider.ModelConfig{
+			"qwen":    {Provider: "ai01", Model: "qwen3-coder:32b"},
+			"llama":   {Provider: "ai01", Model: "llama3"},
+			"tiny":    {Provider: "rk3588", Model: "qwen3:0.6b"},
+			"missing": {Provider: "ai01", Model: "deepseek-r1:70b"},
+			"gpt4o":   {Provider: "openai", Model: "gpt-4o"},
+		},
+		Roles: map[string]provider.RoleConfig{
+			"mayor":    {Model: "gpt4o", Fallbacks: provider.Fallbacks("missing")},
+			"polecat":  {Model: "qwen", Fallbacks: provider.Fallbacks("llama", "tiny")},
+			"reviewer": {Model: "missing"},
+		},
+	}
+	statuses := []Status{
+		{Name: "ai01", Online: true, Models: []string{"qwen3-coder:32b", "llama3:latest"}},
+		// rk3588 has the tiny model but is unreachable, so it doesn't count.
+		{Name: "rk3588", Online: false, Models: []string{"qwen3:0.6b"}},
+	}
+
+	got := FindGaps(cfg, statuses)
+	want := []Gap{
+		{Role: "mayor", Alias: "missing", Model: "deepseek-r1:70b", Fallback: true},
+		{Role: "polecat", Alias: "tiny", Model: "qwen3:0.6b", Fallback: true},
+		{Role: "reviewer", Alias: "missing", Model: "deepseek-r1:70b"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d gaps, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("gap[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFindGaps_ModelOnAnotherNode(t *testing.T) {
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{
+			"a": {Type: "ollama"},
+			"b": {Type: "ollama"},
+		},
+		Models: map[string]provider.ModelConfig{"m": {Provider: "a", Model: "qwen3:8b"}},
+		Roles:  map[string]provider.RoleConfig{"polecat": {Model: "m"}},
+	}
+	statuses := []Status{
+		{Name: "a", Online: true},
+		{Name: "b", Online: true, Models: []string{"qwen3:8b"}},
+	}
+	if gaps := FindGaps(cfg, statuses); len(gaps) != 0 {
+		t.Errorf("expected no gaps when any reachable node has the model, got %+v", gaps)
+	}
+}
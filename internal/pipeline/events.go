@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// EventKind names a PipelineEvent.
+type EventKind string
+
+const (
+	EventPhase     EventKind = "phase"     // a phase started; Phase and Attrs
+	EventSubtasks  EventKind = "subtasks"  // the decomposition is known; Subtasks
+	EventWorker    EventKind = "worker"    // a worker finished; Index and Worker
+	EventReview    EventKind = "review"    // a worker was (re-)scored; Index and Worker
+	EventSynthesis EventKind = "synthesis" // the final synthesis; Synthesis
+	EventBuild     EventKind = "build"     // a WithBuild pass finished; Build
+	EventDone      EventKind = "done"      // Run returned; Err
+)
+
+// PipelineEvent is a progress update from a running Pipeline: the same
+// phase, worker, review, and build updates "et run" renders.
+type PipelineEvent struct {
+	Kind EventKind
+	Time time.Time
+
+	Phase string            // EventPhase: e.g. "decompose", "execute"
+	Attrs map[string]string // EventPhase: e.g. mode=parallel
+
+	Subtasks  []string          // EventSubtasks
+	Index     int               // EventWorker, EventReview: 0-based subtask index
+	Worker    role.WorkerResult // EventWorker, EventReview
+	Synthesis string            // EventSynthesis
+	Build     BuildPass         // EventBuild
+	Err       error             // EventDone: Run's error, if any
+}
+
+// BuildPass is one pass of a build or test loop run by a WithBuild step.
+type BuildPass struct {
+	Step      string // "build" or "test"
+	Iteration int    // 1-based
+	Max       int    // the loop's iteration limit
+	OK        bool
+	Errors    int // errors parsed from the pass's output
+}
+
+// DefaultEventBuffer is the Events channel's capacity unless set with
+// WithEventBuffer.
+const DefaultEventBuffer = 64
+
+// WithEventBuffer sets the Events channel's capacity. Values < 1 keep
+// DefaultEventBuffer.
+func WithEventBuffer(n int) Option {
+	return func(p *Pipeline) {
+		if n >= 1 {
+			p.eventBuffer = n
+		}
+	}
+}
+
+// Events returns the channel carrying the progress of the Run (or Replay)
+// in progress, or of the next one. Each run has its own channel, which
+// ends with an EventDone and is closed when the run returns; call Events
+// again for the next run. Reading it is optional: the pipeline never waits
+// for a reader, and when the buffer is full the oldest unread event is
+// dropped to make room, so a slow reader sees the latest state.
+func (p *Pipeline) Events() <-chan PipelineEvent {
+	return p.currentFeed().ch
+}
+
+// currentFeed returns the feed of the run in progress or the next one.
+func (p *Pipeline) currentFeed() *eventFeed {
+	p.feedMu.Lock()
+	defer p.feedMu.Unlock()
+	if p.feed == nil {
+		p.feed = newEventFeed(p.eventBuffer)
+	}
+	return p.feed
+}
+
+// endFeed sends a run's EventDone and closes its feed, so the next run
+// gets a new one.
+func (p *Pipeline) endFeed(f *eventFeed, err error) {
+	f.send(PipelineEvent{Kind: EventDone, Err: err})
+	f.close()
+	p.feedMu.Lock()
+	if p.feed == f {
+		p.feed = nil
+	}
+	p.feedMu.Unlock()
+}
+
+// eventFeed is a buffered channel that drops its oldest event rather than
+// block the sender.
+type eventFeed struct {
+	mu     sync.Mutex // serializes senders, so a drop always frees a slot
+	ch     chan PipelineEvent
+	closed bool
+}
+
+func newEventFeed(size int) *eventFeed {
+	return &eventFeed{ch: make(chan PipelineEvent, size)}
+}
+
+func (f *eventFeed) send(ev PipelineEvent) {
+	ev.Time = time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return // a concurrent run on the same Pipeline already ended it
+	}
+	for {
+		select {
+		case f.ch <- ev:
+			return
+		default:
+		}
+		select {
+		case <-f.ch: // drop the oldest
+		default: // a reader just made room
+		}
+	}
+}
+
+func (f *eventFeed) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.closed {
+		f.closed = true
+		close(f.ch)
+	}
+}
+
+// phaseEvent converts runlog-style key/value pairs for an EventPhase.
+func phaseEvent(name string, kv []string) PipelineEvent {
+	var attrs map[string]string
+	for i := 0; i+1 < len(kv); i += 2 {
+		if attrs == nil {
+			attrs = make(map[string]string)
+		}
+		attrs[kv[i]] = kv[i+1]
+	}
+	return PipelineEvent{Kind: EventPhase, Phase: name, Attrs: attrs}
+}
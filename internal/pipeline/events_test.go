@@ -0,0 +1,168 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// drain returns the events left on a finished run's channel, failing if
+// the run did not close it.
+func drain(t *testing.T, ch <-chan PipelineEvent) []PipelineEvent {
+	t.Helper()
+	var evs []PipelineEvent
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return evs
+			}
+			evs = append(evs, ev)
+		default:
+			t.Fatalf("events channel still open after %s", describe(evs))
+		}
+	}
+}
+
+func describe(evs []PipelineEvent) string {
+	parts := make([]string, len(evs))
+	for i, ev := range evs {
+		parts[i] = string(ev.Kind)
+		if ev.Kind == EventPhase {
+			parts[i] += ":" + ev.Phase
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestEvents_Sequence(t *testing.T) {
+	router, cfg := testSetup(t, newScripted())
+	p := New(router, cfg, WithPool([]string{"small"}), WithSubtasks([]string{"write the parser"}))
+
+	ch := p.Events()
+	res, err := p.Run(context.Background(), "parse")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	evs := drain(t, ch)
+
+	want := "phase:decompose subtasks phase:execute worker phase:review review phase:synthesize phase:test synthesis done"
+	if got := describe(evs); got != want {
+		t.Fatalf("events = %s\nwant     %s", got, want)
+	}
+	if ev := evs[0]; ev.Attrs["source"] != "file" || ev.Time.IsZero() {
+		t.Errorf("decompose event = %+v, want source=file and a time", ev)
+	}
+	if ev := evs[1]; len(ev.Subtasks) != 1 || ev.Subtasks[0] != "write the parser" {
+		t.Errorf("subtasks event = %+v", ev)
+	}
+	if ev := evs[3]; ev.Index != 0 || ev.Worker.Response != "done: write the parser" {
+		t.Errorf("worker event = %+v", ev)
+	}
+	if ev := evs[5]; ev.Worker.ReviewScore != 8 {
+		t.Errorf("review event score = %d, want 8", ev.Worker.ReviewScore)
+	}
+	if ev := evs[8]; ev.Synthesis != res.Synthesis {
+		t.Errorf("synthesis event = %q, want %q", ev.Synthesis, res.Synthesis)
+	}
+	if ev := evs[9]; ev.Err != nil {
+		t.Errorf("done event err = %v", ev.Err)
+	}
+}
+
+func TestEvents_DoneCarriesError(t *testing.T) {
+	sp := newScripted()
+	sp.answers["boss"] = func(*provider.ChatRequest) (string, error) {
+		return "", &provider.APIError{Status: 401, Message: "bad key"}
+	}
+	router, cfg := testSetup(t, sp)
+	p := New(router, cfg)
+
+	ch := p.Events()
+	_, err := p.Run(context.Background(), "build it")
+	evs := drain(t, ch)
+	if len(evs) == 0 || evs[len(evs)-1].Kind != EventDone || evs[len(evs)-1].Err != err {
+		t.Errorf("events = %s, want a final done carrying %v", describe(evs), err)
+	}
+}
+
+func TestEvents_SlowConsumerDropsOldest(t *testing.T) {
+	router, cfg := testSetup(t, newScripted())
+	p := New(router, cfg, WithEventBuffer(3), WithoutSynthesis(), WithoutReviewer())
+
+	// Nobody reads during the run; it must still finish.
+	ch := p.Events()
+	if _, err := p.Run(context.Background(), "build it"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	evs := drain(t, ch)
+	if len(evs) != 3 {
+		t.Fatalf("buffered %d events, want 3", len(evs))
+	}
+	if got, want := describe(evs), "worker worker done"; got != want {
+		t.Errorf("kept events = %s, want the newest: %s", got, want)
+	}
+}
+
+func TestEvents_Build(t *testing.T) {
+	router, cfg := testSetup(t, newScripted())
+	var built *Result
+	p := New(router, cfg, WithoutReviewer(), WithoutTester(),
+		WithBuild(func(ctx context.Context, res *Result, pass func(BuildPass)) error {
+			built = res
+			pass(BuildPass{Step: "build", Iteration: 1, Max: 2, Errors: 3})
+			pass(BuildPass{Step: "build", Iteration: 2, Max: 2, OK: true})
+			return nil
+		}))
+
+	ch := p.Events()
+	res, err := p.Run(context.Background(), "build it")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if built != res {
+		t.Error("build step did not get the run's result")
+	}
+	evs := drain(t, ch)
+	if got := describe(evs[len(evs)-4:]); got != "synthesis build build done" {
+		t.Fatalf("events end with %s, want synthesis build build done", got)
+	}
+	if b := evs[len(evs)-3].Build; b.Step != "build" || b.Iteration != 1 || b.OK || b.Errors != 3 {
+		t.Errorf("first build event = %+v", b)
+	}
+	if b := evs[len(evs)-2].Build; !b.OK || b.Iteration != 2 {
+		t.Errorf("second build event = %+v", b)
+	}
+}
+
+func TestEvents_ChannelPerRun(t *testing.T) {
+	router, cfg := testSetup(t, newScripted())
+	p := New(router, cfg, WithoutSynthesis(), WithoutReviewer())
+
+	first := p.Events()
+	if _, err := p.Run(context.Background(), "build it"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	second := p.Events()
+	if second == first {
+		t.Fatal("second run reuses the first run's channel")
+	}
+	if _, err := p.Run(context.Background(), "build it again"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	for i, ch := range []<-chan PipelineEvent{first, second} {
+		evs := drain(t, ch)
+		dones := 0
+		for _, ev := range evs {
+			if ev.Kind == EventDone {
+				dones++
+			}
+		}
+		if dones != 1 || evs[len(evs)-1].Kind != EventDone {
+			t.Errorf("run %d events = %s, want exactly one done, last", i+1, describe(evs))
+		}
+	}
+}
@@ -0,0 +1,975 @@
+// Package pipeline runs a task through electrictown's pooled multi-phase
+// flow: the supervisor decomposes it, a worker pool executes the subtasks,
+// a reviewer scores them, and the supervisor synthesizes the results:
+//
+//	Phase 0     RAG context (optional)
+//	Phase 0.5   Jina fetch (optional)
+//	Phase 1     Decompose
+//	Phase 1.25  Specialist routing (optional)
+//	Phase 1.5   Coordination brief (optional)
+//	Phase 2     Workers
+//	Phase 2.25  Output validation (with an output dir)
+//	Phase 2.5   Reviewer and guardrail retries (optional)
+//	Phase 3     Synthesize (optional)
+//	Phase 4     Tester polish (optional)
+//
+// "et run" is a wrapper around a Pipeline that adds the terminal output,
+// file writing, and build loops; library users drive one directly.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/decision"
+	"github.com/meganerd/electrictown/internal/dryrun"
+	"github.com/meganerd/electrictown/internal/fileblock"
+	"github.com/meganerd/electrictown/internal/fileutil"
+	"github.com/meganerd/electrictown/internal/jina"
+	"github.com/meganerd/electrictown/internal/pool"
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/rag"
+	"github.com/meganerd/electrictown/internal/role"
+	"github.com/meganerd/electrictown/internal/runevent"
+	"github.com/meganerd/electrictown/internal/runstate"
+	"github.com/meganerd/electrictown/internal/textutil"
+)
+
+// WorkerRole is the role whose pool executes the subtasks.
+const WorkerRole = "polecat"
+
+// Pipeline runs tasks through the pooled flow. Build one with New; it is
+// configured once and may run several tasks, one at a time.
+type Pipeline struct {
+	router *provider.Router
+	cfg    *provider.Config
+
+	supervisorRole   string
+	poolAliases      []string
+	tracker          *cost.Tracker
+	maxSubtasks      int
+	maxContextTokens int
+	outputDir        string
+	liveDir          string
+
+	synthesize  bool
+	review      bool
+	test        bool
+	coordinate  bool
+	specialists bool
+	readFiles   bool
+	commands    bool
+
+	panel              []string
+	guardrailRetries   int
+	guardrailThreshold int
+	redoFlagged        bool
+
+	workers        int
+	subtaskTimeout time.Duration
+	subtaskRetries int
+
+	ragURL, ragCollection, ragEmbedURL string
+	jinaKey                            string
+
+	dryRun   bool
+	subtasks []string
+	resume   *runstate.State
+
+	out, warn  io.Writer
+	spinner    func(label string) (stop func())
+	phaseHook  func(name string, kv ...string)
+	events     *runevent.Emitter
+	decisions  *decision.Logger
+	progress   func(idx, n int, r role.WorkerResult)
+	checkpoint func(*Result)
+	build      BuildFunc
+
+	feedMu      sync.Mutex
+	feed        *eventFeed // the current or next Run's; see Events
+	eventBuffer int
+}
+
+// Option configures a Pipeline.
+type Option func(*Pipeline)
+
+// WithSupervisorRole sets the role that decomposes and synthesizes.
+// Default: "mayor".
+func WithSupervisorRole(role string) Option {
+	return func(p *Pipeline) { p.supervisorRole = role }
+}
+
+// WithPool sets the model aliases of the worker pool. Default: the pool of
+// WorkerRole in the config.
+func WithPool(aliases []string) Option {
+	return func(p *Pipeline) { p.poolAliases = aliases }
+}
+
+// WithCostTracker records every request's cost with t. Default: a tracker
+// with cost.DefaultPricing.
+func WithCostTracker(t *cost.Tracker) Option {
+	return func(p *Pipeline) { p.tracker = t }
+}
+
+// WithMaxSubtasks caps the decomposition (see role.WithMayorMaxSubtasks).
+func WithMaxSubtasks(n int) Option {
+	return func(p *Pipeline) { p.maxSubtasks = n }
+}
+
+// WithMaxContextTokens bounds the synthesis prompt (see
+// role.WithMayorMaxContextTokens).
+func WithMaxContextTokens(n int) Option {
+	return func(p *Pipeline) { p.maxContextTokens = n }
+}
+
+// WithOutputDir asks workers for ===FILE:=== blocks meant for dir and
+// validates that their output parses. The pipeline writes no files itself.
+func WithOutputDir(dir string) Option {
+	return func(p *Pipeline) { p.outputDir = dir }
+}
+
+// WithLiveOutput streams each worker's response to worker-N.live in dir as
+// it is generated, N counting subtasks from 1.
+func WithLiveOutput(dir string) Option {
+	return func(p *Pipeline) { p.liveDir = dir }
+}
+
+// WithoutSynthesis stops after the reviewer; Result.Synthesis stays empty.
+func WithoutSynthesis() Option {
+	return func(p *Pipeline) { p.synthesize = false }
+}
+
+// WithoutReviewer skips Phase 2.5.
+func WithoutReviewer() Option {
+	return func(p *Pipeline) { p.review = false }
+}
+
+// WithoutTester skips the Phase 4 polish of the synthesis.
+func WithoutTester() Option {
+	return func(p *Pipeline) { p.test = false }
+}
+
+// WithoutCoordination skips the Phase 1.5 coordination brief.
+func WithoutCoordination() Option {
+	return func(p *Pipeline) { p.coordinate = false }
+}
+
+// WithoutSpecialists ignores the config's specialists.
+func WithoutSpecialists() Option {
+	return func(p *Pipeline) { p.specialists = false }
+}
+
+// WithFileReads offers workers the read_file tool, which lets them read files
+// under the output directory. It is off by default because models without
+// tool support reject any request that carries tools.
+func WithFileReads() Option {
+	return func(p *Pipeline) { p.readFiles = true }
+}
+
+// WithoutCommands stops offering workers the run_command tool even when the
+// config's commands section allows some, e.g. when nothing may change in the
+// output directory without the user seeing it first.
+func WithoutCommands() Option {
+	return func(p *Pipeline) { p.commands = false }
+}
+
+// WithReviewerPanel scores with several models and uses the median score.
+func WithReviewerPanel(aliases []string) Option {
+	return func(p *Pipeline) { p.panel = aliases }
+}
+
+// WithGuardrail re-dispatches a worker scoring below threshold (1-10) up to
+// retries times with the reviewer's feedback. Default: 1 retry below 6.
+func WithGuardrail(retries, threshold int) Option {
+	return func(p *Pipeline) { p.guardrailRetries, p.guardrailThreshold = retries, threshold }
+}
+
+// WithRedoFlagged re-dispatches each subtask still flagged after the
+// guardrail once more, to another pool member (see pool.RedoFlagged).
+func WithRedoFlagged() Option {
+	return func(p *Pipeline) { p.redoFlagged = true }
+}
+
+// WithWorkers caps concurrent workers (see pool.WithMaxWorkers).
+func WithWorkers(n int) Option {
+	return func(p *Pipeline) { p.workers = n }
+}
+
+// WithSubtaskTimeout cancels a worker running longer than d (see
+// pool.WithSubtaskTimeout).
+func WithSubtaskTimeout(d time.Duration) Option {
+	return func(p *Pipeline) { p.subtaskTimeout = d }
+}
+
+// WithSubtaskRetries retries failed subtasks on other pool members (see
+// pool.WithSubtaskRetries).
+func WithSubtaskRetries(n int) Option {
+	return func(p *Pipeline) { p.subtaskRetries = n }
+}
+
+// WithRAG prefixes the decomposition and worker prompts with context
+// retrieved from the Qdrant collection at url, embedded through the Ollama
+// server at embedURL.
+func WithRAG(url, collection, embedURL string) Option {
+	return func(p *Pipeline) { p.ragURL, p.ragCollection, p.ragEmbedURL = url, collection, embedURL }
+}
+
+// WithJina lets the supervisor fetch pages it judges the workers need
+// through the Jina Reader API with key.
+func WithJina(key string) Option {
+	return func(p *Pipeline) { p.jinaKey = key }
+}
+
+// WithDryRun stops before the workers; Result.Plan previews their routing
+// and cost.
+func WithDryRun() Option {
+	return func(p *Pipeline) { p.dryRun = true }
+}
+
+// WithSubtasks uses subtasks instead of asking the supervisor to decompose.
+func WithSubtasks(subtasks []string) Option {
+	return func(p *Pipeline) { p.subtasks = subtasks }
+}
+
+// WithResume continues the run saved in s: its decomposition, worker
+// results, and synthesis are reused instead of being produced again.
+func WithResume(s *runstate.State) Option {
+	return func(p *Pipeline) { p.resume = s }
+}
+
+// WithOutput writes human progress lines to out and warnings to warn.
+// Default: both discarded.
+func WithOutput(out, warn io.Writer) Option {
+	return func(p *Pipeline) { p.out, p.warn = out, warn }
+}
+
+// WithSpinner calls start with a label while each long supervisor call
+// runs, and the returned stop when it ends.
+func WithSpinner(start func(label string) (stop func())) Option {
+	return func(p *Pipeline) { p.spinner = start }
+}
+
+// WithPhaseHook calls fn as each phase starts, with key/value attributes;
+// runlog.Logger.Phase fits.
+func WithPhaseHook(fn func(name string, kv ...string)) Option {
+	return func(p *Pipeline) { p.phaseHook = fn }
+}
+
+// WithEvents emits subtask, worker, review, and synthesis events to e.
+func WithEvents(e *runevent.Emitter) Option {
+	return func(p *Pipeline) { p.events = e }
+}
+
+// WithDecisionLogger records the pipeline's routing and review decisions.
+func WithDecisionLogger(l *decision.Logger) Option {
+	return func(p *Pipeline) { p.decisions = l }
+}
+
+// WithProgressHook calls fn as each of the n workers makes progress. It may
+// be called concurrently.
+func WithProgressHook(fn func(idx, n int, r role.WorkerResult)) Option {
+	return func(p *Pipeline) { p.progress = fn }
+}
+
+// WithCheckpoint calls fn once the worker results are final and again
+// after synthesis, so a caller can save the run for WithResume.
+func WithCheckpoint(fn func(*Result)) Option {
+	return func(p *Pipeline) { p.checkpoint = fn }
+}
+
+// BuildFunc builds what a run produced, e.g. writes its files and runs
+// build and test loops, calling pass after each build or test pass.
+type BuildFunc func(ctx context.Context, res *Result, pass func(BuildPass)) error
+
+// WithBuild runs fn at the end of Run, after synthesis (or after review
+// with WithoutSynthesis), so its passes reach Events as EventBuild before
+// EventDone. It is not called with WithDryRun; its error is Run's.
+func WithBuild(fn BuildFunc) Option {
+	return func(p *Pipeline) { p.build = fn }
+}
+
+// New creates a Pipeline routing through router, configured by cfg.
+func New(router *provider.Router, cfg *provider.Config, opts ...Option) *Pipeline {
+	p := &Pipeline{
+		router:             router,
+		cfg:                cfg,
+		supervisorRole:     "mayor",
+		synthesize:         true,
+		review:             true,
+		test:               true,
+		coordinate:         true,
+		specialists:        true,
+		commands:           true,
+		guardrailRetries:   1,
+		guardrailThreshold: 6,
+		out:                io.Discard,
+		warn:               io.Discard,
+		eventBuffer:        DefaultEventBuffer,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.poolAliases == nil {
+		p.poolAliases = cfg.PoolForRole(WorkerRole)
+	}
+	if p.tracker == nil {
+		p.tracker = cost.NewTracker(cost.DefaultPricing())
+	}
+	if p.spinner == nil {
+		p.spinner = func(string) func() { return func() {} }
+	}
+	return p
+}
+
+// Result is the outcome of Run. When Run fails, it holds whatever finished.
+type Result struct {
+	Task               string
+	Subtasks           []string
+	Workers            []role.WorkerResult // one per subtask, in order
+	WorkerSystemPrompt string
+	Synthesis          string       // "" with WithoutSynthesis
+	Plan               *dryrun.Plan // WithDryRun only; no workers ran
+	Phases             []PhaseTime
+	Cost               *cost.Summary
+
+	// Pool is the worker pool the subtasks ran on, for follow-up work such
+	// as fix passes and its reliability figures.
+	Pool *pool.WorkerPool
+}
+
+// PhaseTime is how long a timed phase took.
+type PhaseTime struct {
+	Name    string
+	Elapsed time.Duration
+}
+
+// run holds the state of one Run.
+type run struct {
+	*Pipeline
+	feed  *eventFeed
+	res   *Result
+	mayor *role.Mayor
+	pt    *phaseTracker
+
+	hasSpecialists bool
+	tagRoutes      map[string]string
+	workerContext  string // RAG and fetched pages for the worker prompt
+	models         []string
+	fallbacks      [][]string
+}
+
+// Run runs task through the pipeline. The context bounds the whole run.
+func (p *Pipeline) Run(ctx context.Context, task string) (*Result, error) {
+	feed := p.currentFeed()
+	res, err := p.runTask(ctx, task, feed)
+	p.endFeed(feed, err)
+	return res, err
+}
+
+func (p *Pipeline) runTask(ctx context.Context, task string, feed *eventFeed) (*Result, error) {
+	if len(p.poolAliases) == 0 {
+		return nil, fmt.Errorf("pipeline: no worker pool (roles.%s.pool in the config)", WorkerRole)
+	}
+	r := &run{Pipeline: p, feed: feed, res: &Result{Task: task}, pt: newPhaseTracker(p.out)}
+	defer func() {
+		r.res.Phases = r.pt.phases
+		r.res.Cost = p.tracker.Summary()
+	}()
+	r.mayor = r.newMayor()
+
+	decomposeTask := r.gatherContext(ctx, task)
+	subtasks, err := r.decompose(ctx, task, decomposeTask)
+	if err != nil {
+		return r.res, err
+	}
+	r.resolveSpecialists(subtasks)
+
+	prompt, err := WorkerPrompt(p.cfg, provider.PromptData{Task: task, OutputDir: p.outputDir, SubtaskCount: len(subtasks)})
+	if err != nil {
+		return r.res, err
+	}
+	if r.workerContext != "" {
+		prompt = r.workerContext + "\n---\n\n" + prompt
+	}
+	if p.resume != nil {
+		prompt = p.resume.WorkerSystemPrompt
+	}
+	r.res.WorkerSystemPrompt = prompt
+	r.res.Pool = r.newPool()
+
+	if p.dryRun {
+		r.phase("dry-run")
+		fmt.Fprintf(p.out, "Dry run: worker routing and cost estimate (no workers dispatched)...\n")
+		r.res.Plan = dryrun.New(p.cfg, p.tracker, subtasks, r.res.Pool.Preview(subtasks, r.models), prompt)
+		return r.res, nil
+	}
+
+	r.coordinateWorkers(ctx, task)
+	if err := r.execute(ctx); err != nil {
+		return r.res, err
+	}
+	if p.outputDir != "" && p.resume == nil {
+		r.validate(ctx)
+	}
+	if p.review && p.resume == nil {
+		r.reviewWorkers(ctx)
+	}
+	if p.checkpoint != nil {
+		p.checkpoint(r.res)
+	}
+	if !p.synthesize {
+		return r.res, r.runBuild(ctx)
+	}
+
+	if err := r.synthesizeResults(ctx, task); err != nil {
+		return r.res, err
+	}
+	if p.checkpoint != nil {
+		p.checkpoint(r.res)
+	}
+	p.events.SynthesisDone(r.res.Synthesis)
+	r.feed.send(PipelineEvent{Kind: EventSynthesis, Synthesis: r.res.Synthesis})
+	return r.res, r.runBuild(ctx)
+}
+
+// runBuild runs the WithBuild step, forwarding its passes as events.
+func (r *run) runBuild(ctx context.Context) error {
+	if r.build == nil {
+		return nil
+	}
+	return r.build(ctx, r.res, func(bp BuildPass) {
+		r.feed.send(PipelineEvent{Kind: EventBuild, Build: bp})
+	})
+}
+
+// Replay runs Phases 3 and 4 again on the worker results saved in s and
+// ignores its synthesis, so a changed synthesis prompt or supervisor can be
+// tried without paying for the workers again. No worker or reviewer is
+// called. Options for the earlier phases have no effect; WithResume must
+// not be set.
+func (p *Pipeline) Replay(ctx context.Context, s *runstate.State) (*Result, error) {
+	feed := p.currentFeed()
+	res, err := p.replay(ctx, s, feed)
+	p.endFeed(feed, err)
+	return res, err
+}
+
+func (p *Pipeline) replay(ctx context.Context, s *runstate.State, feed *eventFeed) (*Result, error) {
+	r := &run{Pipeline: p, feed: feed, pt: newPhaseTracker(p.out), res: &Result{
+		Task:               s.Task,
+		Subtasks:           s.Subtasks,
+		Workers:            s.Results(),
+		WorkerSystemPrompt: s.WorkerSystemPrompt,
+	}}
+	defer func() {
+		r.res.Phases = r.pt.phases
+		r.res.Cost = p.tracker.Summary()
+	}()
+	r.mayor = r.newMayor()
+	fmt.Fprintf(p.out, "Replaying synthesis of run %s from %d saved worker results\n", s.RunID, len(s.Workers))
+	if err := r.synthesizeResults(ctx, s.Task); err != nil {
+		return r.res, err
+	}
+	p.events.SynthesisDone(r.res.Synthesis)
+	r.feed.send(PipelineEvent{Kind: EventSynthesis, Synthesis: r.res.Synthesis})
+	return r.res, nil
+}
+
+func (r *run) phase(name string, kv ...string) {
+	r.feed.send(phaseEvent(name, kv))
+	if r.phaseHook != nil {
+		r.phaseHook(name, kv...)
+	}
+}
+
+// newMayor builds the supervisor, telling it about the configured
+// specialists and tag routes so it can mark subtasks for them.
+func (r *run) newMayor() *role.Mayor {
+	opts := []role.MayorOption{role.WithMayorRole(r.supervisorRole), role.WithMayorCostTracker(r.tracker)}
+	if r.maxSubtasks > 0 {
+		opts = append(opts, role.WithMayorMaxSubtasks(r.maxSubtasks))
+	}
+	if r.maxContextTokens > 0 {
+		opts = append(opts, role.WithMayorMaxContextTokens(r.maxContextTokens))
+	}
+	r.hasSpecialists = r.specialists && len(r.cfg.Specialists) > 0
+	if r.hasSpecialists {
+		opts = append(opts, role.WithMayorSpecialists(r.cfg.Specialists))
+	}
+	r.tagRoutes = r.cfg.PoolTagsForRole(WorkerRole)
+	if len(r.tagRoutes) > 0 {
+		tags := make([]string, 0, len(r.tagRoutes))
+		for tag := range r.tagRoutes {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		opts = append(opts, role.WithMayorTags(tags))
+	}
+	return role.NewMayor(r.router, opts...)
+}
+
+// gatherContext runs Phases 0 and 0.5 and returns the task as the
+// supervisor should see it for decomposition.
+func (r *run) gatherContext(ctx context.Context, task string) string {
+	decomposeTask := task
+	if r.resume != nil {
+		return decomposeTask
+	}
+
+	// Phase 0: RAG context retrieval.
+	if r.ragURL != "" {
+		r.phase("rag", "collection", r.ragCollection)
+		fmt.Fprintf(r.out, "Phase 0: RAG context retrieval from %s (collection: %s)...\n", r.ragURL, r.ragCollection)
+		retriever := rag.NewRetriever(rag.NewClient(r.ragURL, r.ragCollection), rag.NewEmbedder(r.ragEmbedURL, rag.DefaultEmbedModel))
+		results, err := retriever.Retrieve(ctx, task, 3)
+		if err != nil {
+			fmt.Fprintf(r.warn, "  warning: RAG retrieval failed: %v — continuing without context\n", err)
+		} else {
+			r.workerContext = retriever.FormatContext(results)
+			fmt.Fprintf(r.out, "  Retrieved %d chunks\n", len(results))
+		}
+		fmt.Fprintln(r.out)
+	}
+	if r.workerContext != "" {
+		decomposeTask = r.workerContext + "\n---\n\n" + task
+	}
+
+	// Phase 0.5: staleness assessment and Jina Reader URL fetch.
+	if r.jinaKey == "" {
+		return decomposeTask
+	}
+	r.phase("assess")
+	fmt.Fprintf(r.out, "Phase 0.5: Mayor assessing knowledge staleness...\n")
+	r.pt.start("Phase 0.5 assess")
+	stop := r.spinner("  assessing")
+	assess, err := r.mayor.Assess(ctx, task)
+	stop()
+	if err != nil {
+		fmt.Fprintf(r.warn, "  warning: mayor assess failed: %v — continuing without Jina fetch\n", err)
+	} else {
+		fmt.Fprintf(r.out, "  Staleness risk: %s\n", assess.StalenessRisk)
+		if len(assess.FetchURLs) > 0 {
+			fmt.Fprintf(r.out, "  Fetching %d URL(s) via Jina Reader...\n", len(assess.FetchURLs))
+			client := jina.New(r.jinaKey)
+			var fetched strings.Builder
+			for _, u := range assess.FetchURLs {
+				content, err := client.FetchURL(ctx, u)
+				if err != nil {
+					fmt.Fprintf(r.warn, "  warning: Jina fetch %s: %v\n", u, err)
+					continue
+				}
+				if len(content) > 8192 {
+					content = content[:8192]
+				}
+				fmt.Fprintf(&fetched, "=== Fetched: %s ===\n%s\n\n", u, content)
+				fmt.Fprintf(r.out, "  ✓ fetched %s (%d chars)\n", u, len(content))
+			}
+			if fetched.Len() > 0 {
+				decomposeTask = fetched.String() + "\n---\n\n" + decomposeTask
+				r.workerContext = fetched.String() + "\n\n" + r.workerContext
+			}
+		}
+	}
+	r.pt.stop()
+	fmt.Fprintln(r.out)
+	return decomposeTask
+}
+
+// decompose runs Phase 1: the subtasks come from the resumed run, from
+// WithSubtasks, or from the supervisor.
+func (r *run) decompose(ctx context.Context, task, decomposeTask string) ([]string, error) {
+	var subtasks []string
+	agent, intent := r.supervisorRole, "split task into parallel subtasks"
+	switch {
+	case r.resume != nil:
+		r.phase("decompose", "source", "resume", "run", r.resume.RunID)
+		fmt.Fprintf(r.out, "Phase 1: Resuming run %s with its saved decomposition...\n", r.resume.RunID)
+		r.pt.start("Phase 1 decompose")
+		subtasks = r.resume.Subtasks
+		agent, intent = "user", "resume run "+r.resume.RunID
+	case r.subtasks != nil:
+		r.phase("decompose", "source", "file")
+		fmt.Fprintf(r.out, "Phase 1: Using pre-written decomposition from --subtask-file...\n")
+		r.pt.start("Phase 1 decompose")
+		subtasks = r.subtasks
+		agent, intent = "user", "supply subtasks via --subtask-file"
+	default:
+		r.phase("decompose", "role", r.supervisorRole)
+		fmt.Fprintf(r.out, "Phase 1: Supervisor (%s) decomposing task...\n", r.supervisorRole)
+		r.pt.start("Phase 1 decompose")
+		stop := r.spinner("  decomposing")
+		var err error
+		subtasks, err = r.mayor.Decompose(ctx, decomposeTask)
+		stop()
+		if err != nil {
+			return nil, fmt.Errorf("supervisor decompose failed: %w", err)
+		}
+	}
+
+	r.decisions.Log(decision.Decision{
+		Phase:   "decompose",
+		Agent:   agent,
+		Intent:  intent,
+		Action:  fmt.Sprintf("produced %d subtasks", len(subtasks)),
+		Outcome: "success",
+		Detail:  textutil.Truncate(task, 120),
+	})
+
+	r.res.Subtasks = subtasks
+	r.events.Subtasks(subtasks)
+	r.feed.send(PipelineEvent{Kind: EventSubtasks, Subtasks: subtasks})
+	fmt.Fprintf(r.out, "  Subtasks: %d\n", len(subtasks))
+	for i, st := range subtasks {
+		fmt.Fprintf(r.out, "  [%d] %s\n", i+1, textutil.Truncate(st, 100))
+	}
+	if pool.HasDependencies(pool.ParseDependencies(subtasks)) {
+		fmt.Fprintf(r.out, "  Dependencies detected — will execute in waves\n")
+	}
+	r.pt.stop()
+	fmt.Fprintln(r.out)
+	return subtasks, nil
+}
+
+// resolveSpecialists runs Phase 1.25, routing each subtask the supervisor
+// marked [specialist: name] to that specialist's model or pool.
+func (r *run) resolveSpecialists(subtasks []string) {
+	if !r.hasSpecialists || r.resume != nil {
+		return
+	}
+	r.phase("specialists")
+	fmt.Fprintf(r.out, "Phase 1.25: Resolving specialist assignments...\n")
+	names := r.cfg.SpecialistNames()
+	r.models = make([]string, len(subtasks))
+	r.fallbacks = make([][]string, len(subtasks))
+	balancers := make(map[string]*provider.Balancer)
+
+	for i, st := range subtasks {
+		assigned := pool.ParseSpecialistAssignment(st)
+		if assigned == "" {
+			// No marker: the default pool's balancer picks.
+			fmt.Fprintf(r.out, "  [%d] → general-default\n", i+1)
+			continue
+		}
+
+		spec, ok := r.cfg.Specialists[assigned]
+		if !ok {
+			match, found := pool.FuzzyMatchSpecialist(assigned, names)
+			if !found {
+				fmt.Fprintf(r.warn, "  ⚠ [%d] specialist %q not found, falling back to general-default\n", i+1, assigned)
+				r.decisions.Log(decision.Decision{
+					Phase:   "specialist-resolve",
+					Agent:   "orchestrator",
+					Intent:  fmt.Sprintf("resolve specialist %q for subtask %d", assigned, i+1),
+					Action:  "fell back to general-default",
+					Outcome: "warning",
+					Detail:  fmt.Sprintf("specialist %q not in config", assigned),
+				})
+				continue
+			}
+			fmt.Fprintf(r.warn, "  ⚠ [%d] specialist %q not found, using fuzzy match %q\n", i+1, assigned, match)
+			assigned = match
+			spec = r.cfg.Specialists[match]
+		}
+
+		if len(spec.Pool) > 0 {
+			if _, exists := balancers[assigned]; !exists {
+				balancers[assigned] = provider.NewBalancer(provider.StrategyRoundRobin)
+			}
+			r.models[i] = balancers[assigned].Select(assigned, spec.Pool)
+		} else {
+			r.models[i] = spec.Model
+		}
+		if len(spec.Fallbacks) > 0 {
+			r.fallbacks[i] = spec.Fallbacks
+		}
+
+		fmt.Fprintf(r.out, "  [%d] → %s (%s)\n", i+1, assigned, r.models[i])
+		r.decisions.Log(decision.Decision{
+			Phase:   "specialist-resolve",
+			Agent:   "orchestrator",
+			Intent:  fmt.Sprintf("assign subtask %d to specialist", i+1),
+			Action:  fmt.Sprintf("routed to %s via %s", assigned, r.models[i]),
+			Outcome: "success",
+			Detail:  textutil.Truncate(st, 120),
+		})
+	}
+	fmt.Fprintln(r.out)
+}
+
+// newPool builds the worker pool. Explicit pool weights select smooth
+// weighted round-robin; otherwise least-loaded keeps a slow member from
+// becoming a bottleneck, and behaves like round-robin with even latencies.
+// Health checks keep a downed node from failing every subtask routed to it,
+// tag routes pin [tag: name] subtasks to their member, a subtask timeout
+// keeps one runaway worker from starving the rest, and subtask retries move
+// a failed subtask to other members. With an output directory, workers get
+// the tools from workerTools.
+func (r *run) newPool() *pool.WorkerPool {
+	balancer := provider.NewBalancer(provider.StrategyLeastLoaded)
+	if weights := r.cfg.PoolWeightsForRole(WorkerRole); hasCustomWeights(weights) {
+		balancer = provider.NewWeightedBalancer(weights)
+	}
+	opts := []pool.Option{
+		pool.WithHealthCheck(30 * time.Second),
+		pool.WithTagRoutes(r.tagRoutes),
+		pool.WithMaxWorkers(r.workers),
+		pool.WithSubtaskTimeout(r.subtaskTimeout),
+		pool.WithSubtaskRetries(r.subtaskRetries),
+	}
+	if tools, exec := r.workerTools(); len(tools) > 0 {
+		opts = append(opts, pool.WithTools(tools, exec))
+	}
+	return pool.New(r.router, balancer, r.poolAliases, opts...)
+}
+
+// workerTools returns the tools workers get with an output directory:
+// read_file with WithFileReads, and run_command when the config's
+// commands section allows any, unless WithoutCommands.
+func (r *run) workerTools() ([]provider.Tool, role.ToolExecutor) {
+	if r.outputDir == "" {
+		return nil, nil
+	}
+	var tools []provider.Tool
+	mux := role.ToolMux{}
+	if r.readFiles {
+		tools = append(tools, role.ReadFileTool)
+		mux[role.ReadFileToolName] = role.NewFileReader(r.outputDir)
+	}
+	if r.runsCommands() {
+		cmds := r.cfg.Commands
+		timeout, _ := time.ParseDuration(cmds.Timeout) // checked by Validate; "" keeps the default
+		tools = append(tools, role.RunCommandTool)
+		mux[role.RunCommandToolName] = role.NewCommandRunner(r.outputDir, cmds.Allow, timeout)
+	}
+	return tools, mux
+}
+
+// runsCommands reports whether workers get the run_command tool.
+func (r *run) runsCommands() bool {
+	return r.outputDir != "" && r.commands && len(r.cfg.Commands.Allow) > 0
+}
+
+// stageFiles writes a finished worker's files to the output directory, so
+// the commands and reads of workers that start later see them. The caller
+// still writes the final files after review; names it would refuse are
+// skipped here silently.
+func (r *run) stageFiles(res role.WorkerResult) {
+	for _, f := range fileblock.Parse(res.Response) {
+		if f.Name == "" {
+			continue
+		}
+		path, err := fileblock.SafePath(r.outputDir, f.Name)
+		if err != nil {
+			continue
+		}
+		if err := fileutil.AtomicWrite(path, []byte(f.Content), 0o644); err != nil {
+			fmt.Fprintf(r.warn, "  warning: could not stage %s: %v\n", f.Name, err)
+		}
+	}
+}
+
+// hasCustomWeights reports whether any pool member has a weight other than
+// the default of 1.
+func hasCustomWeights(opts []provider.WeightedOption) bool {
+	for _, o := range opts {
+		if o.Weight != 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// coordinateWorkers runs Phase 1.5: a brief from the supervisor telling
+// every worker how the subtasks fit together.
+func (r *run) coordinateWorkers(ctx context.Context, task string) {
+	if !r.coordinate || len(r.res.Subtasks) <= 1 || r.resume != nil {
+		return
+	}
+	r.phase("coordinate")
+	fmt.Fprintf(r.out, "Phase 1.5: Mayor producing coordination brief...\n")
+	r.pt.start("Phase 1.5 coordinate")
+	stop := r.spinner("  coordinating")
+	brief, err := r.mayor.Coordinate(ctx, task, r.res.Subtasks)
+	stop()
+	if err != nil {
+		fmt.Fprintf(r.warn, "  warning: coordination brief failed: %v — continuing without\n", err)
+	} else if brief != "" {
+		r.res.WorkerSystemPrompt = "## Project Coordination\n" + brief + "\n---\n\n" + r.res.WorkerSystemPrompt
+		fmt.Fprintf(r.out, "  ✓ coordination brief injected (%d chars)\n", len(brief))
+	}
+	r.pt.stop()
+	fmt.Fprintln(r.out)
+}
+
+// execute runs Phase 2: the subtasks on the pool, in dependency waves when
+// the supervisor marked dependencies.
+func (r *run) execute(ctx context.Context) error {
+	subtasks, prompt, wp := r.res.Subtasks, r.res.WorkerSystemPrompt, r.res.Pool
+	n := len(subtasks)
+	// With run_command, each worker's files are staged as it finishes, so a
+	// later dependency wave can format, vet, or read them. Fix passes that
+	// reuse the pool later do not stage.
+	stage := r.runsCommands() && r.resume == nil
+	defer func() { stage = false }()
+	wp.SetProgressHook(func(idx int, res role.WorkerResult) {
+		if stage && res.Err == nil {
+			r.stageFiles(res)
+		}
+		r.workerDone(idx, res)
+		if r.progress != nil {
+			r.progress(idx, n, res)
+		}
+	})
+
+	if r.liveDir != "" && r.resume == nil {
+		wp.SetLiveOutput(func(idx int) (io.WriteCloser, error) {
+			return os.Create(filepath.Join(r.liveDir, fmt.Sprintf("worker-%d.live", idx+1)))
+		})
+		defer wp.SetLiveOutput(nil)
+		fmt.Fprintf(r.out, "Streaming worker output to %s (tail -f to follow)\n", filepath.Join(r.liveDir, "worker-N.live"))
+	}
+
+	deps := pool.ParseDependencies(subtasks)
+	members := strconv.Itoa(len(r.poolAliases))
+	r.pt.start("Phase 2 workers")
+	switch {
+	case r.resume != nil:
+		r.phase("execute", "mode", "resume", "subtasks", strconv.Itoa(n))
+		fmt.Fprintf(r.out, "Phase 2: Reusing %d worker results from run %s\n", n, r.resume.RunID)
+		r.res.Workers = r.resume.Results()
+		for i, res := range r.res.Workers {
+			r.workerDone(i, res)
+		}
+	case pool.HasDependencies(deps):
+		r.phase("execute", "mode", "dag", "subtasks", strconv.Itoa(n), "members", members)
+		fmt.Fprintf(r.out, "Phase 2: Workers executing with dependency ordering (%d subtasks, %d pool members)...\n", n, len(r.poolAliases))
+		var results []role.WorkerResult
+		var err error
+		if r.models != nil {
+			results, err = wp.ExecuteDAGWithModels(ctx, subtasks, deps, r.models, r.fallbacks, prompt)
+		} else {
+			results, err = wp.ExecuteDAG(ctx, subtasks, deps, prompt)
+		}
+		if err != nil {
+			return fmt.Errorf("DAG execution failed: %w", err)
+		}
+		r.res.Workers = results
+	default:
+		r.phase("execute", "mode", "parallel", "subtasks", strconv.Itoa(n), "members", members)
+		fmt.Fprintf(r.out, "Phase 2: Workers executing in parallel (%d subtasks, %d pool members)...\n", n, len(r.poolAliases))
+		if r.models != nil {
+			r.res.Workers = wp.ExecuteAllWithModels(ctx, subtasks, r.models, r.fallbacks, prompt)
+		} else {
+			r.res.Workers = wp.ExecuteAll(ctx, subtasks, prompt)
+		}
+	}
+	r.pt.stop()
+	fmt.Fprintln(r.out)
+	return nil
+}
+
+// workerDone reports a finished worker.
+func (r *run) workerDone(idx int, res role.WorkerResult) {
+	r.events.WorkerUpdate(idx, res)
+	r.feed.send(PipelineEvent{Kind: EventWorker, Index: idx, Worker: res})
+}
+
+// synthesizeResults runs Phases 3 and 4, or reuses the resumed run's
+// synthesis.
+func (r *run) synthesizeResults(ctx context.Context, task string) error {
+	if r.resume != nil && r.resume.Synthesis != "" {
+		r.phase("synthesize", "source", "resume")
+		fmt.Fprintf(r.out, "Phase 3: Reusing synthesis from run %s\n", r.resume.RunID)
+		r.res.Synthesis = r.resume.Synthesis
+		return nil
+	}
+
+	r.phase("synthesize")
+	fmt.Fprintf(r.out, "Phase 3: Supervisor synthesizing results...\n")
+	r.pt.start("Phase 3 synthesize")
+	stop := r.spinner("  synthesizing")
+	synthesis, err := r.mayor.Synthesize(ctx, task, r.res.Workers)
+	stop()
+	if err != nil {
+		return fmt.Errorf("supervisor synthesize failed (during %s): %w", r.pt.currentPhase(), err)
+	}
+	r.pt.stop()
+	r.res.Synthesis = synthesis
+
+	if !r.test {
+		return nil
+	}
+	if _, ok := r.cfg.Roles["tester"]; !ok {
+		fmt.Fprintf(r.warn, "  note: tester role not configured — skipping Phase 4\n")
+		return nil
+	}
+	r.phase("test")
+	fmt.Fprintf(r.out, "Phase 4: Tester polishing synthesized output...\n")
+	r.pt.start("Phase 4 tester")
+	stop = r.spinner("  refining")
+	tester := role.NewTester(r.router, role.WithRefineryCostTracker(r.tracker), role.WithTesterPromptData(r.promptData()))
+	refined, err := tester.Refine(ctx, synthesis)
+	stop()
+	if err != nil {
+		fmt.Fprintf(r.warn, "  tester failed: %v — using raw synthesis\n", err)
+	} else {
+		r.res.Synthesis = refined.Message.Content
+		fmt.Fprintf(r.out, "  Tester refined output (%d tokens)\n", refined.Usage.TotalTokens)
+	}
+	r.pt.stop()
+	fmt.Fprintln(r.out)
+	return nil
+}
+
+// promptData is the run context for the tester and reviewer prompt
+// templates.
+func (r *run) promptData() provider.PromptData {
+	return provider.PromptData{Task: r.res.Task, OutputDir: r.outputDir, SubtaskCount: len(r.res.Subtasks)}
+}
+
+// phaseTracker times the phases of a run.
+type phaseTracker struct {
+	out        io.Writer
+	runStart   time.Time
+	phaseStart time.Time
+	phaseName  string
+	phases     []PhaseTime
+	mu         sync.Mutex
+}
+
+func newPhaseTracker(out io.Writer) *phaseTracker {
+	return &phaseTracker{out: out, runStart: time.Now()}
+}
+
+func (pt *phaseTracker) start(name string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.phaseName = name
+	pt.phaseStart = time.Now()
+}
+
+// stop records the current phase and reports its time and the run's.
+func (pt *phaseTracker) stop() {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	elapsed := time.Since(pt.phaseStart)
+	if pt.phaseName != "" {
+		pt.phases = append(pt.phases, PhaseTime{Name: pt.phaseName, Elapsed: elapsed})
+	}
+	fmt.Fprintf(pt.out, "  done (%.1fs, cumulative %.1fs)\n", elapsed.Seconds(), time.Since(pt.runStart).Seconds())
+}
+
+func (pt *phaseTracker) currentPhase() string {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return pt.phaseName
+}
@@ -0,0 +1,407 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+	"github.com/meganerd/electrictown/internal/runstate"
+)
+
+// scriptedProvider answers each model with a canned function and records
+// the requests it gets.
+type scriptedProvider struct {
+	mu      sync.Mutex
+	calls   []string // model of each request
+	answers map[string]func(req *provider.ChatRequest) (string, error)
+}
+
+func (p *scriptedProvider) Name() string { return "scripted" }
+
+func (p *scriptedProvider) ChatCompletion(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	p.mu.Lock()
+	p.calls = append(p.calls, req.Model)
+	p.mu.Unlock()
+	answer, ok := p.answers[req.Model]
+	if !ok {
+		return nil, fmt.Errorf("no answer for %s", req.Model)
+	}
+	content, err := answer(req)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.ChatResponse{
+		Model:   req.Model,
+		Message: provider.Message{Role: provider.RoleAssistant, Content: content},
+		Usage:   provider.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		Done:    true,
+	}, nil
+}
+
+func (p *scriptedProvider) StreamChatCompletion(context.Context, *provider.ChatRequest) (provider.ChatStream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *scriptedProvider) ListModels(context.Context) ([]provider.Model, error) { return nil, nil }
+
+func (p *scriptedProvider) count(model string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, m := range p.calls {
+		if m == model {
+			n++
+		}
+	}
+	return n
+}
+
+func lastUser(req *provider.ChatRequest) string {
+	return req.Messages[len(req.Messages)-1].Content
+}
+
+// supervisor answers the mayor's decompose, coordinate, and synthesize
+// requests.
+func supervisor(req *provider.ChatRequest) (string, error) {
+	user := lastUser(req)
+	switch {
+	case strings.HasPrefix(user, "Decompose this task"):
+		return "1. write the parser\n2. write the printer", nil
+	case strings.HasPrefix(user, "Task: "):
+		return "Share one AST type.", nil
+	default:
+		return "synthesized: " + fmt.Sprint(strings.Count(user, "--- Worker")) + " results", nil
+	}
+}
+
+func worker(req *provider.ChatRequest) (string, error) {
+	return "done: " + lastUser(req), nil
+}
+
+func newScripted() *scriptedProvider {
+	return &scriptedProvider{answers: map[string]func(*provider.ChatRequest) (string, error){
+		"boss":   supervisor,
+		"small":  worker,
+		"big":    worker,
+		"judge":  func(*provider.ChatRequest) (string, error) { return "SCORE: 8\nREASON: fine", nil },
+		"polish": func(req *provider.ChatRequest) (string, error) { return "polished " + lastUser(req), nil },
+	}}
+}
+
+func testSetup(t *testing.T, p *scriptedProvider) (*provider.Router, *provider.Config) {
+	t.Helper()
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{"local": {Type: "scripted"}},
+		Models: map[string]provider.ModelConfig{
+			"boss":   {Provider: "local", Model: "boss"},
+			"small":  {Provider: "local", Model: "small"},
+			"big":    {Provider: "local", Model: "big"},
+			"judge":  {Provider: "local", Model: "judge"},
+			"polish": {Provider: "local", Model: "polish"},
+		},
+		Roles: map[string]provider.RoleConfig{
+			"mayor":    {Model: "boss"},
+			"polecat":  {Model: "small", Pool: []provider.PoolEntry{{Model: "small"}, {Model: "big"}}},
+			"reviewer": {Model: "judge"},
+			"tester":   {Model: "polish"},
+		},
+		Defaults: provider.DefaultsConfig{Model: "boss"},
+	}
+	factories := map[string]provider.ProviderFactory{
+		"scripted": func(provider.ProviderConfig) (provider.Provider, error) { return p, nil },
+	}
+	r, err := provider.NewRouter(cfg, factories)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r, cfg
+}
+
+func TestRun_EndToEnd(t *testing.T) {
+	sp := newScripted()
+	router, cfg := testSetup(t, sp)
+
+	var phases []string
+	var checkpoints int
+	var progressMu sync.Mutex
+	progress := map[int]string{}
+	p := New(router, cfg,
+		WithPhaseHook(func(name string, _ ...string) { phases = append(phases, name) }),
+		WithCheckpoint(func(*Result) { checkpoints++ }),
+		WithProgressHook(func(idx, n int, r role.WorkerResult) {
+			progressMu.Lock()
+			defer progressMu.Unlock()
+			if n != 2 {
+				t.Errorf("progress n = %d, want 2", n)
+			}
+			progress[idx] = r.Role
+		}))
+
+	res, err := p.Run(context.Background(), "build a pretty printer")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(res.Subtasks) != 2 || res.Subtasks[0] != "write the parser" {
+		t.Errorf("subtasks = %q", res.Subtasks)
+	}
+	if len(res.Workers) != 2 {
+		t.Fatalf("workers = %d, want 2", len(res.Workers))
+	}
+	for i, w := range res.Workers {
+		if w.Err != nil || w.Response != "done: "+res.Subtasks[i] {
+			t.Errorf("worker %d = %+v", i, w)
+		}
+		if w.ReviewScore != 8 || w.Flagged {
+			t.Errorf("worker %d review = %d flagged=%v, want 8 unflagged", i, w.ReviewScore, w.Flagged)
+		}
+	}
+	if len(progress) != 2 {
+		t.Errorf("progress reported for %d workers, want 2", len(progress))
+	}
+	if !strings.Contains(res.WorkerSystemPrompt, "## Project Coordination\nShare one AST type.") {
+		t.Errorf("worker prompt lacks the coordination brief:\n%s", res.WorkerSystemPrompt)
+	}
+	if res.Synthesis != "polished synthesized: 2 results" {
+		t.Errorf("synthesis = %q", res.Synthesis)
+	}
+
+	wantPhases := "decompose coordinate execute review synthesize test"
+	if got := strings.Join(phases, " "); got != wantPhases {
+		t.Errorf("phases = %s, want %s", got, wantPhases)
+	}
+	if checkpoints != 2 {
+		t.Errorf("checkpoints = %d, want after the workers and after synthesis", checkpoints)
+	}
+	if len(res.Phases) == 0 || res.Phases[0].Name != "Phase 1 decompose" {
+		t.Errorf("phase timings = %+v", res.Phases)
+	}
+	if res.Cost == nil || res.Cost.TotalRequests == 0 {
+		t.Errorf("cost = %+v, want the supervisor and reviewer requests recorded", res.Cost)
+	}
+	if res.Pool == nil || len(res.Pool.Reliability()) == 0 {
+		t.Error("result has no pool reliability")
+	}
+}
+
+func TestRun_Guardrail(t *testing.T) {
+	sp := newScripted()
+	scores := 0
+	sp.answers["judge"] = func(*provider.ChatRequest) (string, error) {
+		scores++
+		if scores == 1 {
+			return "SCORE: 3\nREASON: missing tests", nil
+		}
+		return "SCORE: 9\nREASON: good", nil
+	}
+	revisions := 0
+	sp.answers["small"] = func(req *provider.ChatRequest) (string, error) {
+		if strings.Contains(lastUser(req), "Reviewer feedback: missing tests") {
+			revisions++
+			return "revised with tests", nil
+		}
+		return "first draft", nil
+	}
+	router, cfg := testSetup(t, sp)
+
+	res, err := New(router, cfg,
+		WithPool([]string{"small"}),
+		WithSubtasks([]string{"write the parser"}),
+		WithoutSynthesis(),
+	).Run(context.Background(), "parse")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	w := res.Workers[0]
+	if revisions != 1 || w.Response != "revised with tests" || w.ReviewScore != 9 || w.Flagged {
+		t.Errorf("worker = %+v after %d revisions, want one revision scoring 9", w, revisions)
+	}
+	if res.Synthesis != "" || sp.count("boss") != 0 {
+		t.Errorf("supervisor called %d times without synthesis or decomposition", sp.count("boss"))
+	}
+}
+
+func TestRun_DryRun(t *testing.T) {
+	sp := newScripted()
+	router, cfg := testSetup(t, sp)
+
+	res, err := New(router, cfg, WithDryRun()).Run(context.Background(), "build it")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Plan == nil || len(res.Plan.Subtasks) != 2 {
+		t.Fatalf("plan = %+v, want 2 previewed subtasks", res.Plan)
+	}
+	if res.Workers != nil || sp.count("small")+sp.count("big") != 0 {
+		t.Error("workers ran during a dry run")
+	}
+}
+
+func TestRun_Resume(t *testing.T) {
+	sp := newScripted()
+	router, cfg := testSetup(t, sp)
+
+	saved := runstate.New("abc123", "build it", "")
+	saved.Subtasks = []string{"one", "two"}
+	saved.WorkerSystemPrompt = "saved prompt"
+	saved.SetResults([]role.WorkerResult{
+		{Subtask: "one", Role: "small", Response: "first"},
+		{Subtask: "two", Role: "big", Response: "second"},
+	})
+
+	res, err := New(router, cfg, WithResume(saved), WithoutTester()).Run(context.Background(), "build it")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.WorkerSystemPrompt != "saved prompt" || len(res.Workers) != 2 || res.Workers[1].Response != "second" {
+		t.Errorf("result = %+v, want the saved run", res)
+	}
+	if sp.count("small")+sp.count("big")+sp.count("judge") != 0 {
+		t.Error("workers or reviewer ran again on resume")
+	}
+	if sp.count("boss") != 1 || res.Synthesis != "synthesized: 2 results" {
+		t.Errorf("synthesis = %q after %d supervisor calls, want one synthesis", res.Synthesis, sp.count("boss"))
+	}
+}
+
+func TestReplay(t *testing.T) {
+	sp := newScripted()
+	router, cfg := testSetup(t, sp)
+
+	// A run log directory left by an earlier run, synthesis included.
+	dir := t.TempDir()
+	saved := runstate.New("abc123", "build it", "")
+	saved.Subtasks = []string{"one", "two", "three"}
+	saved.SetResults([]role.WorkerResult{
+		{Subtask: "one", Role: "small", Response: "first"},
+		{Subtask: "two", Role: "big", Response: "second"},
+		{Subtask: "three", Role: "small", Response: "third"},
+	})
+	saved.Synthesis = "old synthesis"
+	if err := saved.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := runstate.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := New(router, cfg).Replay(context.Background(), loaded)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if sp.count("small")+sp.count("big")+sp.count("judge") != 0 {
+		t.Error("workers or reviewer ran on replay")
+	}
+	if sp.count("boss") != 1 || sp.count("polish") != 1 {
+		t.Errorf("supervisor ran %d times and tester %d, want one each", sp.count("boss"), sp.count("polish"))
+	}
+	if res.Synthesis != "polished synthesized: 3 results" {
+		t.Errorf("synthesis = %q, want a new, polished one", res.Synthesis)
+	}
+	if res.Task != "build it" || len(res.Workers) != 3 || res.Workers[2].Response != "third" {
+		t.Errorf("result = %+v, want the saved run", res)
+	}
+
+	// WithoutTester stops after Phase 3.
+	res, err = New(router, cfg, WithoutTester()).Replay(context.Background(), loaded)
+	if err != nil || res.Synthesis != "synthesized: 3 results" {
+		t.Errorf("Replay without tester = %q, %v", res.Synthesis, err)
+	}
+}
+
+func TestRun_DecomposeFailure(t *testing.T) {
+	sp := newScripted()
+	sp.answers["boss"] = func(*provider.ChatRequest) (string, error) {
+		return "", &provider.APIError{Status: 401, Message: "bad key"}
+	}
+	router, cfg := testSetup(t, sp)
+
+	res, err := New(router, cfg).Run(context.Background(), "build it")
+	if err == nil || !strings.Contains(err.Error(), "supervisor decompose failed") {
+		t.Fatalf("err = %v, want a decompose failure", err)
+	}
+	if res == nil || res.Subtasks != nil {
+		t.Errorf("result = %+v, want an empty partial result", res)
+	}
+}
+
+func TestRun_NoPool(t *testing.T) {
+	router, cfg := testSetup(t, newScripted())
+	if _, err := New(router, cfg, WithPool([]string{})).Run(context.Background(), "x"); err == nil {
+		t.Error("expected an error without a worker pool")
+	}
+}
+
+func TestWorkerTools(t *testing.T) {
+	router, cfg := testSetup(t, newScripted())
+	names := func(opts ...Option) string {
+		r := &run{Pipeline: New(router, cfg, opts...)}
+		tools, _ := r.workerTools()
+		var out []string
+		for _, tool := range tools {
+			out = append(out, tool.Function.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := names(); got != "" {
+		t.Errorf("without an output dir, tools = %s", got)
+	}
+	if got := names(WithOutputDir(t.TempDir())); got != "" {
+		t.Errorf("with an output dir, tools = %s, want none until opted in", got)
+	}
+	if got := names(WithOutputDir(t.TempDir()), WithFileReads()); got != "read_file" {
+		t.Errorf("WithFileReads: tools = %s, want read_file only", got)
+	}
+	cfg.Commands.Allow = []string{"gofmt"}
+	if got := names(WithOutputDir(t.TempDir()), WithFileReads()); got != "read_file,run_command" {
+		t.Errorf("with a command allowlist, tools = %s", got)
+	}
+	if got := names(WithOutputDir(t.TempDir()), WithoutCommands()); got != "" {
+		t.Errorf("WithoutCommands: tools = %s", got)
+	}
+}
+
+func TestRun_StagesFilesForCommands(t *testing.T) {
+	dir := t.TempDir()
+	sp := newScripted()
+	var sawParser bool
+	writeFiles := func(req *provider.ChatRequest) (string, error) {
+		if strings.Contains(lastUser(req), "printer") {
+			_, err := os.Stat(filepath.Join(dir, "parser.go"))
+			sawParser = err == nil
+			return "===FILE: printer.go===\npackage p\n===ENDFILE===", nil
+		}
+		return "===FILE: parser.go===\npackage p\n===ENDFILE===", nil
+	}
+	sp.answers["small"], sp.answers["big"] = writeFiles, writeFiles
+	router, cfg := testSetup(t, sp)
+	cfg.Commands.Allow = []string{"gofmt"}
+	subtasks := []string{"write the parser", "write the printer [depends: 1]"}
+
+	p := New(router, cfg, WithOutputDir(dir), WithSubtasks(subtasks), WithoutReviewer(), WithoutSynthesis())
+	if _, err := p.Run(context.Background(), "parse"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !sawParser {
+		t.Error("the dependent worker ran before parser.go was staged")
+	}
+
+	// Without run_command nothing is written before the caller does it.
+	dir = t.TempDir()
+	p = New(router, cfg, WithOutputDir(dir), WithSubtasks(subtasks), WithoutReviewer(), WithoutSynthesis(), WithoutCommands())
+	if _, err := p.Run(context.Background(), "parse"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("output dir has %d entries without run_command, want none", len(entries))
+	}
+}
@@ -0,0 +1,33 @@
+package pipeline
+
+import "github.com/meganerd/electrictown/internal/provider"
+
+// WorkerPrompt returns the system prompt for workers.
+// When data.OutputDir is set, it instructs multi-file output with ===FILE: ===
+// delimiters. The worker and worker_files prompt overrides in cfg replace the
+// defaults and are rendered as templates with data.
+func WorkerPrompt(cfg *provider.Config, data provider.PromptData) (string, error) {
+	base := "You are a coding worker. Implement exactly what is asked."
+	if data.OutputDir != "" {
+		if p := cfg.Prompt(provider.PromptWorkerFiles); p != "" {
+			return provider.RenderPrompt(p, data)
+		}
+		return base + `
+
+Output all required source files using this exact format — one block per file:
+
+===FILE: relative/path/to/file.go===
+<complete file content here>
+===ENDFILE===
+
+Rules:
+- Output ONLY file content — no explanations, no commentary.
+- Each file must be complete and standalone (proper package declaration, all imports).
+- Use relative paths from the project root.
+- You may output as many files as the subtask requires.`, nil
+	}
+	if p := cfg.Prompt(provider.PromptWorker); p != "" {
+		return provider.RenderPrompt(p, data)
+	}
+	return base + " Output ONLY the code — no explanations, no markdown fences unless specifically requested.", nil
+}
@@ -0,0 +1,218 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/meganerd/electrictown/internal/decision"
+	"github.com/meganerd/electrictown/internal/pool"
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+	"github.com/meganerd/electrictown/internal/textutil"
+	"github.com/meganerd/electrictown/internal/validate"
+)
+
+// validate runs Phase 2.25: a worker whose ===FILE:=== blocks do not parse
+// is asked once to resubmit them, with the problems found.
+func (r *run) validate(ctx context.Context) {
+	r.phase("validate")
+	results := r.res.Workers
+	retried := 0
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		ok, problems := validate.ValidateFileBlocks(results[i].Response)
+		if ok {
+			continue
+		}
+		retried++
+		fmt.Fprintf(r.out, "  ⚠ worker[%d] output validation failed: %s\n", i+1, strings.Join(problems, "; "))
+		prompt := fmt.Sprintf(
+			"Your previous output had format errors:\n%s\n\nOriginal subtask: %s\n\nPlease output corrected files using ===FILE: path=== ... ===ENDFILE=== format.",
+			strings.Join(problems, "\n"), results[i].Subtask,
+		)
+		resp, err := r.retry(ctx, results[i].Role, prompt)
+		if err != nil {
+			fmt.Fprintf(r.warn, "  validation retry[%d] failed: %v\n", i+1, err)
+			continue
+		}
+		results[i].Response = resp.Message.Content
+		results[i].Tokens += resp.Usage.TotalTokens
+		fmt.Fprintf(r.out, "  ✓ worker[%d] re-submitted after validation fix\n", i+1)
+	}
+	if retried > 0 {
+		fmt.Fprintln(r.out)
+	}
+}
+
+// retry sends prompt to the model alias that produced a worker result, with
+// the worker system prompt.
+func (r *run) retry(ctx context.Context, alias, prompt string) (*provider.ChatResponse, error) {
+	return r.router.ChatCompletion(ctx, &provider.ChatRequest{
+		Model: alias,
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: r.res.WorkerSystemPrompt},
+			{Role: provider.RoleUser, Content: prompt},
+		},
+	})
+}
+
+// reviewWorkers runs Phase 2.5: each result is scored, and one scoring
+// below its threshold is retried with the reviewer's feedback up to the
+// guardrail's limit, then optionally redone on another pool member.
+func (r *run) reviewWorkers(ctx context.Context) {
+	if _, ok := r.cfg.Roles["reviewer"]; !ok && len(r.panel) == 0 {
+		fmt.Fprintf(r.warn, "  note: reviewer role not configured — skipping Phase 2.5\n")
+		return
+	}
+	r.phase("review", "threshold", strconv.Itoa(r.guardrailThreshold))
+	// A panel scores with several models and reports the median, so a
+	// single harsh model cannot flag borderline output on its own.
+	var reviewer pool.Scorer
+	if len(r.panel) > 0 {
+		fmt.Fprintf(r.out, "Phase 2.5: Reviewer panel (%s) scoring worker outputs...\n", strings.Join(r.panel, ", "))
+		reviewer = role.NewReviewerPanel(r.router, r.panel, role.WithWitnessCostTracker(r.tracker), role.WithReviewerPromptData(r.promptData()))
+	} else {
+		fmt.Fprintf(r.out, "Phase 2.5: Reviewer scoring worker outputs...\n")
+		reviewer = role.NewReviewer(r.router, role.WithWitnessCostTracker(r.tracker), role.WithReviewerPromptData(r.promptData()))
+	}
+	r.pt.start("Phase 2.5 reviewer")
+	results := r.res.Workers
+	// Subtasks the Mayor marked [importance: ...] are held to their own bar.
+	thresholds := pool.ReviewThresholds(r.res.Subtasks, r.guardrailThreshold)
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		score, note, err := reviewer.Score(ctx, results[i].Subtask, results[i].Response)
+		if err != nil {
+			fmt.Fprintf(r.warn, "  reviewer[%d]: %v\n", i+1, err)
+			continue
+		}
+		r.setScore(i, score, note, thresholds[i])
+		r.decisions.Log(decision.Decision{
+			Phase:     "review",
+			Agent:     "reviewer",
+			Intent:    fmt.Sprintf("score worker %d output", i+1),
+			Action:    fmt.Sprintf("scored %d/10", score),
+			Outcome:   map[bool]string{true: "flagged", false: "passed"}[results[i].Flagged],
+			Detail:    textutil.Truncate(note, 120),
+			TokenCost: results[i].Tokens,
+		})
+		r.guardrail(ctx, reviewer, i, thresholds[i])
+
+		flag := "✓"
+		if results[i].Flagged {
+			flag = "⚑"
+		}
+		bar := ""
+		if thresholds[i] != r.guardrailThreshold {
+			bar = fmt.Sprintf(" (threshold %d)", thresholds[i])
+		}
+		fmt.Fprintf(r.out, "  [%d/%d] score=%d/10%s %s %s\n", i+1, len(results), results[i].ReviewScore, bar, flag, textutil.Truncate(results[i].ReviewNote, 80))
+	}
+
+	if r.redoFlagged {
+		r.redo(ctx, reviewer, thresholds)
+	}
+	r.pt.stop()
+	fmt.Fprintln(r.out)
+}
+
+// setScore records a review of result i.
+func (r *run) setScore(i, score int, note string, threshold int) {
+	res := &r.res.Workers[i]
+	res.ReviewScore = score
+	res.ReviewNote = note
+	res.Flagged = score > 0 && score < threshold
+	r.reviewed(i)
+}
+
+// reviewed reports the review of result i.
+func (r *run) reviewed(i int) {
+	res := r.res.Workers[i]
+	r.events.ReviewScore(i, res)
+	r.feed.send(PipelineEvent{Kind: EventReview, Index: i, Worker: res})
+}
+
+// guardrail re-dispatches flagged result i with the reviewer's feedback
+// until it passes, the retries run out, or the worker repeats itself.
+func (r *run) guardrail(ctx context.Context, reviewer pool.Scorer, i, threshold int) {
+	res := &r.res.Workers[i]
+	doom := pool.NewDoomLoop()
+	doom.Check(res.Response) // seed with the original response
+	for retries := 1; res.Flagged && retries <= r.guardrailRetries; retries++ {
+		fmt.Fprintf(r.out, "  [%d/%d] score=%d/10 ⚑ retrying (%d/%d): %s\n",
+			i+1, len(r.res.Workers), res.ReviewScore, retries, r.guardrailRetries, textutil.Truncate(res.ReviewNote, 60))
+		prompt := fmt.Sprintf(
+			"Your previous output scored %d/10. Reviewer feedback: %s\n\nOriginal subtask: %s\n\nPlease revise your output to address the reviewer's feedback.",
+			res.ReviewScore, res.ReviewNote, res.Subtask,
+		)
+		resp, err := r.retry(ctx, res.Role, prompt)
+		if err != nil {
+			fmt.Fprintf(r.warn, "  guardrail retry[%d]: %v\n", i+1, err)
+			return
+		}
+		res.Response = resp.Message.Content
+		res.Tokens += resp.Usage.TotalTokens
+
+		if doom.Check(res.Response) {
+			fmt.Fprintf(r.warn, "  ⚠ worker[%d] doom loop: identical output after retry — aborting\n", i+1)
+			r.decisions.Log(decision.Decision{
+				Phase:   "guardrail",
+				Agent:   res.Role,
+				Intent:  "improve output via retry",
+				Action:  "doom loop detected — aborted",
+				Outcome: "failure",
+				Detail:  "identical response after feedback retry",
+			})
+			return
+		}
+
+		score, note, err := reviewer.Score(ctx, res.Subtask, res.Response)
+		if err != nil {
+			fmt.Fprintf(r.warn, "  guardrail re-score[%d]: %v\n", i+1, err)
+			return
+		}
+		r.setScore(i, score, note, threshold)
+		r.decisions.Log(decision.Decision{
+			Phase:   "guardrail",
+			Agent:   res.Role,
+			Intent:  "improve output via retry",
+			Action:  fmt.Sprintf("re-scored %d/10 after retry %d", score, retries),
+			Outcome: map[bool]string{true: "still-flagged", false: "improved"}[res.Flagged],
+			Detail:  textutil.Truncate(note, 120),
+		})
+	}
+}
+
+// redo gives whatever the guardrail left flagged one fresh attempt on
+// another pool member, kept only if it scores higher.
+func (r *run) redo(ctx context.Context, reviewer pool.Scorer, thresholds []int) {
+	results := r.res.Workers
+	for _, rd := range r.res.Pool.RedoFlagged(ctx, results, thresholds, r.res.WorkerSystemPrompt, reviewer) {
+		i := rd.Index
+		outcome := "discarded"
+		switch {
+		case rd.Err != nil:
+			fmt.Fprintf(r.warn, "  redo[%d]: %v\n", i+1, rd.Err)
+			outcome = "failure"
+		case rd.Kept:
+			outcome = "kept"
+			r.reviewed(i)
+			fmt.Fprintf(r.out, "  [%d/%d] redo on %s: score %d → %d/10, kept\n", i+1, len(results), rd.To, rd.OldScore, rd.NewScore)
+		default:
+			fmt.Fprintf(r.out, "  [%d/%d] redo on %s: score %d/10 ≤ %d/10, discarded\n", i+1, len(results), rd.To, rd.NewScore, rd.OldScore)
+		}
+		r.decisions.Log(decision.Decision{
+			Phase:   "redo",
+			Agent:   rd.To,
+			Intent:  fmt.Sprintf("redo flagged worker %d output from %s", i+1, rd.From),
+			Action:  fmt.Sprintf("re-scored %d/10 (was %d/10)", rd.NewScore, rd.OldScore),
+			Outcome: outcome,
+		})
+	}
+}
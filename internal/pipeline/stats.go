@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// WorkerLatency summarizes how long the workers of a run took.
+type WorkerLatency struct {
+	Workers       int // workers with a recorded time
+	P50, P95, Max time.Duration
+}
+
+// WorkerLatency returns the latency percentiles of the run's workers, from
+// their WorkerResult.Elapsed. Workers with no recorded time are left out;
+// it is zero when none has one.
+func (r *Result) WorkerLatency() WorkerLatency {
+	var ds []time.Duration
+	for _, w := range r.Workers {
+		if w.Elapsed > 0 {
+			ds = append(ds, w.Elapsed)
+		}
+	}
+	if len(ds) == 0 {
+		return WorkerLatency{}
+	}
+	return WorkerLatency{
+		Workers: len(ds),
+		P50:     Percentile(ds, 50),
+		P95:     Percentile(ds, 95),
+		Max:     slices.Max(ds),
+	}
+}
+
+// Percentile returns the p-th percentile (0 to 100) of ds by the
+// nearest-rank method, so the result is always one of ds. ds is not
+// modified; it is 0 when ds is empty.
+func Percentile(ds []time.Duration, p float64) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+// Throughput is completion tokens per second of wall time, or 0 when
+// elapsed is not positive.
+func Throughput(completionTokens int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(completionTokens) / elapsed.Seconds()
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+func TestPercentile(t *testing.T) {
+	s := time.Second
+	ds := []time.Duration{9 * s, 1 * s, 7 * s, 3 * s, 5 * s, 2 * s, 10 * s, 4 * s, 8 * s, 6 * s}
+	for _, tc := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 1 * s},
+		{10, 1 * s},
+		{50, 5 * s},
+		{51, 6 * s},
+		{95, 10 * s},
+		{100, 10 * s},
+	} {
+		if got := Percentile(ds, tc.p); got != tc.want {
+			t.Errorf("Percentile(p%v) = %v, want %v", tc.p, got, tc.want)
+		}
+	}
+	if ds[0] != 9*s {
+		t.Error("Percentile reordered its input")
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile(nil) = %v, want 0", got)
+	}
+	if got := Percentile([]time.Duration{3 * s}, 95); got != 3*s {
+		t.Errorf("Percentile of one = %v, want 3s", got)
+	}
+}
+
+func TestResult_WorkerLatency(t *testing.T) {
+	res := &Result{Workers: []role.WorkerResult{
+		{Elapsed: 4 * time.Second},
+		{}, // no recorded time
+		{Elapsed: 2 * time.Second},
+		{Elapsed: 12 * time.Second},
+	}}
+	want := WorkerLatency{Workers: 3, P50: 4 * time.Second, P95: 12 * time.Second, Max: 12 * time.Second}
+	if got := res.WorkerLatency(); got != want {
+		t.Errorf("WorkerLatency() = %+v, want %+v", got, want)
+	}
+	if got := (&Result{}).WorkerLatency(); got != (WorkerLatency{}) {
+		t.Errorf("WorkerLatency() with no workers = %+v", got)
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	if got := Throughput(500, 4*time.Second); got != 125 {
+		t.Errorf("Throughput = %v, want 125", got)
+	}
+	if got := Throughput(500, 0); got != 0 {
+		t.Errorf("Throughput over no time = %v, want 0", got)
+	}
+}
@@ -0,0 +1,144 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Option configures a WorkerPool during construction.
+type Option func(*WorkerPool)
+
+// WithHealthCheck enables periodic health checks of pool members while the
+// pool is executing. Every interval each alias is pinged via its provider's
+// ListModels; members that fail are ejected from balancer rotation until a
+// later check succeeds. A subtask that fails on a member which then fails a
+// ping is retried on a healthy member. A zero interval disables checking.
+func WithHealthCheck(interval time.Duration) Option {
+	return func(wp *WorkerPool) {
+		wp.healthInterval = interval
+	}
+}
+
+// health tracks which pool aliases are currently ejected.
+type health struct {
+	mu      sync.RWMutex
+	ejected map[string]bool
+}
+
+func (h *health) set(alias string, healthy bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.ejected == nil {
+		h.ejected = make(map[string]bool)
+	}
+	if healthy {
+		delete(h.ejected, alias)
+	} else {
+		h.ejected[alias] = true
+	}
+}
+
+// healthy returns the aliases that are not ejected, in pool order.
+func (h *health) healthy(aliases []string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if len(h.ejected) == 0 {
+		return aliases
+	}
+	out := make([]string, 0, len(aliases))
+	for _, a := range aliases {
+		if !h.ejected[a] {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// HealthyAliases returns the pool aliases currently in balancer rotation.
+func (wp *WorkerPool) HealthyAliases() []string {
+	return wp.health.healthy(wp.aliases)
+}
+
+// rotation returns the aliases the balancer should choose from: the healthy
+// members, or every member when all are ejected (trying beats failing fast).
+func (wp *WorkerPool) rotation() []string {
+	if healthy := wp.HealthyAliases(); len(healthy) > 0 {
+		return healthy
+	}
+	return wp.aliases
+}
+
+// maxPingTimeout caps how long a single health ping may take, so a member
+// that silently drops packets cannot stall a run for a whole (possibly long)
+// check interval before the first subtask is dispatched.
+const maxPingTimeout = 5 * time.Second
+
+// ping reports whether alias answers a ListModels call within the interval,
+// capped at maxPingTimeout.
+func (wp *WorkerPool) ping(ctx context.Context, alias string) bool {
+	timeout := min(wp.healthInterval, maxPingTimeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return wp.router.Ping(ctx, alias) == nil
+}
+
+// checkHealth pings every member concurrently and updates ejection state.
+func (wp *WorkerPool) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, alias := range wp.aliases {
+		wg.Add(1)
+		go func(a string) {
+			defer wg.Done()
+			wp.health.set(a, wp.ping(ctx, a))
+		}(alias)
+	}
+	wg.Wait()
+}
+
+// startHealthCheck runs an immediate health check (bounded by
+// maxPingTimeout) and then repeats it every interval until the returned stop
+// function is called. It is a no-op when health checking is disabled.
+func (wp *WorkerPool) startHealthCheck(ctx context.Context) (stop func()) {
+	if wp.healthInterval <= 0 {
+		return func() {}
+	}
+	wp.checkHealth(ctx)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(wp.healthInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				wp.checkHealth(ctx)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// retryAlias returns the alias to retry a failed subtask on. When health
+// checking is enabled and the failed alias also fails a ping, it is ejected
+// and the first healthy member is returned in its place. Otherwise the same
+// alias is retried. The balancer is not consulted: the caller still holds
+// (and releases) whichever alias it originally selected.
+func (wp *WorkerPool) retryAlias(ctx context.Context, alias string) string {
+	if wp.healthInterval <= 0 || wp.ping(ctx, alias) {
+		return alias
+	}
+	wp.health.set(alias, false)
+	healthy := wp.HealthyAliases()
+	if len(healthy) == 0 {
+		return alias
+	}
+	return healthy[0]
+}
@@ -0,0 +1,200 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// newHealthTestRouter builds a router whose providers report healthy or not
+// according to up[alias]. Chat requests to a down member fail like a refused
+// connection; healthy members answer with a fixed response. If afterPing is
+// non-nil it runs after every health ping has been answered.
+func newHealthTestRouter(t *testing.T, aliases []string, up map[string]*atomic.Bool, afterPing func(alias string)) *provider.Router {
+	t.Helper()
+	cfg := testConfig(aliases)
+	factories := make(map[string]provider.ProviderFactory)
+	for i, alias := range aliases {
+		alias, state := alias, up[alias]
+		mp := &mockProvider{
+			name: fmt.Sprintf("mock-%d", i),
+			chatFn: func(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+				if !state.Load() {
+					return nil, fmt.Errorf("dial tcp: connection refused")
+				}
+				return &provider.ChatResponse{
+					ID:      "ok",
+					Model:   req.Model,
+					Message: provider.Message{Role: provider.RoleAssistant, Content: "done"},
+					Usage:   provider.Usage{TotalTokens: 10},
+					Done:    true,
+				}, nil
+			},
+			listFn: func(_ context.Context) ([]provider.Model, error) {
+				healthy := state.Load()
+				if afterPing != nil {
+					afterPing(alias)
+				}
+				if !healthy {
+					return nil, fmt.Errorf("dial tcp: connection refused")
+				}
+				return nil, nil
+			},
+		}
+		factories[mp.name] = func(provider.ProviderConfig) (provider.Provider, error) { return mp, nil }
+	}
+	r, err := provider.NewRouter(cfg, factories)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r
+}
+
+func upStates(aliases ...string) map[string]*atomic.Bool {
+	m := make(map[string]*atomic.Bool, len(aliases))
+	for _, a := range aliases {
+		b := &atomic.Bool{}
+		b.Store(true)
+		m[a] = b
+	}
+	return m
+}
+
+func TestHealthCheck_EjectsDeadMember(t *testing.T) {
+	aliases := []string{"good", "dead"}
+	up := upStates(aliases...)
+	up["dead"].Store(false)
+	router := newHealthTestRouter(t, aliases, up, nil)
+
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithHealthCheck(time.Hour))
+	results := wp.ExecuteAll(context.Background(), []string{"a", "b", "c", "d"}, "sys")
+
+	for i, r := range results {
+		if r.Role != "good" {
+			t.Errorf("result[%d] routed to %q, want good", i, r.Role)
+		}
+		if r.Response != "done" {
+			t.Errorf("result[%d] = %q, want done", i, r.Response)
+		}
+	}
+	if h := wp.HealthyAliases(); len(h) != 1 || h[0] != "good" {
+		t.Errorf("HealthyAliases = %v, want [good]", h)
+	}
+}
+
+func TestHealthCheck_ReadmitsRecoveredMember(t *testing.T) {
+	aliases := []string{"a", "b"}
+	up := upStates(aliases...)
+	router := newHealthTestRouter(t, aliases, up, nil)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithHealthCheck(10*time.Millisecond))
+
+	up["b"].Store(false)
+	stop := wp.startHealthCheck(context.Background())
+	defer stop()
+	if h := wp.HealthyAliases(); len(h) != 1 || h[0] != "a" {
+		t.Fatalf("after b went down HealthyAliases = %v, want [a]", h)
+	}
+
+	up["b"].Store(true)
+	deadline := time.Now().Add(time.Second)
+	for len(wp.HealthyAliases()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("b was not readmitted; HealthyAliases = %v", wp.HealthyAliases())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHealthCheck_RetriesFailedSubtaskOnHealthyMember(t *testing.T) {
+	aliases := []string{"a", "b"}
+	up := upStates(aliases...)
+	// b passes the initial health check and dies right after it, so its
+	// failures must be caught by the retry path rather than the checker.
+	router := newHealthTestRouter(t, aliases, up, func(alias string) {
+		if alias == "b" {
+			up["b"].Store(false)
+		}
+	})
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithHealthCheck(time.Hour))
+
+	results := wp.ExecuteAll(context.Background(), []string{"t1", "t2", "t3", "t4"}, "sys")
+	for i, r := range results {
+		if r.Response != "done" {
+			t.Errorf("result[%d] = %q, want done after failover", i, r.Response)
+		}
+		if r.Role != "a" {
+			t.Errorf("result[%d] finished on %q, want a", i, r.Role)
+		}
+	}
+	if h := wp.HealthyAliases(); len(h) != 1 || h[0] != "a" {
+		t.Errorf("HealthyAliases = %v, want [a] after b failed its retry ping", h)
+	}
+}
+
+func TestHealthCheck_DisabledRetriesSameMember(t *testing.T) {
+	aliases := []string{"a", "b"}
+	up := upStates(aliases...)
+	up["b"].Store(false)
+	router := newHealthTestRouter(t, aliases, up, nil)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+
+	results := wp.ExecuteAll(context.Background(), []string{"t1", "t2"}, "sys")
+	failed := 0
+	for _, r := range results {
+		if r.Role == "b" && r.Response != "done" {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("expected the subtask on b to fail without health checks, got %d failures", failed)
+	}
+}
+
+func TestRetryAlias_LeavesBalancerToCaller(t *testing.T) {
+	aliases := []string{"a", "b"}
+	up := upStates(aliases...)
+	up["a"].Store(false)
+	router := newHealthTestRouter(t, aliases, up, nil)
+	bal := provider.NewBalancer(provider.StrategyLeastLoaded)
+	wp := New(router, bal, aliases, WithHealthCheck(time.Hour))
+
+	held := bal.Select("pool", []string{"a"})
+	if next := wp.retryAlias(context.Background(), held); next != "b" {
+		t.Fatalf("retryAlias = %q, want b", next)
+	}
+	// a is still in flight for the caller and b was never selected, so the
+	// balancer must prefer b.
+	if got := bal.Select("pool", aliases); got != "b" {
+		t.Errorf("Select after retryAlias = %q, want b (balancer state was changed)", got)
+	}
+}
+
+func TestHealthCheck_InitialPingIsBounded(t *testing.T) {
+	var remaining time.Duration
+	mp := &mockProvider{
+		name: "mock-0",
+		listFn: func(ctx context.Context) ([]provider.Model, error) {
+			if d, ok := ctx.Deadline(); ok {
+				remaining = time.Until(d)
+			}
+			return nil, nil
+		},
+	}
+	factories := map[string]provider.ProviderFactory{
+		mp.name: func(provider.ProviderConfig) (provider.Provider, error) { return mp, nil },
+	}
+	router, err := provider.NewRouter(testConfig([]string{"a"}), factories)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), []string{"a"}, WithHealthCheck(time.Hour))
+
+	wp.startHealthCheck(context.Background())()
+	if remaining <= 0 || remaining > maxPingTimeout {
+		t.Errorf("initial ping had %v left, want a deadline within %v", remaining, maxPingTimeout)
+	}
+}
@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Importance is the criticality the Mayor assigns to a subtask with an
+// [importance: level] marker. It adjusts the reviewer score a subtask must
+// reach before it is flagged for a guardrail retry.
+type Importance string
+
+const (
+	ImportanceLow      Importance = "low"
+	ImportanceNormal   Importance = "normal"
+	ImportanceHigh     Importance = "high"
+	ImportanceCritical Importance = "critical"
+)
+
+// importancePattern matches [importance: level] markers (case-insensitive).
+var importancePattern = regexp.MustCompile(`(?i)\[importance:\s*([a-z]+)\s*\]`)
+
+// importanceMarker matches a whole [importance: ...] marker, with any blanks
+// before it, so workerPrompt can cut it out without disturbing the rest of a
+// multi-line prompt.
+var importanceMarker = regexp.MustCompile(`(?i)[ \t]*\[importance:[^\]]*\]`)
+
+// thresholdOffsets maps each importance level to its adjustment of the global
+// review threshold.
+var thresholdOffsets = map[Importance]int{
+	ImportanceLow:      -2,
+	ImportanceNormal:   0,
+	ImportanceHigh:     1,
+	ImportanceCritical: 2,
+}
+
+// ParseImportance extracts the importance level from a subtask string.
+// Returns an empty Importance when there is no marker or the level is unknown.
+func ParseImportance(subtask string) Importance {
+	matches := importancePattern.FindStringSubmatch(subtask)
+	if matches == nil {
+		return ""
+	}
+	imp := Importance(strings.ToLower(matches[1]))
+	if _, ok := thresholdOffsets[imp]; !ok {
+		return ""
+	}
+	return imp
+}
+
+// ReviewThreshold returns the flag threshold for a subtask of the given
+// importance: base shifted by the level's offset and clamped to the 1-10
+// score range. Unannotated subtasks use base unchanged.
+func ReviewThreshold(imp Importance, base int) int {
+	t := base + thresholdOffsets[imp]
+	if t < 1 {
+		t = 1
+	}
+	if t > 10 {
+		t = 10
+	}
+	return t
+}
+
+// ReviewThresholds returns the per-subtask flag thresholds for subtasks,
+// falling back to base for subtasks without an importance marker.
+func ReviewThresholds(subtasks []string, base int) []int {
+	out := make([]int, len(subtasks))
+	for i, st := range subtasks {
+		imp := ParseImportance(st)
+		if imp == "" {
+			out[i] = base
+			continue
+		}
+		out[i] = ReviewThreshold(imp, base)
+	}
+	return out
+}
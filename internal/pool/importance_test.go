@@ -0,0 +1,99 @@
+package pool
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+func TestParseImportance(t *testing.T) {
+	tests := []struct {
+		name    string
+		subtask string
+		want    Importance
+	}{
+		{"critical", "Write auth middleware [importance: critical]", ImportanceCritical},
+		{"case insensitive", "Task [Importance: HIGH]", ImportanceHigh},
+		{"with other markers", "Docs [specialist: writer] [importance: low] [depends: 1]", ImportanceLow},
+		{"unknown level", "Task [importance: extreme]", ""},
+		{"no marker", "Build the API endpoint", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseImportance(tt.subtask); got != tt.want {
+				t.Errorf("ParseImportance(%q) = %q, want %q", tt.subtask, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkerPrompt_StripsImportance(t *testing.T) {
+	tests := []struct {
+		name, subtask, want string
+	}{
+		{"trailing", "Write auth middleware [importance: critical]", "Write auth middleware"},
+		{"inline", "Write auth [Importance: HIGH] middleware", "Write auth middleware"},
+		{"unknown level", "Task [importance: extreme]", "Task"},
+		{"keeps layout", "## Context\n\n    code()\n\n---\n\nUse it [importance: low]", "## Context\n\n    code()\n\n---\n\nUse it"},
+		{"no marker", "Build  the API", "Build  the API"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workerPrompt(tt.subtask); got != tt.want {
+				t.Errorf("workerPrompt(%q) = %q, want %q", tt.subtask, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteAll_StripsImportanceBeforeDispatch(t *testing.T) {
+	aliases := []string{"model-a"}
+	var mu sync.Mutex
+	var sent []string
+	router := newTestRouter(t, aliases, func(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		mu.Lock()
+		sent = append(sent, req.Messages[len(req.Messages)-1].Content)
+		mu.Unlock()
+		return &provider.ChatResponse{Message: provider.Message{Role: provider.RoleAssistant, Content: "ok"}, Done: true}, nil
+	})
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+
+	subtask := "Payment ledger migration [importance: critical]"
+	results := wp.ExecuteAll(context.Background(), []string{subtask}, "sys")
+
+	if len(sent) != 1 || strings.Contains(sent[0], "[importance") {
+		t.Errorf("worker received %q, want the marker stripped", sent)
+	}
+	if results[0].Subtask != subtask {
+		t.Errorf("result Subtask = %q, want the original %q", results[0].Subtask, subtask)
+	}
+}
+
+func TestReviewThresholds_PerSubtask(t *testing.T) {
+	subtasks := []string{
+		"Payment ledger migration [importance: critical]",
+		"REST handlers [importance: high]",
+		"Plain subtask",
+		"README examples [importance: normal]",
+		"Sample data generator [importance: low]",
+	}
+	got := ReviewThresholds(subtasks, 6)
+	want := []int{8, 7, 6, 6, 4}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("threshold[%d] (%q) = %d, want %d", i, subtasks[i], got[i], want[i])
+		}
+	}
+}
+
+func TestReviewThreshold_Clamped(t *testing.T) {
+	if got := ReviewThreshold(ImportanceCritical, 10); got != 10 {
+		t.Errorf("critical at base 10 = %d, want 10", got)
+	}
+	if got := ReviewThreshold(ImportanceLow, 2); got != 1 {
+		t.Errorf("low at base 2 = %d, want 1", got)
+	}
+}
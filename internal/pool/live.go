@@ -0,0 +1,124 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// SetLiveOutput makes ExecuteAll, ExecuteAllWithModels, and the DAG variants
+// stream worker responses and copy the text to a writer as it arrives, so a
+// long run can be followed with tail -f. open is called with each subtask's
+// index when its worker starts, and the writer is closed when it finishes;
+// retries and tool rounds are marked in it. A subtask whose writer cannot be
+// opened runs without one. Subtasks with fallback chains are not streamed:
+// their text is written once the response is in. nil turns streaming off.
+// Not safe to call while an Execute call is in flight.
+func (wp *WorkerPool) SetLiveOutput(open func(idx int) (io.WriteCloser, error)) {
+	wp.liveOpen = open
+}
+
+// workerStream receives the output of a streaming worker.
+type workerStream struct {
+	live    io.Writer    // SetLiveOutput's writer; nil when off
+	onDelta func(string) // ExecuteAllStreaming's callback; nil when off
+}
+
+type streamKey struct{}
+
+// openStream returns ctx carrying where subtask idx's output streams to,
+// if anywhere, and the function that closes it. onDelta may be nil.
+func (wp *WorkerPool) openStream(ctx context.Context, idx int, onDelta func(idx int, text string)) (context.Context, func()) {
+	var ws workerStream
+	closeLive := func() {}
+	if wp.liveOpen != nil {
+		if w, err := wp.liveOpen(subtaskIndex(ctx, idx)); err == nil {
+			ws.live, closeLive = w, func() { w.Close() }
+		}
+	}
+	if onDelta != nil {
+		ws.onDelta = func(text string) { onDelta(idx, text) }
+	}
+	if ws.live == nil && ws.onDelta == nil {
+		return ctx, closeLive
+	}
+	return context.WithValue(ctx, streamKey{}, &ws), closeLive
+}
+
+// streamFrom returns the worker stream carried by ctx, or nil.
+func streamFrom(ctx context.Context) *workerStream {
+	ws, _ := ctx.Value(streamKey{}).(*workerStream)
+	return ws
+}
+
+// write passes a piece of the response on.
+func (ws *workerStream) write(text string) {
+	if ws.live != nil {
+		io.WriteString(ws.live, text)
+	}
+	if ws.onDelta != nil {
+		ws.onDelta(text)
+	}
+}
+
+// chat sends a worker request, streaming it when ctx carries a worker
+// stream.
+func (wp *WorkerPool) chat(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	ws := streamFrom(ctx)
+	if ws == nil {
+		return wp.router.ChatCompletion(ctx, req)
+	}
+	stream, err := wp.router.StreamChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	return provider.CollectStream(stream, ws.write)
+}
+
+// chatWithFallbacks sends a worker request with a fallback chain. The
+// response is not streamed, but its text is still passed on whole.
+func (wp *WorkerPool) chatWithFallbacks(ctx context.Context, req *provider.ChatRequest, fallbacks []string) (*provider.ChatResponse, error) {
+	resp, err := wp.router.ChatCompletionWithFallbacks(ctx, req, fallbacks)
+	if ws := streamFrom(ctx); ws != nil && err == nil {
+		ws.write(resp.Message.Content)
+	}
+	return resp, err
+}
+
+// liveNote writes a marker line, such as a retry notice, to the live
+// writer. Delta callbacks get only model text.
+func liveNote(ctx context.Context, format string, args ...any) {
+	if ws := streamFrom(ctx); ws != nil && ws.live != nil {
+		fmt.Fprintf(ws.live, "\n--- "+format+" ---\n", args...)
+	}
+}
+
+// toolNames lists the tools whose results end req's messages.
+func toolNames(req *provider.ChatRequest) string {
+	var names []string
+	for i := len(req.Messages) - 1; i >= 0 && req.Messages[i].Role == provider.RoleTool; i-- {
+		names = append([]string{req.Messages[i].Name}, names...)
+	}
+	return strings.Join(names, ", ")
+}
+
+type waveKey struct{}
+
+// withWave records that the subtasks being executed are the given indices
+// of a larger list, for the DAG variants that run one wave at a time.
+func withWave(ctx context.Context, indices []int) context.Context {
+	return context.WithValue(ctx, waveKey{}, indices)
+}
+
+// subtaskIndex maps an index into the subtasks being executed to its index
+// in the full list.
+func subtaskIndex(ctx context.Context, idx int) int {
+	if indices, ok := ctx.Value(waveKey{}).([]int); ok && idx < len(indices) {
+		return indices[idx]
+	}
+	return idx
+}
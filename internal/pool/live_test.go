@@ -0,0 +1,249 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// gatedStream returns its chunks in order, waiting on gates[i] (when set)
+// before chunk i.
+type gatedStream struct {
+	chunks []string
+	gates  map[int]chan struct{}
+	next   int
+	provider.StreamCounter
+}
+
+func (s *gatedStream) Next() (*provider.ChatStreamChunk, error) {
+	if s.next == len(s.chunks) {
+		return nil, io.EOF
+	}
+	if g, ok := s.gates[s.next]; ok {
+		<-g
+	}
+	c := &provider.ChatStreamChunk{Model: "real-model", Delta: provider.MessageDelta{Content: s.chunks[s.next]}}
+	s.next++
+	if s.next == len(s.chunks) {
+		c.Done = true
+		c.Usage = &provider.Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}
+	}
+	s.Observe(c)
+	return c, nil
+}
+
+func (s *gatedStream) Close() error { return nil }
+
+// newStreamRouter routes every alias to one mock provider that streams with
+// streamFn.
+func newStreamRouter(t *testing.T, aliases []string, streamFn func(ctx context.Context, req *provider.ChatRequest) (provider.ChatStream, error)) *provider.Router {
+	t.Helper()
+	factories := make(map[string]provider.ProviderFactory)
+	for i := range aliases {
+		mp := &mockProvider{name: fmt.Sprintf("mock-%d", i), streamFn: streamFn}
+		factories[mp.name] = func(provider.ProviderConfig) (provider.Provider, error) { return mp, nil }
+	}
+	r, err := provider.NewRouter(testConfig(aliases), factories)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r
+}
+
+// liveFiles opens dir/worker-N.live like the pipeline does.
+func liveFiles(dir string) func(int) (io.WriteCloser, error) {
+	return func(idx int) (io.WriteCloser, error) {
+		return os.Create(filepath.Join(dir, fmt.Sprintf("worker-%d.live", idx+1)))
+	}
+}
+
+func readLive(t *testing.T, dir string, n int) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("worker-%d.live", n)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestLiveOutput_GrowsDuringGeneration(t *testing.T) {
+	dir := t.TempDir()
+	gate := make(chan struct{})
+	chunks := []string{"===FILE: main.go===\n", "package main\n", "===ENDFILE==="}
+	router := newStreamRouter(t, []string{"model-a"}, func(context.Context, *provider.ChatRequest) (provider.ChatStream, error) {
+		return &gatedStream{chunks: chunks, gates: map[int]chan struct{}{2: gate}}, nil
+	})
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), []string{"model-a"})
+	wp.SetLiveOutput(liveFiles(dir))
+
+	done := make(chan []string)
+	go func() {
+		var out []string
+		for _, r := range wp.ExecuteAll(context.Background(), []string{"write main"}, "sys") {
+			out = append(out, r.Response)
+		}
+		done <- out
+	}()
+
+	// The first two chunks are on disk while the worker is still generating.
+	partial := chunks[0] + chunks[1]
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b, _ := os.ReadFile(filepath.Join(dir, "worker-1.live"))
+		if string(b) == partial {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("live file = %q while generating, want %q", b, partial)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(gate)
+
+	got := <-done
+	want := strings.Join(chunks, "")
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("responses = %q, want %q", got, want)
+	}
+	if live := readLive(t, dir, 1); live != want {
+		t.Errorf("final live file = %q, want %q", live, want)
+	}
+}
+
+func TestLiveOutput_DAGUsesSubtaskIndex(t *testing.T) {
+	dir := t.TempDir()
+	router := newStreamRouter(t, []string{"model-a", "model-b"}, func(_ context.Context, req *provider.ChatRequest) (provider.ChatStream, error) {
+		task := req.Messages[len(req.Messages)-1].Content
+		return &gatedStream{chunks: []string{"did: " + task[strings.LastIndex(task, "\n")+1:]}}, nil
+	})
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), []string{"model-a", "model-b"})
+	wp.SetLiveOutput(liveFiles(dir))
+
+	subtasks := []string{"first", "second [depends: 1]", "third [depends: 2]"}
+	if _, err := wp.ExecuteDAG(context.Background(), subtasks, ParseDependencies(subtasks), "sys"); err != nil {
+		t.Fatalf("ExecuteDAG: %v", err)
+	}
+	for i, want := range []string{"did: first", "did: second", "did: third"} {
+		if got := readLive(t, dir, i+1); got != want {
+			t.Errorf("worker-%d.live = %q, want %q", i+1, got, want)
+		}
+	}
+}
+
+func TestLiveOutput_MarksRetry(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	router := newStreamRouter(t, []string{"model-a"}, func(context.Context, *provider.ChatRequest) (provider.ChatStream, error) {
+		calls++
+		if calls == 1 {
+			return nil, &provider.APIError{Status: 503, Message: "overloaded"}
+		}
+		return &gatedStream{chunks: []string{"second try"}}, nil
+	})
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), []string{"model-a"})
+	wp.SetLiveOutput(liveFiles(dir))
+
+	res := wp.ExecuteAll(context.Background(), []string{"task"}, "sys")
+	if res[0].Err != nil || res[0].Response != "second try" || res[0].Tokens != 10 {
+		t.Fatalf("result = %+v", res[0])
+	}
+	if got := readLive(t, dir, 1); got != "\n--- retry on model-a ---\nsecond try" {
+		t.Errorf("live file = %q", got)
+	}
+}
+
+func TestLiveOutput_OpenFailureRunsWithout(t *testing.T) {
+	router := newTestRouter(t, []string{"model-a"}, nil)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), []string{"model-a"})
+	wp.SetLiveOutput(func(int) (io.WriteCloser, error) { return nil, errors.New("read-only file system") })
+
+	res := wp.ExecuteAll(context.Background(), []string{"task"}, "sys")
+	if res[0].Err != nil || res[0].Response != "response for: task" {
+		t.Errorf("result = %+v, want the unstreamed response", res[0])
+	}
+}
+
+func TestExecuteAllStreaming(t *testing.T) {
+	chunksFor := func(req *provider.ChatRequest) []string {
+		task := req.Messages[len(req.Messages)-1].Content
+		return []string{"working on ", task, ": ", "done"}
+	}
+	aliases := []string{"model-a", "model-b"}
+	factories := make(map[string]provider.ProviderFactory)
+	for i := range aliases {
+		mp := &mockProvider{
+			name: fmt.Sprintf("mock-%d", i),
+			chatFn: func(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+				return &provider.ChatResponse{
+					Model:   "real-model",
+					Message: provider.Message{Role: provider.RoleAssistant, Content: strings.Join(chunksFor(req), "")},
+					Usage:   provider.Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10},
+					Done:    true,
+				}, nil
+			},
+			streamFn: func(_ context.Context, req *provider.ChatRequest) (provider.ChatStream, error) {
+				return &gatedStream{chunks: chunksFor(req)}, nil
+			},
+		}
+		factories[mp.name] = func(provider.ProviderConfig) (provider.Provider, error) { return mp, nil }
+	}
+	router, err := provider.NewRouter(testConfig(aliases), factories)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	subtasks := []string{"parser", "printer"}
+
+	var mu sync.Mutex
+	var events []string
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+	wp.SetProgressHook(func(idx int, r role.WorkerResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, fmt.Sprintf("%d done", idx))
+	})
+	streamed := wp.ExecuteAllStreaming(context.Background(), subtasks, "sys", func(idx int, delta string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, fmt.Sprintf("%d delta %s", idx, delta))
+	})
+
+	for idx, r := range streamed {
+		var deltas []string
+		done := false
+		for _, ev := range events {
+			prefix := fmt.Sprintf("%d ", idx)
+			if !strings.HasPrefix(ev, prefix) {
+				continue
+			}
+			if ev == prefix+"done" {
+				done = true
+			} else if done {
+				t.Errorf("subtask %d: %q after its result", idx, ev)
+			} else {
+				deltas = append(deltas, strings.TrimPrefix(ev, prefix+"delta "))
+			}
+		}
+		if len(deltas) != 4 || strings.Join(deltas, "") != r.Response {
+			t.Errorf("subtask %d: deltas %q, want 4 joining to %q", idx, deltas, r.Response)
+		}
+	}
+
+	plain := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases).ExecuteAll(context.Background(), subtasks, "sys")
+	for i := range plain {
+		// Roles are left out: workers race for the round-robin balancer.
+		s, p := streamed[i], plain[i]
+		if s.Subtask != p.Subtask || s.Response != p.Response || s.Tokens != p.Tokens || s.TokensEst != p.TokensEst || s.Err != p.Err {
+			t.Errorf("result %d streamed = %+v, plain = %+v", i, s, p)
+		}
+	}
+}
@@ -5,6 +5,7 @@ package pool
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"time"
@@ -14,21 +15,84 @@ import (
 )
 
 // WorkerPool dispatches subtasks concurrently across a pool of model aliases.
-// It uses a Balancer for round-robin assignment and the Router for request routing.
+// It uses a Balancer to assign aliases and the Router for request routing.
 type WorkerPool struct {
 	router     *provider.Router
 	balancer   *provider.Balancer
 	aliases    []string                           // pool model aliases
 	onComplete func(idx int, r role.WorkerResult) // optional per-worker completion hook
+
+	healthInterval time.Duration // 0 = health checks disabled
+	health         health        // ejected aliases
+
+	tagRoutes map[string]string // subtask tag → pinned pool alias
+
+	maxWorkers int // concurrency cap; 0 = one worker per pool member
+
+	subtaskTimeout time.Duration // per-subtask deadline; 0 = none
+	subtaskRetries int           // retries on alternate members; 0 = one retry in place
+
+	reliability reliability // per-alias outcome counts
+
+	tools    []provider.Tool   // offered to workers; see WithTools
+	toolExec role.ToolExecutor // runs the tools workers call
+
+	liveOpen func(idx int) (io.WriteCloser, error) // see SetLiveOutput
 }
 
 // New creates a WorkerPool with the given router, balancer, and pool model aliases.
-func New(router *provider.Router, balancer *provider.Balancer, aliases []string) *WorkerPool {
-	return &WorkerPool{
+func New(router *provider.Router, balancer *provider.Balancer, aliases []string, opts ...Option) *WorkerPool {
+	wp := &WorkerPool{
 		router:   router,
 		balancer: balancer,
 		aliases:  aliases,
 	}
+	for _, opt := range opts {
+		opt(wp)
+	}
+	return wp
+}
+
+// WithMaxWorkers caps how many subtasks run at once, below the default of one
+// per pool member. Values <= 0 leave the default in place.
+func WithMaxWorkers(n int) Option {
+	return func(wp *WorkerPool) {
+		wp.maxWorkers = n
+	}
+}
+
+// SetMaxWorkers changes the concurrency cap for subsequent Execute calls, e.g.
+// to dispatch build fixes with a tighter cap than the main worker phase.
+// Values <= 0 restore the default of one worker per pool member. Not safe to
+// call while an Execute call is in flight.
+func (wp *WorkerPool) SetMaxWorkers(n int) {
+	wp.maxWorkers = n
+}
+
+// concurrency returns how many of n subtasks may run at once:
+// min(n, pool size, maxWorkers), and at least 1.
+func (wp *WorkerPool) concurrency(n int) int {
+	c := len(wp.aliases)
+	if wp.maxWorkers > 0 && wp.maxWorkers < c {
+		c = wp.maxWorkers
+	}
+	if n < c {
+		c = n
+	}
+	if c < 1 {
+		c = 1
+	}
+	return c
+}
+
+// workerPrompt returns the text a worker receives for subtask: the subtask
+// with the Mayor's bookkeeping markers removed. Markers are read from the
+// original subtask (see ReviewThresholds and pinnedAlias) and mean nothing to
+// the worker.
+func workerPrompt(subtask string) string {
+	subtask = importanceMarker.ReplaceAllString(subtask, "")
+	subtask = tagMarker.ReplaceAllString(subtask, "")
+	return strings.TrimSpace(subtask)
 }
 
 // SetProgressHook registers a callback invoked when each worker finishes.
@@ -39,6 +103,28 @@ func (wp *WorkerPool) SetProgressHook(fn func(idx int, r role.WorkerResult)) {
 	wp.onComplete = fn
 }
 
+// Preview returns the alias each subtask would be dispatched to, without
+// sending anything: models[i] when set (as for ExecuteAllWithModels), then
+// the subtask's tag route, then the balancer's pick. Balancer picks advance
+// its rotation like a real dispatch would, so Preview is meant for a pool
+// that will not then execute, e.g. a dry run. Least-loaded picks assume
+// every worker finishes before the next starts.
+func (wp *WorkerPool) Preview(subtasks []string, models []string) []string {
+	out := make([]string, len(subtasks))
+	for i, task := range subtasks {
+		switch {
+		case i < len(models) && models[i] != "":
+			out[i] = models[i]
+		case wp.pinnedAlias(task) != "":
+			out[i] = wp.pinnedAlias(task)
+		default:
+			out[i] = wp.balancer.Select("pool", wp.rotation())
+			wp.balancer.Release("pool", out[i])
+		}
+	}
+	return out
+}
+
 // ExecuteDAG dispatches subtasks respecting dependency ordering. Tasks are
 // grouped into execution waves via topological sort — each wave runs in
 // parallel, and completed task outputs are injected into dependent tasks'
@@ -76,7 +162,7 @@ func (wp *WorkerPool) ExecuteDAG(ctx context.Context, subtasks []string, deps ma
 		}
 
 		// Execute this wave in parallel.
-		waveResults := wp.ExecuteAll(ctx, waveSubtasks, systemPrompt)
+		waveResults := wp.ExecuteAll(withWave(ctx, waveIndices), waveSubtasks, systemPrompt)
 		for i, r := range waveResults {
 			r.Subtask = subtasks[waveIndices[i]] // preserve original subtask text
 			results[waveIndices[i]] = r
@@ -128,7 +214,7 @@ func (wp *WorkerPool) ExecuteDAGWithModels(ctx context.Context, subtasks []strin
 			}
 		}
 
-		waveResults := wp.ExecuteAllWithModels(ctx, waveSubtasks, waveModels, waveFallbacks, systemPrompt)
+		waveResults := wp.ExecuteAllWithModels(withWave(ctx, waveIndices), waveSubtasks, waveModels, waveFallbacks, systemPrompt)
 		for i, r := range waveResults {
 			r.Subtask = subtasks[waveIndices[i]]
 			results[waveIndices[i]] = r
@@ -147,14 +233,10 @@ func (wp *WorkerPool) ExecuteAllWithModels(ctx context.Context, subtasks []strin
 	n := len(subtasks)
 	results := make([]role.WorkerResult, n)
 
-	maxConcurrency := len(wp.aliases)
-	if n < maxConcurrency {
-		maxConcurrency = n
-	}
-	if maxConcurrency == 0 {
-		maxConcurrency = 1
-	}
-	sem := make(chan struct{}, maxConcurrency)
+	sem := make(chan struct{}, wp.concurrency(n))
+
+	stopHealth := wp.startHealthCheck(ctx)
+	defer stopHealth()
 
 	var wg sync.WaitGroup
 	for i, subtask := range subtasks {
@@ -164,21 +246,28 @@ func (wp *WorkerPool) ExecuteAllWithModels(ctx context.Context, subtasks []strin
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			// Use per-subtask model override if provided, otherwise balancer.
+			// Use per-subtask model override if provided, then the subtask's
+			// tag route, otherwise the balancer.
 			alias := ""
+			fromPool := false
 			if models != nil && idx < len(models) && models[idx] != "" {
 				alias = models[idx]
+			} else if alias = wp.pinnedAlias(task); alias != "" {
+				fromPool = true
 			} else {
-				alias = wp.balancer.Select("pool", wp.aliases)
+				alias = wp.balancer.Select("pool", wp.rotation())
+				fromPool = true
+				defer wp.balancer.Release("pool", alias)
 			}
 
 			req := &provider.ChatRequest{
 				Model: alias,
 				Messages: []provider.Message{
-					{Role: provider.RoleSystem, Content: systemPrompt},
-					{Role: provider.RoleUser, Content: task},
+					{Role: provider.RoleSystem, Content: systemPrompt, Cacheable: true},
+					{Role: provider.RoleUser, Content: workerPrompt(task)},
 				},
 			}
+			wp.attachTools(req)
 
 			// Use fallback-aware routing when fallbacks are configured for this subtask.
 			var fb []string
@@ -186,18 +275,28 @@ func (wp *WorkerPool) ExecuteAllWithModels(ctx context.Context, subtasks []strin
 				fb = fallbacks[idx]
 			}
 
+			sctx, cancel := wp.subtaskContext(ctx)
+			defer cancel()
+			sctx, closeStream := wp.openStream(sctx, idx, nil)
+			defer closeStream()
+
 			start := time.Now()
 			var resp *provider.ChatResponse
 			var err error
 			if len(fb) > 0 {
-				resp, err = wp.router.ChatCompletionWithFallbacks(ctx, req, fb)
+				resp, err = wp.chatWithFallbacks(sctx, req, fb)
+				wp.reliability.record(alias, resp, err, time.Since(start))
 			} else {
-				resp, err = wp.router.ChatCompletion(ctx, req)
-				if err != nil {
-					// Retry once on transient failure (existing behavior).
-					resp, err = wp.router.ChatCompletion(ctx, req)
+				resp, err = wp.chat(sctx, req)
+				wp.reliability.record(alias, resp, err, time.Since(start))
+				if err != nil && !timedOut(ctx, sctx) {
+					resp, alias, err = wp.retry(ctx, sctx, req, alias, fromPool, err)
 				}
 			}
+			if err == nil {
+				resp, err = wp.finishTools(sctx, req, resp, alias, fb)
+			}
+			err = wp.subtaskErr(ctx, sctx, err)
 			elapsed := time.Since(start)
 
 			result := role.WorkerResult{
@@ -206,11 +305,12 @@ func (wp *WorkerPool) ExecuteAllWithModels(ctx context.Context, subtasks []strin
 				Elapsed: elapsed,
 			}
 			if err != nil {
-				result.Response = fmt.Sprintf("error: %v", err)
+				result.Err = err
 				provider.DumpFailedRequest(alias, req.Messages, err)
 			} else {
 				result.Response = resp.Message.Content
 				result.Tokens = resp.Usage.TotalTokens
+				result.TokensEst = resp.Usage.Estimated
 			}
 
 			results[idx] = result
@@ -226,20 +326,37 @@ func (wp *WorkerPool) ExecuteAllWithModels(ctx context.Context, subtasks []strin
 }
 
 // ExecuteAll dispatches subtasks concurrently across pool members. Each subtask
-// is assigned a model alias via the Balancer (round-robin). Concurrency is bounded
-// to min(len(subtasks), len(aliases)) goroutines. Results are returned in subtask
-// order. Per-worker errors do not abort other workers — failed subtasks are reported
-// in the result with a non-empty Error field.
+// is assigned a model alias via the Balancer. Concurrency is bounded to
+// min(len(subtasks), len(aliases)) goroutines, further capped by WithMaxWorkers.
+// Results are returned in subtask order. Per-worker errors do not abort other
+// workers — failed subtasks are reported in the result with a non-nil Err
+// field.
 func (wp *WorkerPool) ExecuteAll(ctx context.Context, subtasks []string, systemPrompt string) []role.WorkerResult {
+	return wp.executeAll(ctx, subtasks, systemPrompt, nil)
+}
+
+// ExecuteAllStreaming is ExecuteAll with workers streaming their responses:
+// onDelta is called with each piece of a worker's text as it arrives, so
+// callers can show output live or measure tokens per second. Calls for one
+// subtask are in order and all come before its progress hook; calls for
+// different subtasks run concurrently. For a successful worker the pieces
+// of its final attempt and round join to its Response. A failed attempt's
+// pieces are not retracted before a retry, and each tool round's text is
+// passed on as it streams. Results are the same as ExecuteAll's.
+func (wp *WorkerPool) ExecuteAllStreaming(ctx context.Context, subtasks []string, systemPrompt string, onDelta func(idx int, delta string)) []role.WorkerResult {
+	return wp.executeAll(ctx, subtasks, systemPrompt, onDelta)
+}
+
+// executeAll implements ExecuteAll and ExecuteAllStreaming.
+func (wp *WorkerPool) executeAll(ctx context.Context, subtasks []string, systemPrompt string, onDelta func(idx int, delta string)) []role.WorkerResult {
 	n := len(subtasks)
 	results := make([]role.WorkerResult, n)
 
-	// Bounded concurrency: min(subtasks, pool size).
-	maxConcurrency := len(wp.aliases)
-	if n < maxConcurrency {
-		maxConcurrency = n
-	}
-	sem := make(chan struct{}, maxConcurrency)
+	// Bounded concurrency: min(subtasks, pool size, max workers).
+	sem := make(chan struct{}, wp.concurrency(n))
+
+	stopHealth := wp.startHealthCheck(ctx)
+	defer stopHealth()
 
 	var wg sync.WaitGroup
 	for i, subtask := range subtasks {
@@ -249,22 +366,37 @@ func (wp *WorkerPool) ExecuteAll(ctx context.Context, subtasks []string, systemP
 			sem <- struct{}{}        // acquire
 			defer func() { <-sem }() // release
 
-			alias := wp.balancer.Select("pool", wp.aliases)
+			alias := wp.pinnedAlias(task)
+			if alias == "" {
+				alias = wp.balancer.Select("pool", wp.rotation())
+				defer wp.balancer.Release("pool", alias)
+			}
 
 			req := &provider.ChatRequest{
 				Model: alias,
 				Messages: []provider.Message{
-					{Role: provider.RoleSystem, Content: systemPrompt},
-					{Role: provider.RoleUser, Content: task},
+					{Role: provider.RoleSystem, Content: systemPrompt, Cacheable: true},
+					{Role: provider.RoleUser, Content: workerPrompt(task)},
 				},
 			}
 
+			wp.attachTools(req)
+
+			sctx, cancel := wp.subtaskContext(ctx)
+			defer cancel()
+			sctx, closeStream := wp.openStream(sctx, idx, onDelta)
+			defer closeStream()
+
 			start := time.Now()
-			resp, err := wp.router.ChatCompletion(ctx, req)
-			if err != nil {
-				// Retry once on transient failure.
-				resp, err = wp.router.ChatCompletion(ctx, req)
+			resp, err := wp.chat(sctx, req)
+			wp.reliability.record(alias, resp, err, time.Since(start))
+			if err != nil && !timedOut(ctx, sctx) {
+				resp, alias, err = wp.retry(ctx, sctx, req, alias, true, err)
+			}
+			if err == nil {
+				resp, err = wp.finishTools(sctx, req, resp, alias, nil)
 			}
+			err = wp.subtaskErr(ctx, sctx, err)
 			elapsed := time.Since(start)
 
 			result := role.WorkerResult{
@@ -273,12 +405,13 @@ func (wp *WorkerPool) ExecuteAll(ctx context.Context, subtasks []string, systemP
 				Elapsed: elapsed,
 			}
 			if err != nil {
-				result.Response = fmt.Sprintf("error: %v", err)
+				result.Err = err
 				// Dump failed request for offline debugging.
 				provider.DumpFailedRequest(alias, req.Messages, err)
 			} else {
 				result.Response = resp.Message.Content
 				result.Tokens = resp.Usage.TotalTokens
+				result.TokensEst = resp.Usage.Estimated
 			}
 
 			results[idx] = result
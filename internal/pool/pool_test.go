@@ -6,14 +6,17 @@ import (
 	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/meganerd/electrictown/internal/provider"
 )
 
 // mockProvider implements provider.Provider for testing.
 type mockProvider struct {
-	name   string
-	chatFn func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error)
+	name     string
+	chatFn   func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error)
+	streamFn func(ctx context.Context, req *provider.ChatRequest) (provider.ChatStream, error)
+	listFn   func(ctx context.Context) ([]provider.Model, error)
 }
 
 func (m *mockProvider) Name() string { return m.name }
@@ -32,10 +35,16 @@ func (m *mockProvider) ChatCompletion(ctx context.Context, req *provider.ChatReq
 }
 
 func (m *mockProvider) StreamChatCompletion(ctx context.Context, req *provider.ChatRequest) (provider.ChatStream, error) {
+	if m.streamFn != nil {
+		return m.streamFn(ctx, req)
+	}
 	return nil, fmt.Errorf("not implemented")
 }
 
 func (m *mockProvider) ListModels(ctx context.Context) ([]provider.Model, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx)
+	}
 	return nil, nil
 }
 
@@ -166,9 +175,12 @@ func TestExecuteAll_PartialFailure(t *testing.T) {
 		t.Fatalf("expected 3 results, got %d", len(results))
 	}
 
-	// "fail-me" (index 1) should have failed.
-	if !strings.Contains(results[1].Response, "error:") {
-		t.Errorf("expected error in result[1], got: %s", results[1].Response)
+	// "fail-me" (index 1) should have failed, with no response content.
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "model unavailable") {
+		t.Errorf("expected error in result[1], got: %v", results[1].Err)
+	}
+	if results[1].Response != "" {
+		t.Errorf("failed result[1].Response = %q, want empty", results[1].Response)
 	}
 	// Others should have succeeded.
 	if results[0].Response != "success" {
@@ -177,6 +189,30 @@ func TestExecuteAll_PartialFailure(t *testing.T) {
 	if results[2].Response != "success" {
 		t.Errorf("expected success in result[2], got: %s", results[2].Response)
 	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("successful results carry errors: %v, %v", results[0].Err, results[2].Err)
+	}
+}
+
+func TestExecuteAll_ErrorPrefixedContentIsNotAFailure(t *testing.T) {
+	aliases := []string{"model-a"}
+	// A worker legitimately writing about error messages.
+	router := newTestRouter(t, aliases, func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		return &provider.ChatResponse{
+			Model:   req.Model,
+			Message: provider.Message{Role: provider.RoleAssistant, Content: "error: wrap failures with %w"},
+			Done:    true,
+		}, nil
+	})
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+
+	results := wp.ExecuteAll(context.Background(), []string{"document error handling"}, "sys")
+	if results[0].Err != nil {
+		t.Errorf("Err = %v, want nil for a successful response", results[0].Err)
+	}
+	if results[0].Response != "error: wrap failures with %w" {
+		t.Errorf("Response = %q", results[0].Response)
+	}
 }
 
 func TestExecuteAll_BoundedConcurrency(t *testing.T) {
@@ -250,3 +286,143 @@ func TestExecuteAll_SingleSubtask(t *testing.T) {
 		t.Errorf("expected subtask 'only-one', got %q", results[0].Subtask)
 	}
 }
+
+func TestExecuteAll_LeastLoadedFavorsFastMember(t *testing.T) {
+	aliases := []string{"slow", "fast"}
+	router := newTestRouter(t, aliases, func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		// real-model-0 backs "slow", real-model-1 backs "fast".
+		if req.Model == "real-model-0" {
+			time.Sleep(40 * time.Millisecond)
+		} else {
+			time.Sleep(2 * time.Millisecond)
+		}
+		return &provider.ChatResponse{
+			ID:      "ok",
+			Model:   req.Model,
+			Message: provider.Message{Role: provider.RoleAssistant, Content: "done"},
+			Usage:   provider.Usage{TotalTokens: 10},
+			Done:    true,
+		}, nil
+	})
+	balancer := provider.NewBalancer(provider.StrategyLeastLoaded)
+
+	wp := New(router, balancer, aliases)
+	subtasks := make([]string, 12)
+	for i := range subtasks {
+		subtasks[i] = fmt.Sprintf("task-%d", i)
+	}
+
+	results := wp.ExecuteAll(context.Background(), subtasks, "sys")
+
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Role]++
+	}
+	if counts["fast"] <= counts["slow"] {
+		t.Errorf("expected more subtasks on the fast member, got %v", counts)
+	}
+}
+
+// peakTracker returns a chat function that records the highest number of
+// concurrent requests it observed, holding each one long enough to overlap.
+func peakTracker(peak *int32) func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	var current int32
+	return func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		c := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+		for {
+			old := atomic.LoadInt32(peak)
+			if c <= old || atomic.CompareAndSwapInt32(peak, old, c) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return &provider.ChatResponse{
+			Model:   req.Model,
+			Message: provider.Message{Role: provider.RoleAssistant, Content: "done"},
+			Done:    true,
+		}, nil
+	}
+}
+
+func TestExecuteAll_MaxWorkers(t *testing.T) {
+	aliases := []string{"model-a", "model-b", "model-c", "model-d"}
+	var peak int32
+	router := newTestRouter(t, aliases, peakTracker(&peak))
+
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithMaxWorkers(2))
+	results := wp.ExecuteAll(context.Background(), []string{"a", "b", "c", "d", "e", "f"}, "sys")
+
+	if len(results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(results))
+	}
+	if p := atomic.LoadInt32(&peak); p > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", p)
+	}
+}
+
+func TestExecuteAll_FixDispatchHonorsSetMaxWorkers(t *testing.T) {
+	aliases := []string{"model-a", "model-b", "model-c", "model-d"}
+	var peak int32
+	router := newTestRouter(t, aliases, peakTracker(&peak))
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithMaxWorkers(3))
+
+	// Main phase runs at the configured cap...
+	wp.ExecuteAll(context.Background(), []string{"a", "b", "c", "d"}, "sys")
+	if p := atomic.LoadInt32(&peak); p != 3 {
+		t.Errorf("main phase peak = %d, want 3", p)
+	}
+
+	// ...and the fix dispatch at its own, tighter cap.
+	atomic.StoreInt32(&peak, 0)
+	wp.SetMaxWorkers(1)
+	results := wp.ExecuteAll(context.Background(), []string{"fix 1", "fix 2", "fix 3"}, "sys")
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("fix %d failed: %v", i, r.Err)
+		}
+	}
+	if p := atomic.LoadInt32(&peak); p != 1 {
+		t.Errorf("fix dispatch peak = %d, want 1", p)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	aliases := []string{"model-a", "model-b"}
+	var calls int32
+	router := newTestRouter(t, aliases, func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, fmt.Errorf("unexpected dispatch")
+	})
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithTagRoutes(map[string]string{"docs": "model-b"}))
+
+	subtasks := []string{"one", "two", "readme [tag: docs]", "three", "four"}
+	got := wp.Preview(subtasks, []string{"", "", "", "", "specialist-x"})
+	want := []string{"model-a", "model-b", "model-b", "model-a", "specialist-x"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Preview = %v, want %v", got, want)
+	}
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Errorf("Preview sent %d requests, want none", n)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	wp := &WorkerPool{aliases: []string{"a", "b", "c"}}
+	cases := []struct {
+		max, n, want int
+	}{
+		{0, 5, 3},  // pool size
+		{0, 2, 2},  // fewer subtasks
+		{2, 5, 2},  // capped
+		{10, 5, 3}, // cap above pool size has no effect
+		{-1, 5, 3}, // negative = default
+		{0, 0, 1},  // never zero
+	}
+	for _, c := range cases {
+		wp.maxWorkers = c.max
+		if got := wp.concurrency(c.n); got != c.want {
+			t.Errorf("concurrency(max=%d, n=%d) = %d, want %d", c.max, c.n, got, c.want)
+		}
+	}
+}
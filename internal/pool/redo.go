@@ -0,0 +1,137 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// Scorer scores a worker's output for a subtask. role.Reviewer and
+// role.ReviewerPanel both satisfy it.
+type Scorer interface {
+	Score(ctx context.Context, subtask, response string) (score int, note string, err error)
+}
+
+// Redo records one re-dispatch of a flagged result.
+type Redo struct {
+	Index    int    // 0-based subtask index
+	From     string // alias that produced the flagged output
+	To       string // alias the subtask was re-dispatched to
+	OldScore int
+	NewScore int   // 0 when the redo failed or could not be scored
+	Kept     bool  // the redo scored higher and replaced the original
+	Err      error // dispatch or scoring failure, if any
+}
+
+// RedoFlagged re-dispatches every flagged result once, preferring a pool
+// member other than the one that produced it, with the reviewer's note
+// appended to the subtask. Each redo is scored with scorer and replaces the
+// original in results only when it scores higher; its Flagged state is then
+// recomputed against thresholds[i]. There is a single pass, so a run costs at
+// most one extra worker call and one extra review per flagged subtask.
+func (wp *WorkerPool) RedoFlagged(ctx context.Context, results []role.WorkerResult, thresholds []int, systemPrompt string, scorer Scorer) []Redo {
+	var flagged []int
+	for i, r := range results {
+		if r.Flagged {
+			flagged = append(flagged, i)
+		}
+	}
+	redos := make([]Redo, len(flagged))
+	sem := make(chan struct{}, wp.concurrency(len(flagged)))
+
+	var wg sync.WaitGroup
+	for n, idx := range flagged {
+		wg.Add(1)
+		go func(n, idx int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			orig := results[idx]
+			rd := Redo{Index: idx, From: orig.Role, To: wp.redoAlias(orig.Role), OldScore: orig.ReviewScore}
+			defer func() { redos[n] = rd }()
+			if rd.To != rd.From {
+				defer wp.balancer.Release("pool", rd.To)
+			}
+
+			req := &provider.ChatRequest{
+				Model: rd.To,
+				Messages: []provider.Message{
+					{Role: provider.RoleSystem, Content: systemPrompt, Cacheable: true},
+					{Role: provider.RoleUser, Content: redoPrompt(orig)},
+				},
+			}
+			wp.attachTools(req)
+			sctx, cancel := wp.subtaskContext(ctx)
+			defer cancel()
+			start := time.Now()
+			resp, err := wp.router.ChatCompletion(sctx, req)
+			wp.reliability.record(rd.To, resp, err, time.Since(start))
+			if err == nil {
+				resp, err = wp.finishTools(sctx, req, resp, rd.To, nil)
+			}
+			if err = wp.subtaskErr(ctx, sctx, err); err != nil {
+				rd.Err = fmt.Errorf("pool: redo subtask %d on %s: %w", idx+1, rd.To, err)
+				return
+			}
+
+			score, note, err := scorer.Score(ctx, orig.Subtask, resp.Message.Content)
+			if err != nil {
+				rd.Err = fmt.Errorf("pool: score redo of subtask %d: %w", idx+1, err)
+				return
+			}
+			rd.NewScore = score
+			if score <= orig.ReviewScore {
+				return
+			}
+			rd.Kept = true
+			threshold := 0
+			if idx < len(thresholds) {
+				threshold = thresholds[idx]
+			}
+			results[idx] = role.WorkerResult{
+				Role:        rd.To,
+				Subtask:     orig.Subtask,
+				Response:    resp.Message.Content,
+				Tokens:      orig.Tokens + resp.Usage.TotalTokens,
+				TokensEst:   orig.TokensEst || resp.Usage.Estimated,
+				Elapsed:     orig.Elapsed + time.Since(start),
+				ReviewScore: score,
+				ReviewNote:  note,
+				Flagged:     score < threshold,
+			}
+		}(n, idx)
+	}
+	wg.Wait()
+	return redos
+}
+
+// redoAlias picks the pool member to re-dispatch a flagged result from
+// alias to: the balancer's choice among the other members in rotation, or
+// alias itself when it is the only one. A different member must be released
+// to the balancer once the redo finishes.
+func (wp *WorkerPool) redoAlias(alias string) string {
+	var others []string
+	for _, a := range wp.rotation() {
+		if a != alias {
+			others = append(others, a)
+		}
+	}
+	if len(others) == 0 {
+		return alias
+	}
+	return wp.balancer.Select("pool", others)
+}
+
+// redoPrompt asks for a fresh attempt at r's subtask, carrying the flagged
+// output's score and the reviewer's critique.
+func redoPrompt(r role.WorkerResult) string {
+	return fmt.Sprintf(
+		"%s\n\n---\nA previous attempt at this subtask scored %d/10. Reviewer feedback: %s\n\nWrite a complete answer that addresses the reviewer's feedback.",
+		workerPrompt(r.Subtask), r.ReviewScore, r.ReviewNote,
+	)
+}
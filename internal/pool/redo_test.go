@@ -0,0 +1,160 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// scoreFunc adapts a function to the Scorer interface.
+type scoreFunc func(ctx context.Context, subtask, response string) (int, string, error)
+
+func (f scoreFunc) Score(ctx context.Context, subtask, response string) (int, string, error) {
+	return f(ctx, subtask, response)
+}
+
+// redoRouter answers "model-a" requests with a weak reply and "model-b"
+// requests with a strong one, recording every user prompt by backing model.
+func redoRouter(t *testing.T, aliases []string) (*provider.Router, map[string][]string) {
+	t.Helper()
+	var mu sync.Mutex
+	prompts := make(map[string][]string)
+	router := newTestRouter(t, aliases, func(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		mu.Lock()
+		prompts[req.Model] = append(prompts[req.Model], req.Messages[len(req.Messages)-1].Content)
+		mu.Unlock()
+		content := "weak answer"
+		if req.Model == "real-model-1" {
+			content = "strong answer"
+		}
+		return &provider.ChatResponse{
+			Model:   req.Model,
+			Message: provider.Message{Role: provider.RoleAssistant, Content: content},
+			Usage:   provider.Usage{TotalTokens: 40},
+			Done:    true,
+		}, nil
+	})
+	return router, prompts
+}
+
+// answerScorer scores "strong answer" 8 and anything else 3.
+var answerScorer = scoreFunc(func(_ context.Context, _, response string) (int, string, error) {
+	if response == "strong answer" {
+		return 8, "solid", nil
+	}
+	return 3, "too thin", nil
+})
+
+func TestRedoFlagged_ImprovedOutputReplacesOriginal(t *testing.T) {
+	aliases := []string{"model-a", "model-b"}
+	router, prompts := redoRouter(t, aliases)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+
+	results := []role.WorkerResult{
+		{Role: "model-a", Subtask: "write the parser", Response: "weak answer", Tokens: 40, ReviewScore: 3, ReviewNote: "no error handling", Flagged: true},
+		{Role: "model-b", Subtask: "write the lexer", Response: "strong answer", Tokens: 40, ReviewScore: 8, ReviewNote: "solid"},
+	}
+	redos := wp.RedoFlagged(context.Background(), results, []int{6, 6}, "you are a worker", answerScorer)
+
+	if len(redos) != 1 {
+		t.Fatalf("got %d redos, want 1 (only the flagged result)", len(redos))
+	}
+	rd := redos[0]
+	if rd.Index != 0 || rd.From != "model-a" || rd.To != "model-b" || rd.OldScore != 3 || rd.NewScore != 8 || !rd.Kept || rd.Err != nil {
+		t.Errorf("redo = %+v", rd)
+	}
+	got := results[0]
+	if got.Response != "strong answer" || got.Role != "model-b" || got.ReviewScore != 8 || got.ReviewNote != "solid" || got.Flagged {
+		t.Errorf("result[0] = %+v, want the improved output", got)
+	}
+	if got.Subtask != "write the parser" || got.Tokens != 80 {
+		t.Errorf("result[0] subtask/tokens = %q/%d, want the original subtask and both calls' tokens", got.Subtask, got.Tokens)
+	}
+	if results[1].Response != "strong answer" || results[1].ReviewScore != 8 {
+		t.Errorf("unflagged result changed: %+v", results[1])
+	}
+
+	if len(prompts["real-model-0"]) != 0 {
+		t.Errorf("redo went back to the flagged worker: %v", prompts["real-model-0"])
+	}
+	if p := prompts["real-model-1"]; len(p) != 1 || !strings.Contains(p[0], "write the parser") || !strings.Contains(p[0], "no error handling") {
+		t.Errorf("redo prompt = %v, want the subtask with the reviewer's critique", p)
+	}
+}
+
+func TestRedoFlagged_KeepsHigherScoringOriginal(t *testing.T) {
+	aliases := []string{"model-a", "model-b"}
+	router, _ := redoRouter(t, aliases)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+
+	// model-b's output was flagged at 5; the redo on model-a only scores 3.
+	results := []role.WorkerResult{
+		{Role: "model-b", Subtask: "s", Response: "original", ReviewScore: 5, ReviewNote: "meh", Flagged: true},
+	}
+	redos := wp.RedoFlagged(context.Background(), results, []int{6}, "sys", answerScorer)
+
+	if len(redos) != 1 || redos[0].Kept || redos[0].NewScore != 3 || redos[0].To != "model-a" {
+		t.Errorf("redos = %+v, want one discarded redo on model-a", redos)
+	}
+	if results[0].Response != "original" || results[0].ReviewScore != 5 || !results[0].Flagged {
+		t.Errorf("result = %+v, want the original kept", results[0])
+	}
+}
+
+func TestRedoFlagged_SingleMemberRetriesWithCritique(t *testing.T) {
+	aliases := []string{"model-a"}
+	router, prompts := redoRouter(t, aliases)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+
+	results := []role.WorkerResult{
+		{Role: "model-a", Subtask: "s", Response: "first", ReviewScore: 2, ReviewNote: "wrong API", Flagged: true},
+	}
+	redos := wp.RedoFlagged(context.Background(), results, []int{6}, "sys", answerScorer)
+
+	if len(redos) != 1 || redos[0].To != "model-a" || !redos[0].Kept {
+		t.Errorf("redos = %+v, want a kept redo on the same worker", redos)
+	}
+	if results[0].Response != "weak answer" || results[0].ReviewScore != 3 || !results[0].Flagged {
+		t.Errorf("result = %+v, want the higher-scoring redo, still flagged", results[0])
+	}
+	if p := prompts["real-model-0"]; len(p) != 1 || !strings.Contains(p[0], "wrong API") {
+		t.Errorf("prompts = %v, want the critique appended", p)
+	}
+}
+
+func TestRedoFlagged_DispatchErrorKeepsOriginal(t *testing.T) {
+	aliases := []string{"model-a", "model-b"}
+	router := newTestRouter(t, aliases, func(context.Context, *provider.ChatRequest) (*provider.ChatResponse, error) {
+		return nil, errors.New("connection refused")
+	})
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+
+	results := []role.WorkerResult{{Role: "model-a", Subtask: "s", Response: "first", ReviewScore: 2, Flagged: true}}
+	redos := wp.RedoFlagged(context.Background(), results, []int{6}, "sys", answerScorer)
+
+	if len(redos) != 1 || redos[0].Err == nil || redos[0].Kept {
+		t.Fatalf("redos = %+v, want a failed redo", redos)
+	}
+	if results[0].Response != "first" {
+		t.Errorf("result = %+v, want the original kept", results[0])
+	}
+}
+
+func TestRedoFlagged_NothingFlagged(t *testing.T) {
+	aliases := []string{"model-a"}
+	router, prompts := redoRouter(t, aliases)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+
+	results := []role.WorkerResult{{Role: "model-a", Subtask: "s", Response: "ok", ReviewScore: 9}}
+	if redos := wp.RedoFlagged(context.Background(), results, []int{6}, "sys", answerScorer); len(redos) != 0 {
+		t.Errorf("redos = %+v, want none", redos)
+	}
+	if len(prompts) != 0 {
+		t.Errorf("unexpected worker calls: %v", prompts)
+	}
+}
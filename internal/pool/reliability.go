@@ -0,0 +1,115 @@
+package pool
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// ModelReliability summarizes how one model alias behaved over a run. Every
+// request the pool sends counts once, so a worker that fails and is retried
+// on another member contributes an attempt to each alias. Requests routed
+// through a fallback chain are counted under the primary alias.
+type ModelReliability struct {
+	Alias     string
+	Attempts  int
+	Successes int
+	Retryable int // rate limit, 5xx, network, open circuit, ...
+	Timeouts  int
+	Empty     int           // answered, but with nothing usable
+	Failures  int           // non-retryable errors such as auth or bad request
+	Latency   time.Duration // summed over all attempts
+}
+
+// AvgLatency returns the mean latency per attempt.
+func (m ModelReliability) AvgLatency() time.Duration {
+	if m.Attempts == 0 {
+		return 0
+	}
+	return m.Latency / time.Duration(m.Attempts)
+}
+
+// reliability accumulates per-alias outcomes. The zero value is ready to use.
+type reliability struct {
+	mu     sync.Mutex
+	models map[string]*ModelReliability
+}
+
+// record counts one request to alias that took latency and ended with resp
+// or err.
+func (rt *reliability) record(alias string, resp *provider.ChatResponse, err error, latency time.Duration) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.models == nil {
+		rt.models = make(map[string]*ModelReliability)
+	}
+	m := rt.models[alias]
+	if m == nil {
+		m = &ModelReliability{Alias: alias}
+		rt.models[alias] = m
+	}
+	m.Attempts++
+	m.Latency += latency
+	switch {
+	case err == nil && isEmptyOrRefused(resp.Message.Content):
+		m.Empty++
+	case err == nil:
+		m.Successes++
+	case provider.ClassifyError(err) == provider.ErrTimeout:
+		m.Timeouts++
+	case provider.ClassifyError(err).Retryable():
+		m.Retryable++
+	default:
+		m.Failures++
+	}
+}
+
+// snapshot returns the counts sorted by alias.
+func (rt *reliability) snapshot() []ModelReliability {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	out := make([]ModelReliability, 0, len(rt.models))
+	for _, m := range rt.models {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Alias < out[j].Alias })
+	return out
+}
+
+// Reliability returns per-alias outcome counts for every request the pool has
+// sent, sorted by alias.
+func (wp *WorkerPool) Reliability() []ModelReliability {
+	return wp.reliability.snapshot()
+}
+
+// refusalPrefixes are openings that mark a response as a refusal rather than
+// an attempt at the subtask.
+var refusalPrefixes = []string{
+	"i'm sorry, but i can",
+	"i am sorry, but i can",
+	"sorry, i can't",
+	"sorry, i cannot",
+	"i can't help with",
+	"i cannot help with",
+	"i can't assist with",
+	"i cannot assist with",
+}
+
+// isEmptyOrRefused reports whether a response carries nothing usable: it is
+// blank or opens with a stock refusal.
+func isEmptyOrRefused(content string) bool {
+	s := strings.ToLower(strings.TrimSpace(content))
+	if s == "" {
+		return true
+	}
+	s = strings.ReplaceAll(s, "’", "'")
+	for _, p := range refusalPrefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
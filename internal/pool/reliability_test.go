@@ -0,0 +1,88 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+func TestReliability_MixedOutcomes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // keep failed-request dumps out of the real home
+
+	aliases := []string{"stable", "flaky"}
+	router := newTestRouter(t, aliases, func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		content := "done"
+		switch req.Messages[len(req.Messages)-1].Content {
+		case "empty":
+			content = "  "
+		case "refuse":
+			content = "I'm sorry, but I can't help with that."
+		case "timeout":
+			return nil, context.DeadlineExceeded
+		case "overloaded":
+			return nil, &provider.APIError{Status: 503, Message: "overloaded"}
+		case "unauthorized":
+			return nil, &provider.APIError{Status: 401, Message: "bad key"}
+		}
+		return &provider.ChatResponse{
+			Model:   req.Model,
+			Message: provider.Message{Role: provider.RoleAssistant, Content: content},
+		}, nil
+	})
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+
+	// Pinned models keep the alias fixed across the pool's single retry, so
+	// each failing subtask costs its alias two attempts.
+	subtasks := []string{"a", "b", "a", "empty", "refuse", "timeout", "overloaded", "unauthorized"}
+	models := []string{"stable", "stable", "stable", "flaky", "flaky", "flaky", "flaky", "flaky"}
+	wp.ExecuteAllWithModels(context.Background(), subtasks, models, nil, "sys")
+
+	got := wp.Reliability()
+	if len(got) != 2 {
+		t.Fatalf("want 2 aliases, got %+v", got)
+	}
+
+	flaky, stable := got[0], got[1]
+	if stable.Alias != "stable" || flaky.Alias != "flaky" {
+		t.Fatalf("want sorted aliases [flaky stable], got [%s %s]", flaky.Alias, stable.Alias)
+	}
+	wantStable := ModelReliability{Alias: "stable", Attempts: 3, Successes: 3}
+	wantFlaky := ModelReliability{Alias: "flaky", Attempts: 8, Empty: 2, Timeouts: 2, Retryable: 2, Failures: 2}
+	for _, tc := range []struct{ got, want ModelReliability }{{stable, wantStable}, {flaky, wantFlaky}} {
+		tc.got.Latency = 0
+		if tc.got != tc.want {
+			t.Errorf("reliability = %+v, want %+v", tc.got, tc.want)
+		}
+	}
+}
+
+func TestModelReliability_AvgLatency(t *testing.T) {
+	m := ModelReliability{Attempts: 4, Latency: 2 * time.Second}
+	if got := m.AvgLatency(); got != 500*time.Millisecond {
+		t.Errorf("AvgLatency = %v, want 500ms", got)
+	}
+	if got := (ModelReliability{}).AvgLatency(); got != 0 {
+		t.Errorf("AvgLatency with no attempts = %v, want 0", got)
+	}
+}
+
+func TestIsEmptyOrRefused(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"", true},
+		{"\n\t ", true},
+		{"I’m sorry, but I can’t do that.", true},
+		{"I cannot assist with this request.", true},
+		{"package main\n", false},
+		{"Sorry for the delay — here is the code:", false},
+	}
+	for _, tt := range tests {
+		if got := isEmptyOrRefused(tt.content); got != tt.want {
+			t.Errorf("isEmptyOrRefused(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
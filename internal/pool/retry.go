@@ -0,0 +1,89 @@
+package pool
+
+import (
+	"context"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// WithSubtaskRetries re-attempts a subtask that fails with a retryable error
+// (see provider.ErrorCode.Retryable) on up to n further pool members, each
+// chosen by the balancer from the members not yet tried for that subtask.
+// Once every member has been tried, remaining attempts go to any member but
+// the one that just failed. Values <= 0 keep the default: one retry, on the
+// same member unless the health checker ejects it.
+func WithSubtaskRetries(n int) Option {
+	return func(wp *WorkerPool) {
+		wp.subtaskRetries = n
+	}
+}
+
+// retry re-dispatches req after it failed with err on alias, returning the
+// final response, the alias that produced it, and the final error. Only pool
+// subtasks (fromPool) move to other members; an explicit model override is
+// retried in place. ctx is the caller's context and sctx the subtask's.
+// Each attempt is marked with provider.RetryContext for the router's trace
+// hooks.
+func (wp *WorkerPool) retry(ctx, sctx context.Context, req *provider.ChatRequest, alias string, fromPool bool, err error) (*provider.ChatResponse, string, error) {
+	if wp.subtaskRetries <= 0 || !fromPool {
+		// Retry once on transient failure, moving off a dead pool member.
+		if fromPool {
+			alias = wp.retryAlias(sctx, alias)
+		}
+		return wp.attempt(provider.RetryContext(sctx, 1), req, alias)
+	}
+
+	var resp *provider.ChatResponse
+	tried := map[string]bool{alias: true}
+	for n := 0; n < wp.subtaskRetries; n++ {
+		if timedOut(ctx, sctx) || !provider.ClassifyError(err).Retryable() {
+			break
+		}
+		next, selected := wp.alternateAlias(tried, alias)
+		resp, alias, err = wp.attempt(provider.RetryContext(sctx, n+1), req, next)
+		if selected {
+			wp.balancer.Release("pool", next)
+		}
+		tried[next] = true
+		if err == nil {
+			break
+		}
+	}
+	return resp, alias, err
+}
+
+// attempt sends req to alias and records the outcome.
+func (wp *WorkerPool) attempt(ctx context.Context, req *provider.ChatRequest, alias string) (*provider.ChatResponse, string, error) {
+	req.Model = alias
+	liveNote(ctx, "retry on %s", alias)
+	start := time.Now()
+	resp, err := wp.chat(ctx, req)
+	wp.reliability.record(alias, resp, err, time.Since(start))
+	return resp, alias, err
+}
+
+// alternateAlias picks the member for the next retry after a failure on
+// last: the balancer's choice among untried members in rotation, else among
+// every member but last, else last itself. selected reports whether the
+// balancer made the choice, in which case the caller must release it.
+func (wp *WorkerPool) alternateAlias(tried map[string]bool, last string) (alias string, selected bool) {
+	rotation := wp.rotation()
+	var candidates []string
+	for _, a := range rotation {
+		if !tried[a] {
+			candidates = append(candidates, a)
+		}
+	}
+	if len(candidates) == 0 {
+		for _, a := range rotation {
+			if a != last {
+				candidates = append(candidates, a)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return last, false
+	}
+	return wp.balancer.Select("pool", candidates), true
+}
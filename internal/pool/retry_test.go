@@ -0,0 +1,129 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// recordingChat fails requests to the backing models in failing with err
+// and answers the rest, recording the backing model of every call.
+func recordingChat(failing map[string]bool, err error) (func(context.Context, *provider.ChatRequest) (*provider.ChatResponse, error), func() []string) {
+	var mu sync.Mutex
+	var calls []string
+	chat := func(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		mu.Lock()
+		calls = append(calls, req.Model)
+		mu.Unlock()
+		if failing[req.Model] {
+			return nil, err
+		}
+		return &provider.ChatResponse{
+			Model:   req.Model,
+			Message: provider.Message{Role: provider.RoleAssistant, Content: "ok from " + req.Model},
+			Usage:   provider.Usage{TotalTokens: 10},
+			Done:    true,
+		}, nil
+	}
+	return chat, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), calls...)
+	}
+}
+
+var errUnavailable = &provider.APIError{Status: 503, Message: "unavailable"}
+
+func TestWithSubtaskRetries_SucceedsOnAlternateMember(t *testing.T) {
+	aliases := []string{"model-a", "model-b"}
+	chat, calls := recordingChat(map[string]bool{"real-model-0": true}, errUnavailable)
+	router := newTestRouter(t, aliases, chat)
+	// Round robin hands the first subtask to model-a, which is down.
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithSubtaskRetries(2))
+
+	results := wp.ExecuteAll(context.Background(), []string{"task"}, "sys")
+
+	if got := calls(); len(got) != 2 || got[0] != "real-model-0" || got[1] != "real-model-1" {
+		t.Fatalf("calls = %v, want model-a then model-b", got)
+	}
+	r := results[0]
+	if r.Err != nil || r.Response != "ok from real-model-1" || r.Role != "model-b" {
+		t.Errorf("result = %+v, want the success from model-b", r)
+	}
+}
+
+func TestWithSubtaskRetries_PreservesOrder(t *testing.T) {
+	aliases := []string{"model-a", "model-b", "model-c"}
+	chat, _ := recordingChat(map[string]bool{"real-model-1": true}, errUnavailable)
+	router := newTestRouter(t, aliases, chat)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithSubtaskRetries(1))
+
+	subtasks := []string{"t0", "t1", "t2", "t3", "t4", "t5"}
+	results := wp.ExecuteAll(context.Background(), subtasks, "sys")
+	for i, r := range results {
+		if r.Subtask != subtasks[i] {
+			t.Errorf("result[%d].Subtask = %q, want %q", i, r.Subtask, subtasks[i])
+		}
+		if r.Err != nil || r.Role == "model-b" {
+			t.Errorf("result[%d] = %+v, want a success off model-b", i, r)
+		}
+	}
+}
+
+func TestWithSubtaskRetries_CapsAttempts(t *testing.T) {
+	aliases := []string{"model-a", "model-b", "model-c", "model-d"}
+	failing := map[string]bool{}
+	for i := range aliases {
+		failing[fmt.Sprintf("real-model-%d", i)] = true
+	}
+	chat, calls := recordingChat(failing, errUnavailable)
+	router := newTestRouter(t, aliases, chat)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithSubtaskRetries(2))
+
+	results := wp.ExecuteAll(context.Background(), []string{"task"}, "sys")
+
+	got := calls()
+	if len(got) != 3 {
+		t.Fatalf("calls = %v, want the first attempt plus 2 retries", got)
+	}
+	seen := map[string]bool{}
+	for _, m := range got {
+		if seen[m] {
+			t.Errorf("calls = %v, want each attempt on a different member", got)
+		}
+		seen[m] = true
+	}
+	if results[0].Err == nil {
+		t.Error("expected the subtask to fail after exhausting retries")
+	}
+}
+
+func TestWithSubtaskRetries_SkipsNonRetryableErrors(t *testing.T) {
+	aliases := []string{"model-a", "model-b"}
+	chat, calls := recordingChat(map[string]bool{"real-model-0": true, "real-model-1": true}, &provider.APIError{Status: 401, Message: "bad key"})
+	router := newTestRouter(t, aliases, chat)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithSubtaskRetries(3))
+
+	results := wp.ExecuteAll(context.Background(), []string{"task"}, "sys")
+	if got := calls(); len(got) != 1 {
+		t.Errorf("calls = %v, want no retry after an auth error", got)
+	}
+	if results[0].Err == nil {
+		t.Error("expected the auth error to be reported")
+	}
+}
+
+func TestWithSubtaskRetries_SingleMemberRetriesInPlace(t *testing.T) {
+	aliases := []string{"model-a"}
+	chat, calls := recordingChat(map[string]bool{"real-model-0": true}, errUnavailable)
+	router := newTestRouter(t, aliases, chat)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithSubtaskRetries(2))
+
+	wp.ExecuteAll(context.Background(), []string{"task"}, "sys")
+	if got := calls(); len(got) != 3 {
+		t.Errorf("calls = %v, want 3 attempts on the only member", got)
+	}
+}
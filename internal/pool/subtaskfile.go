@@ -0,0 +1,49 @@
+package pool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReadSubtaskFile loads a pre-written decomposition from path, in either
+// format accepted by ParseSubtaskList.
+func ReadSubtaskFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("pool: read subtask file: %w", err)
+	}
+	subtasks, err := ParseSubtaskList(data)
+	if err != nil {
+		return nil, fmt.Errorf("pool: %s: %w", path, err)
+	}
+	return subtasks, nil
+}
+
+// ParseSubtaskList parses a decomposition written by hand: a JSON array of
+// strings, or one subtask per line. Blank lines are skipped. Markers such as
+// [depends: N] and [tag: name] are kept and behave as if the mayor wrote
+// them. Returns an error when no subtasks remain.
+func ParseSubtaskList(data []byte) ([]string, error) {
+	var raw []string
+	trimmed := bytes.TrimSpace(data)
+	// A line-per-subtask file may also start with "[" (e.g. "[tag: api] ..."),
+	// so only treat it as JSON if it parses as a string array.
+	isJSON := len(trimmed) > 0 && trimmed[0] == '[' && json.Unmarshal(trimmed, &raw) == nil
+	if !isJSON {
+		raw = strings.Split(string(data), "\n")
+	}
+
+	var subtasks []string
+	for _, s := range raw {
+		if s = strings.TrimSpace(s); s != "" {
+			subtasks = append(subtasks, s)
+		}
+	}
+	if len(subtasks) == 0 {
+		return nil, fmt.Errorf("no subtasks")
+	}
+	return subtasks, nil
+}
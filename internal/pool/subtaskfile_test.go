@@ -0,0 +1,89 @@
+package pool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+func TestParseSubtaskList(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"lines", "write the parser\n\n  write the CLI  \n", []string{"write the parser", "write the CLI"}},
+		{"json", `["write the parser", "write the CLI [depends: 1]"]`, []string{"write the parser", "write the CLI [depends: 1]"}},
+		{"lines starting with a marker", "[tag: api] HTTP handlers\n[tag: web] frontend", []string{"[tag: api] HTTP handlers", "[tag: web] frontend"}},
+		{"crlf", "a\r\nb\r\n", []string{"a", "b"}},
+	}
+	for _, c := range cases {
+		got, err := ParseSubtaskList([]byte(c.in))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+
+	for _, empty := range []string{"", "\n  \n", "[]", `["", "  "]`} {
+		if _, err := ParseSubtaskList([]byte(empty)); err == nil {
+			t.Errorf("ParseSubtaskList(%q): expected error for empty decomposition", empty)
+		}
+	}
+}
+
+func TestReadSubtaskFile_Missing(t *testing.T) {
+	if _, err := ReadSubtaskFile(filepath.Join(t.TempDir(), "nope.txt")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+// TestSubtaskFile_DrivesWorkers checks that a pre-written decomposition is
+// dispatched as-is: one worker call per listed subtask and no other model
+// call (in particular, no decompose request).
+func TestSubtaskFile_DrivesWorkers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subtasks.txt")
+	if err := os.WriteFile(path, []byte("implement the tokenizer\nimplement the parser\nwrite the CLI\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	subtasks, err := ReadSubtaskFile(path)
+	if err != nil {
+		t.Fatalf("ReadSubtaskFile: %v", err)
+	}
+
+	const workerPrompt = "you are a worker"
+	var mu sync.Mutex
+	var seen []string
+	aliases := []string{"model-a", "model-b"}
+	router := newTestRouter(t, aliases, func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if req.Messages[0].Content != workerPrompt {
+			t.Errorf("unexpected non-worker call with system prompt %q", req.Messages[0].Content)
+		}
+		seen = append(seen, req.Messages[len(req.Messages)-1].Content)
+		return &provider.ChatResponse{Model: req.Model, Message: provider.Message{Role: provider.RoleAssistant, Content: "ok"}, Done: true}, nil
+	})
+
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases)
+	results := wp.ExecuteAll(context.Background(), subtasks, workerPrompt)
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	sort.Strings(seen)
+	want := append([]string(nil), subtasks...)
+	sort.Strings(want)
+	if strings.Join(seen, "|") != strings.Join(want, "|") {
+		t.Errorf("model calls = %q, want exactly the file's subtasks %q", seen, want)
+	}
+}
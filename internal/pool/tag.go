@@ -0,0 +1,51 @@
+package pool
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tagPattern matches [tag: name] markers in subtask text.
+var tagPattern = regexp.MustCompile(`(?i)\[tag:\s*([^\]]+)\]`)
+
+// tagMarker matches a whole [tag: ...] marker with any blanks before it; see
+// importanceMarker.
+var tagMarker = regexp.MustCompile(`(?i)[ \t]*\[tag:[^\]]*\]`)
+
+// ParseTag extracts the lower-cased tag from a subtask string, or returns an
+// empty string if no marker is found.
+func ParseTag(subtask string) string {
+	matches := tagPattern.FindStringSubmatch(subtask)
+	if matches == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(matches[1]))
+}
+
+// WithTagRoutes pins subtasks carrying a [tag: name] marker to the pool alias
+// routes[name]. Tag names must be lower-case. Untagged subtasks, unknown tags,
+// and tags whose alias is currently ejected by the health checker go through
+// the balancer as usual.
+func WithTagRoutes(routes map[string]string) Option {
+	return func(wp *WorkerPool) {
+		wp.tagRoutes = routes
+	}
+}
+
+// pinnedAlias returns the pool alias a subtask's tag routes it to, or "" when
+// the subtask should be balanced.
+func (wp *WorkerPool) pinnedAlias(subtask string) string {
+	if len(wp.tagRoutes) == 0 {
+		return ""
+	}
+	alias, ok := wp.tagRoutes[ParseTag(subtask)]
+	if !ok {
+		return ""
+	}
+	for _, a := range wp.rotation() {
+		if a == alias {
+			return alias
+		}
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		subtask string
+		want    string
+	}{
+		{"simple", "Build the login page [tag: frontend]", "frontend"},
+		{"case and spaces", "API handlers [Tag:  Backend ]", "backend"},
+		{"with other markers", "Schema [depends: 1] [tag: db] [specialist: sql]", "db"},
+		{"no marker", "Write the README", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTag(tt.subtask); got != tt.want {
+				t.Errorf("ParseTag(%q) = %q, want %q", tt.subtask, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteAll_StripsTagBeforeDispatch(t *testing.T) {
+	aliases := []string{"model-a", "model-b"}
+	var mu sync.Mutex
+	var sent []string
+	router := newTestRouter(t, aliases, func(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		mu.Lock()
+		sent = append(sent, req.Messages[len(req.Messages)-1].Content)
+		mu.Unlock()
+		return &provider.ChatResponse{Message: provider.Message{Role: provider.RoleAssistant, Content: "ok"}, Done: true}, nil
+	})
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithTagRoutes(map[string]string{"frontend": "model-b"}))
+
+	results := wp.ExecuteAll(context.Background(), []string{"Build the [tag: frontend] login page [importance: high]"}, "sys")
+
+	if results[0].Role != "model-b" {
+		t.Fatalf("ran on %q, want the tag route model-b", results[0].Role)
+	}
+	if len(sent) != 1 || sent[0] != "Build the login page" {
+		t.Errorf("worker received %q, want markers stripped", sent)
+	}
+}
+
+func TestExecuteAll_TagRoutesPinSubtasks(t *testing.T) {
+	aliases := []string{"model-a", "model-b", "model-c"}
+	router := newTestRouter(t, aliases, nil)
+	routes := map[string]string{"frontend": "model-c", "backend": "model-a"}
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithTagRoutes(routes))
+
+	subtasks := []string{
+		"Login page [tag: frontend]",
+		"Settings page [tag: Frontend]",
+		"Auth API [tag: backend]",
+		"Dashboard [tag: frontend]",
+		"README",
+		"Design doc [tag: docs]",
+	}
+	results := wp.ExecuteAll(context.Background(), subtasks, "sys")
+
+	want := map[int]string{0: "model-c", 1: "model-c", 2: "model-a", 3: "model-c"}
+	for i, alias := range want {
+		if results[i].Role != alias {
+			t.Errorf("result[%d] (%q) ran on %q, want %q", i, subtasks[i], results[i].Role, alias)
+		}
+	}
+	for _, i := range []int{4, 5} {
+		if results[i].Role == "" || results[i].Response == "" {
+			t.Errorf("untagged/unknown-tag result[%d] not balanced: %+v", i, results[i])
+		}
+	}
+}
+
+func TestExecuteAllWithModels_OverrideBeatsTag(t *testing.T) {
+	aliases := []string{"model-a", "model-b"}
+	router := newTestRouter(t, aliases, nil)
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases,
+		WithTagRoutes(map[string]string{"frontend": "model-b"}))
+
+	subtasks := []string{"Page [tag: frontend]", "Widget [tag: frontend]"}
+	results := wp.ExecuteAllWithModels(context.Background(), subtasks, []string{"model-a", ""}, nil, "sys")
+
+	if results[0].Role != "model-a" {
+		t.Errorf("specialist override ignored: ran on %q", results[0].Role)
+	}
+	if results[1].Role != "model-b" {
+		t.Errorf("tagged subtask ran on %q, want model-b", results[1].Role)
+	}
+}
@@ -0,0 +1,45 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSubtaskTimeout marks a worker result whose subtask ran past the
+// WithSubtaskTimeout deadline and was cancelled. Test with errors.Is.
+var ErrSubtaskTimeout = errors.New("pool: subtask timed out")
+
+// WithSubtaskTimeout bounds each subtask, including its retry, to d. A worker
+// still running at the deadline is cancelled and its result carries
+// ErrSubtaskTimeout; sibling subtasks are unaffected. A zero duration leaves
+// subtasks bounded only by the caller's context.
+func WithSubtaskTimeout(d time.Duration) Option {
+	return func(wp *WorkerPool) {
+		wp.subtaskTimeout = d
+	}
+}
+
+// subtaskContext derives the context a single subtask runs under.
+func (wp *WorkerPool) subtaskContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if wp.subtaskTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, wp.subtaskTimeout)
+}
+
+// timedOut reports whether sctx hit its own subtask deadline while the
+// parent context was still live.
+func timedOut(parent, sctx context.Context) bool {
+	return errors.Is(sctx.Err(), context.DeadlineExceeded) && parent.Err() == nil
+}
+
+// subtaskErr replaces err with ErrSubtaskTimeout when the subtask was
+// cancelled by its own deadline rather than failing on its own.
+func (wp *WorkerPool) subtaskErr(parent, sctx context.Context, err error) error {
+	if err != nil && timedOut(parent, sctx) {
+		return fmt.Errorf("%w after %s", ErrSubtaskTimeout, wp.subtaskTimeout)
+	}
+	return err
+}
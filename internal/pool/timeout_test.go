@@ -0,0 +1,107 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// blockingChat blocks requests for "stuck" subtasks until their context is
+// cancelled, counting the calls, and answers everything else at once.
+func blockingChat(calls *int32) func(context.Context, *provider.ChatRequest) (*provider.ChatResponse, error) {
+	return func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		if strings.Contains(req.Messages[len(req.Messages)-1].Content, "stuck") {
+			atomic.AddInt32(calls, 1)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &provider.ChatResponse{
+			Model:   req.Model,
+			Message: provider.Message{Role: provider.RoleAssistant, Content: "done"},
+			Usage:   provider.Usage{TotalTokens: 10},
+			Done:    true,
+		}, nil
+	}
+}
+
+func TestWithSubtaskTimeout_CancelsStuckWorker(t *testing.T) {
+	aliases := []string{"model-a", "model-b"}
+	var calls int32
+	router := newTestRouter(t, aliases, blockingChat(&calls))
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithSubtaskTimeout(50*time.Millisecond))
+
+	var hooked []role.WorkerResult
+	done := make(chan role.WorkerResult, 2)
+	wp.SetProgressHook(func(_ int, r role.WorkerResult) { done <- r })
+
+	start := time.Now()
+	results := wp.ExecuteAll(context.Background(), []string{"stuck subtask", "quick subtask"}, "sys")
+	elapsed := time.Since(start)
+	close(done)
+	for r := range done {
+		hooked = append(hooked, r)
+	}
+
+	if elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("ExecuteAll took %s, want about the 50ms subtask timeout", elapsed)
+	}
+	if !errors.Is(results[0].Err, ErrSubtaskTimeout) {
+		t.Errorf("stuck result Err = %v, want ErrSubtaskTimeout", results[0].Err)
+	}
+	if results[0].Response != "" {
+		t.Errorf("stuck result Response = %q, want empty", results[0].Response)
+	}
+	if results[1].Err != nil || results[1].Response != "done" {
+		t.Errorf("sibling result = %+v, want it to finish normally", results[1])
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("stuck subtask dispatched %d times, want 1 (no retry after the deadline)", n)
+	}
+	if len(hooked) != 2 {
+		t.Errorf("progress hook ran %d times, want 2", len(hooked))
+	}
+}
+
+func TestWithSubtaskTimeout_AppliesWithModels(t *testing.T) {
+	aliases := []string{"model-a", "model-b"}
+	var calls int32
+	router := newTestRouter(t, aliases, blockingChat(&calls))
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithSubtaskTimeout(30*time.Millisecond))
+
+	results := wp.ExecuteAllWithModels(context.Background(), []string{"quick", "stuck"}, []string{"model-a", "model-b"}, nil, "sys")
+	if results[0].Err != nil {
+		t.Errorf("quick result Err = %v", results[0].Err)
+	}
+	if !errors.Is(results[1].Err, ErrSubtaskTimeout) {
+		t.Errorf("stuck result Err = %v, want ErrSubtaskTimeout", results[1].Err)
+	}
+}
+
+func TestWithSubtaskTimeout_ParentCancelIsNotATimeout(t *testing.T) {
+	aliases := []string{"model-a"}
+	var calls int32
+	router := newTestRouter(t, aliases, blockingChat(&calls))
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases, WithSubtaskTimeout(time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	results := wp.ExecuteAll(ctx, []string{"stuck"}, "sys")
+	if results[0].Err == nil || errors.Is(results[0].Err, ErrSubtaskTimeout) {
+		t.Errorf("Err = %v, want the run's own cancellation, not a subtask timeout", results[0].Err)
+	}
+}
+
+func TestWithSubtaskTimeout_ZeroDisables(t *testing.T) {
+	wp := New(nil, nil, nil, WithSubtaskTimeout(0))
+	ctx, cancel := wp.subtaskContext(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("zero timeout set a deadline")
+	}
+}
@@ -0,0 +1,43 @@
+package pool
+
+import (
+	"context"
+
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// WithTools offers tools to every worker and runs the ones it calls with
+// exec, e.g. role.ReadFileTool with a role.FileReader so workers can read
+// the files their subtasks change. Tool rounds stay on the member that
+// answered the first request.
+func WithTools(tools []provider.Tool, exec role.ToolExecutor) Option {
+	return func(wp *WorkerPool) {
+		wp.tools = tools
+		wp.toolExec = exec
+	}
+}
+
+// attachTools adds the pool's tools to a worker request.
+func (wp *WorkerPool) attachTools(req *provider.ChatRequest) {
+	if wp.toolExec != nil {
+		req.Tools = wp.tools
+	}
+}
+
+// finishTools runs the tool rounds of a worker conversation whose first
+// response, from alias, is resp. Later rounds use fallbacks like the first.
+func (wp *WorkerPool) finishTools(ctx context.Context, req *provider.ChatRequest, resp *provider.ChatResponse, alias string, fallbacks []string) (*provider.ChatResponse, error) {
+	if wp.toolExec == nil || len(resp.Message.ToolCalls) == 0 {
+		return resp, nil
+	}
+	send := func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		req.Model = alias // the router replaces it with the resolved model
+		liveNote(ctx, "tool results: %s", toolNames(req))
+		if len(fallbacks) > 0 {
+			return wp.chatWithFallbacks(ctx, req, fallbacks)
+		}
+		return wp.chat(ctx, req)
+	}
+	return role.ContinueToolLoop(ctx, send, req, resp, wp.toolExec, 0)
+}
@@ -0,0 +1,92 @@
+package pool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// fileReadingWorker asks to read main.go, then answers with what it got.
+// It records how many requests offered tools.
+func fileReadingWorker(mu *sync.Mutex, withTools *int) func(context.Context, *provider.ChatRequest) (*provider.ChatResponse, error) {
+	return func(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		mu.Lock()
+		if len(req.Tools) > 0 {
+			*withTools++
+		}
+		mu.Unlock()
+		last := req.Messages[len(req.Messages)-1]
+		msg := provider.Message{Role: provider.RoleAssistant}
+		if last.Role == provider.RoleTool {
+			msg.Content = "saw: " + last.Content
+		} else {
+			msg.ToolCalls = []provider.ToolCall{{
+				ID: "call_1", Type: "function",
+				Function: provider.FunctionCall{Name: role.ReadFileToolName, Arguments: `{"path":"main.go"}`},
+			}}
+		}
+		return &provider.ChatResponse{Model: req.Model, Message: msg, Usage: provider.Usage{TotalTokens: 10}, Done: true}, nil
+	}
+}
+
+func TestExecuteAll_ReadFileTool(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	withTools := 0
+	aliases := []string{"model-a", "model-b"}
+	router := newTestRouter(t, aliases, fileReadingWorker(&mu, &withTools))
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases,
+		WithTools([]provider.Tool{role.ReadFileTool}, role.NewFileReader(dir)))
+
+	results := wp.ExecuteAll(context.Background(), []string{"edit main", "extend main"}, "you are a worker")
+	for i, r := range results {
+		if r.Err != nil || r.Response != "saw: package main" {
+			t.Errorf("result[%d] = %q, %v; want the file contents", i, r.Response, r.Err)
+		}
+		if r.Tokens != 20 {
+			t.Errorf("result[%d].Tokens = %d, want both rounds counted", i, r.Tokens)
+		}
+	}
+	if withTools != 4 {
+		t.Errorf("%d requests offered tools, want all 4", withTools)
+	}
+}
+
+func TestExecuteAllWithModels_ReadFileToolWithFallbacks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	withTools := 0
+	aliases := []string{"model-a", "model-b"}
+	router := newTestRouter(t, aliases, fileReadingWorker(&mu, &withTools))
+	wp := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases,
+		WithTools([]provider.Tool{role.ReadFileTool}, role.NewFileReader(dir)))
+
+	results := wp.ExecuteAllWithModels(context.Background(), []string{"edit main"}, []string{"model-b"}, [][]string{{"model-a"}}, "sys")
+	if r := results[0]; r.Err != nil || r.Response != "saw: package main" || r.Role != "model-b" {
+		t.Errorf("result = %+v, want model-b to read the file", r)
+	}
+}
+
+func TestExecuteAll_NoToolsByDefault(t *testing.T) {
+	var mu sync.Mutex
+	withTools := 0
+	aliases := []string{"model-a"}
+	router := newTestRouter(t, aliases, fileReadingWorker(&mu, &withTools))
+
+	results := New(router, provider.NewBalancer(provider.StrategyRoundRobin), aliases).
+		ExecuteAll(context.Background(), []string{"edit main"}, "sys")
+	if withTools != 0 || len(results[0].Response) != 0 {
+		t.Errorf("offered tools %d times, response %q; want no tools and the raw tool call", withTools, results[0].Response)
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/meganerd/electrictown/internal/provider"
@@ -21,6 +23,7 @@ const (
 	defaultBaseURL   = "https://api.anthropic.com"
 	defaultMaxTokens = 4096
 	apiVersion       = "2023-06-01"
+	bedrockVersion   = "bedrock-2023-05-31"
 	providerName     = "anthropic"
 )
 
@@ -29,6 +32,9 @@ type AnthropicProvider struct {
 	apiKey  string
 	baseURL string
 	client  *http.Client
+	headers map[string]string      // extra headers on every request
+	signer  provider.RequestSigner // applied last, before sending
+	bedrock bool                   // send InvokeModel requests; see WithBedrock
 }
 
 // Option configures the AnthropicProvider.
@@ -49,12 +55,38 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithDefaultHeaders adds headers to every request; see provider.ProviderConfig.Headers.
+func WithDefaultHeaders(headers map[string]string) Option {
+	return func(p *AnthropicProvider) {
+		p.headers = headers
+	}
+}
+
+// WithRequestSigner signs every request with s after headers are set, e.g.
+// with an AWS SigV4 signer for Bedrock. Use it with an empty API key so no
+// x-api-key header is sent.
+func WithRequestSigner(s provider.RequestSigner) Option {
+	return func(p *AnthropicProvider) {
+		p.signer = s
+	}
+}
+
+// WithBedrock sends requests in the format of Bedrock's InvokeModel API:
+// POST {base_url}/model/{model}/invoke with anthropic_version in the body.
+// Streams are served from a complete response as a single chunk.
+func WithBedrock() Option {
+	return func(p *AnthropicProvider) {
+		p.bedrock = true
+	}
+}
+
 // New creates a new AnthropicProvider with the given API key and options.
 func New(apiKey string, opts ...Option) *AnthropicProvider {
 	p := &AnthropicProvider{
 		apiKey:  apiKey,
 		baseURL: defaultBaseURL,
 		client:  http.DefaultClient,
+		signer:  provider.NoopSigner,
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -67,19 +99,27 @@ func (p *AnthropicProvider) Name() string {
 	return providerName
 }
 
+// Capabilities reports tools and images; Anthropic has no seed or
+// repetition penalties.
+func (p *AnthropicProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Tools: true, Images: true}
+}
+
 // --- Anthropic API request/response types ---
 
-// anthropicRequest is the request body for POST /v1/messages.
+// anthropicRequest is the request body for POST /v1/messages. InvokeModel
+// takes the same body with the model in the URL and anthropic_version set.
 type anthropicRequest struct {
-	Model       string             `json:"model"`
-	Messages    []anthropicMessage `json:"messages"`
-	System      string             `json:"system,omitempty"`
-	MaxTokens   int                `json:"max_tokens"`
-	Temperature *float64           `json:"temperature,omitempty"`
-	TopP        *float64           `json:"top_p,omitempty"`
-	Stop        []string           `json:"stop_sequences,omitempty"`
-	Stream      bool               `json:"stream,omitempty"`
-	Tools       []anthropicTool    `json:"tools,omitempty"`
+	AnthropicVersion string             `json:"anthropic_version,omitempty"` // Bedrock only
+	Model            string             `json:"model,omitempty"`
+	Messages         []anthropicMessage `json:"messages"`
+	System           interface{}        `json:"system,omitempty"` // string or []anthropicContentBlock
+	MaxTokens        int                `json:"max_tokens"`
+	Temperature      *float64           `json:"temperature,omitempty"`
+	TopP             *float64           `json:"top_p,omitempty"`
+	Stop             []string           `json:"stop_sequences,omitempty"`
+	Stream           bool               `json:"stream,omitempty"`
+	Tools            []anthropicTool    `json:"tools,omitempty"`
 }
 
 // anthropicMessage represents a message in Anthropic's format.
@@ -97,8 +137,28 @@ type anthropicContentBlock struct {
 	Input     interface{} `json:"input,omitempty"`      // for tool_use blocks
 	ToolUseID string      `json:"tool_use_id,omitempty"` // for tool_result blocks
 	Content   string      `json:"content,omitempty"`     // for tool_result blocks (when used as nested)
+	Thinking  string      `json:"thinking,omitempty"`    // for thinking blocks
+
+	Source *anthropicImageSource `json:"source,omitempty"` // for image blocks
+
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+// anthropicImageSource carries an image block's data inline.
+type anthropicImageSource struct {
+	Type      string `json:"type"` // always "base64"
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
 }
 
+// anthropicCacheControl marks a prompt caching breakpoint on a content block.
+type anthropicCacheControl struct {
+	Type string `json:"type"` // always "ephemeral"
+}
+
+// ephemeralCache is the cache_control value emitted for cacheable messages.
+var ephemeralCache = &anthropicCacheControl{Type: "ephemeral"}
+
 // anthropicTool represents a tool definition in Anthropic's format.
 type anthropicTool struct {
 	Name        string      `json:"name"`
@@ -118,10 +178,26 @@ type anthropicResponse struct {
 	Error      *anthropicError         `json:"error,omitempty"`
 }
 
-// anthropicUsage tracks token counts.
+// anthropicUsage tracks token counts. InputTokens excludes the cache
+// creation and cache read tokens, which are billed at different rates.
 type anthropicUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// toUsage converts to provider.Usage, where PromptTokens counts every input
+// token, cached or not, as OpenAI-compatible providers do.
+func (u anthropicUsage) toUsage() provider.Usage {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return provider.Usage{
+		PromptTokens:        prompt,
+		CompletionTokens:    u.OutputTokens,
+		TotalTokens:         prompt + u.OutputTokens,
+		CacheCreationTokens: u.CacheCreationInputTokens,
+		CacheReadTokens:     u.CacheReadInputTokens,
+	}
 }
 
 // anthropicError represents an API error from Anthropic.
@@ -150,11 +226,13 @@ type sseContentBlockDelta struct {
 }
 
 type sseDelta struct {
-	Type  string `json:"type"`
-	Text  string `json:"text,omitempty"`
-	ID    string `json:"id,omitempty"`    // tool_use delta
-	Name  string `json:"name,omitempty"`  // tool_use delta
-	Input string `json:"input,omitempty"` // tool_use partial_json delta (comes as partial_json type)
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	ID          string `json:"id,omitempty"`           // tool_use delta
+	Name        string `json:"name,omitempty"`         // tool_use delta
+	PartialJSON string `json:"partial_json,omitempty"` // input_json_delta: a fragment of the tool input
+
+	Thinking string `json:"thinking,omitempty"` // thinking_delta
 }
 
 type sseMessageDelta struct {
@@ -174,16 +252,10 @@ func (p *AnthropicProvider) ChatCompletion(ctx context.Context, req *provider.Ch
 	anthropicReq := p.buildRequest(req)
 	anthropicReq.Stream = false
 
-	body, err := json.Marshal(anthropicReq)
-	if err != nil {
-		return nil, fmt.Errorf("anthropic: marshal request: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(body))
+	httpReq, err := p.newHTTPRequest(ctx, anthropicReq)
 	if err != nil {
-		return nil, fmt.Errorf("anthropic: create request: %w", err)
+		return nil, err
 	}
-	p.setHeaders(httpReq)
 
 	resp, err := p.client.Do(httpReq)
 	if err != nil {
@@ -211,20 +283,24 @@ func (p *AnthropicProvider) ChatCompletion(ctx context.Context, req *provider.Ch
 // StreamChatCompletion sends a streaming chat completion request and returns
 // a ChatStream that yields chunks via SSE.
 func (p *AnthropicProvider) StreamChatCompletion(ctx context.Context, req *provider.ChatRequest) (provider.ChatStream, error) {
+	if p.bedrock {
+		// Bedrock streams use AWS's binary event-stream framing rather than
+		// SSE, so the response is fetched whole and replayed.
+		resp, err := p.ChatCompletion(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return newResponseStream(req, resp), nil
+	}
+
 	anthropicReq := p.buildRequest(req)
 	anthropicReq.Stream = true
 
-	body, err := json.Marshal(anthropicReq)
+	httpReq, err := p.newHTTPRequest(ctx, anthropicReq)
 	if err != nil {
-		return nil, fmt.Errorf("anthropic: marshal request: %w", err)
+		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("anthropic: create request: %w", err)
-	}
-	p.setHeaders(httpReq)
-
 	resp, err := p.client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("anthropic: send request: %w", err)
@@ -240,32 +316,41 @@ func (p *AnthropicProvider) StreamChatCompletion(ctx context.Context, req *provi
 	}
 
 	return &anthropicStream{
-		reader: bufio.NewReader(resp.Body),
-		body:   resp.Body,
+		reader:        bufio.NewReader(resp.Body),
+		body:          resp.Body,
+		StreamCounter: provider.NewStreamCounter(req),
 	}, nil
 }
 
 // ListModels returns the known Anthropic models. Anthropic does not provide
-// a list models endpoint, so we return a curated hardcoded list.
+// a list models endpoint, so we return a curated hardcoded list. Every model
+// listed has a 200K-token context window and accepts tools and images.
 func (p *AnthropicProvider) ListModels(_ context.Context) ([]provider.Model, error) {
 	models := []struct {
-		id   string
-		name string
+		id       string
+		name     string
+		thinking bool
 	}{
-		{"claude-opus-4-20250918", "Claude Opus 4"},
-		{"claude-sonnet-4-20250514", "Claude Sonnet 4"},
-		{"claude-haiku-4-5-20251001", "Claude Haiku 4.5"},
-		{"claude-3-5-sonnet-20241022", "Claude 3.5 Sonnet"},
-		{"claude-3-5-haiku-20241022", "Claude 3.5 Haiku"},
-		{"claude-3-opus-20240229", "Claude 3 Opus"},
+		{"claude-opus-4-20250918", "Claude Opus 4", true},
+		{"claude-sonnet-4-20250514", "Claude Sonnet 4", true},
+		{"claude-haiku-4-5-20251001", "Claude Haiku 4.5", true},
+		{"claude-3-5-sonnet-20241022", "Claude 3.5 Sonnet", false},
+		{"claude-3-5-haiku-20241022", "Claude 3.5 Haiku", false},
+		{"claude-3-opus-20240229", "Claude 3 Opus", false},
 	}
 
 	result := make([]provider.Model, len(models))
 	for i, m := range models {
+		caps := []string{provider.CapabilityTools, provider.CapabilityVision}
+		if m.thinking {
+			caps = append(caps, provider.CapabilityThinking)
+		}
 		result[i] = provider.Model{
-			ID:       m.id,
-			Provider: providerName,
-			Name:     m.name,
+			ID:            m.id,
+			Provider:      providerName,
+			Name:          m.name,
+			ContextLength: 200000,
+			Capabilities:  caps,
 		}
 	}
 	return result, nil
@@ -278,6 +363,8 @@ func (p *AnthropicProvider) ListModels(_ context.Context) ([]provider.Model, err
 // translation, and max_tokens default.
 func (p *AnthropicProvider) buildRequest(req *provider.ChatRequest) anthropicRequest {
 	var systemPrompt string
+	var systemBlocks []anthropicContentBlock
+	systemCached := false
 	var messages []anthropicMessage
 
 	for _, msg := range req.Messages {
@@ -288,6 +375,12 @@ func (p *AnthropicProvider) buildRequest(req *provider.ChatRequest) anthropicReq
 				systemPrompt += "\n\n"
 			}
 			systemPrompt += msg.Content
+			block := anthropicContentBlock{Type: "text", Text: msg.Content}
+			if msg.Cacheable {
+				block.CacheControl = ephemeralCache
+				systemCached = true
+			}
+			systemBlocks = append(systemBlocks, block)
 
 		case provider.RoleUser:
 			messages = append(messages, p.convertUserMessage(msg))
@@ -309,12 +402,18 @@ func (p *AnthropicProvider) buildRequest(req *provider.ChatRequest) anthropicReq
 	ar := anthropicRequest{
 		Model:       req.Model,
 		Messages:    messages,
-		System:      systemPrompt,
 		MaxTokens:   maxTokens,
 		Temperature: req.Temperature,
 		TopP:        req.TopP,
 		Stop:        req.Stop,
 	}
+	// A cache breakpoint needs the block form of system; otherwise keep the
+	// plain string.
+	if systemCached {
+		ar.System = systemBlocks
+	} else if systemPrompt != "" {
+		ar.System = systemPrompt
+	}
 
 	if len(req.Tools) > 0 {
 		ar.Tools = make([]anthropicTool, len(req.Tools))
@@ -331,14 +430,39 @@ func (p *AnthropicProvider) buildRequest(req *provider.ChatRequest) anthropicReq
 }
 
 func (p *AnthropicProvider) convertUserMessage(msg provider.Message) anthropicMessage {
+	if len(msg.Images) == 0 && !msg.Cacheable {
+		return anthropicMessage{
+			Role:    "user",
+			Content: msg.Content,
+		}
+	}
+
+	// Images go before the text, as Anthropic recommends.
+	var blocks []anthropicContentBlock
+	for _, img := range msg.Images {
+		blocks = append(blocks, anthropicContentBlock{
+			Type: "image",
+			Source: &anthropicImageSource{
+				Type:      "base64",
+				MediaType: provider.ImageMediaType(img),
+				Data:      base64.StdEncoding.EncodeToString(img),
+			},
+		})
+	}
+	if msg.Content != "" || len(blocks) == 0 {
+		blocks = append(blocks, anthropicContentBlock{Type: "text", Text: msg.Content})
+	}
+	if msg.Cacheable {
+		blocks[len(blocks)-1].CacheControl = ephemeralCache
+	}
 	return anthropicMessage{
 		Role:    "user",
-		Content: msg.Content,
+		Content: blocks,
 	}
 }
 
 func (p *AnthropicProvider) convertAssistantMessage(msg provider.Message) anthropicMessage {
-	if len(msg.ToolCalls) == 0 {
+	if len(msg.ToolCalls) == 0 && !msg.Cacheable {
 		return anthropicMessage{
 			Role:    "assistant",
 			Content: msg.Content,
@@ -363,6 +487,9 @@ func (p *AnthropicProvider) convertAssistantMessage(msg provider.Message) anthro
 			Input: input,
 		})
 	}
+	if msg.Cacheable && len(blocks) > 0 {
+		blocks[len(blocks)-1].CacheControl = ephemeralCache
+	}
 	return anthropicMessage{
 		Role:    "assistant",
 		Content: blocks,
@@ -376,6 +503,9 @@ func (p *AnthropicProvider) convertToolResultMessage(msg provider.Message) anthr
 		ToolUseID: msg.ToolCallID,
 		Content:   msg.Content,
 	}
+	if msg.Cacheable {
+		block.CacheControl = ephemeralCache
+	}
 	return anthropicMessage{
 		Role:    "user",
 		Content: []anthropicContentBlock{block},
@@ -391,10 +521,13 @@ func (p *AnthropicProvider) convertResponse(resp *anthropicResponse) *provider.C
 	}
 
 	var textParts []string
+	var thinking strings.Builder
 	for _, block := range resp.Content {
 		switch block.Type {
 		case "text":
 			textParts = append(textParts, block.Text)
+		case "thinking":
+			thinking.WriteString(block.Thinking)
 		case "tool_use":
 			argsJSON, _ := json.Marshal(block.Input)
 			msg.ToolCalls = append(msg.ToolCalls, provider.ToolCall{
@@ -409,19 +542,28 @@ func (p *AnthropicProvider) convertResponse(resp *anthropicResponse) *provider.C
 	}
 	msg.Content = strings.Join(textParts, "")
 
-	totalTokens := resp.Usage.InputTokens + resp.Usage.OutputTokens
+	usage := resp.Usage.toUsage()
+	usage.ReasoningTokens = thinkingTokens(thinking.String(), usage.CompletionTokens)
 
 	return &provider.ChatResponse{
 		ID:    resp.ID,
 		Model: resp.Model,
 		Message: msg,
-		Usage: provider.Usage{
-			PromptTokens:     resp.Usage.InputTokens,
-			CompletionTokens: resp.Usage.OutputTokens,
-			TotalTokens:      totalTokens,
-		},
-		Done: true,
+		Usage: usage,
+		Done:  true,
+	}
+}
+
+// thinkingTokens estimates the output tokens spent on extended thinking from
+// the thinking text. Anthropic bills thinking as output but
+// does not break it out in usage, so this is an estimate, capped at the
+// reported output tokens.
+func thinkingTokens(thinking string, outputTokens int) int {
+	n := provider.EstimateTokens(thinking)
+	if outputTokens > 0 && n > outputTokens {
+		n = outputTokens
 	}
+	return n
 }
 
 // --- Error handling ---
@@ -448,10 +590,44 @@ func (p *AnthropicProvider) parseErrorResponse(body []byte, statusCode int) *pro
 
 // --- HTTP helpers ---
 
+// newHTTPRequest encodes areq and returns the signed POST for it, to the
+// Messages API or, with WithBedrock, to InvokeModel.
+func (p *AnthropicProvider) newHTTPRequest(ctx context.Context, areq anthropicRequest) (*http.Request, error) {
+	endpoint := p.baseURL + "/v1/messages"
+	if p.bedrock {
+		// Escape ":" in model IDs such as "anthropic.claude-v2:1", as the
+		// AWS SDKs do, so the signed path matches the one Bedrock checks.
+		endpoint = p.baseURL + "/model/" + strings.ReplaceAll(url.PathEscape(areq.Model), ":", "%3A") + "/invoke"
+		areq.Model = ""
+		areq.AnthropicVersion = bedrockVersion
+	}
+
+	body, err := json.Marshal(areq)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: create request: %w", err)
+	}
+	p.setHeaders(httpReq)
+	if err := p.signer.Sign(httpReq, body); err != nil {
+		return nil, fmt.Errorf("anthropic: sign request: %w", err)
+	}
+	return httpReq, nil
+}
+
 func (p *AnthropicProvider) setHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", p.apiKey)
-	req.Header.Set("anthropic-version", apiVersion)
+	if p.apiKey != "" {
+		req.Header.Set("x-api-key", p.apiKey)
+	}
+	if !p.bedrock {
+		req.Header.Set("anthropic-version", apiVersion)
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
 }
 
 // --- Streaming ---
@@ -463,10 +639,32 @@ type anthropicStream struct {
 	id     string
 	model  string
 	done   bool
+
+	// startUsage holds the input and cache counts from message_start;
+	// message_delta usually reports only output tokens.
+	startUsage anthropicUsage
+
+	// partialUsage is startUsage converted, waiting to ride on the next chunk
+	// so a stream cut short before message_delta still reports its prompt
+	// tokens. Nil once sent.
+	partialUsage *provider.Usage
+
+	thinking strings.Builder // thinking_delta text seen so far
+
+	provider.StreamCounter
 }
 
-// Next reads and parses the next SSE event from the stream.
+// Next returns the next chunk, recording it for Stats.
 func (s *anthropicStream) Next() (*provider.ChatStreamChunk, error) {
+	chunk, err := s.next()
+	if err == nil {
+		s.Observe(chunk)
+	}
+	return chunk, err
+}
+
+// next reads and parses the next SSE event from the stream.
+func (s *anthropicStream) next() (*provider.ChatStreamChunk, error) {
 	if s.done {
 		return nil, io.EOF
 	}
@@ -485,7 +683,11 @@ func (s *anthropicStream) Next() (*provider.ChatStreamChunk, error) {
 			}
 			s.id = msg.Message.ID
 			s.model = msg.Message.Model
-			// message_start doesn't produce a user-visible chunk, continue.
+			s.startUsage = msg.Message.Usage
+			partial := msg.Message.Usage.toUsage()
+			s.partialUsage = &partial
+			// message_start doesn't produce a user-visible chunk; its usage
+			// goes out with the next one.
 			continue
 
 		case "content_block_start":
@@ -495,19 +697,15 @@ func (s *anthropicStream) Next() (*provider.ChatStreamChunk, error) {
 			}
 			// For tool_use blocks, emit the initial chunk with the tool call ID/name.
 			if block.ContentBlock.Type == "tool_use" {
-				return &provider.ChatStreamChunk{
-					ID:    s.id,
-					Model: s.model,
-					Delta: provider.MessageDelta{
-						ToolCalls: []provider.ToolCall{{
-							ID:   block.ContentBlock.ID,
-							Type: "function",
-							Function: provider.FunctionCall{
-								Name: block.ContentBlock.Name,
-							},
-						}},
-					},
-				}, nil
+				return s.chunk(provider.MessageDelta{
+					ToolCalls: []provider.ToolCall{{
+						ID:   block.ContentBlock.ID,
+						Type: "function",
+						Function: provider.FunctionCall{
+							Name: block.ContentBlock.Name,
+						},
+					}},
+				}), nil
 			}
 			continue
 
@@ -517,26 +715,21 @@ func (s *anthropicStream) Next() (*provider.ChatStreamChunk, error) {
 				return nil, fmt.Errorf("anthropic: parse content_block_delta: %w", err)
 			}
 			switch delta.Delta.Type {
+			case "thinking_delta":
+				s.thinking.WriteString(delta.Delta.Thinking)
+				continue
 			case "text_delta":
-				return &provider.ChatStreamChunk{
-					ID:    s.id,
-					Model: s.model,
-					Delta: provider.MessageDelta{
-						Content: delta.Delta.Text,
-					},
-				}, nil
+				return s.chunk(provider.MessageDelta{
+					Content: delta.Delta.Text,
+				}), nil
 			case "input_json_delta":
-				return &provider.ChatStreamChunk{
-					ID:    s.id,
-					Model: s.model,
-					Delta: provider.MessageDelta{
-						ToolCalls: []provider.ToolCall{{
-							Function: provider.FunctionCall{
-								Arguments: delta.Delta.Input,
-							},
-						}},
-					},
-				}, nil
+				return s.chunk(provider.MessageDelta{
+					ToolCalls: []provider.ToolCall{{
+						Function: provider.FunctionCall{
+							Arguments: delta.Delta.PartialJSON,
+						},
+					}},
+				}), nil
 			}
 			continue
 
@@ -550,19 +743,23 @@ func (s *anthropicStream) Next() (*provider.ChatStreamChunk, error) {
 			}
 			var usage *provider.Usage
 			if md.Usage != nil {
-				total := md.Usage.InputTokens + md.Usage.OutputTokens
-				usage = &provider.Usage{
-					PromptTokens:     md.Usage.InputTokens,
-					CompletionTokens: md.Usage.OutputTokens,
-					TotalTokens:      total,
+				u := *md.Usage
+				// Input counts from message_start apply unless the delta
+				// reports its own.
+				if u.InputTokens == 0 && u.CacheCreationInputTokens == 0 && u.CacheReadInputTokens == 0 {
+					u.InputTokens = s.startUsage.InputTokens
+					u.CacheCreationInputTokens = s.startUsage.CacheCreationInputTokens
+					u.CacheReadInputTokens = s.startUsage.CacheReadInputTokens
 				}
+				converted := u.toUsage()
+				converted.ReasoningTokens = thinkingTokens(s.thinking.String(), converted.CompletionTokens)
+				usage = &converted
 			}
-			return &provider.ChatStreamChunk{
-				ID:    s.id,
-				Model: s.model,
-				Delta: provider.MessageDelta{},
-				Usage: usage,
-			}, nil
+			chunk := s.chunk(provider.MessageDelta{})
+			if usage != nil {
+				chunk.Usage = usage
+			}
+			return chunk, nil
 
 		case "message_stop":
 			s.done = true
@@ -583,6 +780,19 @@ func (s *anthropicStream) Next() (*provider.ChatStreamChunk, error) {
 	}
 }
 
+// chunk builds a stream chunk carrying delta. The first chunk after
+// message_start also carries the partial usage.
+func (s *anthropicStream) chunk(delta provider.MessageDelta) *provider.ChatStreamChunk {
+	c := &provider.ChatStreamChunk{
+		ID:    s.id,
+		Model: s.model,
+		Delta: delta,
+		Usage: s.partialUsage,
+	}
+	s.partialUsage = nil
+	return c
+}
+
 // Close releases the underlying HTTP response body.
 func (s *anthropicStream) Close() error {
 	if s.body != nil {
@@ -591,6 +801,48 @@ func (s *anthropicStream) Close() error {
 	return nil
 }
 
+// responseStream implements provider.ChatStream over a complete response,
+// which it returns as a single final chunk.
+type responseStream struct {
+	chunk *provider.ChatStreamChunk // nil once returned
+
+	provider.StreamCounter
+}
+
+func newResponseStream(req *provider.ChatRequest, resp *provider.ChatResponse) *responseStream {
+	usage := resp.Usage
+	return &responseStream{
+		chunk: &provider.ChatStreamChunk{
+			ID:    resp.ID,
+			Model: resp.Model,
+			Delta: provider.MessageDelta{
+				Role:      provider.RoleAssistant,
+				Content:   resp.Message.Content,
+				ToolCalls: resp.Message.ToolCalls,
+			},
+			Done:  true,
+			Usage: &usage,
+		},
+		StreamCounter: provider.NewStreamCounter(req),
+	}
+}
+
+// Next returns the response, then io.EOF.
+func (s *responseStream) Next() (*provider.ChatStreamChunk, error) {
+	if s.chunk == nil {
+		return nil, io.EOF
+	}
+	chunk := s.chunk
+	s.chunk = nil
+	s.Observe(chunk)
+	return chunk, nil
+}
+
+// Close is a no-op; the response body has already been read.
+func (s *responseStream) Close() error {
+	return nil
+}
+
 // readSSEEvent reads a single SSE event (event + data lines) from the stream.
 func (s *anthropicStream) readSSEEvent() (event string, data string, err error) {
 	var eventType string
@@ -625,5 +877,7 @@ func (s *anthropicStream) readSSEEvent() (event string, data string, err error)
 	}
 }
 
-// Compile-time verification that AnthropicProvider satisfies the Provider interface.
+// Compile-time verification that AnthropicProvider satisfies the Provider and
+// CapabilityReporter interfaces.
 var _ provider.Provider = (*AnthropicProvider)(nil)
+var _ provider.CapabilityReporter = (*AnthropicProvider)(nil)
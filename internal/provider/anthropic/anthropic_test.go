@@ -2,6 +2,7 @@ package anthropic
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -523,6 +524,9 @@ func TestListModels(t *testing.T) {
 		if m.Name == "" {
 			t.Error("model has empty Name")
 		}
+		if m.ContextLength != 200000 || !m.HasCapability(provider.CapabilityTools) || !m.HasCapability(provider.CapabilityVision) {
+			t.Errorf("model %q: context %d, capabilities %v, want 200000 with tools and vision", m.ID, m.ContextLength, m.Capabilities)
+		}
 	}
 
 	// Check that claude-sonnet-4-20250514 is present.
@@ -652,10 +656,10 @@ event: content_block_start
 data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_abc","name":"get_weather"}}
 
 event: content_block_delta
-data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","input":"{\"location\":"}}
+data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"location\":"}}
 
 event: content_block_delta
-data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","input":"\"NYC\"}"}}
+data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"NYC\"}"}}
 
 event: content_block_stop
 data: {"type":"content_block_stop","index":0}
@@ -723,7 +727,700 @@ data: {"type":"message_stop"}
 	}
 }
 
+func TestStreamChatCompletion_ToolUseCollected(t *testing.T) {
+	sseData := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_ctool","type":"message","role":"assistant","content":[],"model":"claude-sonnet-4-20250514","stop_reason":null,"usage":{"input_tokens":10,"output_tokens":0}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking."}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"read_file","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"path\": \"ma"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"in.go\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":12}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, sseData)
+	}))
+	defer srv.Close()
+
+	p := New("key", WithBaseURL(srv.URL))
+	stream, err := p.StreamChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Read main.go"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	resp, err := provider.CollectStream(stream, nil)
+	if err != nil {
+		t.Fatalf("CollectStream: %v", err)
+	}
+	if resp.Message.Content != "Checking." {
+		t.Errorf("content = %q, want %q", resp.Message.Content, "Checking.")
+	}
+	calls := resp.Message.ToolCalls
+	if len(calls) != 1 || calls[0].ID != "toolu_1" || calls[0].Function.Name != "read_file" {
+		t.Fatalf("tool calls = %+v, want one read_file call", calls)
+	}
+	if got, want := calls[0].Function.Arguments, `{"path": "main.go"}`; got != want {
+		t.Errorf("arguments = %q, want %q", got, want)
+	}
+}
+
+func TestChatCompletion_PromptCaching(t *testing.T) {
+	var raw map[string]json.RawMessage
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &raw)
+
+		resp := anthropicResponse{
+			ID:      "msg_cache",
+			Type:    "message",
+			Role:    "assistant",
+			Content: []anthropicContentBlock{{Type: "text", Text: "OK"}},
+			Model:   "claude-sonnet-4-20250514",
+			Usage:   anthropicUsage{InputTokens: 5, OutputTokens: 2},
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	p := New("key", WithBaseURL(srv.URL))
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "Long shared instructions.", Cacheable: true},
+			{Role: provider.RoleSystem, Content: "Per-request note."},
+			{Role: provider.RoleUser, Content: "Reference document.", Cacheable: true},
+			{Role: provider.RoleUser, Content: "Question?"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A flagged system message switches system to block form with the
+	// breakpoint on the flagged block only.
+	var system []anthropicContentBlock
+	if err := json.Unmarshal(raw["system"], &system); err != nil {
+		t.Fatalf("system is not a block array: %s", raw["system"])
+	}
+	if len(system) != 2 {
+		t.Fatalf("system blocks = %d, want 2", len(system))
+	}
+	if system[0].CacheControl == nil || system[0].CacheControl.Type != "ephemeral" {
+		t.Errorf("system[0].cache_control = %+v, want ephemeral", system[0].CacheControl)
+	}
+	if system[1].CacheControl != nil {
+		t.Errorf("system[1].cache_control = %+v, want none", system[1].CacheControl)
+	}
+
+	var messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(raw["messages"], &messages); err != nil {
+		t.Fatalf("decoding messages: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("messages = %d, want 2", len(messages))
+	}
+	var blocks []anthropicContentBlock
+	if err := json.Unmarshal(messages[0].Content, &blocks); err != nil {
+		t.Fatalf("flagged user content is not a block array: %s", messages[0].Content)
+	}
+	if len(blocks) != 1 || blocks[0].Text != "Reference document." || blocks[0].CacheControl == nil {
+		t.Errorf("flagged user blocks = %+v, want one text block with cache_control", blocks)
+	}
+	var plain string
+	if err := json.Unmarshal(messages[1].Content, &plain); err != nil || plain != "Question?" {
+		t.Errorf("unflagged user content = %s, want plain string", messages[1].Content)
+	}
+}
+
+func TestChatCompletion_PromptCachingToolTurns(t *testing.T) {
+	var req struct {
+		Messages []struct {
+			Role    string                  `json:"role"`
+			Content []anthropicContentBlock `json:"content"`
+		} `json:"messages"`
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(anthropicResponse{
+			Type:    "message",
+			Role:    "assistant",
+			Content: []anthropicContentBlock{{Type: "text", Text: "OK"}},
+		})
+	}))
+	defer srv.Close()
+
+	p := New("key", WithBaseURL(srv.URL))
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "Weather?"},
+			{Role: provider.RoleAssistant, Content: "Checking.", Cacheable: true, ToolCalls: []provider.ToolCall{{
+				ID: "toolu_1", Type: "function",
+				Function: provider.FunctionCall{Name: "get_weather", Arguments: `{"city":"Oslo"}`},
+			}}},
+			{Role: provider.RoleTool, ToolCallID: "toolu_1", Content: "rain", Cacheable: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(req.Messages) != 3 {
+		t.Fatalf("messages = %d, want 3", len(req.Messages))
+	}
+
+	// The breakpoint goes on the last block of a flagged assistant turn.
+	assistant := req.Messages[1].Content
+	if len(assistant) != 2 || assistant[0].CacheControl != nil || assistant[1].CacheControl == nil {
+		t.Errorf("assistant blocks = %+v, want cache_control on the tool_use block only", assistant)
+	}
+	result := req.Messages[2].Content
+	if len(result) != 1 || result[0].Type != "tool_result" || result[0].CacheControl == nil {
+		t.Errorf("tool result blocks = %+v, want cache_control on the tool_result", result)
+	}
+}
+
+func TestChatCompletion_Images(t *testing.T) {
+	var req struct {
+		Messages []struct {
+			Role    string                  `json:"role"`
+			Content []anthropicContentBlock `json:"content"`
+		} `json:"messages"`
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(anthropicResponse{
+			Type:    "message",
+			Role:    "assistant",
+			Content: []anthropicContentBlock{{Type: "text", Text: "A settings page."}},
+		})
+	}))
+	defer srv.Close()
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF")
+	p := New("key", WithBaseURL(srv.URL))
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "Compare these screenshots.", Images: [][]byte{png, jpeg}, Cacheable: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(req.Messages) != 1 {
+		t.Fatalf("messages = %d, want 1", len(req.Messages))
+	}
+
+	blocks := req.Messages[0].Content
+	if len(blocks) != 3 {
+		t.Fatalf("blocks = %+v, want two images then the text", blocks)
+	}
+	for i, want := range []struct {
+		mediaType string
+		data      []byte
+	}{{"image/png", png}, {"image/jpeg", jpeg}} {
+		b := blocks[i]
+		if b.Type != "image" || b.Source == nil {
+			t.Fatalf("block %d = %+v, want an image block", i, b)
+		}
+		if b.Source.Type != "base64" || b.Source.MediaType != want.mediaType || b.Source.Data != base64.StdEncoding.EncodeToString(want.data) {
+			t.Errorf("block %d source = %+v, want base64 %s", i, b.Source, want.mediaType)
+		}
+		if b.CacheControl != nil {
+			t.Errorf("block %d has cache_control, want it on the last block only", i)
+		}
+	}
+	if blocks[2].Type != "text" || blocks[2].Text != "Compare these screenshots." || blocks[2].CacheControl == nil {
+		t.Errorf("last block = %+v, want the text with cache_control", blocks[2])
+	}
+}
+
+func TestChatCompletion_NoCacheControlByDefault(t *testing.T) {
+	var body []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(anthropicResponse{
+			Type:    "message",
+			Role:    "assistant",
+			Content: []anthropicContentBlock{{Type: "text", Text: "OK"}},
+		})
+	}))
+	defer srv.Close()
+
+	p := New("key", WithBaseURL(srv.URL))
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "Be concise."},
+			{Role: provider.RoleUser, Content: "Hi"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(body), "cache_control") {
+		t.Errorf("request contains cache_control without a flagged message: %s", body)
+	}
+	if !strings.Contains(string(body), `"system":"Be concise."`) {
+		t.Errorf("system should stay a plain string: %s", body)
+	}
+}
+
+func TestChatCompletion_CacheUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"msg_c","type":"message","role":"assistant","model":"claude-sonnet-4-20250514",
+			"content":[{"type":"text","text":"OK"}],
+			"usage":{"input_tokens":20,"output_tokens":7,"cache_creation_input_tokens":1500,"cache_read_input_tokens":3000}}`)
+	}))
+	defer srv.Close()
+
+	p := New("key", WithBaseURL(srv.URL))
+	resp, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := provider.Usage{
+		PromptTokens:        4520,
+		CompletionTokens:    7,
+		TotalTokens:         4527,
+		CacheCreationTokens: 1500,
+		CacheReadTokens:     3000,
+	}
+	if resp.Usage != want {
+		t.Errorf("usage = %+v, want %+v", resp.Usage, want)
+	}
+}
+
+func TestStreamChatCompletion_CacheUsage(t *testing.T) {
+	sseData := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_s","type":"message","role":"assistant","content":[],"model":"claude-sonnet-4-20250514","usage":{"input_tokens":20,"output_tokens":1,"cache_creation_input_tokens":0,"cache_read_input_tokens":3000}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"OK"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":9}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, sseData)
+	}))
+	defer srv.Close()
+
+	p := New("key", WithBaseURL(srv.URL))
+	stream, err := p.StreamChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	var usage *provider.Usage
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	want := provider.Usage{PromptTokens: 3020, CompletionTokens: 9, TotalTokens: 3029, CacheReadTokens: 3000}
+	if usage == nil || *usage != want {
+		t.Errorf("usage = %+v, want %+v", usage, want)
+	}
+}
+
+func TestStreamChatCompletion_CancelledKeepsPromptUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `event: message_start
+data: {"type":"message_start","message":{"id":"msg_c","type":"message","role":"assistant","content":[],"model":"claude-sonnet-4-20250514","usage":{"input_tokens":1200,"output_tokens":1,"cache_read_input_tokens":800}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Once upon"}}
+
+`)
+		w.(http.Flusher).Flush()
+		// A long generation: nothing more arrives before the client gives up.
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := New("key", WithBaseURL(srv.URL))
+	stream, err := p.StreamChatCompletion(ctx, &provider.ChatRequest{
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Tell me a long story"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	var usage *provider.Usage
+	chunk, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if chunk.Delta.Content != "Once upon" {
+		t.Errorf("content = %q, want %q", chunk.Delta.Content, "Once upon")
+	}
+	if chunk.Usage != nil {
+		usage = chunk.Usage
+	}
+
+	cancel()
+	for {
+		chunk, err := stream.Next()
+		if err != nil {
+			break
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+
+	if usage == nil {
+		t.Fatal("no usage reported before cancellation")
+	}
+	if usage.PromptTokens != 2000 || usage.CacheReadTokens != 800 {
+		t.Errorf("usage = %+v, want PromptTokens 2000 with CacheReadTokens 800", usage)
+	}
+
+	// Stats keeps the reported prompt counts and estimates the output from
+	// the 9 characters received.
+	st := stream.Stats()
+	want := provider.StreamStats{
+		Chunks: 1,
+		Usage: provider.Usage{
+			PromptTokens: 2000, CompletionTokens: 3, TotalTokens: 2003,
+			CacheReadTokens: 800, Estimated: true,
+		},
+	}
+	if st != want {
+		t.Errorf("stats = %+v, want %+v", st, want)
+	}
+}
+
+func TestChatCompletion_ThinkingUsage(t *testing.T) {
+	thinking := strings.Repeat("step ", 80) // 400 chars, ~100 tokens
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(anthropicResponse{
+			ID:   "msg_t",
+			Type: "message",
+			Role: "assistant",
+			Content: []anthropicContentBlock{
+				{Type: "thinking", Thinking: thinking},
+				{Type: "text", Text: "Answer."},
+			},
+			Usage: anthropicUsage{InputTokens: 10, OutputTokens: 130},
+		})
+	}))
+	defer srv.Close()
+
+	p := New("key", WithBaseURL(srv.URL))
+	resp, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Message.Content != "Answer." {
+		t.Errorf("content = %q, thinking must not leak into it", resp.Message.Content)
+	}
+	if resp.Usage.ReasoningTokens != 100 {
+		t.Errorf("ReasoningTokens = %d, want 100", resp.Usage.ReasoningTokens)
+	}
+	if resp.Usage.CompletionTokens != 130 {
+		t.Errorf("CompletionTokens = %d, want 130 (thinking is already included)", resp.Usage.CompletionTokens)
+	}
+}
+
+func TestStreamChatCompletion_ThinkingUsage(t *testing.T) {
+	sseData := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_s","type":"message","role":"assistant","content":[],"model":"claude-sonnet-4-20250514","usage":{"input_tokens":10,"output_tokens":1}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me work this out carefully."}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"OK"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, sseData)
+	}))
+	defer srv.Close()
+
+	p := New("key", WithBaseURL(srv.URL))
+	stream, err := p.StreamChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	var content string
+	var usage *provider.Usage
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		content += chunk.Delta.Content
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	if content != "OK" {
+		t.Errorf("content = %q, want %q", content, "OK")
+	}
+	// 31 chars estimates to 8 tokens, capped at the 4 output tokens reported.
+	if usage == nil || usage.ReasoningTokens != 4 {
+		t.Errorf("usage = %+v, want ReasoningTokens 4", usage)
+	}
+}
+
 // Verify the compile-time interface check.
 func TestProviderInterface(t *testing.T) {
 	var _ provider.Provider = (*AnthropicProvider)(nil)
 }
+
+func TestDefaultHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		json.NewEncoder(w).Encode(anthropicResponse{Type: "message", Role: "assistant", Content: []anthropicContentBlock{{Type: "text", Text: "OK"}}})
+	}))
+	defer srv.Close()
+
+	p := New("key", WithBaseURL(srv.URL), WithDefaultHeaders(map[string]string{"X-Request-Id": "req-1"}))
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Get("X-Request-Id") != "req-1" || got.Get("x-api-key") != "key" {
+		t.Errorf("headers = %v, want X-Request-Id alongside x-api-key", got)
+	}
+}
+
+func TestRequestSigner(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		json.NewEncoder(w).Encode(anthropicResponse{Type: "message", Role: "assistant", Content: []anthropicContentBlock{{Type: "text", Text: "OK"}}})
+	}))
+	defer srv.Close()
+
+	var calls int
+	var signedBody []byte
+	signer := provider.SignerFunc(func(req *http.Request, body []byte) error {
+		calls++
+		signedBody = body
+		if req.Header.Get("anthropic-version") == "" {
+			t.Error("signer ran before the adapter's headers were set")
+		}
+		req.Header.Set("Authorization", "Signed abc")
+		return nil
+	})
+
+	p := New("", WithBaseURL(srv.URL), WithRequestSigner(signer))
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("signer calls = %d, want 1", calls)
+	}
+	if !strings.Contains(string(signedBody), `"model":"claude-sonnet-4-20250514"`) {
+		t.Errorf("signer got body %s, want the request body", signedBody)
+	}
+	if got.Get("Authorization") != "Signed abc" {
+		t.Errorf("Authorization = %q, want the signer's header", got.Get("Authorization"))
+	}
+	if got.Get("x-api-key") != "" {
+		t.Errorf("x-api-key = %q, want none without an API key", got.Get("x-api-key"))
+	}
+
+	stream, err := p.StreamChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err == nil {
+		stream.Close()
+	}
+	if calls != 2 {
+		t.Errorf("signer calls after streaming = %d, want 2", calls)
+	}
+}
+
+func TestBedrockInvokeModel(t *testing.T) {
+	var paths []string
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		json.NewEncoder(w).Encode(anthropicResponse{
+			ID: "msg_1", Type: "message", Role: "assistant", Model: "claude-3-5-sonnet",
+			Content: []anthropicContentBlock{{Type: "text", Text: "OK"}},
+			Usage:   anthropicUsage{InputTokens: 5, OutputTokens: 1},
+		})
+	}))
+	defer srv.Close()
+
+	p := New("", WithBaseURL(srv.URL), WithBedrock())
+	req := &provider.ChatRequest{
+		Model:    "anthropic.claude-3-5-sonnet-20240620-v1:0",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	}
+	resp, err := p.ChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Message.Content != "OK" {
+		t.Errorf("content = %q, want OK", resp.Message.Content)
+	}
+
+	// Streaming goes to the same endpoint and replays the whole response.
+	stream, err := p.StreamChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+	chunk, err := stream.Next()
+	if err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+	if chunk.Delta.Content != "OK" || !chunk.Done || chunk.Usage == nil || chunk.Usage.PromptTokens != 5 {
+		t.Errorf("streamed chunk = %+v, want a final chunk with content OK and 5 prompt tokens", chunk)
+	}
+	if _, err := stream.Next(); err != io.EOF {
+		t.Errorf("second Next error = %v, want io.EOF", err)
+	}
+	stream.Close()
+
+	want := "POST /model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/invoke"
+	for i, path := range paths {
+		if path != want {
+			t.Errorf("request %d = %s, want %s", i, path, want)
+		}
+		body := bodies[i]
+		if body["anthropic_version"] != "bedrock-2023-05-31" {
+			t.Errorf("request %d anthropic_version = %v, want bedrock-2023-05-31", i, body["anthropic_version"])
+		}
+		if _, ok := body["model"]; ok {
+			t.Errorf("request %d body has a model field; InvokeModel takes it from the path", i)
+		}
+		if _, ok := body["stream"]; ok {
+			t.Errorf("request %d body has a stream field", i)
+		}
+		if body["max_tokens"] == nil || body["messages"] == nil {
+			t.Errorf("request %d body = %v, want max_tokens and messages", i, body)
+		}
+	}
+	if len(paths) != 2 {
+		t.Errorf("requests = %d, want 2", len(paths))
+	}
+}
+
+func TestRequestSigner_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not be sent when signing fails")
+	}))
+	defer srv.Close()
+
+	p := New("", WithBaseURL(srv.URL), WithRequestSigner(provider.SignerFunc(func(*http.Request, []byte) error {
+		return fmt.Errorf("no credentials")
+	})))
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "sign request") {
+		t.Errorf("err = %v, want sign request error", err)
+	}
+}
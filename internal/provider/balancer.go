@@ -17,9 +17,15 @@ const (
 	// StrategyRandom selects a backend at random using crypto/rand.
 	StrategyRandom Strategy = "random"
 
-	// StrategyLeastLoad selects the backend with the fewest in-flight requests.
-	// Reserved for future implementation; currently falls back to round-robin.
-	StrategyLeastLoad Strategy = "least-load"
+	// StrategyLeastLoaded selects the backend with the fewest in-flight
+	// requests, breaking ties round-robin. Callers must pair every Select with
+	// a Release once the request completes.
+	StrategyLeastLoaded Strategy = "least-loaded"
+
+	// StrategyWeighted distributes requests in proportion to per-backend
+	// weights using smooth weighted round-robin, which interleaves picks
+	// rather than sending runs of requests to the heaviest backend.
+	StrategyWeighted Strategy = "weighted"
 )
 
 // WeightedOption pairs a backend value with a relative weight for weighted
@@ -35,6 +41,13 @@ type WeightedOption struct {
 type Balancer struct {
 	strategy Strategy
 	counters sync.Map // map[string]*atomic.Uint64 — per-group counters
+
+	mu       sync.Mutex
+	inflight map[string]map[string]int // group -> backend -> in-flight requests (least-loaded only)
+	current  map[string]map[string]int // group -> backend -> current weight (weighted only)
+
+	weights map[string]int // backend -> weight (weighted only; missing means 1)
+	members []string       // backends used by Next
 }
 
 // NewBalancer creates a Balancer with the given strategy.
@@ -44,6 +57,23 @@ func NewBalancer(strategy Strategy) *Balancer {
 	}
 }
 
+// NewWeightedBalancer creates a Balancer using StrategyWeighted over the given
+// options. The options become the members returned by Next, and their weights
+// also apply when the same backends are passed to Select. Backends without a
+// configured weight default to 1.
+func NewWeightedBalancer(options []WeightedOption) *Balancer {
+	b := &Balancer{
+		strategy: StrategyWeighted,
+		weights:  make(map[string]int, len(options)),
+		members:  make([]string, len(options)),
+	}
+	for i, opt := range options {
+		b.weights[opt.Value] = opt.Weight
+		b.members[i] = opt.Value
+	}
+	return b
+}
+
 // Select picks one backend from the list for the given group.
 //
 // For round-robin: uses an atomic counter per group, modulo the number of
@@ -51,20 +81,30 @@ func NewBalancer(strategy Strategy) *Balancer {
 //
 // For random: uses crypto/rand for unbiased selection.
 //
+// For weighted: smooth weighted round-robin over the backends' weights. If
+// every weight is zero, plain round-robin is used.
+//
+// For least-loaded: picks the backend with the fewest in-flight requests and
+// counts the selection as in flight until Release is called.
+//
 // Returns an empty string if backends is empty.
 func (b *Balancer) Select(group string, backends []string) string {
 	if len(backends) == 0 {
 		return ""
 	}
+	if b.strategy == StrategyLeastLoaded {
+		return b.selectLeastLoaded(group, backends)
+	}
 	if len(backends) == 1 {
 		return backends[0]
 	}
 
 	switch b.strategy {
+	case StrategyWeighted:
+		return b.selectSmoothWeighted(group, backends)
 	case StrategyRandom:
 		return backends[cryptoRandIntn(len(backends))]
-	case StrategyRoundRobin, StrategyLeastLoad:
-		// LeastLoad falls back to round-robin until implemented.
+	case StrategyRoundRobin:
 		counter := b.getCounter(group)
 		idx := counter.Add(1) - 1 // 0-indexed
 		return backends[idx%uint64(len(backends))]
@@ -76,6 +116,100 @@ func (b *Balancer) Select(group string, backends []string) string {
 	}
 }
 
+// Next picks the next backend from the members the balancer was constructed
+// with (see NewWeightedBalancer). Returns an empty string if there are none.
+func (b *Balancer) Next() string {
+	return b.Select("", b.members)
+}
+
+// Release marks a request previously assigned to backend by Select as
+// finished. It only has an effect for the least-loaded strategy and is safe to
+// call for any strategy.
+func (b *Balancer) Release(group, backend string) {
+	if b.strategy != StrategyLeastLoaded {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n := b.inflight[group][backend]; n > 0 {
+		b.inflight[group][backend] = n - 1
+	}
+}
+
+// selectLeastLoaded returns the backend with the fewest in-flight requests in
+// group and increments its count. The scan starts at a round-robin offset so
+// that ties rotate across backends instead of always favouring the first.
+func (b *Balancer) selectLeastLoaded(group string, backends []string) string {
+	start := int((b.getCounter(group).Add(1) - 1) % uint64(len(backends)))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.inflight == nil {
+		b.inflight = make(map[string]map[string]int)
+	}
+	load, ok := b.inflight[group]
+	if !ok {
+		load = make(map[string]int)
+		b.inflight[group] = load
+	}
+
+	best := backends[start]
+	for i := 1; i < len(backends); i++ {
+		candidate := backends[(start+i)%len(backends)]
+		if load[candidate] < load[best] {
+			best = candidate
+		}
+	}
+	load[best]++
+	return best
+}
+
+// selectSmoothWeighted implements nginx-style smooth weighted round-robin:
+// every backend's current weight grows by its weight, the largest is picked,
+// and the pick is reduced by the total. Over any window of sum(weights)
+// selections each backend is chosen exactly weight times.
+func (b *Balancer) selectSmoothWeighted(group string, backends []string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.current == nil {
+		b.current = make(map[string]map[string]int)
+	}
+	cur, ok := b.current[group]
+	if !ok {
+		cur = make(map[string]int)
+		b.current[group] = cur
+	}
+
+	total := 0
+	best := ""
+	for _, be := range backends {
+		w := b.weightOf(be)
+		if w <= 0 {
+			continue
+		}
+		cur[be] += w
+		total += w
+		if best == "" || cur[be] > cur[best] {
+			best = be
+		}
+	}
+	if best == "" {
+		// All weights zero: fall back to round-robin.
+		idx := b.getCounter(group).Add(1) - 1
+		return backends[idx%uint64(len(backends))]
+	}
+	cur[best] -= total
+	return best
+}
+
+// weightOf returns the configured weight for a backend, defaulting to 1.
+func (b *Balancer) weightOf(backend string) int {
+	if w, ok := b.weights[backend]; ok {
+		return w
+	}
+	return 1
+}
+
 // SelectWeighted picks one backend from the weighted options using crypto/rand.
 // The probability of selecting an option is proportional to its weight relative
 // to the total weight. If all weights are zero, uniform random selection is used.
@@ -1,8 +1,10 @@
 package provider
 
 import (
+	"math"
 	"sync"
 	"testing"
+	"time"
 )
 
 // ---------------------------------------------------------------------------
@@ -212,12 +214,169 @@ func TestSelect_EmptyBackends_Random(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Least Loaded
+// ---------------------------------------------------------------------------
+
+func TestLeastLoaded_PrefersIdleBackend(t *testing.T) {
+	b := NewBalancer(StrategyLeastLoaded)
+	backends := []string{"a", "b", "c"}
+
+	// Three selections with nothing released spread across all backends.
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		seen[b.Select("g", backends)] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("expected all 3 backends in flight, got %v", seen)
+	}
+
+	// Releasing "b" makes it the only idle backend.
+	b.Release("g", "b")
+	if got := b.Select("g", backends); got != "b" {
+		t.Errorf("expected idle backend b, got %s", got)
+	}
+}
+
+func TestLeastLoaded_TiesRotate(t *testing.T) {
+	b := NewBalancer(StrategyLeastLoaded)
+	backends := []string{"a", "b", "c"}
+
+	counts := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		got := b.Select("g", backends)
+		counts[got]++
+		b.Release("g", got)
+	}
+	for _, be := range backends {
+		if counts[be] != 10 {
+			t.Errorf("backend %s selected %d times, want 10 (counts=%v)", be, counts[be], counts)
+		}
+	}
+}
+
+func TestLeastLoaded_UnevenLatency(t *testing.T) {
+	b := NewBalancer(StrategyLeastLoaded)
+	backends := []string{"slow", "fast"}
+	latency := map[string]time.Duration{"slow": 40 * time.Millisecond, "fast": 2 * time.Millisecond}
+
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	sem := make(chan struct{}, len(backends))
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			be := b.Select("pool", backends)
+			defer b.Release("pool", be)
+			time.Sleep(latency[be])
+
+			mu.Lock()
+			counts[be]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if counts["fast"] <= counts["slow"] {
+		t.Errorf("expected more work on the fast backend, got %v", counts)
+	}
+}
+
+func TestLeastLoaded_ReleaseUnknownIsNoop(t *testing.T) {
+	b := NewBalancer(StrategyLeastLoaded)
+	b.Release("g", "missing") // must not panic
+	if got := b.Select("g", []string{"x"}); got != "x" {
+		t.Errorf("expected x, got %s", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Smooth Weighted Round-Robin
+// ---------------------------------------------------------------------------
+
+func TestWeighted_Distribution(t *testing.T) {
+	opts := []WeightedOption{
+		{Value: "gpu", Weight: 5},
+		{Value: "mid", Weight: 2},
+		{Value: "laptop", Weight: 1},
+	}
+	b := NewWeightedBalancer(opts)
+
+	const rounds = 8000
+	counts := make(map[string]int)
+	for i := 0; i < rounds; i++ {
+		counts[b.Next()]++
+	}
+
+	total := 8
+	for _, opt := range opts {
+		want := float64(rounds) * float64(opt.Weight) / float64(total)
+		got := float64(counts[opt.Value])
+		if math.Abs(got-want)/want > 0.01 {
+			t.Errorf("%s: got %d picks, want ~%.0f (counts=%v)", opt.Value, counts[opt.Value], want, counts)
+		}
+	}
+}
+
+func TestWeighted_Smooth(t *testing.T) {
+	b := NewWeightedBalancer([]WeightedOption{
+		{Value: "a", Weight: 5},
+		{Value: "b", Weight: 1},
+		{Value: "c", Weight: 1},
+	})
+
+	// Smooth WRR interleaves the lighter members instead of emitting a run of
+	// five "a" picks first.
+	want := []string{"a", "a", "b", "a", "c", "a", "a"}
+	for i, w := range want {
+		if got := b.Next(); got != w {
+			t.Errorf("pick %d = %s, want %s", i, got, w)
+		}
+	}
+}
+
+func TestWeighted_DefaultWeightViaSelect(t *testing.T) {
+	// Backends without a configured weight default to 1.
+	b := NewWeightedBalancer([]WeightedOption{{Value: "big", Weight: 3}})
+
+	counts := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		counts[b.Select("pool", []string{"big", "small"})]++
+	}
+	if counts["big"] != 300 || counts["small"] != 100 {
+		t.Errorf("expected 300/100 split, got %v", counts)
+	}
+}
+
+func TestWeighted_AllZeroFallsBackToRoundRobin(t *testing.T) {
+	b := NewWeightedBalancer([]WeightedOption{{Value: "a"}, {Value: "b"}})
+
+	counts := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		counts[b.Next()]++
+	}
+	if counts["a"] != 5 || counts["b"] != 5 {
+		t.Errorf("expected even split with zero weights, got %v", counts)
+	}
+}
+
+func TestNext_NoMembers(t *testing.T) {
+	if got := NewBalancer(StrategyRoundRobin).Next(); got != "" {
+		t.Errorf("expected empty string, got %q", got)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Strategy Validation
 // ---------------------------------------------------------------------------
 
 func TestNewBalancer_Strategies(t *testing.T) {
-	strategies := []Strategy{StrategyRoundRobin, StrategyRandom, StrategyLeastLoad}
+	strategies := []Strategy{StrategyRoundRobin, StrategyRandom, StrategyLeastLoaded, StrategyWeighted}
 	for _, s := range strategies {
 		b := NewBalancer(s)
 		if b == nil {
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupported is wrapped by errors for requests that use a feature the
+// provider or model cannot honour, such as images sent to a text-only model
+// or a seed sent to Anthropic. Failing is preferred to dropping the feature
+// silently. It is not retryable.
+var ErrUnsupported = errors.New("provider: unsupported feature")
+
+// Capabilities lists the optional ChatRequest features an adapter
+// translates. Temperature, top_p, max_tokens and stop sequences are basic
+// and always allowed.
+type Capabilities struct {
+	Tools     bool // ChatRequest.Tools and tool turns
+	Images    bool // Message.Images; a model may still refuse them
+	Seed      bool // ChatRequest.Seed
+	Penalties bool // ChatRequest.FrequencyPenalty and PresencePenalty
+}
+
+// BasicCapabilities are assumed for providers that do not report their own:
+// plain chat with tool calling.
+var BasicCapabilities = Capabilities{Tools: true}
+
+// CapabilityReporter is implemented by providers that report which optional
+// request features they support.
+type CapabilityReporter interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns p's capabilities, or BasicCapabilities when p does
+// not report them.
+func CapabilitiesOf(p Provider) Capabilities {
+	if cr, ok := p.(CapabilityReporter); ok {
+		return cr.Capabilities()
+	}
+	return BasicCapabilities
+}
+
+// Unsupported returns the features req uses that c lacks, by their request
+// field names; nil when c covers the request.
+func (c Capabilities) Unsupported(req *ChatRequest) []string {
+	var missing []string
+	if !c.Tools && len(req.Tools) > 0 {
+		missing = append(missing, "tools")
+	}
+	if !c.Images {
+		for _, m := range req.Messages {
+			if len(m.Images) > 0 {
+				missing = append(missing, "images")
+				break
+			}
+		}
+	}
+	if !c.Seed && req.Seed != nil {
+		missing = append(missing, "seed")
+	}
+	if !c.Penalties && req.FrequencyPenalty != nil {
+		missing = append(missing, "frequency_penalty")
+	}
+	if !c.Penalties && req.PresencePenalty != nil {
+		missing = append(missing, "presence_penalty")
+	}
+	return missing
+}
+
+// checkCapabilities returns an error wrapping ErrUnsupported when req uses
+// features p does not support.
+func checkCapabilities(p Provider, req *ChatRequest) error {
+	missing := CapabilitiesOf(p).Unsupported(req)
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (model %q) does not support %s; unset it or route to a provider that does",
+		ErrUnsupported, p.Name(), req.Model, strings.Join(missing, ", "))
+}
@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// reportingProvider is a mockProvider with declared capabilities.
+type reportingProvider struct {
+	*mockProvider
+	caps Capabilities
+}
+
+func (p reportingProvider) Capabilities() Capabilities { return p.caps }
+
+func TestCapabilitiesUnsupported(t *testing.T) {
+	seed := 7
+	pen := 0.5
+	png := []byte("\x89PNG\r\n\x1a\n")
+	req := &ChatRequest{
+		Model:            "m",
+		Messages:         []Message{{Role: RoleUser, Content: "hi", Images: [][]byte{png}}},
+		Tools:            []Tool{{Type: "function", Function: ToolFunction{Name: "f"}}},
+		Seed:             &seed,
+		FrequencyPenalty: &pen,
+		PresencePenalty:  &pen,
+	}
+
+	all := Capabilities{Tools: true, Images: true, Seed: true, Penalties: true}
+	if got := all.Unsupported(req); got != nil {
+		t.Errorf("full capabilities: Unsupported = %v, want nil", got)
+	}
+	want := []string{"tools", "images", "seed", "frequency_penalty", "presence_penalty"}
+	if got := (Capabilities{}).Unsupported(req); !reflect.DeepEqual(got, want) {
+		t.Errorf("no capabilities: Unsupported = %v, want %v", got, want)
+	}
+	plain := &ChatRequest{Model: "m", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if got := (Capabilities{}).Unsupported(plain); got != nil {
+		t.Errorf("basic request: Unsupported = %v, want nil", got)
+	}
+
+	if got := CapabilitiesOf(&mockProvider{name: "m"}); got != BasicCapabilities {
+		t.Errorf("CapabilitiesOf(non-reporter) = %+v, want BasicCapabilities", got)
+	}
+}
+
+func TestRouterRejectsUnsupportedFeatures(t *testing.T) {
+	called := false
+	primary := &mockProvider{
+		name: "primary",
+		chatFn: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+			called = true
+			return &ChatResponse{}, nil
+		},
+	}
+	r := newTestRouter(t, primary, &mockProvider{name: "fallback"})
+
+	seed := 42
+	req := &ChatRequest{Model: "model-a", Seed: &seed, Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	_, err := r.ChatCompletion(context.Background(), req)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("ChatCompletion err = %v, want ErrUnsupported", err)
+	}
+	if !strings.Contains(err.Error(), `primary (model "real-model-a") does not support seed`) {
+		t.Errorf("error = %q, want it to name the provider, model and feature", err)
+	}
+	if _, err := r.StreamChatCompletionForRole(context.Background(), "worker", req); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("StreamChatCompletionForRole err = %v, want ErrUnsupported", err)
+	}
+	if called {
+		t.Error("provider called with a feature it does not support")
+	}
+	if ClassifyError(err).Retryable() {
+		t.Error("unsupported-feature errors should not be retryable")
+	}
+}
+
+func TestRouterAllowsReportedFeatures(t *testing.T) {
+	var got *ChatRequest
+	primary := reportingProvider{
+		mockProvider: &mockProvider{
+			name: "primary",
+			chatFn: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+				got = req
+				return &ChatResponse{}, nil
+			},
+		},
+		caps: Capabilities{Tools: true, Seed: true},
+	}
+	factories := map[string]ProviderFactory{
+		"mock-primary":  func(ProviderConfig) (Provider, error) { return primary, nil },
+		"mock-fallback": func(ProviderConfig) (Provider, error) { return &mockProvider{name: "fallback"}, nil },
+	}
+	r, err := NewRouter(routerTestConfig(), factories)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+
+	seed := 42
+	req := &ChatRequest{Model: "model-a", Seed: &seed, Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if _, err := r.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if got == nil || got.Seed == nil || *got.Seed != 42 {
+		t.Errorf("provider got %+v, want the seed passed through", got)
+	}
+}
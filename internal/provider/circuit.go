@@ -0,0 +1,215 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RouterOption configures a Router during construction.
+type RouterOption func(*Router)
+
+// WithCircuitBreaker enables a per-model circuit breaker. After failures
+// consecutive transient failures (rate limit, 5xx, timeout, network error)
+// on a model, each no more than cooldown apart, the circuit opens: requests
+// to that model fail immediately with a *CircuitOpenError, which is
+// retryable, so the router goes straight to the fallback chain instead of
+// waiting out another timeout. Once cooldown has passed, the next request is
+// let through as a probe (half-open). Success closes the circuit; failure
+// opens it for another cooldown.
+//
+// A model is a provider instance plus model name, so the same model served by
+// two Ollama nodes has two independent circuits. failures <= 0 disables the
+// breaker.
+func WithCircuitBreaker(failures int, cooldown time.Duration) RouterOption {
+	return func(r *Router) {
+		if failures <= 0 {
+			r.circuits = nil
+			return
+		}
+		r.circuits = &circuits{
+			threshold: failures,
+			cooldown:  cooldown,
+			now:       time.Now,
+			state:     make(map[circuitKey]*circuit),
+		}
+	}
+}
+
+// CircuitOpenError is returned, without contacting the provider, for a model
+// whose circuit is open.
+type CircuitOpenError struct {
+	Model string    // model name sent to the provider
+	Until time.Time // when the next probe will be allowed
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("router: circuit open for model %q until %s", e.Model, e.Until.Format(time.TimeOnly))
+}
+
+type circuitKey struct {
+	p     Provider
+	model string
+}
+
+type circuit struct {
+	failures    int       // consecutive counted failures
+	lastFailure time.Time // time of the most recent counted failure
+	openUntil   time.Time // zero while closed
+	probing     bool      // a half-open probe is in flight
+}
+
+// circuits tracks breaker state for every model the router has called.
+// A nil *circuits is a disabled breaker.
+type circuits struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	state     map[circuitKey]*circuit
+}
+
+// allow reports whether a request to k may proceed, claiming the probe slot
+// when the circuit is half-open.
+func (cs *circuits) allow(k circuitKey) error {
+	if cs == nil {
+		return nil
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	c := cs.state[k]
+	if c == nil || c.openUntil.IsZero() {
+		return nil
+	}
+	if !c.probing && !cs.now().Before(c.openUntil) {
+		c.probing = true
+		return nil
+	}
+	return &CircuitOpenError{Model: k.model, Until: c.openUntil}
+}
+
+// record updates k's circuit with the outcome of a request that allow let
+// through. Transient failures count toward opening the circuit; success, or
+// any answer from the provider (e.g. a 400), closes it. Errors that say
+// nothing about the model's health, such as the caller cancelling, only
+// release the probe slot.
+func (cs *circuits) record(ctx context.Context, k circuitKey, err error) {
+	if cs == nil {
+		return
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	var apiErr *APIError
+	healthy := err == nil || (!countsAsFailure(err) && errors.As(err, &apiErr))
+	if healthy {
+		delete(cs.state, k)
+		return
+	}
+	if ctx.Err() != nil || !countsAsFailure(err) {
+		if c := cs.state[k]; c != nil {
+			c.probing = false
+		}
+		return
+	}
+
+	now := cs.now()
+	c := cs.state[k]
+	if c == nil {
+		c = &circuit{}
+		cs.state[k] = c
+	}
+	if c.probing {
+		c.probing = false
+		c.openUntil = now.Add(cs.cooldown)
+		c.lastFailure = now
+		return
+	}
+	if now.Sub(c.lastFailure) > cs.cooldown {
+		c.failures = 0 // too long since the last failure to be consecutive
+	}
+	c.failures++
+	c.lastFailure = now
+	if c.failures >= cs.threshold {
+		c.openUntil = now.Add(cs.cooldown)
+	}
+}
+
+// countsAsFailure reports whether err suggests the model itself is unhealthy.
+func countsAsFailure(err error) bool {
+	switch ClassifyError(err) {
+	case ErrRateLimit, ErrServerError, ErrTimeout, ErrNetwork:
+		return true
+	}
+	return false
+}
+
+// chat sends req to p through the model's circuit breaker, after checking
+// that p supports the features req uses. role is "" for requests routed by
+// alias.
+func (r *Router) chat(ctx context.Context, role string, p Provider, req *ChatRequest) (*ChatResponse, error) {
+	if err := r.admit(role, p, req, false); err != nil {
+		return nil, err
+	}
+	k := circuitKey{p, req.Model}
+	start := time.Now()
+	resp, err := p.ChatCompletion(ctx, req)
+	r.circuits.record(ctx, k, err)
+	end := TraceEvent{Kind: TraceRequestEnd, Role: role, Provider: p.Name(), Model: req.Model, Latency: time.Since(start), Err: err}
+	if err != nil {
+		r.trace(end)
+		r.logFailure(p, req, start, err)
+		return nil, err
+	}
+	end.Usage = resp.Usage
+	r.trace(end)
+	r.log.Debug("request done", "provider", p.Name(), "model", req.Model, "elapsed", time.Since(start),
+		"prompt_tokens", resp.Usage.PromptTokens, "completion_tokens", resp.Usage.CompletionTokens)
+	return resp, nil
+}
+
+// stream opens a stream on p through the model's circuit breaker, after the
+// same capability check as chat. Only the outcome of opening the stream is
+// recorded.
+func (r *Router) stream(ctx context.Context, role string, p Provider, req *ChatRequest) (ChatStream, error) {
+	if err := r.admit(role, p, req, true); err != nil {
+		return nil, err
+	}
+	k := circuitKey{p, req.Model}
+	start := time.Now()
+	s, err := p.StreamChatCompletion(ctx, req)
+	r.circuits.record(ctx, k, err)
+	r.trace(TraceEvent{Kind: TraceRequestEnd, Role: role, Provider: p.Name(), Model: req.Model, Stream: true, Latency: time.Since(start), Err: err})
+	if err != nil {
+		r.logFailure(p, req, start, err)
+		return nil, err
+	}
+	r.log.Debug("stream opened", "provider", p.Name(), "model", req.Model, "elapsed", time.Since(start))
+	return s, nil
+}
+
+// admit runs the checks shared by chat and stream before a request is sent:
+// the capability check and the model's circuit. A request that passes is
+// traced as started.
+func (r *Router) admit(role string, p Provider, req *ChatRequest, stream bool) error {
+	if err := checkCapabilities(p, req); err != nil {
+		r.log.Warn("unsupported request", "provider", p.Name(), "model", req.Model, "err", err)
+		return err
+	}
+	if err := r.circuits.allow(circuitKey{p, req.Model}); err != nil {
+		r.log.Warn("circuit open", "provider", p.Name(), "model", req.Model, "err", err)
+		return err
+	}
+	r.trace(TraceEvent{Kind: TraceRequestStart, Role: role, Provider: p.Name(), Model: req.Model, Stream: stream})
+	r.log.Debug("request start", "provider", p.Name(), "model", req.Model,
+		"messages", len(req.Messages), "stream", stream)
+	return nil
+}
+
+// logFailure logs a request the provider failed, with its error class.
+func (r *Router) logFailure(p Provider, req *ChatRequest, start time.Time, err error) {
+	r.log.Warn("request failed", "provider", p.Name(), "model", req.Model, "elapsed", time.Since(start),
+		"code", ClassifyError(err), "err", err)
+}
@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source for breaker tests.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newBreakerRouter builds the routerTestConfig router with a circuit breaker
+// driven by a fake clock.
+func newBreakerRouter(t *testing.T, primary, fallback *mockProvider, failures int, cooldown time.Duration) (*Router, *fakeClock) {
+	t.Helper()
+	factories := map[string]ProviderFactory{
+		"mock-primary":  func(ProviderConfig) (Provider, error) { return primary, nil },
+		"mock-fallback": func(ProviderConfig) (Provider, error) { return fallback, nil },
+	}
+	r, err := NewRouter(routerTestConfig(), factories, WithCircuitBreaker(failures, cooldown))
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	r.circuits.now = clock.now
+	return r, clock
+}
+
+func TestCircuitBreaker_OpensFailsFastAndRecovers(t *testing.T) {
+	primaryCalls := 0
+	primaryDown := true
+	primary := &mockProvider{name: "primary", chatFn: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+		primaryCalls++
+		if primaryDown {
+			return nil, &APIError{Status: 503, Message: "unavailable"}
+		}
+		return &ChatResponse{ID: "primary", Model: req.Model, Done: true}, nil
+	}}
+	fallback := &mockProvider{name: "fallback", chatFn: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+		return &ChatResponse{ID: "fallback", Model: req.Model, Done: true}, nil
+	}}
+	r, clock := newBreakerRouter(t, primary, fallback, 2, time.Minute)
+	var codes []ErrorCode
+	r.SetFallbackHook(func(ev FallbackEvent) { codes = append(codes, ev.Code) })
+
+	ask := func() string {
+		t.Helper()
+		resp, err := r.ChatCompletionForRole(context.Background(), "leader", &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+		if err != nil {
+			t.Fatalf("ChatCompletionForRole: %v", err)
+		}
+		return resp.ID
+	}
+
+	// Two failures open the circuit; both requests still succeed via fallback.
+	ask()
+	ask()
+	if primaryCalls != 2 {
+		t.Fatalf("primary calls = %d, want 2", primaryCalls)
+	}
+
+	// Open: the primary is skipped entirely.
+	if got := ask(); got != "fallback" {
+		t.Errorf("served by %s, want fallback", got)
+	}
+	if primaryCalls != 2 {
+		t.Errorf("primary called while circuit open (calls = %d)", primaryCalls)
+	}
+	if codes[len(codes)-1] != ErrCircuitOpen {
+		t.Errorf("last fallback code = %s, want %s", codes[len(codes)-1], ErrCircuitOpen)
+	}
+
+	// After the cooldown a probe goes through; the primary has recovered.
+	primaryDown = false
+	clock.advance(time.Minute)
+	if got := ask(); got != "primary" {
+		t.Errorf("probe served by %s, want primary", got)
+	}
+	if got := ask(); got != "primary" || primaryCalls != 4 {
+		t.Errorf("circuit should be closed after a successful probe (served by %s, calls = %d)", got, primaryCalls)
+	}
+}
+
+func TestCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	primaryCalls := 0
+	primary := &mockProvider{name: "primary", chatFn: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		primaryCalls++
+		return nil, &APIError{Status: 500}
+	}}
+	r, clock := newBreakerRouter(t, primary, &mockProvider{name: "fallback"}, 1, time.Minute)
+	req := func() *ChatRequest {
+		return &ChatRequest{Model: "model-a", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	}
+
+	r.ChatCompletion(context.Background(), req()) // opens
+	_, err := r.ChatCompletion(context.Background(), req())
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || ClassifyError(err) != ErrCircuitOpen || !ClassifyError(err).Retryable() {
+		t.Fatalf("expected retryable CircuitOpenError, got %v", err)
+	}
+
+	clock.advance(time.Minute)
+	r.ChatCompletion(context.Background(), req()) // probe fails
+	if primaryCalls != 2 {
+		t.Fatalf("primary calls = %d, want 2 (initial + probe)", primaryCalls)
+	}
+	if _, err := r.ChatCompletion(context.Background(), req()); !errors.As(err, &openErr) {
+		t.Errorf("expected circuit reopened after failed probe, got %v", err)
+	}
+	if want := clock.t.Add(time.Minute); !openErr.Until.Equal(want) {
+		t.Errorf("reopened until %v, want %v", openErr.Until, want)
+	}
+}
+
+func TestCircuitBreaker_SingleProbeWhileHalfOpen(t *testing.T) {
+	cs := &circuits{threshold: 1, cooldown: time.Minute, state: make(map[circuitKey]*circuit)}
+	clock := &fakeClock{t: time.Now()}
+	cs.now = clock.now
+	k := circuitKey{model: "m"}
+
+	cs.record(context.Background(), k, &APIError{Status: 502})
+	clock.advance(time.Minute)
+	if err := cs.allow(k); err != nil {
+		t.Fatalf("first request after cooldown should probe, got %v", err)
+	}
+	if err := cs.allow(k); err == nil {
+		t.Error("second request should fail fast while the probe is in flight")
+	}
+	// A cancelled probe says nothing about health: release the slot only.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cs.record(ctx, k, context.Canceled)
+	if err := cs.allow(k); err != nil {
+		t.Errorf("probe slot should be free again, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_CountsOnlyConsecutiveTransientFailures(t *testing.T) {
+	cs := &circuits{threshold: 3, cooldown: time.Minute, state: make(map[circuitKey]*circuit)}
+	clock := &fakeClock{t: time.Now()}
+	cs.now = clock.now
+	k := circuitKey{model: "m"}
+	ctx := context.Background()
+
+	// An answered request (even a 400) resets the count.
+	cs.record(ctx, k, &APIError{Status: 503})
+	cs.record(ctx, k, &APIError{Status: 503})
+	cs.record(ctx, k, &APIError{Status: 400})
+	cs.record(ctx, k, &APIError{Status: 503})
+	if err := cs.allow(k); err != nil {
+		t.Errorf("400 should have reset the failure count, got %v", err)
+	}
+
+	// Auth errors don't count.
+	cs.record(ctx, k, &APIError{Status: 401})
+	cs.record(ctx, k, &APIError{Status: 401})
+	if err := cs.allow(k); err != nil {
+		t.Errorf("auth errors should not open the circuit, got %v", err)
+	}
+
+	// Failures further apart than the cooldown are not consecutive.
+	for i := 0; i < 3; i++ {
+		cs.record(ctx, k, &APIError{Status: 503})
+		clock.advance(2 * time.Minute)
+	}
+	if err := cs.allow(k); err != nil {
+		t.Errorf("spread-out failures should not open the circuit, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_DisabledByDefault(t *testing.T) {
+	calls := 0
+	primary := &mockProvider{name: "primary", chatFn: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		calls++
+		return nil, &APIError{Status: 503}
+	}}
+	r := newTestRouter(t, primary, &mockProvider{name: "fallback"})
+	for i := 0; i < 5; i++ {
+		r.ChatCompletion(context.Background(), &ChatRequest{Model: "model-a", Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	}
+	if calls != 5 {
+		t.Errorf("primary calls = %d, want 5 with no breaker", calls)
+	}
+}
@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"errors"
+	"io"
+)
+
+// CollectStream reads stream to the end and assembles the response a
+// non-streaming request would have returned, calling onContent with each
+// piece of content as it arrives (onContent may be nil). Tool calls
+// streamed in fragments are joined: a fragment with an ID or a name starts
+// a new call, and one without continues the previous call's arguments.
+// Usage comes from stream.Stats, so it is estimated when the provider
+// reports none. On error the text received so far is lost; stream.Stats
+// still has its usage.
+func CollectStream(stream ChatStream, onContent func(string)) (*ChatResponse, error) {
+	resp := &ChatResponse{Message: Message{Role: RoleAssistant}}
+	var content []byte
+	for {
+		chunk, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if resp.ID == "" {
+			resp.ID = chunk.ID
+		}
+		if resp.Model == "" {
+			resp.Model = chunk.Model
+		}
+		if chunk.Delta.Content != "" {
+			content = append(content, chunk.Delta.Content...)
+			if onContent != nil {
+				onContent(chunk.Delta.Content)
+			}
+		}
+		for _, tc := range chunk.Delta.ToolCalls {
+			calls := resp.Message.ToolCalls
+			if tc.ID == "" && tc.Function.Name == "" && len(calls) > 0 {
+				calls[len(calls)-1].Function.Arguments += tc.Function.Arguments
+				continue
+			}
+			resp.Message.ToolCalls = append(calls, tc)
+		}
+		if chunk.Done {
+			resp.Done = true
+		}
+	}
+	resp.Message.Content = string(content)
+	resp.Usage = stream.Stats().Usage
+	return resp, nil
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// chunkStream returns its chunks in order, then err (io.EOF if nil), and
+// counts them like an adapter would.
+type chunkStream struct {
+	chunks []*ChatStreamChunk
+	err    error
+	StreamCounter
+}
+
+func (s *chunkStream) Next() (*ChatStreamChunk, error) {
+	if len(s.chunks) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	c := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	s.Observe(c)
+	return c, nil
+}
+
+func (s *chunkStream) Close() error { return nil }
+
+func TestCollectStream(t *testing.T) {
+	s := &chunkStream{chunks: []*ChatStreamChunk{
+		{ID: "r1", Model: "m", Delta: MessageDelta{Content: "let me "}},
+		{Delta: MessageDelta{Content: "check"}},
+		{Delta: MessageDelta{ToolCalls: []ToolCall{{ID: "c1", Type: "function", Function: FunctionCall{Name: "read_file", Arguments: `{"pa`}}}}},
+		{Delta: MessageDelta{ToolCalls: []ToolCall{{Function: FunctionCall{Arguments: `th":"a.go"}`}}}}},
+		{Delta: MessageDelta{ToolCalls: []ToolCall{{ID: "c2", Type: "function", Function: FunctionCall{Name: "read_file", Arguments: `{"path":"b.go"}`}}}}},
+		{Done: true, Usage: &Usage{PromptTokens: 20, CompletionTokens: 8, TotalTokens: 28}},
+	}}
+	var seen []string
+	resp, err := CollectStream(s, func(text string) { seen = append(seen, text) })
+	if err != nil {
+		t.Fatalf("CollectStream: %v", err)
+	}
+	if resp.ID != "r1" || resp.Model != "m" || !resp.Done || resp.Message.Role != RoleAssistant {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.Message.Content != "let me check" || strings.Join(seen, "|") != "let me |check" {
+		t.Errorf("content = %q, seen %q", resp.Message.Content, seen)
+	}
+	calls := resp.Message.ToolCalls
+	if len(calls) != 2 || calls[0].Function.Arguments != `{"path":"a.go"}` || calls[1].ID != "c2" {
+		t.Errorf("tool calls = %+v, want the fragments joined into 2 calls", calls)
+	}
+	if resp.Usage.TotalTokens != 28 || resp.Usage.Estimated {
+		t.Errorf("usage = %+v, want the reported usage", resp.Usage)
+	}
+}
+
+func TestCollectStream_Error(t *testing.T) {
+	boom := errors.New("connection reset")
+	s := &chunkStream{chunks: []*ChatStreamChunk{{Delta: MessageDelta{Content: "partial"}}}, err: boom}
+	if _, err := CollectStream(s, nil); !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+	if st := s.Stats(); st.Chunks != 1 || st.Complete {
+		t.Errorf("stats = %+v, want the partial stream", st)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -27,22 +30,87 @@ type Config struct {
 	// Specialists defines domain-specific workers with dedicated models.
 	// The mayor assigns subtasks to specialists based on their descriptions.
 	Specialists map[string]SpecialistConfig `yaml:"specialists,omitempty"`
+
+	// Prompts overrides built-in prompt templates, keyed by role or phase
+	// name (the Prompt* constants). Keys left out keep their defaults.
+	// Overrides may use PromptData fields as text/template actions.
+	Prompts map[string]string `yaml:"prompts,omitempty"`
+
+	// Includes lists config files merged under this one (see ReadConfig).
+	// Only LoadConfig and ReadConfig resolve them; ParseConfig rejects
+	// them because it has no file to resolve relative paths against.
+	Includes []string `yaml:"includes,omitempty"`
+
+	// Commands enables the run_command tool for workers. It is off unless
+	// it allows at least one command.
+	Commands CommandsConfig `yaml:"commands,omitempty"`
+
+	// envKeys marks providers whose api_key ParseConfig expanded from the
+	// environment, so Validate leaves checking the value to runtime.
+	envKeys map[string]bool
 }
 
+// Prompt override keys for Config.Prompts.
+const (
+	PromptMayor       = "mayor"        // Mayor decompose system prompt
+	PromptSynthesize  = "synthesize"   // Mayor synthesis system prompt
+	PromptWorker      = "worker"       // worker system prompt when output goes to stdout
+	PromptWorkerFiles = "worker_files" // worker system prompt with --output-dir (===FILE:=== blocks)
+	PromptReviewer    = "reviewer"     // reviewer system prompt for Review and Validate
+	PromptReviewScore = "review_score" // reviewer system prompt for Phase 2.5 scoring
+	PromptTester      = "tester"       // tester polish system prompt
+)
+
+// promptKeys lists the valid Config.Prompts keys.
+var promptKeys = []string{PromptMayor, PromptSynthesize, PromptWorker, PromptWorkerFiles, PromptReviewer, PromptReviewScore, PromptTester}
+
 // AuthType constants for provider authentication methods.
 const (
 	AuthNone   = "none"   // No authentication (default for local Ollama)
 	AuthBearer = "bearer" // Bearer token (default when api_key is set)
 	AuthBasic  = "basic"  // HTTP Basic Auth (for reverse-proxied Ollama)
+
+	// AuthAWSSigV4 signs requests with AWS SigV4 using credentials from the
+	// AWS_* environment variables (Anthropic via AWS Bedrock).
+	AuthAWSSigV4 = "awssigv4"
 )
 
 // ProviderConfig defines connection details for a single provider.
 type ProviderConfig struct {
-	Type     string `yaml:"type"`               // "openai", "anthropic", "ollama"
+	Type     string `yaml:"type"`               // "openai", "openai-compatible", "anthropic", "ollama", "gemini", "mistral"
 	BaseURL  string `yaml:"base_url"`           // API base URL
 	APIKey   string `yaml:"api_key,omitempty"`  // API key (or env var reference)
 	AuthType string `yaml:"auth_type,omitempty"` // "bearer" (default), "basic", "none"
 	Org      string `yaml:"org,omitempty"`      // Organization ID (OpenAI)
+
+	// KeepAlive sets how long Ollama keeps models loaded between requests,
+	// e.g. "30m", or "-1" to keep them loaded indefinitely. Ollama only.
+	KeepAlive string `yaml:"keep_alive,omitempty"`
+
+	// Headers are extra HTTP headers sent with every request. They override
+	// the adapter's own headers of the same name.
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// ParseKeepAlive parses a keep_alive value: a Go duration ("30m", "1h"),
+// a whole number of seconds, or a negative number to keep models loaded
+// indefinitely (returned as -1).
+func ParseKeepAlive(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 {
+			return -1, nil
+		}
+		return time.Duration(n) * time.Second, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid keep_alive %q (want a duration like 30m, seconds, or -1)", s)
+	}
+	if d < 0 {
+		return -1, nil
+	}
+	return d, nil
 }
 
 // ModelConfig maps a model alias to a specific provider and model name.
@@ -53,9 +121,95 @@ type ModelConfig struct {
 
 // RoleConfig defines which model(s) a given agent role should use.
 type RoleConfig struct {
-	Model     string   `yaml:"model"`               // primary model alias
-	Pool      []string `yaml:"pool,omitempty"`       // parallel worker pool model aliases
-	Fallbacks []string `yaml:"fallbacks,omitempty"`  // fallback model aliases in order
+	Model     string          `yaml:"model"`               // primary model alias
+	Pool      []PoolEntry     `yaml:"pool,omitempty"`      // parallel worker pool model aliases
+	Fallbacks []FallbackEntry `yaml:"fallbacks,omitempty"` // fallback model aliases in order
+
+	// PoolTags pins subtasks the Mayor labels [tag: name] to a pool member,
+	// e.g. {frontend: qwen-small, backend: qwen-big}. Untagged subtasks and
+	// tags not listed here go through the balancer.
+	PoolTags map[string]string `yaml:"pool_tags,omitempty"`
+}
+
+// PoolEntry is one member of a role's worker pool. In YAML it is either a
+// bare model alias or a mapping with an explicit weight:
+//
+//	pool:
+//	  - qwen-local
+//	  - model: qwen-gpu
+//	    weight: 3
+type PoolEntry struct {
+	Model  string `yaml:"model"`            // model alias
+	Weight int    `yaml:"weight,omitempty"` // relative share of work (default 1)
+
+	weightSet bool // the YAML mapping had a weight key, so 0 was written
+}
+
+// UnmarshalYAML accepts either a scalar alias or a {model, weight} mapping.
+func (e *PoolEntry) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		e.Model = value.Value
+		return nil
+	}
+	type plain PoolEntry
+	if err := value.Decode((*plain)(e)); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		if value.Content[i].Value == "weight" {
+			e.weightSet = true
+		}
+	}
+	return nil
+}
+
+// EffectiveWeight returns the entry's weight, defaulting to 1 when unset.
+// Validate rejects an explicit weight that is not positive.
+func (e PoolEntry) EffectiveWeight() int {
+	if e.Weight == 0 {
+		return 1
+	}
+	return e.Weight
+}
+
+// FallbackEntry is one step of a role's fallback chain. In YAML it is
+// either a bare model alias, tried after any retryable failure, or a
+// mapping that limits it to some kinds of failure:
+//
+//	fallbacks:
+//	  - model: qwen-local       # rate limited: wait it out locally
+//	    on: [rate_limit]
+//	  - model: gpt-4o           # the provider is down: another cloud
+//	    on: [server_error, timeout, network]
+//	  - llama-local             # anything else retryable
+type FallbackEntry struct {
+	Model string      `yaml:"model"`        // model alias
+	On    []ErrorCode `yaml:"on,omitempty"` // failure kinds it handles (default: all retryable)
+}
+
+// UnmarshalYAML accepts either a scalar alias or a {model, on} mapping.
+func (e *FallbackEntry) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		e.Model = value.Value
+		return nil
+	}
+	type plain FallbackEntry
+	return value.Decode((*plain)(e))
+}
+
+// Handles reports whether the entry should be tried after a failure
+// classified as code.
+func (e FallbackEntry) Handles(code ErrorCode) bool {
+	return len(e.On) == 0 || slices.Contains(e.On, code)
+}
+
+// Fallbacks returns plain fallback entries for aliases.
+func Fallbacks(aliases ...string) []FallbackEntry {
+	entries := make([]FallbackEntry, len(aliases))
+	for i, alias := range aliases {
+		entries[i] = FallbackEntry{Model: alias}
+	}
+	return entries
 }
 
 // DefaultsConfig provides fallback settings.
@@ -65,6 +219,20 @@ type DefaultsConfig struct {
 	MaxTokens   int      `yaml:"max_tokens,omitempty"`   // default max tokens
 	Temperature float64  `yaml:"temperature,omitempty"`  // default temperature
 	LogDir      string   `yaml:"log_dir,omitempty"`      // directory for run logs (default: ~/Documents)
+	MaxSubtasks int      `yaml:"max_subtasks,omitempty"` // default for et run --max-subtasks, 1-50 (0 = 10)
+
+	// SystemPrefix is a preamble the router puts at the start of the system
+	// prompt of every request, for every role, e.g. "Never output secrets."
+	SystemPrefix string `yaml:"system_prefix,omitempty"`
+}
+
+// CommandsConfig lists the commands workers may run in the output
+// directory. An entry allows a program and, when it has more words, only
+// invocations starting with them: "gofmt" allows any gofmt command line,
+// "go fmt" allows "go fmt ./..." but not "go run".
+type CommandsConfig struct {
+	Allow   []string `yaml:"allow,omitempty"`   // allowed command prefixes
+	Timeout string   `yaml:"timeout,omitempty"` // per command, e.g. "30s" (default 30s)
 }
 
 // SpecialistConfig defines a domain-specific worker that uses a particular model.
@@ -76,42 +244,150 @@ type SpecialistConfig struct {
 	Fallbacks   []string `yaml:"fallbacks,omitempty"`   // fallback chain
 }
 
-// LoadConfig reads and parses an electrictown YAML config file.
+// LoadConfig reads and parses an electrictown YAML config file, merging
+// in the files it includes (see ReadConfig).
 func LoadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	data, err := ReadConfig(path)
 	if err != nil {
-		return nil, fmt.Errorf("reading config %s: %w", path, err)
+		return nil, err
 	}
 	return ParseConfig(data)
 }
 
 // ParseConfig parses YAML bytes into a Config.
+//
+// Provider string fields (base_url, api_key, auth_type, org, and header
+// values) support environment variable references of the form $VAR or
+// ${VAR}; a literal "$" is written as "$$". base_url, auth_type, org, and
+// headers are expanded before validation. api_key is expanded after validation so that basic-auth keys
+// held in the environment are not checked for the user:password format.
 func ParseConfig(data []byte) (*Config, error) {
 	var cfg Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
+	if len(cfg.Includes) > 0 {
+		return nil, fmt.Errorf("config includes are resolved when loading from a file; use LoadConfig or ReadConfig")
+	}
+	for name, p := range cfg.Providers {
+		p.BaseURL, _ = expandEnv(p.BaseURL)
+		p.AuthType, _ = expandEnv(p.AuthType)
+		p.Org, _ = expandEnv(p.Org)
+		for k, v := range p.Headers {
+			p.Headers[k], _ = expandEnv(v)
+		}
+		cfg.Providers[name] = p
+	}
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 	// Resolve environment variable references in API keys.
 	for name, p := range cfg.Providers {
-		if len(p.APIKey) > 0 && p.APIKey[0] == '$' {
-			varName := p.APIKey[1:]
-			p.APIKey = os.Getenv(varName)
-			// Fail early for bearer auth with an unset env var — the request will
-			// always be rejected without it. Basic auth defers validation to runtime
-			// (colon format can't be checked until the value is actually resolved).
-			if p.APIKey == "" && p.AuthType == AuthBearer {
-				return nil, fmt.Errorf("provider %q requires an API key but $%s is not set or is empty", name, varName)
-			}
-			cfg.Providers[name] = p
+		if !strings.Contains(p.APIKey, "$") {
+			continue
+		}
+		var unset []string
+		p.APIKey, unset = expandEnv(p.APIKey)
+		if cfg.envKeys == nil {
+			cfg.envKeys = make(map[string]bool)
 		}
+		cfg.envKeys[name] = true
+		// Fail early for bearer auth with an unset env var — the request will
+		// always be rejected without it. Basic auth defers validation to runtime
+		// (colon format can't be checked until the value is actually resolved).
+		if p.APIKey == "" && p.AuthType == AuthBearer && len(unset) > 0 {
+			return nil, fmt.Errorf("provider %q requires an API key but $%s is not set or is empty", name, unset[0])
+		}
+		cfg.Providers[name] = p
 	}
 	return &cfg, nil
 }
 
-// Validate checks the config for internal consistency.
+// MissingAuthEnv reports, per provider, the environment variables referenced
+// by api_key that are unset or empty, for providers that authenticate with
+// bearer or basic auth. The config is parsed but not validated, so this works
+// on configs that ParseConfig would reject because of the missing variables.
+func MissingAuthEnv(data []byte) (map[string][]string, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	missing := make(map[string][]string)
+	for name, p := range cfg.Providers {
+		authType, _ := expandEnv(p.AuthType)
+		if authType == AuthNone || (authType == "" && p.APIKey == "") {
+			continue
+		}
+		if _, unset := expandEnv(p.APIKey); len(unset) > 0 {
+			missing[name] = unset
+		}
+	}
+	return missing, nil
+}
+
+// expandEnv replaces $VAR and ${VAR} references in s with values from the
+// environment. "$$" produces a literal "$". A "$" not followed by a valid
+// variable name is kept as-is. It returns the expanded string and the names
+// of referenced variables that are unset or empty.
+func expandEnv(s string) (string, []string) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var sb strings.Builder
+	var unset []string
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i == len(s)-1 {
+			sb.WriteByte(s[i])
+			continue
+		}
+		next := s[i+1]
+		switch {
+		case next == '$':
+			sb.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				sb.WriteByte(s[i])
+				continue
+			}
+			name := s[i+2 : i+2+end]
+			val := os.Getenv(name)
+			if val == "" {
+				unset = append(unset, name)
+			}
+			sb.WriteString(val)
+			i += end + 2
+		case isEnvNameStart(next):
+			j := i + 1
+			for j < len(s) && isEnvNameChar(s[j]) {
+				j++
+			}
+			name := s[i+1 : j]
+			val := os.Getenv(name)
+			if val == "" {
+				unset = append(unset, name)
+			}
+			sb.WriteString(val)
+			i = j - 1
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), unset
+}
+
+func isEnvNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isEnvNameChar(c byte) bool {
+	return isEnvNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// Validate checks the config for internal consistency. ParseConfig and
+// NewRouter call it; a program building a Config in code can call it
+// directly to report mistakes before constructing a router.
 func (c *Config) Validate() error {
 	if len(c.Providers) == 0 {
 		return fmt.Errorf("config: no providers defined")
@@ -131,13 +407,36 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("config: role %q references unknown model alias %q", role, rc.Model)
 		}
 		for _, fb := range rc.Fallbacks {
-			if _, ok := c.Models[fb]; !ok {
-				return fmt.Errorf("config: role %q fallback references unknown model alias %q", role, fb)
+			if _, ok := c.Models[fb.Model]; !ok {
+				return fmt.Errorf("config: role %q fallback references unknown model alias %q", role, fb.Model)
+			}
+			for _, code := range fb.On {
+				if !code.Retryable() {
+					return fmt.Errorf("config: role %q fallback %q: on: %q is not a failure that falls back (must be rate_limit, context_window, server_error, timeout, network, or circuit_open)", role, fb.Model, code)
+				}
 			}
 		}
-		for _, pa := range rc.Pool {
-			if _, ok := c.Models[pa]; !ok {
-				return fmt.Errorf("config: role %q pool references unknown model alias %q", role, pa)
+		aliases := make([]string, len(rc.Pool))
+		for i, pe := range rc.Pool {
+			aliases[i] = pe.Model
+		}
+		if err := c.validatePool("role", role, aliases); err != nil {
+			return err
+		}
+		for _, pe := range rc.Pool {
+			if _, ok := c.Models[pe.Model]; !ok {
+				return fmt.Errorf("config: role %q pool references unknown model alias %q", role, pe.Model)
+			}
+			if pe.Weight < 0 {
+				return fmt.Errorf("config: role %q pool member %q has negative weight %d", role, pe.Model, pe.Weight)
+			}
+			if pe.weightSet && pe.Weight == 0 {
+				return fmt.Errorf("config: role %q pool member %q has weight 0; weights must be positive (remove the member to leave it out)", role, pe.Model)
+			}
+		}
+		for tag, alias := range rc.PoolTags {
+			if !poolHas(rc.Pool, alias) {
+				return fmt.Errorf("config: role %q pool_tags maps %q to %q, which is not in the role's pool", role, tag, alias)
 			}
 		}
 	}
@@ -161,16 +460,34 @@ func (c *Config) Validate() error {
 		switch pc.AuthType {
 		case "", AuthBearer, AuthBasic, AuthNone:
 			// valid
+		case AuthAWSSigV4:
+			if pc.Type != "anthropic" {
+				return fmt.Errorf("config: provider %q: auth_type %q is only supported for anthropic providers", name, pc.AuthType)
+			}
 		default:
-			return fmt.Errorf("config: provider %q has invalid auth_type %q (must be bearer, basic, or none)", name, pc.AuthType)
+			return fmt.Errorf("config: provider %q has invalid auth_type %q (must be bearer, basic, none, or awssigv4)", name, pc.AuthType)
 		}
-		if pc.AuthType == AuthBasic && pc.APIKey != "" && len(pc.APIKey) > 0 && pc.APIKey[0] != '$' {
-			if !strings.Contains(pc.APIKey, ":") {
-				return fmt.Errorf("config: provider %q auth_type is basic but api_key does not contain ':' (expected user:password)", name)
+		// A key ParseConfig took from the environment is checked at runtime.
+		if !c.envKeys[name] {
+			if pc.AuthType == AuthBasic && pc.APIKey != "" && len(pc.APIKey) > 0 && pc.APIKey[0] != '$' {
+				if !strings.Contains(pc.APIKey, ":") {
+					return fmt.Errorf("config: provider %q auth_type is basic but api_key does not contain ':' (expected user:password)", name)
+				}
+			}
+			if (pc.AuthType == AuthBearer || pc.AuthType == AuthBasic) && pc.APIKey == "" {
+				return fmt.Errorf("config: provider %q auth_type is %q but no api_key is set", name, pc.AuthType)
 			}
 		}
-		if (pc.AuthType == AuthBearer || pc.AuthType == AuthBasic) && pc.APIKey == "" {
-			return fmt.Errorf("config: provider %q auth_type is %q but no api_key is set", name, pc.AuthType)
+		if pc.Type == "openai-compatible" && pc.BaseURL == "" {
+			return fmt.Errorf("config: openai-compatible provider %q requires base_url", name)
+		}
+		if pc.KeepAlive != "" {
+			if pc.Type != "ollama" {
+				return fmt.Errorf("config: provider %q sets keep_alive, which only applies to ollama providers", name)
+			}
+			if _, err := ParseKeepAlive(pc.KeepAlive); err != nil {
+				return fmt.Errorf("config: provider %q: %w", name, err)
+			}
 		}
 	}
 	// Validate specialist references.
@@ -182,6 +499,9 @@ func (c *Config) Validate() error {
 		if _, ok := c.Models[sc.Model]; !ok {
 			return fmt.Errorf("config: specialist %q references unknown model alias %q", name, sc.Model)
 		}
+		if err := c.validatePool("specialist", name, sc.Pool); err != nil {
+			return err
+		}
 		for _, pa := range sc.Pool {
 			if _, ok := c.Models[pa]; !ok {
 				return fmt.Errorf("config: specialist %q pool references unknown model alias %q", name, pa)
@@ -193,6 +513,29 @@ func (c *Config) Validate() error {
 			}
 		}
 	}
+	// Validate prompt overrides.
+	for key, text := range c.Prompts {
+		if !slices.Contains(promptKeys, key) {
+			return fmt.Errorf("config: unknown prompt %q (must be one of %s)", key, strings.Join(promptKeys, ", "))
+		}
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("config: prompt %q is empty", key)
+		}
+		if _, err := RenderPrompt(text, samplePromptData); err != nil {
+			return fmt.Errorf("config: prompt %q: %w", key, err)
+		}
+	}
+	// Validate the command allowlist.
+	for i, entry := range c.Commands.Allow {
+		if strings.TrimSpace(entry) == "" {
+			return fmt.Errorf("config: commands.allow entry %d is empty", i)
+		}
+	}
+	if c.Commands.Timeout != "" {
+		if d, err := time.ParseDuration(c.Commands.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("config: commands.timeout %q is not a positive duration like 30s", c.Commands.Timeout)
+		}
+	}
 	// Detect pointless fallbacks (same provider+model as primary).
 	for role, rc := range c.Roles {
 		primary, ok := c.Models[rc.Model]
@@ -200,18 +543,48 @@ func (c *Config) Validate() error {
 			continue // already caught above
 		}
 		for _, fb := range rc.Fallbacks {
-			fbModel, ok := c.Models[fb]
+			fbModel, ok := c.Models[fb.Model]
 			if !ok {
 				continue // already caught above
 			}
 			if primary.Provider == fbModel.Provider && primary.Model == fbModel.Model {
-				return fmt.Errorf("config: role %q fallback %q resolves to same provider+model as primary %q", role, fb, rc.Model)
+				return fmt.Errorf("config: role %q fallback %q resolves to same provider+model as primary %q", role, fb.Model, rc.Model)
 			}
 		}
 	}
 	return nil
 }
 
+// validatePool checks the pool of the role or specialist name (kind says
+// which): every member names a model alias, once. The role's own model need
+// not be a member; it is the fallback when the pool is empty. Unknown
+// aliases are reported by the caller.
+func (c *Config) validatePool(kind, name string, pool []string) error {
+	if len(pool) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(pool))
+	for i, alias := range pool {
+		if strings.TrimSpace(alias) == "" {
+			return fmt.Errorf("config: %s %q pool member %d has no model alias", kind, name, i+1)
+		}
+		if seen[alias] {
+			return fmt.Errorf("config: %s %q pool lists %q more than once", kind, name, alias)
+		}
+		seen[alias] = true
+	}
+	return nil
+}
+
+// Prompt returns the configured override for the prompt key, or "" when the
+// built-in default applies. It is safe to call on a nil Config.
+func (c *Config) Prompt(key string) string {
+	if c == nil {
+		return ""
+	}
+	return c.Prompts[key]
+}
+
 // ResolveLogDir returns the configured log directory, expanding ~ and falling
 // back to ~/Documents when no log_dir is set in the config.
 func (c *Config) ResolveLogDir() (string, error) {
@@ -236,14 +609,35 @@ func (c *Config) ResolveLogDir() (string, error) {
 // ResolveRole returns the provider config and model name for a given role.
 // Falls back to defaults if the role is not explicitly configured.
 func (c *Config) ResolveRole(role string) (ProviderConfig, string, error) {
-	rc, ok := c.Roles[role]
+	alias, err := c.roleModelAlias(role)
+	if err != nil {
+		return ProviderConfig{}, "", err
+	}
+	return c.ResolveModel(alias)
+}
+
+// roleModelAlias returns the primary model alias for a role, falling back to
+// the default model when the role is not explicitly configured.
+func (c *Config) roleModelAlias(role string) (string, error) {
+	if rc, ok := c.Roles[role]; ok {
+		return rc.Model, nil
+	}
+	if c.Defaults.Model == "" {
+		return "", fmt.Errorf("config: role %q not configured and no default set", role)
+	}
+	return c.Defaults.Model, nil
+}
+
+// modelRef resolves a model alias to a ModelRef naming its provider entry.
+func (c *Config) modelRef(alias string) (ModelRef, error) {
+	mc, ok := c.Models[alias]
 	if !ok {
-		if c.Defaults.Model == "" {
-			return ProviderConfig{}, "", fmt.Errorf("config: role %q not configured and no default set", role)
-		}
-		rc = RoleConfig{Model: c.Defaults.Model, Fallbacks: c.Defaults.Fallbacks}
+		return ModelRef{}, fmt.Errorf("config: unknown model alias %q", alias)
+	}
+	if _, ok := c.Providers[mc.Provider]; !ok {
+		return ModelRef{}, fmt.Errorf("config: model %q references unknown provider %q", alias, mc.Provider)
 	}
-	return c.ResolveModel(rc.Model)
+	return ModelRef{Alias: alias, Provider: mc.Provider, Model: mc.Model}, nil
 }
 
 // ResolveModel returns the provider config and actual model name for a model alias.
@@ -259,20 +653,81 @@ func (c *Config) ResolveModel(alias string) (ProviderConfig, string, error) {
 	return pc, mc.Model, nil
 }
 
-// FallbacksForRole returns the ordered fallback model aliases for a role.
+// FallbacksForRole returns the ordered fallback model aliases for a role,
+// whatever failures they are limited to.
 func (c *Config) FallbacksForRole(role string) []string {
+	entries := c.fallbackEntries(role)
+	if entries == nil {
+		return nil
+	}
+	aliases := make([]string, len(entries))
+	for i, fb := range entries {
+		aliases[i] = fb.Model
+	}
+	return aliases
+}
+
+// fallbackEntries returns a role's fallback chain with its conditions; the
+// defaults' fallbacks apply to any failure.
+func (c *Config) fallbackEntries(role string) []FallbackEntry {
 	if rc, ok := c.Roles[role]; ok {
 		return rc.Fallbacks
 	}
-	return c.Defaults.Fallbacks
+	if len(c.Defaults.Fallbacks) == 0 {
+		return nil
+	}
+	return Fallbacks(c.Defaults.Fallbacks...)
 }
 
 // PoolForRole returns the pool model aliases for a role, or nil if no pool is configured.
 func (c *Config) PoolForRole(role string) []string {
-	if rc, ok := c.Roles[role]; ok {
-		return rc.Pool
+	rc, ok := c.Roles[role]
+	if !ok || len(rc.Pool) == 0 {
+		return nil
 	}
-	return nil
+	aliases := make([]string, len(rc.Pool))
+	for i, pe := range rc.Pool {
+		aliases[i] = pe.Model
+	}
+	return aliases
+}
+
+// PoolWeightsForRole returns the pool members of a role with their effective
+// weights, or nil if no pool is configured.
+func (c *Config) PoolWeightsForRole(role string) []WeightedOption {
+	rc, ok := c.Roles[role]
+	if !ok || len(rc.Pool) == 0 {
+		return nil
+	}
+	opts := make([]WeightedOption, len(rc.Pool))
+	for i, pe := range rc.Pool {
+		opts[i] = WeightedOption{Value: pe.Model, Weight: pe.EffectiveWeight()}
+	}
+	return opts
+}
+
+// poolHas reports whether alias is a member of pool.
+func poolHas(pool []PoolEntry, alias string) bool {
+	for _, pe := range pool {
+		if pe.Model == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// PoolTagsForRole returns the tag → pool alias routes for a role, with tags
+// lower-cased, or nil if none are configured.
+func (c *Config) PoolTagsForRole(role string) map[string]string {
+	rc, ok := c.Roles[role]
+	if !ok || len(rc.PoolTags) == 0 {
+		return nil
+	}
+	routes := make(map[string]string, len(rc.PoolTags))
+	for tag, alias := range rc.PoolTags {
+		routes[strings.ToLower(tag)] = alias
+	}
+	return routes
 }
 
 // SpecialistNames returns a sorted list of configured specialist names.
@@ -2,7 +2,9 @@ package provider
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 )
 
 var testConfigYAML = []byte(`
@@ -90,6 +92,84 @@ func TestFallbacks(t *testing.T) {
 	}
 }
 
+func TestFallbacks_Conditional(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+providers:
+  ollama:
+    type: ollama
+    base_url: http://localhost:11434
+  cloud:
+    type: openai
+    base_url: https://api.openai.com
+    api_key: sk-test
+models:
+  big:
+    provider: cloud
+    model: gpt-4o
+  other:
+    provider: cloud
+    model: gpt-4o-mini
+  local:
+    provider: ollama
+    model: qwen3:8b
+roles:
+  mayor:
+    model: big
+    fallbacks:
+      - model: local
+        on: [rate_limit]
+      - model: other
+        on: [server_error, timeout]
+      - local
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	fbs := cfg.Roles["mayor"].Fallbacks
+	if len(fbs) != 3 || fbs[0].Model != "local" || fbs[1].Model != "other" || fbs[2].Model != "local" {
+		t.Fatalf("fallbacks = %+v", fbs)
+	}
+	if !fbs[0].Handles(ErrRateLimit) || fbs[0].Handles(ErrServerError) {
+		t.Errorf("first fallback = %+v, want rate limits only", fbs[0])
+	}
+	if !fbs[1].Handles(ErrTimeout) || fbs[1].Handles(ErrRateLimit) {
+		t.Errorf("second fallback = %+v, want server errors and timeouts", fbs[1])
+	}
+	if !fbs[2].Handles(ErrNetwork) {
+		t.Error("plain fallback should handle any failure")
+	}
+	if got := strings.Join(cfg.FallbacksForRole("mayor"), ","); got != "local,other,local" {
+		t.Errorf("FallbacksForRole = %s", got)
+	}
+}
+
+func TestValidation_FallbackCondition(t *testing.T) {
+	for _, on := range []string{"auth", "unknown", "rate-limit"} {
+		_, err := ParseConfig([]byte(`
+providers:
+  ollama:
+    type: ollama
+    base_url: http://localhost:11434
+models:
+  a:
+    provider: ollama
+    model: qwen3:8b
+  b:
+    provider: ollama
+    model: llama3
+roles:
+  mayor:
+    model: a
+    fallbacks:
+      - model: b
+        on: [` + on + `]
+`))
+		if err == nil || !strings.Contains(err.Error(), `on: "`+on+`" is not a failure that falls back`) {
+			t.Errorf("on: [%s]: err = %v, want it rejected", on, err)
+		}
+	}
+}
+
 func TestValidation_UnknownProvider(t *testing.T) {
 	bad := []byte(`
 providers:
@@ -453,6 +533,168 @@ defaults:
 	}
 }
 
+func TestPoolWeightsForRole(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+providers:
+  gpu:
+    type: ollama
+    base_url: http://gpu:11434
+  laptop:
+    type: ollama
+    base_url: http://laptop:11434
+models:
+  qwen-gpu:
+    provider: gpu
+    model: qwen3-coder:32b
+  qwen-laptop:
+    provider: laptop
+    model: qwen3-coder:8b
+roles:
+  polecat:
+    model: qwen-gpu
+    pool:
+      - model: qwen-gpu
+        weight: 4
+      - qwen-laptop
+defaults:
+  model: qwen-gpu
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	if pool := cfg.PoolForRole("polecat"); len(pool) != 2 || pool[0] != "qwen-gpu" || pool[1] != "qwen-laptop" {
+		t.Errorf("unexpected pool aliases: %v", pool)
+	}
+	want := []WeightedOption{{Value: "qwen-gpu", Weight: 4}, {Value: "qwen-laptop", Weight: 1}}
+	got := cfg.PoolWeightsForRole("polecat")
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("PoolWeightsForRole = %+v, want %+v", got, want)
+	}
+	if w := cfg.PoolWeightsForRole("mayor"); w != nil {
+		t.Errorf("expected nil weights for role without pool, got %v", w)
+	}
+}
+
+func TestValidation_PoolNegativeWeight(t *testing.T) {
+	bad := []byte(`
+providers:
+  ollama:
+    type: ollama
+    base_url: http://localhost:11434
+models:
+  qwen:
+    provider: ollama
+    model: qwen3
+roles:
+  polecat:
+    model: qwen
+    pool:
+      - model: qwen
+        weight: -2
+`)
+	_, err := ParseConfig(bad)
+	if err == nil {
+		t.Fatal("expected error for negative pool weight")
+	}
+	if !strings.Contains(err.Error(), "negative weight") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidation_PoolZeroWeight(t *testing.T) {
+	cfg := func(weight string) []byte {
+		return []byte(`
+providers:
+  ollama:
+    type: ollama
+    base_url: http://localhost:11434
+models:
+  qwen:
+    provider: ollama
+    model: qwen3
+roles:
+  polecat:
+    model: qwen
+    pool:
+      - model: qwen
+` + weight)
+	}
+	_, err := ParseConfig(cfg("        weight: 0\n"))
+	if err == nil || !strings.Contains(err.Error(), "weight 0") {
+		t.Errorf("explicit weight 0: err = %v, want it rejected", err)
+	}
+	parsed, err := ParseConfig(cfg(""))
+	if err != nil {
+		t.Fatalf("no weight: %v", err)
+	}
+	if w := parsed.PoolWeightsForRole("polecat"); len(w) != 1 || w[0].Weight != 1 {
+		t.Errorf("unset weight = %+v, want the default 1", w)
+	}
+}
+
+func TestPoolTagsForRole(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+providers:
+  ollama:
+    type: ollama
+    base_url: http://localhost:11434
+models:
+  big:
+    provider: ollama
+    model: qwen3-coder:32b
+  small:
+    provider: ollama
+    model: qwen3:8b
+roles:
+  polecat:
+    model: big
+    pool: [big, small]
+    pool_tags:
+      Frontend: small
+      backend: big
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	routes := cfg.PoolTagsForRole("polecat")
+	if routes["frontend"] != "small" || routes["backend"] != "big" {
+		t.Errorf("PoolTagsForRole = %v, want lower-cased tag routes", routes)
+	}
+	if cfg.PoolTagsForRole("mayor") != nil {
+		t.Error("expected nil routes for a role without pool_tags")
+	}
+}
+
+func TestValidation_PoolTagNotInPool(t *testing.T) {
+	bad := []byte(`
+providers:
+  ollama:
+    type: ollama
+    base_url: http://localhost:11434
+models:
+  big:
+    provider: ollama
+    model: qwen3-coder:32b
+  small:
+    provider: ollama
+    model: qwen3:8b
+roles:
+  polecat:
+    model: big
+    pool: [big]
+    pool_tags:
+      frontend: small
+`)
+	_, err := ParseConfig(bad)
+	if err == nil {
+		t.Fatal("expected error for pool_tags alias outside the pool")
+	}
+	if !strings.Contains(err.Error(), "not in the role's pool") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestValidation_PoolUnknownAlias(t *testing.T) {
 	bad := []byte(`
 providers:
@@ -476,6 +718,52 @@ defaults:
 	}
 }
 
+func TestValidation_PoolStanzas(t *testing.T) {
+	const base = `
+providers:
+  ollama:
+    type: ollama
+    base_url: http://localhost:11434
+models:
+  big:
+    provider: ollama
+    model: qwen3-coder:32b
+  small:
+    provider: ollama
+    model: qwen3:8b
+`
+	tests := []struct {
+		name    string
+		stanza  string
+		wantErr string // "" = valid
+	}{
+		{"model in pool", "roles:\n  polecat:\n    model: big\n    pool: [big, small]\n", ""},
+		{"weighted members", "roles:\n  polecat:\n    model: small\n    pool:\n      - model: big\n        weight: 2\n      - small\n", ""},
+		{"no pool", "roles:\n  polecat:\n    model: small\n", ""},
+		{"unknown alias", "roles:\n  polecat:\n    model: big\n    pool: [big, huge]\n", `unknown model alias "huge"`},
+		{"empty member", "roles:\n  polecat:\n    model: big\n    pool: [big, \"\"]\n", `role "polecat" pool member 2 has no model alias`},
+		{"empty mapping member", "roles:\n  polecat:\n    model: big\n    pool:\n      - big\n      - weight: 2\n", "pool member 2 has no model alias"},
+		{"duplicate", "roles:\n  polecat:\n    model: big\n    pool: [big, small, big]\n", `role "polecat" pool lists "big" more than once`},
+		{"duplicate across forms", "roles:\n  polecat:\n    model: big\n    pool:\n      - big\n      - model: big\n        weight: 3\n", `lists "big" more than once`},
+		{"model outside pool", "roles:\n  polecat:\n    model: big\n    pool: [small]\n", ""},
+		{"specialist duplicate", "roles:\n  polecat:\n    model: big\nspecialists:\n  frontend:\n    model: small\n    pool: [small, small]\n", `specialist "frontend" pool lists "small" more than once`},
+		{"specialist model outside pool", "roles:\n  polecat:\n    model: big\nspecialists:\n  frontend:\n    model: small\n    pool: [big]\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(base + tt.stanza))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("expected error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestSpecialistConfig_Valid(t *testing.T) {
 	cfg := []byte(`
 providers:
@@ -635,3 +923,330 @@ func TestResolveRole_Default(t *testing.T) {
 		t.Errorf("expected nil fallbacks from defaults (none configured), got %v", fbs)
 	}
 }
+
+func TestParseConfig_EnvExpansion(t *testing.T) {
+	t.Setenv("ET_TEST_HOST", "gpu01")
+	t.Setenv("ET_TEST_PORT", "11434")
+	t.Setenv("ET_TEST_KEY", "secret")
+	cfg, err := ParseConfig([]byte(`
+providers:
+  remote:
+    type: ollama
+    base_url: http://${ET_TEST_HOST}:$ET_TEST_PORT
+    api_key: ${ET_TEST_KEY}$$x
+    auth_type: bearer
+models:
+  m:
+    provider: remote
+    model: llama3
+roles: {}
+defaults:
+  model: m
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	pc := cfg.Providers["remote"]
+	if pc.BaseURL != "http://gpu01:11434" {
+		t.Errorf("expected expanded base_url http://gpu01:11434, got %q", pc.BaseURL)
+	}
+	if pc.APIKey != "secret$x" {
+		t.Errorf("expected api_key secret$x, got %q", pc.APIKey)
+	}
+}
+
+func TestParseConfig_EnvExpansion_AuthType(t *testing.T) {
+	t.Setenv("ET_TEST_AUTH", "none")
+	cfg, err := ParseConfig([]byte(`
+providers:
+  local:
+    type: ollama
+    base_url: http://localhost:11434
+    auth_type: $ET_TEST_AUTH
+models:
+  m:
+    provider: local
+    model: llama3
+roles: {}
+defaults:
+  model: m
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	if got := cfg.Providers["local"].AuthType; got != AuthNone {
+		t.Errorf("expected auth_type %q, got %q", AuthNone, got)
+	}
+}
+
+func TestParseConfig_EnvExpansion_BearerUnset(t *testing.T) {
+	t.Setenv("ET_TEST_MISSING_KEY", "")
+	_, err := ParseConfig([]byte(`
+providers:
+  cloud:
+    type: openai
+    base_url: https://api.example.com
+    api_key: ${ET_TEST_MISSING_KEY}
+    auth_type: bearer
+models:
+  m:
+    provider: cloud
+    model: gpt
+roles: {}
+defaults:
+  model: m
+`))
+	if err == nil {
+		t.Fatal("expected error for bearer auth with unset env var")
+	}
+	if !strings.Contains(err.Error(), "ET_TEST_MISSING_KEY") {
+		t.Errorf("expected error to name the missing variable, got: %v", err)
+	}
+}
+
+func TestMissingAuthEnv(t *testing.T) {
+	t.Setenv("ET_TEST_SET_KEY", "secret")
+	t.Setenv("ET_TEST_UNSET_KEY", "")
+	missing, err := MissingAuthEnv([]byte(`
+providers:
+  cloud:
+    type: openai
+    api_key: ${ET_TEST_UNSET_KEY}
+  proxied:
+    type: ollama
+    api_key: $ET_TEST_SET_KEY
+    auth_type: basic
+  local:
+    type: ollama
+    api_key: $ET_TEST_UNSET_KEY
+    auth_type: none
+`))
+	if err != nil {
+		t.Fatalf("MissingAuthEnv: %v", err)
+	}
+	if len(missing) != 1 {
+		t.Fatalf("expected 1 provider with missing env, got %v", missing)
+	}
+	if got := missing["cloud"]; len(got) != 1 || got[0] != "ET_TEST_UNSET_KEY" {
+		t.Errorf("missing[cloud] = %v, want [ET_TEST_UNSET_KEY]", got)
+	}
+}
+
+func TestParseKeepAlive(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30m", 30 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"300", 300 * time.Second, false},
+		{"0", 0, false},
+		{"-1", -1, false},
+		{"-5m", -1, false},
+		{"forever", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseKeepAlive(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseKeepAlive(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseKeepAlive(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidation_KeepAlive(t *testing.T) {
+	base := `
+providers:
+  p:
+    type: %s
+    base_url: http://localhost:11434
+    keep_alive: %s
+models:
+  m:
+    provider: p
+    model: x
+`
+	if _, err := ParseConfig([]byte(fmt.Sprintf(base, "ollama", "-1"))); err != nil {
+		t.Errorf("keep_alive -1 on ollama should be valid: %v", err)
+	}
+	if _, err := ParseConfig([]byte(fmt.Sprintf(base, "ollama", "soon"))); err == nil || !strings.Contains(err.Error(), "keep_alive") {
+		t.Errorf("expected invalid keep_alive error, got %v", err)
+	}
+	if _, err := ParseConfig([]byte(fmt.Sprintf(base, "openai", "30m"))); err == nil || !strings.Contains(err.Error(), "only applies to ollama") {
+		t.Errorf("expected keep_alive on openai to be rejected, got %v", err)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("ET_TEST_A", "alpha")
+	tests := []struct {
+		in        string
+		want      string
+		wantUnset int
+	}{
+		{"plain", "plain", 0},
+		{"$ET_TEST_A", "alpha", 0},
+		{"${ET_TEST_A}/v1", "alpha/v1", 0},
+		{"$$ET_TEST_A", "$ET_TEST_A", 0},
+		{"cost$", "cost$", 0},
+		{"$ET_TEST_UNSET_XYZ", "", 1},
+		{"${unterminated", "${unterminated", 0},
+	}
+	for _, tt := range tests {
+		got, unset := expandEnv(tt.in)
+		if got != tt.want {
+			t.Errorf("expandEnv(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if len(unset) != tt.wantUnset {
+			t.Errorf("expandEnv(%q) unset = %v, want %d entries", tt.in, unset, tt.wantUnset)
+		}
+	}
+}
+
+func TestParseConfig_CompatibleHeaders(t *testing.T) {
+	t.Setenv("ET_TEST_REFERER", "https://example.com")
+	cfg, err := ParseConfig([]byte(`
+providers:
+  router:
+    type: openai-compatible
+    base_url: https://openrouter.ai/api/v1
+    headers:
+      HTTP-Referer: $ET_TEST_REFERER
+      X-Title: electrictown
+models:
+  m:
+    provider: router
+    model: meta-llama/llama-3-70b
+roles: {}
+defaults:
+  model: m
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	h := cfg.Providers["router"].Headers
+	if h["HTTP-Referer"] != "https://example.com" || h["X-Title"] != "electrictown" {
+		t.Errorf("headers = %v, want expanded HTTP-Referer and X-Title", h)
+	}
+}
+
+func TestValidation_CompatibleProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		pc      ProviderConfig
+		wantErr string
+	}{
+		{"ok", ProviderConfig{Type: "openai-compatible", BaseURL: "http://vllm:8000/v1"}, ""},
+		{"missing base_url", ProviderConfig{Type: "openai-compatible"}, "requires base_url"},
+		{"headers on any type", ProviderConfig{Type: "ollama", Headers: map[string]string{"X-Request-Id": "y"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Providers: map[string]ProviderConfig{"p": tt.pc},
+				Models:    map[string]ModelConfig{"m": {Provider: "p", Model: "x"}},
+				Defaults:  DefaultsConfig{Model: "m"},
+			}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Prompts(t *testing.T) {
+	base := `
+providers:
+  local:
+    type: ollama
+models:
+  m:
+    provider: local
+    model: qwen
+defaults:
+  model: m
+`
+	cfg, err := ParseConfig([]byte(base + `
+prompts:
+  mayor: Split the task into at most three subtasks.
+  worker_files: |
+    Write files as ===FILE: path=== blocks.
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	if got := cfg.Prompt(PromptMayor); got != "Split the task into at most three subtasks." {
+		t.Errorf("Prompt(mayor) = %q", got)
+	}
+	if got := cfg.Prompt(PromptWorkerFiles); got != "Write files as ===FILE: path=== blocks.\n" {
+		t.Errorf("Prompt(worker_files) = %q", got)
+	}
+	if got := cfg.Prompt(PromptTester); got != "" {
+		t.Errorf("Prompt(tester) = %q, want empty for an unset key", got)
+	}
+	var nilCfg *Config
+	if got := nilCfg.Prompt(PromptMayor); got != "" {
+		t.Errorf("nil Config Prompt = %q", got)
+	}
+
+	for _, tt := range []struct{ prompts, wantErr string }{
+		{"prompts:\n  planner: x\n", `unknown prompt "planner"`},
+		{"prompts:\n  tester: \"  \"\n", `prompt "tester" is empty`},
+		{"prompts:\n  mayor: \"{{.Tsk}}\"\n", `prompt "mayor": provider: render prompt template`},
+		{"prompts:\n  synthesize: \"{{range .WorkerResults}}{{.Score}}{{end}}\"\n", `can't evaluate field Score`},
+	} {
+		if _, err := ParseConfig([]byte(base + tt.prompts)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseConfig(%q) error = %v, want containing %q", tt.prompts, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidation_Commands(t *testing.T) {
+	const base = `
+providers:
+  ollama:
+    type: ollama
+    base_url: http://localhost:11434
+models:
+  small:
+    provider: ollama
+    model: qwen3:8b
+`
+	tests := []struct {
+		name    string
+		stanza  string
+		wantErr string // "" = valid
+	}{
+		{"off by default", "", ""},
+		{"allowlist", "commands:\n  allow: [gofmt, go fmt]\n  timeout: 10s\n", ""},
+		{"empty entry", "commands:\n  allow: [gofmt, \" \"]\n", "commands.allow entry 1 is empty"},
+		{"bad timeout", "commands:\n  allow: [gofmt]\n  timeout: soon\n", `commands.timeout "soon"`},
+		{"negative timeout", "commands:\n  allow: [gofmt]\n  timeout: -5s\n", "not a positive duration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig([]byte(base + tt.stanza))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("expected error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			case tt.name == "allowlist" && (len(cfg.Commands.Allow) != 2 || cfg.Commands.Allow[1] != "go fmt"):
+				t.Errorf("allow = %q", cfg.Commands.Allow)
+			}
+		})
+	}
+}
@@ -45,12 +45,6 @@ func DumpFailedRequest(model string, messages []Message, reqErr error) (string,
 		}
 	}
 
-	// Estimate tokens: ~4 chars per token.
-	totalChars := 0
-	for _, m := range messages {
-		totalChars += len(m.Content)
-	}
-
 	errType := "unknown"
 	if reqErr != nil {
 		errType = string(ClassifyError(reqErr))
@@ -63,7 +57,7 @@ func DumpFailedRequest(model string, messages []Message, reqErr error) (string,
 		ErrorMsg:    reqErr.Error(),
 		NumMessages: len(messages),
 		Messages:    sanitized,
-		TokenEst:    totalChars / 4,
+		TokenEst:    EstimateMessagesTokens(messages),
 	}
 
 	data, err := json.MarshalIndent(dump, "", "  ")
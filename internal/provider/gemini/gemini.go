@@ -6,10 +6,12 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/meganerd/electrictown/internal/provider"
@@ -25,6 +27,7 @@ type GeminiProvider struct {
 	apiKey  string
 	baseURL string
 	client  *http.Client
+	headers map[string]string // extra headers on every request
 }
 
 // Option configures a GeminiProvider.
@@ -44,6 +47,13 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithDefaultHeaders adds headers to every request; see provider.ProviderConfig.Headers.
+func WithDefaultHeaders(headers map[string]string) Option {
+	return func(p *GeminiProvider) {
+		p.headers = headers
+	}
+}
+
 // New creates a GeminiProvider with the given API key and options.
 func New(apiKey string, opts ...Option) *GeminiProvider {
 	p := &GeminiProvider{
@@ -62,6 +72,11 @@ func (p *GeminiProvider) Name() string {
 	return providerName
 }
 
+// Capabilities reports tools and images; seed and penalties are not translated.
+func (p *GeminiProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Tools: true, Images: true}
+}
+
 // --- Gemini API types (wire format) ---
 
 type geminiRequest struct {
@@ -81,11 +96,18 @@ type geminiContent struct {
 }
 
 type geminiPart struct {
-	Text             string                 `json:"text,omitempty"`
-	FunctionCall     *geminiFunctionCall    `json:"functionCall,omitempty"`
+	Text             string                  `json:"text,omitempty"`
+	InlineData       *geminiInlineData       `json:"inline_data,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
 	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
 }
 
+// geminiInlineData is an inline_data part: an image sent with the request.
+type geminiInlineData struct {
+	MimeType string `json:"mime_type"`
+	Data     string `json:"data"` // base64
+}
+
 type geminiFunctionCall struct {
 	Name string         `json:"name"`
 	Args map[string]any `json:"args"`
@@ -153,7 +175,8 @@ func toGeminiContents(msgs []provider.Message) ([]geminiContent, *geminiSystemIn
 	var contents []geminiContent
 	var sysInstruction *geminiSystemInstruction
 
-	for _, m := range msgs {
+	for i := 0; i < len(msgs); i++ {
+		m := msgs[i]
 		switch m.Role {
 		case provider.RoleSystem:
 			sysInstruction = &geminiSystemInstruction{
@@ -161,9 +184,19 @@ func toGeminiContents(msgs []provider.Message) ([]geminiContent, *geminiSystemIn
 			}
 
 		case provider.RoleUser:
+			var parts []geminiPart
+			if m.Content != "" || len(m.Images) == 0 {
+				parts = append(parts, geminiPart{Text: m.Content})
+			}
+			for _, img := range m.Images {
+				parts = append(parts, geminiPart{InlineData: &geminiInlineData{
+					MimeType: provider.ImageMediaType(img),
+					Data:     base64.StdEncoding.EncodeToString(img),
+				}})
+			}
 			contents = append(contents, geminiContent{
 				Role:  "user",
-				Parts: []geminiPart{{Text: m.Content}},
+				Parts: parts,
 			})
 
 		case provider.RoleAssistant:
@@ -189,28 +222,59 @@ func toGeminiContents(msgs []provider.Message) ([]geminiContent, *geminiSystemIn
 			}
 
 		case provider.RoleTool:
-			var respData map[string]any
-			_ = json.Unmarshal([]byte(m.Content), &respData)
-			if respData == nil {
-				respData = map[string]any{"result": m.Content}
+			// Gemini matches responses to calls by name and position, so a
+			// run of tool results becomes one content, ordered like the calls.
+			end := i + 1
+			for end < len(msgs) && msgs[end].Role == provider.RoleTool {
+				end++
 			}
 			contents = append(contents, geminiContent{
-				Role: "function",
-				Parts: []geminiPart{
-					{
-						FunctionResponse: &geminiFunctionResponse{
-							Name:     m.Name,
-							Response: respData,
-						},
-					},
-				},
+				Role:  "function",
+				Parts: toGeminiFunctionResponses(msgs, i, end),
 			})
+			i = end - 1
 		}
 	}
 
 	return contents, sysInstruction
 }
 
+// toGeminiFunctionResponses converts the tool results msgs[start:end] into
+// functionResponse parts, named after the call each result's ToolCallID
+// matches and sorted into call order. Unmatched results keep their Name and
+// follow in their original order.
+func toGeminiFunctionResponses(msgs []provider.Message, start, end int) []geminiPart {
+	type result struct {
+		order int
+		part  geminiPart
+	}
+	results := make([]result, 0, end-start)
+	for i := start; i < end; i++ {
+		var respData map[string]any
+		_ = json.Unmarshal([]byte(msgs[i].Content), &respData)
+		if respData == nil {
+			respData = map[string]any{"result": msgs[i].Content}
+		}
+		order := len(msgs) + i // unmatched: after every matched result
+		if _, idx, ok := provider.MatchToolResult(msgs, i); ok {
+			order = idx
+		}
+		results = append(results, result{order: order, part: geminiPart{
+			FunctionResponse: &geminiFunctionResponse{
+				Name:     provider.ToolResultName(msgs, i),
+				Response: respData,
+			},
+		}})
+	}
+	sort.SliceStable(results, func(a, b int) bool { return results[a].order < results[b].order })
+
+	parts := make([]geminiPart, len(results))
+	for i, r := range results {
+		parts[i] = r.part
+	}
+	return parts
+}
+
 // toGeminiTools converts provider tools to Gemini tool declarations.
 func toGeminiTools(tools []provider.Tool) []geminiToolDeclaration {
 	if len(tools) == 0 {
@@ -261,7 +325,7 @@ func fromGeminiContent(c geminiContent) provider.Message {
 		if part.FunctionCall != nil {
 			argsJSON, _ := json.Marshal(part.FunctionCall.Args)
 			msg.ToolCalls = append(msg.ToolCalls, provider.ToolCall{
-				ID:   fmt.Sprintf("call_%s", part.FunctionCall.Name),
+				ID:   provider.SyntheticToolCallID(len(msg.ToolCalls), part.FunctionCall.Name),
 				Type: "function",
 				Function: provider.FunctionCall{
 					Name:      part.FunctionCall.Name,
@@ -302,6 +366,9 @@ func (p *GeminiProvider) newRequest(ctx context.Context, method, path string, bo
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
 	return req, nil
 }
 
@@ -443,9 +510,10 @@ func (p *GeminiProvider) StreamChatCompletion(ctx context.Context, req *provider
 	}
 
 	return &sseStream{
-		reader: bufio.NewReader(resp.Body),
-		body:   resp.Body,
-		model:  req.Model,
+		reader:        bufio.NewReader(resp.Body),
+		body:          resp.Body,
+		model:         req.Model,
+		StreamCounter: provider.NewStreamCounter(req),
 	}, nil
 }
 
@@ -477,12 +545,23 @@ func (p *GeminiProvider) ListModels(ctx context.Context) ([]provider.Model, erro
 // --- SSE stream implementation ---
 
 type sseStream struct {
-	reader *bufio.Reader
-	body   io.ReadCloser
-	model  string
+	reader    *bufio.Reader
+	body      io.ReadCloser
+	model     string
+	toolCalls int // calls seen so far; numbers IDs across chunks
+	provider.StreamCounter
 }
 
+// Next returns the next chunk, recording it for Stats.
 func (s *sseStream) Next() (*provider.ChatStreamChunk, error) {
+	chunk, err := s.next()
+	if err == nil {
+		s.Observe(chunk)
+	}
+	return chunk, err
+}
+
+func (s *sseStream) next() (*provider.ChatStreamChunk, error) {
 	for {
 		line, err := s.reader.ReadString('\n')
 		if err != nil {
@@ -541,13 +620,14 @@ func (s *sseStream) Next() (*provider.ChatStreamChunk, error) {
 				if part.FunctionCall != nil {
 					argsJSON, _ := json.Marshal(part.FunctionCall.Args)
 					chunk.Delta.ToolCalls = append(chunk.Delta.ToolCalls, provider.ToolCall{
-						ID:   fmt.Sprintf("call_%s", part.FunctionCall.Name),
+						ID:   provider.SyntheticToolCallID(s.toolCalls, part.FunctionCall.Name),
 						Type: "function",
 						Function: provider.FunctionCall{
 							Name:      part.FunctionCall.Name,
 							Arguments: string(argsJSON),
 						},
 					})
+					s.toolCalls++
 				}
 			}
 
@@ -571,4 +651,5 @@ func (s *sseStream) Close() error {
 
 // Compile-time interface compliance checks.
 var _ provider.Provider = (*GeminiProvider)(nil)
+var _ provider.CapabilityReporter = (*GeminiProvider)(nil)
 var _ provider.ChatStream = (*sseStream)(nil)
@@ -2,6 +2,7 @@ package gemini
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -345,6 +346,56 @@ func TestChatCompletionWithToolResponse(t *testing.T) {
 	}
 }
 
+func TestToolCalls_SameFunctionTwice(t *testing.T) {
+	var second geminiRequest
+	calls := 0
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var resp geminiResponse
+		if calls == 1 {
+			resp.Candidates = []geminiCandidate{{Content: geminiContent{Role: "model", Parts: []geminiPart{
+				{FunctionCall: &geminiFunctionCall{Name: "get_weather", Args: map[string]any{"location": "NYC"}}},
+				{FunctionCall: &geminiFunctionCall{Name: "get_weather", Args: map[string]any{"location": "LA"}}},
+			}}}}
+		} else {
+			json.NewDecoder(r.Body).Decode(&second)
+			resp.Candidates = []geminiCandidate{{Content: geminiContent{Role: "model", Parts: []geminiPart{{Text: "done"}}}}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	msgs := []provider.Message{{Role: provider.RoleUser, Content: "Weather in NYC and LA?"}}
+	resp, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{Model: "gemini-pro", Messages: msgs})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tcs := resp.Message.ToolCalls
+	if len(tcs) != 2 || tcs[0].ID == tcs[1].ID {
+		t.Fatalf("expected two tool calls with distinct IDs, got %+v", tcs)
+	}
+
+	// Answer out of order and without Name: matching must go through the IDs.
+	msgs = append(msgs, resp.Message,
+		provider.Message{Role: provider.RoleTool, ToolCallID: tcs[1].ID, Content: `{"temp":"80F"}`},
+		provider.Message{Role: provider.RoleTool, ToolCallID: tcs[0].ID, Content: `{"temp":"72F"}`},
+	)
+	if _, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{Model: "gemini-pro", Messages: msgs}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	last := second.Contents[len(second.Contents)-1]
+	if last.Role != "function" || len(last.Parts) != 2 {
+		t.Fatalf("expected both results in one function content, got %+v", last)
+	}
+	for i, want := range []string{"72F", "80F"} {
+		fr := last.Parts[i].FunctionResponse
+		if fr == nil || fr.Name != "get_weather" || fr.Response["temp"] != want {
+			t.Errorf("part %d = %+v, want get_weather temp %s (call order)", i, fr, want)
+		}
+	}
+}
+
 func TestChatCompletionAPIError(t *testing.T) {
 	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -544,3 +595,122 @@ func TestChatCompletionNoCandidates(t *testing.T) {
 		t.Fatal("expected error for empty candidates, got nil")
 	}
 }
+
+func TestChatCompletion_CacheableIgnored(t *testing.T) {
+	var body []byte
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`)
+	})
+
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "gemini-pro",
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "shared instructions", Cacheable: true},
+			{Role: provider.RoleUser, Content: "Hi"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("decoding request: %v", err)
+	}
+	if _, ok := req["cached_content"]; ok {
+		t.Errorf("request should not reference a cached content resource: %s", body)
+	}
+	if _, ok := req["system_instruction"]; !ok {
+		t.Errorf("cacheable system message not sent as system_instruction: %s", body)
+	}
+}
+
+func TestChatCompletion_Images(t *testing.T) {
+	var req geminiRequest
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"a chart"}]},"finishReason":"STOP"}]}`)
+	})
+
+	gif := []byte("GIF89a\x01\x00\x01\x00")
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "gemini-pro",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "Describe this.", Images: [][]byte{gif}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if len(req.Contents) != 1 {
+		t.Fatalf("contents = %d, want 1", len(req.Contents))
+	}
+	parts := req.Contents[0].Parts
+	if len(parts) != 2 || parts[0].Text != "Describe this." || parts[1].InlineData == nil {
+		t.Fatalf("parts = %+v, want text then inline_data", parts)
+	}
+	if d := parts[1].InlineData; d.MimeType != "image/gif" || d.Data != base64.StdEncoding.EncodeToString(gif) {
+		t.Errorf("inline_data = %+v, want base64 image/gif", d)
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := New("key", WithBaseURL(srv.URL), WithDefaultHeaders(map[string]string{"X-Request-Id": "req-1"}))
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "gemini-pro",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if got.Get("X-Request-Id") != "req-1" {
+		t.Errorf("headers = %v, want X-Request-Id", got)
+	}
+}
+
+func TestStreamStats_Cancelled(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, text := range []string{"Once", " upon", " a time"} {
+			fmt.Fprintf(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":%q}]}}]}\n\n", text)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := p.StreamChatCompletion(ctx, &provider.ChatRequest{
+		Model:    "gemini-pro",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Tell me a story"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := stream.Next(); err != nil {
+			t.Fatalf("Next %d: %v", i, err)
+		}
+	}
+	cancel()
+	if _, err := stream.Next(); err == nil {
+		t.Fatal("expected an error after cancellation")
+	}
+	stream.Close()
+
+	st := stream.Stats()
+	want := provider.StreamStats{
+		Chunks: 3,
+		Usage:  provider.Usage{PromptTokens: 4, CompletionTokens: 4, TotalTokens: 8, Estimated: true},
+	}
+	if st != want {
+		t.Errorf("stats = %+v, want %+v", st, want)
+	}
+}
@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ErrImagesUnsupported is returned by adapters asked to send Message.Images
+// to a provider or model that cannot take image input. It wraps
+// ErrUnsupported.
+var ErrImagesUnsupported = fmt.Errorf("%w: images", ErrUnsupported)
+
+// ImageMediaType returns the MIME type of image data attached to a
+// Message: "image/png", "image/jpeg", "image/gif" or "image/webp". It
+// returns "" for anything else.
+func ImageMediaType(data []byte) string {
+	switch t := http.DetectContentType(data); t {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return t
+	}
+	return ""
+}
@@ -0,0 +1,24 @@
+package provider
+
+import "testing"
+
+func TestImageMediaType(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "image/png"},
+		{"jpeg", "\xff\xd8\xff\xe0\x00\x10JFIF", "image/jpeg"},
+		{"gif", "GIF89a\x01\x00\x01\x00", "image/gif"},
+		{"webp", "RIFF\x24\x00\x00\x00WEBPVP8 ", "image/webp"},
+		{"text", "hello", ""},
+		{"svg", `<svg xmlns="http://www.w3.org/2000/svg"></svg>`, ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		if got := ImageMediaType([]byte(tt.data)); got != tt.want {
+			t.Errorf("ImageMediaType(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReadConfig reads the config file at path with its includes resolved, as
+// YAML ready for ParseConfig.
+//
+// A config may list other config files under a top-level includes key.
+// Relative include paths are resolved against the including file's
+// directory, and included files may include others. The files are merged
+// in order, each over the ones before it, and the including file over all
+// of its includes: mappings merge key by key at every depth, while any
+// other value (a string, a list such as a pool or fallbacks) replaces the
+// earlier one outright. An include cycle is an error.
+func ReadConfig(path string) ([]byte, error) {
+	merged, err := readMerged(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("merging config %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// readMerged returns the file at path merged over its includes. stack
+// holds the absolute paths of the files including it, for cycle detection.
+func readMerged(path string, stack []string) (map[string]any, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+	if slices.Contains(stack, abs) {
+		return nil, fmt.Errorf("config include cycle: %s -> %s", strings.Join(stack, " -> "), abs)
+	}
+	stack = append(stack, abs)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	var inc struct {
+		Includes []string `yaml:"includes"`
+	}
+	if err := yaml.Unmarshal(data, &inc); err != nil {
+		return nil, fmt.Errorf("parsing config %s: includes must be a list of paths: %w", path, err)
+	}
+	delete(doc, "includes")
+
+	merged := map[string]any{}
+	for _, p := range inc.Includes {
+		if p == "" {
+			return nil, fmt.Errorf("config %s: empty include path", path)
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(abs), p)
+		}
+		sub, err := readMerged(p, stack)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeMaps(merged, sub)
+	}
+	return mergeMaps(merged, doc), nil
+}
+
+// mergeMaps merges over into base, recursing into mappings present in both.
+func mergeMaps(base, over map[string]any) map[string]any {
+	for k, v := range over {
+		bm, ok1 := base[k].(map[string]any)
+		om, ok2 := v.(map[string]any)
+		if ok1 && ok2 {
+			base[k] = mergeMaps(bm, om)
+			continue
+		}
+		base[k] = v
+	}
+	return base
+}
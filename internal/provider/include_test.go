@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfig_IncludePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "shared", "base.yaml"), `
+providers:
+  local:
+    type: ollama
+    base_url: http://base:11434
+models:
+  small:
+    provider: local
+    model: qwen3:8b
+  big:
+    provider: local
+    model: qwen3:32b
+roles:
+  mayor:
+    model: big
+  polecat:
+    model: small
+    pool: [small, big]
+defaults:
+  model: small
+  max_tokens: 4096
+`)
+	// Relative to shared/, not to the project file.
+	writeFile(t, filepath.Join(dir, "shared", "gpu.yaml"), `
+providers:
+  local:
+    base_url: http://gpu:11434
+defaults:
+  max_tokens: 8192
+`)
+	writeFile(t, filepath.Join(dir, "shared", "team.yaml"), `
+includes: [base.yaml, gpu.yaml]
+roles:
+  polecat:
+    pool: [small]
+`)
+	project := filepath.Join(dir, "project", "electrictown.yaml")
+	writeFile(t, project, `
+includes: [../shared/team.yaml]
+defaults:
+  max_tokens: 2048
+`)
+
+	cfg, err := LoadConfig(project)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if got := cfg.Providers["local"].BaseURL; got != "http://gpu:11434" {
+		t.Errorf("base_url = %q, want the later include's override", got)
+	}
+	if got := cfg.Providers["local"].Type; got != "ollama" {
+		t.Errorf("type = %q, want it kept from base (maps merge key by key)", got)
+	}
+	if cfg.Defaults.MaxTokens != 2048 {
+		t.Errorf("max_tokens = %d, want the local file's 2048", cfg.Defaults.MaxTokens)
+	}
+	if got := cfg.PoolForRole("polecat"); len(got) != 1 || got[0] != "small" {
+		t.Errorf("pool = %v, want lists replaced, not appended", got)
+	}
+	if cfg.Roles["polecat"].Model != "small" || cfg.Roles["mayor"].Model != "big" {
+		t.Errorf("roles = %+v, want base values kept", cfg.Roles)
+	}
+	if len(cfg.Includes) != 0 {
+		t.Errorf("includes = %v, want them consumed by the merge", cfg.Includes)
+	}
+}
+
+func TestLoadConfig_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.yaml"), "includes: [b.yaml]\n")
+	writeFile(t, filepath.Join(dir, "b.yaml"), "includes: [sub/c.yaml]\n")
+	writeFile(t, filepath.Join(dir, "sub", "c.yaml"), "includes: [../a.yaml]\n")
+
+	_, err := LoadConfig(filepath.Join(dir, "a.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("err = %v, want an include cycle", err)
+	}
+	if !strings.Contains(err.Error(), "c.yaml -> "+filepath.Join(dir, "a.yaml")) {
+		t.Errorf("err = %v, want the cycle's path", err)
+	}
+}
+
+func TestLoadConfig_IncludeTwiceIsNotACycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "base.yaml"), string(testConfigYAML))
+	writeFile(t, filepath.Join(dir, "mid.yaml"), "includes: [base.yaml]\n")
+	writeFile(t, filepath.Join(dir, "top.yaml"), "includes: [base.yaml, mid.yaml]\n")
+
+	if _, err := LoadConfig(filepath.Join(dir, "top.yaml")); err != nil {
+		t.Errorf("LoadConfig: %v", err)
+	}
+}
+
+func TestLoadConfig_MissingInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.yaml"), "includes: [nope.yaml]\n")
+	_, err := LoadConfig(filepath.Join(dir, "a.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "nope.yaml") {
+		t.Errorf("err = %v, want the missing include named", err)
+	}
+}
+
+func TestParseConfig_RejectsIncludes(t *testing.T) {
+	data := append([]byte("includes: [base.yaml]\n"), testConfigYAML...)
+	if _, err := ParseConfig(data); err == nil {
+		t.Error("ParseConfig resolved includes without a file path")
+	}
+}
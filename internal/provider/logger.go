@@ -0,0 +1,34 @@
+package provider
+
+// Logger receives the router's structured log events: a message plus
+// alternating key/value pairs. *slog.Logger satisfies it, so a caller can
+// pass slog.New(slog.NewTextHandler(os.Stderr, ...)) to WithLogger.
+//
+// Debug covers every request (start, routed model, outcome); Info covers
+// fallback hops; Warn covers failed requests, open circuits and unsupported
+// features; Error covers requests that failed on every fallback.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// WithLogger sends the router's log events to l. Without it, or with a nil
+// l, nothing is logged.
+func WithLogger(l Logger) RouterOption {
+	return func(r *Router) {
+		if l == nil {
+			l = nopLogger{}
+		}
+		r.log = l
+	}
+}
+
+// nopLogger discards every event.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
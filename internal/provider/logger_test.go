@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logEvent is one call captured by testLogger.
+type logEvent struct {
+	level string
+	msg   string
+	attrs map[string]any
+}
+
+// testLogger records every event it receives.
+type testLogger struct {
+	mu     sync.Mutex
+	events []logEvent
+}
+
+func (l *testLogger) add(level, msg string, args []any) {
+	attrs := make(map[string]any)
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs[fmt.Sprint(args[i])] = args[i+1]
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, logEvent{level, msg, attrs})
+}
+
+func (l *testLogger) Debug(msg string, args ...any) { l.add("debug", msg, args) }
+func (l *testLogger) Info(msg string, args ...any)  { l.add("info", msg, args) }
+func (l *testLogger) Warn(msg string, args ...any)  { l.add("warn", msg, args) }
+func (l *testLogger) Error(msg string, args ...any) { l.add("error", msg, args) }
+
+// summary renders the events as "level msg" lines.
+func (l *testLogger) summary() string {
+	var b strings.Builder
+	for _, e := range l.events {
+		fmt.Fprintf(&b, "%s %s\n", e.level, e.msg)
+	}
+	return b.String()
+}
+
+func TestRouterLogsRoutedRequest(t *testing.T) {
+	primary := &mockProvider{
+		name: "primary",
+		chatFn: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+			return nil, &APIError{Status: 503, Message: "overloaded"}
+		},
+	}
+	fallback := &mockProvider{
+		name: "fallback",
+		chatFn: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+			return &ChatResponse{Model: req.Model, Usage: Usage{PromptTokens: 12, CompletionTokens: 3}}, nil
+		},
+	}
+	log := &testLogger{}
+	factories := map[string]ProviderFactory{
+		"mock-primary":  func(ProviderConfig) (Provider, error) { return primary, nil },
+		"mock-fallback": func(ProviderConfig) (Provider, error) { return fallback, nil },
+	}
+	r, err := NewRouter(routerTestConfig(), factories, WithLogger(log))
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if _, err := r.ChatCompletionForRole(context.Background(), "leader", req); err != nil {
+		t.Fatalf("ChatCompletionForRole: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"debug role resolved",
+		"debug request start",
+		"warn request failed",
+		"info fallback",
+		"debug request start",
+		"debug request done",
+	}, "\n") + "\n"
+	if got := log.summary(); got != want {
+		t.Fatalf("events:\n%s\nwant:\n%s", got, want)
+	}
+
+	resolved := log.events[0].attrs
+	if resolved["role"] != "leader" || resolved["provider"] != "primary" || resolved["model"] != "real-model-a" {
+		t.Errorf("role resolved attrs = %v", resolved)
+	}
+	failed := log.events[2].attrs
+	if failed["code"] != ErrServerError || failed["model"] != "real-model-a" {
+		t.Errorf("request failed attrs = %v", failed)
+	}
+	fb := log.events[3].attrs
+	if fb["from"] != "model-a" || fb["to"] != "model-b" {
+		t.Errorf("fallback attrs = %v", fb)
+	}
+	done := log.events[5].attrs
+	if done["provider"] != "fallback" || done["model"] != "real-model-b" || done["prompt_tokens"] != 12 {
+		t.Errorf("request done attrs = %v", done)
+	}
+}
+
+func TestRouterLogsExhaustedFallbacks(t *testing.T) {
+	down := func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		return nil, &APIError{Status: 500, Message: "boom"}
+	}
+	log := &testLogger{}
+	factories := map[string]ProviderFactory{
+		"mock-primary":  func(ProviderConfig) (Provider, error) { return &mockProvider{name: "primary", chatFn: down}, nil },
+		"mock-fallback": func(ProviderConfig) (Provider, error) { return &mockProvider{name: "fallback", chatFn: down}, nil },
+	}
+	r, err := NewRouter(routerTestConfig(), factories, WithLogger(log))
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if _, err := r.ChatCompletionForRole(context.Background(), "leader", req); err == nil {
+		t.Fatal("expected an error")
+	}
+	last := log.events[len(log.events)-1]
+	if last.level != "error" || last.msg != "fallbacks exhausted" || last.attrs["role"] != "leader" {
+		t.Errorf("last event = %+v, want fallbacks exhausted at error level", last)
+	}
+}
+
+func TestWithLogger_Slog(t *testing.T) {
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	r := newCacheTestRouter(t, &mockProvider{name: "primary"}, &mockProvider{name: "fallback"}, WithLogger(logger))
+
+	req := &ChatRequest{Model: "model-a", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if _, err := r.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{`msg="model resolved" ref=model-a provider=primary model=real-model-a`, `msg="request done"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("slog output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWithLogger_NilIsQuiet(t *testing.T) {
+	r := newCacheTestRouter(t, &mockProvider{name: "primary"}, &mockProvider{name: "fallback"}, WithLogger(nil))
+	req := &ChatRequest{Model: "model-a", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if _, err := r.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+}
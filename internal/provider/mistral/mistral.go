@@ -0,0 +1,546 @@
+// Package mistral implements the provider.Provider interface for the Mistral API.
+// It uses native net/http for all HTTP communication -- no external SDK.
+package mistral
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+const (
+	defaultBaseURL = "https://api.mistral.ai/v1"
+	providerName   = "mistral"
+)
+
+// MistralProvider implements provider.Provider using the Mistral REST API.
+type MistralProvider struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+	headers map[string]string // extra headers, applied after auth
+}
+
+// Option configures a MistralProvider.
+type Option func(*MistralProvider)
+
+// WithBaseURL overrides the default Mistral API base URL.
+func WithBaseURL(url string) Option {
+	return func(p *MistralProvider) {
+		p.baseURL = strings.TrimRight(url, "/")
+	}
+}
+
+// WithHTTPClient overrides the default HTTP client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *MistralProvider) {
+		p.client = client
+	}
+}
+
+// WithDefaultHeaders adds headers to every request. They are set after the
+// Authorization header and replace it if they share its name.
+func WithDefaultHeaders(headers map[string]string) Option {
+	return func(p *MistralProvider) {
+		p.headers = headers
+	}
+}
+
+// New creates a MistralProvider with the given API key and options.
+func New(apiKey string, opts ...Option) *MistralProvider {
+	p := &MistralProvider{
+		apiKey:  apiKey,
+		baseURL: defaultBaseURL,
+		client:  http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Name returns the provider identifier.
+func (p *MistralProvider) Name() string {
+	return providerName
+}
+
+// Capabilities reports tools, seed and penalties; images are not translated.
+func (p *MistralProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Tools: true, Seed: true, Penalties: true}
+}
+
+// --- Mistral API types (wire format) ---
+
+type mistralRequest struct {
+	Model            string           `json:"model"`
+	Messages         []mistralMessage `json:"messages"`
+	Tools            []provider.Tool  `json:"tools,omitempty"`
+	Temperature      *float64         `json:"temperature,omitempty"`
+	TopP             *float64         `json:"top_p,omitempty"`
+	MaxTokens        *int             `json:"max_tokens,omitempty"`
+	Stop             []string         `json:"stop,omitempty"`
+	RandomSeed       *int             `json:"random_seed,omitempty"`
+	FrequencyPenalty *float64         `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64         `json:"presence_penalty,omitempty"`
+	Stream           bool             `json:"stream,omitempty"`
+}
+
+type mistralMessage struct {
+	Role       provider.Role     `json:"role"`
+	Content    mistralContent    `json:"content"`
+	Name       string            `json:"name,omitempty"`
+	ToolCallID string            `json:"tool_call_id,omitempty"`
+	ToolCalls  []mistralToolCall `json:"tool_calls,omitempty"`
+}
+
+// mistralContent is message content. Requests always send a string; responses
+// may carry either a string or an array of typed chunks, whose text parts are
+// concatenated.
+type mistralContent string
+
+func (c *mistralContent) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*c = ""
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*c = mistralContent(s)
+		return nil
+	}
+	var chunks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &chunks); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, ch := range chunks {
+		if ch.Type == "text" {
+			sb.WriteString(ch.Text)
+		}
+	}
+	*c = mistralContent(sb.String())
+	return nil
+}
+
+type mistralToolCall struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type,omitempty"`
+	Function mistralFunction `json:"function"`
+}
+
+type mistralFunction struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"` // a JSON string or object
+}
+
+type mistralResponse struct {
+	ID      string          `json:"id"`
+	Model   string          `json:"model"`
+	Choices []mistralChoice `json:"choices"`
+	Usage   *mistralUsage   `json:"usage,omitempty"`
+}
+
+type mistralChoice struct {
+	Index        int            `json:"index"`
+	Message      mistralMessage `json:"message"`
+	Delta        mistralMessage `json:"delta"`
+	FinishReason *string        `json:"finish_reason"`
+}
+
+type mistralUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// mistralError is the error body. Message is usually a string but is an
+// object for request validation errors.
+type mistralError struct {
+	Message json.RawMessage `json:"message"`
+	Type    string          `json:"type"`
+	Code    any             `json:"code"`
+}
+
+type mistralModelsResponse struct {
+	Data []mistralModel `json:"data"`
+}
+
+type mistralModel struct {
+	ID string `json:"id"`
+}
+
+// --- Request/Response translation ---
+
+// validToolCallID matches the only tool call IDs Mistral accepts.
+var validToolCallID = regexp.MustCompile(`^[a-zA-Z0-9]{9}$`)
+
+// toolCallID maps a tool call ID from another provider (e.g. OpenAI's
+// "call_..." after a fallback) to the nine-character form Mistral requires.
+// The mapping is deterministic, so an assistant tool call and the tool result
+// answering it still match.
+func toolCallID(id string) string {
+	if id == "" || validToolCallID.MatchString(id) {
+		return id
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])[:9]
+}
+
+func toMistralMessages(msgs []provider.Message) []mistralMessage {
+	out := make([]mistralMessage, len(msgs))
+	for i, m := range msgs {
+		mm := mistralMessage{
+			Role:       m.Role,
+			Content:    mistralContent(m.Content),
+			Name:       m.Name,
+			ToolCallID: toolCallID(m.ToolCallID),
+		}
+		for _, tc := range m.ToolCalls {
+			args, _ := json.Marshal(tc.Function.Arguments)
+			mm.ToolCalls = append(mm.ToolCalls, mistralToolCall{
+				ID:       toolCallID(tc.ID),
+				Type:     "function",
+				Function: mistralFunction{Name: tc.Function.Name, Arguments: args},
+			})
+		}
+		out[i] = mm
+	}
+	return out
+}
+
+func fromMistralMessage(m mistralMessage) provider.Message {
+	return provider.Message{
+		Role:       m.Role,
+		Content:    string(m.Content),
+		Name:       m.Name,
+		ToolCallID: m.ToolCallID,
+		ToolCalls:  fromMistralToolCalls(m.ToolCalls),
+	}
+}
+
+func fromMistralToolCalls(calls []mistralToolCall) []provider.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	out := make([]provider.ToolCall, len(calls))
+	for i, tc := range calls {
+		out[i] = provider.ToolCall{
+			ID:   tc.ID,
+			Type: "function",
+			Function: provider.FunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: jsonText(tc.Function.Arguments),
+			},
+		}
+	}
+	return out
+}
+
+// jsonText returns raw unquoted if it is a JSON string and as JSON text
+// otherwise. Tool call arguments become the JSON string the rest of
+// electrictown expects whether Mistral sent a string or an object.
+func jsonText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	return string(raw)
+}
+
+func fromMistralUsage(u *mistralUsage) provider.Usage {
+	if u == nil {
+		return provider.Usage{}
+	}
+	return provider.Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+// checkNoImages refuses requests carrying Message.Images, which this adapter
+// does not translate, instead of sending them without the pictures.
+func checkNoImages(req *provider.ChatRequest) error {
+	for _, m := range req.Messages {
+		if len(m.Images) > 0 {
+			return fmt.Errorf("mistral: %w", provider.ErrImagesUnsupported)
+		}
+	}
+	return nil
+}
+
+func toMistralRequest(req *provider.ChatRequest, stream bool) mistralRequest {
+	return mistralRequest{
+		Model:            req.Model,
+		Messages:         toMistralMessages(req.Messages),
+		Tools:            req.Tools,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		MaxTokens:        req.MaxTokens,
+		Stop:             req.Stop,
+		RandomSeed:       req.Seed,
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
+		Stream:           stream,
+	}
+}
+
+// --- HTTP helpers ---
+
+func (p *MistralProvider) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+func (p *MistralProvider) doJSON(req *http.Request, dst any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("mistral: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return p.parseErrorResponse(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("mistral: failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func (p *MistralProvider) parseErrorResponse(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp mistralError
+	if json.Unmarshal(body, &errResp) == nil && len(errResp.Message) > 0 {
+		msg := jsonText(errResp.Message)
+		code := errorCode(errResp.Code)
+		// Mistral reports an oversized prompt as a plain 400; give it the
+		// code the router recognizes so fallbacks kick in.
+		if code == "" && strings.Contains(msg, "maximum context length") {
+			code = "context_length_exceeded"
+		}
+		return &provider.APIError{
+			Code:    code,
+			Message: msg,
+			Type:    errResp.Type,
+			Status:  resp.StatusCode,
+		}
+	}
+
+	return &provider.APIError{
+		Code:    http.StatusText(resp.StatusCode),
+		Message: string(body),
+		Status:  resp.StatusCode,
+	}
+}
+
+func errorCode(code any) string {
+	if code == nil {
+		return ""
+	}
+	if s, ok := code.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", code)
+}
+
+// --- Provider interface implementation ---
+
+// ChatCompletion sends a non-streaming chat completion request.
+func (p *MistralProvider) ChatCompletion(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	if err := checkNoImages(req); err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(toMistralRequest(req, false))
+	if err != nil {
+		return nil, fmt.Errorf("mistral: failed to marshal request: %w", err)
+	}
+
+	httpReq, err := p.newRequest(ctx, http.MethodPost, "/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var mResp mistralResponse
+	if err := p.doJSON(httpReq, &mResp); err != nil {
+		return nil, err
+	}
+
+	if len(mResp.Choices) == 0 {
+		return nil, fmt.Errorf("mistral: response contained no choices")
+	}
+
+	choice := mResp.Choices[0]
+	return &provider.ChatResponse{
+		ID:      mResp.ID,
+		Model:   mResp.Model,
+		Message: fromMistralMessage(choice.Message),
+		Usage:   fromMistralUsage(mResp.Usage),
+		Done:    true,
+	}, nil
+}
+
+// StreamChatCompletion sends a streaming chat completion request and returns a ChatStream.
+func (p *MistralProvider) StreamChatCompletion(ctx context.Context, req *provider.ChatRequest) (provider.ChatStream, error) {
+	if err := checkNoImages(req); err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(toMistralRequest(req, true))
+	if err != nil {
+		return nil, fmt.Errorf("mistral: failed to marshal request: %w", err)
+	}
+
+	httpReq, err := p.newRequest(ctx, http.MethodPost, "/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("mistral: stream request failed: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, p.parseErrorResponse(resp)
+	}
+
+	return &sseStream{
+		reader:        bufio.NewReader(resp.Body),
+		body:          resp.Body,
+		StreamCounter: provider.NewStreamCounter(req),
+	}, nil
+}
+
+// ListModels retrieves available models from the Mistral API.
+func (p *MistralProvider) ListModels(ctx context.Context) ([]provider.Model, error) {
+	httpReq, err := p.newRequest(ctx, http.MethodGet, "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsResp mistralModelsResponse
+	if err := p.doJSON(httpReq, &modelsResp); err != nil {
+		return nil, err
+	}
+
+	models := make([]provider.Model, len(modelsResp.Data))
+	for i, m := range modelsResp.Data {
+		models[i] = provider.Model{
+			ID:       m.ID,
+			Provider: providerName,
+			Name:     m.ID,
+		}
+	}
+	return models, nil
+}
+
+// --- SSE stream implementation ---
+
+// sseStream reads Mistral's server-sent events. Unlike OpenAI, Mistral sends
+// each tool call whole in a single chunk and always includes usage on the
+// final chunk.
+type sseStream struct {
+	reader *bufio.Reader
+	body   io.ReadCloser
+	provider.StreamCounter
+}
+
+// Next returns the next chunk, recording it for Stats.
+func (s *sseStream) Next() (*provider.ChatStreamChunk, error) {
+	chunk, err := s.next()
+	if err == nil {
+		s.Observe(chunk)
+	}
+	return chunk, err
+}
+
+func (s *sseStream) next() (*provider.ChatStreamChunk, error) {
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("mistral: stream read error: %w", err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ":") || !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return nil, io.EOF
+		}
+
+		var mResp mistralResponse
+		if err := json.Unmarshal([]byte(data), &mResp); err != nil {
+			return nil, fmt.Errorf("mistral: failed to parse stream chunk: %w", err)
+		}
+
+		chunk := &provider.ChatStreamChunk{
+			ID:    mResp.ID,
+			Model: mResp.Model,
+		}
+
+		if mResp.Usage != nil {
+			usage := fromMistralUsage(mResp.Usage)
+			chunk.Usage = &usage
+		}
+
+		if len(mResp.Choices) > 0 {
+			delta := mResp.Choices[0].Delta
+			chunk.Delta = provider.MessageDelta{
+				Role:      delta.Role,
+				Content:   string(delta.Content),
+				ToolCalls: fromMistralToolCalls(delta.ToolCalls),
+			}
+			if mResp.Choices[0].FinishReason != nil {
+				chunk.Done = true
+			}
+		}
+
+		return chunk, nil
+	}
+}
+
+func (s *sseStream) Close() error {
+	return s.body.Close()
+}
+
+// Compile-time interface compliance checks.
+var _ provider.Provider = (*MistralProvider)(nil)
+var _ provider.CapabilityReporter = (*MistralProvider)(nil)
+var _ provider.ChatStream = (*sseStream)(nil)
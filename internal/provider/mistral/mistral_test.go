@@ -0,0 +1,554 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// newTestServer creates an httptest.Server and a MistralProvider pointed at it.
+func newTestServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *MistralProvider) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	p := New("test-key", WithBaseURL(srv.URL))
+	return srv, p
+}
+
+func TestName(t *testing.T) {
+	p := New("key")
+	if p.Name() != "mistral" {
+		t.Fatalf("expected Name() = %q, got %q", "mistral", p.Name())
+	}
+}
+
+func TestChatCompletion(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("expected /chat/completions, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected auth header: %s", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content-type: %s", r.Header.Get("Content-Type"))
+		}
+
+		var req mistralRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.Model != "mistral-large-latest" {
+			t.Errorf("expected model mistral-large-latest, got %s", req.Model)
+		}
+		if len(req.Messages) != 1 || req.Messages[0].Content != "Hello" {
+			t.Errorf("unexpected messages: %+v", req.Messages)
+		}
+		if req.Stream {
+			t.Error("expected stream=false for ChatCompletion")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"cmpl-123","object":"chat.completion","model":"mistral-large-latest",
+			"choices":[{"index":0,"message":{"role":"assistant","content":"Bonjour!"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`)
+	})
+
+	resp, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "mistral-large-latest",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "Hello"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ID != "cmpl-123" {
+		t.Errorf("expected ID cmpl-123, got %s", resp.ID)
+	}
+	if resp.Message.Content != "Bonjour!" {
+		t.Errorf("expected content 'Bonjour!', got %q", resp.Message.Content)
+	}
+	if resp.Message.Role != provider.RoleAssistant {
+		t.Errorf("expected role assistant, got %s", resp.Message.Role)
+	}
+	want := provider.Usage{PromptTokens: 5, CompletionTokens: 3, TotalTokens: 8}
+	if resp.Usage != want {
+		t.Errorf("usage = %+v, want %+v", resp.Usage, want)
+	}
+	if !resp.Done {
+		t.Error("expected Done=true")
+	}
+}
+
+func TestChatCompletionContentChunks(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"cmpl-c","model":"magistral-medium-latest",
+			"choices":[{"index":0,"message":{"role":"assistant","content":[
+				{"type":"thinking","thinking":[{"type":"text","text":"hmm"}]},
+				{"type":"text","text":"The answer "},{"type":"text","text":"is 42."}]},"finish_reason":"stop"}]}`)
+	})
+
+	resp, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "magistral-medium-latest",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Message.Content != "The answer is 42." {
+		t.Errorf("content = %q, want text chunks joined", resp.Message.Content)
+	}
+}
+
+func TestChatCompletionWithToolCalls(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req mistralRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if len(req.Tools) != 1 || req.Tools[0].Function.Name != "get_weather" {
+			t.Errorf("unexpected tools: %+v", req.Tools)
+		}
+
+		// Mistral may return arguments as an object rather than a string.
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"cmpl-456","model":"mistral-large-latest",
+			"choices":[{"index":0,"message":{"role":"assistant","content":"","tool_calls":[
+				{"id":"D681PevKs","function":{"name":"get_weather","arguments":{"location":"Paris"}}}]},
+				"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+	})
+
+	resp, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "mistral-large-latest",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "What's the weather in Paris?"},
+		},
+		Tools: []provider.Tool{
+			{
+				Type: "function",
+				Function: provider.ToolFunction{
+					Name:        "get_weather",
+					Description: "Get weather for a location",
+					Parameters:  map[string]any{"type": "object"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Message.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(resp.Message.ToolCalls))
+	}
+	tc := resp.Message.ToolCalls[0]
+	if tc.ID != "D681PevKs" || tc.Type != "function" || tc.Function.Name != "get_weather" {
+		t.Errorf("unexpected tool call: %+v", tc)
+	}
+	if tc.Function.Arguments != `{"location":"Paris"}` {
+		t.Errorf("arguments = %q, want the object as a JSON string", tc.Function.Arguments)
+	}
+}
+
+func TestToolCallIDsRewritten(t *testing.T) {
+	var raw map[string]any
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&raw)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"cmpl-t","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"sunny"}}]}`)
+	})
+
+	// A conversation started on OpenAI and continued on Mistral after a
+	// fallback carries OpenAI-style tool call IDs.
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "m",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "Weather?"},
+			{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{{
+				ID:       "call_abc123def456",
+				Type:     "function",
+				Function: provider.FunctionCall{Name: "get_weather", Arguments: `{"location":"Paris"}`},
+			}}},
+			{Role: provider.RoleTool, Name: "get_weather", ToolCallID: "call_abc123def456", Content: `{"temp":21}`},
+			{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{{
+				ID:       "D681PevKs",
+				Function: provider.FunctionCall{Name: "get_weather", Arguments: `{}`},
+			}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msgs := raw["messages"].([]any)
+	call := msgs[1].(map[string]any)["tool_calls"].([]any)[0].(map[string]any)
+	callID := call["id"].(string)
+	if !validToolCallID.MatchString(callID) {
+		t.Errorf("tool call id %q is not nine alphanumerics", callID)
+	}
+	if got := msgs[2].(map[string]any)["tool_call_id"]; got != callID {
+		t.Errorf("tool result id = %v, want %q to match its call", got, callID)
+	}
+	if got := call["function"].(map[string]any)["arguments"]; got != `{"location":"Paris"}` {
+		t.Errorf("arguments = %v, want the JSON string", got)
+	}
+	kept := msgs[3].(map[string]any)["tool_calls"].([]any)[0].(map[string]any)["id"]
+	if kept != "D681PevKs" {
+		t.Errorf("valid id rewritten to %v", kept)
+	}
+}
+
+func TestChatCompletionAPIError(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"object":"error","message":"Requests rate limit exceeded","type":"rate_limited","param":null,"code":"1300"}`)
+	})
+
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "mistral-small-latest",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	apiErr, ok := err.(*provider.APIError)
+	if !ok {
+		t.Fatalf("expected *provider.APIError, got %T", err)
+	}
+	if apiErr.Status != 429 {
+		t.Errorf("expected status 429, got %d", apiErr.Status)
+	}
+	if apiErr.Code != "1300" {
+		t.Errorf("expected code 1300, got %s", apiErr.Code)
+	}
+	if apiErr.Message != "Requests rate limit exceeded" {
+		t.Errorf("unexpected message %q", apiErr.Message)
+	}
+	if provider.ClassifyError(apiErr) != provider.ErrRateLimit {
+		t.Errorf("expected ErrRateLimit classification, got %v", provider.ClassifyError(apiErr))
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   provider.ErrorCode
+	}{
+		{"auth", http.StatusUnauthorized, `{"message":"Unauthorized","request_id":"abc"}`, provider.ErrAuth},
+		{"server", http.StatusServiceUnavailable, `{"object":"error","message":"Service unavailable","type":"internal_server_error","code":null}`, provider.ErrServerError},
+		{"context window", http.StatusBadRequest,
+			`{"object":"error","message":"Prompt contains 40000 tokens and 0 draft tokens, too large for model with 32768 maximum context length","type":"invalid_request_error","param":null,"code":null}`,
+			provider.ErrContextWindow},
+		{"validation", http.StatusUnprocessableEntity,
+			`{"object":"error","message":{"detail":[{"type":"missing","loc":["body","model"],"msg":"Field required"}]},"type":"invalid_request_message_error","code":null}`,
+			provider.ErrUnknown},
+		{"non-json", http.StatusBadGateway, `<html>bad gateway</html>`, provider.ErrServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+				Model:    "m",
+				Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+			})
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if got := provider.ClassifyError(err); got != tt.want {
+				t.Errorf("ClassifyError = %v, want %v (err: %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestStreamChatCompletion(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req mistralRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("expected stream=true")
+		}
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("Accept = %q, want text/event-stream", r.Header.Get("Accept"))
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("server does not support flushing")
+		}
+
+		chunks := []string{
+			`{"id":"cmpl-s1","model":"mistral-small-latest","choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}`,
+			`{"id":"cmpl-s1","model":"mistral-small-latest","choices":[{"index":0,"delta":{"content":"Hello"},"finish_reason":null}]}`,
+			`{"id":"cmpl-s1","model":"mistral-small-latest","choices":[{"index":0,"delta":{"content":" world"},"finish_reason":null}]}`,
+			`{"id":"cmpl-s1","model":"mistral-small-latest","choices":[{"index":0,"delta":{"content":""},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+		}
+
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			flusher.Flush()
+		}
+		fmt.Fprintf(w, "data: [DONE]\n\n")
+		flusher.Flush()
+	})
+
+	stream, err := p.StreamChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "mistral-small-latest",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	var chunks []*provider.ChatStreamChunk
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d", len(chunks))
+	}
+	if chunks[0].Delta.Role != provider.RoleAssistant {
+		t.Errorf("expected assistant role in first chunk, got %q", chunks[0].Delta.Role)
+	}
+	if chunks[1].Delta.Content != "Hello" || chunks[2].Delta.Content != " world" {
+		t.Errorf("unexpected content: %q %q", chunks[1].Delta.Content, chunks[2].Delta.Content)
+	}
+	if !chunks[3].Done {
+		t.Error("expected Done=true on final chunk")
+	}
+	if chunks[3].Usage == nil || chunks[3].Usage.TotalTokens != 7 {
+		t.Errorf("expected 7 total tokens on final chunk, got %+v", chunks[3].Usage)
+	}
+}
+
+func TestStreamChatCompletionToolCalls(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"cmpl-s2","model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"","tool_calls":[{"id":"abcDEF123","function":{"name":"search","arguments":"{\"q\":\"go\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":9,"completion_tokens":4,"total_tokens":13}}`+"\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	stream, err := p.StreamChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "m",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Search"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	chunk, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if len(chunk.Delta.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %+v", chunk.Delta.ToolCalls)
+	}
+	tc := chunk.Delta.ToolCalls[0]
+	if tc.ID != "abcDEF123" || tc.Function.Name != "search" || tc.Function.Arguments != `{"q":"go"}` {
+		t.Errorf("unexpected tool call: %+v", tc)
+	}
+	if !chunk.Done {
+		t.Error("expected Done=true")
+	}
+	if _, err := stream.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after [DONE], got %v", err)
+	}
+}
+
+func TestStreamChatCompletionHTTPError(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message":"Unauthorized","request_id":"r1"}`)
+	})
+
+	_, err := p.StreamChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "m",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	apiErr, ok := err.(*provider.APIError)
+	if !ok {
+		t.Fatalf("expected *provider.APIError, got %T", err)
+	}
+	if apiErr.Status != 401 || apiErr.Message != "Unauthorized" {
+		t.Errorf("unexpected error: %+v", apiErr)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+		if r.URL.Path != "/models" {
+			t.Errorf("expected /models, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"mistral-large-latest","object":"model"},{"id":"codestral-latest","object":"model"}]}`)
+	})
+
+	models, err := p.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("expected 2 models, got %d", len(models))
+	}
+	for _, m := range models {
+		if m.Provider != "mistral" {
+			t.Errorf("expected provider 'mistral', got %q", m.Provider)
+		}
+	}
+	if models[1].ID != "codestral-latest" {
+		t.Errorf("expected second model codestral-latest, got %s", models[1].ID)
+	}
+}
+
+func TestSamplingParamsTransmitted(t *testing.T) {
+	var raw map[string]any
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&raw)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"cmpl-p","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+
+	temp, seed, maxTok, pres := 0.3, 42, 100, 0.5
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:           "m",
+		Messages:        []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+		Temperature:     &temp,
+		Seed:            &seed,
+		MaxTokens:       &maxTok,
+		PresencePenalty: &pres,
+		Stop:            []string{"\n"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// JSON numbers decode as float64.
+	if raw["random_seed"] != float64(42) {
+		t.Errorf("random_seed = %v, want 42", raw["random_seed"])
+	}
+	if _, ok := raw["seed"]; ok {
+		t.Error("seed should be sent as random_seed only")
+	}
+	if raw["temperature"] != 0.3 || raw["max_tokens"] != float64(100) || raw["presence_penalty"] != 0.5 {
+		t.Errorf("unexpected sampling params: %v", raw)
+	}
+	if _, ok := raw["frequency_penalty"]; ok {
+		t.Error("frequency_penalty should be omitted when nil")
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	var got http.Header
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		fmt.Fprint(w, `{"data":[]}`)
+	})
+	WithDefaultHeaders(map[string]string{"X-Request-Id": "req-1"})(p)
+
+	if _, err := p.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if got.Get("X-Request-Id") != "req-1" {
+		t.Errorf("custom header missing: %v", got)
+	}
+	if got.Get("Authorization") != "Bearer test-key" {
+		t.Errorf("Authorization = %q, want bearer kept alongside custom headers", got.Get("Authorization"))
+	}
+}
+
+func TestWithBaseURL(t *testing.T) {
+	p := New("key", WithBaseURL("https://eu.mistral.example/v1/"))
+	if p.baseURL != "https://eu.mistral.example/v1" {
+		t.Errorf("expected trailing slash stripped, got %q", p.baseURL)
+	}
+}
+
+func TestStreamStats_Cancelled(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, text := range []string{"Once", " upon", " a time"} {
+			fmt.Fprintf(w, "data: {\"id\":\"c1\",\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", text)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := p.StreamChatCompletion(ctx, &provider.ChatRequest{
+		Model:    "m",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Tell me a story"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := stream.Next(); err != nil {
+			t.Fatalf("Next %d: %v", i, err)
+		}
+	}
+	cancel()
+	if _, err := stream.Next(); err == nil {
+		t.Fatal("expected an error after cancellation")
+	}
+	stream.Close()
+
+	st := stream.Stats()
+	want := provider.StreamStats{
+		Chunks: 3,
+		Usage:  provider.Usage{PromptTokens: 4, CompletionTokens: 4, TotalTokens: 8, Estimated: true},
+	}
+	if st != want {
+		t.Errorf("stats = %+v, want %+v", st, want)
+	}
+}
+
+func TestImagesUnsupported(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a request with images should not reach the server")
+	})
+	req := &provider.ChatRequest{
+		Model:    "mistral-large-latest",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Look.", Images: [][]byte{[]byte("\x89PNG\r\n\x1a\n")}}},
+	}
+	if _, err := p.ChatCompletion(context.Background(), req); !errors.Is(err, provider.ErrImagesUnsupported) {
+		t.Errorf("ChatCompletion = %v, want ErrImagesUnsupported", err)
+	}
+	if _, err := p.StreamChatCompletion(context.Background(), req); !errors.Is(err, provider.ErrImagesUnsupported) {
+		t.Errorf("StreamChatCompletion = %v, want ErrImagesUnsupported", err)
+	}
+}
@@ -0,0 +1,266 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/fileutil"
+)
+
+// WithModelCache makes ListAllModels reuse each provider's model list for
+// ttl after fetching it, instead of asking every provider on every call.
+// Providers that fail to list are not cached. ttl <= 0 disables the cache.
+func WithModelCache(ttl time.Duration) RouterOption {
+	return func(r *Router) {
+		if ttl <= 0 {
+			r.models = nil
+			return
+		}
+		if r.models == nil {
+			r.models = &modelCache{now: time.Now, entries: make(map[string]modelCacheEntry)}
+		}
+		r.models.ttl = ttl
+	}
+}
+
+// WithModelCacheFile keeps the model cache in the JSON file at path, so it
+// outlives the process: a later router with the same file reuses lists that
+// are still within the TTL. It has no effect without WithModelCache, which
+// may come before or after it.
+func WithModelCacheFile(path string) RouterOption {
+	return func(r *Router) {
+		r.modelCachePath = path
+	}
+}
+
+// WithModelDetails makes ListAllModels ask providers that implement
+// ModelDescriber for each model's metadata, e.g. its context length. This
+// costs a request per model, so it is off by default.
+func WithModelDetails() RouterOption {
+	return func(r *Router) {
+		r.modelDetails = true
+	}
+}
+
+// modelCache holds model lists by provider config name.
+type modelCache struct {
+	ttl  time.Duration
+	path string // "" = in-process only
+	now  func() time.Time
+
+	mu      sync.Mutex
+	loaded  bool
+	entries map[string]modelCacheEntry
+}
+
+type modelCacheEntry struct {
+	BaseURL   string    `json:"base_url"` // a provider renamed or repointed is a miss
+	FetchedAt time.Time `json:"fetched_at"`
+	Models    []Model   `json:"models"`
+	Detailed  bool      `json:"detailed,omitempty"` // Models carry all the metadata the provider has
+}
+
+// get returns the cached models for provider name at baseURL while they
+// are fresh. With detailed, a list cached without metadata is a miss.
+func (c *modelCache) get(name, baseURL string, detailed bool) ([]Model, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	e, ok := c.entries[name]
+	if !ok || e.BaseURL != baseURL || c.now().Sub(e.FetchedAt) >= c.ttl || (detailed && !e.Detailed) {
+		return nil, false
+	}
+	return e.Models, true
+}
+
+// put caches models for provider name at baseURL.
+func (c *modelCache) put(name, baseURL string, models []Model, detailed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	c.entries[name] = modelCacheEntry{BaseURL: baseURL, FetchedAt: c.now(), Models: models, Detailed: detailed}
+}
+
+// load reads the cache file once. A missing or unreadable file is an empty
+// cache; the cache only saves work.
+func (c *modelCache) load() {
+	if c.loaded || c.path == "" {
+		return
+	}
+	c.loaded = true
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	var entries map[string]modelCacheEntry
+	if json.Unmarshal(data, &entries) == nil {
+		for name, e := range entries {
+			c.entries[name] = e
+		}
+	}
+}
+
+// save writes the cache file, if there is one.
+func (c *modelCache) save() error {
+	if c.path == "" {
+		return nil
+	}
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return fileutil.AtomicWrite(c.path, append(data, '\n'), 0o644)
+}
+
+// maxModelListers bounds how many providers ListAllModels asks at once.
+const maxModelListers = 8
+
+// ModelListError reports the providers that failed to list their models.
+// ListAllModels returns it alongside the models of the providers that did
+// list, so one unreachable provider does not hide the rest.
+type ModelListError struct {
+	Errs   map[string]error // by provider config name
+	Listed int              // providers that did list, possibly no models
+}
+
+func (e *ModelListError) Error() string {
+	names := e.providers()
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + e.Errs[name].Error()
+	}
+	return "provider: list models: " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the per-provider errors in provider name order.
+func (e *ModelListError) Unwrap() []error {
+	names := e.providers()
+	errs := make([]error, len(names))
+	for i, name := range names {
+		errs[i] = e.Errs[name]
+	}
+	return errs
+}
+
+func (e *ModelListError) providers() []string {
+	names := make([]string, 0, len(e.Errs))
+	for name := range e.Errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ListAllModels returns models from all configured providers, sorted by
+// provider and model ID. Providers are asked concurrently. When some fail,
+// the others' models are returned with a *ModelListError naming the
+// failures. With WithModelCache, a provider listed within the TTL is not
+// asked again; with WithModelDetails, models are described as well.
+func (r *Router) ListAllModels(ctx context.Context) ([]Model, error) {
+	return r.listAllModels(ctx, false)
+}
+
+// RefreshModels is ListAllModels without reading the model cache. The
+// fresh lists replace the cached ones.
+func (r *Router) RefreshModels(ctx context.Context) ([]Model, error) {
+	return r.listAllModels(ctx, true)
+}
+
+func (r *Router) listAllModels(ctx context.Context, refresh bool) ([]Model, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type listing struct {
+		models  []Model
+		err     error
+		fetched bool
+	}
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	listings := make([]listing, len(names))
+
+	cache := r.models
+	sem := make(chan struct{}, maxModelListers)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		baseURL := r.config.Providers[name].BaseURL
+		if cache != nil && !refresh {
+			if models, ok := cache.get(name, baseURL, r.modelDetails); ok {
+				r.log.Debug("models cached", "provider", name, "count", len(models))
+				listings[i].models = models
+				continue
+			}
+		}
+		wg.Add(1)
+		go func(l *listing, name, baseURL string, p Provider) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			models, err := p.ListModels(ctx)
+			if err != nil {
+				r.log.Warn("list models failed", "provider", name, "elapsed", time.Since(start), "err", err)
+				l.err = err
+				return
+			}
+			r.log.Debug("models listed", "provider", name, "count", len(models), "elapsed", time.Since(start))
+			describer, describes := p.(ModelDescriber)
+			if describes && r.modelDetails {
+				for i, m := range models {
+					if d, err := describer.DescribeModel(ctx, m); err == nil {
+						models[i] = d // best effort; the model is listed either way
+					}
+				}
+			}
+			if cache != nil {
+				cache.put(name, baseURL, models, r.modelDetails || !describes)
+				l.fetched = true
+			}
+			l.models = models
+		}(&listings[i], name, baseURL, r.providers[name])
+	}
+	wg.Wait()
+
+	var all []Model
+	var failed map[string]error
+	fetched := false
+	for i, l := range listings {
+		if l.err != nil {
+			if failed == nil {
+				failed = make(map[string]error)
+			}
+			failed[names[i]] = l.err
+			continue
+		}
+		fetched = fetched || l.fetched
+		all = append(all, l.models...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Provider != all[j].Provider {
+			return all[i].Provider < all[j].Provider
+		}
+		if all[i].ID != all[j].ID {
+			return all[i].ID < all[j].ID
+		}
+		return all[i].Name < all[j].Name
+	})
+
+	if fetched {
+		_ = cache.save() // best effort; the listing itself succeeded
+	}
+	if failed != nil {
+		return all, &ModelListError{Errs: failed, Listed: len(names) - len(failed)}
+	}
+	return all, nil
+}
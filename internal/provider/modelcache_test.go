@@ -0,0 +1,290 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// countingLister returns a mockProvider whose ListModels counts its calls.
+func countingLister(name string, calls *int, err error) *mockProvider {
+	return &mockProvider{
+		name: name,
+		listModelsFn: func(context.Context) ([]Model, error) {
+			*calls++
+			if err != nil {
+				return nil, err
+			}
+			return []Model{{ID: name + "-model", Provider: name}}, nil
+		},
+	}
+}
+
+func newCacheTestRouter(t *testing.T, primary, fallback *mockProvider, opts ...RouterOption) *Router {
+	t.Helper()
+	factories := map[string]ProviderFactory{
+		"mock-primary":  func(ProviderConfig) (Provider, error) { return primary, nil },
+		"mock-fallback": func(ProviderConfig) (Provider, error) { return fallback, nil },
+	}
+	r, err := NewRouter(routerTestConfig(), factories, opts...)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r
+}
+
+func modelIDs(models []Model) []string {
+	ids := make([]string, len(models))
+	for i, m := range models {
+		ids[i] = m.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestListAllModels_CacheWithinTTL(t *testing.T) {
+	var primaryCalls, fallbackCalls int
+	r := newCacheTestRouter(t,
+		countingLister("primary", &primaryCalls, nil),
+		countingLister("fallback", &fallbackCalls, nil),
+		WithModelCache(time.Minute))
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	r.models.now = func() time.Time { return now }
+
+	first, err := r.ListAllModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllModels: %v", err)
+	}
+	now = now.Add(59 * time.Second)
+	second, err := r.ListAllModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllModels: %v", err)
+	}
+	if primaryCalls != 1 || fallbackCalls != 1 {
+		t.Errorf("ListModels calls = %d, %d within the TTL, want 1 each", primaryCalls, fallbackCalls)
+	}
+	if got, want := modelIDs(second), modelIDs(first); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("cached models = %v, want %v", got, want)
+	}
+
+	now = now.Add(time.Second) // TTL reached
+	if _, err := r.ListAllModels(context.Background()); err != nil {
+		t.Fatalf("ListAllModels: %v", err)
+	}
+	if primaryCalls != 2 || fallbackCalls != 2 {
+		t.Errorf("ListModels calls = %d, %d after the TTL, want 2 each", primaryCalls, fallbackCalls)
+	}
+
+	if _, err := r.RefreshModels(context.Background()); err != nil {
+		t.Fatalf("RefreshModels: %v", err)
+	}
+	if primaryCalls != 3 || fallbackCalls != 3 {
+		t.Errorf("ListModels calls = %d, %d after RefreshModels, want 3 each", primaryCalls, fallbackCalls)
+	}
+}
+
+func TestListAllModels_FailuresNotCached(t *testing.T) {
+	var primaryCalls, fallbackCalls int
+	r := newCacheTestRouter(t,
+		countingLister("primary", &primaryCalls, nil),
+		countingLister("fallback", &fallbackCalls, errors.New("connection refused")),
+		WithModelCache(time.Hour))
+
+	for i := 0; i < 2; i++ {
+		models, err := r.ListAllModels(context.Background())
+		var listErr *ModelListError
+		if !errors.As(err, &listErr) {
+			t.Fatalf("ListAllModels error = %v, want *ModelListError", err)
+		}
+		if len(listErr.Errs) != 1 || listErr.Errs["fallback"] == nil {
+			t.Errorf("failed providers = %v, want just fallback", listErr.Errs)
+		}
+		if listErr.Listed != 1 {
+			t.Errorf("Listed = %d, want 1", listErr.Listed)
+		}
+		if ids := modelIDs(models); len(ids) != 1 || ids[0] != "primary-model" {
+			t.Errorf("models = %v, want just primary-model", ids)
+		}
+	}
+	if primaryCalls != 1 || fallbackCalls != 2 {
+		t.Errorf("ListModels calls = %d, %d, want the failing provider retried and the other cached", primaryCalls, fallbackCalls)
+	}
+}
+
+func TestListAllModels_NoCache(t *testing.T) {
+	var primaryCalls, fallbackCalls int
+	r := newCacheTestRouter(t,
+		countingLister("primary", &primaryCalls, nil),
+		countingLister("fallback", &fallbackCalls, nil))
+	for i := 0; i < 2; i++ {
+		if _, err := r.ListAllModels(context.Background()); err != nil {
+			t.Fatalf("ListAllModels: %v", err)
+		}
+	}
+	if primaryCalls != 2 || fallbackCalls != 2 {
+		t.Errorf("ListModels calls = %d, %d without a cache, want 2 each", primaryCalls, fallbackCalls)
+	}
+}
+
+func TestListAllModels_CacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+	var primaryCalls, fallbackCalls int
+	primary := countingLister("primary", &primaryCalls, nil)
+	fallback := countingLister("fallback", &fallbackCalls, nil)
+
+	// The file option may come before the cache option.
+	r := newCacheTestRouter(t, primary, fallback, WithModelCacheFile(path), WithModelCache(time.Hour))
+	if _, err := r.ListAllModels(context.Background()); err != nil {
+		t.Fatalf("ListAllModels: %v", err)
+	}
+
+	// A second router, as in a later "et models", reads the file.
+	r2 := newCacheTestRouter(t, primary, fallback, WithModelCache(time.Hour), WithModelCacheFile(path))
+	models, err := r2.ListAllModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllModels: %v", err)
+	}
+	if primaryCalls != 1 || fallbackCalls != 1 {
+		t.Errorf("ListModels calls = %d, %d, want the second router served from the file", primaryCalls, fallbackCalls)
+	}
+	if ids := modelIDs(models); len(ids) != 2 {
+		t.Errorf("models from file = %v, want both providers'", ids)
+	}
+
+	// A provider whose base_url changed is listed again.
+	factories := map[string]ProviderFactory{
+		"mock-primary":  func(ProviderConfig) (Provider, error) { return primary, nil },
+		"mock-fallback": func(ProviderConfig) (Provider, error) { return fallback, nil },
+	}
+	cfg := routerTestConfig()
+	pc := cfg.Providers["primary"]
+	pc.BaseURL = "http://elsewhere"
+	cfg.Providers["primary"] = pc
+	r3, err := NewRouter(cfg, factories, WithModelCache(time.Hour), WithModelCacheFile(path))
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	if _, err := r3.ListAllModels(context.Background()); err != nil {
+		t.Fatalf("ListAllModels: %v", err)
+	}
+	if primaryCalls != 2 || fallbackCalls != 1 {
+		t.Errorf("ListModels calls = %d, %d, want only the repointed provider listed again", primaryCalls, fallbackCalls)
+	}
+}
+
+// sleepyLister returns a mockProvider that takes delay to list models.
+func sleepyLister(name string, delay time.Duration, ids ...string) *mockProvider {
+	return &mockProvider{
+		name: name,
+		listModelsFn: func(ctx context.Context) ([]Model, error) {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			models := make([]Model, len(ids))
+			for i, id := range ids {
+				models[i] = Model{ID: id, Provider: name}
+			}
+			return models, nil
+		},
+	}
+}
+
+func TestListAllModels_Concurrent(t *testing.T) {
+	r := newCacheTestRouter(t,
+		sleepyLister("primary", 200*time.Millisecond, "p-b", "p-a"),
+		sleepyLister("fallback", 300*time.Millisecond, "f-b", "f-a"))
+
+	start := time.Now()
+	models, err := r.ListAllModels(context.Background())
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("ListAllModels: %v", err)
+	}
+	// Sequential listing would take the sum, 500ms.
+	if elapsed >= 450*time.Millisecond {
+		t.Errorf("ListAllModels took %v, want about the slowest provider's 300ms", elapsed)
+	}
+
+	var got []string
+	for _, m := range models {
+		got = append(got, m.Provider+"/"+m.ID)
+	}
+	want := []string{"fallback/f-a", "fallback/f-b", "primary/p-a", "primary/p-b"}
+	if len(got) != len(want) {
+		t.Fatalf("models = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("models = %v, want %v in order", got, want)
+		}
+	}
+}
+
+// describingLister is a mockProvider that also implements ModelDescriber.
+type describingLister struct {
+	*mockProvider
+	describes *int
+}
+
+func (d describingLister) DescribeModel(_ context.Context, m Model) (Model, error) {
+	*d.describes++
+	m.ContextLength = 8192
+	m.Capabilities = []string{CapabilityTools}
+	return m, nil
+}
+
+func TestListAllModels_Details(t *testing.T) {
+	var listCalls, describes, fallbackCalls int
+	primary := describingLister{countingLister("primary", &listCalls, nil), &describes}
+	factories := map[string]ProviderFactory{
+		"mock-primary":  func(ProviderConfig) (Provider, error) { return primary, nil },
+		"mock-fallback": func(ProviderConfig) (Provider, error) { return countingLister("fallback", &fallbackCalls, nil), nil },
+	}
+	cache := WithModelCache(time.Hour)
+
+	plain, err := NewRouter(routerTestConfig(), factories, cache)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	models, err := plain.ListAllModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllModels: %v", err)
+	}
+	if describes != 0 || models[1].ContextLength != 0 {
+		t.Errorf("described %d models without WithModelDetails", describes)
+	}
+
+	detailed, err := NewRouter(routerTestConfig(), factories, cache, WithModelDetails())
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	detailed.models = plain.models // share the cache, as the file would
+	models, err = detailed.ListAllModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllModels: %v", err)
+	}
+	if describes != 1 || listCalls != 2 {
+		t.Errorf("describes = %d, lists = %d; want the undetailed cache entry listed and described again", describes, listCalls)
+	}
+	if fallbackCalls != 1 {
+		t.Errorf("fallback listed %d times, want its cached list reused", fallbackCalls)
+	}
+	for _, m := range models {
+		if m.Provider == "primary" && (m.ContextLength != 8192 || !m.HasCapability(CapabilityTools)) {
+			t.Errorf("primary model = %+v, want described", m)
+		}
+	}
+
+	// Detailed lists serve later plain listings too.
+	if _, err := plain.ListAllModels(context.Background()); err != nil {
+		t.Fatalf("ListAllModels: %v", err)
+	}
+	if listCalls != 2 {
+		t.Errorf("lists = %d, want the detailed entry reused", listCalls)
+	}
+}
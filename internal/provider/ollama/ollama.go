@@ -11,7 +11,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/meganerd/electrictown/internal/provider"
 )
@@ -22,6 +25,11 @@ type OllamaProvider struct {
 	apiKey     string
 	authType   string // "bearer" (default), "basic", or "none"
 	httpClient *http.Client
+	keepAlive  *time.Duration    // nil = server default; negative = keep loaded indefinitely
+	headers    map[string]string // extra headers on every request
+
+	visionMu sync.Mutex
+	vision   map[string]bool // model -> accepts images, from /api/show
 }
 
 // New creates a new OllamaProvider. The baseURL should be the Ollama server
@@ -51,14 +59,46 @@ func WithAuthType(authType string) OllamaOption {
 	}
 }
 
+// WithKeepAlive sets how long Ollama keeps a model loaded after each chat
+// request. A negative duration keeps it loaded indefinitely and zero unloads
+// it immediately. Without this option the server default (5m) applies.
+func WithKeepAlive(d time.Duration) OllamaOption {
+	return func(p *OllamaProvider) {
+		p.keepAlive = &d
+	}
+}
+
+// WithDefaultHeaders adds headers to every request; see provider.ProviderConfig.Headers.
+func WithDefaultHeaders(headers map[string]string) OllamaOption {
+	return func(p *OllamaProvider) {
+		p.headers = headers
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, e.g. to set a timeout.
+func WithHTTPClient(c *http.Client) OllamaOption {
+	return func(p *OllamaProvider) {
+		p.httpClient = c
+	}
+}
+
 // Name returns "ollama".
 func (p *OllamaProvider) Name() string {
 	return "ollama"
 }
 
+// Capabilities reports every optional feature. Images are further checked
+// against the model's vision capability when sent.
+func (p *OllamaProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Tools: true, Images: true, Seed: true, Penalties: true}
+}
+
 // ChatCompletion sends a non-streaming chat request to Ollama and returns
 // the full response.
 func (p *OllamaProvider) ChatCompletion(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	if err := p.checkImages(ctx, req); err != nil {
+		return nil, err
+	}
 	ollamaReq := p.buildChatRequest(req, false)
 
 	body, err := json.Marshal(ollamaReq)
@@ -93,6 +133,9 @@ func (p *OllamaProvider) ChatCompletion(ctx context.Context, req *provider.ChatR
 // StreamChatCompletion sends a streaming chat request to Ollama and returns
 // a ChatStream. Ollama uses newline-delimited JSON (NDJSON), not SSE.
 func (p *OllamaProvider) StreamChatCompletion(ctx context.Context, req *provider.ChatRequest) (provider.ChatStream, error) {
+	if err := p.checkImages(ctx, req); err != nil {
+		return nil, err
+	}
 	ollamaReq := p.buildChatRequest(req, true)
 
 	body, err := json.Marshal(ollamaReq)
@@ -117,9 +160,10 @@ func (p *OllamaProvider) StreamChatCompletion(ctx context.Context, req *provider
 	}
 
 	return &ollamaStream{
-		scanner: bufio.NewScanner(httpResp.Body),
-		body:    httpResp.Body,
-		done:    false,
+		scanner:       bufio.NewScanner(httpResp.Body),
+		body:          httpResp.Body,
+		done:          false,
+		StreamCounter: provider.NewStreamCounter(req),
 	}, nil
 }
 
@@ -157,20 +201,221 @@ func (p *OllamaProvider) ListModels(ctx context.Context) ([]provider.Model, erro
 	return models, nil
 }
 
+// ModelDetails describes a model installed on an Ollama server, as reported
+// by /api/show.
+type ModelDetails struct {
+	Name              string
+	Family            string   // e.g. "llama"
+	ParameterSize     string   // e.g. "8.0B"
+	QuantizationLevel string   // e.g. "Q4_K_M"
+	ContextLength     int      // trained context window in tokens; 0 = not reported
+	Capabilities      []string // e.g. "completion", "tools", "vision"
+	Template          string   // prompt template
+}
+
+// ShowModel calls /api/show and returns the details of the named model.
+func (p *OllamaProvider) ShowModel(ctx context.Context, name string) (*ModelDetails, error) {
+	body, err := json.Marshal(ollamaShowRequest{Model: name})
+	if err != nil {
+		return nil, fmt.Errorf("ollama: marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/show", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("ollama: create request: %w", err)
+	}
+	p.setHeaders(httpReq)
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, p.parseError(httpResp)
+	}
+
+	var showResp ollamaShowResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&showResp); err != nil {
+		return nil, fmt.Errorf("ollama: decode response: %w", err)
+	}
+	return &ModelDetails{
+		Name:              name,
+		Family:            showResp.Details.Family,
+		ParameterSize:     showResp.Details.ParameterSize,
+		QuantizationLevel: showResp.Details.QuantizationLevel,
+		ContextLength:     showResp.contextLength(),
+		Capabilities:      showResp.Capabilities,
+		Template:          showResp.Template,
+	}, nil
+}
+
+// DescribeModel returns m with the context length and capabilities from
+// ShowModel. It implements provider.ModelDescriber.
+func (p *OllamaProvider) DescribeModel(ctx context.Context, m provider.Model) (provider.Model, error) {
+	d, err := p.ShowModel(ctx, m.ID)
+	if err != nil {
+		return m, err
+	}
+	m.ContextLength = d.ContextLength
+	m.Capabilities = d.Capabilities
+	return m, nil
+}
+
+// RunningModel is a model currently loaded into memory on an Ollama server.
+type RunningModel struct {
+	Name      string
+	Size      int64     // bytes resident in total
+	SizeVRAM  int64     // bytes resident in GPU memory
+	ExpiresAt time.Time // when the model is unloaded if left idle
+}
+
+// RunningModels calls /api/ps and returns the models that are loaded and
+// warm on the server. Models not listed are cold and must be loaded first.
+func (p *OllamaProvider) RunningModels(ctx context.Context) ([]RunningModel, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/ps", nil)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: create request: %w", err)
+	}
+	p.setHeaders(httpReq)
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, p.parseError(httpResp)
+	}
+
+	var psResp ollamaPsResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&psResp); err != nil {
+		return nil, fmt.Errorf("ollama: decode response: %w", err)
+	}
+
+	models := make([]RunningModel, len(psResp.Models))
+	for i, m := range psResp.Models {
+		models[i] = RunningModel{
+			Name:      m.Name,
+			Size:      m.Size,
+			SizeVRAM:  m.SizeVRAM,
+			ExpiresAt: m.ExpiresAt,
+		}
+	}
+	return models, nil
+}
+
+// Pull downloads model onto the server via /api/pull, calling progress (if
+// non-nil) for every NDJSON status line as it streams in. completed and total
+// are byte counts for the layer being downloaded and are 0 for status-only
+// lines. Pulling a model that is already present is cheap: Ollama verifies
+// the layers and reports success without downloading them again.
+func (p *OllamaProvider) Pull(ctx context.Context, model string, progress func(status string, completed, total int64)) error {
+	body, err := json.Marshal(ollamaPullRequest{Model: model, Stream: true})
+	if err != nil {
+		return fmt.Errorf("ollama: marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/pull", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ollama: create request: %w", err)
+	}
+	p.setHeaders(httpReq)
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("ollama: send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return p.parseError(httpResp)
+	}
+
+	scanner := bufio.NewScanner(httpResp.Body)
+	status := ""
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var msg ollamaPullProgress
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return fmt.Errorf("ollama: decode pull progress: %w", err)
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("ollama: pull %s: %s", model, msg.Error)
+		}
+		status = msg.Status
+		if progress != nil {
+			progress(msg.Status, msg.Completed, msg.Total)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ollama: read pull progress: %w", err)
+	}
+	if status != "success" {
+		return fmt.Errorf("ollama: pull %s ended without success (last status %q)", model, status)
+	}
+	return nil
+}
+
 // --- Internal helpers ---
 
 func (p *OllamaProvider) setHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
-	if p.apiKey == "" || p.authType == "none" {
-		return
+	if p.apiKey != "" && p.authType != "none" {
+		switch p.authType {
+		case "basic":
+			encoded := base64.StdEncoding.EncodeToString([]byte(p.apiKey))
+			req.Header.Set("Authorization", "Basic "+encoded)
+		default: // "bearer" or unset
+			req.Header.Set("Authorization", "Bearer "+p.apiKey)
+		}
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+}
+
+// checkImages refuses a request with images for a model whose /api/show
+// capabilities lack "vision", rather than letting the server silently drop
+// them. A model whose capabilities cannot be read is given the benefit of
+// the doubt. Answers are kept per model.
+func (p *OllamaProvider) checkImages(ctx context.Context, req *provider.ChatRequest) error {
+	hasImages := false
+	for _, m := range req.Messages {
+		if len(m.Images) > 0 {
+			hasImages = true
+			break
+		}
+	}
+	if !hasImages {
+		return nil
+	}
+
+	p.visionMu.Lock()
+	vision, known := p.vision[req.Model]
+	p.visionMu.Unlock()
+	if !known {
+		d, err := p.ShowModel(ctx, req.Model)
+		if err != nil || len(d.Capabilities) == 0 {
+			return nil
+		}
+		vision = slices.Contains(d.Capabilities, provider.CapabilityVision)
+		p.visionMu.Lock()
+		if p.vision == nil {
+			p.vision = make(map[string]bool)
+		}
+		p.vision[req.Model] = vision
+		p.visionMu.Unlock()
 	}
-	switch p.authType {
-	case "basic":
-		encoded := base64.StdEncoding.EncodeToString([]byte(p.apiKey))
-		req.Header.Set("Authorization", "Basic "+encoded)
-	default: // "bearer" or unset
-		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	if !vision {
+		return fmt.Errorf("ollama: %s: %w", req.Model, provider.ErrImagesUnsupported)
 	}
+	return nil
 }
 
 func (p *OllamaProvider) buildChatRequest(req *provider.ChatRequest, stream bool) ollamaChatRequest {
@@ -180,6 +425,13 @@ func (p *OllamaProvider) buildChatRequest(req *provider.ChatRequest, stream bool
 			Role:    string(m.Role),
 			Content: m.Content,
 		}
+		for _, img := range m.Images {
+			msg.Images = append(msg.Images, base64.StdEncoding.EncodeToString(img))
+		}
+		if m.Role == provider.RoleTool {
+			// Ollama keys tool results by function name, not call ID.
+			msg.ToolName = provider.ToolResultName(req.Messages, i)
+		}
 		if len(m.ToolCalls) > 0 {
 			msg.ToolCalls = make([]ollamaToolCall, len(m.ToolCalls))
 			for j, tc := range m.ToolCalls {
@@ -201,6 +453,13 @@ func (p *OllamaProvider) buildChatRequest(req *provider.ChatRequest, stream bool
 		Messages: messages,
 		Stream:   stream,
 	}
+	if p.keepAlive != nil {
+		if *p.keepAlive < 0 {
+			ollamaReq.KeepAlive = -1
+		} else {
+			ollamaReq.KeepAlive = p.keepAlive.String()
+		}
+	}
 
 	// Map optional parameters to Ollama's options object.
 	options := make(map[string]interface{})
@@ -216,6 +475,15 @@ func (p *OllamaProvider) buildChatRequest(req *provider.ChatRequest, stream bool
 	if len(req.Stop) > 0 {
 		options["stop"] = req.Stop
 	}
+	if req.Seed != nil {
+		options["seed"] = *req.Seed
+	}
+	if req.FrequencyPenalty != nil {
+		options["frequency_penalty"] = *req.FrequencyPenalty
+	}
+	if req.PresencePenalty != nil {
+		options["repeat_penalty"] = *req.PresencePenalty
+	}
 	if len(options) > 0 {
 		ollamaReq.Options = options
 	}
@@ -249,7 +517,7 @@ func (p *OllamaProvider) convertResponse(resp *ollamaChatResponse) *provider.Cha
 		for i, tc := range resp.Message.ToolCalls {
 			argsJSON, _ := json.Marshal(tc.Function.Arguments)
 			msg.ToolCalls[i] = provider.ToolCall{
-				ID:   fmt.Sprintf("call_%d", i),
+				ID:   provider.SyntheticToolCallID(i, tc.Function.Name),
 				Type: "function",
 				Function: provider.FunctionCall{
 					Name:      tc.Function.Name,
@@ -299,12 +567,17 @@ type ollamaChatRequest struct {
 	Stream   bool                   `json:"stream"`
 	Options  map[string]interface{} `json:"options,omitempty"`
 	Tools    []ollamaTool           `json:"tools,omitempty"`
+
+	// KeepAlive is -1 (keep loaded) or a Go duration string such as "30m0s".
+	KeepAlive interface{} `json:"keep_alive,omitempty"`
 }
 
 type ollamaMessage struct {
 	Role      string           `json:"role"`
 	Content   string           `json:"content"`
 	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"` // tool result messages only
+	Images    []string         `json:"images,omitempty"`    // base64, vision models only
 }
 
 type ollamaToolCall struct {
@@ -347,15 +620,98 @@ type ollamaModelInfo struct {
 	Size  int64  `json:"size"`
 }
 
+type ollamaPullRequest struct {
+	Model  string `json:"model"`
+	Stream bool   `json:"stream"`
+}
+
+type ollamaPullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ollamaShowRequest struct {
+	Model string `json:"model"`
+}
+
+type ollamaShowResponse struct {
+	Template     string                     `json:"template"`
+	Details      ollamaModelDetails         `json:"details"`
+	Capabilities []string                   `json:"capabilities"`
+	ModelInfo    map[string]json.RawMessage `json:"model_info"`
+}
+
+type ollamaModelDetails struct {
+	Family            string `json:"family"`
+	ParameterSize     string `json:"parameter_size"`
+	QuantizationLevel string `json:"quantization_level"`
+}
+
+// contextLength returns the trained context length from model_info, which
+// keys it by architecture, e.g. "llama.context_length". 0 = not reported.
+func (r ollamaShowResponse) contextLength() int {
+	var arch string
+	if raw, ok := r.ModelInfo["general.architecture"]; ok {
+		_ = json.Unmarshal(raw, &arch)
+	}
+	if n := jsonInt(r.ModelInfo[arch+".context_length"]); n > 0 {
+		return n
+	}
+	// Fall back to any context length when the architecture key is unusual.
+	for key, raw := range r.ModelInfo {
+		if strings.HasSuffix(key, ".context_length") {
+			if n := jsonInt(raw); n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// jsonInt decodes raw as a non-negative integer; anything else is 0.
+func jsonInt(raw json.RawMessage) int {
+	var n int
+	if len(raw) == 0 || json.Unmarshal(raw, &n) != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+type ollamaPsResponse struct {
+	Models []ollamaRunningModel `json:"models"`
+}
+
+type ollamaRunningModel struct {
+	Name      string    `json:"name"`
+	Model     string    `json:"model"`
+	Size      int64     `json:"size"`
+	SizeVRAM  int64     `json:"size_vram"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 // --- Stream implementation ---
 
 type ollamaStream struct {
-	scanner *bufio.Scanner
-	body    io.ReadCloser
-	done    bool
+	scanner   *bufio.Scanner
+	body      io.ReadCloser
+	done      bool
+	toolCalls int // calls seen so far; numbers IDs across chunks
+	provider.StreamCounter
 }
 
+// Next returns the next chunk, recording it for Stats.
 func (s *ollamaStream) Next() (*provider.ChatStreamChunk, error) {
+	chunk, err := s.next()
+	if err == nil {
+		s.Observe(chunk)
+	}
+	return chunk, err
+}
+
+func (s *ollamaStream) next() (*provider.ChatStreamChunk, error) {
 	if s.done {
 		return nil, io.EOF
 	}
@@ -394,7 +750,7 @@ func (s *ollamaStream) Next() (*provider.ChatStreamChunk, error) {
 		for i, tc := range resp.Message.ToolCalls {
 			argsJSON, _ := json.Marshal(tc.Function.Arguments)
 			chunk.Delta.ToolCalls[i] = provider.ToolCall{
-				ID:   fmt.Sprintf("call_%d", i),
+				ID:   provider.SyntheticToolCallID(s.toolCalls+i, tc.Function.Name),
 				Type: "function",
 				Function: provider.FunctionCall{
 					Name:      tc.Function.Name,
@@ -402,6 +758,7 @@ func (s *ollamaStream) Next() (*provider.ChatStreamChunk, error) {
 				},
 			}
 		}
+		s.toolCalls += len(resp.Message.ToolCalls)
 	}
 
 	if resp.Done {
@@ -421,5 +778,7 @@ func (s *ollamaStream) Close() error {
 	return s.body.Close()
 }
 
-// Compile-time interface check.
+// Compile-time interface checks.
 var _ provider.Provider = (*OllamaProvider)(nil)
+var _ provider.CapabilityReporter = (*OllamaProvider)(nil)
+var _ provider.ModelDescriber = (*OllamaProvider)(nil)
@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/meganerd/electrictown/internal/provider"
 )
@@ -302,6 +305,375 @@ func TestListModelsError(t *testing.T) {
 	}
 }
 
+func TestShowResponseContextLength(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{
+			name: "architecture key",
+			body: `{"model_info":{"general.architecture":"llama","general.parameter_count":8030261248,
+				"llama.context_length":131072,"llama.embedding_length":4096}}`,
+			want: 131072,
+		},
+		{
+			name: "other architecture key",
+			body: `{"model_info":{"general.architecture":"qwen3","qwen3moe.context_length":40960}}`,
+			want: 40960,
+		},
+		{name: "no model_info", body: `{"capabilities":["completion"]}`},
+		{name: "not a number", body: `{"model_info":{"general.architecture":"llama","llama.context_length":"long"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp ollamaShowResponse
+			if err := json.Unmarshal([]byte(tt.body), &resp); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if got := resp.contextLength(); got != tt.want {
+				t.Errorf("contextLength() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShowModel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/show" {
+			t.Errorf("expected POST /api/show, got %s %s", r.Method, r.URL.Path)
+		}
+		var req ollamaShowRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "llama3.1:8b" {
+			t.Errorf("expected model llama3.1:8b, got %q", req.Model)
+		}
+		w.Write([]byte(`{
+			"modelfile":"FROM llama3.1:8b",
+			"parameters":"stop \"<|eot_id|>\"",
+			"template":"{{ .System }}{{ .Prompt }}",
+			"details":{"parent_model":"","format":"gguf","family":"llama","families":["llama"],
+			           "parameter_size":"8.0B","quantization_level":"Q4_K_M"},
+			"model_info":{"general.architecture":"llama","llama.context_length":131072},
+			"capabilities":["completion","tools"]
+		}`))
+	}))
+	defer srv.Close()
+
+	d, err := New(srv.URL, "").ShowModel(context.Background(), "llama3.1:8b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := ModelDetails{
+		Name:              "llama3.1:8b",
+		Family:            "llama",
+		ParameterSize:     "8.0B",
+		QuantizationLevel: "Q4_K_M",
+		ContextLength:     131072,
+		Capabilities:      []string{"completion", "tools"},
+		Template:          "{{ .System }}{{ .Prompt }}",
+	}
+	if !reflect.DeepEqual(*d, want) {
+		t.Errorf("ShowModel =\n%+v\nwant\n%+v", *d, want)
+	}
+}
+
+func TestShowModelError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model 'nope' not found"}`))
+	}))
+	defer srv.Close()
+
+	if _, err := New(srv.URL, "").ShowModel(context.Background(), "nope"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestDescribeModel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/show" {
+			t.Errorf("expected POST /api/show, got %s %s", r.Method, r.URL.Path)
+		}
+		var req ollamaShowRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "llava:13b" {
+			t.Errorf("expected model llava:13b, got %q", req.Model)
+		}
+		w.Write([]byte(`{"capabilities":["completion","vision"],
+			"model_info":{"general.architecture":"llama","llama.context_length":4096}}`))
+	}))
+	defer srv.Close()
+
+	p := New(srv.URL, "")
+	m, err := p.DescribeModel(context.Background(), provider.Model{ID: "llava:13b", Provider: "ollama", Name: "llava:13b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.ID != "llava:13b" || m.ContextLength != 4096 || !m.HasCapability(provider.CapabilityVision) || m.HasCapability(provider.CapabilityTools) {
+		t.Errorf("DescribeModel = %+v, want llava:13b with 4096 context and vision", m)
+	}
+}
+
+func TestDescribeModelError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model 'nope' not found"}`))
+	}))
+	defer srv.Close()
+
+	in := provider.Model{ID: "nope", Provider: "ollama", Name: "nope"}
+	m, err := New(srv.URL, "").DescribeModel(context.Background(), in)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if m.ID != in.ID {
+		t.Errorf("expected the model returned unchanged on error, got %+v", m)
+	}
+}
+
+func TestChatCompletionImages(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	shows := 0
+	var chat ollamaChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			shows++
+			var req ollamaShowRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Model == "llava:13b" {
+				w.Write([]byte(`{"capabilities":["completion","vision"]}`))
+			} else {
+				w.Write([]byte(`{"capabilities":["completion","tools"]}`))
+			}
+		case "/api/chat":
+			json.NewDecoder(r.Body).Decode(&chat)
+			w.Write([]byte(`{"model":"llava:13b","message":{"role":"assistant","content":"a cat"},"done":true}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	p := New(srv.URL, "")
+
+	req := func(model string) *provider.ChatRequest {
+		return &provider.ChatRequest{
+			Model:    model,
+			Messages: []provider.Message{{Role: provider.RoleUser, Content: "What is this?", Images: [][]byte{png}}},
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := p.ChatCompletion(context.Background(), req("llava:13b")); err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+	}
+	if shows != 1 {
+		t.Errorf("/api/show called %d times, want the answer kept", shows)
+	}
+	if len(chat.Messages) != 1 || len(chat.Messages[0].Images) != 1 || chat.Messages[0].Images[0] != base64.StdEncoding.EncodeToString(png) {
+		t.Errorf("chat messages = %+v, want the image base64-encoded", chat.Messages)
+	}
+
+	_, err := p.ChatCompletion(context.Background(), req("llama3.1:8b"))
+	if !errors.Is(err, provider.ErrImagesUnsupported) {
+		t.Errorf("ChatCompletion to a text-only model = %v, want ErrImagesUnsupported", err)
+	}
+	if _, err := p.StreamChatCompletion(context.Background(), req("llama3.1:8b")); !errors.Is(err, provider.ErrImagesUnsupported) {
+		t.Errorf("StreamChatCompletion to a text-only model = %v, want ErrImagesUnsupported", err)
+	}
+}
+
+func TestRouterRejectsImagesForTextModel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			w.Write([]byte(`{"capabilities":["completion","tools"]}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{"local": {Type: "ollama", BaseURL: srv.URL}},
+		Models:    map[string]provider.ModelConfig{"coder": {Provider: "local", Model: "qwen2.5-coder:7b"}},
+		Defaults:  provider.DefaultsConfig{Model: "coder"},
+	}
+	router, err := provider.NewRouter(cfg, map[string]provider.ProviderFactory{
+		"ollama": func(pc provider.ProviderConfig) (provider.Provider, error) { return New(pc.BaseURL, ""), nil },
+	})
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+
+	_, err = router.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "coder",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Fix this UI.", Images: [][]byte{[]byte("\x89PNG\r\n\x1a\n")}}},
+	})
+	if !errors.Is(err, provider.ErrUnsupported) {
+		t.Fatalf("ChatCompletion err = %v, want ErrUnsupported", err)
+	}
+	if !strings.Contains(err.Error(), "qwen2.5-coder:7b") || !strings.Contains(err.Error(), "images") {
+		t.Errorf("error = %q, want it to name the model and images", err)
+	}
+}
+
+func TestRunningModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/ps" {
+			t.Errorf("expected /api/ps, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"models":[
+			{"name":"qwen3:32b","model":"qwen3:32b","size":21474836480,"size_vram":19327352832,
+			 "digest":"abc","details":{"family":"qwen3"},"expires_at":"2025-06-04T14:38:31.83753-07:00"},
+			{"name":"llama3:8b","model":"llama3:8b","size":5000000000,"size_vram":0,
+			 "expires_at":"2025-06-04T14:40:00Z"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	p := New(srv.URL, "")
+	models, err := p.RunningModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("expected 2 running models, got %d", len(models))
+	}
+	m := models[0]
+	if m.Name != "qwen3:32b" || m.Size != 21474836480 || m.SizeVRAM != 19327352832 {
+		t.Errorf("unexpected first model: %+v", m)
+	}
+	want := time.Date(2025, 6, 4, 21, 38, 31, 837530000, time.UTC)
+	if !m.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", m.ExpiresAt, want)
+	}
+	if models[1].SizeVRAM != 0 {
+		t.Errorf("expected CPU-only model to report 0 VRAM, got %d", models[1].SizeVRAM)
+	}
+}
+
+func TestChatCompletion_KeepAlive(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []OllamaOption
+		want string // raw JSON value of keep_alive, "" = omitted
+	}{
+		{"unset", nil, ""},
+		{"duration", []OllamaOption{WithKeepAlive(30 * time.Minute)}, `"30m0s"`},
+		{"indefinite", []OllamaOption{WithKeepAlive(-1)}, `-1`},
+		{"unload immediately", []OllamaOption{WithKeepAlive(0)}, `"0s"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]json.RawMessage
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&body)
+				json.NewEncoder(w).Encode(ollamaChatResponse{Model: "llama3", Message: ollamaMessage{Role: "assistant", Content: "hi"}, Done: true})
+			}))
+			defer srv.Close()
+
+			p := New(srv.URL, "", tt.opts...)
+			_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+				Model:    "llama3",
+				Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, ok := body["keep_alive"]
+			if tt.want == "" {
+				if ok {
+					t.Errorf("expected keep_alive to be omitted, got %s", got)
+				}
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("keep_alive = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPull_StreamsProgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/pull" {
+			t.Errorf("expected POST /api/pull, got %s %s", r.Method, r.URL.Path)
+		}
+		var req ollamaPullRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "qwen3:8b" || !req.Stream {
+			t.Errorf("unexpected pull request: %+v", req)
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		lines := []string{
+			`{"status":"pulling manifest"}`,
+			`{"status":"pulling abc123","digest":"sha256:abc123","total":1000,"completed":250}`,
+			`{"status":"pulling abc123","digest":"sha256:abc123","total":1000,"completed":1000}`,
+			`{"status":"verifying sha256 digest"}`,
+			`{"status":"success"}`,
+		}
+		for _, l := range lines {
+			fmt.Fprintln(w, l)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+
+	type update struct {
+		status           string
+		completed, total int64
+	}
+	var got []update
+	p := New(srv.URL, "")
+	err := p.Pull(context.Background(), "qwen3:8b", func(status string, completed, total int64) {
+		got = append(got, update{status, completed, total})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []update{
+		{"pulling manifest", 0, 0},
+		{"pulling abc123", 250, 1000},
+		{"pulling abc123", 1000, 1000},
+		{"verifying sha256 digest", 0, 0},
+		{"success", 0, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d progress updates, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("update[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPull_ErrorLine(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+		fmt.Fprintln(w, `{"error":"pull model manifest: file does not exist"}`)
+	}))
+	defer srv.Close()
+
+	err := New(srv.URL, "").Pull(context.Background(), "nope:1b", nil)
+	if err == nil || !strings.Contains(err.Error(), "file does not exist") {
+		t.Errorf("expected manifest error, got %v", err)
+	}
+}
+
+func TestPull_TruncatedStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+	}))
+	defer srv.Close()
+
+	if err := New(srv.URL, "").Pull(context.Background(), "qwen3:8b", nil); err == nil {
+		t.Error("expected error when the stream ends without success")
+	}
+}
+
 func TestChatCompletionWithToolCalls(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := ollamaChatResponse{
@@ -348,6 +720,50 @@ func TestChatCompletionWithToolCalls(t *testing.T) {
 	}
 }
 
+func TestToolCalls_SameFunctionTwice(t *testing.T) {
+	var second ollamaChatRequest
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		resp := ollamaChatResponse{Model: "llama3", Done: true, Message: ollamaMessage{Role: "assistant"}}
+		if calls == 1 {
+			resp.Message.ToolCalls = []ollamaToolCall{
+				{Function: ollamaFunctionCall{Name: "get_weather", Arguments: map[string]interface{}{"location": "NYC"}}},
+				{Function: ollamaFunctionCall{Name: "get_weather", Arguments: map[string]interface{}{"location": "LA"}}},
+			}
+		} else {
+			json.NewDecoder(r.Body).Decode(&second)
+			resp.Message.Content = "done"
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	p := New(srv.URL, "")
+	msgs := []provider.Message{{Role: provider.RoleUser, Content: "Weather in NYC and LA?"}}
+	resp, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{Model: "llama3", Messages: msgs})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tcs := resp.Message.ToolCalls
+	if len(tcs) != 2 || tcs[0].ID == tcs[1].ID {
+		t.Fatalf("expected two tool calls with distinct IDs, got %+v", tcs)
+	}
+
+	msgs = append(msgs, resp.Message,
+		provider.Message{Role: provider.RoleTool, ToolCallID: tcs[0].ID, Content: "72F"},
+		provider.Message{Role: provider.RoleTool, ToolCallID: tcs[1].ID, Content: "80F"},
+	)
+	if _, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{Model: "llama3", Messages: msgs}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, m := range second.Messages[2:] {
+		if m.Role != "tool" || m.ToolName != "get_weather" {
+			t.Errorf("tool result %+v should carry tool_name get_weather", m)
+		}
+	}
+}
+
 func TestChatCompletionWithTemperature(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var body ollamaChatRequest
@@ -386,6 +802,81 @@ func TestChatCompletionWithTemperature(t *testing.T) {
 	}
 }
 
+func TestChatCompletionWithSeed(t *testing.T) {
+	var got []interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body ollamaChatRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body.Options["seed"])
+		json.NewEncoder(w).Encode(ollamaChatResponse{
+			Model:   "llama3",
+			Message: ollamaMessage{Role: "assistant", Content: "response"},
+			Done:    true,
+		})
+	}))
+	defer srv.Close()
+
+	seed := 7
+	p := New(srv.URL, "")
+	req := &provider.ChatRequest{
+		Model:    "llama3",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+		Seed:     &seed,
+	}
+	if _, err := p.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Seed = nil
+	if _, err := p.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 || got[0] != float64(7) || got[1] != nil {
+		t.Errorf("options.seed per request = %v, want [7 <nil>]", got)
+	}
+}
+
+func TestChatCompletionWithPenalties(t *testing.T) {
+	var got []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body ollamaChatRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body.Options)
+		json.NewEncoder(w).Encode(ollamaChatResponse{
+			Model:   "llama3",
+			Message: ollamaMessage{Role: "assistant", Content: "response"},
+			Done:    true,
+		})
+	}))
+	defer srv.Close()
+
+	freq, pres := 0.5, 1.0
+	p := New(srv.URL, "")
+	req := &provider.ChatRequest{
+		Model:            "llama3",
+		Messages:         []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+		FrequencyPenalty: &freq,
+		PresencePenalty:  &pres,
+	}
+	if _, err := p.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.FrequencyPenalty, req.PresencePenalty = nil, nil
+	if _, err := p.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got[0]["frequency_penalty"] != 0.5 || got[0]["repeat_penalty"] != 1.0 {
+		t.Errorf("options = %v, want frequency_penalty 0.5 and repeat_penalty 1", got[0])
+	}
+	if _, ok := got[0]["presence_penalty"]; ok {
+		t.Error("presence_penalty should not be set")
+	}
+	if got[1] != nil {
+		t.Errorf("expected no options without penalties, got %v", got[1])
+	}
+}
+
 func TestProviderInterface(t *testing.T) {
 	// Compile-time check that OllamaProvider implements provider.Provider.
 	var _ provider.Provider = (*OllamaProvider)(nil)
@@ -502,3 +993,110 @@ func TestNew_BackwardsCompatible(t *testing.T) {
 		t.Errorf("expected 'Bearer legacy-key', got %q", gotAuth)
 	}
 }
+
+func TestChatCompletion_CacheableIgnored(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		json.NewEncoder(w).Encode(ollamaChatResponse{Model: "llama3", Message: ollamaMessage{Role: "assistant", Content: "hi"}, Done: true})
+	}))
+	defer srv.Close()
+
+	p := New(srv.URL, "")
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "llama3",
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "shared instructions", Cacheable: true},
+			{Role: provider.RoleUser, Content: "hi"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(body), "cache") {
+		t.Errorf("request should carry no caching fields: %s", body)
+	}
+	if !strings.Contains(string(body), `"content":"shared instructions"`) {
+		t.Errorf("cacheable system message not sent as-is: %s", body)
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	var chat, tags http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/chat":
+			chat = r.Header.Clone()
+			json.NewEncoder(w).Encode(ollamaChatResponse{Model: "llama3", Message: ollamaMessage{Role: "assistant", Content: "hi"}, Done: true})
+		case "/api/tags":
+			tags = r.Header.Clone()
+			fmt.Fprint(w, `{"models":[]}`)
+		}
+	}))
+	defer srv.Close()
+
+	// No api_key: custom headers must still be sent.
+	p := New(srv.URL, "", WithDefaultHeaders(map[string]string{"X-Request-Id": "req-1"}))
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "llama3",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if _, err := p.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if chat.Get("X-Request-Id") != "req-1" {
+		t.Errorf("chat request missing X-Request-Id: %v", chat)
+	}
+	if tags.Get("X-Request-Id") != "req-1" {
+		t.Errorf("tags request missing X-Request-Id: %v", tags)
+	}
+	if chat.Get("Authorization") != "" {
+		t.Errorf("Authorization = %q, want none without an api key", chat.Get("Authorization"))
+	}
+}
+
+func TestStreamStats_Cancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, text := range []string{"Once", " upon", " a time"} {
+			data, _ := json.Marshal(ollamaChatResponse{Model: "llama3", Message: ollamaMessage{Role: "assistant", Content: text}})
+			fmt.Fprintf(w, "%s\n", data)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := New(srv.URL, "")
+	stream, err := p.StreamChatCompletion(ctx, &provider.ChatRequest{
+		Model:    "llama3",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Tell me a story"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := stream.Next(); err != nil {
+			t.Fatalf("Next %d: %v", i, err)
+		}
+	}
+	cancel()
+	if _, err := stream.Next(); err == nil {
+		t.Fatal("expected an error after cancellation")
+	}
+	stream.Close()
+
+	st := stream.Stats()
+	want := provider.StreamStats{
+		Chunks: 3,
+		Usage:  provider.Usage{PromptTokens: 4, CompletionTokens: 4, TotalTokens: 8, Estimated: true},
+	}
+	if st != want {
+		t.Errorf("stats = %+v, want %+v", st, want)
+	}
+}
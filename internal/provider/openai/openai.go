@@ -6,10 +6,13 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/meganerd/electrictown/internal/provider"
@@ -18,6 +21,10 @@ import (
 const (
 	defaultBaseURL = "https://api.openai.com/v1"
 	providerName   = "openai"
+
+	// CompatibleName is the provider type and Name of gateways that speak the
+	// OpenAI wire format (Together, Groq, OpenRouter, vLLM, ...).
+	CompatibleName = "openai-compatible"
 )
 
 // OpenAIProvider implements provider.Provider using the OpenAI REST API.
@@ -26,6 +33,11 @@ type OpenAIProvider struct {
 	baseURL string
 	orgID   string
 	client  *http.Client
+
+	name       string            // reported by Name and on listed models
+	authType   string            // "bearer" (default), "basic", or "none"
+	headers    map[string]string // extra headers, applied after auth
+	compatible bool              // gateway mode: auth and /models are optional
 }
 
 // Option configures an OpenAIProvider.
@@ -52,12 +64,29 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithDefaultHeaders adds headers to every request. They are set after the
+// Authorization header, so a gateway with its own auth scheme can replace it.
+func WithDefaultHeaders(headers map[string]string) Option {
+	return func(p *OpenAIProvider) {
+		p.headers = headers
+	}
+}
+
+// WithAuthType sets how the API key is sent: "bearer" (default), "basic"
+// (the key is user:password), or "none".
+func WithAuthType(authType string) Option {
+	return func(p *OpenAIProvider) {
+		p.authType = authType
+	}
+}
+
 // New creates an OpenAIProvider with the given API key and options.
 func New(apiKey string, opts ...Option) *OpenAIProvider {
 	p := &OpenAIProvider{
 		apiKey:  apiKey,
 		baseURL: defaultBaseURL,
 		client:  http.DefaultClient,
+		name:    providerName,
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -65,9 +94,43 @@ func New(apiKey string, opts ...Option) *OpenAIProvider {
 	return p
 }
 
+// NewCompatible creates a provider for an OpenAI-compatible gateway at
+// baseURL, where the API key and the /models endpoint are optional.
+func NewCompatible(baseURL, apiKey string, opts ...Option) *OpenAIProvider {
+	p := New(apiKey, append([]Option{WithBaseURL(baseURL)}, opts...)...)
+	p.name = CompatibleName
+	p.compatible = true
+	return p
+}
+
+// CompatibleFactory builds an openai-compatible provider from config:
+// base_url (required), api_key, auth_type, org and headers.
+func CompatibleFactory(pc provider.ProviderConfig) (provider.Provider, error) {
+	if pc.BaseURL == "" {
+		return nil, fmt.Errorf("%s: base_url is required", CompatibleName)
+	}
+	var opts []Option
+	if pc.AuthType != "" {
+		opts = append(opts, WithAuthType(pc.AuthType))
+	}
+	if pc.Org != "" {
+		opts = append(opts, WithOrganization(pc.Org))
+	}
+	if len(pc.Headers) > 0 {
+		opts = append(opts, WithDefaultHeaders(pc.Headers))
+	}
+	return NewCompatible(pc.BaseURL, pc.APIKey, opts...), nil
+}
+
 // Name returns the provider identifier.
 func (p *OpenAIProvider) Name() string {
-	return providerName
+	return p.name
+}
+
+// Capabilities reports the full OpenAI feature set. Compatible gateways
+// pass each feature on to the model behind them, which may still reject it.
+func (p *OpenAIProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Tools: true, Images: true, Seed: true, Penalties: true}
 }
 
 // --- OpenAI API types (wire format) ---
@@ -80,6 +143,9 @@ type oaiRequest struct {
 	TopP        *float64      `json:"top_p,omitempty"`
 	MaxTokens   *int          `json:"max_tokens,omitempty"`
 	Stop        []string      `json:"stop,omitempty"`
+	Seed        *int          `json:"seed,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
 	Stream      bool          `json:"stream,omitempty"`
 	StreamOptions *oaiStreamOptions `json:"stream_options,omitempty"`
 }
@@ -94,6 +160,32 @@ type oaiMessage struct {
 	Name       string              `json:"name,omitempty"`
 	ToolCallID string              `json:"tool_call_id,omitempty"`
 	ToolCalls  []provider.ToolCall `json:"tool_calls,omitempty"`
+
+	// Parts replaces Content on the wire when set: a message with images is
+	// sent as an array of text and image_url parts.
+	Parts []oaiContentPart `json:"-"`
+}
+
+type oaiContentPart struct {
+	Type     string       `json:"type"` // "text" or "image_url"
+	Text     string       `json:"text,omitempty"`
+	ImageURL *oaiImageURL `json:"image_url,omitempty"`
+}
+
+type oaiImageURL struct {
+	URL string `json:"url"` // data:<media type>;base64,<data>
+}
+
+// MarshalJSON sends Parts as the content when the message has them.
+func (m oaiMessage) MarshalJSON() ([]byte, error) {
+	type plain oaiMessage
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []oaiContentPart `json:"content"`
+	}{plain(m), m.Parts})
 }
 
 type oaiResponse struct {
@@ -112,9 +204,16 @@ type oaiChoice struct {
 }
 
 type oaiUsage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens            int              `json:"prompt_tokens"`
+	CompletionTokens        int              `json:"completion_tokens"`
+	TotalTokens             int              `json:"total_tokens"`
+	CompletionTokensDetails *oaiTokenDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// oaiTokenDetails breaks completion tokens down; reasoning models report the
+// hidden reasoning share here.
+type oaiTokenDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
 }
 
 type oaiError struct {
@@ -127,8 +226,42 @@ type oaiModelsResponse struct {
 	Data []oaiModel `json:"data"`
 }
 
+// oaiModel is an entry in the /models list. OpenAI itself returns only the
+// ID; some compatible gateways also report the context window and what the
+// model accepts (OpenRouter: context_length, supported_parameters and
+// architecture; vLLM: max_model_len).
 type oaiModel struct {
-	ID string `json:"id"`
+	ID                  string   `json:"id"`
+	ContextLength       int      `json:"context_length,omitempty"`
+	MaxModelLen         int      `json:"max_model_len,omitempty"`
+	SupportedParameters []string `json:"supported_parameters,omitempty"`
+	Architecture        struct {
+		InputModalities []string `json:"input_modalities,omitempty"`
+	} `json:"architecture"`
+}
+
+// contextLength returns the context window the gateway reports, or 0.
+func (m oaiModel) contextLength() int {
+	if m.ContextLength > 0 {
+		return m.ContextLength
+	}
+	return m.MaxModelLen
+}
+
+// capabilities derives provider capabilities from gateway metadata; nil
+// when the gateway reports none.
+func (m oaiModel) capabilities() []string {
+	var caps []string
+	if slices.Contains(m.SupportedParameters, "tools") {
+		caps = append(caps, provider.CapabilityTools)
+	}
+	if slices.Contains(m.SupportedParameters, "reasoning") {
+		caps = append(caps, provider.CapabilityThinking)
+	}
+	if slices.Contains(m.Architecture.InputModalities, "image") {
+		caps = append(caps, provider.CapabilityVision)
+	}
+	return caps
 }
 
 // --- Request/Response translation ---
@@ -142,11 +275,29 @@ func toOAIMessages(msgs []provider.Message) []oaiMessage {
 			Name:       m.Name,
 			ToolCallID: m.ToolCallID,
 			ToolCalls:  m.ToolCalls,
+			Parts:      toOAIParts(m),
 		}
 	}
 	return out
 }
 
+// toOAIParts returns m's text and images as content parts, or nil when m
+// has no images and plain string content will do.
+func toOAIParts(m provider.Message) []oaiContentPart {
+	if len(m.Images) == 0 {
+		return nil
+	}
+	var parts []oaiContentPart
+	if m.Content != "" {
+		parts = append(parts, oaiContentPart{Type: "text", Text: m.Content})
+	}
+	for _, img := range m.Images {
+		url := "data:" + provider.ImageMediaType(img) + ";base64," + base64.StdEncoding.EncodeToString(img)
+		parts = append(parts, oaiContentPart{Type: "image_url", ImageURL: &oaiImageURL{URL: url}})
+	}
+	return parts
+}
+
 func fromOAIMessage(m oaiMessage) provider.Message {
 	return provider.Message{
 		Role:       m.Role,
@@ -161,11 +312,15 @@ func fromOAIUsage(u *oaiUsage) provider.Usage {
 	if u == nil {
 		return provider.Usage{}
 	}
-	return provider.Usage{
+	usage := provider.Usage{
 		PromptTokens:     u.PromptTokens,
 		CompletionTokens: u.CompletionTokens,
 		TotalTokens:      u.TotalTokens,
 	}
+	if u.CompletionTokensDetails != nil {
+		usage.ReasoningTokens = u.CompletionTokensDetails.ReasoningTokens
+	}
+	return usage
 }
 
 func oaiErrorCode(code any) string {
@@ -185,14 +340,31 @@ func (p *OpenAIProvider) newRequest(ctx context.Context, method, path string, bo
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", "Bearer "+p.apiKey)
 	req.Header.Set("Content-Type", "application/json")
+	p.setAuth(req)
 	if p.orgID != "" {
 		req.Header.Set("OpenAI-Organization", p.orgID)
 	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
 	return req, nil
 }
 
+// setAuth sets the Authorization header. The OpenAI API always gets a bearer
+// token; compatible gateways get none when no key is configured.
+func (p *OpenAIProvider) setAuth(req *http.Request) {
+	switch p.authType {
+	case provider.AuthNone:
+	case provider.AuthBasic:
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(p.apiKey)))
+	default:
+		if p.apiKey != "" || !p.compatible {
+			req.Header.Set("Authorization", "Bearer "+p.apiKey)
+		}
+	}
+}
+
 func (p *OpenAIProvider) doJSON(req *http.Request, dst any) error {
 	resp, err := p.client.Do(req)
 	if err != nil {
@@ -244,6 +416,9 @@ func (p *OpenAIProvider) ChatCompletion(ctx context.Context, req *provider.ChatR
 		TopP:        req.TopP,
 		MaxTokens:   req.MaxTokens,
 		Stop:        req.Stop,
+		Seed:        req.Seed,
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
 		Stream:      false,
 	}
 
@@ -294,6 +469,9 @@ func (p *OpenAIProvider) StreamChatCompletion(ctx context.Context, req *provider
 		TopP:        req.TopP,
 		MaxTokens:   req.MaxTokens,
 		Stop:        req.Stop,
+		Seed:        req.Seed,
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
 		Stream:      true,
 		StreamOptions: &oaiStreamOptions{IncludeUsage: true},
 	}
@@ -319,8 +497,9 @@ func (p *OpenAIProvider) StreamChatCompletion(ctx context.Context, req *provider
 	}
 
 	return &sseStream{
-		reader: bufio.NewReader(resp.Body),
-		body:   resp.Body,
+		reader:        bufio.NewReader(resp.Body),
+		body:          resp.Body,
+		StreamCounter: provider.NewStreamCounter(req),
 	}, nil
 }
 
@@ -333,15 +512,20 @@ func (p *OpenAIProvider) ListModels(ctx context.Context) ([]provider.Model, erro
 
 	var modelsResp oaiModelsResponse
 	if err := p.doJSON(httpReq, &modelsResp); err != nil {
+		if p.compatible && missingEndpoint(err) {
+			return nil, nil
+		}
 		return nil, err
 	}
 
 	models := make([]provider.Model, len(modelsResp.Data))
 	for i, m := range modelsResp.Data {
 		models[i] = provider.Model{
-			ID:       m.ID,
-			Provider: providerName,
-			Name:     m.ID,
+			ID:            m.ID,
+			Provider:      p.name,
+			Name:          m.ID,
+			ContextLength: m.contextLength(),
+			Capabilities:  m.capabilities(),
 		}
 	}
 	return models, nil
@@ -352,9 +536,19 @@ func (p *OpenAIProvider) ListModels(ctx context.Context) ([]provider.Model, erro
 type sseStream struct {
 	reader *bufio.Reader
 	body   io.ReadCloser
+	provider.StreamCounter
 }
 
+// Next returns the next chunk, recording it for Stats.
 func (s *sseStream) Next() (*provider.ChatStreamChunk, error) {
+	chunk, err := s.next()
+	if err == nil {
+		s.Observe(chunk)
+	}
+	return chunk, err
+}
+
+func (s *sseStream) next() (*provider.ChatStreamChunk, error) {
 	for {
 		line, err := s.reader.ReadString('\n')
 		if err != nil {
@@ -421,6 +615,21 @@ func (s *sseStream) Close() error {
 	return s.body.Close()
 }
 
-// Compile-time interface compliance check.
+// Compile-time interface compliance checks.
 var _ provider.Provider = (*OpenAIProvider)(nil)
+var _ provider.CapabilityReporter = (*OpenAIProvider)(nil)
 var _ provider.ChatStream = (*sseStream)(nil)
+
+// missingEndpoint reports whether err means the server does not implement
+// the requested endpoint.
+func missingEndpoint(err error) bool {
+	var apiErr *provider.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Status {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
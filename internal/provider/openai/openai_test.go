@@ -2,11 +2,14 @@ package openai
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/meganerd/electrictown/internal/provider"
@@ -363,6 +366,36 @@ func TestListModels(t *testing.T) {
 	}
 }
 
+func TestListModels_GatewayMetadata(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[
+			{"id":"meta-llama/llama-3.3-70b","context_length":131072,
+			 "supported_parameters":["tools","temperature"],
+			 "architecture":{"input_modalities":["text","image"]}},
+			{"id":"qwen3-32b","max_model_len":32768},
+			{"id":"gpt-4o"}
+		]}`))
+	})
+
+	models, err := p.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(models) != 3 {
+		t.Fatalf("expected 3 models, got %d", len(models))
+	}
+	if m := models[0]; m.ContextLength != 131072 || !reflect.DeepEqual(m.Capabilities, []string{"tools", "vision"}) {
+		t.Errorf("openrouter-style model: context %d, capabilities %v", m.ContextLength, m.Capabilities)
+	}
+	if m := models[1]; m.ContextLength != 32768 || m.Capabilities != nil {
+		t.Errorf("vLLM-style model: context %d, capabilities %v", m.ContextLength, m.Capabilities)
+	}
+	if m := models[2]; m.ContextLength != 0 || m.Capabilities != nil {
+		t.Errorf("plain model: context %d, capabilities %v, want unknown", m.ContextLength, m.Capabilities)
+	}
+}
+
 func TestWithOrganization(t *testing.T) {
 	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
 		org := r.Header.Get("OpenAI-Organization")
@@ -438,6 +471,96 @@ func TestChatCompletionWithOptionalParams(t *testing.T) {
 	}
 }
 
+func TestSeedTransmitted(t *testing.T) {
+	var seeds []interface{}
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var raw map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&raw)
+		seeds = append(seeds, raw["seed"])
+
+		if raw["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(oaiResponse{
+			ID:      "chatcmpl-seed",
+			Model:   "gpt-4",
+			Choices: []oaiChoice{{Message: oaiMessage{Role: provider.RoleAssistant, Content: "ok"}}},
+		})
+	})
+
+	seed := 42
+	req := &provider.ChatRequest{
+		Model:    "gpt-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+		Seed:     &seed,
+	}
+	if _, err := p.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	stream, err := p.StreamChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("StreamChatCompletion: %v", err)
+	}
+	stream.Close()
+	req.Seed = nil
+	if _, err := p.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("ChatCompletion without seed: %v", err)
+	}
+
+	// JSON numbers decode as float64.
+	want := []interface{}{float64(42), float64(42), nil}
+	if len(seeds) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(seeds), len(want))
+	}
+	for i := range want {
+		if seeds[i] != want[i] {
+			t.Errorf("request %d: seed = %v, want %v", i, seeds[i], want[i])
+		}
+	}
+}
+
+func TestPenaltiesTransmitted(t *testing.T) {
+	var bodies []map[string]interface{}
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var raw map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&raw)
+		bodies = append(bodies, raw)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(oaiResponse{
+			ID:      "chatcmpl-pen",
+			Model:   "gpt-4",
+			Choices: []oaiChoice{{Message: oaiMessage{Role: provider.RoleAssistant, Content: "ok"}}},
+		})
+	})
+
+	freq, pres := 0.5, -0.25
+	req := &provider.ChatRequest{
+		Model:            "gpt-4",
+		Messages:         []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+		FrequencyPenalty: &freq,
+		PresencePenalty:  &pres,
+	}
+	if _, err := p.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	req.FrequencyPenalty, req.PresencePenalty = nil, nil
+	if _, err := p.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("ChatCompletion without penalties: %v", err)
+	}
+
+	if bodies[0]["frequency_penalty"] != 0.5 || bodies[0]["presence_penalty"] != -0.25 {
+		t.Errorf("penalties = %v / %v, want 0.5 / -0.25", bodies[0]["frequency_penalty"], bodies[0]["presence_penalty"])
+	}
+	for _, k := range []string{"frequency_penalty", "presence_penalty"} {
+		if _, ok := bodies[1][k]; ok {
+			t.Errorf("%s should be omitted when nil", k)
+		}
+	}
+}
+
 func TestChatCompletionNoChoices(t *testing.T) {
 	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
 		resp := oaiResponse{
@@ -457,3 +580,265 @@ func TestChatCompletionNoChoices(t *testing.T) {
 		t.Fatal("expected error for empty choices, got nil")
 	}
 }
+
+func TestReasoningTokens(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"chatcmpl-r","model":"o3-mini","choices":[{"index":0,"message":{"role":"assistant","content":"42"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":12,"completion_tokens":900,"total_tokens":912,"completion_tokens_details":{"reasoning_tokens":850}}}`)
+	})
+
+	resp, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "o3-mini",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	want := provider.Usage{PromptTokens: 12, CompletionTokens: 900, TotalTokens: 912, ReasoningTokens: 850}
+	if resp.Usage != want {
+		t.Errorf("usage = %+v, want %+v", resp.Usage, want)
+	}
+}
+
+func TestCacheableIgnored(t *testing.T) {
+	var body []byte
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(oaiResponse{
+			ID:      "chatcmpl-c",
+			Model:   "gpt-4",
+			Choices: []oaiChoice{{Message: oaiMessage{Role: provider.RoleAssistant, Content: "ok"}}},
+		})
+	})
+
+	// OpenAI caches long prompt prefixes automatically; the flag must not
+	// reach the wire.
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "gpt-4",
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "shared instructions", Cacheable: true},
+			{Role: provider.RoleUser, Content: "Hi"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if strings.Contains(string(body), "cache") {
+		t.Errorf("request should carry no caching fields: %s", body)
+	}
+	if !strings.Contains(string(body), `"content":"shared instructions"`) {
+		t.Errorf("cacheable system message not sent as-is: %s", body)
+	}
+}
+
+func TestImagesSentAsContentParts(t *testing.T) {
+	var body []byte
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(oaiResponse{
+			ID:      "chatcmpl-i",
+			Model:   "gpt-4o",
+			Choices: []oaiChoice{{Message: oaiMessage{Role: provider.RoleAssistant, Content: "a login form"}}},
+		})
+	})
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	resp, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model: "gpt-4o",
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "sys"},
+			{Role: provider.RoleUser, Content: "What is on screen?", Images: [][]byte{png}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if resp.Message.Content != "a login form" {
+		t.Errorf("response content = %q", resp.Message.Content)
+	}
+
+	var sent struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &sent); err != nil {
+		t.Fatalf("decode request: %v\n%s", err, body)
+	}
+	if string(sent.Messages[0].Content) != `"sys"` {
+		t.Errorf("message without images should keep string content, got %s", sent.Messages[0].Content)
+	}
+	var parts []oaiContentPart
+	if err := json.Unmarshal(sent.Messages[1].Content, &parts); err != nil {
+		t.Fatalf("user content is not a part array: %s", sent.Messages[1].Content)
+	}
+	want := []oaiContentPart{
+		{Type: "text", Text: "What is on screen?"},
+		{Type: "image_url", ImageURL: &oaiImageURL{URL: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)}},
+	}
+	if !reflect.DeepEqual(parts, want) {
+		t.Errorf("parts = %+v, want %+v", parts, want)
+	}
+}
+
+func TestCompatibleFactory_ThroughRouter(t *testing.T) {
+	var chatHeaders http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			chatHeaders = r.Header.Clone()
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(oaiResponse{
+				ID:      "chatcmpl-gw",
+				Model:   "llama-3-70b",
+				Choices: []oaiChoice{{Message: oaiMessage{Role: provider.RoleAssistant, Content: "hi from gateway"}}},
+			})
+		default:
+			http.NotFound(w, r) // no /models endpoint
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{
+			"gw": {
+				Type:    CompatibleName,
+				BaseURL: srv.URL + "/v1",
+				Headers: map[string]string{"X-Gateway-Key": "k123", "HTTP-Referer": "https://example.com"},
+			},
+		},
+		Models:   map[string]provider.ModelConfig{"llama": {Provider: "gw", Model: "llama-3-70b"}},
+		Defaults: provider.DefaultsConfig{Model: "llama"},
+	}
+	router, err := provider.NewRouter(cfg, map[string]provider.ProviderFactory{CompatibleName: CompatibleFactory})
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+
+	resp, err := router.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "llama",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if resp.Message.Content != "hi from gateway" {
+		t.Errorf("content = %q", resp.Message.Content)
+	}
+	if got := chatHeaders.Get("X-Gateway-Key"); got != "k123" {
+		t.Errorf("X-Gateway-Key = %q, want k123", got)
+	}
+	if got := chatHeaders.Get("HTTP-Referer"); got != "https://example.com" {
+		t.Errorf("HTTP-Referer = %q", got)
+	}
+	if got := chatHeaders.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none without an api_key", got)
+	}
+
+	// A gateway without /models is still reachable.
+	if err := router.Ping(context.Background(), "llama"); err != nil {
+		t.Errorf("Ping with missing /models: %v", err)
+	}
+}
+
+func TestCompatibleFactory_RequiresBaseURL(t *testing.T) {
+	if _, err := CompatibleFactory(provider.ProviderConfig{Type: CompatibleName}); err == nil {
+		t.Error("expected error without base_url")
+	}
+}
+
+func TestCompatible_AuthOverride(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(oaiModelsResponse{})
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewCompatible(srv.URL, "secret", WithDefaultHeaders(map[string]string{"Authorization": "Token secret"}))
+	if _, err := p.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if auth != "Token secret" {
+		t.Errorf("Authorization = %q, want the configured header to replace Bearer", auth)
+	}
+	if p.Name() != CompatibleName {
+		t.Errorf("Name = %q, want %q", p.Name(), CompatibleName)
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		json.NewEncoder(w).Encode(oaiResponse{
+			ID:      "chatcmpl-h",
+			Model:   "gpt-4",
+			Choices: []oaiChoice{{Message: oaiMessage{Role: provider.RoleAssistant, Content: "ok"}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	p := New("test-key", WithBaseURL(srv.URL), WithDefaultHeaders(map[string]string{
+		"X-Request-Id":    "req-1",
+		"X-Gateway-Token": "gw",
+	}))
+	_, err := p.ChatCompletion(context.Background(), &provider.ChatRequest{
+		Model:    "gpt-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if got.Get("X-Request-Id") != "req-1" || got.Get("X-Gateway-Token") != "gw" {
+		t.Errorf("custom headers missing: %v", got)
+	}
+	if got.Get("Authorization") != "Bearer test-key" {
+		t.Errorf("Authorization = %q, want bearer kept alongside custom headers", got.Get("Authorization"))
+	}
+}
+
+func TestStreamStats_Cancelled(t *testing.T) {
+	_, p := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, text := range []string{"Once", " upon", " a time"} {
+			fmt.Fprintf(w, "data: {\"id\":\"c1\",\"model\":\"gpt-4\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", text)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := p.StreamChatCompletion(ctx, &provider.ChatRequest{
+		Model:    "gpt-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Tell me a story"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := stream.Next(); err != nil {
+			t.Fatalf("Next %d: %v", i, err)
+		}
+	}
+	cancel()
+	if _, err := stream.Next(); err == nil {
+		t.Fatal("expected an error after cancellation")
+	}
+	stream.Close()
+
+	st := stream.Stats()
+	if st.Chunks != 3 || st.Complete {
+		t.Errorf("stats = %+v, want 3 chunks, incomplete", st)
+	}
+	// 15 prompt chars and 16 completion chars estimate to 4 tokens each.
+	want := provider.Usage{PromptTokens: 4, CompletionTokens: 4, TotalTokens: 8, Estimated: true}
+	if st.Usage != want {
+		t.Errorf("usage = %+v, want %+v", st.Usage, want)
+	}
+}
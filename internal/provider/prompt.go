@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// PromptData is the run context a prompt can reference with text/template
+// actions, e.g. {{.Task}} or {{range .WorkerResults}}{{.Role}}{{end}}.
+// Fields that do not apply to a request are left empty.
+type PromptData struct {
+	Task          string
+	OutputDir     string
+	Subtask       string // the subtask being worked on or scored
+	SubtaskCount  int
+	WorkerResults []PromptWorkerResult
+}
+
+// PromptWorkerResult is one worker's output as seen by a prompt template.
+type PromptWorkerResult struct {
+	Role        string
+	Subtask     string
+	Response    string
+	ReviewScore int
+}
+
+// RenderPrompt expands the template actions in text with data. Text without
+// "{{" is returned as is. Templates get no functions beyond the text/template
+// builtins and PromptData has no methods, so rendering cannot run code; a
+// reference to a field PromptData lacks is an error.
+func RenderPrompt(text string, data PromptData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("prompt").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("provider: parse prompt template: %w", err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("provider: render prompt template: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// samplePromptData exercises every PromptData field, including a range
+// body, so Validate catches bad field references when the config loads.
+var samplePromptData = PromptData{
+	Task:          "task",
+	OutputDir:     "out",
+	Subtask:       "subtask",
+	SubtaskCount:  1,
+	WorkerResults: []PromptWorkerResult{{Role: "worker", Subtask: "subtask", Response: "response", ReviewScore: 1}},
+}
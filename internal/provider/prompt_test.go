@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderPrompt(t *testing.T) {
+	data := PromptData{
+		Task:         "build a CLI",
+		OutputDir:    "./out",
+		SubtaskCount: 2,
+		WorkerResults: []PromptWorkerResult{
+			{Role: "qwen", Subtask: "parser", ReviewScore: 8},
+			{Role: "llama", Subtask: "printer", ReviewScore: 5},
+		},
+	}
+	got, err := RenderPrompt("Task: {{.Task}} ({{.SubtaskCount}} subtasks, files in {{.OutputDir}})\n"+
+		"{{range $i, $r := .WorkerResults}}{{$i}}:{{$r.Role}}/{{$r.Subtask}}={{$r.ReviewScore}} {{end}}", data)
+	if err != nil {
+		t.Fatalf("RenderPrompt: %v", err)
+	}
+	want := "Task: build a CLI (2 subtasks, files in ./out)\n0:qwen/parser=8 1:llama/printer=5 "
+	if got != want {
+		t.Errorf("RenderPrompt = %q, want %q", got, want)
+	}
+}
+
+func TestRenderPrompt_PlainTextUnchanged(t *testing.T) {
+	text := "Output ===FILE: path=== blocks. Braces like { and } stay."
+	if got, err := RenderPrompt(text, PromptData{}); err != nil || got != text {
+		t.Errorf("RenderPrompt = %q, %v; want the text unchanged", got, err)
+	}
+}
+
+func TestRenderPrompt_Errors(t *testing.T) {
+	tests := []struct{ text, wantErr string }{
+		{"{{.Task", "parse prompt template"},
+		{"{{.Secret}}", "can't evaluate field Secret"},
+		{`{{template "x"}}`, "render prompt template"},
+	}
+	for _, tt := range tests {
+		if _, err := RenderPrompt(tt.text, PromptData{}); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("RenderPrompt(%q) error = %v, want containing %q", tt.text, err, tt.wantErr)
+		}
+	}
+}
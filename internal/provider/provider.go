@@ -5,7 +5,10 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"io"
+	"net"
+	"syscall"
 )
 
 // Provider is the core interface that all LLM provider adapters must implement.
@@ -48,6 +51,28 @@ type Message struct {
 
 	// ToolCalls contains any tool calls the assistant wants to make.
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// Cacheable marks the end of a prompt prefix worth caching across
+	// requests, such as a large system prompt reused by every worker. Each
+	// adapter maps it to the provider's native mechanism:
+	//   - Anthropic: a cache_control breakpoint on the message (the API
+	//     allows at most four per request and ignores prefixes under ~1024
+	//     tokens).
+	//   - OpenAI and Gemini: no-op; both cache repeated prompt prefixes
+	//     implicitly. Gemini's explicit cachedContents resources need a
+	//     managed lifecycle and minimum size, so they are not created.
+	//   - Ollama: no-op; the server keeps the loaded model's KV cache.
+	Cacheable bool `json:"cacheable,omitempty"`
+
+	// Images attaches pictures, such as screenshots, to a user message as
+	// raw PNG, JPEG, GIF or WebP bytes; see ImageMediaType. Each adapter
+	// sends them in the provider's native form:
+	//   - OpenAI: image_url content parts holding data URLs.
+	//   - Anthropic: base64 image content blocks.
+	//   - Gemini: inline_data parts.
+	//   - Ollama: the message's images list; models whose /api/show
+	//     capabilities lack "vision" are refused with ErrImagesUnsupported.
+	Images [][]byte `json:"images,omitempty"`
 }
 
 // ToolCall represents a tool/function call requested by the model.
@@ -87,6 +112,20 @@ type ChatRequest struct {
 	Stop        []string  `json:"stop,omitempty"`
 	Stream      bool      `json:"stream,omitempty"`
 
+	// Seed requests reproducible sampling. Sent as "seed" by OpenAI-compatible
+	// providers, as "random_seed" by Mistral and as options.seed by Ollama;
+	// the Router rejects it for Anthropic and Gemini with ErrUnsupported (see
+	// Capabilities). Determinism is best-effort on every backend.
+	Seed *int `json:"seed,omitempty"`
+
+	// FrequencyPenalty and PresencePenalty discourage repetition (OpenAI
+	// range -2.0 to 2.0, 0 = off). Sent as top-level fields by OpenAI-compatible
+	// providers and Mistral, and as options.frequency_penalty and
+	// options.repeat_penalty by Ollama; the Router rejects them for Anthropic
+	// and Gemini with ErrUnsupported.
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+
 	// ProviderOptions holds provider-specific options that don't fit the
 	// unified schema. Adapters can read these for provider-specific features.
 	ProviderOptions map[string]interface{} `json:"provider_options,omitempty"`
@@ -107,6 +146,22 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	// CacheCreationTokens and CacheReadTokens are the parts of PromptTokens
+	// written to and served from the provider's prompt cache (Anthropic).
+	// Both are zero for providers that do not report them.
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     int `json:"cache_read_tokens,omitempty"`
+
+	// ReasoningTokens is the part of CompletionTokens spent on hidden
+	// reasoning (OpenAI o-series reasoning tokens, Anthropic extended
+	// thinking). These tokens are billed as output even though they do not
+	// appear in the response text.
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+
+	// Estimated is true when the provider returned no usage and the counts
+	// were estimated from message text by the router.
+	Estimated bool `json:"estimated,omitempty"`
 }
 
 // APIError represents a structured error from a provider.
@@ -132,6 +187,11 @@ type ChatStream interface {
 
 	// Close releases resources associated with the stream.
 	Close() error
+
+	// Stats reports the chunks and token usage observed so far. It stays
+	// valid after Close, so a caller that cancels a stream can still record
+	// the partial usage.
+	Stats() StreamStats
 }
 
 // ChatStreamChunk represents a single chunk in a streaming response.
@@ -139,8 +199,13 @@ type ChatStreamChunk struct {
 	ID    string       `json:"id"`
 	Model string       `json:"model"`
 	Delta MessageDelta `json:"delta"`
-	Usage *Usage       `json:"usage,omitempty"` // present in final chunk
 	Done  bool         `json:"done"`
+
+	// Usage, when set, holds running totals rather than a delta, so a later
+	// chunk's Usage supersedes an earlier one. Most adapters send it only
+	// with the final chunk; Anthropic also sends the prompt counts on the
+	// first chunk so a stream cancelled early still reports them.
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // MessageDelta represents the incremental content in a stream chunk.
@@ -150,11 +215,39 @@ type MessageDelta struct {
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
-// Model represents an available model from a provider.
+// Model represents an available model from a provider. ContextLength and
+// Capabilities are filled in where the provider reports them.
 type Model struct {
 	ID       string `json:"id"`
 	Provider string `json:"provider"`
 	Name     string `json:"name"`
+
+	ContextLength int      `json:"context_length,omitempty"` // context window in tokens; 0 = unknown
+	Capabilities  []string `json:"capabilities,omitempty"`   // e.g. CapabilityTools; nil = unknown
+}
+
+// Model capabilities beyond plain text completion.
+const (
+	CapabilityTools    = "tools"    // function/tool calling
+	CapabilityVision   = "vision"   // image input
+	CapabilityThinking = "thinking" // extended reasoning
+)
+
+// HasCapability reports whether the model lists capability c.
+func (m Model) HasCapability(c string) bool {
+	for _, have := range m.Capabilities {
+		if have == c {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelDescriber is implemented by providers that can look up metadata for
+// a listed model with an extra request, such as Ollama's /api/show. The
+// router only calls it when built WithModelDetails.
+type ModelDescriber interface {
+	DescribeModel(ctx context.Context, m Model) (Model, error)
 }
 
 // ErrorCode classifies errors for fallback routing decisions.
@@ -166,15 +259,23 @@ const (
 	ErrAuth          ErrorCode = "auth"
 	ErrTimeout       ErrorCode = "timeout"
 	ErrServerError   ErrorCode = "server_error"
+	ErrNetwork       ErrorCode = "network"      // connection refused/reset, unreachable host, DNS failure
+	ErrCircuitOpen   ErrorCode = "circuit_open" // skipped: the model's circuit breaker is open
 	ErrUnknown       ErrorCode = "unknown"
 )
 
 // ClassifyError examines an error and returns its ErrorCode for routing decisions.
+// It looks through wrapped errors, so an adapter's "ollama: ...: %w" around a
+// transport failure classifies the same as the bare failure: timeouts (client
+// or context deadline) as ErrTimeout, and refused, reset or unreachable
+// connections and DNS errors as ErrNetwork. Cancellation is ErrUnknown since
+// retrying elsewhere cannot help.
 func ClassifyError(err error) ErrorCode {
 	if err == nil {
 		return ErrUnknown
 	}
-	if apiErr, ok := err.(*APIError); ok {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
 		switch {
 		case apiErr.Status == 429:
 			return ErrRateLimit
@@ -185,10 +286,51 @@ func ClassifyError(err error) ErrorCode {
 		case apiErr.Code == "context_length_exceeded":
 			return ErrContextWindow
 		}
+		return ErrUnknown
+	}
+	var openErr *CircuitOpenError
+	if errors.As(err, &openErr) {
+		return ErrCircuitOpen
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return ErrUnknown
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTimeout
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrNetwork
+	}
+	for _, errno := range []syscall.Errno{syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.EHOSTUNREACH, syscall.ENETUNREACH} {
+		if errors.Is(err, errno) {
+			return ErrNetwork
+		}
+	}
+	// The server hung up mid-response.
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrNetwork
 	}
 	return ErrUnknown
 }
 
+// Retryable reports whether an error class is worth retrying on a different
+// model: rate limits, oversized contexts, server faults, timeouts, network
+// failures and open circuits. Auth errors and malformed requests would fail
+// again.
+func (c ErrorCode) Retryable() bool {
+	switch c {
+	case ErrRateLimit, ErrContextWindow, ErrServerError, ErrTimeout, ErrNetwork, ErrCircuitOpen:
+		return true
+	}
+	return false
+}
+
 // Ensure APIError implements the error interface.
 var _ error = (*APIError)(nil)
 
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// timeoutErr is a net.Error that reports a timeout, like an http.Client
+// Timeout expiring.
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+// transport wraps err the way net/http and an adapter do before it reaches
+// the router.
+func transport(err error) error {
+	return fmt.Errorf("ollama: send request: %w", &url.Error{Op: "Post", URL: "http://ai01:11434/api/chat", Err: err})
+}
+
+func TestClassifyError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}}
+	unreachable := &net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.EHOSTUNREACH}}
+	dns := &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "ai01", IsNotFound: true}}
+
+	cases := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{"nil", nil, ErrUnknown},
+		{"rate limit", &APIError{Status: 429}, ErrRateLimit},
+		{"wrapped server error", fmt.Errorf("openai: %w", &APIError{Status: 503}), ErrServerError},
+		{"bad request", &APIError{Status: 400}, ErrUnknown},
+		{"connection refused", transport(refused), ErrNetwork},
+		{"connection reset", transport(reset), ErrNetwork},
+		{"host unreachable", transport(unreachable), ErrNetwork},
+		{"dns", transport(dns), ErrNetwork},
+		{"unexpected eof", fmt.Errorf("ollama: decode: %w", io.ErrUnexpectedEOF), ErrNetwork},
+		{"client timeout", transport(timeoutErr{}), ErrTimeout},
+		{"context deadline", transport(context.DeadlineExceeded), ErrTimeout},
+		{"context canceled", transport(context.Canceled), ErrUnknown},
+		{"plain error", errors.New("boom"), ErrUnknown},
+	}
+	for _, c := range cases {
+		if got := ClassifyError(c.err); got != c.want {
+			t.Errorf("%s: ClassifyError = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestClassifyError_RealDialFailure(t *testing.T) {
+	// Port 1 on loopback is closed: the dial fails with ECONNREFUSED.
+	client := &http.Client{Timeout: 2 * time.Second}
+	_, err := client.Get("http://127.0.0.1:1/api/tags")
+	if err == nil {
+		t.Skip("something is listening on 127.0.0.1:1")
+	}
+	if got := ClassifyError(fmt.Errorf("ollama: send request: %w", err)); got != ErrNetwork {
+		t.Errorf("ClassifyError(%v) = %q, want %q", err, got, ErrNetwork)
+	}
+}
+
+func TestErrorCodeRetryable(t *testing.T) {
+	for _, c := range []ErrorCode{ErrRateLimit, ErrContextWindow, ErrServerError, ErrTimeout, ErrNetwork} {
+		if !c.Retryable() {
+			t.Errorf("%s should be retryable", c)
+		}
+	}
+	for _, c := range []ErrorCode{ErrAuth, ErrUnknown} {
+		if c.Retryable() {
+			t.Errorf("%s should not be retryable", c)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultMaxResumes is how many times a ResumingStream reconnects after a
+// mid-stream failure before giving up.
+const DefaultMaxResumes = 2
+
+// continuePrompt follows the partial answer when a stream is resumed.
+const continuePrompt = "Your previous response was cut off by a network error. Continue exactly from where it stopped. Do not repeat any text already written and do not add a preamble."
+
+const (
+	// maxSeamOverlap bounds how much received text is compared with the
+	// start of a continuation when removing repeated text at the seam.
+	maxSeamOverlap = 256
+	// minSeamOverlap is the shortest repeat that is removed; shorter matches
+	// are too likely to be coincidence.
+	minSeamOverlap = 4
+)
+
+// OpenStreamFunc starts a streaming request, e.g. a Router method bound to a role.
+type OpenStreamFunc func(ctx context.Context, req *ChatRequest) (ChatStream, error)
+
+// ResumingStream is a ChatStream that survives transient disconnects. When
+// the underlying stream fails mid-response it re-issues the request with the
+// text received so far as an assistant prefill and an instruction to
+// continue, then stitches the continuation on, dropping any text the model
+// repeats at the seam. Resumes are bounded; best effort only.
+type ResumingStream struct {
+	// OnResume, if set, is called before each reconnect with the 1-based
+	// attempt number and the error that ended the previous stream.
+	OnResume func(attempt int, err error)
+
+	ctx        context.Context
+	open       OpenStreamFunc
+	req        *ChatRequest
+	cur        ChatStream
+	received   strings.Builder
+	resumes    int
+	maxResumes int
+
+	seam    bool            // buffering the start of a continuation
+	pending strings.Builder // continuation text held until the overlap is known
+	last    *ChatStreamChunk
+	done    bool
+
+	spent StreamStats // totals of the streams already replaced by a resume
+}
+
+// NewResumingStream opens the first stream for req and returns a stream that
+// resumes up to maxResumes times.
+func NewResumingStream(ctx context.Context, open OpenStreamFunc, req *ChatRequest, maxResumes int) (*ResumingStream, error) {
+	orig := *req
+	orig.Messages = append([]Message(nil), req.Messages...)
+	cur, err := open(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &ResumingStream{ctx: ctx, open: open, req: &orig, cur: cur, maxResumes: maxResumes}, nil
+}
+
+// Next returns the next chunk, reconnecting on resumable errors.
+func (s *ResumingStream) Next() (*ChatStreamChunk, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	for {
+		chunk, err := s.cur.Next()
+		if err == io.EOF {
+			s.done = true
+			if s.seam && s.last != nil {
+				// The continuation ended while still being buffered.
+				out := s.flushSeam(s.last)
+				s.received.WriteString(out.Delta.Content)
+				return out, nil
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			if !resumableStreamError(s.ctx, err) || s.resumes >= s.maxResumes {
+				return nil, err
+			}
+			if rerr := s.resume(err); rerr != nil {
+				return nil, rerr
+			}
+			continue
+		}
+
+		if s.seam {
+			s.pending.WriteString(chunk.Delta.Content)
+			s.last = chunk
+			need := s.received.Len()
+			if need > maxSeamOverlap {
+				need = maxSeamOverlap
+			}
+			if s.pending.Len() < need && !chunk.Done {
+				continue
+			}
+			chunk = s.flushSeam(chunk)
+		}
+		s.received.WriteString(chunk.Delta.Content)
+		if chunk.Done {
+			s.done = true
+		}
+		return chunk, nil
+	}
+}
+
+// Close closes the current underlying stream.
+func (s *ResumingStream) Close() error {
+	return s.cur.Close()
+}
+
+// Stats sums the stats of every stream opened so far, so the prompt re-sent
+// with each continuation is counted too.
+func (s *ResumingStream) Stats() StreamStats {
+	cur := s.cur.Stats()
+	return StreamStats{
+		Chunks:   s.spent.Chunks + cur.Chunks,
+		Complete: cur.Complete,
+		Usage:    addUsage(s.spent.Usage, cur.Usage),
+	}
+}
+
+// Resumes returns how many times the stream has reconnected.
+func (s *ResumingStream) Resumes() int {
+	return s.resumes
+}
+
+// resume closes the failed stream and opens a continuation request.
+func (s *ResumingStream) resume(cause error) error {
+	s.cur.Close()
+	s.spent = s.Stats()
+	s.resumes++
+	if s.OnResume != nil {
+		s.OnResume(s.resumes, cause)
+	}
+
+	next := *s.req
+	next.Messages = append(append([]Message(nil), s.req.Messages...),
+		Message{Role: RoleAssistant, Content: s.received.String()},
+		Message{Role: RoleUser, Content: continuePrompt},
+	)
+	cur, err := s.open(s.ctx, &next)
+	if err != nil {
+		return fmt.Errorf("provider: resume stream after %v: %w", cause, err)
+	}
+	s.cur = cur
+	s.seam = true
+	s.pending.Reset()
+	s.last = nil
+	return nil
+}
+
+// flushSeam returns chunk carrying the buffered continuation text with the
+// repeated prefix removed, and ends seam buffering.
+func (s *ResumingStream) flushSeam(chunk *ChatStreamChunk) *ChatStreamChunk {
+	out := *chunk
+	out.Delta.Content = trimSeamOverlap(s.received.String(), s.pending.String())
+	s.seam = false
+	s.pending.Reset()
+	return &out
+}
+
+// trimSeamOverlap removes from the start of cont the longest prefix that
+// received already ends with, ignoring overlaps shorter than minSeamOverlap.
+func trimSeamOverlap(received, cont string) string {
+	maxK := len(cont)
+	if len(received) < maxK {
+		maxK = len(received)
+	}
+	if maxK > maxSeamOverlap {
+		maxK = maxSeamOverlap
+	}
+	for k := maxK; k >= minSeamOverlap; k-- {
+		if strings.HasSuffix(received, cont[:k]) {
+			return cont[k:]
+		}
+	}
+	return cont
+}
+
+// resumableStreamError reports whether a mid-stream error is worth a resume:
+// not a cancellation and not a client error the provider will repeat.
+func resumableStreamError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Status >= 400 && apiErr.Status < 500 && apiErr.Status != 429 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// tokenStream yields one chunk per token, then fails with err (if non-nil)
+// or marks the last chunk done.
+type tokenStream struct {
+	tokens []string
+	err    error
+	pos    int
+}
+
+func (s *tokenStream) Next() (*ChatStreamChunk, error) {
+	if s.pos >= len(s.tokens) {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	tok := s.tokens[s.pos]
+	s.pos++
+	return &ChatStreamChunk{
+		Delta: MessageDelta{Content: tok},
+		Done:  s.err == nil && s.pos == len(s.tokens),
+	}, nil
+}
+
+func (s *tokenStream) Close() error { return nil }
+
+func (s *tokenStream) Stats() StreamStats { return StreamStats{} }
+
+// drain reads a stream to the end and returns the concatenated content.
+func drain(t *testing.T, s ChatStream) (string, error) {
+	t.Helper()
+	var sb strings.Builder
+	for {
+		chunk, err := s.Next()
+		if err == io.EOF {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+		sb.WriteString(chunk.Delta.Content)
+	}
+}
+
+func TestResumingStream_StitchesAfterDrop(t *testing.T) {
+	dropped := fmt.Errorf("read tcp: connection reset by peer")
+	streams := []*tokenStream{
+		{tokens: []string{"func main() {\n", "\tfmt.Println(", `"hel`}, err: dropped},
+		// The model repeats part of what it already sent before continuing.
+		{tokens: []string{`Println("hel`, `lo")`, "\n}\n"}},
+	}
+	var reqs []*ChatRequest
+	open := func(_ context.Context, req *ChatRequest) (ChatStream, error) {
+		reqs = append(reqs, req)
+		return streams[len(reqs)-1], nil
+	}
+
+	req := &ChatRequest{Model: "m", Messages: []Message{{Role: RoleUser, Content: "write main"}}}
+	s, err := NewResumingStream(context.Background(), open, req, DefaultMaxResumes)
+	if err != nil {
+		t.Fatalf("NewResumingStream: %v", err)
+	}
+	var resumed []error
+	s.OnResume = func(_ int, err error) { resumed = append(resumed, err) }
+
+	got, err := drain(t, s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "func main() {\n\tfmt.Println(\"hello\")\n}\n"
+	if got != want {
+		t.Errorf("stitched content = %q, want %q", got, want)
+	}
+	if s.Resumes() != 1 || len(resumed) != 1 || resumed[0] != dropped {
+		t.Errorf("expected one resume caused by the drop, got %d (%v)", s.Resumes(), resumed)
+	}
+
+	cont := reqs[1].Messages
+	if len(cont) != 3 || cont[1].Role != RoleAssistant || cont[1].Content != "func main() {\n\tfmt.Println(\"hel" || cont[2].Role != RoleUser {
+		t.Errorf("unexpected continuation messages: %+v", cont)
+	}
+	if len(req.Messages) != 1 {
+		t.Errorf("original request was modified: %+v", req.Messages)
+	}
+}
+
+// statsStream is a tokenStream reporting fixed stats.
+type statsStream struct {
+	tokenStream
+	stats StreamStats
+}
+
+func (s *statsStream) Stats() StreamStats { return s.stats }
+
+func TestResumingStream_StatsSpanResumes(t *testing.T) {
+	streams := []*statsStream{
+		{
+			tokenStream: tokenStream{tokens: []string{"partial "}, err: fmt.Errorf("unexpected EOF")},
+			stats:       StreamStats{Chunks: 1, Usage: Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12, Estimated: true}},
+		},
+		{
+			tokenStream: tokenStream{tokens: []string{"answer"}},
+			stats:       StreamStats{Chunks: 1, Complete: true, Usage: Usage{PromptTokens: 25, CompletionTokens: 3, TotalTokens: 28}},
+		},
+	}
+	n := 0
+	open := func(context.Context, *ChatRequest) (ChatStream, error) {
+		n++
+		return streams[n-1], nil
+	}
+
+	s, err := NewResumingStream(context.Background(), open, &ChatRequest{Model: "m"}, DefaultMaxResumes)
+	if err != nil {
+		t.Fatalf("NewResumingStream: %v", err)
+	}
+	if _, err := drain(t, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The continuation re-sends the prompt, so both attempts are billed.
+	want := StreamStats{
+		Chunks:   2,
+		Complete: true,
+		Usage:    Usage{PromptTokens: 35, CompletionTokens: 5, TotalTokens: 40, Estimated: true},
+	}
+	if got := s.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResumingStream_GivesUpAfterMaxResumes(t *testing.T) {
+	opens := 0
+	open := func(context.Context, *ChatRequest) (ChatStream, error) {
+		opens++
+		return &tokenStream{tokens: []string{"partial "}, err: fmt.Errorf("unexpected EOF")}, nil
+	}
+	s, err := NewResumingStream(context.Background(), open, &ChatRequest{Model: "m"}, 2)
+	if err != nil {
+		t.Fatalf("NewResumingStream: %v", err)
+	}
+	if _, err := drain(t, s); err == nil {
+		t.Fatal("expected error after exhausting resumes")
+	}
+	if opens != 3 {
+		t.Errorf("expected 1 open + 2 resumes, got %d opens", opens)
+	}
+}
+
+func TestResumingStream_DoesNotResumeClientErrors(t *testing.T) {
+	opens := 0
+	open := func(context.Context, *ChatRequest) (ChatStream, error) {
+		opens++
+		return &tokenStream{tokens: []string{"x"}, err: &APIError{Status: 400, Message: "bad request"}}, nil
+	}
+	s, _ := NewResumingStream(context.Background(), open, &ChatRequest{Model: "m"}, 2)
+	if _, err := drain(t, s); err == nil {
+		t.Fatal("expected the 400 to be returned")
+	}
+	if opens != 1 {
+		t.Errorf("client error should not trigger a resume, got %d opens", opens)
+	}
+}
+
+func TestTrimSeamOverlap(t *testing.T) {
+	tests := []struct {
+		received, cont, want string
+	}{
+		{"hello worl", "world", "d"},
+		{"hello worl", "d!", "d!"},
+		{"abc", "cat", "cat"}, // overlaps under minSeamOverlap are kept
+		{"", "anything", "anything"},
+	}
+	for _, tt := range tests {
+		if got := trimSeamOverlap(tt.received, tt.cont); got != tt.want {
+			t.Errorf("trimSeamOverlap(%q, %q) = %q, want %q", tt.received, tt.cont, got, tt.want)
+		}
+	}
+}
@@ -13,17 +13,80 @@ type ProviderFactory func(cfg ProviderConfig) (Provider, error)
 // Router routes chat requests to the appropriate provider based on config.
 // It manages provider instances and handles model alias resolution.
 type Router struct {
-	config    *Config
-	providers map[string]Provider // keyed by provider config name
-	mu        sync.RWMutex
+	config     *Config
+	providers  map[string]Provider // keyed by provider config name
+	mu         sync.RWMutex
+	onFallback func(FallbackEvent) // optional fallback observer
+	circuits   *circuits           // per-model circuit breakers; nil = disabled
+
+	models         *modelCache // ListAllModels cache; nil = disabled
+	modelCachePath string      // file backing models; "" = in-process only
+	modelDetails   bool        // describe listed models; see WithModelDetails
+
+	log        Logger             // never nil; see WithLogger
+	traceHooks []func(TraceEvent) // see WithTraceHook
+}
+
+// FallbackEvent describes a single fallback hop: a request that failed on From
+// being retried on To.
+type FallbackEvent struct {
+	Role   string    // role being served; empty for alias-based requests
+	From   string    // model alias (or reference) that failed
+	To     string    // fallback model alias being tried next
+	Code   ErrorCode // classification of the error that triggered the hop
+	Reason string    // error message from From
+}
+
+// SetFallbackHook registers a callback invoked before each fallback attempt.
+// It must be set before the router is used; the callback may be invoked
+// concurrently and is responsible for its own synchronization.
+func (r *Router) SetFallbackHook(fn func(FallbackEvent)) {
+	r.onFallback = fn
+}
+
+// emitFallback logs and traces a fallback hop and reports it to the
+// registered hook, if any.
+func (r *Router) emitFallback(role, from, to string, err error) {
+	r.log.Info("fallback", "role", role, "from", from, "to", to, "code", ClassifyError(err), "err", err)
+	r.trace(TraceEvent{Kind: TraceFallback, Role: role, From: from, To: to, Err: err})
+	if r.onFallback == nil {
+		return
+	}
+	r.onFallback(FallbackEvent{
+		Role:   role,
+		From:   from,
+		To:     to,
+		Code:   ClassifyError(err),
+		Reason: err.Error(),
+	})
+}
+
+// ModelRef identifies a concrete model a role can be routed to.
+type ModelRef struct {
+	Alias    string // model alias from the models section
+	Provider string // provider name from the providers section
+	Model    string // actual model name sent to the provider
 }
 
 // NewRouter creates a router from config and a set of provider factories.
 // The factories map provider type names (e.g., "openai") to their constructors.
-func NewRouter(cfg *Config, factories map[string]ProviderFactory) (*Router, error) {
+func NewRouter(cfg *Config, factories map[string]ProviderFactory, opts ...RouterOption) (*Router, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("router: nil config")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("router: %w", err)
+	}
 	r := &Router{
 		config:    cfg,
 		providers: make(map[string]Provider),
+		log:       nopLogger{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.models != nil {
+		r.models.path = r.modelCachePath
 	}
 	// Initialize all configured providers.
 	for name, pc := range cfg.Providers {
@@ -44,22 +107,39 @@ func NewRouter(cfg *Config, factories map[string]ProviderFactory) (*Router, erro
 // model field in the request. The model field can be a direct model name
 // (prefixed with provider, e.g., "openai/gpt-4") or a model alias from config.
 func (r *Router) ChatCompletion(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	addSystemPrefix(req, r.config.Defaults.SystemPrefix)
 	p, model, err := r.resolve(req.Model)
 	if err != nil {
 		return nil, err
 	}
+	r.log.Debug("model resolved", "ref", req.Model, "provider", p.Name(), "model", model)
+	r.traceRetry(ctx, "", model, false)
 	req.Model = model
-	return p.ChatCompletion(ctx, req)
+	resp, err := r.chat(ctx, "", p, req)
+	if err != nil {
+		return nil, err
+	}
+	fillUsage(req, resp)
+	return resp, nil
 }
 
 // StreamChatCompletion routes a streaming request to the appropriate provider.
 func (r *Router) StreamChatCompletion(ctx context.Context, req *ChatRequest) (ChatStream, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	addSystemPrefix(req, r.config.Defaults.SystemPrefix)
 	p, model, err := r.resolve(req.Model)
 	if err != nil {
 		return nil, err
 	}
+	r.log.Debug("model resolved", "ref", req.Model, "provider", p.Name(), "model", model)
+	r.traceRetry(ctx, "", model, true)
 	req.Model = model
-	return p.StreamChatCompletion(ctx, req)
+	return r.stream(ctx, "", p, req)
 }
 
 // ChatCompletionForRole routes a request using the role's configured model.
@@ -72,14 +152,57 @@ func (r *Router) ChatCompletionForRole(ctx context.Context, role string, req *Ch
 	if err != nil {
 		return nil, err
 	}
+	r.log.Debug("role resolved", "role", role, "provider", p.Name(), "model", model)
 	req.Model = model
-	resp, err := p.ChatCompletion(ctx, req)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	addSystemPrefix(req, r.config.Defaults.SystemPrefix)
+	r.traceRetry(ctx, role, model, false)
+	resp, err := r.chat(ctx, role, p, req)
 	if err != nil {
-		return r.tryFallbacks(ctx, role, req, err)
+		resp, err = r.tryFallbacks(ctx, role, req, err)
+		if err != nil {
+			return nil, err
+		}
 	}
+	fillUsage(req, resp)
 	return resp, nil
 }
 
+// ModelFor reports where a role's requests are routed without making a call:
+// the provider name and model of the primary, followed by the fallback chain
+// in the order it would be tried. Fallback aliases that do not resolve are
+// omitted, matching how they are skipped at request time.
+func (r *Router) ModelFor(role string) (provider, model string, fallbacks []ModelRef, err error) {
+	alias, err := r.config.roleModelAlias(role)
+	if err != nil {
+		return "", "", nil, err
+	}
+	primary, err := r.config.modelRef(alias)
+	if err != nil {
+		return "", "", nil, err
+	}
+	for _, fb := range r.config.FallbacksForRole(role) {
+		ref, err := r.config.modelRef(fb)
+		if err != nil {
+			continue
+		}
+		fallbacks = append(fallbacks, ref)
+	}
+	return primary.Provider, primary.Model, fallbacks, nil
+}
+
+// Prompt returns the config's override for a prompt key (see
+// Config.Prompts), or "" when the built-in default applies. It is safe to
+// call on a nil Router, so role constructors can consult it unconditionally.
+func (r *Router) Prompt(key string) string {
+	if r == nil {
+		return ""
+	}
+	return r.config.Prompt(key)
+}
+
 // StreamChatCompletionForRole routes a streaming request using the role's configured model.
 func (r *Router) StreamChatCompletionForRole(ctx context.Context, role string, req *ChatRequest) (ChatStream, error) {
 	pc, model, err := r.config.ResolveRole(role)
@@ -90,43 +213,55 @@ func (r *Router) StreamChatCompletionForRole(ctx context.Context, role string, r
 	if err != nil {
 		return nil, err
 	}
+	r.log.Debug("role resolved", "role", role, "provider", p.Name(), "model", model)
 	req.Model = model
-	stream, err := p.StreamChatCompletion(ctx, req)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	addSystemPrefix(req, r.config.Defaults.SystemPrefix)
+	r.traceRetry(ctx, role, model, true)
+	stream, err := r.stream(ctx, role, p, req)
 	if err != nil {
 		return r.tryStreamFallbacks(ctx, role, req, err)
 	}
 	return stream, nil
 }
 
-// ListAllModels returns models from all configured providers.
-func (r *Router) ListAllModels(ctx context.Context) ([]Model, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// Ping checks that the provider behind a model reference is reachable by
+// asking it to list its models. modelRef is resolved like ChatCompletion's
+// model field.
+func (r *Router) Ping(ctx context.Context, modelRef string) error {
+	p, _, err := r.resolve(modelRef)
+	if err != nil {
+		return err
+	}
+	_, err = p.ListModels(ctx)
+	return err
+}
 
-	var all []Model
-	for _, p := range r.providers {
-		models, err := p.ListModels(ctx)
-		if err != nil {
-			continue // skip providers that fail to list
-		}
-		all = append(all, models...)
+// PingProvider is Ping for the provider configured under name, without
+// resolving name as a model alias first.
+func (r *Router) PingProvider(ctx context.Context, name string) error {
+	r.mu.RLock()
+	p, ok := r.providers[name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("router: unknown provider %q", name)
 	}
-	return all, nil
+	_, err := p.ListModels(ctx)
+	return err
 }
 
 // ChatCompletionWithFallbacks routes a request by model alias, trying the given
 // fallback aliases in order if the primary fails with a retryable error.
 func (r *Router) ChatCompletionWithFallbacks(ctx context.Context, req *ChatRequest, fallbacks []string) (*ChatResponse, error) {
+	from, primaryRef := req.Model, req.Model
 	resp, err := r.ChatCompletion(ctx, req)
 	if err == nil || len(fallbacks) == 0 {
 		return resp, err
 	}
 
-	errCode := ClassifyError(err)
-	switch errCode {
-	case ErrRateLimit, ErrContextWindow, ErrServerError, ErrTimeout:
-		// Worth retrying with a different model.
-	default:
+	if !ClassifyError(err).Retryable() {
 		return nil, err
 	}
 
@@ -140,12 +275,16 @@ func (r *Router) ChatCompletionWithFallbacks(ctx context.Context, req *ChatReque
 		if pErr != nil {
 			continue
 		}
+		r.emitFallback("", from, fb, err)
 		req.Model = model
-		resp, err = p.ChatCompletion(ctx, req)
+		resp, err = r.chat(ctx, "", p, req)
 		if err == nil {
+			fillUsage(req, resp)
 			return resp, nil
 		}
+		from = fb
 	}
+	r.log.Error("fallbacks exhausted", "model", primaryRef, "err", primaryErr)
 	return nil, fmt.Errorf("router: all fallbacks exhausted for model (primary error: %w)", primaryErr)
 }
 
@@ -190,22 +329,26 @@ func (r *Router) providerFor(pc ProviderConfig) (Provider, error) {
 
 // tryFallbacks attempts fallback models for a role after the primary fails.
 func (r *Router) tryFallbacks(ctx context.Context, role string, req *ChatRequest, primaryErr error) (*ChatResponse, error) {
-	fallbacks := r.config.FallbacksForRole(role)
+	fallbacks := r.config.fallbackEntries(role)
 	if len(fallbacks) == 0 {
 		return nil, primaryErr
 	}
 
-	errCode := ClassifyError(primaryErr)
 	// Only fall back on retryable errors.
-	switch errCode {
-	case ErrRateLimit, ErrContextWindow, ErrServerError, ErrTimeout:
-		// These are worth retrying with a different model.
-	default:
+	if !ClassifyError(primaryErr).Retryable() {
 		return nil, primaryErr
 	}
 
+	// A fallback limited to some failures is skipped unless the latest
+	// failure is one of them.
+	from, _ := r.config.roleModelAlias(role)
+	lastErr := primaryErr
 	for _, fb := range fallbacks {
-		pc, model, err := r.config.ResolveModel(fb)
+		if code := ClassifyError(lastErr); !fb.Handles(code) {
+			r.log.Debug("fallback skipped", "role", role, "fallback", fb.Model, "code", code)
+			continue
+		}
+		pc, model, err := r.config.ResolveModel(fb.Model)
 		if err != nil {
 			continue
 		}
@@ -213,33 +356,40 @@ func (r *Router) tryFallbacks(ctx context.Context, role string, req *ChatRequest
 		if err != nil {
 			continue
 		}
+		r.emitFallback(role, from, fb.Model, lastErr)
 		req.Model = model
-		resp, err := p.ChatCompletion(ctx, req)
+		resp, err := r.chat(ctx, role, p, req)
 		if err == nil {
 			return resp, nil
 		}
+		from, lastErr = fb.Model, err
 	}
+	r.log.Error("fallbacks exhausted", "role", role, "err", primaryErr)
 	return nil, fmt.Errorf("router: all fallbacks exhausted for role %q (primary error: %w)", role, primaryErr)
 }
 
 // tryStreamFallbacks attempts fallback models for streaming after the primary fails.
 func (r *Router) tryStreamFallbacks(ctx context.Context, role string, req *ChatRequest, primaryErr error) (ChatStream, error) {
-	fallbacks := r.config.FallbacksForRole(role)
+	fallbacks := r.config.fallbackEntries(role)
 	if len(fallbacks) == 0 {
 		return nil, primaryErr
 	}
 
-	errCode := ClassifyError(primaryErr)
 	// Only fall back on retryable errors.
-	switch errCode {
-	case ErrRateLimit, ErrContextWindow, ErrServerError, ErrTimeout:
-		// These are worth retrying with a different model.
-	default:
+	if !ClassifyError(primaryErr).Retryable() {
 		return nil, primaryErr
 	}
 
+	// A fallback limited to some failures is skipped unless the latest
+	// failure is one of them.
+	from, _ := r.config.roleModelAlias(role)
+	lastErr := primaryErr
 	for _, fb := range fallbacks {
-		pc, model, err := r.config.ResolveModel(fb)
+		if code := ClassifyError(lastErr); !fb.Handles(code) {
+			r.log.Debug("fallback skipped", "role", role, "fallback", fb.Model, "code", code)
+			continue
+		}
+		pc, model, err := r.config.ResolveModel(fb.Model)
 		if err != nil {
 			continue
 		}
@@ -247,11 +397,14 @@ func (r *Router) tryStreamFallbacks(ctx context.Context, role string, req *ChatR
 		if err != nil {
 			continue
 		}
+		r.emitFallback(role, from, fb.Model, lastErr)
 		req.Model = model
-		stream, err := p.StreamChatCompletion(ctx, req)
+		stream, err := r.stream(ctx, role, p, req)
 		if err == nil {
 			return stream, nil
 		}
+		from, lastErr = fb.Model, err
 	}
+	r.log.Error("stream fallbacks exhausted", "role", role, "err", primaryErr)
 	return nil, fmt.Errorf("router: all stream fallbacks exhausted for role %q (primary error: %w)", role, primaryErr)
 }
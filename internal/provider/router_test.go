@@ -3,6 +3,10 @@ package provider
 import (
 	"context"
 	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
 	"testing"
 )
 
@@ -67,6 +71,8 @@ func (s *mockStream) Next() (*ChatStreamChunk, error) {
 
 func (s *mockStream) Close() error { return nil }
 
+func (s *mockStream) Stats() StreamStats { return StreamStats{} }
+
 // ---------------------------------------------------------------------------
 // Test config and helpers
 // ---------------------------------------------------------------------------
@@ -95,7 +101,7 @@ func routerTestConfig() *Config {
 			"model-b": {Provider: "fallback", Model: "real-model-b"},
 		},
 		Roles: map[string]RoleConfig{
-			"leader": {Model: "model-a", Fallbacks: []string{"model-b"}},
+			"leader": {Model: "model-a", Fallbacks: Fallbacks("model-b")},
 			"worker": {Model: "model-a"},
 		},
 		Defaults: DefaultsConfig{
@@ -226,6 +232,31 @@ func TestRouterFallbackOnServerError(t *testing.T) {
 	}
 }
 
+func TestRouterFallbackOnNetworkError(t *testing.T) {
+	primary := &mockProvider{
+		name: "primary",
+		chatFn: func(_ context.Context, _ *ChatRequest) (*ChatResponse, error) {
+			return nil, transport(&net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}})
+		},
+	}
+	fallback := &mockProvider{
+		name: "fallback",
+		chatFn: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+			return &ChatResponse{ID: "fb-net", Model: req.Model, Done: true}, nil
+		},
+	}
+	r := newTestRouter(t, primary, fallback)
+
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "help"}}}
+	resp, err := r.ChatCompletionForRole(context.Background(), "leader", req)
+	if err != nil {
+		t.Fatalf("expected fallback on connection refused, got error: %v", err)
+	}
+	if resp.ID != "fb-net" {
+		t.Errorf("expected fb-net, got %s", resp.ID)
+	}
+}
+
 func TestRouterNoFallbackOnAuthError(t *testing.T) {
 	primary := &mockProvider{
 		name: "primary",
@@ -439,6 +470,159 @@ func TestRouterNoFallbacksConfigured(t *testing.T) {
 	}
 }
 
+func TestRouterFallbackHook(t *testing.T) {
+	primary := &mockProvider{
+		name: "primary",
+		chatFn: func(_ context.Context, _ *ChatRequest) (*ChatResponse, error) {
+			return nil, &APIError{Status: 429, Code: "rate_limit", Message: "slow down"}
+		},
+	}
+	fallback := &mockProvider{
+		name: "fallback",
+		chatFn: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+			return &ChatResponse{ID: "fb", Model: req.Model, Done: true}, nil
+		},
+	}
+	r := newTestRouter(t, primary, fallback)
+
+	var events []FallbackEvent
+	r.SetFallbackHook(func(ev FallbackEvent) { events = append(events, ev) })
+
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	resp, err := r.ChatCompletionForRole(context.Background(), "leader", req)
+	if err != nil {
+		t.Fatalf("ChatCompletionForRole error: %v", err)
+	}
+	if resp.Model != "real-model-b" {
+		t.Errorf("expected response served by real-model-b, got %s", resp.Model)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 fallback event, got %d: %+v", len(events), events)
+	}
+	ev := events[0]
+	if ev.Role != "leader" || ev.From != "model-a" || ev.To != "model-b" {
+		t.Errorf("unexpected event routing: %+v", ev)
+	}
+	if ev.Code != ErrRateLimit {
+		t.Errorf("Code = %q, want %q", ev.Code, ErrRateLimit)
+	}
+	if !containsSubstring(ev.Reason, "slow down") {
+		t.Errorf("Reason = %q, want it to contain the primary error", ev.Reason)
+	}
+}
+
+func TestRouterFallbackHook_WithFallbacks(t *testing.T) {
+	primary := &mockProvider{
+		name: "primary",
+		chatFn: func(_ context.Context, _ *ChatRequest) (*ChatResponse, error) {
+			return nil, &APIError{Status: 503, Code: "server_error", Message: "overloaded"}
+		},
+	}
+	fallback := &mockProvider{name: "fallback"}
+	r := newTestRouter(t, primary, fallback)
+
+	var events []FallbackEvent
+	r.SetFallbackHook(func(ev FallbackEvent) { events = append(events, ev) })
+
+	req := &ChatRequest{Model: "model-a", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if _, err := r.ChatCompletionWithFallbacks(context.Background(), req, []string{"model-b"}); err != nil {
+		t.Fatalf("ChatCompletionWithFallbacks error: %v", err)
+	}
+	if len(events) != 1 || events[0].From != "model-a" || events[0].To != "model-b" || events[0].Role != "" {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestRouterModelFor(t *testing.T) {
+	r := newTestRouter(t, &mockProvider{name: "primary"}, &mockProvider{name: "fallback"})
+
+	prov, model, fallbacks, err := r.ModelFor("leader")
+	if err != nil {
+		t.Fatalf("ModelFor error: %v", err)
+	}
+	if prov != "primary" || model != "real-model-a" {
+		t.Errorf("primary = %s/%s, want primary/real-model-a", prov, model)
+	}
+	want := []ModelRef{{Alias: "model-b", Provider: "fallback", Model: "real-model-b"}}
+	if len(fallbacks) != len(want) || fallbacks[0] != want[0] {
+		t.Errorf("fallbacks = %+v, want %+v", fallbacks, want)
+	}
+
+	// Unconfigured roles resolve through the defaults.
+	prov, model, fallbacks, err = r.ModelFor("unconfigured")
+	if err != nil {
+		t.Fatalf("ModelFor(unconfigured) error: %v", err)
+	}
+	if prov != "primary" || model != "real-model-a" || len(fallbacks) != 0 {
+		t.Errorf("unconfigured = %s/%s %+v, want primary/real-model-a with no fallbacks", prov, model, fallbacks)
+	}
+}
+
+func TestRouterModelFor_NoDefault(t *testing.T) {
+	r := newTestRouter(t, &mockProvider{name: "primary"}, &mockProvider{name: "fallback"})
+	r.config.Defaults.Model = ""
+
+	if _, _, _, err := r.ModelFor("unconfigured"); err == nil {
+		t.Fatal("expected error for unconfigured role with no default")
+	}
+}
+
+func TestRouterEstimatesMissingUsage(t *testing.T) {
+	primary := &mockProvider{
+		name: "primary",
+		chatFn: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+			return &ChatResponse{
+				ID:      "no-usage",
+				Model:   req.Model,
+				Message: Message{Role: RoleAssistant, Content: "12345678"},
+				Done:    true,
+			}, nil
+		},
+	}
+	fallback := &mockProvider{name: "fallback"}
+	r := newTestRouter(t, primary, fallback)
+
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "1234567890123456"}}}
+	resp, err := r.ChatCompletionForRole(context.Background(), "leader", req)
+	if err != nil {
+		t.Fatalf("ChatCompletionForRole error: %v", err)
+	}
+	if !resp.Usage.Estimated {
+		t.Error("expected usage to be marked estimated")
+	}
+	if resp.Usage.PromptTokens != 4 || resp.Usage.CompletionTokens != 2 || resp.Usage.TotalTokens != 6 {
+		t.Errorf("usage = %+v, want prompt=4 completion=2 total=6", resp.Usage)
+	}
+}
+
+func TestRouterKeepsReportedUsage(t *testing.T) {
+	primary := &mockProvider{
+		name: "primary",
+		chatFn: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+			return &ChatResponse{
+				ID:    "usage",
+				Model: req.Model,
+				Usage: Usage{PromptTokens: 10, CompletionTokens: 5},
+				Done:  true,
+			}, nil
+		},
+	}
+	fallback := &mockProvider{name: "fallback"}
+	r := newTestRouter(t, primary, fallback)
+
+	req := &ChatRequest{Model: "model-a", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	resp, err := r.ChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatCompletion error: %v", err)
+	}
+	if resp.Usage.Estimated {
+		t.Error("reported usage should not be marked estimated")
+	}
+	if resp.Usage.TotalTokens != 15 {
+		t.Errorf("TotalTokens = %d, want 15 (derived from prompt+completion)", resp.Usage.TotalTokens)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Helpers
 // ---------------------------------------------------------------------------
@@ -455,3 +639,147 @@ func searchSubstring(s, sub string) bool {
 	}
 	return false
 }
+
+func TestConfigValidate_InCode(t *testing.T) {
+	if err := routerTestConfig().Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestNewRouter_ValidatesConfig(t *testing.T) {
+	cfg := routerTestConfig()
+	cfg.Roles["worker"] = RoleConfig{Model: "model-z"}
+	factories := map[string]ProviderFactory{
+		"mock-primary":  func(ProviderConfig) (Provider, error) { return &mockProvider{name: "primary"}, nil },
+		"mock-fallback": func(ProviderConfig) (Provider, error) { return &mockProvider{name: "fallback"}, nil },
+	}
+
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `role "worker" references unknown model alias "model-z"`) {
+		t.Errorf("Validate = %v, want the bad role reference", err)
+	}
+	if _, err := NewRouter(cfg, factories); err == nil || !strings.Contains(err.Error(), "model-z") {
+		t.Errorf("NewRouter = %v, want the validation error", err)
+	}
+	if _, err := NewRouter(nil, factories); err == nil {
+		t.Error("NewRouter accepted a nil config")
+	}
+}
+
+func TestNewRouter_EnvBasicKeyCheckedAtRuntime(t *testing.T) {
+	t.Setenv("ET_TEST_BASIC_CREDS", "")
+	cfg, err := ParseConfig([]byte(`
+providers:
+  test:
+    type: mock-primary
+    api_key: $ET_TEST_BASIC_CREDS
+    auth_type: basic
+models:
+  m:
+    provider: test
+    model: llama3
+defaults:
+  model: m
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	factories := map[string]ProviderFactory{
+		"mock-primary": func(ProviderConfig) (Provider, error) { return &mockProvider{name: "test"}, nil },
+	}
+	if _, err := NewRouter(cfg, factories); err != nil {
+		t.Errorf("NewRouter rejected an environment basic-auth key ParseConfig deferred: %v", err)
+	}
+}
+
+// conditionalRouter routes role "worker" to "primary", falling back to
+// "local" on rate limits, "cloud" on server errors and timeouts, and
+// "spare" on anything retryable. fail maps a provider to the error it
+// returns; called records the providers tried, in order.
+func conditionalRouter(t *testing.T, fail map[string]error, called *[]string) *Router {
+	t.Helper()
+	cfg := &Config{
+		Providers: map[string]ProviderConfig{},
+		Models:    map[string]ModelConfig{},
+		Roles: map[string]RoleConfig{
+			"worker": {Model: "primary", Fallbacks: []FallbackEntry{
+				{Model: "local", On: []ErrorCode{ErrRateLimit}},
+				{Model: "cloud", On: []ErrorCode{ErrServerError, ErrTimeout}},
+				{Model: "spare"},
+			}},
+		},
+	}
+	factories := map[string]ProviderFactory{}
+	for _, name := range []string{"primary", "local", "cloud", "spare"} {
+		cfg.Providers[name] = ProviderConfig{Type: "mock-" + name, BaseURL: "http://" + name}
+		cfg.Models[name] = ModelConfig{Provider: name, Model: name + "-model"}
+		mp := &mockProvider{
+			name: name,
+			chatFn: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+				*called = append(*called, name)
+				if err := fail[name]; err != nil {
+					return nil, err
+				}
+				return &ChatResponse{ID: name, Model: req.Model, Done: true}, nil
+			},
+			streamFn: func(_ context.Context, req *ChatRequest) (ChatStream, error) {
+				*called = append(*called, name)
+				if err := fail[name]; err != nil {
+					return nil, err
+				}
+				return &mockStream{model: req.Model}, nil
+			},
+		}
+		factories["mock-"+name] = func(ProviderConfig) (Provider, error) { return mp, nil }
+	}
+	r, err := NewRouter(cfg, factories)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r
+}
+
+func TestRouterConditionalFallbacks(t *testing.T) {
+	rateLimited := &APIError{Status: 429, Message: "slow down"}
+	down := &APIError{Status: 503, Message: "unavailable"}
+	tests := []struct {
+		name   string
+		fail   map[string]error
+		want   []string // providers tried
+		wantOK bool
+	}{
+		{"rate limit goes local", map[string]error{"primary": rateLimited}, []string{"primary", "local"}, true},
+		{"server error skips local", map[string]error{"primary": down}, []string{"primary", "cloud"}, true},
+		{"conditions follow the latest failure", map[string]error{"primary": rateLimited, "local": down}, []string{"primary", "local", "cloud"}, true},
+		{"unconditional catches the rest", map[string]error{"primary": down, "cloud": rateLimited}, []string{"primary", "cloud", "spare"}, true},
+		{"network failure only matches unconditional", map[string]error{"primary": &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, []string{"primary", "spare"}, true},
+		{"not retryable", map[string]error{"primary": &APIError{Status: 401, Message: "bad key"}}, []string{"primary"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called []string
+			r := conditionalRouter(t, tt.fail, &called)
+			req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+			_, err := r.ChatCompletionForRole(context.Background(), "worker", req)
+			if (err == nil) != tt.wantOK {
+				t.Errorf("err = %v, want success %v", err, tt.wantOK)
+			}
+			if strings.Join(called, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("tried %v, want %v", called, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterConditionalStreamFallbacks(t *testing.T) {
+	var called []string
+	r := conditionalRouter(t, map[string]error{"primary": &APIError{Status: 500, Message: "boom"}}, &called)
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	stream, err := r.StreamChatCompletionForRole(context.Background(), "worker", req)
+	if err != nil {
+		t.Fatalf("StreamChatCompletionForRole: %v", err)
+	}
+	stream.Close()
+	if got := strings.Join(called, ","); got != "primary,cloud" {
+		t.Errorf("tried %s, want primary,cloud", got)
+	}
+}
@@ -0,0 +1,197 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RequestSigner authenticates an outgoing HTTP request just before it is sent,
+// after the adapter has set its own headers. body is the exact request body,
+// for signing schemes that hash it. Adapters that support signing default to
+// NoopSigner.
+type RequestSigner interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// SignerFunc adapts a function to the RequestSigner interface.
+type SignerFunc func(req *http.Request, body []byte) error
+
+// Sign calls f(req, body).
+func (f SignerFunc) Sign(req *http.Request, body []byte) error { return f(req, body) }
+
+// NoopSigner leaves requests unchanged.
+var NoopSigner RequestSigner = SignerFunc(func(*http.Request, []byte) error { return nil })
+
+// AWSSigV4Signer signs requests with AWS Signature Version 4, as required by
+// AWS Bedrock. It signs the host, x-amz-date, content-type and any other
+// x-amz-* headers.
+type AWSSigV4Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // optional; sent as X-Amz-Security-Token
+	Region          string
+	Service         string // e.g. "bedrock"
+
+	now func() time.Time // for tests; nil = time.Now
+}
+
+// NewAWSSigV4SignerFromEnv builds a signer from the standard AWS environment
+// variables (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN).
+// An empty region falls back to AWS_REGION, then AWS_DEFAULT_REGION.
+func NewAWSSigV4SignerFromEnv(region, service string) (*AWSSigV4Signer, error) {
+	s := &AWSSigV4Signer{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Region:          region,
+		Service:         service,
+	}
+	if s.Region == "" {
+		s.Region = os.Getenv("AWS_REGION")
+	}
+	if s.Region == "" {
+		s.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	switch {
+	case s.AccessKeyID == "" || s.SecretAccessKey == "":
+		return nil, fmt.Errorf("sigv4: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	case s.Region == "":
+		return nil, fmt.Errorf("sigv4: no region in base_url and AWS_REGION is not set")
+	}
+	return s, nil
+}
+
+// AWSRegionFromURL extracts the region from an AWS endpoint URL such as
+// https://bedrock-runtime.us-east-1.amazonaws.com, or returns "".
+func AWSRegionFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(u.Hostname(), ".")
+	if len(parts) < 4 || parts[len(parts)-2] != "amazonaws" {
+		return ""
+	}
+	return parts[1]
+}
+
+// Sign adds X-Amz-Date (and X-Amz-Security-Token, if set) and an
+// Authorization header carrying the SigV4 signature.
+func (s *AWSSigV4Signer) Sign(req *http.Request, body []byte) error {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	// Canonical headers: host plus content-type and x-amz-*, lowercased and
+	// sorted.
+	headers := map[string]string{"host": req.URL.Host}
+	if req.Host != "" {
+		headers["host"] = req.Host
+	}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if lk == "content-type" || strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	payloadHash := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		req.Method,
+		sigv4Path(req.URL),
+		sigv4Query(req.URL),
+		canonHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sigv4Path returns the canonical URI: each segment of the escaped path
+// URI-encoded again, as SigV4 requires for every service but S3.
+func sigv4Path(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		segs[i] = sigv4Escape(seg)
+	}
+	return strings.Join(segs, "/")
+}
+
+// sigv4Query returns the canonical query string: encoded pairs sorted by key
+// then value.
+func sigv4Query(u *url.URL) string {
+	q := u.Query()
+	var pairs []string
+	for k, vs := range q {
+		for _, v := range vs {
+			pairs = append(pairs, sigv4Escape(k)+"="+sigv4Escape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// sigv4Escape percent-encodes everything except the RFC 3986 unreserved
+// characters.
+func sigv4Escape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAWSSigV4Signer_Vanilla checks the signer against the get-vanilla case
+// from the AWS SigV4 test suite.
+func TestAWSSigV4Signer_Vanilla(t *testing.T) {
+	s := &AWSSigV4Signer{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "service",
+		now:             func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err := s.Sign(req, nil); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n  %s\nwant\n  %s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("X-Amz-Date = %q", got)
+	}
+}
+
+func TestAWSSigV4Signer_SessionTokenSigned(t *testing.T) {
+	s := &AWSSigV4Signer{
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		SessionToken:    "tok",
+		Region:          "us-west-2",
+		Service:         "bedrock",
+		now:             func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) },
+	}
+	req, _ := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-west-2.amazonaws.com/model/anthropic.claude-v2:1/invoke", nil)
+	req.Header.Set("Content-Type", "application/json")
+	if err := s.Sign(req, []byte(`{}`)); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if req.Header.Get("X-Amz-Security-Token") != "tok" {
+		t.Errorf("session token header not set")
+	}
+	auth := req.Header.Get("Authorization")
+	if !strings.Contains(auth, "Credential=AKID/20250102/us-west-2/bedrock/aws4_request") ||
+		!strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token") {
+		t.Errorf("Authorization = %s", auth)
+	}
+}
+
+func TestAWSRegionFromURL(t *testing.T) {
+	tests := map[string]string{
+		"https://bedrock-runtime.us-east-1.amazonaws.com":    "us-east-1",
+		"https://bedrock-runtime.eu-west-3.amazonaws.com/v1": "eu-west-3",
+		"https://api.anthropic.com":                          "",
+		"not a url \x7f":                                     "",
+	}
+	for in, want := range tests {
+		if got := AWSRegionFromURL(in); got != want {
+			t.Errorf("AWSRegionFromURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNewAWSSigV4SignerFromEnv(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if _, err := NewAWSSigV4SignerFromEnv("us-east-1", "bedrock"); err == nil {
+		t.Error("expected error without credentials")
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "ap-south-1")
+	s, err := NewAWSSigV4SignerFromEnv("", "bedrock")
+	if err != nil {
+		t.Fatalf("NewAWSSigV4SignerFromEnv: %v", err)
+	}
+	if s.Region != "ap-south-1" {
+		t.Errorf("Region = %q, want AWS_REGION fallback", s.Region)
+	}
+}
@@ -0,0 +1,88 @@
+package provider
+
+// StreamStats reports what a stream has delivered so far. It is meant for
+// accounting when a stream ends early, e.g. on Ctrl-C: the tokens generated
+// before cancellation are billed even though the final usage chunk never
+// arrives.
+type StreamStats struct {
+	Chunks   int  // chunks returned by Next
+	Complete bool // the final chunk has been returned
+
+	// Usage is the provider's reported usage, with any counts it has not
+	// reported yet estimated from the request and the text received so far.
+	// Usage.Estimated is set when an estimate was used.
+	Usage Usage
+}
+
+// StreamCounter tracks the chunks a stream returns and implements
+// ChatStream.Stats for it. Adapters embed it in their stream type and call
+// Observe with every chunk Next returns.
+type StreamCounter struct {
+	promptEstimate  int
+	completionChars int // content and tool call text received
+	reported        *Usage
+	chunks          int
+	complete        bool
+}
+
+// NewStreamCounter returns a counter for a stream opened for req.
+func NewStreamCounter(req *ChatRequest) StreamCounter {
+	return StreamCounter{promptEstimate: EstimateMessagesTokens(req.Messages)}
+}
+
+// Observe records a chunk returned by the stream.
+func (c *StreamCounter) Observe(chunk *ChatStreamChunk) {
+	if chunk == nil {
+		return
+	}
+	c.chunks++
+	c.completionChars += len(chunk.Delta.Content)
+	for _, tc := range chunk.Delta.ToolCalls {
+		c.completionChars += len(tc.Function.Name) + len(tc.Function.Arguments)
+	}
+	if chunk.Usage != nil {
+		u := *chunk.Usage
+		c.reported = &u
+	}
+	if chunk.Done {
+		c.complete = true
+	}
+}
+
+// Stats returns the usage observed so far. Reported counts win once the
+// stream is complete; before that, the completion count is at least the
+// estimate for the text received, since providers report output tokens only
+// at the end.
+func (c *StreamCounter) Stats() StreamStats {
+	st := StreamStats{Chunks: c.chunks, Complete: c.complete}
+	if c.reported != nil {
+		st.Usage = *c.reported
+	}
+	u := &st.Usage
+	if u.PromptTokens == 0 && c.promptEstimate > 0 {
+		u.PromptTokens = c.promptEstimate
+		u.Estimated = true
+	}
+	est := (c.completionChars + charsPerToken - 1) / charsPerToken
+	if (!c.complete || u.CompletionTokens == 0) && est > u.CompletionTokens {
+		u.CompletionTokens = est
+		u.Estimated = true
+	}
+	if u.TotalTokens < u.PromptTokens+u.CompletionTokens {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	return st
+}
+
+// addUsage returns the sum of a and b. Estimated is set if either was.
+func addUsage(a, b Usage) Usage {
+	return Usage{
+		PromptTokens:        a.PromptTokens + b.PromptTokens,
+		CompletionTokens:    a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:         a.TotalTokens + b.TotalTokens,
+		CacheCreationTokens: a.CacheCreationTokens + b.CacheCreationTokens,
+		CacheReadTokens:     a.CacheReadTokens + b.CacheReadTokens,
+		ReasoningTokens:     a.ReasoningTokens + b.ReasoningTokens,
+		Estimated:           a.Estimated || b.Estimated,
+	}
+}
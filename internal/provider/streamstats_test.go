@@ -0,0 +1,62 @@
+package provider
+
+import "testing"
+
+func TestStreamCounter(t *testing.T) {
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "Tell me a story"}}} // ~4 tokens
+	text := func(s string) *ChatStreamChunk { return &ChatStreamChunk{Delta: MessageDelta{Content: s}} }
+
+	tests := []struct {
+		name   string
+		chunks []*ChatStreamChunk
+		want   StreamStats
+	}{
+		{
+			name:   "nothing received",
+			chunks: nil,
+			want:   StreamStats{Usage: Usage{PromptTokens: 4, TotalTokens: 4, Estimated: true}},
+		},
+		{
+			name:   "cancelled before usage",
+			chunks: []*ChatStreamChunk{text("Once upon"), text(" a time")},
+			want: StreamStats{
+				Chunks: 2,
+				Usage:  Usage{PromptTokens: 4, CompletionTokens: 4, TotalTokens: 8, Estimated: true},
+			},
+		},
+		{
+			name: "complete with reported usage",
+			chunks: []*ChatStreamChunk{
+				text("Once upon a time, in a land far away"),
+				{Done: true, Usage: &Usage{PromptTokens: 12, CompletionTokens: 7, TotalTokens: 19}},
+			},
+			want: StreamStats{Chunks: 2, Complete: true, Usage: Usage{PromptTokens: 12, CompletionTokens: 7, TotalTokens: 19}},
+		},
+		{
+			name: "partial usage reported early",
+			chunks: []*ChatStreamChunk{
+				{Delta: MessageDelta{Content: "Once upon a time, in"}, Usage: &Usage{PromptTokens: 900, CompletionTokens: 1, TotalTokens: 901}},
+			},
+			want: StreamStats{Chunks: 1, Usage: Usage{PromptTokens: 900, CompletionTokens: 5, TotalTokens: 905, Estimated: true}},
+		},
+		{
+			name: "complete without completion count",
+			chunks: []*ChatStreamChunk{
+				{Delta: MessageDelta{ToolCalls: []ToolCall{{Function: FunctionCall{Name: "search", Arguments: `{"q":"go"}`}}}}},
+				{Done: true, Usage: &Usage{PromptTokens: 30}},
+			},
+			want: StreamStats{Chunks: 2, Complete: true, Usage: Usage{PromptTokens: 30, CompletionTokens: 4, TotalTokens: 34, Estimated: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewStreamCounter(req)
+			for _, ch := range tt.chunks {
+				c.Observe(ch)
+			}
+			if got := c.Stats(); got != tt.want {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package provider
+
+import "strings"
+
+// addSystemPrefix puts prefix at the start of req's system prompt: the
+// first system message, or a new one ahead of the conversation when there
+// is none. A prompt that already starts with prefix is left alone, so a
+// request re-sent to a fallback or retried keeps a single copy. The
+// caller's Messages slice is not modified.
+func addSystemPrefix(req *ChatRequest, prefix string) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return
+	}
+	for i, m := range req.Messages {
+		if m.Role != RoleSystem {
+			continue
+		}
+		if strings.HasPrefix(m.Content, prefix) {
+			return
+		}
+		msgs := make([]Message, len(req.Messages))
+		copy(msgs, req.Messages)
+		msgs[i].Content = strings.TrimSpace(prefix + "\n\n" + m.Content)
+		req.Messages = msgs
+		return
+	}
+	req.Messages = append([]Message{{Role: RoleSystem, Content: prefix}}, req.Messages...)
+}
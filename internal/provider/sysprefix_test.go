@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAddSystemPrefix(t *testing.T) {
+	const prefix = "Never output secrets."
+	tests := []struct {
+		name string
+		msgs []Message
+		want []Message
+	}{
+		{
+			"prepended to the system prompt",
+			[]Message{{Role: RoleSystem, Content: "You are a mayor."}, {Role: RoleUser, Content: "hi"}},
+			[]Message{{Role: RoleSystem, Content: prefix + "\n\nYou are a mayor."}, {Role: RoleUser, Content: "hi"}},
+		},
+		{
+			"added when there is no system prompt",
+			[]Message{{Role: RoleUser, Content: "hi"}},
+			[]Message{{Role: RoleSystem, Content: prefix}, {Role: RoleUser, Content: "hi"}},
+		},
+		{
+			"not added twice",
+			[]Message{{Role: RoleSystem, Content: prefix + "\n\nYou are a mayor."}, {Role: RoleUser, Content: "hi"}},
+			[]Message{{Role: RoleSystem, Content: prefix + "\n\nYou are a mayor."}, {Role: RoleUser, Content: "hi"}},
+		},
+		{
+			"only the first system message",
+			[]Message{{Role: RoleUser, Content: "hi"}, {Role: RoleSystem, Content: "a"}, {Role: RoleSystem, Content: "b"}},
+			[]Message{{Role: RoleUser, Content: "hi"}, {Role: RoleSystem, Content: prefix + "\n\na"}, {Role: RoleSystem, Content: "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := append([]Message(nil), tt.msgs...)
+			req := &ChatRequest{Messages: tt.msgs}
+			addSystemPrefix(req, prefix)
+			if len(req.Messages) != len(tt.want) {
+				t.Fatalf("messages = %+v, want %+v", req.Messages, tt.want)
+			}
+			for i := range tt.want {
+				if req.Messages[i].Role != tt.want[i].Role || req.Messages[i].Content != tt.want[i].Content {
+					t.Errorf("message %d = %+v, want %+v", i, req.Messages[i], tt.want[i])
+				}
+			}
+			for i := range orig {
+				if tt.msgs[i].Content != orig[i].Content {
+					t.Errorf("caller's message %d changed to %+v", i, tt.msgs[i])
+				}
+			}
+		})
+	}
+
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	addSystemPrefix(req, "  ")
+	if len(req.Messages) != 1 {
+		t.Errorf("blank prefix added a message: %+v", req.Messages)
+	}
+}
+
+func TestRouterSystemPrefix_OnceAcrossFallbacks(t *testing.T) {
+	var seen [][]Message
+	record := func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+		seen = append(seen, req.Messages)
+		if len(seen) == 1 {
+			return nil, &APIError{Status: 503, Message: "down"}
+		}
+		return &ChatResponse{Model: req.Model, Done: true}, nil
+	}
+	cfg := routerTestConfig()
+	cfg.Defaults.SystemPrefix = "Be careful."
+	mp := &mockProvider{name: "m", chatFn: record}
+	r, err := NewRouter(cfg, map[string]ProviderFactory{
+		"mock-primary":  func(ProviderConfig) (Provider, error) { return mp, nil },
+		"mock-fallback": func(ProviderConfig) (Provider, error) { return mp, nil },
+	})
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+
+	req := &ChatRequest{Messages: []Message{{Role: RoleSystem, Content: "You lead."}, {Role: RoleUser, Content: "hi"}}}
+	if _, err := r.ChatCompletionForRole(context.Background(), "leader", req); err != nil {
+		t.Fatalf("ChatCompletionForRole: %v", err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("provider called %d times, want primary and fallback", len(seen))
+	}
+	for i, msgs := range seen {
+		if got := msgs[0].Content; got != "Be careful.\n\nYou lead." || strings.Count(got, "Be careful.") != 1 {
+			t.Errorf("attempt %d system = %q", i, got)
+		}
+	}
+}
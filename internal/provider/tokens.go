@@ -0,0 +1,66 @@
+package provider
+
+// charsPerToken is the rough characters-per-token ratio used for estimates.
+// It is deliberately simple: estimates only need to be in the right ballpark
+// for cost summaries and pre-flight checks, not exact.
+const charsPerToken = 4
+
+// EstimateTokens returns an approximate token count for text, without a
+// tokenizer: about one token per 4 bytes, with each run of consecutive
+// whitespace (indentation, blank lines) counted as a single byte, since
+// tokenizers merge such runs. It is meant for budgets and pre-flight
+// estimates, such as dry-run costs and synthesis batching, and can be off
+// by a fair margin for any one model. Non-empty text always estimates to
+// at least one token, and appending text never lowers the estimate.
+func EstimateTokens(text string) int {
+	n := 0
+	inSpace := false
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case ' ', '\t', '\n', '\r', '\v', '\f':
+			if !inSpace {
+				n++
+			}
+			inSpace = true
+		default:
+			n++
+			inSpace = false
+		}
+	}
+	return (n + charsPerToken - 1) / charsPerToken
+}
+
+// EstimateMessagesTokens returns the estimated token count of a message list,
+// including tool call names and arguments.
+func EstimateMessagesTokens(msgs []Message) int {
+	total := 0
+	for _, m := range msgs {
+		total += EstimateTokens(m.Content)
+		for _, tc := range m.ToolCalls {
+			total += EstimateTokens(tc.Function.Name) + EstimateTokens(tc.Function.Arguments)
+		}
+	}
+	return total
+}
+
+// fillUsage ensures resp carries usage numbers. When the provider reported no
+// usage at all (common with local Ollama builds and some gateways), prompt and
+// completion tokens are estimated from the request and response text and
+// Usage.Estimated is set. When only TotalTokens is missing it is derived from
+// the reported parts.
+func fillUsage(req *ChatRequest, resp *ChatResponse) {
+	if resp == nil {
+		return
+	}
+	u := &resp.Usage
+	if u.PromptTokens == 0 && u.CompletionTokens == 0 && u.TotalTokens == 0 {
+		u.PromptTokens = EstimateMessagesTokens(req.Messages)
+		u.CompletionTokens = EstimateMessagesTokens([]Message{resp.Message})
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+		u.Estimated = true
+		return
+	}
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens_Basics(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"Tell me a story", 4},
+		// Runs of whitespace count once: "a" + " " + "b".
+		{"a \t\n\n    b", 1},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateTokens_Monotonic(t *testing.T) {
+	samples := []string{
+		"The quick brown fox jumps over the lazy dog.\n\n",
+		"func main() {\n\tfmt.Println(\"hello\")\n}\n",
+		"        indented    with   gaps\n\n\n",
+		"naïve café — ünïcödé ✓",
+	}
+	for _, s := range samples {
+		prev := 0
+		for i := 0; i <= len(s); i++ {
+			got := EstimateTokens(s[:i])
+			if got < prev {
+				t.Errorf("EstimateTokens(%q) = %d, below %d for the shorter prefix", s[:i], got, prev)
+			}
+			prev = got
+		}
+	}
+	// Longer text of the same kind never estimates lower.
+	short := strings.Repeat("lorem ipsum dolor sit amet ", 10)
+	if EstimateTokens(short+short) < EstimateTokens(short) {
+		t.Error("doubling the text lowered the estimate")
+	}
+}
+
+func TestEstimateTokens_Ranges(t *testing.T) {
+	prose := "Electrictown decomposes a task into subtasks, runs them on a pool of " +
+		"workers in parallel, reviews each result, and synthesizes the outputs into " +
+		"a single answer. Each phase can be skipped or tuned from the command line."
+	code := "package main\n\nimport \"fmt\"\n\nfunc main() {\n" +
+		"\tfor i := 0; i < 10; i++ {\n\t\tif i%2 == 0 {\n\t\t\tfmt.Println(i)\n\t\t}\n\t}\n}\n"
+
+	// Real tokenizers give English prose about 1.3 tokens per word, and
+	// code a little more per character; a rough estimate should land within
+	// a factor of two either way.
+	words := len(strings.Fields(prose))
+	if got := EstimateTokens(prose); got < words*13/20 || got > words*26/10 {
+		t.Errorf("prose: %d words estimated at %d tokens, want roughly %d", words, got, words*13/10)
+	}
+	if got, chars := EstimateTokens(code), len(code); got < chars/8 || got > chars/2 {
+		t.Errorf("code: %d chars estimated at %d tokens, want between %d and %d", chars, got, chars/8, chars/2)
+	}
+
+	// Indentation counts for less than the same bytes of text.
+	indented := strings.Repeat("                x\n", 20)
+	dense := strings.Repeat("xxxxxxxxxxxxxxxxxx", 20)
+	if EstimateTokens(indented) >= EstimateTokens(dense) {
+		t.Errorf("indented text estimated at %d tokens, want fewer than %d for dense text of the same length",
+			EstimateTokens(indented), EstimateTokens(dense))
+	}
+}
@@ -0,0 +1,52 @@
+package provider
+
+import "fmt"
+
+// SyntheticToolCallID returns the ID an adapter assigns to a tool call when
+// the provider does not supply one (Gemini, Ollama): "call_<index>_<name>",
+// where index is the call's position within its assistant message. The ID is
+// deterministic, so replaying a response yields the same IDs, and unique
+// within the message even when the same function is called more than once.
+//
+// Tool results are matched to calls through the nearest preceding assistant
+// message (see MatchToolResult), so IDs only need to be unique per message and
+// a conversation can switch providers between turns.
+func SyntheticToolCallID(index int, name string) string {
+	return fmt.Sprintf("call_%d_%s", index, name)
+}
+
+// MatchToolResult finds the tool call that the RoleTool message at
+// messages[i] answers, by looking for its ToolCallID in the nearest preceding
+// assistant message with tool calls. It returns the call and its index
+// within that message. ok is false if the message is not a tool result or
+// no call with that ID is found. Adapters that key results by function name
+// then fall back to the message's Name.
+func MatchToolResult(messages []Message, i int) (call ToolCall, index int, ok bool) {
+	if i < 0 || i >= len(messages) || messages[i].Role != RoleTool || messages[i].ToolCallID == "" {
+		return ToolCall{}, -1, false
+	}
+	id := messages[i].ToolCallID
+	for j := i - 1; j >= 0; j-- {
+		m := messages[j]
+		if m.Role != RoleAssistant || len(m.ToolCalls) == 0 {
+			continue
+		}
+		for k, tc := range m.ToolCalls {
+			if tc.ID == id {
+				return tc, k, true
+			}
+		}
+		// Results answer the latest batch of calls only.
+		break
+	}
+	return ToolCall{}, -1, false
+}
+
+// ToolResultName returns the function name the RoleTool message at
+// messages[i] answers: the matched call's name, else the message's own Name.
+func ToolResultName(messages []Message, i int) string {
+	if tc, _, ok := MatchToolResult(messages, i); ok {
+		return tc.Function.Name
+	}
+	return messages[i].Name
+}
@@ -0,0 +1,55 @@
+package provider
+
+import "testing"
+
+func TestSyntheticToolCallID(t *testing.T) {
+	a, b := SyntheticToolCallID(0, "get_weather"), SyntheticToolCallID(1, "get_weather")
+	if a == b {
+		t.Fatalf("two calls to the same function got the same ID %q", a)
+	}
+	if a != "call_0_get_weather" || SyntheticToolCallID(0, "get_weather") != a {
+		t.Errorf("ID should be deterministic call_<index>_<name>, got %q", a)
+	}
+}
+
+func TestMatchToolResult(t *testing.T) {
+	call := func(id, name string) ToolCall {
+		return ToolCall{ID: id, Type: "function", Function: FunctionCall{Name: name}}
+	}
+	msgs := []Message{
+		{Role: RoleUser, Content: "weather in NYC and LA, then the time"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{call("call_0_get_time", "get_time")}},
+		{Role: RoleTool, ToolCallID: "call_0_get_time", Content: "12:00"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{
+			call("call_0_get_weather", "get_weather"),
+			call("call_1_get_weather", "get_weather"),
+		}},
+		{Role: RoleTool, ToolCallID: "call_1_get_weather", Content: "LA: 80F"},
+		{Role: RoleTool, ToolCallID: "call_0_get_weather", Content: "NYC: 72F"},
+		// Same ID as the first turn's call, but that batch is no longer current.
+		{Role: RoleTool, ToolCallID: "call_0_get_time", Name: "get_time", Content: "stale"},
+	}
+
+	cases := []struct {
+		i       int
+		ok      bool
+		index   int
+		name    string
+		display string
+	}{
+		{2, true, 0, "get_time", "first turn"},
+		{4, true, 1, "get_weather", "second call answered first"},
+		{5, true, 0, "get_weather", "first call answered second"},
+		{6, false, -1, "get_time", "falls back to Name"},
+		{0, false, -1, "", "not a tool message"},
+	}
+	for _, c := range cases {
+		tc, idx, ok := MatchToolResult(msgs, c.i)
+		if ok != c.ok || idx != c.index || (ok && tc.Function.Name != c.name) {
+			t.Errorf("%s: MatchToolResult = (%q, %d, %v), want (%q, %d, %v)", c.display, tc.Function.Name, idx, ok, c.name, c.index, c.ok)
+		}
+		if got := ToolResultName(msgs, c.i); got != c.name {
+			t.Errorf("%s: ToolResultName = %q, want %q", c.display, got, c.name)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// TraceKind names a TraceEvent.
+type TraceKind string
+
+const (
+	TraceRequestStart TraceKind = "request_start" // a request is about to be sent
+	TraceRequestEnd   TraceKind = "request_end"   // the provider answered or failed
+	TraceFallback     TraceKind = "fallback"      // a failed request moves to the next fallback
+	TraceRetry        TraceKind = "retry"         // a caller retries an earlier failed request; see RetryContext
+)
+
+// TraceEvent is a structured record of router activity for external
+// tracing and metrics, such as OpenTelemetry spans or Prometheus counters.
+type TraceEvent struct {
+	Kind     TraceKind
+	Time     time.Time
+	Role     string // role being served; "" for requests routed by alias
+	Provider string // provider name, e.g. "ollama"; "" for Retry
+	Model    string // model sent to the provider
+	Stream   bool
+
+	// RequestEnd only. For streams the request ends when the stream opens,
+	// and Usage is zero.
+	Latency time.Duration
+	Usage   Usage
+
+	Err  error     // RequestEnd failure, or the error that caused a Fallback
+	Code ErrorCode // classification of Err
+
+	From, To string // Fallback: the model aliases left and tried next
+	Attempt  int    // Retry: 1 for the first retry
+}
+
+// WithTraceHook registers fn to receive every TraceEvent. Several hooks may
+// be registered; each sees every event, in order. Hooks run synchronously on
+// the request path and may be called concurrently, so they must be quick and
+// do their own synchronization.
+func WithTraceHook(fn func(TraceEvent)) RouterOption {
+	return func(r *Router) {
+		if fn != nil {
+			r.traceHooks = append(r.traceHooks, fn)
+		}
+	}
+}
+
+// trace stamps ev and hands it to the registered hooks.
+func (r *Router) trace(ev TraceEvent) {
+	if len(r.traceHooks) == 0 {
+		return
+	}
+	ev.Time = time.Now()
+	if ev.Err != nil {
+		ev.Code = ClassifyError(ev.Err)
+	}
+	for _, fn := range r.traceHooks {
+		fn(ev)
+	}
+}
+
+type retryKey struct{}
+
+// RetryContext marks requests made with the returned context as retry
+// attempt n (1 = first retry) of an earlier failed request, so the router
+// reports a TraceRetry event before routing them. Callers that retry, such
+// as the worker pool, use it; the router itself only falls back.
+func RetryContext(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, retryKey{}, n)
+}
+
+// traceRetry reports a TraceRetry event when ctx carries a retry attempt.
+func (r *Router) traceRetry(ctx context.Context, role, model string, stream bool) {
+	if n, ok := ctx.Value(retryKey{}).(int); ok && n > 0 {
+		r.trace(TraceEvent{Kind: TraceRetry, Role: role, Model: model, Stream: stream, Attempt: n})
+	}
+}
@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// traceRecorder collects the events a trace hook receives.
+type traceRecorder struct {
+	mu     sync.Mutex
+	events []TraceEvent
+}
+
+func (r *traceRecorder) hook(ev TraceEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev)
+}
+
+// summary renders the events as "kind provider/model" lines, or
+// "kind from->to" for fallbacks.
+func (r *traceRecorder) summary() string {
+	var b strings.Builder
+	for _, ev := range r.events {
+		if ev.Kind == TraceFallback {
+			fmt.Fprintf(&b, "%s %s->%s\n", ev.Kind, ev.From, ev.To)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s/%s\n", ev.Kind, ev.Provider, ev.Model)
+	}
+	return b.String()
+}
+
+func TestTraceHook_FallbackSequence(t *testing.T) {
+	primary := &mockProvider{
+		name: "primary",
+		chatFn: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+			return nil, &APIError{Status: 503, Message: "overloaded"}
+		},
+	}
+	fallback := &mockProvider{
+		name: "fallback",
+		chatFn: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+			return &ChatResponse{Model: req.Model, Usage: Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15}}, nil
+		},
+	}
+	rec := &traceRecorder{}
+	r := newCacheTestRouter(t, primary, fallback, WithTraceHook(rec.hook))
+
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if _, err := r.ChatCompletionForRole(context.Background(), "leader", req); err != nil {
+		t.Fatalf("ChatCompletionForRole: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"request_start primary/real-model-a",
+		"request_end primary/real-model-a",
+		"fallback model-a->model-b",
+		"request_start fallback/real-model-b",
+		"request_end fallback/real-model-b",
+	}, "\n") + "\n"
+	if got := rec.summary(); got != want {
+		t.Fatalf("events:\n%s\nwant:\n%s", got, want)
+	}
+	for i, ev := range rec.events {
+		if ev.Role != "leader" {
+			t.Errorf("event %d role = %q, want leader", i, ev.Role)
+		}
+		if ev.Time.IsZero() {
+			t.Errorf("event %d has no time", i)
+		}
+	}
+	if failed := rec.events[1]; failed.Err == nil || failed.Code != ErrServerError {
+		t.Errorf("primary request_end = %+v, want a server error", failed)
+	}
+	if fb := rec.events[2]; fb.Code != ErrServerError {
+		t.Errorf("fallback code = %v, want %v", fb.Code, ErrServerError)
+	}
+	done := rec.events[4]
+	if done.Err != nil || done.Usage.TotalTokens != 15 || done.Latency <= 0 {
+		t.Errorf("fallback request_end = %+v, want usage and latency", done)
+	}
+}
+
+func TestTraceHook_Retry(t *testing.T) {
+	rec := &traceRecorder{}
+	r := newCacheTestRouter(t, &mockProvider{name: "primary"}, &mockProvider{name: "fallback"}, WithTraceHook(rec.hook))
+
+	req := &ChatRequest{Model: "model-a", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if _, err := r.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	req.Model = "model-a"
+	if _, err := r.ChatCompletion(RetryContext(context.Background(), 2), req); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	want := "request_start primary/real-model-a\nrequest_end primary/real-model-a\n" +
+		"retry /real-model-a\nrequest_start primary/real-model-a\nrequest_end primary/real-model-a\n"
+	if got := rec.summary(); got != want {
+		t.Fatalf("events:\n%s\nwant:\n%s", got, want)
+	}
+	if n := rec.events[2].Attempt; n != 2 {
+		t.Errorf("retry attempt = %d, want 2", n)
+	}
+}
+
+func TestTraceHook_Stream(t *testing.T) {
+	rec := &traceRecorder{}
+	r := newCacheTestRouter(t, &mockProvider{name: "primary"}, &mockProvider{name: "fallback"}, WithTraceHook(rec.hook), WithTraceHook(nil))
+
+	req := &ChatRequest{Model: "model-a", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if _, err := r.StreamChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("StreamChatCompletion: %v", err)
+	}
+	if len(rec.events) != 2 || !rec.events[0].Stream || !rec.events[1].Stream {
+		t.Errorf("events = %+v, want a start and end marked as streams", rec.events)
+	}
+}
@@ -0,0 +1,44 @@
+package provider
+
+import "fmt"
+
+// Validate checks a request for mistakes that providers would otherwise
+// reject with an opaque 400: no model, no non-system message, negative
+// sampling parameters, or images that are not user-message PNG, JPEG, GIF
+// or WebP data. The Router calls it before dispatching; the returned
+// error names the offending field.
+func (req *ChatRequest) Validate() error {
+	if req.Model == "" {
+		return fmt.Errorf("provider: invalid request: model is empty (set ChatRequest.Model to an alias or provider/model, or use a role)")
+	}
+	if len(req.Messages) == 0 {
+		return fmt.Errorf("provider: invalid request: no messages")
+	}
+	hasPrompt := false
+	for i, m := range req.Messages {
+		if m.Role != RoleSystem {
+			hasPrompt = true
+		}
+		if len(m.Images) > 0 && m.Role != RoleUser {
+			return fmt.Errorf("provider: invalid request: message %d: images are only allowed on user messages, not %s", i, m.Role)
+		}
+		for j, img := range m.Images {
+			if ImageMediaType(img) == "" {
+				return fmt.Errorf("provider: invalid request: message %d: image %d is not PNG, JPEG, GIF or WebP", i, j)
+			}
+		}
+	}
+	if !hasPrompt {
+		return fmt.Errorf("provider: invalid request: only system messages (add at least one user message)")
+	}
+	if req.Temperature != nil && *req.Temperature < 0 {
+		return fmt.Errorf("provider: invalid request: temperature %g is negative", *req.Temperature)
+	}
+	if req.TopP != nil && *req.TopP < 0 {
+		return fmt.Errorf("provider: invalid request: top_p %g is negative", *req.TopP)
+	}
+	if req.MaxTokens != nil && *req.MaxTokens < 0 {
+		return fmt.Errorf("provider: invalid request: max_tokens %d is negative", *req.MaxTokens)
+	}
+	return nil
+}
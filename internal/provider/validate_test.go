@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestChatRequestValidate(t *testing.T) {
+	neg := -0.5
+	negInt := -1
+	user := []Message{{Role: RoleUser, Content: "hi"}}
+	pngHeader := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	tests := []struct {
+		name    string
+		req     ChatRequest
+		wantErr string
+	}{
+		{"valid", ChatRequest{Model: "fast", Messages: []Message{{Role: RoleSystem, Content: "sys"}, {Role: RoleUser, Content: "hi"}}}, ""},
+		{"empty model", ChatRequest{Messages: user}, "model is empty"},
+		{"no messages", ChatRequest{Model: "fast"}, "no messages"},
+		{"system only", ChatRequest{Model: "fast", Messages: []Message{{Role: RoleSystem, Content: "sys"}}}, "only system messages"},
+		{"negative temperature", ChatRequest{Model: "fast", Messages: user, Temperature: &neg}, "temperature"},
+		{"negative top_p", ChatRequest{Model: "fast", Messages: user, TopP: &neg}, "top_p"},
+		{"negative max_tokens", ChatRequest{Model: "fast", Messages: user, MaxTokens: &negInt}, "max_tokens"},
+		{"png image", ChatRequest{Model: "fast", Messages: []Message{{Role: RoleUser, Content: "look", Images: [][]byte{pngHeader}}}}, ""},
+		{"not an image", ChatRequest{Model: "fast", Messages: []Message{{Role: RoleUser, Content: "look", Images: [][]byte{[]byte("plain text")}}}}, "image 0 is not PNG"},
+		{"image on assistant", ChatRequest{Model: "fast", Messages: []Message{user[0], {Role: RoleAssistant, Images: [][]byte{pngHeader}}}}, "only allowed on user messages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouterRejectsInvalidRequest(t *testing.T) {
+	called := false
+	mp := &mockProvider{
+		name: "primary",
+		chatFn: func(_ context.Context, _ *ChatRequest) (*ChatResponse, error) {
+			called = true
+			return &ChatResponse{}, nil
+		},
+	}
+	router := newTestRouter(t, mp, &mockProvider{name: "fallback"})
+
+	req := &ChatRequest{Model: "model-a", Messages: []Message{{Role: RoleSystem, Content: "sys"}}}
+	if _, err := router.ChatCompletion(context.Background(), req); err == nil || !strings.Contains(err.Error(), "invalid request") {
+		t.Errorf("ChatCompletion err = %v, want invalid request", err)
+	}
+	if _, err := router.ChatCompletionForRole(context.Background(), "leader", req); err == nil || !strings.Contains(err.Error(), "invalid request") {
+		t.Errorf("ChatCompletionForRole err = %v, want invalid request", err)
+	}
+	if called {
+		t.Error("provider was called for an invalid request")
+	}
+}
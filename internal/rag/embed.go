@@ -6,6 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
 )
 
 // DefaultEmbedModel is the default Ollama embedding model.
@@ -15,11 +20,38 @@ const DefaultEmbedModel = "nomic-embed-text"
 // DefaultEmbedVectorSize is the vector dimension for nomic-embed-text.
 const DefaultEmbedVectorSize = 768
 
+// DefaultEmbedBatchSize is the maximum number of inputs sent per /api/embed call.
+const DefaultEmbedBatchSize = 64
+
+// DefaultEmbedBatchTokens is the estimated token budget per /api/embed call.
+// It keeps a batch within the embedding model's context (nomic-embed-text: 8192).
+const DefaultEmbedBatchTokens = 8192
+
+// DefaultEmbedConcurrency is the maximum number of /api/embed calls EmbedBatch
+// keeps in flight at once.
+const DefaultEmbedConcurrency = 4
+
 // Embedder calls the Ollama /api/embed endpoint to produce embeddings.
 type Embedder struct {
-	OllamaURL  string
-	Model      string
+	OllamaURL string
+	Model     string
+
+	// BatchSize and MaxBatchTokens bound each /api/embed call; EmbedBatch
+	// splits larger inputs into several calls. Zero means the default.
+	BatchSize      int
+	MaxBatchTokens int
+
+	// Concurrency caps the /api/embed calls in flight at once; zero means
+	// DefaultEmbedConcurrency. MinInterval is the least time between the
+	// starts of two calls, a simple rate limit; zero means no limit.
+	Concurrency int
+	MinInterval time.Duration
+
 	httpClient *http.Client
+	tokens     atomic.Int64 // prompt tokens reported across all calls
+
+	mu       sync.Mutex
+	nextCall time.Time // earliest start of the next call under MinInterval
 }
 
 // NewEmbedder creates an Embedder targeting the given Ollama URL.
@@ -29,15 +61,25 @@ func NewEmbedder(ollamaURL, model string) *Embedder {
 		model = DefaultEmbedModel
 	}
 	return &Embedder{
-		OllamaURL:  ollamaURL,
-		Model:      model,
-		httpClient: &http.Client{},
+		OllamaURL:      ollamaURL,
+		Model:          model,
+		BatchSize:      DefaultEmbedBatchSize,
+		MaxBatchTokens: DefaultEmbedBatchTokens,
+		Concurrency:    DefaultEmbedConcurrency,
+		httpClient:     &http.Client{},
 	}
 }
 
 // embedResponse mirrors the Ollama /api/embed response shape.
 type embedResponse struct {
-	Embeddings [][]float32 `json:"embeddings"`
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+// TokensUsed returns the prompt tokens Ollama reported across all embed calls
+// made by this Embedder.
+func (e *Embedder) TokensUsed() int {
+	return int(e.tokens.Load())
 }
 
 // Embed returns the embedding vector for a single text string.
@@ -52,9 +94,127 @@ func (e *Embedder) Embed(ctx context.Context, text string) ([]float32, error) {
 	return vecs[0], nil
 }
 
-// EmbedBatch returns embeddings for a batch of texts in a single API call.
-// Available since Ollama 0.2.0+. Results are in the same order as the input.
+// EmbedBatch returns embeddings for texts, in the same order as the input.
+// Inputs are split into batches of at most BatchSize texts and about
+// MaxBatchTokens estimated tokens. Up to Concurrency batches are sent at
+// once, paced by MinInterval, and the results concatenated. The first
+// failure cancels the batches still pending. Available since Ollama 0.2.0+.
 func (e *Embedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	batches := splitEmbedBatches(texts, e.BatchSize, e.MaxBatchTokens)
+	limit := e.Concurrency
+	if limit <= 0 {
+		limit = DefaultEmbedConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make([][][]float32, len(batches))
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	sem := make(chan struct{}, limit)
+	for i, batch := range batches {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			vecs, err := e.embedPaced(ctx, batch)
+			switch {
+			case err != nil && len(batches) > 1:
+				fail(fmt.Errorf("%w (batch %d/%d)", err, i+1, len(batches)))
+			case err != nil:
+				fail(err)
+			case len(vecs) != len(batch):
+				fail(fmt.Errorf("ollama embed: got %d embeddings for %d inputs (batch %d/%d)", len(vecs), len(batch), i+1, len(batches)))
+			default:
+				results[i] = vecs
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([][]float32, 0, len(texts))
+	for _, vecs := range results {
+		out = append(out, vecs...)
+	}
+	return out, nil
+}
+
+// embedPaced waits until MinInterval has passed since the previous call
+// started, then sends texts with embedOnce.
+func (e *Embedder) embedPaced(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.MinInterval > 0 {
+		e.mu.Lock()
+		now := time.Now()
+		start := e.nextCall
+		if start.Before(now) {
+			start = now
+		}
+		e.nextCall = start.Add(e.MinInterval)
+		e.mu.Unlock()
+
+		if wait := start.Sub(now); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return nil, ctx.Err()
+			}
+		}
+	}
+	return e.embedOnce(ctx, texts)
+}
+
+// splitEmbedBatches groups texts into consecutive batches bounded by maxItems
+// inputs and maxTokens estimated tokens. A single text larger than maxTokens
+// gets a batch of its own. Non-positive limits fall back to the defaults.
+func splitEmbedBatches(texts []string, maxItems, maxTokens int) [][]string {
+	if maxItems <= 0 {
+		maxItems = DefaultEmbedBatchSize
+	}
+	if maxTokens <= 0 {
+		maxTokens = DefaultEmbedBatchTokens
+	}
+	var batches [][]string
+	start, tokens := 0, 0
+	for i, t := range texts {
+		n := provider.EstimateTokens(t)
+		if i > start && (i-start >= maxItems || tokens+n > maxTokens) {
+			batches = append(batches, texts[start:i])
+			start, tokens = i, 0
+		}
+		tokens += n
+	}
+	if start < len(texts) {
+		batches = append(batches, texts[start:])
+	}
+	return batches
+}
+
+// embedOnce sends a single /api/embed request for texts.
+func (e *Embedder) embedOnce(ctx context.Context, texts []string) ([][]float32, error) {
 	body := map[string]interface{}{
 		"model": e.Model,
 		"input": texts,
@@ -83,5 +243,6 @@ func (e *Embedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32,
 	if err := json.NewDecoder(resp.Body).Decode(&er); err != nil {
 		return nil, fmt.Errorf("ollama embed: decode: %w", err)
 	}
+	e.tokens.Add(int64(er.PromptEvalCount))
 	return er.Embeddings, nil
 }
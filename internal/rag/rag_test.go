@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // ---- ChunkText tests ----
@@ -183,3 +186,142 @@ func TestPointID_Deterministic(t *testing.T) {
 		t.Errorf("UUID segment lengths wrong: %v", parts)
 	}
 }
+
+// ---- Embedder batching tests ----
+
+func TestEmbedBatch_SplitsAndPreservesOrder(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		mu.Lock()
+		calls++
+		sizes = append(sizes, len(req.Input))
+		mu.Unlock()
+		// Encode each input's length as its one-dimensional embedding so the
+		// test can check ordering after concatenation.
+		embs := make([][]float32, len(req.Input))
+		for i, in := range req.Input {
+			embs[i] = []float32{float32(len(in))}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"embeddings":        embs,
+			"prompt_eval_count": 10 * len(req.Input),
+		})
+	}))
+	defer server.Close()
+
+	e := NewEmbedder(server.URL, "")
+	e.BatchSize = 3
+
+	texts := make([]string, 7)
+	for i := range texts {
+		texts[i] = strings.Repeat("x", i+1)
+	}
+	vecs, err := e.EmbedBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedBatch failed: %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected 3 embed calls, got %d (sizes %v)", calls, sizes)
+	}
+	if len(vecs) != len(texts) {
+		t.Fatalf("expected %d vectors, got %d", len(texts), len(vecs))
+	}
+	for i, v := range vecs {
+		if int(v[0]) != i+1 {
+			t.Errorf("vector %d = %v, want [%d]", i, v, i+1)
+		}
+	}
+	if got := e.TokensUsed(); got != 70 {
+		t.Errorf("TokensUsed = %d, want 70", got)
+	}
+}
+
+func TestEmbedBatch_ConcurrencyCap(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": [][]float32{{1}}})
+	}))
+	defer server.Close()
+
+	e := NewEmbedder(server.URL, "")
+	e.BatchSize = 1
+	e.Concurrency = 2
+	vecs, err := e.EmbedBatch(context.Background(), []string{"a", "b", "c", "d", "e", "f"})
+	if err != nil {
+		t.Fatalf("EmbedBatch failed: %v", err)
+	}
+	if len(vecs) != 6 {
+		t.Fatalf("expected 6 vectors, got %d", len(vecs))
+	}
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrent calls = %d, want 2", got)
+	}
+}
+
+func TestEmbedBatch_MinInterval(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": [][]float32{{1}}})
+	}))
+	defer server.Close()
+
+	e := NewEmbedder(server.URL, "")
+	e.BatchSize = 1
+	e.MinInterval = 20 * time.Millisecond
+	start := time.Now()
+	if _, err := e.EmbedBatch(context.Background(), []string{"a", "b", "c", "d"}); err != nil {
+		t.Fatalf("EmbedBatch failed: %v", err)
+	}
+	// Four calls paced 20ms apart: the last starts at least 60ms in.
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("4 calls took %v, want at least 60ms with a 20ms MinInterval", elapsed)
+	}
+}
+
+func TestSplitEmbedBatches_TokenBudget(t *testing.T) {
+	// 40 chars ≈ 10 tokens each; a 25-token budget fits two per batch.
+	text := strings.Repeat("a", 40)
+	batches := splitEmbedBatches([]string{text, text, text, text, text}, 100, 25)
+	if len(batches) != 3 {
+		t.Fatalf("expected 3 batches, got %d", len(batches))
+	}
+	if len(batches[0]) != 2 || len(batches[1]) != 2 || len(batches[2]) != 1 {
+		t.Errorf("unexpected batch sizes: %d %d %d", len(batches[0]), len(batches[1]), len(batches[2]))
+	}
+
+	// An oversized input still gets its own batch rather than being dropped.
+	big := strings.Repeat("b", 400)
+	batches = splitEmbedBatches([]string{"small", big, "small"}, 100, 25)
+	if len(batches) != 3 || batches[1][0] != big {
+		t.Errorf("expected oversized input isolated in its own batch, got %d batches", len(batches))
+	}
+}
+
+func TestEmbedBatch_CountMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": [][]float32{{1}}})
+	}))
+	defer server.Close()
+
+	e := NewEmbedder(server.URL, "")
+	if _, err := e.EmbedBatch(context.Background(), []string{"a", "b"}); err == nil {
+		t.Fatal("expected error when embedding count does not match input count")
+	}
+}
@@ -0,0 +1,104 @@
+package role
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// ReviewerPanel scores worker output with several reviewer models and
+// reports their consensus. One model's score is noisy on borderline output;
+// the median of a panel only drops below a threshold when most of the panel
+// agrees the output is weak.
+type ReviewerPanel struct {
+	reviewer *Reviewer // prompts, role name, and cost tracking
+	aliases  []string  // model aliases on the panel
+}
+
+// NewReviewerPanel creates a panel that sends each review to every model
+// alias in aliases. The options configure the shared reviewer prompt and
+// cost tracker as for NewReviewer.
+func NewReviewerPanel(router *provider.Router, aliases []string, opts ...WitnessOption) *ReviewerPanel {
+	return &ReviewerPanel{
+		reviewer: NewReviewer(router, opts...),
+		aliases:  append([]string(nil), aliases...),
+	}
+}
+
+// Aliases returns the model aliases on the panel.
+func (p *ReviewerPanel) Aliases() []string {
+	return p.aliases
+}
+
+// Score asks every panel model to score the output concurrently and returns
+// the median score with each model's note, in panel order. Models that fail
+// or return no parseable score are left out; an error is returned only when
+// no model produced a score.
+func (p *ReviewerPanel) Score(ctx context.Context, subtask, response string) (score int, note string, err error) {
+	base, err := p.reviewer.scoreRequest(subtask, response)
+	if err != nil {
+		return 0, "", err
+	}
+
+	type vote struct {
+		score int
+		note  string
+		err   error
+	}
+	votes := make([]vote, len(p.aliases))
+	var wg sync.WaitGroup
+	for i, alias := range p.aliases {
+		wg.Add(1)
+		go func(i int, alias string) {
+			defer wg.Done()
+			req := *base
+			req.Model = alias
+			resp, err := p.reviewer.router.ChatCompletion(ctx, &req)
+			if err != nil {
+				votes[i].err = err
+				return
+			}
+			p.reviewer.recordCost(resp)
+			votes[i].score, votes[i].note = parseScoreResponse(resp.Message.Content)
+		}(i, alias)
+	}
+	wg.Wait()
+
+	var scores []int
+	var notes []string
+	var firstErr error
+	for i, v := range votes {
+		switch {
+		case v.err != nil:
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", p.aliases[i], v.err)
+			}
+		case v.score > 0:
+			scores = append(scores, v.score)
+			notes = append(notes, fmt.Sprintf("%s %d/10: %s", p.aliases[i], v.score, v.note))
+		}
+	}
+	if len(scores) == 0 {
+		if firstErr != nil {
+			return 0, "", fmt.Errorf("role: reviewer panel: no model scored the output: %w", firstErr)
+		}
+		return 0, "", nil
+	}
+	return medianScore(scores), strings.Join(notes, "; "), nil
+}
+
+// medianScore returns the median of scores, rounding the mean of the two
+// middle values up for an even count. scores must not be empty.
+func medianScore(scores []int) int {
+	s := append([]int(nil), scores...)
+	sort.Ints(s)
+	mid := len(s) / 2
+	if len(s)%2 == 1 {
+		return s[mid]
+	}
+	return (s[mid-1] + s[mid] + 1) / 2
+}
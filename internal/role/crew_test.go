@@ -0,0 +1,136 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// buildPanelRouter creates a Router with one mock provider per alias, each
+// replying with the given SCORE/REASON text (or failing when it is empty).
+func buildPanelRouter(t *testing.T, replies map[string]string) (*provider.Router, map[string]*mockProvider) {
+	t.Helper()
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{},
+		Models:    map[string]provider.ModelConfig{},
+		Roles:     map[string]provider.RoleConfig{},
+	}
+	factories := map[string]provider.ProviderFactory{}
+	mocks := map[string]*mockProvider{}
+	for alias, reply := range replies {
+		mock := &mockProvider{name: alias}
+		if reply == "" {
+			mock.err = errors.New("connection refused")
+		} else {
+			mock.response = &provider.ChatResponse{
+				Model:   "model-" + alias,
+				Message: provider.Message{Role: provider.RoleAssistant, Content: reply},
+				Usage:   provider.Usage{TotalTokens: 10},
+				Done:    true,
+			}
+		}
+		mocks[alias] = mock
+		cfg.Providers["p-"+alias] = provider.ProviderConfig{Type: "mock-" + alias}
+		cfg.Models[alias] = provider.ModelConfig{Provider: "p-" + alias, Model: "model-" + alias}
+		factories["mock-"+alias] = func(provider.ProviderConfig) (provider.Provider, error) { return mock, nil }
+		cfg.Defaults.Model = alias
+	}
+	router, err := provider.NewRouter(cfg, factories)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return router, mocks
+}
+
+func TestReviewerPanel_MedianOfDivergentScores(t *testing.T) {
+	router, mocks := buildPanelRouter(t, map[string]string{
+		"harsh":    "SCORE: 3\nREASON: no tests",
+		"fair":     "SCORE: 7\nREASON: works",
+		"generous": "SCORE: 9\nREASON: clean",
+	})
+	tracker := cost.NewTracker(nil)
+	panel := NewReviewerPanel(router, []string{"harsh", "fair", "generous"}, WithWitnessCostTracker(tracker))
+
+	score, note, err := panel.Score(context.Background(), "write the parser", "func Parse() {}")
+	if err != nil {
+		t.Fatalf("Score: %v", err)
+	}
+	// A single harsh reviewer no longer flags the output at threshold 6.
+	if score != 7 {
+		t.Errorf("score = %d, want the median 7", score)
+	}
+	if want := "harsh 3/10: no tests; fair 7/10: works; generous 9/10: clean"; note != want {
+		t.Errorf("note = %q, want %q", note, want)
+	}
+	for alias, mock := range mocks {
+		if mock.lastReq == nil || mock.lastReq.Model != "model-"+alias {
+			t.Errorf("%s did not receive the review request", alias)
+		} else if !strings.Contains(mock.lastReq.Messages[1].Content, "write the parser") {
+			t.Errorf("%s request is missing the subtask", alias)
+		}
+	}
+	if sum := tracker.SummaryForRole("reviewer"); sum.TotalRequests != 3 {
+		t.Errorf("recorded %d reviewer requests, want 3", sum.TotalRequests)
+	}
+}
+
+func TestReviewerPanel_ConsensusLowStillFlags(t *testing.T) {
+	router, _ := buildPanelRouter(t, map[string]string{
+		"a": "SCORE: 2\nREASON: wrong",
+		"b": "SCORE: 4\nREASON: incomplete",
+		"c": "SCORE: 9\nREASON: fine",
+	})
+	score, _, err := NewReviewerPanel(router, []string{"a", "b", "c"}).Score(context.Background(), "s", "r")
+	if err != nil || score != 4 {
+		t.Errorf("Score = %d, %v; want the median 4", score, err)
+	}
+}
+
+func TestReviewerPanel_SkipsFailedModels(t *testing.T) {
+	router, _ := buildPanelRouter(t, map[string]string{
+		"down":  "",
+		"vague": "I think it is fine",
+		"a":     "SCORE: 5\nREASON: ok",
+		"b":     "SCORE: 8\nREASON: good",
+	})
+	score, note, err := NewReviewerPanel(router, []string{"down", "vague", "a", "b"}).Score(context.Background(), "s", "r")
+	if err != nil {
+		t.Fatalf("Score: %v", err)
+	}
+	if score != 7 {
+		t.Errorf("score = %d, want 7 (median of 5 and 8, rounded up)", score)
+	}
+	if note != "a 5/10: ok; b 8/10: good" {
+		t.Errorf("note = %q", note)
+	}
+}
+
+func TestReviewerPanel_AllFail(t *testing.T) {
+	router, _ := buildPanelRouter(t, map[string]string{"x": "", "y": ""})
+	_, _, err := NewReviewerPanel(router, []string{"x", "y"}).Score(context.Background(), "s", "r")
+	if err == nil || !strings.Contains(err.Error(), "no model scored") {
+		t.Errorf("err = %v, want an error when every model fails", err)
+	}
+}
+
+func TestMedianScore(t *testing.T) {
+	tests := []struct {
+		scores []int
+		want   int
+	}{
+		{[]int{6}, 6},
+		{[]int{9, 1, 5}, 5},
+		{[]int{4, 8}, 6},
+		{[]int{5, 6}, 6},
+		{[]int{10, 2, 3, 9}, 6},
+	}
+	for _, tt := range tests {
+		if got := medianScore(tt.scores); got != tt.want {
+			t.Errorf("medianScore(%v) = %d, want %d", tt.scores, got, tt.want)
+		}
+	}
+}
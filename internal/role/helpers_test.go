@@ -17,12 +17,15 @@ type mockProvider struct {
 	err      error
 	// lastReq captures the last request sent to this provider for assertions.
 	lastReq *provider.ChatRequest
+	// reqs captures every ChatCompletion request, in order.
+	reqs []*provider.ChatRequest
 }
 
 func (m *mockProvider) Name() string { return m.name }
 
 func (m *mockProvider) ChatCompletion(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
 	m.lastReq = req
+	m.reqs = append(m.reqs, req)
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -61,9 +64,23 @@ func (s *mockStream) Next() (*provider.ChatStreamChunk, error) {
 
 func (s *mockStream) Close() error { return nil }
 
+func (s *mockStream) Stats() provider.StreamStats { return provider.StreamStats{} }
+
 // buildTestRouter creates a Router with a mock provider wired to the given role.
 func buildTestRouter(t *testing.T, roleName string, mock *mockProvider) *provider.Router {
 	t.Helper()
+	return buildPromptRouter(t, roleName, mock, nil)
+}
+
+// buildPromptRouter is buildTestRouter with prompt overrides in the config.
+func buildPromptRouter(t *testing.T, roleName string, mock *mockProvider, prompts map[string]string) *provider.Router {
+	t.Helper()
+	return buildConfigRouter(t, roleName, mock, func(cfg *provider.Config) { cfg.Prompts = prompts })
+}
+
+// buildConfigRouter is buildTestRouter with the config adjusted by edit.
+func buildConfigRouter(t *testing.T, roleName string, mock *mockProvider, edit func(*provider.Config)) *provider.Router {
+	t.Helper()
 
 	cfg := &provider.Config{
 		Providers: map[string]provider.ProviderConfig{
@@ -77,6 +94,7 @@ func buildTestRouter(t *testing.T, roleName string, mock *mockProvider) *provide
 		},
 		Defaults: provider.DefaultsConfig{Model: "test-model"},
 	}
+	edit(cfg)
 
 	factories := map[string]provider.ProviderFactory{
 		"test": func(_ provider.ProviderConfig) (provider.Provider, error) {
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/meganerd/electrictown/internal/cost"
 	"github.com/meganerd/electrictown/internal/provider"
@@ -30,10 +31,42 @@ type WorkerResult struct {
 	Subtask     string
 	Response    string
 	Tokens      int
+	TokensEst   bool          // true when Tokens was estimated (provider reported no usage)
 	Elapsed     time.Duration // time taken for the LLM call
 	ReviewScore int           // 0 = not reviewed; 1-10 reviewer quality score
 	ReviewNote  string        // brief reviewer feedback
 	Flagged     bool          // true when ReviewScore < reviewer threshold
+	Err         error         // non-nil when the worker failed; Response is then empty
+}
+
+// promptResults converts worker results for use in prompt templates.
+func promptResults(results []WorkerResult) []provider.PromptWorkerResult {
+	out := make([]provider.PromptWorkerResult, len(results))
+	for i, r := range results {
+		out[i] = provider.PromptWorkerResult{Role: r.Role, Subtask: r.Subtask, Response: r.Response, ReviewScore: r.ReviewScore}
+	}
+	return out
+}
+
+// Bounds on the number of subtasks Decompose and Plan return.
+const (
+	DefaultMaxSubtasks = 10
+	MinSubtasks        = 1
+	MaxSubtasks        = 50
+)
+
+// ClampSubtasks limits n to [MinSubtasks, MaxSubtasks].
+func ClampSubtasks(n int) int {
+	return min(max(n, MinSubtasks), MaxSubtasks)
+}
+
+// CheckMaxSubtasks returns an error unless n is 0, meaning the default, or
+// within [MinSubtasks, MaxSubtasks].
+func CheckMaxSubtasks(n int) error {
+	if n != 0 && ClampSubtasks(n) != n {
+		return fmt.Errorf("%d subtasks is out of range: use %d to %d, or 0 for the default of %d", n, MinSubtasks, MaxSubtasks, DefaultMaxSubtasks)
+	}
+	return nil
 }
 
 // MayorOption configures a Mayor during construction.
@@ -47,8 +80,12 @@ type Mayor struct {
 	tracker      *cost.Tracker
 	role         string
 	systemPrompt string
+	synthPrompt  string
 	maxSubtasks  int
+	maxContext   int                                  // synthesis prompt budget in estimated tokens; 0 = unlimited
 	specialists  map[string]provider.SpecialistConfig // nil when no specialists configured
+	tags         []string                             // pool routing tags the mayor may assign
+	tools        toolSet                              // tools offered to the model, if any
 }
 
 const defaultMayorSystemPrompt = `You are a software architect decomposing a task into implementation subtasks for parallel coding workers.
@@ -59,16 +96,28 @@ RULES:
 - Name the specific files and Go packages the worker should write.
 - Workers run in parallel and cannot see each other's output, so define any shared interfaces inline in the subtask description so workers agree on them.
 - If a subtask depends on another subtask's output (e.g. "implement User API" needs "User model" first), append [depends: N] where N is the subtask number it depends on. Multiple dependencies: [depends: 1,3]. Subtasks with no dependencies run in parallel.
+- If a mistake in a subtask would be costly (security, data migrations, public APIs), append [importance: critical] (or [importance: high]); append [importance: low] for throwaway work such as examples or sample data. Leave ordinary subtasks unannotated.
 - Generate as many subtasks as the task genuinely requires (no artificial limit).
 - Output ONLY a numbered list of subtasks. No headings, no preamble, no prose.`
 
+const defaultSynthesizePrompt = "You are a technical supervisor. Synthesize the following worker results into a unified, coherent response that addresses the original task. Combine insights, resolve any conflicts, and present a clear final answer."
+
 // NewMayor creates a Mayor supervisor with the given router and options.
+// Prompt overrides in the router's config (mayor, synthesize) replace the
+// defaults; WithMayorSystemPrompt takes precedence over both.
 func NewMayor(router *provider.Router, opts ...MayorOption) *Mayor {
 	m := &Mayor{
 		router:       router,
 		role:         "mayor",
 		systemPrompt: defaultMayorSystemPrompt,
-		maxSubtasks:  10,
+		synthPrompt:  defaultSynthesizePrompt,
+		maxSubtasks:  DefaultMaxSubtasks,
+	}
+	if p := router.Prompt(provider.PromptMayor); p != "" {
+		m.systemPrompt = p
+	}
+	if p := router.Prompt(provider.PromptSynthesize); p != "" {
+		m.synthPrompt = p
 	}
 	for _, opt := range opts {
 		opt(m)
@@ -97,10 +146,21 @@ func WithMayorCostTracker(t *cost.Tracker) MayorOption {
 	}
 }
 
-// WithMayorMaxSubtasks sets the maximum number of subtasks returned by Decompose.
+// WithMayorMaxSubtasks sets the maximum number of subtasks returned by
+// Decompose and Plan, clamped to [MinSubtasks, MaxSubtasks].
 func WithMayorMaxSubtasks(n int) MayorOption {
 	return func(m *Mayor) {
-		m.maxSubtasks = n
+		m.maxSubtasks = ClampSubtasks(n)
+	}
+}
+
+// WithMayorMaxContextTokens caps the estimated size, in tokens, of a
+// synthesis prompt. When all worker results together would exceed it,
+// Synthesize works hierarchically: it synthesizes batches of workers that
+// fit, then synthesizes those summaries. 0, the default, means no limit.
+func WithMayorMaxContextTokens(n int) MayorOption {
+	return func(m *Mayor) {
+		m.maxContext = n
 	}
 }
 
@@ -113,15 +173,56 @@ func WithMayorSpecialists(specialists map[string]provider.SpecialistConfig) Mayo
 	}
 }
 
-// buildDecomposePrompt returns the system prompt for decomposition, optionally
-// augmented with specialist routing instructions.
-func (m *Mayor) buildDecomposePrompt() string {
-	if len(m.specialists) == 0 {
-		return m.systemPrompt
+// WithMayorTags lists the subtask tags the pool routes on (e.g. "frontend",
+// "backend"). When set, the decompose prompt asks the mayor to label matching
+// subtasks with a [tag: name] marker.
+func WithMayorTags(tags []string) MayorOption {
+	return func(m *Mayor) {
+		m.tags = tags
+	}
+}
+
+// WithMayorTools offers tools to the mayor's model. Tool calls come back
+// unanswered unless WithMayorToolExecutor is also set.
+func WithMayorTools(tools []provider.Tool) MayorOption {
+	return func(m *Mayor) {
+		m.tools.tools = tools
+	}
+}
+
+// WithMayorToolExecutor runs the tools set with WithMayorTools (see
+// RunToolLoop).
+func WithMayorToolExecutor(exec ToolExecutor) MayorOption {
+	return func(m *Mayor) {
+		m.tools.exec = exec
+	}
+}
+
+// buildDecomposePrompt returns the system prompt for decomposition: system
+// (the rendered prompt override) optionally augmented with specialist routing
+// and tagging instructions. The sections are plain text appended after
+// rendering, so specialist descriptions are never parsed as templates.
+func (m *Mayor) buildDecomposePrompt(system string) string {
+	if len(m.specialists) == 0 && len(m.tags) == 0 {
+		return system
 	}
 
 	var sb strings.Builder
-	sb.WriteString(m.systemPrompt)
+	sb.WriteString(system)
+	if len(m.specialists) > 0 {
+		m.writeSpecialists(&sb)
+	}
+	if len(m.tags) > 0 {
+		sb.WriteString("\n\nSUBTASK TAGS (label using [tag: name] marker): ")
+		sb.WriteString(strings.Join(m.tags, ", "))
+		sb.WriteString("\n- Append [tag: name] when a subtask clearly belongs to one of these areas.\n")
+		sb.WriteString("- Use at most one tag per subtask, ONLY from the list above; leave other subtasks untagged.\n")
+	}
+	return sb.String()
+}
+
+// writeSpecialists appends the specialist list and assignment rules.
+func (m *Mayor) writeSpecialists(sb *strings.Builder) {
 	sb.WriteString("\n\nAVAILABLE SPECIALISTS (assign using [specialist: name] marker):\n")
 
 	// Sort for deterministic output.
@@ -144,64 +245,219 @@ func (m *Mayor) buildDecomposePrompt() string {
 		if desc == "" {
 			desc = "specialist worker"
 		}
-		fmt.Fprintf(&sb, "- %s: %s\n", name, desc)
+		fmt.Fprintf(sb, "- %s: %s\n", name, desc)
 	}
 	sb.WriteString("- general-default: General-purpose coding (used when no specialist matches)\n")
 	sb.WriteString("\nRules:\n")
 	sb.WriteString("- Append [specialist: name] when a subtask clearly matches a specialist's expertise.\n")
 	sb.WriteString("- Omit the marker for general-purpose work (general-default will be used).\n")
 	sb.WriteString("- Use ONLY specialist names from the list above.\n")
-
-	return sb.String()
 }
 
 // Decompose takes a high-level task description and returns a list of discrete
 // subtasks for workers. It sends the task to the supervisor model and parses
 // the response into individual subtask strings.
 func (m *Mayor) Decompose(ctx context.Context, task string) ([]string, error) {
+	subtasks, _, err := m.decompose(ctx, task)
+	return subtasks, err
+}
+
+// decompose implements Decompose and also returns the raw response for its
+// usage.
+func (m *Mayor) decompose(ctx context.Context, task string) ([]string, *provider.ChatResponse, error) {
+	system, err := provider.RenderPrompt(m.systemPrompt, provider.PromptData{Task: task})
+	if err != nil {
+		return nil, nil, err
+	}
 	req := &provider.ChatRequest{
 		Messages: []provider.Message{
-			{Role: provider.RoleSystem, Content: m.buildDecomposePrompt()},
+			{Role: provider.RoleSystem, Content: m.buildDecomposePrompt(system), Cacheable: true},
 			{Role: provider.RoleUser, Content: fmt.Sprintf("Decompose this task into subtasks:\n\n%s", task)},
 		},
 	}
 
-	resp, err := m.router.ChatCompletionForRole(ctx, m.role, req)
+	resp, err := m.tools.chat(ctx, m.router, m.role, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	m.recordCost(resp)
 
 	subtasks := ParseSubtasks(resp.Message.Content)
-	if len(subtasks) > m.maxSubtasks {
-		subtasks = subtasks[:m.maxSubtasks]
+	if limit := ClampSubtasks(m.maxSubtasks); len(subtasks) > limit {
+		subtasks = subtasks[:limit]
 	}
 
-	return subtasks, nil
+	return subtasks, resp, nil
+}
+
+// Run decomposes input and returns the subtasks as a numbered list, so the
+// next stage (typically a worker) sees the plan.
+func (m *Mayor) Run(ctx context.Context, input string) (string, provider.Usage, error) {
+	subtasks, resp, err := m.decompose(ctx, input)
+	if err != nil {
+		return "", provider.Usage{}, err
+	}
+	var sb strings.Builder
+	for i, s := range subtasks {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, s)
+	}
+	return sb.String(), resp.Usage, nil
 }
 
 // Synthesize takes a set of worker results and produces a unified final response.
 // It sends the original task and all worker outputs to the supervisor model,
-// which combines them into a coherent synthesis.
+// which combines them into a coherent synthesis. With WithMayorMaxContextTokens,
+// outputs too large for one request are synthesized in batches first.
 func (m *Mayor) Synthesize(ctx context.Context, task string, results []WorkerResult) (string, error) {
+	return m.synthesize(ctx, task, results, 0)
+}
+
+// maxSynthesisDepth bounds how many rounds of partial syntheses
+// hierarchical synthesis runs before it truncates results to fit instead.
+const maxSynthesisDepth = 3
+
+// synthesize synthesizes results in one request when they fit the context
+// budget, and otherwise in batches whose summaries are synthesized in turn.
+// depth counts the rounds of batching so far.
+func (m *Mayor) synthesize(ctx context.Context, task string, results []WorkerResult, depth int) (string, error) {
+	req, err := m.synthesisRequest(task, results)
+	if err != nil {
+		return "", err
+	}
+	if m.maxContext <= 0 || provider.EstimateMessagesTokens(req.Messages) <= m.maxContext {
+		return m.complete(ctx, req)
+	}
+	if len(results) == 1 || depth >= maxSynthesisDepth {
+		if req, err = m.fitSynthesisRequest(task, results); err != nil {
+			return "", err
+		}
+		return m.complete(ctx, req)
+	}
+
+	batches, err := m.synthesisBatches(task, results)
+	if err != nil {
+		return "", err
+	}
+	summaries := make([]WorkerResult, len(batches))
+	first := 1
+	for i, batch := range batches {
+		last := first + len(batch) - 1
+		summary, err := m.synthesize(ctx, task, batch, maxSynthesisDepth)
+		if err != nil {
+			return "", fmt.Errorf("partial synthesis of workers %d-%d: %w", first, last, err)
+		}
+		summaries[i] = WorkerResult{
+			Role:     m.role,
+			Subtask:  fmt.Sprintf("partial synthesis of workers %d-%d", first, last),
+			Response: summary,
+		}
+		first = last + 1
+	}
+	return m.synthesize(ctx, task, summaries, depth+1)
+}
+
+// synthesisBatches splits results into consecutive batches whose synthesis
+// requests fit the context budget. A result too large to share a request
+// gets a batch of its own.
+func (m *Mayor) synthesisBatches(task string, results []WorkerResult) ([][]WorkerResult, error) {
+	var batches [][]WorkerResult
+	var cur []WorkerResult
+	for _, r := range results {
+		if len(cur) > 0 {
+			req, err := m.synthesisRequest(task, append(cur[:len(cur):len(cur)], r))
+			if err != nil {
+				return nil, err
+			}
+			if provider.EstimateMessagesTokens(req.Messages) > m.maxContext {
+				batches = append(batches, cur)
+				cur = nil
+			}
+		}
+		cur = append(cur, r)
+	}
+	return append(batches, cur), nil
+}
+
+// fitSynthesisRequest builds the synthesis request for results, trimming
+// the longest worker outputs as needed to keep it within the context budget.
+func (m *Mayor) fitSynthesisRequest(task string, results []WorkerResult) (*provider.ChatRequest, error) {
+	req, err := m.synthesisRequest(task, results)
+	if err != nil {
+		return nil, err
+	}
+	over := provider.EstimateMessagesTokens(req.Messages) - m.maxContext
+	if over <= 0 {
+		return req, nil
+	}
+	const marker = "\n[... truncated to fit the synthesis context ...]"
+	trimmed := append([]WorkerResult(nil), results...)
+	kept := make([]string, len(trimmed)) // each output without the marker
+	for i, r := range trimmed {
+		kept[i] = r.Response
+	}
+	for over > 0 {
+		longest := -1
+		for i := range kept {
+			if kept[i] != "" && trimmed[i].Err == nil && (longest < 0 || len(kept[i]) > len(kept[longest])) {
+				longest = i
+			}
+		}
+		if longest < 0 {
+			break // the prompt is over budget without any worker output
+		}
+		// At least ~4 chars per token, so this cuts at least enough when
+		// the estimate holds and the loop goes round again when it does not.
+		keep := max(len(kept[longest])-4*over-len(marker), 0)
+		for keep > 0 && !utf8.RuneStart(kept[longest][keep]) {
+			keep--
+		}
+		kept[longest] = kept[longest][:keep]
+		trimmed[longest].Response = kept[longest] + marker
+		if req, err = m.synthesisRequest(task, trimmed); err != nil {
+			return nil, err
+		}
+		over = provider.EstimateMessagesTokens(req.Messages) - m.maxContext
+	}
+	return req, nil
+}
+
+// synthesisRequest builds the request that synthesizes results.
+func (m *Mayor) synthesisRequest(task string, results []WorkerResult) (*provider.ChatRequest, error) {
 	var sb strings.Builder
 	sb.WriteString("Original task: ")
 	sb.WriteString(task)
 	sb.WriteString("\n\nWorker results:\n")
 
 	for i, r := range results {
-		fmt.Fprintf(&sb, "\n--- Worker %d (role: %s, subtask: %s) ---\n%s\n", i+1, r.Role, r.Subtask, r.Response)
+		out := r.Response
+		if r.Err != nil {
+			out = "error: " + r.Err.Error()
+		}
+		fmt.Fprintf(&sb, "\n--- Worker %d (role: %s, subtask: %s) ---\n%s\n", i+1, r.Role, r.Subtask, out)
 	}
 
-	req := &provider.ChatRequest{
+	system, err := provider.RenderPrompt(m.synthPrompt, provider.PromptData{
+		Task:          task,
+		SubtaskCount:  len(results),
+		WorkerResults: promptResults(results),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The worker results are the bulk of the request and are sent again by
+	// each tool round, so they end the cached prefix.
+	return &provider.ChatRequest{
 		Messages: []provider.Message{
-			{Role: provider.RoleSystem, Content: "You are a technical supervisor. Synthesize the following worker results into a unified, coherent response that addresses the original task. Combine insights, resolve any conflicts, and present a clear final answer."},
-			{Role: provider.RoleUser, Content: sb.String()},
+			{Role: provider.RoleSystem, Content: system, Cacheable: true},
+			{Role: provider.RoleUser, Content: sb.String(), Cacheable: true},
 		},
-	}
+	}, nil
+}
 
-	resp, err := m.router.ChatCompletionForRole(ctx, m.role, req)
+// complete sends a synthesis request and records its cost.
+func (m *Mayor) complete(ctx context.Context, req *provider.ChatRequest) (string, error) {
+	resp, err := m.tools.chat(ctx, m.router, m.role, req)
 	if err != nil {
 		return "", err
 	}
@@ -215,14 +471,18 @@ func (m *Mayor) Synthesize(ctx context.Context, task string, results []WorkerRes
 // The model is asked to produce a structured response with a summary section
 // and a subtasks section.
 func (m *Mayor) Plan(ctx context.Context, task string) (*PlanResult, error) {
+	system, err := provider.RenderPrompt(m.systemPrompt, provider.PromptData{Task: task})
+	if err != nil {
+		return nil, err
+	}
 	req := &provider.ChatRequest{
 		Messages: []provider.Message{
-			{Role: provider.RoleSystem, Content: m.systemPrompt},
+			{Role: provider.RoleSystem, Content: system},
 			{Role: provider.RoleUser, Content: fmt.Sprintf("Create a plan for this task. Start with a '## Summary' section explaining the approach, then a '## Subtasks' section with a numbered list of subtasks.\n\n%s", task)},
 		},
 	}
 
-	resp, err := m.router.ChatCompletionForRole(ctx, m.role, req)
+	resp, err := m.tools.chat(ctx, m.router, m.role, req)
 	if err != nil {
 		return nil, err
 	}
@@ -230,8 +490,8 @@ func (m *Mayor) Plan(ctx context.Context, task string) (*PlanResult, error) {
 	m.recordCost(resp)
 
 	result := parsePlanResponse(resp.Message.Content)
-	if len(result.Subtasks) > m.maxSubtasks {
-		result.Subtasks = result.Subtasks[:m.maxSubtasks]
+	if limit := ClampSubtasks(m.maxSubtasks); len(result.Subtasks) > limit {
+		result.Subtasks = result.Subtasks[:limit]
 	}
 
 	return result, nil
@@ -294,7 +554,7 @@ func (m *Mayor) Assess(ctx context.Context, task string) (*AssessResult, error)
 		},
 	}
 
-	resp, err := m.router.ChatCompletionForRole(ctx, m.role, req)
+	resp, err := m.tools.chat(ctx, m.router, m.role, req)
 	if err != nil {
 		return nil, err
 	}
@@ -362,7 +622,7 @@ func (m *Mayor) Coordinate(ctx context.Context, task string, subtasks []string)
 		},
 	}
 
-	resp, err := m.router.ChatCompletionForRole(ctx, m.role, req)
+	resp, err := m.tools.chat(ctx, m.router, m.role, req)
 	if err != nil {
 		return "", err
 	}
@@ -381,9 +641,13 @@ func (m *Mayor) recordCost(resp *provider.ChatResponse) {
 		resp.Model,
 		m.role,
 		cost.Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
+			PromptTokens:        resp.Usage.PromptTokens,
+			CompletionTokens:    resp.Usage.CompletionTokens,
+			TotalTokens:         resp.Usage.TotalTokens,
+			CacheCreationTokens: resp.Usage.CacheCreationTokens,
+			CacheReadTokens:     resp.Usage.CacheReadTokens,
+			ReasoningTokens:     resp.Usage.ReasoningTokens,
+			Estimated:           resp.Usage.Estimated,
 		},
 	)
 }
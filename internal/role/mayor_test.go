@@ -3,6 +3,8 @@ package role
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/meganerd/electrictown/internal/cost"
@@ -60,6 +62,98 @@ func TestNewMayor_CustomOptions(t *testing.T) {
 	}
 }
 
+func TestNewMayor_ConfigPromptOverrides(t *testing.T) {
+	mock := &mockProvider{name: "test", response: &provider.ChatResponse{
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "1. only subtask"},
+		Done:    true,
+	}}
+	router := buildPromptRouter(t, "mayor", mock, map[string]string{
+		provider.PromptMayor:      "custom decompose prompt",
+		provider.PromptSynthesize: "custom synthesis prompt",
+	})
+	m := NewMayor(router)
+
+	if _, err := m.Decompose(context.Background(), "task"); err != nil {
+		t.Fatalf("Decompose: %v", err)
+	}
+	if got := mock.lastReq.Messages[0].Content; got != "custom decompose prompt" {
+		t.Errorf("decompose system prompt = %q, want the config override", got)
+	}
+	if _, err := m.Synthesize(context.Background(), "task", nil); err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if got := mock.lastReq.Messages[0].Content; got != "custom synthesis prompt" {
+		t.Errorf("synthesis system prompt = %q, want the config override", got)
+	}
+
+	// An explicit option still wins over the config.
+	if m := NewMayor(router, WithMayorSystemPrompt("option prompt")); m.systemPrompt != "option prompt" {
+		t.Errorf("systemPrompt = %q, want the option to take precedence", m.systemPrompt)
+	}
+}
+
+func TestMayor_TemplatePrompts(t *testing.T) {
+	mock := &mockProvider{name: "test", response: &provider.ChatResponse{
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "1. only subtask"},
+		Done:    true,
+	}}
+	router := buildPromptRouter(t, "mayor", mock, map[string]string{
+		provider.PromptMayor:      "Plan {{.Task}}.",
+		provider.PromptSynthesize: "Merge {{.SubtaskCount}} results for {{.Task}}:{{range .WorkerResults}} {{.Role}}={{.ReviewScore}}{{end}}",
+	})
+	m := NewMayor(router)
+
+	if _, err := m.Decompose(context.Background(), "a web server"); err != nil {
+		t.Fatalf("Decompose: %v", err)
+	}
+	if got := mock.lastReq.Messages[0].Content; got != "Plan a web server." {
+		t.Errorf("decompose system prompt = %q", got)
+	}
+
+	results := []WorkerResult{{Role: "qwen", ReviewScore: 9}, {Role: "llama", ReviewScore: 4}}
+	if _, err := m.Synthesize(context.Background(), "a web server", results); err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if got, want := mock.lastReq.Messages[0].Content, "Merge 2 results for a web server: qwen=9 llama=4"; got != want {
+		t.Errorf("synthesis system prompt = %q, want %q", got, want)
+	}
+}
+
+func TestDecompose_SpecialistDescriptionsAreNotTemplates(t *testing.T) {
+	mock := &mockProvider{name: "test", response: &provider.ChatResponse{
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "1. only subtask"},
+		Done:    true,
+	}}
+	router := buildPromptRouter(t, "mayor", mock, map[string]string{provider.PromptMayor: "Plan {{.Task}}."})
+	m := NewMayor(router, WithMayorSpecialists(map[string]provider.SpecialistConfig{
+		"helm": {Description: "Helm charts using {{ .Values.image }}"},
+	}))
+
+	if _, err := m.Decompose(context.Background(), "a web server"); err != nil {
+		t.Fatalf("Decompose: %v", err)
+	}
+	got := mock.lastReq.Messages[0].Content
+	if !strings.HasPrefix(got, "Plan a web server.") || !strings.Contains(got, "- helm: Helm charts using {{ .Values.image }}") {
+		t.Errorf("decompose system prompt = %q", got)
+	}
+}
+
+func TestBuildDecomposePrompt_Tags(t *testing.T) {
+	mock := &mockProvider{name: "test"}
+	router := buildTestRouter(t, "mayor", mock)
+
+	plain := NewMayor(router).buildDecomposePrompt("base")
+	if strings.Contains(plain, "[tag:") {
+		t.Error("prompt without tags should not mention [tag:] markers")
+	}
+
+	m := NewMayor(router, WithMayorTags([]string{"backend", "frontend"}))
+	prompt := m.buildDecomposePrompt("base")
+	if !strings.Contains(prompt, "[tag: name]") || !strings.Contains(prompt, "backend, frontend") {
+		t.Errorf("expected tag instructions listing tags, got:\n%s", prompt)
+	}
+}
+
 // --- Decompose tests ---
 
 func TestDecompose_ReturnsParsedSubtasks(t *testing.T) {
@@ -100,6 +194,28 @@ func TestDecompose_ReturnsParsedSubtasks(t *testing.T) {
 	}
 }
 
+func TestDecompose_SystemPrefix(t *testing.T) {
+	mock := &mockProvider{name: "test", response: &provider.ChatResponse{
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "1. one"},
+		Done:    true,
+	}}
+	router := buildConfigRouter(t, "mayor", mock, func(cfg *provider.Config) {
+		cfg.Defaults.SystemPrefix = "Never output secrets."
+	})
+	m := NewMayor(router)
+
+	if _, err := m.Decompose(context.Background(), "Build a REST API"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sys := mock.lastReq.Messages[0]
+	if sys.Role != provider.RoleSystem || !strings.HasPrefix(sys.Content, "Never output secrets.\n\n") {
+		t.Fatalf("system message = %+v, want the prefix first", sys)
+	}
+	if strings.Count(sys.Content, "Never output secrets.") != 1 || !strings.Contains(sys.Content, m.systemPrompt) {
+		t.Errorf("system message = %q, want one prefix before the mayor prompt", sys.Content)
+	}
+}
+
 func TestDecompose_RespectsMaxSubtasks(t *testing.T) {
 	mock := &mockProvider{
 		name: "test",
@@ -128,6 +244,67 @@ func TestDecompose_RespectsMaxSubtasks(t *testing.T) {
 	}
 }
 
+func TestClampSubtasks(t *testing.T) {
+	cases := []struct{ in, want int }{
+		{-5, 1}, {0, 1}, {1, 1}, {2, 2}, {49, 49}, {50, 50}, {51, 50}, {1 << 30, 50},
+	}
+	for _, c := range cases {
+		if got := ClampSubtasks(c.in); got != c.want {
+			t.Errorf("ClampSubtasks(%d) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestCheckMaxSubtasks(t *testing.T) {
+	for _, n := range []int{0, 1, 10, 50} {
+		if err := CheckMaxSubtasks(n); err != nil {
+			t.Errorf("CheckMaxSubtasks(%d) = %v, want nil", n, err)
+		}
+	}
+	for _, n := range []int{-1, 51, 1000} {
+		if err := CheckMaxSubtasks(n); err == nil {
+			t.Errorf("CheckMaxSubtasks(%d) = nil, want an error", n)
+		}
+	}
+}
+
+func TestDecompose_ClampsMaxSubtasks(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 60; i++ {
+		lines = append(lines, fmt.Sprintf("%d. Task %d", i, i))
+	}
+	mock := &mockProvider{
+		name: "test",
+		response: &provider.ChatResponse{
+			Message: provider.Message{Role: provider.RoleAssistant, Content: strings.Join(lines, "\n")},
+			Done:    true,
+		},
+	}
+	router := buildTestRouter(t, "mayor", mock)
+
+	cases := []struct {
+		name string
+		m    *Mayor
+		want int
+	}{
+		{"above ceiling", NewMayor(router, WithMayorMaxSubtasks(1000)), MaxSubtasks},
+		{"at ceiling", NewMayor(router, WithMayorMaxSubtasks(50)), 50},
+		{"zero", NewMayor(router, WithMayorMaxSubtasks(0)), MinSubtasks},
+		{"negative", NewMayor(router, WithMayorMaxSubtasks(-3)), MinSubtasks},
+		// Decompose clamps even when the field was never set through the option.
+		{"unset", &Mayor{router: router, role: "mayor", systemPrompt: defaultMayorSystemPrompt}, MinSubtasks},
+	}
+	for _, c := range cases {
+		subtasks, err := c.m.Decompose(context.Background(), "Build everything")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if len(subtasks) != c.want {
+			t.Errorf("%s: got %d subtasks, want %d", c.name, len(subtasks), c.want)
+		}
+	}
+}
+
 func TestDecompose_RecordsCostWhenTrackerProvided(t *testing.T) {
 	mock := &mockProvider{
 		name: "test",
@@ -219,7 +396,137 @@ func TestSynthesize_CombinesWorkerResults(t *testing.T) {
 	}
 	// Should have system message and user message with worker results.
 	if len(mock.lastReq.Messages) < 2 {
-		t.Errorf("expected at least 2 messages in request, got %d", len(mock.lastReq.Messages))
+		t.Fatalf("expected at least 2 messages in request, got %d", len(mock.lastReq.Messages))
+	}
+	for i, msg := range mock.lastReq.Messages[:2] {
+		if !msg.Cacheable {
+			t.Errorf("message %d (%s) is not marked cacheable", i, msg.Role)
+		}
+	}
+}
+
+func TestSynthesize_ReportsFailedWorkers(t *testing.T) {
+	mock := &mockProvider{
+		name: "test",
+		response: &provider.ChatResponse{
+			Message: provider.Message{Role: provider.RoleAssistant, Content: "partial synthesis"},
+			Done:    true,
+		},
+	}
+	router := buildTestRouter(t, "mayor", mock)
+	m := NewMayor(router)
+
+	results := []WorkerResult{
+		{Role: "polecat", Subtask: "Create schema", Response: "Schema created."},
+		{Role: "polecat", Subtask: "Build endpoints", Err: errors.New("model unavailable")},
+	}
+	if _, err := m.Synthesize(context.Background(), "Build a REST API", results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	user := mock.lastReq.Messages[len(mock.lastReq.Messages)-1].Content
+	if !strings.Contains(user, "error: model unavailable") {
+		t.Errorf("synthesis prompt does not report the failed worker:\n%s", user)
+	}
+}
+
+func TestSynthesize_HierarchicalOverContextLimit(t *testing.T) {
+	mock := &mockProvider{
+		name: "test",
+		response: &provider.ChatResponse{
+			Message: provider.Message{Role: provider.RoleAssistant, Content: "summary"},
+			Done:    true,
+		},
+	}
+	router := buildTestRouter(t, "mayor", mock)
+	const limit = 400
+	m := NewMayor(router, WithMayorMaxContextTokens(limit))
+
+	var results []WorkerResult
+	for i := 1; i <= 6; i++ {
+		out := fmt.Sprintf("OUTPUT-%d ", i) + strings.Repeat("x", 400)
+		results = append(results, WorkerResult{Role: "polecat", Subtask: fmt.Sprintf("part %d", i), Response: out})
+	}
+	synthesis, err := m.Synthesize(context.Background(), "Build it", results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if synthesis != "summary" {
+		t.Errorf("synthesis = %q, want the final summary", synthesis)
+	}
+
+	if len(mock.reqs) < 3 {
+		t.Fatalf("got %d requests, want batches plus a final synthesis", len(mock.reqs))
+	}
+	seen := map[int]int{}
+	for i, req := range mock.reqs {
+		if n := provider.EstimateMessagesTokens(req.Messages); n > limit {
+			t.Errorf("request %d is ~%d tokens, over the %d limit", i, n, limit)
+		}
+		user := req.Messages[len(req.Messages)-1].Content
+		if strings.Contains(user, "truncated") {
+			t.Errorf("request %d truncated output that fit in a batch", i)
+		}
+		for w := 1; w <= 6; w++ {
+			if strings.Contains(user, fmt.Sprintf("OUTPUT-%d ", w)) {
+				seen[w]++
+			}
+		}
+	}
+	for w := 1; w <= 6; w++ {
+		if seen[w] != 1 {
+			t.Errorf("worker %d output was sent %d times, want once", w, seen[w])
+		}
+	}
+	final := mock.lastReq.Messages[len(mock.lastReq.Messages)-1].Content
+	if !strings.Contains(final, "partial synthesis of workers 1-") || strings.Contains(final, "OUTPUT-") {
+		t.Errorf("final request should synthesize the batch summaries only:\n%s", final)
+	}
+}
+
+func TestSynthesize_UnderContextLimitIsOneRequest(t *testing.T) {
+	mock := &mockProvider{
+		name: "test",
+		response: &provider.ChatResponse{
+			Message: provider.Message{Role: provider.RoleAssistant, Content: "summary"},
+			Done:    true,
+		},
+	}
+	m := NewMayor(buildTestRouter(t, "mayor", mock), WithMayorMaxContextTokens(10000))
+	results := []WorkerResult{
+		{Role: "polecat", Subtask: "a", Response: "small"},
+		{Role: "polecat", Subtask: "b", Response: "also small"},
+	}
+	if _, err := m.Synthesize(context.Background(), "Build it", results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.reqs) != 1 {
+		t.Errorf("got %d requests, want 1", len(mock.reqs))
+	}
+}
+
+func TestSynthesize_TruncatesOversizedWorker(t *testing.T) {
+	mock := &mockProvider{
+		name: "test",
+		response: &provider.ChatResponse{
+			Message: provider.Message{Role: provider.RoleAssistant, Content: "summary"},
+			Done:    true,
+		},
+	}
+	const limit = 300
+	m := NewMayor(buildTestRouter(t, "mayor", mock), WithMayorMaxContextTokens(limit))
+	results := []WorkerResult{{Role: "polecat", Subtask: "huge", Response: "START " + strings.Repeat("y", 8000)}}
+	if _, err := m.Synthesize(context.Background(), "Build it", results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(mock.reqs))
+	}
+	if n := provider.EstimateMessagesTokens(mock.lastReq.Messages); n > limit {
+		t.Errorf("request is ~%d tokens, over the %d limit", n, limit)
+	}
+	user := mock.lastReq.Messages[len(mock.lastReq.Messages)-1].Content
+	if !strings.Contains(user, "START ") || !strings.Contains(user, "truncated") {
+		t.Errorf("want the start of the output kept and marked truncated:\n%s", user)
 	}
 }
 
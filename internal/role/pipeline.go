@@ -0,0 +1,59 @@
+package role
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// Pipeline runs registered roles in sequence, feeding each stage's output to
+// the next as input. It implements Role itself, so pipelines can be nested or
+// registered under a name.
+type Pipeline struct {
+	stages []string
+	roles  []Role
+}
+
+// NewPipeline resolves each stage name through the registry, passing opts to
+// every stage's factory. It fails before any model call if a stage is not
+// registered.
+func NewPipeline(router *provider.Router, stages []string, opts ...Option) (*Pipeline, error) {
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("role: pipeline has no stages")
+	}
+	p := &Pipeline{stages: stages, roles: make([]Role, len(stages))}
+	for i, name := range stages {
+		r, err := New(name, router, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("role: pipeline stage %d: %w", i+1, err)
+		}
+		p.roles[i] = r
+	}
+	return p, nil
+}
+
+// Stages returns the stage names in execution order.
+func (p *Pipeline) Stages() []string {
+	return p.stages
+}
+
+// Run executes every stage in order and returns the last stage's output with
+// the usage summed across stages. It stops at the first failing stage.
+func (p *Pipeline) Run(ctx context.Context, input string) (string, provider.Usage, error) {
+	var total provider.Usage
+	out := input
+	for i, r := range p.roles {
+		var usage provider.Usage
+		var err error
+		out, usage, err = r.Run(ctx, out)
+		total.PromptTokens += usage.PromptTokens
+		total.CompletionTokens += usage.CompletionTokens
+		total.TotalTokens += usage.TotalTokens
+		total.Estimated = total.Estimated || usage.Estimated
+		if err != nil {
+			return "", total, fmt.Errorf("role: pipeline stage %d (%s): %w", i+1, p.stages[i], err)
+		}
+	}
+	return out, total, nil
+}
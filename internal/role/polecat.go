@@ -23,9 +23,12 @@ type Polecat struct {
 	tracker      *cost.Tracker // optional, nil-safe
 	role         string        // role name, defaults to "polecat"
 	systemPrompt string        // configurable system prompt
+	tools        toolSet       // tools offered to the model, if any
 }
 
-// Option configures a Polecat during construction.
+// Option configures a Polecat during construction. WithRole,
+// WithSystemPrompt, WithCostTracker, WithTools and WithToolExecutor are also
+// accepted by every registered role's Factory.
 type Option func(*Polecat)
 
 // WithRole sets a custom role name for the polecat worker.
@@ -50,6 +53,24 @@ func WithCostTracker(t *cost.Tracker) Option {
 	}
 }
 
+// WithTools offers tools to the worker's model on Execute and
+// ExecuteWithContext. Tool calls come back in the response unless
+// WithToolExecutor is also set.
+func WithTools(tools []provider.Tool) Option {
+	return func(p *Polecat) {
+		p.tools.tools = tools
+	}
+}
+
+// WithToolExecutor runs the tools set with WithTools, so Execute and
+// ExecuteWithContext continue the conversation until the model answers
+// without tool calls (see RunToolLoop).
+func WithToolExecutor(exec ToolExecutor) Option {
+	return func(p *Polecat) {
+		p.tools.exec = exec
+	}
+}
+
 // NewPolecat creates a polecat worker with the given router and options.
 func NewPolecat(router *provider.Router, opts ...Option) *Polecat {
 	p := &Polecat{
@@ -78,7 +99,7 @@ func (p *Polecat) Role() string {
 // ChatCompletionForRole which handles fallbacks automatically.
 func (p *Polecat) Execute(ctx context.Context, task string) (*provider.ChatResponse, error) {
 	messages := []provider.Message{
-		{Role: provider.RoleSystem, Content: p.systemPrompt},
+		{Role: provider.RoleSystem, Content: p.systemPrompt, Cacheable: true},
 		{Role: provider.RoleUser, Content: task},
 	}
 
@@ -86,7 +107,7 @@ func (p *Polecat) Execute(ctx context.Context, task string) (*provider.ChatRespo
 		Messages: messages,
 	}
 
-	resp, err := p.router.ChatCompletionForRole(ctx, p.role, req)
+	resp, err := p.tools.chat(ctx, p.router, p.role, req)
 	if err != nil {
 		return nil, err
 	}
@@ -95,12 +116,21 @@ func (p *Polecat) Execute(ctx context.Context, task string) (*provider.ChatRespo
 	return resp, nil
 }
 
+// Run executes the worker on input and returns its response.
+func (p *Polecat) Run(ctx context.Context, input string) (string, provider.Usage, error) {
+	resp, err := p.Execute(ctx, input)
+	if err != nil {
+		return "", provider.Usage{}, err
+	}
+	return resp.Message.Content, resp.Usage, nil
+}
+
 // ExecuteStream sends a task and returns a streaming response. The system
 // prompt is automatically prepended. Uses StreamChatCompletionForRole which
-// handles fallbacks automatically.
+// handles fallbacks automatically. Tools are not offered on streams.
 func (p *Polecat) ExecuteStream(ctx context.Context, task string) (provider.ChatStream, error) {
 	messages := []provider.Message{
-		{Role: provider.RoleSystem, Content: p.systemPrompt},
+		{Role: provider.RoleSystem, Content: p.systemPrompt, Cacheable: true},
 		{Role: provider.RoleUser, Content: task},
 	}
 
@@ -118,8 +148,9 @@ func (p *Polecat) ExecuteStream(ctx context.Context, task string) (provider.Chat
 func (p *Polecat) ExecuteWithContext(ctx context.Context, history []provider.Message) (*provider.ChatResponse, error) {
 	messages := make([]provider.Message, 0, len(history)+1)
 	messages = append(messages, provider.Message{
-		Role:    provider.RoleSystem,
-		Content: p.systemPrompt,
+		Role:      provider.RoleSystem,
+		Content:   p.systemPrompt,
+		Cacheable: true,
 	})
 	messages = append(messages, history...)
 
@@ -127,7 +158,7 @@ func (p *Polecat) ExecuteWithContext(ctx context.Context, history []provider.Mes
 		Messages: messages,
 	}
 
-	resp, err := p.router.ChatCompletionForRole(ctx, p.role, req)
+	resp, err := p.tools.chat(ctx, p.router, p.role, req)
 	if err != nil {
 		return nil, err
 	}
@@ -147,9 +178,13 @@ func (p *Polecat) recordCost(resp *provider.ChatResponse) {
 		resp.Model,
 		p.role,
 		cost.Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
+			PromptTokens:        resp.Usage.PromptTokens,
+			CompletionTokens:    resp.Usage.CompletionTokens,
+			TotalTokens:         resp.Usage.TotalTokens,
+			CacheCreationTokens: resp.Usage.CacheCreationTokens,
+			CacheReadTokens:     resp.Usage.CacheReadTokens,
+			ReasoningTokens:     resp.Usage.ReasoningTokens,
+			Estimated:           resp.Usage.Estimated,
 		},
 	)
 }
@@ -52,6 +52,25 @@ func TestNewPolecat_Defaults(t *testing.T) {
 	}
 }
 
+func TestPolecat_SystemPrefix(t *testing.T) {
+	mp := &mockProvider{name: "test", response: defaultMockResponse()}
+	router := buildConfigRouter(t, "polecat", mp, func(cfg *provider.Config) {
+		cfg.Defaults.SystemPrefix = "Never output secrets."
+	})
+	p := NewPolecat(router)
+
+	for range 2 {
+		if _, err := p.Execute(context.Background(), "write hello"); err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+	for i, req := range mp.reqs {
+		if got, want := req.Messages[0].Content, "Never output secrets.\n\n"+p.SystemPrompt(); got != want {
+			t.Errorf("request %d system = %q, want %q", i, got, want)
+		}
+	}
+}
+
 func TestNewPolecat_WithCustomRole(t *testing.T) {
 	mp := &mockProvider{name: "test", response: defaultMockResponse()}
 	router := buildTestRouter(t, "custom-worker", mp)
@@ -146,6 +165,41 @@ func TestExecute_RecordsCostWhenTrackerProvided(t *testing.T) {
 	}
 }
 
+func TestExecute_RecordsEstimatedCostWhenUsageMissing(t *testing.T) {
+	mp := &mockProvider{name: "test", response: &provider.ChatResponse{
+		ID:      "no-usage",
+		Model:   "mock-model",
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "a response with no usage block"},
+		Done:    true,
+	}}
+	router := buildTestRouter(t, "polecat", mp)
+
+	tracker := cost.NewTracker(cost.DefaultPricing())
+	p := NewPolecat(router, WithCostTracker(tracker))
+
+	if _, err := p.Execute(context.Background(), "write something"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records := tracker.Records()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 cost record, got %d", len(records))
+	}
+	rec := records[0]
+	if !rec.Estimated {
+		t.Error("expected cost record to be marked estimated")
+	}
+	if rec.PromptTokens == 0 || rec.CompletionTokens == 0 {
+		t.Errorf("expected non-zero estimated tokens, got prompt=%d completion=%d", rec.PromptTokens, rec.CompletionTokens)
+	}
+	if rec.TotalTokens != rec.PromptTokens+rec.CompletionTokens {
+		t.Errorf("TotalTokens = %d, want %d", rec.TotalTokens, rec.PromptTokens+rec.CompletionTokens)
+	}
+	if !tracker.Summary().Estimated {
+		t.Error("expected summary to be marked estimated")
+	}
+}
+
 func TestExecute_WithoutTrackerDoesNotPanic(t *testing.T) {
 	mp := &mockProvider{name: "test", response: defaultMockResponse()}
 	router := buildTestRouter(t, "polecat", mp)
@@ -0,0 +1,94 @@
+package role
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/meganerd/electrictown/internal/fileblock"
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// ReadFileToolName is the name of the built-in file-reading tool.
+const ReadFileToolName = "read_file"
+
+// MaxReadFileBytes caps how much of a file FileReader returns, so a large
+// file cannot flood the model's context.
+const MaxReadFileBytes = 64 << 10
+
+// ReadFileTool lets a model ask for the current contents of a project file,
+// e.g. one its subtask modifies. Run it with a FileReader.
+var ReadFileTool = provider.Tool{
+	Type: "function",
+	Function: provider.ToolFunction{
+		Name:        ReadFileToolName,
+		Description: "Read the current contents of a file in the project output directory. Use it before changing a file that already exists.",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{
+					"type":        "string",
+					"description": "File path relative to the project root, e.g. internal/foo/foo.go",
+				},
+			},
+			"required": []string{"path"},
+		},
+	},
+}
+
+// FileReader is a ToolExecutor for ReadFileTool. Paths are checked with
+// fileblock.SafePath, the check applied to written files, and opened through
+// an os.Root, so neither a crafted path nor a symlink lets a model read
+// anything outside the directory it writes to.
+type FileReader struct {
+	dir string
+}
+
+// NewFileReader returns a FileReader rooted at dir.
+func NewFileReader(dir string) *FileReader {
+	return &FileReader{dir: dir}
+}
+
+// ExecuteTool returns the contents of the file named by the call's "path"
+// argument, truncated to MaxReadFileBytes.
+func (r *FileReader) ExecuteTool(_ context.Context, call provider.ToolCall) (string, error) {
+	if call.Function.Name != ReadFileToolName {
+		return "", fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+	var args struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+		return "", fmt.Errorf("read_file: bad arguments: %w", err)
+	}
+	if _, err := fileblock.SafePath(r.dir, args.Path); err != nil {
+		return "", err
+	}
+	root, err := os.OpenRoot(r.dir)
+	if err != nil {
+		return "", fmt.Errorf("read_file: %w", err)
+	}
+	defer root.Close()
+	f, err := root.Open(filepath.Clean(args.Path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("read_file: %s does not exist yet", args.Path)
+		}
+		return "", fmt.Errorf("read_file: %w", err)
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return "", fmt.Errorf("read_file: %s is a directory", args.Path)
+	}
+	data, err := io.ReadAll(io.LimitReader(f, MaxReadFileBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("read_file: %w", err)
+	}
+	if len(data) > MaxReadFileBytes {
+		return string(data[:MaxReadFileBytes]) + "\n[truncated]", nil
+	}
+	return string(data), nil
+}
@@ -0,0 +1,116 @@
+package role
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+func readCall(path string) provider.ToolCall {
+	return toolCall("call_1", ReadFileToolName, `{"path":"`+path+`"}`)
+}
+
+func TestFileReader(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pkg", "a.go"), []byte("package pkg\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), "secret.txt"), []byte("key"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := NewFileReader(dir)
+
+	out, err := r.ExecuteTool(context.Background(), readCall("pkg/a.go"))
+	if err != nil || out != "package pkg\n" {
+		t.Errorf("read pkg/a.go = %q, %v", out, err)
+	}
+
+	for _, tc := range []struct {
+		call provider.ToolCall
+		want string
+	}{
+		{readCall("../secret.txt"), `".." segment`},
+		{readCall("pkg/../../secret.txt"), `".." segment`},
+		{readCall("/etc/passwd"), "absolute path"},
+		{readCall("pkg/missing.go"), "does not exist yet"},
+		{readCall("pkg"), "is a directory"},
+		{toolCall("call_1", ReadFileToolName, `{"path":`), "bad arguments"},
+		{toolCall("call_1", "write_file", `{"path":"pkg/a.go"}`), "unknown tool"},
+	} {
+		out, err := r.ExecuteTool(context.Background(), tc.call)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s %s = %q, %v; want an error containing %q", tc.call.Function.Name, tc.call.Function.Arguments, out, err, tc.want)
+		}
+	}
+}
+
+func TestFileReader_RefusesEscapingSymlink(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("key"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "linkdir")); err != nil {
+		t.Fatal(err)
+	}
+	r := NewFileReader(dir)
+
+	for _, path := range []string{"link.txt", "linkdir/secret.txt"} {
+		if out, err := r.ExecuteTool(context.Background(), readCall(path)); err == nil {
+			t.Errorf("read %s = %q, want it refused", path, out)
+		}
+	}
+}
+
+func TestFileReader_Truncates(t *testing.T) {
+	dir := t.TempDir()
+	big := strings.Repeat("x", MaxReadFileBytes+10)
+	if err := os.WriteFile(filepath.Join(dir, "big.txt"), []byte(big), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := NewFileReader(dir).ExecuteTool(context.Background(), readCall("big.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out, "\n[truncated]") || len(out) != MaxReadFileBytes+len("\n[truncated]") {
+		t.Errorf("read %d bytes, want %d and a truncation marker", len(out), MaxReadFileBytes)
+	}
+}
+
+func TestPolecat_ReadsFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("func main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sp := &scriptedToolProvider{replies: []provider.Message{
+		{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{readCall("main.go")}},
+		{Role: provider.RoleAssistant, Content: "===FILE: main.go===\nfunc main() { run() }\n===ENDFILE==="},
+	}}
+	p := NewPolecat(buildToolRouter(t, sp), WithTools([]provider.Tool{ReadFileTool}), WithToolExecutor(NewFileReader(dir)))
+
+	resp, err := p.Execute(context.Background(), "make main call run")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(resp.Message.Content, "run()") {
+		t.Errorf("final content = %q", resp.Message.Content)
+	}
+	if len(sp.reqs) != 2 {
+		t.Fatalf("requests = %d, want 2", len(sp.reqs))
+	}
+	msgs := sp.reqs[1].Messages
+	last := msgs[len(msgs)-1]
+	if last.Role != provider.RoleTool || last.Name != ReadFileToolName || last.Content != "func main() {}\n" {
+		t.Errorf("tool result = %+v, want main.go's contents", last)
+	}
+}
@@ -24,6 +24,9 @@ type Tester struct {
 	tracker      *cost.Tracker // optional, nil-safe
 	role         string        // role name, defaults to "tester"
 	systemPrompt string        // configurable system prompt
+	tools        toolSet       // tools offered to the model, if any
+
+	promptData provider.PromptData // run context for a templated system prompt
 }
 
 // RefineryOption configures a Refinery during construction.
@@ -51,13 +54,41 @@ func WithRefineryCostTracker(t *cost.Tracker) RefineryOption {
 	}
 }
 
+// WithTesterTools offers tools to the tester's model. Tool calls come back
+// unanswered unless WithTesterToolExecutor is also set.
+func WithTesterTools(tools []provider.Tool) RefineryOption {
+	return func(r *Tester) {
+		r.tools.tools = tools
+	}
+}
+
+// WithTesterToolExecutor runs the tools set with WithTesterTools (see
+// RunToolLoop).
+func WithTesterToolExecutor(exec ToolExecutor) RefineryOption {
+	return func(r *Tester) {
+		r.tools.exec = exec
+	}
+}
+
+// WithTesterPromptData sets the run context, such as the task, that a
+// templated tester prompt override is rendered with.
+func WithTesterPromptData(data provider.PromptData) RefineryOption {
+	return func(r *Tester) {
+		r.promptData = data
+	}
+}
+
 // NewTester creates a refinery agent with the given router and options.
+// A tester prompt override in the router's config replaces the default.
 func NewTester(router *provider.Router, opts ...RefineryOption) *Tester {
 	r := &Tester{
 		router:       router,
 		role:         defaultTesterRole,
 		systemPrompt: defaultTesterSystemPrompt,
 	}
+	if p := router.Prompt(provider.PromptTester); p != "" {
+		r.systemPrompt = p
+	}
 	for _, opt := range opts {
 		opt(r)
 	}
@@ -77,8 +108,12 @@ func (r *Tester) Role() string {
 // Refine sends input content to the refinery model for quality improvement.
 // The system prompt is automatically prepended.
 func (r *Tester) Refine(ctx context.Context, input string) (*provider.ChatResponse, error) {
+	system, err := provider.RenderPrompt(r.systemPrompt, r.promptData)
+	if err != nil {
+		return nil, err
+	}
 	messages := []provider.Message{
-		{Role: provider.RoleSystem, Content: r.systemPrompt},
+		{Role: provider.RoleSystem, Content: system},
 		{Role: provider.RoleUser, Content: input},
 	}
 
@@ -86,7 +121,7 @@ func (r *Tester) Refine(ctx context.Context, input string) (*provider.ChatRespon
 		Messages: messages,
 	}
 
-	resp, err := r.router.ChatCompletionForRole(ctx, r.role, req)
+	resp, err := r.tools.chat(ctx, r.router, r.role, req)
 	if err != nil {
 		return nil, err
 	}
@@ -95,14 +130,27 @@ func (r *Tester) Refine(ctx context.Context, input string) (*provider.ChatRespon
 	return resp, nil
 }
 
+// Run refines input and returns the polished output.
+func (r *Tester) Run(ctx context.Context, input string) (string, provider.Usage, error) {
+	resp, err := r.Refine(ctx, input)
+	if err != nil {
+		return "", provider.Usage{}, err
+	}
+	return resp.Message.Content, resp.Usage, nil
+}
+
 // RefineWithFeedback sends input content along with specific improvement
 // instructions to the refinery model. Both the original content and the
 // feedback are included in the user message.
 func (r *Tester) RefineWithFeedback(ctx context.Context, input string, feedback string) (*provider.ChatResponse, error) {
 	userContent := fmt.Sprintf("Content to refine:\n\n%s\n\nImprovement instructions:\n\n%s", input, feedback)
+	system, err := provider.RenderPrompt(r.systemPrompt, r.promptData)
+	if err != nil {
+		return nil, err
+	}
 
 	messages := []provider.Message{
-		{Role: provider.RoleSystem, Content: r.systemPrompt},
+		{Role: provider.RoleSystem, Content: system},
 		{Role: provider.RoleUser, Content: userContent},
 	}
 
@@ -110,7 +158,7 @@ func (r *Tester) RefineWithFeedback(ctx context.Context, input string, feedback
 		Messages: messages,
 	}
 
-	resp, err := r.router.ChatCompletionForRole(ctx, r.role, req)
+	resp, err := r.tools.chat(ctx, r.router, r.role, req)
 	if err != nil {
 		return nil, err
 	}
@@ -123,9 +171,13 @@ func (r *Tester) RefineWithFeedback(ctx context.Context, input string, feedback
 // concise summary. Uses a summarization-specific user prompt.
 func (r *Tester) Summarize(ctx context.Context, content string) (*provider.ChatResponse, error) {
 	userContent := fmt.Sprintf("Produce a concise summary of the following content:\n\n%s", content)
+	system, err := provider.RenderPrompt(r.systemPrompt, r.promptData)
+	if err != nil {
+		return nil, err
+	}
 
 	messages := []provider.Message{
-		{Role: provider.RoleSystem, Content: r.systemPrompt},
+		{Role: provider.RoleSystem, Content: system},
 		{Role: provider.RoleUser, Content: userContent},
 	}
 
@@ -133,7 +185,7 @@ func (r *Tester) Summarize(ctx context.Context, content string) (*provider.ChatR
 		Messages: messages,
 	}
 
-	resp, err := r.router.ChatCompletionForRole(ctx, r.role, req)
+	resp, err := r.tools.chat(ctx, r.router, r.role, req)
 	if err != nil {
 		return nil, err
 	}
@@ -153,9 +205,13 @@ func (r *Tester) recordCost(resp *provider.ChatResponse) {
 		resp.Model,
 		r.role,
 		cost.Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
+			PromptTokens:        resp.Usage.PromptTokens,
+			CompletionTokens:    resp.Usage.CompletionTokens,
+			TotalTokens:         resp.Usage.TotalTokens,
+			CacheCreationTokens: resp.Usage.CacheCreationTokens,
+			CacheReadTokens:     resp.Usage.CacheReadTokens,
+			ReasoningTokens:     resp.Usage.ReasoningTokens,
+			Estimated:           resp.Usage.Estimated,
 		},
 	)
 }
@@ -81,6 +81,41 @@ func TestNewTester_CustomOptions(t *testing.T) {
 	}
 }
 
+func TestNewTester_ConfigPromptOverride(t *testing.T) {
+	mp := &mockProvider{name: "test", response: testerMockResponse()}
+	router := buildPromptRouter(t, "tester", mp, map[string]string{provider.PromptTester: "custom polish prompt"})
+
+	if _, err := NewTester(router).Refine(context.Background(), "draft"); err != nil {
+		t.Fatalf("Refine: %v", err)
+	}
+	if got := mp.lastReq.Messages[0].Content; got != "custom polish prompt" {
+		t.Errorf("system prompt = %q, want the config override", got)
+	}
+}
+
+func TestTester_TemplatePrompt(t *testing.T) {
+	mp := &mockProvider{name: "test", response: testerMockResponse()}
+	router := buildPromptRouter(t, "tester", mp, map[string]string{provider.PromptTester: "Polish the answer to {{.Task}}."})
+	r := NewTester(router, WithTesterPromptData(provider.PromptData{Task: "a web server"}))
+
+	calls := map[string]func() error{
+		"Refine": func() error { _, err := r.Refine(context.Background(), "draft"); return err },
+		"RefineWithFeedback": func() error {
+			_, err := r.RefineWithFeedback(context.Background(), "draft", "shorter")
+			return err
+		},
+		"Summarize": func() error { _, err := r.Summarize(context.Background(), "draft"); return err },
+	}
+	for name, call := range calls {
+		if err := call(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := mp.lastReq.Messages[0].Content; got != "Polish the answer to a web server." {
+			t.Errorf("%s system prompt = %q", name, got)
+		}
+	}
+}
+
 // --- Refine tests ---
 
 func TestRefine_ReturnsResponseFromRouter(t *testing.T) {
@@ -0,0 +1,151 @@
+package role
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// Role is the common contract for pipeline stages: take the previous stage's
+// output as input and return this stage's output along with the token usage
+// it incurred. The built-in Mayor, Polecat, Reviewer and Tester implement it;
+// embedders add their own through Register.
+type Role interface {
+	Run(ctx context.Context, input string) (string, provider.Usage, error)
+}
+
+// Factory builds a Role bound to a router. The shared options WithRole,
+// WithSystemPrompt, WithCostTracker, WithTools and WithToolExecutor apply to
+// every factory; a factory ignores options it has no use for.
+type Factory func(router *provider.Router, opts ...Option) Role
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+func init() {
+	Register("mayor", func(router *provider.Router, opts ...Option) Role {
+		s := resolveOptions(opts)
+		var mo []MayorOption
+		if s.role != "" {
+			mo = append(mo, WithMayorRole(s.role))
+		}
+		if s.systemPrompt != "" {
+			mo = append(mo, WithMayorSystemPrompt(s.systemPrompt))
+		}
+		if s.tracker != nil {
+			mo = append(mo, WithMayorCostTracker(s.tracker))
+		}
+		mo = append(mo, WithMayorTools(s.tools.tools), WithMayorToolExecutor(s.tools.exec))
+		return NewMayor(router, mo...)
+	})
+	Register("polecat", func(router *provider.Router, opts ...Option) Role {
+		return NewPolecat(router, opts...)
+	})
+	Register("reviewer", func(router *provider.Router, opts ...Option) Role {
+		s := resolveOptions(opts)
+		var wo []WitnessOption
+		if s.role != "" {
+			wo = append(wo, WithReviewerRole(s.role))
+		}
+		if s.systemPrompt != "" {
+			wo = append(wo, WithWitnessSystemPrompt(s.systemPrompt))
+		}
+		if s.tracker != nil {
+			wo = append(wo, WithWitnessCostTracker(s.tracker))
+		}
+		wo = append(wo, WithReviewerTools(s.tools.tools), WithReviewerToolExecutor(s.tools.exec))
+		return NewReviewer(router, wo...)
+	})
+	Register("tester", func(router *provider.Router, opts ...Option) Role {
+		s := resolveOptions(opts)
+		var ro []RefineryOption
+		if s.role != "" {
+			ro = append(ro, WithTesterRole(s.role))
+		}
+		if s.systemPrompt != "" {
+			ro = append(ro, WithRefinerySystemPrompt(s.systemPrompt))
+		}
+		if s.tracker != nil {
+			ro = append(ro, WithRefineryCostTracker(s.tracker))
+		}
+		ro = append(ro, WithTesterTools(s.tools.tools), WithTesterToolExecutor(s.tools.exec))
+		return NewTester(router, ro...)
+	})
+}
+
+// Register makes a role available to New and NewPipeline under name. Like
+// database/sql.Register, it panics if name is empty, factory is nil, or the
+// name is already taken, since those are programming errors best caught at
+// init time.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" {
+		panic("role: Register called with empty name")
+	}
+	if factory == nil {
+		panic("role: Register factory is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("role: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New builds the role registered under name.
+func New(name string, router *provider.Router, opts ...Option) (Role, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("role: unknown role %q (registered: %s)", name, strings.Join(Registered(), ", "))
+	}
+	return factory(router, opts...), nil
+}
+
+// Registered returns the names of all registered roles, sorted.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// roleSettings holds the shared options as set by the caller; fields left at
+// their zero value mean "use the role's default".
+type roleSettings struct {
+	role         string
+	systemPrompt string
+	tracker      *cost.Tracker
+	tools        toolSet
+}
+
+// resolveOptions applies opts to an empty Polecat to read back which shared
+// settings the caller provided, for translation into another role's options.
+func resolveOptions(opts []Option) roleSettings {
+	var p Polecat
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return roleSettings{role: p.role, systemPrompt: p.systemPrompt, tracker: p.tracker, tools: p.tools}
+}
+
+// Compile-time interface checks.
+var (
+	_ Role = (*Mayor)(nil)
+	_ Role = (*Polecat)(nil)
+	_ Role = (*Reviewer)(nil)
+	_ Role = (*Tester)(nil)
+	_ Role = (*Pipeline)(nil)
+)
@@ -0,0 +1,155 @@
+package role
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// auditor is a custom role with its own prompt and output parser, built on a
+// Polecat the way an embedder would.
+type auditor struct {
+	worker *Polecat
+}
+
+const auditPrompt = "You are a security auditor. Reply with FINDINGS: <count> followed by one finding per line."
+
+func (a *auditor) Run(ctx context.Context, input string) (string, provider.Usage, error) {
+	out, usage, err := a.worker.Run(ctx, input)
+	if err != nil {
+		return "", usage, err
+	}
+	var n int
+	if _, err := fmt.Sscanf(out, "FINDINGS: %d", &n); err != nil {
+		return "", usage, fmt.Errorf("auditor: unparseable response: %q", out)
+	}
+	lines := strings.SplitN(out, "\n", 2)
+	return fmt.Sprintf("Fix %d security finding(s):\n%s", n, lines[len(lines)-1]), usage, nil
+}
+
+func init() {
+	Register("test-security-auditor", func(router *provider.Router, opts ...Option) Role {
+		base := []Option{WithRole("security-auditor"), WithSystemPrompt(auditPrompt)}
+		return &auditor{worker: NewPolecat(router, append(base, opts...)...)}
+	})
+}
+
+func TestPipeline_CustomRole(t *testing.T) {
+	mp := &mockProvider{name: "test", response: &provider.ChatResponse{
+		Model:   "mock-model",
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "FINDINGS: 1\nSQL built by string concatenation in query.go"},
+		Usage:   provider.Usage{PromptTokens: 30, CompletionTokens: 10, TotalTokens: 40},
+		Done:    true,
+	}}
+	router := buildTestRouter(t, "security-auditor", mp)
+
+	p, err := NewPipeline(router, []string{"test-security-auditor", "polecat"})
+	if err != nil {
+		t.Fatalf("NewPipeline: %v", err)
+	}
+	out, usage, err := p.Run(context.Background(), "func Query(id string) { db.Exec(\"... \" + id) }")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// The polecat stage received the auditor's parsed output, not the raw reply.
+	got := mp.lastReq.Messages[len(mp.lastReq.Messages)-1].Content
+	want := "Fix 1 security finding(s):\nSQL built by string concatenation in query.go"
+	if got != want {
+		t.Errorf("polecat input = %q, want %q", got, want)
+	}
+	if mp.lastReq.Messages[0].Content != defaultSystemPrompt {
+		t.Error("polecat stage should use its own default system prompt")
+	}
+	if out != mp.response.Message.Content {
+		t.Errorf("pipeline output = %q, want last stage's response", out)
+	}
+	if usage.TotalTokens != 80 || usage.PromptTokens != 60 {
+		t.Errorf("usage = %+v, want the sum of both stages", usage)
+	}
+}
+
+func TestPipeline_StageError(t *testing.T) {
+	mp := &mockProvider{name: "test", response: &provider.ChatResponse{
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "no findings format"},
+		Usage:   provider.Usage{TotalTokens: 5},
+	}}
+	router := buildTestRouter(t, "security-auditor", mp)
+
+	p, err := NewPipeline(router, []string{"polecat", "test-security-auditor"})
+	if err != nil {
+		t.Fatalf("NewPipeline: %v", err)
+	}
+	_, usage, err := p.Run(context.Background(), "code")
+	if err == nil || !strings.Contains(err.Error(), "stage 2 (test-security-auditor)") {
+		t.Fatalf("expected stage 2 error, got %v", err)
+	}
+	if usage.TotalTokens != 10 {
+		t.Errorf("usage = %d, want tokens from both calls counted", usage.TotalTokens)
+	}
+}
+
+func TestNewPipeline_UnknownStage(t *testing.T) {
+	router := buildTestRouter(t, "polecat", &mockProvider{name: "test"})
+	_, err := NewPipeline(router, []string{"polecat", "no-such-role"})
+	if err == nil || !strings.Contains(err.Error(), `unknown role "no-such-role"`) {
+		t.Fatalf("expected unknown role error, got %v", err)
+	}
+	if _, err := NewPipeline(router, nil); err == nil {
+		t.Error("expected error for empty pipeline")
+	}
+}
+
+func TestNew_BuiltinsHonorSharedOptions(t *testing.T) {
+	router := buildTestRouter(t, "polecat", &mockProvider{name: "test"})
+
+	for _, name := range []string{"mayor", "polecat", "reviewer", "tester"} {
+		r, err := New(name, router, WithRole("custom"), WithSystemPrompt("custom prompt"))
+		if err != nil {
+			t.Fatalf("New(%q): %v", name, err)
+		}
+		var gotRole, gotPrompt string
+		switch v := r.(type) {
+		case *Mayor:
+			gotRole, gotPrompt = v.role, v.systemPrompt
+		case *Polecat:
+			gotRole, gotPrompt = v.Role(), v.SystemPrompt()
+		case *Reviewer:
+			gotRole, gotPrompt = v.Role(), v.SystemPrompt()
+		case *Tester:
+			gotRole, gotPrompt = v.Role(), v.SystemPrompt()
+		default:
+			t.Fatalf("New(%q) returned %T", name, r)
+		}
+		if gotRole != "custom" || gotPrompt != "custom prompt" {
+			t.Errorf("%s: role=%q prompt=%q, want options applied", name, gotRole, gotPrompt)
+		}
+	}
+
+	// Without options each role keeps its own defaults.
+	r, _ := New("reviewer", router)
+	if rv := r.(*Reviewer); rv.Role() != defaultReviewerRole || rv.SystemPrompt() != defaultWitnessSystemPrompt {
+		t.Errorf("reviewer defaults not preserved: %q", rv.Role())
+	}
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic registering a duplicate name")
+		}
+	}()
+	Register("polecat", func(*provider.Router, ...Option) Role { return nil })
+}
+
+func TestRegistered(t *testing.T) {
+	names := strings.Join(Registered(), ",")
+	for _, want := range []string{"mayor", "polecat", "reviewer", "tester", "test-security-auditor"} {
+		if !strings.Contains(names, want) {
+			t.Errorf("Registered() = %s, missing %s", names, want)
+		}
+	}
+}
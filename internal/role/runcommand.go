@@ -0,0 +1,214 @@
+package role
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// RunCommandToolName is the name of the built-in command tool.
+const RunCommandToolName = "run_command"
+
+// DefaultCommandTimeout bounds each command a CommandRunner runs when no
+// timeout is given.
+const DefaultCommandTimeout = 30 * time.Second
+
+// MaxCommandOutput caps the output a CommandRunner returns to the model.
+const MaxCommandOutput = 64 << 10
+
+// RunCommandTool lets a model run an allowed command, such as a formatter,
+// in the project output directory. Run it with a CommandRunner.
+var RunCommandTool = provider.Tool{
+	Type: "function",
+	Function: provider.ToolFunction{
+		Name:        RunCommandToolName,
+		Description: "Run a command in the project output directory and return its combined output. Only allowlisted commands run; there is no shell, so pipes, redirects, and globs are not expanded.",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"command": map[string]any{
+					"type":        "string",
+					"description": "Program to run, e.g. gofmt",
+				},
+				"args": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "Arguments, e.g. [\"-l\", \".\"]",
+				},
+			},
+			"required": []string{"command"},
+		},
+	},
+}
+
+// CommandRunner is a ToolExecutor for RunCommandTool. It runs commands
+// without a shell, in its directory, only when they match the allowlist,
+// and kills them at the timeout. Arguments that name absolute paths or ".."
+// segments, and flags that make a tool run another program (see execFlags),
+// are refused. Commands get only the environment in commandEnvVars, so API
+// keys in et's environment do not reach them.
+type CommandRunner struct {
+	dir     string
+	allow   [][]string // allowlist entries split into words
+	timeout time.Duration
+}
+
+// NewCommandRunner returns a CommandRunner for dir. Each allow entry permits
+// command lines starting with its words, so "go fmt" permits "go fmt ./..."
+// and not "go run". A timeout <= 0 means DefaultCommandTimeout. With an
+// empty allowlist every command is refused.
+func NewCommandRunner(dir string, allow []string, timeout time.Duration) *CommandRunner {
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	r := &CommandRunner{dir: dir, timeout: timeout}
+	for _, entry := range allow {
+		if words := strings.Fields(entry); len(words) > 0 {
+			r.allow = append(r.allow, words)
+		}
+	}
+	return r
+}
+
+// Allowed reports whether argv matches an allowlist entry.
+func (r *CommandRunner) Allowed(argv []string) bool {
+	for _, words := range r.allow {
+		if len(argv) >= len(words) && equalWords(argv[:len(words)], words) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ExecuteTool runs the command named by the call and returns its combined
+// output. A command that runs but fails is not an error: its output is
+// returned with the exit status, for the model to act on.
+func (r *CommandRunner) ExecuteTool(ctx context.Context, call provider.ToolCall) (string, error) {
+	if call.Function.Name != RunCommandToolName {
+		return "", fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+	var args struct {
+		Command string   `json:"command"`
+		Args    []string `json:"args"`
+	}
+	if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+		return "", fmt.Errorf("run_command: bad arguments: %w", err)
+	}
+	argv := append([]string{args.Command}, args.Args...)
+	if args.Command == "" || !r.Allowed(argv) {
+		return "", fmt.Errorf("run_command: %q is not allowed (allowed: %s)", strings.Join(argv, " "), r.describeAllow())
+	}
+	if err := checkArgs(args.Args); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = r.dir
+	cmd.Env = commandEnv()
+	cmd.WaitDelay = time.Second // don't wait on a killed command's children
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	text := out.String()
+	if len(text) > MaxCommandOutput {
+		text = text[:MaxCommandOutput] + "\n[truncated]"
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return text + fmt.Sprintf("\n[killed after %s]", r.timeout), nil
+	case err == nil:
+		return text, nil
+	case errors.As(err, &exitErr):
+		return text + fmt.Sprintf("\n[%v]", err), nil
+	default:
+		return "", fmt.Errorf("run_command: %w", err)
+	}
+}
+
+// execFlags are flag names (without dashes) that make an allowed tool run a
+// program of the caller's choosing, e.g. "go vet -vettool=x" or
+// "find -exec x".
+var execFlags = map[string]bool{
+	"exec":     true,
+	"execdir":  true,
+	"toolexec": true,
+	"vettool":  true,
+}
+
+// checkArgs refuses arguments, or -flag=value values, that are absolute
+// paths or contain a ".." segment, and the flags in execFlags.
+func checkArgs(args []string) error {
+	for _, arg := range args {
+		value := arg
+		if strings.HasPrefix(arg, "-") {
+			name, v, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if execFlags[name] {
+				return fmt.Errorf("run_command: %s runs another program and is not allowed", arg)
+			}
+			if !hasValue {
+				continue
+			}
+			value = v
+		}
+		if filepath.IsAbs(value) || strings.HasPrefix(value, "/") || strings.HasPrefix(value, `\`) {
+			return fmt.Errorf("run_command: %q is an absolute path; use a path relative to the output directory", arg)
+		}
+		for _, seg := range strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == '\\' }) {
+			if seg == ".." {
+				return fmt.Errorf("run_command: %q contains a \"..\" segment", arg)
+			}
+		}
+	}
+	return nil
+}
+
+// commandEnvVars are the environment variables commands inherit: enough
+// to find programs and for Go tools to locate their caches.
+var commandEnvVars = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TMPDIR", "GOPATH", "GOROOT", "GOCACHE", "GOMODCACHE"}
+
+// commandEnv returns the set variables among commandEnvVars as a command
+// environment.
+func commandEnv() []string {
+	env := []string{}
+	for _, name := range commandEnvVars {
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	return env
+}
+
+// describeAllow lists the allowlist for error messages.
+func (r *CommandRunner) describeAllow() string {
+	if len(r.allow) == 0 {
+		return "none"
+	}
+	entries := make([]string, len(r.allow))
+	for i, words := range r.allow {
+		entries[i] = strings.Join(words, " ")
+	}
+	return strings.Join(entries, ", ")
+}
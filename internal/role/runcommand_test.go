@@ -0,0 +1,157 @@
+package role
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+func commandCall(command string, args ...string) provider.ToolCall {
+	b, _ := json.Marshal(map[string]any{"command": command, "args": args})
+	return toolCall("call_1", RunCommandToolName, string(b))
+}
+
+func requireCommands(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s not available: %v", name, err)
+		}
+	}
+}
+
+func TestCommandRunner_RejectsDisallowed(t *testing.T) {
+	dir := t.TempDir()
+	r := NewCommandRunner(dir, []string{"ls", "go fmt"}, 0)
+
+	for _, call := range []provider.ToolCall{
+		commandCall("rm", "-rf", "."),
+		commandCall("go", "run", "main.go"),
+		commandCall("go"),
+		commandCall("/bin/ls"),
+		commandCall("sh", "-c", "ls; rm -rf ."),
+		commandCall(""),
+	} {
+		out, err := r.ExecuteTool(context.Background(), call)
+		if err == nil || !strings.Contains(err.Error(), "is not allowed (allowed: ls, go fmt)") {
+			t.Errorf("%s = %q, %v; want it refused", call.Function.Arguments, out, err)
+		}
+	}
+
+	if _, err := NewCommandRunner(dir, nil, 0).ExecuteTool(context.Background(), commandCall("ls")); err == nil || !strings.Contains(err.Error(), "allowed: none") {
+		t.Errorf("empty allowlist ran ls: %v", err)
+	}
+}
+
+func TestCommandRunner_RejectsEscapingArgs(t *testing.T) {
+	r := NewCommandRunner(t.TempDir(), []string{"gofmt", "go vet", "cat"}, 0)
+
+	for _, tc := range []struct {
+		call provider.ToolCall
+		want string
+	}{
+		{commandCall("cat", "/etc/passwd"), "absolute path"},
+		{commandCall("cat", "../secret.txt"), `".." segment`},
+		{commandCall("gofmt", "-l", "pkg/../../x.go"), `".." segment`},
+		{commandCall("gofmt", "-w=/tmp/x.go"), "absolute path"},
+		{commandCall("go", "vet", "-vettool=./evil"), "runs another program"},
+		{commandCall("go", "vet", "-toolexec", "./evil", "./..."), "runs another program"},
+	} {
+		out, err := r.ExecuteTool(context.Background(), tc.call)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s = %q, %v; want an error containing %q", tc.call.Function.Arguments, out, err, tc.want)
+		}
+	}
+}
+
+func TestCommandRunner_WithholdsEnvironment(t *testing.T) {
+	requireCommands(t, "env")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-secret")
+	r := NewCommandRunner(t.TempDir(), []string{"env"}, 0)
+
+	out, err := r.ExecuteTool(context.Background(), commandCall("env"))
+	if err != nil {
+		t.Fatalf("env: %v", err)
+	}
+	if strings.Contains(out, "sk-secret") {
+		t.Errorf("command saw the API key:\n%s", out)
+	}
+	if !strings.Contains(out, "PATH=") {
+		t.Errorf("command lost PATH:\n%s", out)
+	}
+}
+
+func TestCommandRunner_RunsAllowed(t *testing.T) {
+	requireCommands(t, "ls", "sh")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := NewCommandRunner(dir, []string{"ls", "sh -c"}, 0)
+
+	out, err := r.ExecuteTool(context.Background(), commandCall("ls"))
+	if err != nil || out != "main.go\n" {
+		t.Errorf("ls in the output directory = %q, %v", out, err)
+	}
+
+	out, err = r.ExecuteTool(context.Background(), commandCall("sh", "-c", "echo out; echo err >&2; exit 3"))
+	if err != nil {
+		t.Fatalf("failing command: %v", err)
+	}
+	if !strings.Contains(out, "out\n") || !strings.Contains(out, "err\n") || !strings.HasSuffix(out, "[exit status 3]") {
+		t.Errorf("failing command output = %q, want both streams and the exit status", out)
+	}
+}
+
+func TestCommandRunner_Timeout(t *testing.T) {
+	requireCommands(t, "sleep")
+	r := NewCommandRunner(t.TempDir(), []string{"sleep"}, 100*time.Millisecond)
+
+	start := time.Now()
+	out, err := r.ExecuteTool(context.Background(), commandCall("sleep", "10"))
+	if err != nil || !strings.Contains(out, "[killed after 100ms]") {
+		t.Errorf("sleep = %q, %v; want it killed", out, err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("took %s to time out", time.Since(start))
+	}
+}
+
+func TestPolecat_RunsCommand(t *testing.T) {
+	requireCommands(t, "ls")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sp := &scriptedToolProvider{replies: []provider.Message{
+		{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{commandCall("rm", "main.go"), commandCall("ls")}},
+		{Role: provider.RoleAssistant, Content: "done"},
+	}}
+	mux := ToolMux{
+		ReadFileToolName:   NewFileReader(dir),
+		RunCommandToolName: NewCommandRunner(dir, []string{"ls"}, 0),
+	}
+	p := NewPolecat(buildToolRouter(t, sp), WithTools([]provider.Tool{ReadFileTool, RunCommandTool}), WithToolExecutor(mux))
+
+	if _, err := p.Execute(context.Background(), "tidy up"); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	msgs := sp.reqs[1].Messages
+	rm, ls := msgs[len(msgs)-2], msgs[len(msgs)-1]
+	if !strings.HasPrefix(rm.Content, "error: run_command: \"rm main.go\" is not allowed") {
+		t.Errorf("rm result = %q, want it refused", rm.Content)
+	}
+	if ls.Content != "main.go\n" {
+		t.Errorf("ls result = %q", ls.Content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.go")); err != nil {
+		t.Errorf("main.go: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// DefaultMaxToolRounds bounds RunToolLoop when maxRounds is 0.
+const DefaultMaxToolRounds = 8
+
+// ErrToolRounds is returned by RunToolLoop when the model is still calling
+// tools after the last allowed round.
+var ErrToolRounds = errors.New("role: tool rounds exhausted")
+
+// ToolExecutor runs the tools a model calls. The returned text is sent back
+// to the model as the call's result. An error is reported to the model as
+// the result too, so it can recover, and does not end the conversation.
+type ToolExecutor interface {
+	ExecuteTool(ctx context.Context, call provider.ToolCall) (string, error)
+}
+
+// ToolExecutorFunc adapts a function to ToolExecutor.
+type ToolExecutorFunc func(ctx context.Context, call provider.ToolCall) (string, error)
+
+// ExecuteTool calls f.
+func (f ToolExecutorFunc) ExecuteTool(ctx context.Context, call provider.ToolCall) (string, error) {
+	return f(ctx, call)
+}
+
+// ToolMux runs each tool call with the executor registered under the
+// tool's name, for offering several tools that have their own executors.
+type ToolMux map[string]ToolExecutor
+
+// ExecuteTool runs call with the executor for its tool.
+func (m ToolMux) ExecuteTool(ctx context.Context, call provider.ToolCall) (string, error) {
+	exec, ok := m[call.Function.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+	return exec.ExecuteTool(ctx, call)
+}
+
+// ChatFunc sends one chat request, e.g. a Router method bound to a role or
+// a model alias.
+type ChatFunc func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error)
+
+// RunToolLoop sends req for role and, while the model answers with tool
+// calls, runs them with exec and sends the results back as RoleTool
+// messages, for at most maxRounds rounds of calls (0 = DefaultMaxToolRounds).
+// It returns the first response without tool calls, with Usage summed over
+// every round. req.Messages is left holding the whole conversation.
+func RunToolLoop(ctx context.Context, router *provider.Router, role string, req *provider.ChatRequest, exec ToolExecutor, maxRounds int) (*provider.ChatResponse, error) {
+	send := func(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+		return router.ChatCompletionForRole(ctx, role, req)
+	}
+	resp, err := send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return ContinueToolLoop(ctx, send, req, resp, exec, maxRounds)
+}
+
+// ContinueToolLoop is RunToolLoop for a request already sent: resp is the
+// model's answer to req, and later rounds go through send. It lets callers
+// with their own retry or routing logic make the first call themselves.
+func ContinueToolLoop(ctx context.Context, send ChatFunc, req *provider.ChatRequest, resp *provider.ChatResponse, exec ToolExecutor, maxRounds int) (*provider.ChatResponse, error) {
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxToolRounds
+	}
+	var usage provider.Usage
+	for round := 0; ; round++ {
+		addUsage(&usage, resp.Usage)
+		calls := resp.Message.ToolCalls
+		if len(calls) == 0 {
+			resp.Usage = usage
+			return resp, nil
+		}
+		if round == maxRounds {
+			return nil, fmt.Errorf("%w: %s still calling tools after %d rounds", ErrToolRounds, resp.Model, maxRounds)
+		}
+
+		assistant := resp.Message
+		assistant.Role = provider.RoleAssistant
+		req.Messages = append(req.Messages, assistant)
+		for _, call := range calls {
+			out, err := exec.ExecuteTool(ctx, call)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				out = "error: " + err.Error()
+			}
+			req.Messages = append(req.Messages, provider.Message{
+				Role:       provider.RoleTool,
+				Content:    out,
+				Name:       call.Function.Name,
+				ToolCallID: call.ID,
+			})
+		}
+
+		var err error
+		if resp, err = send(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// addUsage adds u to sum.
+func addUsage(sum *provider.Usage, u provider.Usage) {
+	sum.PromptTokens += u.PromptTokens
+	sum.CompletionTokens += u.CompletionTokens
+	sum.TotalTokens += u.TotalTokens
+	sum.CacheCreationTokens += u.CacheCreationTokens
+	sum.CacheReadTokens += u.CacheReadTokens
+	sum.ReasoningTokens += u.ReasoningTokens
+	sum.Estimated = sum.Estimated || u.Estimated
+}
+
+// toolSet holds the tools a role offers its model and what runs them.
+type toolSet struct {
+	tools []provider.Tool
+	exec  ToolExecutor
+}
+
+// chat sends req for role with the tools attached. With an executor, tool
+// calls are run through RunToolLoop; without one they are returned to the
+// caller in the response.
+func (t toolSet) chat(ctx context.Context, router *provider.Router, role string, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	if len(t.tools) == 0 {
+		return router.ChatCompletionForRole(ctx, role, req)
+	}
+	req.Tools = t.tools
+	if t.exec == nil {
+		return router.ChatCompletionForRole(ctx, role, req)
+	}
+	return RunToolLoop(ctx, router, role, req, t.exec, 0)
+}
@@ -0,0 +1,214 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/provider"
+)
+
+// scriptedToolProvider answers requests with its replies in order and keeps
+// a copy of each request's messages.
+type scriptedToolProvider struct {
+	replies []provider.Message
+	reqs    []provider.ChatRequest
+}
+
+func (s *scriptedToolProvider) Name() string { return "scripted" }
+
+func (s *scriptedToolProvider) ChatCompletion(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	cp := *req
+	cp.Messages = append([]provider.Message(nil), req.Messages...)
+	s.reqs = append(s.reqs, cp)
+	if len(s.reqs) > len(s.replies) {
+		return nil, fmt.Errorf("unexpected request %d", len(s.reqs))
+	}
+	return &provider.ChatResponse{
+		Model:   req.Model,
+		Message: s.replies[len(s.reqs)-1],
+		Usage:   provider.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+		Done:    true,
+	}, nil
+}
+
+func (s *scriptedToolProvider) StreamChatCompletion(context.Context, *provider.ChatRequest) (provider.ChatStream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *scriptedToolProvider) ListModels(context.Context) ([]provider.Model, error) { return nil, nil }
+
+func toolCall(id, name, args string) provider.ToolCall {
+	return provider.ToolCall{ID: id, Type: "function", Function: provider.FunctionCall{Name: name, Arguments: args}}
+}
+
+var weatherTools = []provider.Tool{
+	{Type: "function", Function: provider.ToolFunction{Name: "weather", Description: "current weather for a city"}},
+	{Type: "function", Function: provider.ToolFunction{Name: "convert", Description: "convert celsius to fahrenheit"}},
+}
+
+// twoRoundScript calls weather for two cities, then convert, then answers.
+func twoRoundScript() *scriptedToolProvider {
+	return &scriptedToolProvider{replies: []provider.Message{
+		{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{
+			toolCall("call_1", "weather", `{"city":"Paris"}`),
+			toolCall("call_2", "weather", `{"city":"Oslo"}`),
+		}},
+		{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{
+			toolCall("call_3", "convert", `{"celsius":21}`),
+		}},
+		{Role: provider.RoleAssistant, Content: "Paris is 70°F; Oslo is unknown."},
+	}}
+}
+
+// weatherExecutor answers the weather tools and records the calls it ran.
+func weatherExecutor(ran *[]string) ToolExecutor {
+	return ToolExecutorFunc(func(_ context.Context, call provider.ToolCall) (string, error) {
+		*ran = append(*ran, call.Function.Name+" "+call.Function.Arguments)
+		switch {
+		case strings.Contains(call.Function.Arguments, "Paris"):
+			return "21C", nil
+		case call.Function.Name == "convert":
+			return "70F", nil
+		}
+		return "", errors.New("no station")
+	})
+}
+
+func TestRunToolLoop_TwoRounds(t *testing.T) {
+	sp := twoRoundScript()
+	router := buildToolRouter(t, sp)
+	var ran []string
+
+	req := &provider.ChatRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "weather in Paris and Oslo, in F"}},
+		Tools:    weatherTools,
+	}
+	resp, err := RunToolLoop(context.Background(), router, "polecat", req, weatherExecutor(&ran), 0)
+	if err != nil {
+		t.Fatalf("RunToolLoop: %v", err)
+	}
+
+	if resp.Message.Content != "Paris is 70°F; Oslo is unknown." {
+		t.Errorf("final content = %q", resp.Message.Content)
+	}
+	if resp.Usage.TotalTokens != 36 || resp.Usage.PromptTokens != 30 {
+		t.Errorf("usage = %+v, want three rounds summed", resp.Usage)
+	}
+	wantRan := `weather {"city":"Paris"}|weather {"city":"Oslo"}|convert {"celsius":21}`
+	if got := strings.Join(ran, "|"); got != wantRan {
+		t.Errorf("executed %s, want %s", got, wantRan)
+	}
+	if len(sp.reqs) != 3 {
+		t.Fatalf("requests = %d, want 3", len(sp.reqs))
+	}
+	for i, r := range sp.reqs {
+		if len(r.Tools) != 2 {
+			t.Errorf("request %d offered %d tools, want 2", i, len(r.Tools))
+		}
+	}
+
+	// The last request carries the whole exchange, results matched to calls.
+	msgs := sp.reqs[2].Messages
+	roles := make([]string, len(msgs))
+	for i, m := range msgs {
+		roles[i] = string(m.Role)
+	}
+	if got := strings.Join(roles, ","); got != "user,assistant,tool,tool,assistant,tool" {
+		t.Fatalf("conversation roles = %s", got)
+	}
+	for i, want := range map[int]string{2: "21C", 3: "error: no station", 5: "70F"} {
+		if msgs[i].Content != want {
+			t.Errorf("message %d = %q, want %q", i, msgs[i].Content, want)
+		}
+		call, _, ok := provider.MatchToolResult(msgs, i)
+		if !ok || call.Function.Name != msgs[i].Name {
+			t.Errorf("message %d (%s) does not match a call: %+v", i, msgs[i].ToolCallID, call)
+		}
+	}
+	if len(req.Messages) != 6 {
+		t.Errorf("req.Messages has %d messages, want the 6 sent in the last round", len(req.Messages))
+	}
+}
+
+func TestRunToolLoop_RoundsExhausted(t *testing.T) {
+	sp := twoRoundScript()
+	router := buildToolRouter(t, sp)
+	var ran []string
+	req := &provider.ChatRequest{Messages: []provider.Message{{Role: provider.RoleUser, Content: "go"}}, Tools: weatherTools}
+
+	_, err := RunToolLoop(context.Background(), router, "polecat", req, weatherExecutor(&ran), 1)
+	if !errors.Is(err, ErrToolRounds) {
+		t.Fatalf("err = %v, want ErrToolRounds", err)
+	}
+	if len(ran) != 2 || len(sp.reqs) != 2 {
+		t.Errorf("ran %d tools over %d requests, want one round of 2 calls", len(ran), len(sp.reqs))
+	}
+}
+
+func TestPolecat_WithTools(t *testing.T) {
+	sp := twoRoundScript()
+	router := buildToolRouter(t, sp)
+	var ran []string
+	p := NewPolecat(router, WithTools(weatherTools), WithToolExecutor(weatherExecutor(&ran)))
+
+	resp, err := p.Execute(context.Background(), "weather please")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if resp.Message.Content != "Paris is 70°F; Oslo is unknown." || len(ran) != 3 {
+		t.Errorf("response %q after %d tool calls", resp.Message.Content, len(ran))
+	}
+	if sp.reqs[0].Messages[0].Role != provider.RoleSystem {
+		t.Error("system prompt missing from the tool conversation")
+	}
+}
+
+func TestPolecat_WithToolsNoExecutor(t *testing.T) {
+	sp := twoRoundScript()
+	p := NewPolecat(buildToolRouter(t, sp), WithTools(weatherTools))
+
+	resp, err := p.Execute(context.Background(), "weather please")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if len(resp.Message.ToolCalls) != 2 || len(sp.reqs) != 1 {
+		t.Errorf("got %d tool calls over %d requests, want the first round returned as is", len(resp.Message.ToolCalls), len(sp.reqs))
+	}
+}
+
+func TestRegistry_ToolOptions(t *testing.T) {
+	sp := twoRoundScript()
+	var ran []string
+	r, err := New("tester", buildToolRouter(t, sp), WithRole("polecat"), WithTools(weatherTools), WithToolExecutor(weatherExecutor(&ran)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out, _, err := r.Run(context.Background(), "weather please")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.Contains(out, "70°F") || len(ran) != 3 {
+		t.Errorf("out = %q after %d tool calls", out, len(ran))
+	}
+}
+
+// buildToolRouter routes role "polecat" to p.
+func buildToolRouter(t *testing.T, p provider.Provider) *provider.Router {
+	t.Helper()
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{"test": {Type: "test", BaseURL: "http://localhost"}},
+		Models:    map[string]provider.ModelConfig{"test-model": {Provider: "test", Model: "mock-model"}},
+		Roles:     map[string]provider.RoleConfig{"polecat": {Model: "test-model"}},
+		Defaults:  provider.DefaultsConfig{Model: "test-model"},
+	}
+	router, err := provider.NewRouter(cfg, map[string]provider.ProviderFactory{
+		"test": func(provider.ProviderConfig) (provider.Provider, error) { return p, nil },
+	})
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return router
+}
@@ -16,6 +16,8 @@ const defaultWitnessSystemPrompt = "You are a code reviewer. Analyze the provide
 	"security issues, performance problems, and adherence to best practices. " +
 	"Provide specific, actionable feedback organized by severity."
 
+const defaultScoreSystemPrompt = "You are a concise code quality reviewer. Output only SCORE: N and REASON: text."
+
 // Witness represents a code reviewer/validator agent that reviews work output
 // for correctness, security issues, and quality. It is provider-agnostic --
 // it uses the router to talk to whatever model is configured for the "reviewer"
@@ -25,6 +27,10 @@ type Reviewer struct {
 	tracker      *cost.Tracker // optional, nil-safe
 	role         string        // role name, defaults to "reviewer"
 	systemPrompt string        // configurable system prompt
+	scorePrompt  string        // system prompt for Score
+	tools        toolSet       // tools offered to the model, if any
+
+	promptData provider.PromptData // run context for templated prompts
 }
 
 // WitnessOption configures a Witness during construction.
@@ -52,12 +58,46 @@ func WithWitnessCostTracker(t *cost.Tracker) WitnessOption {
 	}
 }
 
+// WithReviewerTools offers tools to the reviewer's model. Tool calls come
+// back unanswered unless WithReviewerToolExecutor is also set. A
+// ReviewerPanel does not offer them.
+func WithReviewerTools(tools []provider.Tool) WitnessOption {
+	return func(w *Reviewer) {
+		w.tools.tools = tools
+	}
+}
+
+// WithReviewerToolExecutor runs the tools set with WithReviewerTools (see
+// RunToolLoop).
+func WithReviewerToolExecutor(exec ToolExecutor) WitnessOption {
+	return func(w *Reviewer) {
+		w.tools.exec = exec
+	}
+}
+
+// WithReviewerPromptData sets the run context, such as the task, that
+// templated reviewer and review_score prompt overrides are rendered with.
+func WithReviewerPromptData(data provider.PromptData) WitnessOption {
+	return func(w *Reviewer) {
+		w.promptData = data
+	}
+}
+
 // NewReviewer creates a witness reviewer with the given router and options.
+// The reviewer and review_score prompt overrides in the router's config
+// replace the defaults.
 func NewReviewer(router *provider.Router, opts ...WitnessOption) *Reviewer {
 	w := &Reviewer{
 		router:       router,
 		role:         defaultReviewerRole,
 		systemPrompt: defaultWitnessSystemPrompt,
+		scorePrompt:  defaultScoreSystemPrompt,
+	}
+	if p := router.Prompt(provider.PromptReviewer); p != "" {
+		w.systemPrompt = p
+	}
+	if p := router.Prompt(provider.PromptReviewScore); p != "" {
+		w.scorePrompt = p
 	}
 	for _, opt := range opts {
 		opt(w)
@@ -78,8 +118,12 @@ func (w *Reviewer) Role() string {
 // Review sends code to the reviewer's configured model for analysis and returns
 // the full review response. The system prompt is automatically prepended.
 func (w *Reviewer) Review(ctx context.Context, code string) (*provider.ChatResponse, error) {
+	system, err := provider.RenderPrompt(w.systemPrompt, w.promptData)
+	if err != nil {
+		return nil, err
+	}
 	messages := []provider.Message{
-		{Role: provider.RoleSystem, Content: w.systemPrompt},
+		{Role: provider.RoleSystem, Content: system},
 		{Role: provider.RoleUser, Content: fmt.Sprintf("Review the following code:\n\n%s", code)},
 	}
 
@@ -87,7 +131,7 @@ func (w *Reviewer) Review(ctx context.Context, code string) (*provider.ChatRespo
 		Messages: messages,
 	}
 
-	resp, err := w.router.ChatCompletionForRole(ctx, w.role, req)
+	resp, err := w.tools.chat(ctx, w.router, w.role, req)
 	if err != nil {
 		return nil, err
 	}
@@ -96,11 +140,26 @@ func (w *Reviewer) Review(ctx context.Context, code string) (*provider.ChatRespo
 	return resp, nil
 }
 
+// Run reviews input and returns the reviewer's feedback.
+func (w *Reviewer) Run(ctx context.Context, input string) (string, provider.Usage, error) {
+	resp, err := w.Review(ctx, input)
+	if err != nil {
+		return "", provider.Usage{}, err
+	}
+	return resp.Message.Content, resp.Usage, nil
+}
+
 // ReviewWithContext reviews code with the original task context, allowing the
 // reviewer to assess whether the implementation correctly addresses the task.
 func (w *Reviewer) ReviewWithContext(ctx context.Context, task string, code string) (*provider.ChatResponse, error) {
+	data := w.promptData
+	data.Task = task
+	system, err := provider.RenderPrompt(w.systemPrompt, data)
+	if err != nil {
+		return nil, err
+	}
 	messages := []provider.Message{
-		{Role: provider.RoleSystem, Content: w.systemPrompt},
+		{Role: provider.RoleSystem, Content: system},
 		{Role: provider.RoleUser, Content: fmt.Sprintf("Original task:\n%s\n\nCode to review:\n%s", task, code)},
 	}
 
@@ -108,7 +167,7 @@ func (w *Reviewer) ReviewWithContext(ctx context.Context, task string, code stri
 		Messages: messages,
 	}
 
-	resp, err := w.router.ChatCompletionForRole(ctx, w.role, req)
+	resp, err := w.tools.chat(ctx, w.router, w.role, req)
 	if err != nil {
 		return nil, err
 	}
@@ -120,8 +179,12 @@ func (w *Reviewer) ReviewWithContext(ctx context.Context, task string, code stri
 // Validate checks output against acceptance criteria, determining whether the
 // output meets the specified requirements.
 func (w *Reviewer) Validate(ctx context.Context, criteria string, output string) (*provider.ChatResponse, error) {
+	system, err := provider.RenderPrompt(w.systemPrompt, w.promptData)
+	if err != nil {
+		return nil, err
+	}
 	messages := []provider.Message{
-		{Role: provider.RoleSystem, Content: w.systemPrompt},
+		{Role: provider.RoleSystem, Content: system},
 		{Role: provider.RoleUser, Content: fmt.Sprintf("Acceptance criteria:\n%s\n\nOutput to validate:\n%s", criteria, output)},
 	}
 
@@ -129,7 +192,7 @@ func (w *Reviewer) Validate(ctx context.Context, criteria string, output string)
 		Messages: messages,
 	}
 
-	resp, err := w.router.ChatCompletionForRole(ctx, w.role, req)
+	resp, err := w.tools.chat(ctx, w.router, w.role, req)
 	if err != nil {
 		return nil, err
 	}
@@ -142,6 +205,21 @@ func (w *Reviewer) Validate(ctx context.Context, criteria string, output string)
 // It asks the reviewer model to respond with SCORE: N and REASON: text lines.
 // Returns score=0 on parse failure.
 func (w *Reviewer) Score(ctx context.Context, subtask, response string) (score int, note string, err error) {
+	req, err := w.scoreRequest(subtask, response)
+	if err != nil {
+		return 0, "", err
+	}
+	resp, callErr := w.tools.chat(ctx, w.router, w.role, req)
+	if callErr != nil {
+		return 0, "", callErr
+	}
+	w.recordCost(resp)
+	score, note = parseScoreResponse(resp.Message.Content)
+	return score, note, nil
+}
+
+// scoreRequest builds the request Score sends to the reviewer model.
+func (w *Reviewer) scoreRequest(subtask, response string) (*provider.ChatRequest, error) {
 	prompt := fmt.Sprintf(
 		"You are a code quality reviewer. Score this worker output for a coding subtask.\n\n"+
 			"Subtask:\n%s\n\nOutput:\n%s\n\n"+
@@ -149,18 +227,17 @@ func (w *Reviewer) Score(ctx context.Context, subtask, response string) (score i
 			"(N is 1-10; 1=completely wrong, 10=perfect)",
 		subtask, response,
 	)
+	data := w.promptData
+	data.Subtask = subtask
+	system, err := provider.RenderPrompt(w.scorePrompt, data)
+	if err != nil {
+		return nil, err
+	}
 	messages := []provider.Message{
-		{Role: provider.RoleSystem, Content: "You are a concise code quality reviewer. Output only SCORE: N and REASON: text."},
+		{Role: provider.RoleSystem, Content: system},
 		{Role: provider.RoleUser, Content: prompt},
 	}
-	req := &provider.ChatRequest{Messages: messages}
-	resp, callErr := w.router.ChatCompletionForRole(ctx, w.role, req)
-	if callErr != nil {
-		return 0, "", callErr
-	}
-	w.recordCost(resp)
-	score, note = parseScoreResponse(resp.Message.Content)
-	return score, note, nil
+	return &provider.ChatRequest{Messages: messages}, nil
 }
 
 // parseScoreResponse extracts SCORE and REASON from a reviewer response.
@@ -194,9 +271,13 @@ func (w *Reviewer) recordCost(resp *provider.ChatResponse) {
 		resp.Model,
 		w.role,
 		cost.Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
+			PromptTokens:        resp.Usage.PromptTokens,
+			CompletionTokens:    resp.Usage.CompletionTokens,
+			TotalTokens:         resp.Usage.TotalTokens,
+			CacheCreationTokens: resp.Usage.CacheCreationTokens,
+			CacheReadTokens:     resp.Usage.CacheReadTokens,
+			ReasoningTokens:     resp.Usage.ReasoningTokens,
+			Estimated:           resp.Usage.Estimated,
 		},
 	)
 }
@@ -73,6 +73,65 @@ func TestNewReviewer_CustomOptions(t *testing.T) {
 	}
 }
 
+func TestNewReviewer_ConfigPromptOverrides(t *testing.T) {
+	mp := &mockProvider{name: "test", response: &provider.ChatResponse{
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "SCORE: 7\nREASON: fine"},
+		Done:    true,
+	}}
+	router := buildPromptRouter(t, "reviewer", mp, map[string]string{
+		provider.PromptReviewer:    "custom review prompt",
+		provider.PromptReviewScore: "custom score prompt",
+	})
+	w := NewReviewer(router)
+
+	if w.SystemPrompt() != "custom review prompt" {
+		t.Errorf("SystemPrompt() = %q, want the config override", w.SystemPrompt())
+	}
+	score, _, err := w.Score(context.Background(), "subtask", "output")
+	if err != nil || score != 7 {
+		t.Fatalf("Score = %d, %v", score, err)
+	}
+	if got := mp.lastReq.Messages[0].Content; got != "custom score prompt" {
+		t.Errorf("score system prompt = %q, want the config override", got)
+	}
+}
+
+func TestReviewer_TemplatePrompts(t *testing.T) {
+	mp := &mockProvider{name: "test", response: &provider.ChatResponse{
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "SCORE: 7\nREASON: fine"},
+		Done:    true,
+	}}
+	router := buildPromptRouter(t, "reviewer", mp, map[string]string{
+		provider.PromptReviewer:    "Review the code for {{.Task}}.",
+		provider.PromptReviewScore: "Score {{.Subtask}} of {{.Task}}.",
+	})
+	w := NewReviewer(router, WithReviewerPromptData(provider.PromptData{Task: "a web server"}))
+
+	calls := map[string]func() error{
+		"Review": func() error { _, err := w.Review(context.Background(), "code"); return err },
+		"ReviewWithContext": func() error {
+			_, err := w.ReviewWithContext(context.Background(), "a web server", "code")
+			return err
+		},
+		"Validate": func() error { _, err := w.Validate(context.Background(), "compiles", "code"); return err },
+	}
+	for name, call := range calls {
+		if err := call(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := mp.lastReq.Messages[0].Content; got != "Review the code for a web server." {
+			t.Errorf("%s system prompt = %q", name, got)
+		}
+	}
+
+	if _, _, err := w.Score(context.Background(), "the router", "code"); err != nil {
+		t.Fatalf("Score: %v", err)
+	}
+	if got := mp.lastReq.Messages[0].Content; got != "Score the router of a web server." {
+		t.Errorf("score system prompt = %q", got)
+	}
+}
+
 // --- Review tests ---
 
 func TestReview_ReturnsResponseFromRouter(t *testing.T) {
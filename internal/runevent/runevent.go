@@ -0,0 +1,157 @@
+// Package runevent writes the newline-delimited JSON event stream of
+// "et run --stream-json". Each lifecycle event is one self-contained JSON
+// object on its own line, so a frontend can render progress as it happens.
+package runevent
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// Event types.
+const (
+	TypePhaseStart    = "phase_start"
+	TypeSubtask       = "subtask"
+	TypeWorkerUpdate  = "worker_update"
+	TypeReviewScore   = "review_score"
+	TypeSynthesisDone = "synthesis_done"
+	TypeBuildIter     = "build_iter"
+	TypeTestIter      = "test_iter"
+	TypeCostSummary   = "cost_summary"
+)
+
+// Event is one line of the stream. Type and Time are always set; the other
+// fields are filled in as the type requires and omitted otherwise.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+
+	// phase_start
+	Phase string            `json:"phase,omitempty"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+
+	// subtask, worker_update, review_score: 1-based subtask index.
+	Index   int    `json:"index,omitempty"`
+	Subtask string `json:"subtask,omitempty"`
+
+	// worker_update
+	Role            string  `json:"role,omitempty"`
+	Status          string  `json:"status,omitempty"` // "ok" or "error"
+	Error           string  `json:"error,omitempty"`
+	Tokens          int     `json:"tokens,omitempty"`
+	TokensEstimated bool    `json:"tokens_estimated,omitempty"`
+	ElapsedSeconds  float64 `json:"elapsed_seconds,omitempty"`
+
+	// review_score
+	Score   int    `json:"score,omitempty"`
+	Note    string `json:"note,omitempty"`
+	Flagged bool   `json:"flagged,omitempty"`
+
+	// synthesis_done
+	Content string `json:"content,omitempty"`
+
+	// build_iter
+	Iteration     int   `json:"iteration,omitempty"`
+	MaxIterations int   `json:"max_iterations,omitempty"`
+	OK            *bool `json:"ok,omitempty"`
+	ErrorCount    int   `json:"error_count,omitempty"`
+
+	// cost_summary
+	Cost *cost.Summary `json:"cost,omitempty"`
+}
+
+// Emitter writes events to an output stream. It is safe for concurrent use,
+// and a nil *Emitter discards everything, so callers need not check whether
+// streaming is enabled.
+type Emitter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	now func() time.Time
+}
+
+// New creates an Emitter writing to w.
+func New(w io.Writer) *Emitter {
+	return &Emitter{enc: json.NewEncoder(w), now: time.Now}
+}
+
+// Emit writes ev as one line, stamping Time when it is unset.
+func (e *Emitter) Emit(ev Event) {
+	if e == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = e.now().UTC()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.enc.Encode(ev) // Encode terminates each value with a newline
+}
+
+// PhaseStart emits a phase_start event. kv holds key/value pairs as passed
+// to runlog.Logger.Phase, so it can serve as that logger's phase hook.
+func (e *Emitter) PhaseStart(name string, kv []string) {
+	var attrs map[string]string
+	for i := 0; i+1 < len(kv); i += 2 {
+		if attrs == nil {
+			attrs = make(map[string]string)
+		}
+		attrs[kv[i]] = kv[i+1]
+	}
+	e.Emit(Event{Type: TypePhaseStart, Phase: name, Attrs: attrs})
+}
+
+// Subtasks emits one subtask event per subtask, in order.
+func (e *Emitter) Subtasks(subtasks []string) {
+	for i, st := range subtasks {
+		e.Emit(Event{Type: TypeSubtask, Index: i + 1, Subtask: st})
+	}
+}
+
+// WorkerUpdate emits a worker_update event for the worker at 0-based idx.
+func (e *Emitter) WorkerUpdate(idx int, r role.WorkerResult) {
+	ev := Event{
+		Type:            TypeWorkerUpdate,
+		Index:           idx + 1,
+		Subtask:         r.Subtask,
+		Role:            r.Role,
+		Status:          "ok",
+		Tokens:          r.Tokens,
+		TokensEstimated: r.TokensEst,
+		ElapsedSeconds:  r.Elapsed.Seconds(),
+	}
+	if r.Err != nil {
+		ev.Status = "error"
+		ev.Error = r.Err.Error()
+	}
+	e.Emit(ev)
+}
+
+// ReviewScore emits a review_score event for the worker at 0-based idx.
+func (e *Emitter) ReviewScore(idx int, r role.WorkerResult) {
+	e.Emit(Event{Type: TypeReviewScore, Index: idx + 1, Score: r.ReviewScore, Note: r.ReviewNote, Flagged: r.Flagged})
+}
+
+// SynthesisDone emits a synthesis_done event carrying the final synthesis.
+func (e *Emitter) SynthesisDone(content string) {
+	e.Emit(Event{Type: TypeSynthesisDone, Content: content})
+}
+
+// BuildIter emits a build_iter event for one Phase 5 build attempt.
+func (e *Emitter) BuildIter(iter, max int, ok bool, errorCount int) {
+	e.Emit(Event{Type: TypeBuildIter, Iteration: iter, MaxIterations: max, OK: &ok, ErrorCount: errorCount})
+}
+
+// TestIter emits a test_iter event for one Phase 5.5 test attempt.
+func (e *Emitter) TestIter(iter, max int, ok bool, errorCount int) {
+	e.Emit(Event{Type: TypeTestIter, Iteration: iter, MaxIterations: max, OK: &ok, ErrorCount: errorCount})
+}
+
+// CostSummary emits the run's final cost_summary event.
+func (e *Emitter) CostSummary(s *cost.Summary) {
+	e.Emit(Event{Type: TypeCostSummary, Cost: s})
+}
@@ -0,0 +1,205 @@
+package runevent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/pool"
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+	"github.com/meganerd/electrictown/internal/runlog"
+)
+
+// mockProvider answers as supervisor or worker depending on the request.
+type mockProvider struct{}
+
+func (mockProvider) Name() string { return "mock" }
+
+func (mockProvider) ChatCompletion(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	last := req.Messages[len(req.Messages)-1].Content
+	content := "func main() {}"
+	switch {
+	case strings.HasPrefix(last, "Decompose this task"):
+		content = "1. write the lexer\n2. write the parser"
+	case strings.HasPrefix(last, "Original task:"):
+		content = "combined answer"
+	}
+	return &provider.ChatResponse{
+		Model:   req.Model,
+		Message: provider.Message{Role: provider.RoleAssistant, Content: content},
+		Usage:   provider.Usage{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30},
+		Done:    true,
+	}, nil
+}
+
+func (mockProvider) StreamChatCompletion(context.Context, *provider.ChatRequest) (provider.ChatStream, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mockProvider) ListModels(context.Context) ([]provider.Model, error) { return nil, nil }
+
+func newTestRouter(t *testing.T) *provider.Router {
+	t.Helper()
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{"local": {Type: "mock"}},
+		Models: map[string]provider.ModelConfig{
+			"boss":   {Provider: "local", Model: "boss-model"},
+			"worker": {Provider: "local", Model: "worker-model"},
+		},
+		Roles:    map[string]provider.RoleConfig{"mayor": {Model: "boss"}},
+		Defaults: provider.DefaultsConfig{Model: "worker"},
+	}
+	factories := map[string]provider.ProviderFactory{
+		"mock": func(provider.ProviderConfig) (provider.Provider, error) { return mockProvider{}, nil },
+	}
+	r, err := provider.NewRouter(cfg, factories)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r
+}
+
+// readEvents parses the stream, failing on any line that is not a JSON object.
+func readEvents(t *testing.T, out []byte) []map[string]any {
+	t.Helper()
+	var events []map[string]any
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		var ev map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("line %d is not a JSON object: %v\n%s", len(events)+1, err, sc.Text())
+		}
+		if _, err := time.Parse(time.RFC3339Nano, fmt.Sprint(ev["time"])); err != nil {
+			t.Errorf("line %d: bad time %v", len(events)+1, ev["time"])
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+// TestEmitter_RunSequence runs decompose, the worker pool, a review, a
+// synthesis, and a build attempt against a mock router, wired the way
+// "et run --stream-json" wires them, and checks the event sequence.
+func TestEmitter_RunSequence(t *testing.T) {
+	ctx := context.Background()
+	var out bytes.Buffer
+	em := New(&out)
+	rl := runlog.New(&bytes.Buffer{}, runlog.WithoutBanner(), runlog.WithPhaseHook(em.PhaseStart))
+
+	router := newTestRouter(t)
+	tracker := cost.NewTracker(cost.DefaultPricing())
+	mayor := role.NewMayor(router, role.WithMayorCostTracker(tracker))
+
+	rl.Phase("decompose", "role", "mayor")
+	subtasks, err := mayor.Decompose(ctx, "build a compiler")
+	if err != nil {
+		t.Fatalf("Decompose: %v", err)
+	}
+	em.Subtasks(subtasks)
+
+	rl.Phase("execute", "mode", "parallel")
+	wp := pool.New(router, provider.NewBalancer(provider.StrategyRoundRobin), []string{"worker"}, pool.WithMaxWorkers(1))
+	wp.SetProgressHook(em.WorkerUpdate)
+	results := wp.ExecuteAll(ctx, subtasks, "system")
+
+	rl.Phase("review")
+	results[1].ReviewScore, results[1].ReviewNote, results[1].Flagged = 4, "missing tests", true
+	em.ReviewScore(1, results[1])
+
+	rl.Phase("synthesize")
+	synthesis, err := mayor.Synthesize(ctx, "build a compiler", results)
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	em.SynthesisDone(synthesis)
+
+	rl.Phase("iterate", "runner", "go")
+	em.BuildIter(1, 3, false, 2)
+	em.BuildIter(2, 3, true, 0)
+	rl.Phase("run-tests", "runner", "go")
+	em.TestIter(1, 3, true, 0)
+	em.CostSummary(tracker.Summary())
+
+	events := readEvents(t, out.Bytes())
+	var seq []string
+	for _, ev := range events {
+		s := ev["type"].(string)
+		if p, ok := ev["phase"]; ok {
+			s += ":" + p.(string)
+		}
+		seq = append(seq, s)
+	}
+	want := []string{
+		"phase_start:decompose", "subtask", "subtask",
+		"phase_start:execute", "worker_update", "worker_update",
+		"phase_start:review", "review_score",
+		"phase_start:synthesize", "synthesis_done",
+		"phase_start:iterate", "build_iter", "build_iter",
+		"phase_start:run-tests", "test_iter",
+		"cost_summary",
+	}
+	if strings.Join(seq, " ") != strings.Join(want, " ") {
+		t.Fatalf("event sequence:\n got %v\nwant %v", seq, want)
+	}
+
+	if attrs := events[0]["attrs"].(map[string]any); attrs["role"] != "mayor" {
+		t.Errorf("phase_start attrs = %v", attrs)
+	}
+	if events[2]["index"] != 2.0 || events[2]["subtask"] != "write the parser" {
+		t.Errorf("second subtask event = %v", events[2])
+	}
+	indexes := map[any]bool{}
+	for _, ev := range events[4:6] {
+		indexes[ev["index"]] = true
+		if ev["status"] != "ok" || ev["tokens"] != 30.0 || ev["role"] != "worker" {
+			t.Errorf("worker_update = %v", ev)
+		}
+	}
+	if !indexes[1.0] || !indexes[2.0] {
+		t.Errorf("worker_update indexes = %v, want 1 and 2", indexes)
+	}
+	if ev := events[7]; ev["index"] != 2.0 || ev["score"] != 4.0 || ev["flagged"] != true {
+		t.Errorf("review_score = %v", ev)
+	}
+	if events[9]["content"] != "combined answer" {
+		t.Errorf("synthesis_done = %v", events[9])
+	}
+	if ev := events[11]; ev["ok"] != false || ev["error_count"] != 2.0 || ev["max_iterations"] != 3.0 {
+		t.Errorf("failed build_iter = %v", ev)
+	}
+	if ev := events[14]; ev["type"] != "test_iter" || ev["ok"] != true || ev["iteration"] != 1.0 {
+		t.Errorf("test_iter = %v", ev)
+	}
+	if c := events[15]["cost"].(map[string]any); c["total_requests"] != 2.0 {
+		t.Errorf("cost_summary = %v, want the two supervisor calls", c)
+	}
+}
+
+func TestEmitter_NilDiscards(t *testing.T) {
+	var em *Emitter
+	em.PhaseStart("decompose", nil)
+	em.Subtasks([]string{"a"})
+	em.CostSummary(&cost.Summary{})
+}
+
+func TestEmitter_WorkerError(t *testing.T) {
+	var out bytes.Buffer
+	em := New(&out)
+	em.WorkerUpdate(0, role.WorkerResult{Role: "worker", Err: errors.New("connection refused")})
+	em.WorkerUpdate(1, role.WorkerResult{Role: "worker", Response: "error: is a common log prefix"})
+	events := readEvents(t, out.Bytes())
+	if len(events) != 2 || events[0]["status"] != "error" || events[0]["error"] != "connection refused" {
+		t.Errorf("events = %v", events)
+	}
+	if len(events) == 2 && events[1]["status"] != "ok" {
+		t.Errorf("error-prefixed content reported as a failure: %v", events[1])
+	}
+}
@@ -0,0 +1,121 @@
+// Package runlog writes the run header and the stable phase markers that
+// "et run" prints. Markers have the form
+//
+//	>>> phase=decompose role=mayor
+//
+// and are emitted whether or not the header banner is shown, so tooling can
+// grep run output without parsing the human-oriented text around them.
+package runlog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MarkerPrefix starts every phase marker line.
+const MarkerPrefix = ">>> "
+
+// Header is the information shown in the run banner.
+type Header struct {
+	Version string
+	Config  string
+	Task    string
+	LogDir  string
+	Start   time.Time
+}
+
+// Logger writes the banner and phase markers to an output stream.
+// It is safe for concurrent use.
+type Logger struct {
+	w         io.Writer
+	noBanner  bool
+	phaseHook func(name string, kv []string)
+	synthesis SynthesisFormat
+	mu        sync.Mutex
+}
+
+// Option configures a Logger.
+type Option func(*Logger)
+
+// WithoutBanner suppresses the header block written by Banner.
+func WithoutBanner() Option {
+	return func(l *Logger) {
+		l.noBanner = true
+	}
+}
+
+// WithPhaseHook calls fn with the arguments of every Phase call, after the
+// marker is written. It lets other outputs follow the same phase boundaries.
+func WithPhaseHook(fn func(name string, kv []string)) Option {
+	return func(l *Logger) {
+		l.phaseHook = fn
+	}
+}
+
+// WithSynthesisFormat sets how Synthesis prints the synthesized output. The
+// default is SynthesisBanner.
+func WithSynthesisFormat(f SynthesisFormat) Option {
+	return func(l *Logger) {
+		l.synthesis = f
+	}
+}
+
+// New creates a Logger writing to w.
+func New(w io.Writer, opts ...Option) *Logger {
+	l := &Logger{w: w}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Banner writes the run header block, unless the banner is disabled.
+func (l *Logger) Banner(h Header) {
+	if l.noBanner {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.w, "electrictown %s\n", h.Version)
+	fmt.Fprintf(l.w, "============\n")
+	fmt.Fprintf(l.w, "Config: %s\n", h.Config)
+	fmt.Fprintf(l.w, "Task:   %s\n", h.Task)
+	fmt.Fprintf(l.w, "Logs:   %s\n", h.LogDir)
+	fmt.Fprintf(l.w, "Start:  %s\n\n", h.Start.Format("15:04:05"))
+}
+
+// Phase writes a marker line for the named phase followed by the key/value
+// pairs in kv, in order. Values containing spaces, quotes, or "=" are
+// quoted; a trailing key without a value is ignored.
+func (l *Logger) Phase(name string, kv ...string) {
+	var sb strings.Builder
+	sb.WriteString(MarkerPrefix)
+	sb.WriteString("phase=")
+	sb.WriteString(quote(name))
+	for i := 0; i+1 < len(kv); i += 2 {
+		sb.WriteByte(' ')
+		sb.WriteString(kv[i])
+		sb.WriteByte('=')
+		sb.WriteString(quote(kv[i+1]))
+	}
+	sb.WriteByte('\n')
+
+	l.mu.Lock()
+	io.WriteString(l.w, sb.String())
+	l.mu.Unlock()
+
+	if l.phaseHook != nil {
+		l.phaseHook(name, kv)
+	}
+}
+
+// quote returns v unchanged when it is a bare token, otherwise Go-quoted.
+func quote(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+		return fmt.Sprintf("%q", v)
+	}
+	return v
+}
@@ -0,0 +1,141 @@
+package runlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBanner(t *testing.T) {
+	h := Header{Version: "v1.2.3", Config: "et.yaml", Task: "build it", LogDir: "/tmp/logs", Start: time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)}
+
+	var on bytes.Buffer
+	New(&on).Banner(h)
+	for _, want := range []string{"electrictown v1.2.3", "============", "Task:   build it", "Start:  15:04:05"} {
+		if !strings.Contains(on.String(), want) {
+			t.Errorf("banner missing %q:\n%s", want, on.String())
+		}
+	}
+
+	var off bytes.Buffer
+	New(&off, WithoutBanner()).Banner(h)
+	if off.Len() != 0 {
+		t.Errorf("WithoutBanner still wrote:\n%s", off.String())
+	}
+}
+
+func TestPhase(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithoutBanner())
+	l.Phase("decompose", "role", "mayor")
+	l.Phase("execute", "subtasks", "4", "mode", "dag")
+	l.Phase("review", "note", "two words", "dangling")
+
+	want := []string{
+		">>> phase=decompose role=mayor",
+		">>> phase=execute subtasks=4 mode=dag",
+		`>>> phase=review note="two words"`,
+	}
+	got := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(got), len(want), buf.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPhaseHook(t *testing.T) {
+	var buf bytes.Buffer
+	var got []string
+	l := New(&buf, WithPhaseHook(func(name string, kv []string) {
+		got = append(got, name+" "+strings.Join(kv, ","))
+	}))
+	l.Phase("execute", "mode", "dag")
+	l.Phase("synthesize")
+
+	if want := []string{"execute mode,dag", "synthesize "}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("hook calls = %q, want %q", got, want)
+	}
+	if !strings.Contains(buf.String(), ">>> phase=synthesize") {
+		t.Errorf("marker not written alongside the hook:\n%s", buf.String())
+	}
+}
+
+func TestSynthesis(t *testing.T) {
+	synthesis := "## Result\n\nThe **parser** lives in `parse.go`:\n\n```go\nfunc Parse(s string) (*AST, error)\n```\n"
+	for _, tc := range []struct {
+		format SynthesisFormat
+		want   string
+	}{
+		{SynthesisBanner, "\n--- Final Output ---\n" + synthesis + "\n--------------------\n"},
+		{SynthesisMarkdown, strings.TrimSpace(synthesis) + "\n"},
+		{SynthesisPlain, "Result\n\nThe parser lives in parse.go:\n\nfunc Parse(s string) (*AST, error)\n"},
+		{SynthesisFilesOnly, ""},
+	} {
+		var buf bytes.Buffer
+		New(&buf, WithSynthesisFormat(tc.format)).Synthesis(synthesis)
+		if buf.String() != tc.want {
+			t.Errorf("%s: wrote %q, want %q", tc.format, buf.String(), tc.want)
+		}
+	}
+
+	var def bytes.Buffer
+	New(&def).Synthesis("done")
+	if !strings.HasPrefix(def.String(), "\n--- Final Output ---\n") {
+		t.Errorf("default format wrote %q, want the banners", def.String())
+	}
+}
+
+func TestSynthesis_PlainKeepsIdentifiers(t *testing.T) {
+	for in, want := range map[string]string{
+		"Edit __init__.py and **main.py**.":         "Edit __init__.py and main.py.",
+		"**Note:** keep `__init__` and snake__case": "Note: keep __init__ and snake__case",
+		"__Bold text__, a**b**c, and **open":        "Bold text, a**b**c, and **open",
+		"call **Run()** then __exit__":              "call Run() then __exit__",
+	} {
+		if got := stripMarkdown(in); got != want+"\n" {
+			t.Errorf("stripMarkdown(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSaveSynthesis_FilesOnly(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	l := New(&buf, WithSynthesisFormat(SynthesisFilesOnly))
+
+	l.Synthesis("## Result\n")
+	if err := l.SaveSynthesis(dir, "## Result\n"); err != nil {
+		t.Fatalf("SaveSynthesis: %v", err)
+	}
+	path := filepath.Join(dir, SynthesisFile)
+	if got, err := os.ReadFile(path); err != nil || string(got) != "## Result\n" {
+		t.Errorf("%s = %q, %v; want the synthesis", SynthesisFile, got, err)
+	}
+	if got := buf.String(); got != "  → logged "+path+"\n" {
+		t.Errorf("files-only wrote %q, want only the logged path", got)
+	}
+}
+
+func TestParseSynthesisFormat(t *testing.T) {
+	for in, want := range map[string]SynthesisFormat{
+		"banner":     SynthesisBanner,
+		"markdown":   SynthesisMarkdown,
+		"plain":      SynthesisPlain,
+		"files-only": SynthesisFilesOnly,
+		"none":       SynthesisFilesOnly,
+	} {
+		if got, err := ParseSynthesisFormat(in); err != nil || got != want {
+			t.Errorf("ParseSynthesisFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseSynthesisFormat("html"); err == nil {
+		t.Error("ParseSynthesisFormat(html) succeeded, want an error")
+	}
+}
@@ -0,0 +1,192 @@
+package runlog
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/meganerd/electrictown/internal/fileutil"
+)
+
+// SynthesisFormat is how Synthesis prints a run's synthesized output. The
+// output is saved to SynthesisFile by SaveSynthesis in every format.
+type SynthesisFormat string
+
+// SynthesisFile is the file in a run's log directory that holds the
+// synthesized output.
+const SynthesisFile = "_synthesis.md"
+
+const (
+	// SynthesisBanner prints the output between "--- Final Output ---"
+	// banners.
+	SynthesisBanner SynthesisFormat = "banner"
+	// SynthesisMarkdown prints the output alone, as Markdown.
+	SynthesisMarkdown SynthesisFormat = "markdown"
+	// SynthesisPlain prints the output alone with its Markdown markup
+	// removed: code fences, heading markers, bold markers, and inline code
+	// backticks.
+	SynthesisPlain SynthesisFormat = "plain"
+	// SynthesisFilesOnly prints nothing.
+	SynthesisFilesOnly SynthesisFormat = "files-only"
+)
+
+// ParseSynthesisFormat returns the format named s. "none" is accepted for
+// SynthesisFilesOnly.
+func ParseSynthesisFormat(s string) (SynthesisFormat, error) {
+	switch f := SynthesisFormat(s); f {
+	case SynthesisBanner, SynthesisMarkdown, SynthesisPlain, SynthesisFilesOnly:
+		return f, nil
+	case "none":
+		return SynthesisFilesOnly, nil
+	}
+	return "", fmt.Errorf("runlog: unknown synthesis format %q (want banner, markdown, plain, or files-only)", s)
+}
+
+// Synthesis prints the synthesized output of a run in the Logger's
+// synthesis format.
+func (l *Logger) Synthesis(text string) {
+	var out string
+	switch l.synthesis {
+	case SynthesisFilesOnly:
+		return
+	case SynthesisMarkdown:
+		out = strings.TrimSpace(text) + "\n"
+	case SynthesisPlain:
+		out = stripMarkdown(text)
+	default:
+		out = "\n--- Final Output ---\n" + text + "\n--------------------\n"
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, out)
+}
+
+// SaveSynthesis writes text to SynthesisFile in dir and notes the path on
+// the output stream. It writes the file whatever the synthesis format, so
+// SynthesisFilesOnly still leaves the output on disk.
+func (l *Logger) SaveSynthesis(dir, text string) error {
+	path := filepath.Join(dir, SynthesisFile)
+	if err := fileutil.AtomicWrite(path, []byte(text), 0644); err != nil {
+		return fmt.Errorf("runlog: save synthesis: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.w, "  → logged %s\n", path)
+	return nil
+}
+
+// stripMarkdown removes the markup SynthesisPlain drops, line by line.
+// Fenced code keeps its content but not its fences.
+func stripMarkdown(text string) string {
+	var sb strings.Builder
+	inFence := false
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence {
+			if h := strings.TrimLeft(trimmed, "#"); h != trimmed && (h == "" || h[0] == ' ') {
+				line = strings.TrimSpace(h)
+			}
+			line = stripInline(line)
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// stripInline drops the backticks of inline code spans and the ** and __
+// that wrap text outside them. Identifiers such as __init__.py and
+// snake__case, and anything inside a code span, keep their underscores.
+func stripInline(line string) string {
+	// Even parts are outside code spans, odd parts inside.
+	parts := strings.Split(line, "`")
+	for i := 0; i < len(parts); i += 2 {
+		parts[i] = unwrapEmphasis(unwrapEmphasis(parts[i], "**"), "__")
+	}
+	return strings.Join(parts, "")
+}
+
+// unwrapEmphasis removes each pair of delim that wraps text at word
+// boundaries: an opener not preceded by a word character and followed by
+// a non-space, and a closer preceded by a non-space and followed by the end
+// of the text, a space, or closing punctuation. A single word between __
+// pairs is taken for a Python dunder name and kept.
+func unwrapEmphasis(s, delim string) string {
+	var sb strings.Builder
+	pos, from := 0, 0
+	for {
+		open := indexDelim(s, delim, from, opensEmphasis)
+		if open < 0 {
+			break
+		}
+		end := indexDelim(s, delim, open+len(delim)+1, closesEmphasis)
+		if end < 0 {
+			break
+		}
+		if delim == "__" && !strings.ContainsAny(s[open:end], " \t") {
+			from = end + len(delim)
+			continue
+		}
+		sb.WriteString(s[pos:open])
+		sb.WriteString(s[open+len(delim) : end])
+		pos = end + len(delim)
+		from = pos
+	}
+	sb.WriteString(s[pos:])
+	return sb.String()
+}
+
+// indexDelim returns the index of the first delim in s at or after from
+// for which ok holds, or -1.
+func indexDelim(s, delim string, from int, ok func(s string, i, n int) bool) int {
+	for from <= len(s) {
+		i := strings.Index(s[from:], delim)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		if ok(s, i, len(delim)) {
+			return i
+		}
+		from = i + 1
+	}
+	return -1
+}
+
+// opensEmphasis reports whether the n-byte delimiter at s[i] can open
+// emphasis.
+func opensEmphasis(s string, i, n int) bool {
+	if i > 0 && (isWordByte(s[i-1]) || s[i-1] == s[i]) {
+		return false
+	}
+	return i+n < len(s) && s[i+n] != ' ' && s[i+n] != '\t' && s[i+n] != s[i]
+}
+
+// closesEmphasis reports whether the n-byte delimiter at s[i] can close
+// emphasis. A closer followed by "." counts only at the end of a sentence,
+// so the "__" in "__init__.py" does not.
+func closesEmphasis(s string, i, n int) bool {
+	if i == 0 || s[i-1] == ' ' || s[i-1] == '\t' {
+		return false
+	}
+	rest := s[i+n:]
+	switch {
+	case rest == "":
+		return true
+	case rest[0] == '.':
+		return len(rest) == 1 || rest[1] == ' '
+	default:
+		return strings.IndexByte(" \t,;:!?)]}\"'", rest[0]) >= 0
+	}
+}
+
+// isWordByte reports whether b can be part of a word or identifier; bytes
+// of multi-byte characters count as letters.
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
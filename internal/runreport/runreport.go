@@ -0,0 +1,122 @@
+// Package runreport builds the single JSON document "et run --json" prints in
+// place of its human-oriented output, so scripts can drive a run without
+// scraping banners, spinners, and progress lines.
+package runreport
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// SchemaVersion is bumped when a field is renamed or removed. Adding fields
+// does not change it.
+const SchemaVersion = 1
+
+// Run and worker statuses.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Report describes a finished (or failed) run.
+type Report struct {
+	SchemaVersion int           `json:"schema_version"`
+	RunID         string        `json:"run_id"`
+	Task          string        `json:"task"`
+	LogDir        string        `json:"log_dir"`
+	Status        string        `json:"status"`
+	Error         string        `json:"error,omitempty"`
+	Subtasks      []string      `json:"subtasks"`
+	Workers       []Worker      `json:"workers"`
+	Synthesis     string        `json:"synthesis,omitempty"`
+	Files         []string      `json:"files"` // written under --output-dir, sorted
+	Cost          *cost.Summary `json:"cost"`
+
+	files map[string]struct{}
+}
+
+// Worker is the outcome of one subtask.
+type Worker struct {
+	Index           int     `json:"index"` // 1-based, matching the human output
+	Subtask         string  `json:"subtask"`
+	Role            string  `json:"role"`
+	Status          string  `json:"status"`
+	Error           string  `json:"error,omitempty"`
+	Tokens          int     `json:"tokens"`
+	TokensEstimated bool    `json:"tokens_estimated"`
+	ElapsedSeconds  float64 `json:"elapsed_seconds"`
+	ReviewScore     int     `json:"review_score"` // 0 = not reviewed
+	ReviewNote      string  `json:"review_note,omitempty"`
+	Flagged         bool    `json:"flagged"`
+	Output          string  `json:"output,omitempty"`
+}
+
+// New returns an empty report for task.
+func New(task string) *Report {
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		Task:          task,
+		Status:        StatusOK,
+		Subtasks:      []string{},
+		Workers:       []Worker{},
+		Files:         []string{},
+		files:         make(map[string]struct{}),
+	}
+}
+
+// SetWorkers replaces the worker list with results, in subtask order.
+// A result with a non-nil Err marks the worker as failed.
+func (r *Report) SetWorkers(results []role.WorkerResult) {
+	r.Workers = make([]Worker, 0, len(results))
+	for i, res := range results {
+		w := Worker{
+			Index:           i + 1,
+			Subtask:         res.Subtask,
+			Role:            res.Role,
+			Status:          StatusOK,
+			Tokens:          res.Tokens,
+			TokensEstimated: res.TokensEst,
+			ElapsedSeconds:  res.Elapsed.Seconds(),
+			ReviewScore:     res.ReviewScore,
+			ReviewNote:      res.ReviewNote,
+			Flagged:         res.Flagged,
+			Output:          res.Response,
+		}
+		if res.Err != nil {
+			w.Status = StatusError
+			w.Error = res.Err.Error()
+		}
+		r.Workers = append(r.Workers, w)
+	}
+}
+
+// AddFiles records written output files. Repeats are ignored.
+func (r *Report) AddFiles(paths ...string) {
+	for _, p := range paths {
+		if _, ok := r.files[p]; ok {
+			continue
+		}
+		r.files[p] = struct{}{}
+		r.Files = append(r.Files, p)
+	}
+	sort.Strings(r.Files)
+}
+
+// Finish records how the run ended. A nil err leaves the status "ok".
+func (r *Report) Finish(err error) {
+	if err != nil {
+		r.Status = StatusError
+		r.Error = err.Error()
+	}
+}
+
+// Write encodes the report as indented JSON followed by a newline.
+func (r *Report) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,199 @@
+package runreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/meganerd/electrictown/internal/cost"
+	"github.com/meganerd/electrictown/internal/pool"
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// mockProvider answers as supervisor or worker depending on the request.
+type mockProvider struct{}
+
+func (mockProvider) Name() string { return "mock" }
+
+func (mockProvider) ChatCompletion(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	last := req.Messages[len(req.Messages)-1].Content
+	var content string
+	switch {
+	case strings.HasPrefix(last, "Decompose this task"):
+		content = "1. write the parser\n2. write the broken printer"
+	case strings.HasPrefix(last, "Original task:"):
+		content = "combined answer"
+	case strings.Contains(last, "broken"):
+		return nil, &provider.APIError{Status: 400, Message: "bad request"}
+	default:
+		content = "===FILE: parser.go===\npackage main\n===ENDFILE==="
+	}
+	return &provider.ChatResponse{
+		Model:   req.Model,
+		Message: provider.Message{Role: provider.RoleAssistant, Content: content},
+		Usage:   provider.Usage{PromptTokens: 40, CompletionTokens: 10, TotalTokens: 50},
+		Done:    true,
+	}, nil
+}
+
+func (mockProvider) StreamChatCompletion(context.Context, *provider.ChatRequest) (provider.ChatStream, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mockProvider) ListModels(context.Context) ([]provider.Model, error) { return nil, nil }
+
+func newTestRouter(t *testing.T) *provider.Router {
+	t.Helper()
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{"local": {Type: "mock"}},
+		Models: map[string]provider.ModelConfig{
+			"boss":   {Provider: "local", Model: "boss-model"},
+			"worker": {Provider: "local", Model: "worker-model"},
+		},
+		Roles:    map[string]provider.RoleConfig{"mayor": {Model: "boss"}},
+		Defaults: provider.DefaultsConfig{Model: "worker"},
+	}
+	factories := map[string]provider.ProviderFactory{
+		"mock": func(provider.ProviderConfig) (provider.Provider, error) { return mockProvider{}, nil },
+	}
+	r, err := provider.NewRouter(cfg, factories)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r
+}
+
+// TestReport_RunPath drives decompose, parallel workers, and synthesis
+// through a mock router the way "et run" does and checks the JSON document
+// a script would read.
+func TestReport_RunPath(t *testing.T) {
+	ctx := context.Background()
+	router := newTestRouter(t)
+	tracker := cost.NewTracker(cost.DefaultPricing())
+	mayor := role.NewMayor(router, role.WithMayorCostTracker(tracker))
+
+	rep := New("build a pretty printer")
+	rep.RunID = "abc123"
+	rep.LogDir = "/tmp/logs/abc123"
+
+	subtasks, err := mayor.Decompose(ctx, rep.Task)
+	if err != nil {
+		t.Fatalf("Decompose: %v", err)
+	}
+	rep.Subtasks = subtasks
+
+	wp := pool.New(router, provider.NewBalancer(provider.StrategyRoundRobin), []string{"worker"})
+	results := wp.ExecuteAll(ctx, subtasks, "system")
+	results[0].ReviewScore = 8
+	results[0].ReviewNote = "fine"
+	rep.SetWorkers(results)
+
+	synthesis, err := mayor.Synthesize(ctx, rep.Task, results)
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	rep.Synthesis = synthesis
+	rep.AddFiles("out/parser.go", "out/a.go", "out/parser.go")
+	rep.Cost = tracker.Summary()
+	rep.Finish(nil)
+
+	var buf bytes.Buffer
+	if err := rep.Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	for key, kind := range map[string]string{
+		"schema_version": "number", "run_id": "string", "task": "string", "log_dir": "string",
+		"status": "string", "subtasks": "array", "workers": "array", "synthesis": "string",
+		"files": "array", "cost": "object",
+	} {
+		if got := jsonKind(doc[key]); got != kind {
+			t.Errorf("%s: got %s, want %s", key, got, kind)
+		}
+	}
+	if doc["status"] != StatusOK || doc["synthesis"] != "combined answer" {
+		t.Errorf("status/synthesis = %v/%v", doc["status"], doc["synthesis"])
+	}
+	if files := doc["files"].([]any); len(files) != 2 || files[0] != "out/a.go" {
+		t.Errorf("files = %v, want sorted and deduplicated", files)
+	}
+
+	workers := doc["workers"].([]any)
+	if len(workers) != 2 {
+		t.Fatalf("workers = %d, want 2", len(workers))
+	}
+	for i, w := range workers {
+		w := w.(map[string]any)
+		for key, kind := range map[string]string{
+			"index": "number", "subtask": "string", "role": "string", "status": "string",
+			"tokens": "number", "tokens_estimated": "bool", "elapsed_seconds": "number",
+			"review_score": "number", "flagged": "bool",
+		} {
+			if got := jsonKind(w[key]); got != kind {
+				t.Errorf("workers[%d].%s: got %s, want %s", i, key, got, kind)
+			}
+		}
+	}
+	ok, failed := workers[0].(map[string]any), workers[1].(map[string]any)
+	if ok["status"] != StatusOK || ok["review_score"] != 8.0 || ok["tokens"] != 50.0 {
+		t.Errorf("first worker = %v", ok)
+	}
+	if failed["status"] != StatusError || failed["error"] == nil || failed["output"] != nil {
+		t.Errorf("second worker should be an error without output: %v", failed)
+	}
+
+	c := doc["cost"].(map[string]any)
+	if c["total_requests"] != 2.0 || c["by_role"].(map[string]any)["mayor"] == nil {
+		t.Errorf("cost = %v, want the two supervisor calls", c)
+	}
+}
+
+func TestReport_FinishError(t *testing.T) {
+	rep := New("task")
+	rep.Finish(fmt.Errorf("supervisor decompose failed: boom"))
+
+	var buf bytes.Buffer
+	if err := rep.Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if doc["status"] != StatusError || doc["error"] != "supervisor decompose failed: boom" {
+		t.Errorf("status/error = %v/%v", doc["status"], doc["error"])
+	}
+	// Empty lists stay arrays so consumers need no null checks.
+	for _, key := range []string{"subtasks", "workers", "files"} {
+		if jsonKind(doc[key]) != "array" {
+			t.Errorf("%s = %v, want an empty array", key, doc[key])
+		}
+	}
+}
+
+// jsonKind names the JSON type of a decoded value.
+func jsonKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
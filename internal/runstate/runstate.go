@@ -0,0 +1,200 @@
+// Package runstate checkpoints a pooled "et run" to _state.json in its run
+// log directory, so "et run --resume <run-id>" can pick a failed run up at
+// synthesis or the build loop instead of paying for decomposition and the
+// workers again.
+package runstate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/fileutil"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// FileName is the checkpoint file written into each run log directory.
+const FileName = "_state.json"
+
+// SchemaVersion is the version of the _state.json layout. Load rejects
+// other versions.
+const SchemaVersion = 1
+
+// State is everything a resumed run needs to continue after Phase 2.5.
+type State struct {
+	SchemaVersion      int            `json:"schema_version"`
+	RunID              string         `json:"run_id"`
+	Task               string         `json:"task"`
+	OutputDir          string         `json:"output_dir,omitempty"`
+	WorkerSystemPrompt string         `json:"worker_system_prompt"` // as sent to the workers, for build fixes
+	Subtasks           []string       `json:"subtasks"`
+	Workers            []Worker       `json:"workers"`
+	Synthesis          string         `json:"synthesis,omitempty"` // set once Phases 3 and 4 are done
+	Files              map[string]int `json:"files,omitempty"`     // written file → 0-based worker index
+	UpdatedAt          time.Time      `json:"updated_at"`
+}
+
+// Worker is a role.WorkerResult in serializable form.
+type Worker struct {
+	Role        string        `json:"role"`
+	Subtask     string        `json:"subtask"`
+	Response    string        `json:"response,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Tokens      int           `json:"tokens"`
+	TokensEst   bool          `json:"tokens_estimated,omitempty"`
+	Elapsed     time.Duration `json:"elapsed_ns"`
+	ReviewScore int           `json:"review_score,omitempty"`
+	ReviewNote  string        `json:"review_note,omitempty"`
+	Flagged     bool          `json:"flagged,omitempty"`
+}
+
+// New creates an empty State for a run.
+func New(runID, task, outputDir string) *State {
+	return &State{SchemaVersion: SchemaVersion, RunID: runID, Task: task, OutputDir: outputDir}
+}
+
+// SetResults records the worker results, in subtask order.
+func (s *State) SetResults(results []role.WorkerResult) {
+	s.Workers = make([]Worker, len(results))
+	for i, r := range results {
+		w := Worker{
+			Role:        r.Role,
+			Subtask:     r.Subtask,
+			Response:    r.Response,
+			Tokens:      r.Tokens,
+			TokensEst:   r.TokensEst,
+			Elapsed:     r.Elapsed,
+			ReviewScore: r.ReviewScore,
+			ReviewNote:  r.ReviewNote,
+			Flagged:     r.Flagged,
+		}
+		if r.Err != nil {
+			w.Error = r.Err.Error()
+		}
+		s.Workers[i] = w
+	}
+}
+
+// Results returns the recorded worker results. A failed worker's error
+// comes back as a plain error carrying the original message.
+func (s *State) Results() []role.WorkerResult {
+	out := make([]role.WorkerResult, len(s.Workers))
+	for i, w := range s.Workers {
+		out[i] = role.WorkerResult{
+			Role:        w.Role,
+			Subtask:     w.Subtask,
+			Response:    w.Response,
+			Tokens:      w.Tokens,
+			TokensEst:   w.TokensEst,
+			Elapsed:     w.Elapsed,
+			ReviewScore: w.ReviewScore,
+			ReviewNote:  w.ReviewNote,
+			Flagged:     w.Flagged,
+		}
+		if w.Error != "" {
+			out[i].Err = errors.New(w.Error)
+		}
+	}
+	return out
+}
+
+// Save writes the state to dir/_state.json atomically, so a crash mid-write
+// leaves the previous checkpoint intact.
+func (s *State) Save(dir string) error {
+	s.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("runstate: encode: %w", err)
+	}
+	if err := fileutil.AtomicWrite(filepath.Join(dir, FileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("runstate: write: %w", err)
+	}
+	return nil
+}
+
+// Load reads dir/_state.json.
+func Load(dir string) (*State, error) {
+	path := filepath.Join(dir, FileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("runstate: read %s: %w", path, err)
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("runstate: parse %s: %w", path, err)
+	}
+	if s.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("runstate: %s has schema version %d, want %d", path, s.SchemaVersion, SchemaVersion)
+	}
+	if len(s.Workers) != len(s.Subtasks) {
+		return nil, fmt.Errorf("runstate: %s has %d workers for %d subtasks", path, len(s.Workers), len(s.Subtasks))
+	}
+	return &s, nil
+}
+
+// FindRunDir returns the run log directory under baseLogDir for runID.
+// Run directories are named {YYYY-MM-DD}_{runID}.
+func FindRunDir(baseLogDir, runID string) (string, error) {
+	if runID == "" || strings.ContainsAny(runID, `/\*?[`) {
+		return "", fmt.Errorf("runstate: invalid run ID %q", runID)
+	}
+	matches, err := filepath.Glob(filepath.Join(baseLogDir, "*_"+runID))
+	if err != nil {
+		return "", fmt.Errorf("runstate: find run %s: %w", runID, err)
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("runstate: no run %s under %s", runID, baseLogDir)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("runstate: run ID %s matches %d directories under %s", runID, len(matches), baseLogDir)
+}
+
+// TaskRunID returns a run ID derived from task and the config, as read by
+// provider.ReadConfig with its includes merged, so every run of the same
+// task with the same config gets the same ID. Use FreeRunID to tell the
+// runs apart.
+func TaskRunID(task string, config []byte) string {
+	h := sha256.New()
+	h.Write([]byte(task))
+	h.Write([]byte{0})
+	h.Write(config)
+	return hex.EncodeToString(h.Sum(nil))[:8]
+}
+
+// FreeRunID claims a run directory baseLogDir/<day>_<id> and returns the ID
+// and the directory. The ID is runID if no run directory under baseLogDir
+// has it, and otherwise runID with the first free numeric suffix (runID-2,
+// runID-3, ...), so FindRunDir finds exactly one directory for it. The
+// directory is created with os.Mkdir, which fails if it exists, so two runs
+// racing for an ID end up with different ones.
+func FreeRunID(baseLogDir, day, runID string) (string, string, error) {
+	if err := os.MkdirAll(baseLogDir, 0755); err != nil {
+		return "", "", fmt.Errorf("runstate: create log directory: %w", err)
+	}
+	for n := 1; ; n++ {
+		id := runID
+		if n > 1 {
+			id = fmt.Sprintf("%s-%d", runID, n)
+		}
+		if matches, _ := filepath.Glob(filepath.Join(baseLogDir, "*_"+id)); len(matches) > 0 {
+			continue
+		}
+		dir := filepath.Join(baseLogDir, day+"_"+id)
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return id, dir, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", "", fmt.Errorf("runstate: create run directory: %w", err)
+		}
+	}
+}
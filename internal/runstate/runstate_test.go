@@ -0,0 +1,263 @@
+package runstate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/pool"
+	"github.com/meganerd/electrictown/internal/provider"
+	"github.com/meganerd/electrictown/internal/role"
+)
+
+// mockProvider plays supervisor and worker, counting calls per model.
+type mockProvider struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (p *mockProvider) Name() string { return "mock" }
+
+func (p *mockProvider) ChatCompletion(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	p.mu.Lock()
+	p.calls[req.Model]++
+	p.mu.Unlock()
+	last := req.Messages[len(req.Messages)-1].Content
+	content := "===FILE: " + strings.Fields(last)[len(strings.Fields(last))-1] + ".go===\npackage main\n===ENDFILE==="
+	switch {
+	case strings.HasPrefix(last, "Decompose this task"):
+		content = "1. write lexer\n2. write parser"
+	case strings.HasPrefix(last, "Original task:"):
+		content = "synthesized: " + fmt.Sprint(strings.Count(last, "===FILE:")) + " files"
+	}
+	return &provider.ChatResponse{
+		Model:   req.Model,
+		Message: provider.Message{Role: provider.RoleAssistant, Content: content},
+		Usage:   provider.Usage{TotalTokens: 30},
+		Done:    true,
+	}, nil
+}
+
+func (p *mockProvider) StreamChatCompletion(context.Context, *provider.ChatRequest) (provider.ChatStream, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *mockProvider) ListModels(context.Context) ([]provider.Model, error) { return nil, nil }
+
+func newTestRouter(t *testing.T) (*provider.Router, *mockProvider) {
+	t.Helper()
+	mock := &mockProvider{calls: map[string]int{}}
+	cfg := &provider.Config{
+		Providers: map[string]provider.ProviderConfig{"local": {Type: "mock"}},
+		Models: map[string]provider.ModelConfig{
+			"boss":   {Provider: "local", Model: "boss-model"},
+			"worker": {Provider: "local", Model: "worker-model"},
+		},
+		Roles:    map[string]provider.RoleConfig{"mayor": {Model: "boss"}},
+		Defaults: provider.DefaultsConfig{Model: "worker"},
+	}
+	r, err := provider.NewRouter(cfg, map[string]provider.ProviderFactory{
+		"mock": func(provider.ProviderConfig) (provider.Provider, error) { return mock, nil },
+	})
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r, mock
+}
+
+// TestResume_SaveMidPipelineAndComplete checkpoints a run after its workers
+// finish, then resumes from the checkpoint the way "et run --resume" does and
+// completes synthesis without re-running decomposition or any worker.
+func TestResume_SaveMidPipelineAndComplete(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// First run: decompose and execute, then "fail" before synthesis.
+	router, mock := newTestRouter(t)
+	mayor := role.NewMayor(router)
+	subtasks, err := mayor.Decompose(ctx, "build a compiler")
+	if err != nil {
+		t.Fatalf("Decompose: %v", err)
+	}
+	wp := pool.New(router, provider.NewBalancer(provider.StrategyRoundRobin), []string{"worker"})
+	results := wp.ExecuteAll(ctx, subtasks, "you are a worker")
+	results[1].ReviewScore, results[1].ReviewNote = 7, "fine"
+
+	st := New("abc123", "build a compiler", "out")
+	st.Subtasks, st.WorkerSystemPrompt = subtasks, "you are a worker"
+	st.SetResults(results)
+	if err := st.Save(dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Resumed run: a fresh router, so any call it makes is counted anew.
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	router2, mock2 := newTestRouter(t)
+	synthesis, err := role.NewMayor(router2).Synthesize(ctx, loaded.Task, loaded.Results())
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+
+	if synthesis != "synthesized: 2 files" {
+		t.Errorf("synthesis = %q, want it built from both saved worker outputs", synthesis)
+	}
+	if mock2.calls["worker-model"] != 0 || mock2.calls["boss-model"] != 1 {
+		t.Errorf("resumed run calls = %v, want only the synthesis call", mock2.calls)
+	}
+	if mock.calls["worker-model"] != 2 {
+		t.Errorf("first run worker calls = %d, want 2", mock.calls["worker-model"])
+	}
+	if loaded.WorkerSystemPrompt != "you are a worker" || loaded.Results()[1].ReviewScore != 7 {
+		t.Errorf("loaded state lost fields: %+v", loaded)
+	}
+
+	// Checkpoint the synthesis and written files; a second resume reuses both.
+	loaded.Synthesis = synthesis
+	loaded.Files = map[string]int{"lexer.go": 0, "parser.go": 1}
+	if err := loaded.Save(dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	again, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if again.Synthesis != synthesis || again.Files["parser.go"] != 1 {
+		t.Errorf("second checkpoint = %+v", again)
+	}
+}
+
+func TestResults_RoundTripsErrors(t *testing.T) {
+	st := New("id", "task", "")
+	st.Subtasks = []string{"a", "b"}
+	st.SetResults([]role.WorkerResult{
+		{Role: "w", Subtask: "a", Response: "ok", Tokens: 5, Elapsed: 2 * time.Second, Flagged: true},
+		{Role: "w", Subtask: "b", Err: errors.New("connection refused")},
+	})
+	dir := t.TempDir()
+	if err := st.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := loaded.Results()
+	if got[0].Err != nil || got[0].Response != "ok" || got[0].Elapsed != 2*time.Second || !got[0].Flagged {
+		t.Errorf("result[0] = %+v", got[0])
+	}
+	if got[1].Err == nil || got[1].Err.Error() != "connection refused" || got[1].Response != "" {
+		t.Errorf("result[1] = %+v, want the saved error", got[1])
+	}
+}
+
+func TestLoad_Rejects(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(dir); err == nil {
+		t.Error("Load of a missing state succeeded")
+	}
+	for name, body := range map[string]string{
+		"version":  `{"schema_version": 99}`,
+		"mismatch": `{"schema_version": 1, "subtasks": ["a"], "workers": []}`,
+		"garbage":  `{`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, FileName), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(dir); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+}
+
+func TestFindRunDir(t *testing.T) {
+	base := t.TempDir()
+	for _, d := range []string{"2026-10-01_aaa111", "2026-10-02_bbb222", "2026-10-03_bbb222"} {
+		if err := os.Mkdir(filepath.Join(base, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := FindRunDir(base, "aaa111")
+	if err != nil || got != filepath.Join(base, "2026-10-01_aaa111") {
+		t.Errorf("FindRunDir(aaa111) = %q, %v", got, err)
+	}
+	for _, id := range []string{"missing", "bbb222", "", "../x", "a*"} {
+		if _, err := FindRunDir(base, id); err == nil {
+			t.Errorf("FindRunDir(%q) succeeded", id)
+		}
+	}
+}
+
+func TestTaskRunID(t *testing.T) {
+	config := []byte("roles:\n  mayor:\n    model: big\n")
+	id := TaskRunID("build a CLI", config)
+	if len(id) != 8 || strings.Trim(id, "0123456789abcdef") != "" {
+		t.Errorf("TaskRunID = %q, want 8 hex digits", id)
+	}
+	if again := TaskRunID("build a CLI", config); again != id {
+		t.Errorf("same task and config: %q then %q", id, again)
+	}
+	for name, other := range map[string]string{
+		"task":   TaskRunID("build a TUI", config),
+		"config": TaskRunID("build a CLI", []byte("roles:\n  mayor:\n    model: small\n")),
+		"split":  TaskRunID("build a CL", append([]byte("I"), config...)),
+	} {
+		if other == id {
+			t.Errorf("different %s gave the same ID %q", name, id)
+		}
+	}
+}
+
+func TestFreeRunID(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "logs")
+	id, dir, err := FreeRunID(base, "2026-10-01", "abcd1234")
+	if err != nil || id != "abcd1234" || filepath.Base(dir) != "2026-10-01_abcd1234" {
+		t.Errorf("FreeRunID with no runs = %q, %q, %v", id, dir, err)
+	}
+	for _, d := range []string{"2026-10-02_abcd1234-2", "2026-10-02_abcd1234-20"} {
+		if err := os.Mkdir(filepath.Join(base, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id, dir, err = FreeRunID(base, "2026-10-03", "abcd1234")
+	if err != nil || id != "abcd1234-3" {
+		t.Errorf("FreeRunID = %q, %v, want abcd1234-3", id, err)
+	}
+	if found, err := FindRunDir(base, id); err != nil || found != dir {
+		t.Errorf("FindRunDir(%s) = %q, %v, want %q", id, found, err, dir)
+	}
+}
+
+func TestFreeRunID_Concurrent(t *testing.T) {
+	base := t.TempDir()
+	const runs = 8
+	ids := make([]string, runs)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, _, err := FreeRunID(base, "2026-10-01", "abcd1234")
+			if err != nil {
+				t.Error(err)
+			}
+			ids[i] = id
+		}()
+	}
+	wg.Wait()
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("ID %q claimed twice: %v", id, ids)
+		}
+		seen[id] = true
+	}
+}
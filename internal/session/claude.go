@@ -0,0 +1,176 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/fileutil"
+)
+
+// ClaudeAdapter implements ProviderAdapter for the Claude Code CLI. Sessions
+// run the claude binary directly in the work directory, where it picks up
+// CLAUDE.md and the .claude/settings.local.json that ProvisionHooks writes.
+type ClaudeAdapter struct {
+	command  string
+	model    string
+	args     []string
+	stopHook string
+	getenv   func(string) string
+}
+
+// ClaudeOption configures a ClaudeAdapter.
+type ClaudeOption func(*ClaudeAdapter)
+
+// WithClaudeCommand sets the claude binary to run. It overrides
+// ET_CLAUDE_COMMAND.
+func WithClaudeCommand(command string) ClaudeOption {
+	return func(a *ClaudeAdapter) { a.command = command }
+}
+
+// WithClaudeModel sets the model for every role. It overrides
+// ET_CLAUDE_MODEL_<ROLE> and ANTHROPIC_MODEL.
+func WithClaudeModel(model string) ClaudeOption {
+	return func(a *ClaudeAdapter) { a.model = model }
+}
+
+// WithClaudeArgs sets extra arguments passed to claude before --model and
+// the prompt, e.g. "-p" for a non-interactive run.
+func WithClaudeArgs(args ...string) ClaudeOption {
+	return func(a *ClaudeAdapter) { a.args = args }
+}
+
+// WithClaudeStopHook sets a shell command that Claude Code runs through its
+// Stop hook each time the agent finishes responding.
+func WithClaudeStopHook(command string) ClaudeOption {
+	return func(a *ClaudeAdapter) { a.stopHook = command }
+}
+
+// withClaudeEnv replaces os.Getenv for tests.
+func withClaudeEnv(getenv func(string) string) ClaudeOption {
+	return func(a *ClaudeAdapter) { a.getenv = getenv }
+}
+
+// NewClaudeAdapter creates an adapter that launches Claude Code sessions.
+func NewClaudeAdapter(opts ...ClaudeOption) *ClaudeAdapter {
+	a := &ClaudeAdapter{getenv: os.Getenv}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Name returns "claude".
+func (a *ClaudeAdapter) Name() string {
+	return "claude"
+}
+
+// ResolveConfig resolves the session configuration for a role from the
+// environment. The binary is ET_CLAUDE_COMMAND, or "claude". The model is
+// ET_CLAUDE_MODEL_<ROLE> (role upper-cased, "-" as "_"), then
+// ANTHROPIC_MODEL; with neither set, claude uses its own default.
+// Instructions come from CLAUDE.md in the work directory, which claude reads
+// itself.
+func (a *ClaudeAdapter) ResolveConfig(role string) (*SessionConfig, error) {
+	if role == "" {
+		return nil, fmt.Errorf("claude: role is required")
+	}
+	command := a.command
+	if command == "" {
+		command = a.getenv("ET_CLAUDE_COMMAND")
+	}
+	if command == "" {
+		command = "claude"
+	}
+	model := a.model
+	if model == "" {
+		model = a.getenv("ET_CLAUDE_MODEL_" + strings.ToUpper(strings.ReplaceAll(role, "-", "_")))
+	}
+	if model == "" {
+		model = a.getenv("ANTHROPIC_MODEL")
+	}
+
+	return &SessionConfig{
+		Provider:         "claude",
+		Role:             role,
+		Command:          command,
+		Args:             append([]string(nil), a.args...),
+		Env:              map[string]string{"ET_ROLE": role},
+		InstructionsFile: "CLAUDE.md",
+		Model:            model,
+		Timeout:          30 * time.Minute,
+	}, nil
+}
+
+// ProvisionHooks writes .claude/settings.local.json in workDir, setting
+// ET_ROLE in the agent's environment and, when a stop hook is configured,
+// registering it as a Stop hook. Other settings already in the file are kept.
+func (a *ClaudeAdapter) ProvisionHooks(workDir string, role string) error {
+	path := filepath.Join(workDir, ".claude", "settings.local.json")
+	settings := map[string]any{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("claude: parse %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("claude: read %s: %w", path, err)
+	}
+
+	env, _ := settings["env"].(map[string]any)
+	if env == nil {
+		env = map[string]any{}
+	}
+	env["ET_ROLE"] = role
+	settings["env"] = env
+
+	if a.stopHook != "" {
+		hooks, _ := settings["hooks"].(map[string]any)
+		if hooks == nil {
+			hooks = map[string]any{}
+		}
+		hooks["Stop"] = []any{
+			map[string]any{
+				"hooks": []any{
+					map[string]any{"type": "command", "command": a.stopHook},
+				},
+			},
+		}
+		settings["hooks"] = hooks
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("claude: encode settings: %w", err)
+	}
+	if err := fileutil.AtomicWrite(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("claude: write %s: %w", path, err)
+	}
+	return nil
+}
+
+// BuildCommand constructs the claude CLI command to launch an agent session.
+// Returns the command and args: claude [args...] [--model <model>] <prompt>
+func (a *ClaudeAdapter) BuildCommand(cfg *SessionConfig, prompt string) (string, []string) {
+	args := append([]string(nil), cfg.Args...)
+	if cfg.Model != "" {
+		args = append(args, "--model", cfg.Model)
+	}
+	args = append(args, prompt)
+	return cfg.Command, args
+}
+
+// ReadinessCheck returns a delay-based readiness strategy. Claude Code draws
+// a full-screen interface rather than a plain prompt line, so a short delay
+// covers its startup.
+func (a *ClaudeAdapter) ReadinessCheck(cfg *SessionConfig) ReadinessStrategy {
+	return ReadinessStrategy{
+		Type:  "delay",
+		Delay: 5 * time.Second,
+	}
+}
+
+// Compile-time interface compliance check.
+var _ ProviderAdapter = (*ClaudeAdapter)(nil)
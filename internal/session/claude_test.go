@@ -0,0 +1,224 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeEnv returns a getenv backed by vars.
+func fakeEnv(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestClaudeAdapter_Name(t *testing.T) {
+	adapter := NewClaudeAdapter()
+	if adapter.Name() != "claude" {
+		t.Errorf("expected name 'claude', got %q", adapter.Name())
+	}
+}
+
+func TestClaudeAdapter_ResolveConfig(t *testing.T) {
+	adapter := NewClaudeAdapter(withClaudeEnv(fakeEnv(map[string]string{
+		"ANTHROPIC_MODEL":             "claude-sonnet-4-20250514",
+		"ET_CLAUDE_MODEL_CODE_REVIEW": "claude-opus-4-20250514",
+	})))
+
+	// Test resolving a role that uses ANTHROPIC_MODEL.
+	sessCfg, err := adapter.ResolveConfig("mayor")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sessCfg.Provider != "claude" {
+		t.Errorf("expected provider 'claude', got %q", sessCfg.Provider)
+	}
+	if sessCfg.Role != "mayor" {
+		t.Errorf("expected role 'mayor', got %q", sessCfg.Role)
+	}
+	if sessCfg.Model != "claude-sonnet-4-20250514" {
+		t.Errorf("expected model 'claude-sonnet-4-20250514', got %q", sessCfg.Model)
+	}
+	if sessCfg.Command != "claude" {
+		t.Errorf("expected command 'claude', got %q", sessCfg.Command)
+	}
+	if sessCfg.InstructionsFile != "CLAUDE.md" {
+		t.Errorf("expected instructions file 'CLAUDE.md', got %q", sessCfg.InstructionsFile)
+	}
+	if sessCfg.Env["ET_ROLE"] != "mayor" {
+		t.Errorf("expected ET_ROLE=mayor in env, got %v", sessCfg.Env)
+	}
+	if sessCfg.Timeout <= 0 {
+		t.Error("expected positive timeout")
+	}
+
+	// Test a role with its own model variable.
+	sessCfg, err = adapter.ResolveConfig("code-review")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sessCfg.Model != "claude-opus-4-20250514" {
+		t.Errorf("expected per-role model 'claude-opus-4-20250514', got %q", sessCfg.Model)
+	}
+}
+
+func TestClaudeAdapter_ResolveConfig_Overrides(t *testing.T) {
+	env := fakeEnv(map[string]string{
+		"ANTHROPIC_MODEL":   "from-env",
+		"ET_CLAUDE_COMMAND": "/opt/claude/bin/claude",
+	})
+
+	sessCfg, err := NewClaudeAdapter(withClaudeEnv(env)).ResolveConfig("polecat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sessCfg.Command != "/opt/claude/bin/claude" {
+		t.Errorf("expected command from ET_CLAUDE_COMMAND, got %q", sessCfg.Command)
+	}
+
+	adapter := NewClaudeAdapter(withClaudeEnv(env),
+		WithClaudeCommand("claude-dev"), WithClaudeModel("from-option"), WithClaudeArgs("-p"))
+	sessCfg, err = adapter.ResolveConfig("polecat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sessCfg.Command != "claude-dev" || sessCfg.Model != "from-option" {
+		t.Errorf("expected options to win over env, got command %q model %q", sessCfg.Command, sessCfg.Model)
+	}
+	if !reflect.DeepEqual(sessCfg.Args, []string{"-p"}) {
+		t.Errorf("expected args [-p], got %v", sessCfg.Args)
+	}
+}
+
+func TestClaudeAdapter_ResolveConfig_NoModel(t *testing.T) {
+	adapter := NewClaudeAdapter(withClaudeEnv(fakeEnv(nil)))
+	sessCfg, err := adapter.ResolveConfig("polecat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sessCfg.Model != "" {
+		t.Errorf("expected no model, leaving claude's default, got %q", sessCfg.Model)
+	}
+
+	if _, err := adapter.ResolveConfig(""); err == nil {
+		t.Fatal("expected error for empty role")
+	}
+}
+
+func TestClaudeAdapter_BuildCommand(t *testing.T) {
+	adapter := NewClaudeAdapter()
+
+	sessCfg := &SessionConfig{
+		Provider: "claude",
+		Role:     "polecat",
+		Command:  "claude",
+		Args:     []string{"-p"},
+		Model:    "claude-sonnet-4-20250514",
+	}
+
+	cmd, args := adapter.BuildCommand(sessCfg, "fix the authentication bug")
+	if cmd != "claude" {
+		t.Errorf("expected command 'claude', got %q", cmd)
+	}
+	want := []string{"-p", "--model", "claude-sonnet-4-20250514", "fix the authentication bug"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+	if len(sessCfg.Args) != 1 {
+		t.Errorf("BuildCommand modified the config's args: %v", sessCfg.Args)
+	}
+
+	// Without a model, --model is left out.
+	sessCfg.Model = ""
+	_, args = adapter.BuildCommand(sessCfg, "hello")
+	if strings.Contains(strings.Join(args, " "), "--model") {
+		t.Errorf("expected no '--model' without a model, got: %v", args)
+	}
+}
+
+func TestClaudeAdapter_ReadinessCheck(t *testing.T) {
+	adapter := NewClaudeAdapter()
+
+	sessCfg := &SessionConfig{
+		Provider: "claude",
+		Role:     "polecat",
+	}
+
+	readiness := adapter.ReadinessCheck(sessCfg)
+	if readiness.Type != "delay" {
+		t.Errorf("expected readiness type 'delay', got %q", readiness.Type)
+	}
+	if readiness.Delay <= 0 {
+		t.Error("expected positive delay duration")
+	}
+}
+
+func TestClaudeAdapter_ProvisionHooks(t *testing.T) {
+	workDir := t.TempDir()
+	adapter := NewClaudeAdapter(WithClaudeStopHook("et session notify"))
+
+	if err := adapter.ProvisionHooks(workDir, "polecat"); err != nil {
+		t.Fatalf("unexpected error from ProvisionHooks: %v", err)
+	}
+	settings := readClaudeSettings(t, workDir)
+	if env, _ := settings["env"].(map[string]any); env["ET_ROLE"] != "polecat" {
+		t.Errorf("expected env.ET_ROLE 'polecat', got %v", settings["env"])
+	}
+	hooks, _ := settings["hooks"].(map[string]any)
+	stop, _ := json.Marshal(hooks["Stop"])
+	if string(stop) != `[{"hooks":[{"command":"et session notify","type":"command"}]}]` {
+		t.Errorf("unexpected Stop hook: %s", stop)
+	}
+}
+
+func TestClaudeAdapter_ProvisionHooks_KeepsSettings(t *testing.T) {
+	workDir := t.TempDir()
+	path := filepath.Join(workDir, ".claude", "settings.local.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	existing := `{"permissions":{"allow":["Bash(go test:*)"]},"env":{"FOO":"bar"}}`
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a stop hook, only ET_ROLE is added.
+	if err := NewClaudeAdapter().ProvisionHooks(workDir, "crew"); err != nil {
+		t.Fatalf("unexpected error from ProvisionHooks: %v", err)
+	}
+	settings := readClaudeSettings(t, workDir)
+	if _, ok := settings["permissions"]; !ok {
+		t.Error("expected existing permissions to be kept")
+	}
+	env, _ := settings["env"].(map[string]any)
+	if env["FOO"] != "bar" || env["ET_ROLE"] != "crew" {
+		t.Errorf("expected env FOO=bar and ET_ROLE=crew, got %v", env)
+	}
+	if _, ok := settings["hooks"]; ok {
+		t.Error("expected no hooks without a stop hook")
+	}
+
+	// A file that is not JSON is an error, not overwritten.
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewClaudeAdapter().ProvisionHooks(workDir, "crew"); err == nil {
+		t.Error("expected error for unparseable settings")
+	}
+}
+
+// readClaudeSettings decodes the settings file ProvisionHooks wrote.
+func readClaudeSettings(t *testing.T, workDir string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(workDir, ".claude", "settings.local.json"))
+	if err != nil {
+		t.Fatalf("reading settings: %v", err)
+	}
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatalf("parsing settings: %v", err)
+	}
+	return settings
+}
@@ -14,3 +14,17 @@ type Executor interface {
 	// Stop terminates a running session by its ID.
 	Stop(sessionID string) error
 }
+
+// Interactive is implemented by executors whose sessions can be attached to
+// and sent input while they run, such as TmuxExecutor.
+type Interactive interface {
+	// Attach connects the current terminal to the session until the user
+	// detaches.
+	Attach(sess *Session) error
+
+	// Send delivers a line of input to the session.
+	Send(sess *Session, text string) error
+
+	// Kill terminates the session.
+	Kill(sess *Session) error
+}
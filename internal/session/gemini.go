@@ -0,0 +1,157 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/fileutil"
+)
+
+// GeminiCLIAdapter implements ProviderAdapter for Google's Gemini CLI.
+// Sessions run the gemini binary in the work directory, with
+// .gemini/settings.json pointing it at AGENTS.md for its instructions.
+type GeminiCLIAdapter struct {
+	command string
+	model   string
+	args    []string
+	getenv  func(string) string
+}
+
+// GeminiCLIOption configures a GeminiCLIAdapter.
+type GeminiCLIOption func(*GeminiCLIAdapter)
+
+// WithGeminiCommand sets the gemini binary to run. It overrides
+// ET_GEMINI_COMMAND.
+func WithGeminiCommand(command string) GeminiCLIOption {
+	return func(a *GeminiCLIAdapter) { a.command = command }
+}
+
+// WithGeminiModel sets the model for every role. It overrides
+// ET_GEMINI_MODEL_<ROLE> and GEMINI_MODEL.
+func WithGeminiModel(model string) GeminiCLIOption {
+	return func(a *GeminiCLIAdapter) { a.model = model }
+}
+
+// WithGeminiArgs sets extra arguments passed to gemini before --model and
+// the prompt, e.g. "--yolo" to auto-approve tool calls.
+func WithGeminiArgs(args ...string) GeminiCLIOption {
+	return func(a *GeminiCLIAdapter) { a.args = args }
+}
+
+// withGeminiEnv replaces os.Getenv for tests.
+func withGeminiEnv(getenv func(string) string) GeminiCLIOption {
+	return func(a *GeminiCLIAdapter) { a.getenv = getenv }
+}
+
+// NewGeminiCLIAdapter creates an adapter that launches Gemini CLI sessions.
+func NewGeminiCLIAdapter(opts ...GeminiCLIOption) *GeminiCLIAdapter {
+	a := &GeminiCLIAdapter{getenv: os.Getenv}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Name returns "gemini".
+func (a *GeminiCLIAdapter) Name() string {
+	return "gemini"
+}
+
+// ResolveConfig resolves the session configuration for a role from the
+// environment. The binary is ET_GEMINI_COMMAND, or "gemini". The model is
+// ET_GEMINI_MODEL_<ROLE> (role upper-cased, "-" as "_"), then GEMINI_MODEL;
+// with neither set, gemini uses its own default.
+func (a *GeminiCLIAdapter) ResolveConfig(role string) (*SessionConfig, error) {
+	if role == "" {
+		return nil, fmt.Errorf("gemini: role is required")
+	}
+	command := a.command
+	if command == "" {
+		command = a.getenv("ET_GEMINI_COMMAND")
+	}
+	if command == "" {
+		command = "gemini"
+	}
+	model := a.model
+	if model == "" {
+		model = a.getenv("ET_GEMINI_MODEL_" + strings.ToUpper(strings.ReplaceAll(role, "-", "_")))
+	}
+	if model == "" {
+		model = a.getenv("GEMINI_MODEL")
+	}
+
+	return &SessionConfig{
+		Provider:         "gemini",
+		Role:             role,
+		Command:          command,
+		Args:             append([]string(nil), a.args...),
+		Env:              map[string]string{"ET_ROLE": role},
+		InstructionsFile: "AGENTS.md",
+		Model:            model,
+		Timeout:          30 * time.Minute,
+	}, nil
+}
+
+// ProvisionHooks sets contextFileName to AGENTS.md in workDir's
+// .gemini/settings.json, keeping any other settings, and writes a starter
+// AGENTS.md naming the role when the work directory has none.
+func (a *GeminiCLIAdapter) ProvisionHooks(workDir string, role string) error {
+	path := filepath.Join(workDir, ".gemini", "settings.json")
+	settings := map[string]any{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("gemini: parse %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("gemini: read %s: %w", path, err)
+	}
+	settings["contextFileName"] = "AGENTS.md"
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("gemini: encode settings: %w", err)
+	}
+	if err := fileutil.AtomicWrite(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("gemini: write %s: %w", path, err)
+	}
+
+	agents := filepath.Join(workDir, "AGENTS.md")
+	if _, err := os.Stat(agents); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("gemini: stat %s: %w", agents, err)
+	}
+	starter := fmt.Sprintf("# Agent instructions\n\nYou are the %s agent in an electrictown session. Work in this directory.\n", role)
+	if err := fileutil.AtomicWrite(agents, []byte(starter), 0o644); err != nil {
+		return fmt.Errorf("gemini: write %s: %w", agents, err)
+	}
+	return nil
+}
+
+// BuildCommand constructs the gemini CLI command to launch an agent session.
+// Returns the command and args: gemini [args...] [--model <model>] --prompt-interactive <prompt>
+func (a *GeminiCLIAdapter) BuildCommand(cfg *SessionConfig, prompt string) (string, []string) {
+	args := append([]string(nil), cfg.Args...)
+	if cfg.Model != "" {
+		args = append(args, "--model", cfg.Model)
+	}
+	args = append(args, "--prompt-interactive", prompt)
+	return cfg.Command, args
+}
+
+// ReadinessCheck returns a delay-based readiness strategy. Like Claude Code,
+// the Gemini CLI draws a full-screen interface rather than a plain prompt
+// line, so a short delay covers its startup.
+func (a *GeminiCLIAdapter) ReadinessCheck(cfg *SessionConfig) ReadinessStrategy {
+	return ReadinessStrategy{
+		Type:  "delay",
+		Delay: 5 * time.Second,
+	}
+}
+
+// Compile-time interface compliance check.
+var _ ProviderAdapter = (*GeminiCLIAdapter)(nil)
@@ -0,0 +1,168 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGeminiCLIAdapter_Name(t *testing.T) {
+	adapter := NewGeminiCLIAdapter()
+	if adapter.Name() != "gemini" {
+		t.Errorf("expected name 'gemini', got %q", adapter.Name())
+	}
+}
+
+func TestGeminiCLIAdapter_ResolveConfig(t *testing.T) {
+	adapter := NewGeminiCLIAdapter(withGeminiEnv(fakeEnv(map[string]string{
+		"GEMINI_MODEL":            "gemini-2.5-flash",
+		"ET_GEMINI_MODEL_MAYOR":   "gemini-2.5-pro",
+		"ET_GEMINI_COMMAND":       "/usr/local/bin/gemini",
+		"ET_GEMINI_MODEL_POLECAT": "",
+	})))
+
+	// Test a role with its own model variable.
+	sessCfg, err := adapter.ResolveConfig("mayor")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sessCfg.Provider != "gemini" {
+		t.Errorf("expected provider 'gemini', got %q", sessCfg.Provider)
+	}
+	if sessCfg.Role != "mayor" {
+		t.Errorf("expected role 'mayor', got %q", sessCfg.Role)
+	}
+	if sessCfg.Model != "gemini-2.5-pro" {
+		t.Errorf("expected model 'gemini-2.5-pro', got %q", sessCfg.Model)
+	}
+	if sessCfg.Command != "/usr/local/bin/gemini" {
+		t.Errorf("expected command from ET_GEMINI_COMMAND, got %q", sessCfg.Command)
+	}
+	if sessCfg.InstructionsFile != "AGENTS.md" {
+		t.Errorf("expected instructions file 'AGENTS.md', got %q", sessCfg.InstructionsFile)
+	}
+	if sessCfg.Env["ET_ROLE"] != "mayor" {
+		t.Errorf("expected ET_ROLE=mayor in env, got %v", sessCfg.Env)
+	}
+
+	// Test a role that falls back to GEMINI_MODEL.
+	sessCfg, err = adapter.ResolveConfig("polecat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sessCfg.Model != "gemini-2.5-flash" {
+		t.Errorf("expected default model 'gemini-2.5-flash', got %q", sessCfg.Model)
+	}
+
+	// Options win over the environment.
+	sessCfg, err = NewGeminiCLIAdapter(withGeminiEnv(fakeEnv(nil)),
+		WithGeminiModel("gemini-2.0-flash"), WithGeminiArgs("--yolo")).ResolveConfig("crew")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sessCfg.Command != "gemini" || sessCfg.Model != "gemini-2.0-flash" || !reflect.DeepEqual(sessCfg.Args, []string{"--yolo"}) {
+		t.Errorf("expected gemini with gemini-2.0-flash and [--yolo], got %q %q %v", sessCfg.Command, sessCfg.Model, sessCfg.Args)
+	}
+
+	if _, err := adapter.ResolveConfig(""); err == nil {
+		t.Fatal("expected error for empty role")
+	}
+}
+
+func TestGeminiCLIAdapter_BuildCommand(t *testing.T) {
+	adapter := NewGeminiCLIAdapter()
+
+	sessCfg := &SessionConfig{
+		Provider: "gemini",
+		Role:     "polecat",
+		Command:  "gemini",
+		Args:     []string{"--yolo"},
+		Model:    "gemini-2.5-pro",
+	}
+
+	cmd, args := adapter.BuildCommand(sessCfg, "fix the authentication bug")
+	if cmd != "gemini" {
+		t.Errorf("expected command 'gemini', got %q", cmd)
+	}
+	want := []string{"--yolo", "--model", "gemini-2.5-pro", "--prompt-interactive", "fix the authentication bug"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+
+	// Without a model, --model is left out.
+	sessCfg.Model = ""
+	_, args = adapter.BuildCommand(sessCfg, "hello")
+	if strings.Contains(strings.Join(args, " "), "--model") {
+		t.Errorf("expected no '--model' without a model, got: %v", args)
+	}
+}
+
+func TestGeminiCLIAdapter_ReadinessCheck(t *testing.T) {
+	readiness := NewGeminiCLIAdapter().ReadinessCheck(&SessionConfig{Provider: "gemini", Role: "polecat"})
+	if readiness.Type != "delay" {
+		t.Errorf("expected readiness type 'delay', got %q", readiness.Type)
+	}
+	if readiness.Delay <= 0 {
+		t.Error("expected positive delay duration")
+	}
+}
+
+func TestGeminiCLIAdapter_ProvisionHooks(t *testing.T) {
+	workDir := t.TempDir()
+	settingsPath := filepath.Join(workDir, ".gemini", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settingsPath, []byte(`{"theme":"Dracula"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	adapter := NewGeminiCLIAdapter()
+	if err := adapter.ProvisionHooks(workDir, "polecat"); err != nil {
+		t.Fatalf("unexpected error from ProvisionHooks: %v", err)
+	}
+
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := string(data)
+	if !strings.Contains(settings, `"contextFileName": "AGENTS.md"`) || !strings.Contains(settings, `"theme": "Dracula"`) {
+		t.Errorf("expected contextFileName added and theme kept, got:\n%s", settings)
+	}
+
+	agents, err := os.ReadFile(filepath.Join(workDir, "AGENTS.md"))
+	if err != nil {
+		t.Fatalf("expected a starter AGENTS.md: %v", err)
+	}
+	if !strings.Contains(string(agents), "polecat") {
+		t.Errorf("expected the starter AGENTS.md to name the role, got:\n%s", agents)
+	}
+
+	// An existing AGENTS.md is left alone.
+	if err := os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("custom"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.ProvisionHooks(workDir, "crew"); err != nil {
+		t.Fatalf("unexpected error from ProvisionHooks: %v", err)
+	}
+	if agents, _ := os.ReadFile(filepath.Join(workDir, "AGENTS.md")); string(agents) != "custom" {
+		t.Errorf("expected AGENTS.md kept, got %q", agents)
+	}
+}
+
+func TestGeminiCLIAdapter_ProvisionHooks_BadSettings(t *testing.T) {
+	workDir := t.TempDir()
+	settingsPath := filepath.Join(workDir, ".gemini", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settingsPath, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewGeminiCLIAdapter().ProvisionHooks(workDir, "polecat"); err == nil {
+		t.Error("expected error for unparseable settings")
+	}
+}
@@ -91,10 +91,7 @@ func (e *SubprocessExecutor) Execute(ctx context.Context, sess *Session) error {
 	cmd.Env = env
 
 	// Pipe stdout and stderr to session output.
-	sess.mu.Lock()
-	writer := io.Writer(&sess.Output)
-	sess.mu.Unlock()
-
+	writer := io.Writer(outputWriter{sess})
 	cmd.Stdout = writer
 	cmd.Stderr = writer
 
@@ -145,7 +142,9 @@ var _ Executor = (*SubprocessExecutor)(nil)
 // Execute delegates to the SessionLauncher's executor.
 // If no executor is set, it creates a default SubprocessExecutor.
 func (l *SessionLauncher) Execute(ctx context.Context, sess *Session) error {
-	return l.executor().Execute(ctx, sess)
+	err := l.executor().Execute(ctx, sess)
+	_ = l.persist(sess)
+	return err
 }
 
 // ExecuteAsync launches the session in a background goroutine.
@@ -161,6 +160,51 @@ func (l *SessionLauncher) Stop(sessionID string) error {
 	return l.executor().Stop(sessionID)
 }
 
+// Attach attaches the current terminal to the session with the given ID.
+// The launcher's Executor must implement Interactive, as TmuxExecutor does.
+func (l *SessionLauncher) Attach(id string) error {
+	sess, ex, err := l.interactive(id)
+	if err != nil {
+		return err
+	}
+	return ex.Attach(sess)
+}
+
+// Send delivers a line of input to the session with the given ID.
+func (l *SessionLauncher) Send(id, text string) error {
+	sess, ex, err := l.interactive(id)
+	if err != nil {
+		return err
+	}
+	return ex.Send(sess, text)
+}
+
+// Kill terminates the session with the given ID.
+func (l *SessionLauncher) Kill(id string) error {
+	sess, ex, err := l.interactive(id)
+	if err != nil {
+		return err
+	}
+	if err := ex.Kill(sess); err != nil {
+		return err
+	}
+	return l.persist(sess)
+}
+
+// interactive returns the session with the given ID and the launcher's
+// Executor as an Interactive.
+func (l *SessionLauncher) interactive(id string) (*Session, Interactive, error) {
+	sess, ok := l.GetSession(id)
+	if !ok {
+		return nil, nil, fmt.Errorf("no session with ID %q", id)
+	}
+	ex, ok := l.executor().(Interactive)
+	if !ok {
+		return nil, nil, fmt.Errorf("executor %T does not support attach, send, or kill", l.executor())
+	}
+	return sess, ex, nil
+}
+
 // executor returns the configured Executor, lazily creating a SubprocessExecutor
 // if none was set. This preserves backward compatibility for existing callers.
 func (l *SessionLauncher) executor() Executor {
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultPromptTimeout is how long Start waits for a "prompt" readiness
+// strategy's prompt before assuming the session is ready anyway, when the
+// strategy sets no Timeout.
+const DefaultPromptTimeout = 60 * time.Second
+
+// DefaultHealthTimeout is how long Start polls a "health" readiness
+// strategy's HealthURL before failing the session, when the strategy sets
+// no Timeout.
+const DefaultHealthTimeout = 60 * time.Second
+
+// maxHealthPoll caps the backoff between WaitHealthy attempts, and
+// healthAttemptTimeout bounds each attempt.
+const (
+	maxHealthPoll        = 5 * time.Second
+	healthAttemptTimeout = 2 * time.Second
+)
+
+// PromptDetector watches session output as it is written for a line that
+// starts with a ready prompt, ignoring leading whitespace. It is an
+// io.Writer, so it can be fed the output stream directly; a prompt split
+// across writes is still found, and one with no trailing newline (as
+// interactive prompts usually have) counts as soon as it is written.
+type PromptDetector struct {
+	prefix []byte
+
+	mu    sync.Mutex
+	line  []byte // start of the current line, up to what a match needs
+	ready chan struct{}
+	seen  bool
+}
+
+// NewPromptDetector returns a PromptDetector for prompts starting with
+// prefix.
+func NewPromptDetector(prefix string) *PromptDetector {
+	return &PromptDetector{prefix: bytes.TrimLeft([]byte(prefix), " \t\r"), ready: make(chan struct{})}
+}
+
+// Write scans p for the prompt. It never fails.
+func (d *PromptDetector) Write(p []byte) (int, error) {
+	n := len(p)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen {
+		return n, nil
+	}
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		chunk := p
+		if i >= 0 {
+			chunk = p[:i]
+		}
+		if d.scan(chunk) {
+			d.seen = true
+			close(d.ready)
+			return n, nil
+		}
+		if i < 0 {
+			break
+		}
+		d.line = d.line[:0]
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// scan adds chunk, which has no newline, to the current line and reports
+// whether the line now starts with the prompt.
+func (d *PromptDetector) scan(chunk []byte) bool {
+	if len(d.line) == 0 {
+		chunk = bytes.TrimLeft(chunk, " \t\r")
+	}
+	if need := len(d.prefix) - len(d.line); need > 0 {
+		d.line = append(d.line, chunk[:min(need, len(chunk))]...)
+	}
+	return len(d.prefix) > 0 && bytes.Equal(d.line, d.prefix)
+}
+
+// Ready returns a channel closed once the prompt has been written.
+func (d *PromptDetector) Ready() <-chan struct{} {
+	return d.ready
+}
+
+// WaitHealthy polls url with GET requests until one answers 200 OK or ctx
+// ends. The wait between attempts starts at interval and doubles after each
+// failed attempt, up to 5s. It returns the last failure when ctx ends.
+func WaitHealthy(ctx context.Context, url string, interval time.Duration) error {
+	wait := interval
+	for {
+		err := checkHealth(ctx, url)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last attempt: %v)", ctx.Err(), err)
+		}
+		wait = min(2*wait, maxHealthPoll)
+	}
+}
+
+// checkHealth makes one GET request to url and returns an error unless it
+// answers 200 OK.
+func checkHealth(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, healthAttemptTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
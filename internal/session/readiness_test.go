@@ -0,0 +1,120 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ready reports whether d has seen its prompt.
+func ready(d *PromptDetector) bool {
+	select {
+	case <-d.Ready():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestPromptDetector(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   bool
+	}{
+		{"prompt line", []string{"Loading model...\n", "Model loaded.\n", "agent> "}, true},
+		{"prompt followed by newline", []string{"booting\nagent>\nmore output\n"}, true},
+		{"prompt split across writes", []string{"booting\nag", "en", "t> "}, true},
+		{"leading whitespace", []string{"  \t", "agent> "}, true},
+		{"CRLF output", []string{"booting\r\n", "agent> "}, true},
+		{"mid-line mention", []string{"waiting for agent> to appear\n"}, false},
+		{"partial prompt", []string{"booting\nage"}, false},
+		{"prefix across lines", []string{"age\nnt> "}, false},
+		{"no prompt", []string{"line one\n", "line two\n"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewPromptDetector("agent>")
+			for _, w := range tt.writes {
+				if n, err := d.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if got := ready(d); got != tt.want {
+				t.Errorf("ready after %q = %v, want %v", strings.Join(tt.writes, ""), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptDetector_WritesAfterReady(t *testing.T) {
+	d := NewPromptDetector("$ ")
+	d.Write([]byte("$ "))
+	d.Write([]byte("$ ")) // must not close Ready twice
+	if !ready(d) {
+		t.Error("not ready after the prompt")
+	}
+}
+
+// flippingServer answers 503 until healthyAfter has passed since it
+// started, then 200, and records when each request arrived.
+func flippingServer(t *testing.T, healthyAfter time.Duration) (*httptest.Server, func() []time.Duration) {
+	t.Helper()
+	start := time.Now()
+	var mu sync.Mutex
+	var arrivals []time.Duration
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since := time.Since(start)
+		mu.Lock()
+		arrivals = append(arrivals, since)
+		mu.Unlock()
+		if since < healthyAfter {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Duration(nil), arrivals...)
+	}
+}
+
+func TestWaitHealthy_FlipsToHealthy(t *testing.T) {
+	srv, arrivals := flippingServer(t, 120*time.Millisecond)
+
+	start := time.Now()
+	if err := WaitHealthy(context.Background(), srv.URL, 10*time.Millisecond); err != nil {
+		t.Fatalf("WaitHealthy: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 120*time.Millisecond || elapsed > time.Second {
+		t.Errorf("healthy after %v, want shortly after 120ms", elapsed)
+	}
+
+	// Attempts back off: 10ms, 20ms, 40ms, 80ms apart.
+	a := arrivals()
+	if len(a) < 4 {
+		t.Fatalf("only %d attempts: %v", len(a), a)
+	}
+	for i := 2; i < len(a); i++ {
+		if prev, gap := a[i-1]-a[i-2], a[i]-a[i-1]; gap < prev {
+			t.Errorf("attempt gaps %v: gap %d (%v) is shorter than the one before (%v)", a, i, gap, prev)
+		}
+	}
+}
+
+func TestWaitHealthy_Timeout(t *testing.T) {
+	srv, _ := flippingServer(t, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 80*time.Millisecond)
+	defer cancel()
+
+	err := WaitHealthy(ctx, srv.URL, 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "503") {
+		t.Errorf("err = %v, want a deadline error naming the 503", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"time"
@@ -44,9 +45,14 @@ type SessionConfig struct {
 // ReadinessStrategy describes how to detect that an agent is ready for input.
 type ReadinessStrategy struct {
 	Type         string        // "prompt", "delay", "health"
-	PromptPrefix string        // for "prompt" type: prefix to look for in output
+	PromptPrefix string        // for "prompt" type: prefix of the ready prompt's line
 	Delay        time.Duration // for "delay" type: how long to wait
-	HealthURL    string        // for "health" type: URL to poll
+	HealthURL    string        // for "health" type: URL to poll until it answers 200
+	// Timeout bounds the wait for "prompt" and "health" types. A prompt
+	// that has not appeared by then is assumed ready; a health check that
+	// has not passed fails the session. 0 means DefaultPromptTimeout or
+	// DefaultHealthTimeout.
+	Timeout time.Duration
 }
 
 // Session represents a running agent session.
@@ -58,17 +64,76 @@ type Session struct {
 	StartedAt time.Time
 	Prompt    string
 	Output    strings.Builder // captured output
+	TmuxName  string          // tmux session name, set by TmuxExecutor
 
 	mu sync.Mutex
+
+	// Set by SessionLauncher.Start.
+	awaitingReady bool          // hold back StatusRunning until ready
+	done          chan struct{} // closed when Start stops tracking the session
+	err           error         // why the session failed, once done is closed
+	watchers      []io.Writer   // also receive everything written to Output
 }
 
-// SetStatus updates the session status in a thread-safe manner.
+// SetStatus updates the session status in a thread-safe manner. While
+// SessionLauncher.Start waits for the session to become ready, a move to
+// StatusRunning is held back; Start makes it once the readiness check passes.
 func (s *Session) SetStatus(status SessionStatus) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.awaitingReady && status == StatusRunning {
+		return
+	}
 	s.Status = status
 }
 
+// status returns the session status in a thread-safe manner.
+func (s *Session) status() SessionStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Status
+}
+
+// OutputString returns the output captured so far. Unlike reading Output
+// directly, it is safe while the session is running.
+func (s *Session) OutputString() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Output.String()
+}
+
+// Wait blocks until a session launched with SessionLauncher.Start ends and
+// returns its error. A session handed to a non-blocking executor such as
+// TmuxExecutor ends, as far as Wait is concerned, once it is running, or at
+// its timeout when it has one. Wait returns nil at once for a session that
+// was not launched with Start.
+func (s *Session) Wait() error {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	<-done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// outputWriter appends to a session's Output under its lock, so the output
+// can be read with OutputString while the session runs, and passes it on to
+// the session's watchers.
+type outputWriter struct{ s *Session }
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+	for _, wr := range w.s.watchers {
+		wr.Write(p)
+	}
+	return w.s.Output.Write(p)
+}
+
 // ProviderAdapter abstracts how different agent CLIs are configured and launched.
 // Each supported agent runtime (Claude Code, Gemini CLI, etc.) implements this
 // interface to provide provider-specific behavior while keeping the session
@@ -94,6 +159,7 @@ type ProviderAdapter interface {
 type SessionLauncher struct {
 	adapter  ProviderAdapter
 	exec     Executor // optional; defaults to SubprocessExecutor
+	store    *Store   // optional; see SetStore
 	sessions map[string]*Session
 	mu       sync.RWMutex
 }
@@ -116,10 +182,30 @@ func NewSessionLauncherWithExecutor(adapter ProviderAdapter, exec Executor) *Ses
 	}
 }
 
+// SetStore makes the launcher record its sessions in st as they change, and
+// have GetSession and ListSessions include the sessions st holds from other
+// processes.
+func (l *SessionLauncher) SetStore(st *Store) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.store = st
+}
+
+// persist records sess in the launcher's store, if it has one.
+func (l *SessionLauncher) persist(sess *Session) error {
+	l.mu.RLock()
+	st := l.store
+	l.mu.RUnlock()
+	if st == nil {
+		return nil
+	}
+	return st.putSession(sess)
+}
+
 // Spawn creates a new agent session for the given role. It resolves the session
 // configuration through the adapter, provisions any required hooks, and prepares
 // the session for launch. The session is created in StatusPending and must be
-// started separately (e.g., via tmux).
+// started separately, with Start or directly through an Executor.
 func (l *SessionLauncher) Spawn(role, workDir, prompt string) (*Session, error) {
 	cfg, err := l.adapter.ResolveConfig(role)
 	if err != nil {
@@ -151,25 +237,59 @@ func (l *SessionLauncher) Spawn(role, workDir, prompt string) (*Session, error)
 	l.sessions[id] = sess
 	l.mu.Unlock()
 
+	if err := l.persist(sess); err != nil {
+		return nil, err
+	}
 	return sess, nil
 }
 
-// GetSession returns the session with the given ID, if it exists.
+// GetSession returns the session with the given ID, if it exists. A session
+// this launcher did not spawn is looked up in its store, with its status
+// reconciled against tmux.
 func (l *SessionLauncher) GetSession(id string) (*Session, bool) {
 	l.mu.RLock()
-	defer l.mu.RUnlock()
 	sess, ok := l.sessions[id]
-	return sess, ok
+	st := l.store
+	l.mu.RUnlock()
+	if ok || st == nil {
+		return sess, ok
+	}
+	records, err := st.Load()
+	if err != nil {
+		return nil, false
+	}
+	for _, r := range records {
+		if r.ID == id {
+			return r.session(), true
+		}
+	}
+	return nil, false
 }
 
-// ListSessions returns all tracked sessions.
+// ListSessions returns all tracked sessions, including those in the
+// launcher's store that it did not spawn itself.
 func (l *SessionLauncher) ListSessions() []*Session {
 	l.mu.RLock()
-	defer l.mu.RUnlock()
 	result := make([]*Session, 0, len(l.sessions))
 	for _, s := range l.sessions {
 		result = append(result, s)
 	}
+	st := l.store
+	l.mu.RUnlock()
+	if st == nil {
+		return result
+	}
+	records, err := st.Load()
+	if err != nil {
+		return result
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, r := range records {
+		if _, ok := l.sessions[r.ID]; !ok {
+			result = append(result, r.session())
+		}
+	}
 	return result
 }
 
@@ -266,7 +266,7 @@ func newTestConfig() *provider.Config {
 			"qwen-local":    {Provider: "ollama-local", Model: "qwen3-coder:32b"},
 		},
 		Roles: map[string]provider.RoleConfig{
-			"mayor":   {Model: "claude-sonnet", Fallbacks: []string{"qwen-local"}},
+			"mayor":   {Model: "claude-sonnet", Fallbacks: provider.Fallbacks("qwen-local")},
 			"polecat": {Model: "qwen-local"},
 			"crew":    {Model: "qwen-local"},
 		},
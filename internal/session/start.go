@@ -0,0 +1,201 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// readinessPoll is the first wait between polls of a "health" readiness
+// strategy.
+var readinessPoll = 250 * time.Millisecond
+
+// ReadyWaiter is implemented by executors that detect readiness themselves,
+// such as TmuxExecutor, whose output is not captured in Session.Output.
+type ReadyWaiter interface {
+	WaitForReady(ctx context.Context, sess *Session) error
+}
+
+// Start launches a session created by Spawn through the launcher's Executor
+// (a SubprocessExecutor unless another was set) and returns once it is
+// ready for input. The session moves from StatusStarting to StatusReady when
+// the adapter's ReadinessStrategy is met, then to StatusRunning, and ends in
+// StatusDone or StatusFailed; use Session.Wait to block until then. A
+// session that fails or reaches its Config.Timeout before it is ready is
+// marked failed and stopped, and Start returns the error. One whose command
+// completes successfully before it is ready is simply done.
+func (l *SessionLauncher) Start(id string) error {
+	sess, ok := l.GetSession(id)
+	if !ok {
+		return fmt.Errorf("no session with ID %q", id)
+	}
+	sess.mu.Lock()
+	if sess.Status != StatusPending {
+		status := sess.Status
+		sess.mu.Unlock()
+		return fmt.Errorf("session %s is %s, not %s", id, status, StatusPending)
+	}
+	sess.Status = StatusStarting
+	sess.awaitingReady = true
+	sess.done = make(chan struct{})
+	strategy := l.adapter.ReadinessCheck(sess.Config)
+	var prompt *PromptDetector
+	if strategy.Type == "prompt" {
+		prompt = NewPromptDetector(strategy.PromptPrefix)
+		sess.watchers = append(sess.watchers, prompt)
+	}
+	sess.mu.Unlock()
+	// The store is best effort once the session is running; a write that
+	// fails here must not fail or strand the session.
+	defer l.persist(sess)
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if sess.Config.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), sess.Config.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	exec := l.executor()
+	exited := make(chan error, 1)
+	go func() { exited <- exec.Execute(ctx, sess) }()
+	ready := make(chan error, 1)
+	interval := readinessPoll
+	go func() { ready <- waitReady(ctx, exec, sess, strategy, prompt, interval) }()
+
+	// Execute returning nil before the session is ready means either a
+	// blocking executor whose command already finished (it marks the
+	// session done) or a non-blocking one that handed the session off and
+	// left it starting; only the latter still has a readiness check to pass.
+	handedOff := false
+	for {
+		select {
+		case err := <-exited:
+			if err != nil || sess.status() == StatusDone {
+				cancel()
+				finish(sess, err)
+				if err != nil {
+					return fmt.Errorf("session %s exited before it was ready: %w", id, err)
+				}
+				return nil
+			}
+			handedOff, exited = true, nil
+		case err := <-ready:
+			if err != nil {
+				cancel()
+				_ = exec.Stop(id)
+				if !handedOff {
+					<-exited
+				}
+				err = fmt.Errorf("session %s not ready: %w", id, err)
+				finish(sess, err)
+				return err
+			}
+			// The command may have finished as readiness was met; never
+			// move a session back from done.
+			advance(sess, StatusStarting, StatusReady)
+			advance(sess, StatusReady, StatusRunning)
+			go l.track(ctx, cancel, exec, sess, exited, handedOff)
+			return nil
+		}
+	}
+}
+
+// track waits for a ready session to end. A blocking executor's session
+// ends when Execute returns; a handed-off session ends at its timeout, when
+// it is stopped, or immediately when it has none.
+func (l *SessionLauncher) track(ctx context.Context, cancel context.CancelFunc, exec Executor, sess *Session, exited <-chan error, handedOff bool) {
+	defer cancel()
+	defer l.persist(sess)
+	if !handedOff {
+		finish(sess, <-exited)
+		return
+	}
+	if sess.Config.Timeout <= 0 {
+		finish(sess, nil)
+		return
+	}
+	<-ctx.Done()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		_ = exec.Stop(sess.ID)
+		finish(sess, fmt.Errorf("session %s: %w", sess.ID, ctx.Err()))
+		return
+	}
+	finish(sess, nil)
+}
+
+// advance moves sess from status from to status to, and does nothing if
+// it has moved on since. It ends the hold SetStatus puts on StatusRunning.
+func advance(sess *Session, from, to SessionStatus) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.awaitingReady = false
+	if sess.Status == from {
+		sess.Status = to
+	}
+}
+
+// finish records how a session launched with Start ended and releases
+// Session.Wait. A nil err leaves the status the executor set unless it is
+// still starting.
+func finish(sess *Session, err error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.awaitingReady = false
+	switch {
+	case err != nil:
+		sess.Status = StatusFailed
+	case sess.Status == StatusStarting || sess.Status == StatusReady:
+		sess.Status = StatusRunning
+	}
+	sess.err = err
+	close(sess.done)
+}
+
+// waitReady blocks until sess meets strategy or ctx ends. A "prompt"
+// strategy is met when prompt sees the prompt in the session's output, or
+// when its Timeout passes without it, since the session is still alive; a
+// "health" strategy is polled with WaitHealthy, starting at interval, and
+// fails after its Timeout. Executors that implement ReadyWaiter check
+// readiness themselves.
+func waitReady(ctx context.Context, exec Executor, sess *Session, strategy ReadinessStrategy, prompt *PromptDetector, interval time.Duration) error {
+	if w, ok := exec.(ReadyWaiter); ok {
+		return w.WaitForReady(ctx, sess)
+	}
+	switch strategy.Type {
+	case "delay":
+		select {
+		case <-time.After(strategy.Delay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case "prompt":
+		timeout := strategy.Timeout
+		if timeout <= 0 {
+			timeout = DefaultPromptTimeout
+		}
+		select {
+		case <-prompt.Ready():
+			return nil
+		case <-time.After(timeout):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case "health":
+		timeout := strategy.Timeout
+		if timeout <= 0 {
+			timeout = DefaultHealthTimeout
+		}
+		hctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if err := WaitHealthy(hctx, strategy.HealthURL, interval); err != nil {
+			return fmt.Errorf("health check %s: %w", strategy.HealthURL, err)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
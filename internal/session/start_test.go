@@ -0,0 +1,376 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeExecutor runs execute in place of a real launch and records Stop calls.
+type fakeExecutor struct {
+	execute func(ctx context.Context, sess *Session) error
+
+	mu      sync.Mutex
+	stopped []string
+}
+
+func (f *fakeExecutor) Execute(ctx context.Context, sess *Session) error {
+	return f.execute(ctx, sess)
+}
+
+func (f *fakeExecutor) Stop(sessionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = append(f.stopped, sessionID)
+	return nil
+}
+
+// fastReadiness shortens the health readiness poll for the duration of a
+// test.
+func fastReadiness(t *testing.T) {
+	t.Helper()
+	old := readinessPoll
+	readinessPoll = 5 * time.Millisecond
+	t.Cleanup(func() { readinessPoll = old })
+}
+
+// gatedExecutor is a fakeExecutor that checks readiness itself: sessions
+// become ready when ready is closed.
+type gatedExecutor struct {
+	*fakeExecutor
+	ready chan struct{}
+}
+
+func newGatedExecutor(execute func(ctx context.Context, sess *Session) error) *gatedExecutor {
+	return &gatedExecutor{fakeExecutor: &fakeExecutor{execute: execute}, ready: make(chan struct{})}
+}
+
+func (g *gatedExecutor) WaitForReady(ctx context.Context, sess *Session) error {
+	select {
+	case <-g.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// spawnWith spawns a session on a launcher using exec and adapter.
+func spawnWith(t *testing.T, adapter *mockAdapter, exec Executor) (*SessionLauncher, *Session) {
+	t.Helper()
+	launcher := NewSessionLauncherWithExecutor(adapter, exec)
+	sess, err := launcher.Spawn("polecat", t.TempDir(), "work")
+	if err != nil {
+		t.Fatalf("Spawn: %v", err)
+	}
+	return launcher, sess
+}
+
+func TestStart_StateMachine(t *testing.T) {
+	booted, finish := make(chan struct{}), make(chan struct{})
+	exec := newGatedExecutor(func(ctx context.Context, sess *Session) error {
+		sess.SetStatus(StatusStarting)
+		outputWriter{sess}.Write([]byte("booting\n"))
+		sess.SetStatus(StatusRunning) // held until ready
+		close(booted)
+		<-finish
+		sess.SetStatus(StatusDone)
+		return nil
+	})
+	launcher, sess := spawnWith(t, &mockAdapter{name: "test"}, exec)
+
+	started := make(chan error, 1)
+	go func() { started <- launcher.Start(sess.ID) }()
+	<-booted
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case err := <-started:
+		t.Fatalf("Start returned %v before the session was ready", err)
+	default:
+	}
+	if got := sess.status(); got != StatusStarting {
+		t.Errorf("status before ready = %q, want %q", got, StatusStarting)
+	}
+
+	close(exec.ready)
+	if err := <-started; err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if got := sess.status(); got != StatusRunning {
+		t.Errorf("status once ready = %q, want %q", got, StatusRunning)
+	}
+
+	close(finish)
+	if err := sess.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if sess.Status != StatusDone {
+		t.Errorf("final status = %q, want %q", sess.Status, StatusDone)
+	}
+	if !strings.Contains(sess.OutputString(), "booting") {
+		t.Errorf("output = %q", sess.OutputString())
+	}
+}
+
+func TestStart_PromptReadiness(t *testing.T) {
+	booted, showPrompt, finish := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	exec := &fakeExecutor{execute: func(ctx context.Context, sess *Session) error {
+		sess.SetStatus(StatusStarting)
+		outputWriter{sess}.Write([]byte("booting\n"))
+		sess.SetStatus(StatusRunning) // held until the prompt shows
+		close(booted)
+		<-showPrompt
+		outputWriter{sess}.Write([]byte("agent> "))
+		<-finish
+		sess.SetStatus(StatusDone)
+		return nil
+	}}
+	adapter := &mockAdapter{name: "test", readiness: ReadinessStrategy{Type: "prompt", PromptPrefix: "agent>"}}
+	launcher, sess := spawnWith(t, adapter, exec)
+
+	started := make(chan error, 1)
+	go func() { started <- launcher.Start(sess.ID) }()
+	<-booted
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case err := <-started:
+		t.Fatalf("Start returned %v before the prompt appeared", err)
+	default:
+	}
+	if got := sess.status(); got != StatusStarting {
+		t.Errorf("status before the prompt = %q, want %q", got, StatusStarting)
+	}
+
+	close(showPrompt)
+	if err := <-started; err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if got := sess.status(); got != StatusRunning {
+		t.Errorf("status once ready = %q, want %q", got, StatusRunning)
+	}
+
+	close(finish)
+	if err := sess.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if sess.Status != StatusDone {
+		t.Errorf("final status = %q, want %q", sess.Status, StatusDone)
+	}
+	if !strings.Contains(sess.OutputString(), "booting") {
+		t.Errorf("output = %q", sess.OutputString())
+	}
+}
+
+func TestStart_PromptFallbackTimeout(t *testing.T) {
+	exec := &fakeExecutor{execute: func(ctx context.Context, sess *Session) error {
+		outputWriter{sess}.Write([]byte("an agent with a prompt we do not recognize\n% "))
+		<-ctx.Done()
+		return nil
+	}}
+	adapter := &mockAdapter{
+		name:      "test",
+		config:    &SessionConfig{Provider: "test", Timeout: time.Second},
+		readiness: ReadinessStrategy{Type: "prompt", PromptPrefix: "agent>", Timeout: 40 * time.Millisecond},
+	}
+	launcher, sess := spawnWith(t, adapter, exec)
+
+	start := time.Now()
+	if err := launcher.Start(sess.ID); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Start returned after %v, want the 40ms fallback", elapsed)
+	}
+	if got := sess.status(); got != StatusRunning {
+		t.Errorf("status = %q, want %q", got, StatusRunning)
+	}
+}
+
+func TestStart_FailsBeforeReady(t *testing.T) {
+	exec := newGatedExecutor(func(ctx context.Context, sess *Session) error {
+		outputWriter{sess}.Write([]byte("panic: no config\n"))
+		sess.SetStatus(StatusFailed)
+		return errors.New("exit status 2")
+	})
+	launcher, sess := spawnWith(t, &mockAdapter{name: "test"}, exec)
+
+	err := launcher.Start(sess.ID)
+	if err == nil || !strings.Contains(err.Error(), "exit status 2") {
+		t.Fatalf("Start error = %v, want the executor's error", err)
+	}
+	if sess.Status != StatusFailed {
+		t.Errorf("status = %q, want %q", sess.Status, StatusFailed)
+	}
+	if werr := sess.Wait(); werr == nil {
+		t.Error("Wait returned nil for a failed session")
+	}
+}
+
+func TestStart_Timeout(t *testing.T) {
+	exec := newGatedExecutor(func(ctx context.Context, sess *Session) error {
+		<-ctx.Done() // never becomes ready
+		sess.SetStatus(StatusFailed)
+		return ctx.Err()
+	})
+	adapter := &mockAdapter{name: "test", config: &SessionConfig{Provider: "test", Timeout: 50 * time.Millisecond}}
+	launcher, sess := spawnWith(t, adapter, exec)
+
+	start := time.Now()
+	err := launcher.Start(sess.ID)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Start error = %v, want a deadline error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Start took %v to time out", elapsed)
+	}
+	if sess.Status != StatusFailed {
+		t.Errorf("status = %q, want %q", sess.Status, StatusFailed)
+	}
+}
+
+func TestStart_NonBlockingExecutor(t *testing.T) {
+	exec := &fakeExecutor{execute: func(ctx context.Context, sess *Session) error {
+		sess.SetStatus(StatusStarting)
+		sess.SetStatus(StatusRunning)
+		return nil // handed off, like TmuxExecutor
+	}}
+	adapter := &mockAdapter{
+		name:      "test",
+		config:    &SessionConfig{Provider: "test"},
+		readiness: ReadinessStrategy{Type: "delay", Delay: 30 * time.Millisecond},
+	}
+	launcher, sess := spawnWith(t, adapter, exec)
+
+	start := time.Now()
+	if err := launcher.Start(sess.ID); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Errorf("Start returned after %v, before the readiness delay", elapsed)
+	}
+	if err := sess.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if sess.Status != StatusRunning {
+		t.Errorf("status = %q, want %q", sess.Status, StatusRunning)
+	}
+}
+
+func TestStart_NonBlockingExecutor_Timeout(t *testing.T) {
+	handedOff := func(ctx context.Context, sess *Session) error { return nil }
+	tests := map[string]struct {
+		readiness ReadinessStrategy
+		startErr  bool // the timeout hits before the session is ready
+	}{
+		"before ready":  {ReadinessStrategy{Type: "delay", Delay: time.Hour}, true},
+		"while running": {ReadinessStrategy{Type: "delay", Delay: 10 * time.Millisecond}, false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			exec := &fakeExecutor{execute: handedOff}
+			adapter := &mockAdapter{
+				name:      "test",
+				config:    &SessionConfig{Provider: "test", Timeout: 60 * time.Millisecond},
+				readiness: tt.readiness,
+			}
+			launcher, sess := spawnWith(t, adapter, exec)
+
+			err := launcher.Start(sess.ID)
+			if (err != nil) != tt.startErr {
+				t.Fatalf("Start error = %v, want error: %v", err, tt.startErr)
+			}
+			if werr := sess.Wait(); !errors.Is(werr, context.DeadlineExceeded) {
+				t.Errorf("Wait = %v, want a deadline error", werr)
+			}
+			if sess.Status != StatusFailed {
+				t.Errorf("status = %q, want %q", sess.Status, StatusFailed)
+			}
+			exec.mu.Lock()
+			defer exec.mu.Unlock()
+			if len(exec.stopped) != 1 || exec.stopped[0] != sess.ID {
+				t.Errorf("stopped = %v, want the timed-out session", exec.stopped)
+			}
+		})
+	}
+}
+
+func TestStart_HealthReadiness(t *testing.T) {
+	fastReadiness(t)
+	srv, _ := flippingServer(t, 100*time.Millisecond)
+	exec := &fakeExecutor{execute: func(ctx context.Context, sess *Session) error { return nil }}
+	adapter := &mockAdapter{name: "test", config: &SessionConfig{Provider: "test"}, readiness: ReadinessStrategy{Type: "health", HealthURL: srv.URL}}
+	launcher, sess := spawnWith(t, adapter, exec)
+
+	start := time.Now()
+	if err := launcher.Start(sess.ID); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Errorf("ready after %v, want shortly after the endpoint turned healthy at 100ms", elapsed)
+	}
+	if sess.status() != StatusRunning {
+		t.Errorf("status = %q, want %q", sess.status(), StatusRunning)
+	}
+}
+
+func TestStart_HealthTimeout(t *testing.T) {
+	fastReadiness(t)
+	srv, _ := flippingServer(t, time.Hour)
+	exec := &fakeExecutor{execute: func(ctx context.Context, sess *Session) error { return nil }}
+	adapter := &mockAdapter{
+		name:      "test",
+		config:    &SessionConfig{Provider: "test"},
+		readiness: ReadinessStrategy{Type: "health", HealthURL: srv.URL, Timeout: 60 * time.Millisecond},
+	}
+	launcher, sess := spawnWith(t, adapter, exec)
+
+	err := launcher.Start(sess.ID)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "health check") {
+		t.Fatalf("Start error = %v, want a health check deadline error", err)
+	}
+	if sess.Status != StatusFailed {
+		t.Errorf("status = %q, want %q", sess.Status, StatusFailed)
+	}
+}
+
+func TestStart_DefaultSubprocessExecutor(t *testing.T) {
+	adapter := &mockAdapter{
+		name:      "test",
+		builtCmd:  "sh",
+		builtArgs: []string{"-c", "echo booting; sleep 0.05; echo 'agent>'; sleep 0.05; echo bye"},
+		readiness: ReadinessStrategy{Type: "delay", Delay: 20 * time.Millisecond},
+	}
+	launcher := NewSessionLauncher(adapter)
+	sess, err := launcher.Spawn("polecat", t.TempDir(), "work")
+	if err != nil {
+		t.Fatalf("Spawn: %v", err)
+	}
+
+	if err := launcher.Start(sess.ID); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := sess.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if sess.Status != StatusDone {
+		t.Errorf("status = %q, want %q", sess.Status, StatusDone)
+	}
+	if out := sess.OutputString(); !strings.Contains(out, "agent>") || !strings.Contains(out, "bye") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestStart_Errors(t *testing.T) {
+	exec := &fakeExecutor{execute: func(ctx context.Context, sess *Session) error { return nil }}
+	launcher, sess := spawnWith(t, &mockAdapter{name: "test"}, exec)
+
+	if err := launcher.Start("nonexistent"); err == nil || !strings.Contains(err.Error(), "no session") {
+		t.Errorf("Start(unknown) = %v, want a no-session error", err)
+	}
+	sess.SetStatus(StatusRunning)
+	if err := launcher.Start(sess.ID); err == nil || !strings.Contains(err.Error(), "not pending") {
+		t.Errorf("Start(running session) = %v, want a not-pending error", err)
+	}
+}
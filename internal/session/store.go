@@ -0,0 +1,206 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/meganerd/electrictown/internal/fileutil"
+	"github.com/meganerd/electrictown/internal/tmux"
+)
+
+// StoreFile is the name of the session store inside the log directory.
+const StoreFile = "sessions.json"
+
+// Record is the part of a Session that outlives the process that started
+// it.
+type Record struct {
+	ID        string        `json:"id"`
+	Role      string        `json:"role"`
+	TmuxName  string        `json:"tmux_name,omitempty"`
+	Status    SessionStatus `json:"status"`
+	WorkDir   string        `json:"work_dir,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+}
+
+// recordOf snapshots sess as a Record.
+func recordOf(sess *Session) Record {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	r := Record{
+		ID:        sess.ID,
+		Role:      sess.Role,
+		TmuxName:  sess.TmuxName,
+		Status:    sess.Status,
+		StartedAt: sess.StartedAt,
+	}
+	if sess.Config != nil {
+		r.WorkDir = sess.Config.WorkDir
+	}
+	return r
+}
+
+// session rebuilds a Session from r. It carries no output and cannot be
+// waited on.
+func (r Record) session() *Session {
+	return &Session{
+		ID:        r.ID,
+		Role:      r.Role,
+		Config:    &SessionConfig{Role: r.Role, WorkDir: r.WorkDir},
+		Status:    r.Status,
+		StartedAt: r.StartedAt,
+		TmuxName:  r.TmuxName,
+	}
+}
+
+// Store keeps session records in a JSON file, so "et session list" in one
+// process sees the sessions "et session spawn" started in another. Writes
+// hold an flock on a lock file beside it, so concurrent et processes do
+// not drop each other's records.
+type Store struct {
+	path   string
+	runner tmux.Runner // for reconciling; nil skips it
+	mu     sync.Mutex
+}
+
+// NewStore returns a Store backed by the file at path. When runner is not
+// nil, Load checks records with a tmux session against it.
+func NewStore(path string, runner tmux.Runner) *Store {
+	return &Store{path: path, runner: runner}
+}
+
+// Path returns the file the store reads and writes.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load returns the stored records, oldest first. A missing file is an
+// empty store. A record that is neither done nor failed but whose tmux
+// session no longer exists is returned as done; the file is not rewritten.
+func (s *Store) Load() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if s.runner != nil {
+		for i, r := range records {
+			if r.TmuxName != "" && !terminal(r.Status) && !s.runner.HasSession(r.TmuxName) {
+				records[i].Status = StatusDone
+			}
+		}
+	}
+	return records, nil
+}
+
+// Save replaces the stored records with records.
+func (s *Store) Save(records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write(records)
+}
+
+// Put adds r to the store, replacing any record with the same ID.
+func (s *Store) Put(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(r)
+}
+
+// putSession is Put for a snapshot of sess taken under the store's lock, so
+// concurrent updates to one session are stored in the order they were made.
+func (s *Store) putSession(sess *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(recordOf(sess))
+}
+
+func (s *Store) put(r Record) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	records, err := s.read()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range records {
+		if records[i].ID == r.ID {
+			records[i], replaced = r, true
+			break
+		}
+	}
+	if !replaced {
+		records = append(records, r)
+	}
+	return s.write(records)
+}
+
+// lock takes an exclusive flock on the store's lock file, blocking until
+// any other process holding it lets go.
+func (s *Store) lock() (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return nil, fmt.Errorf("lock session store: %w", err)
+	}
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("lock session store: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lock session store: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+func (s *Store) read() ([]Record, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read session store: %w", err)
+	}
+	var records []Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("parse session store %s: %w", s.path, err)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].StartedAt.Before(records[j].StartedAt) })
+	return records, nil
+}
+
+func (s *Store) write(records []Record) error {
+	if records == nil {
+		records = []Record{}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode session store: %w", err)
+	}
+	if err := fileutil.AtomicWrite(s.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write session store: %w", err)
+	}
+	return nil
+}
+
+// terminal reports whether status is one a session does not leave.
+func terminal(status SessionStatus) bool {
+	return status == StatusDone || status == StatusFailed
+}
@@ -0,0 +1,156 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStore_SaveLoadReconcile(t *testing.T) {
+	runner := newMockRunner()
+	runner.sessions["et-polecat-aaaa"] = ""
+	path := filepath.Join(t.TempDir(), "logs", StoreFile)
+	st := NewStore(path, runner)
+
+	if records, err := st.Load(); err != nil || len(records) != 0 {
+		t.Fatalf("Load of a missing store = %v, %v; want empty, nil", records, err)
+	}
+
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	saved := []Record{
+		{ID: "bbbb0002", Role: "crew", TmuxName: "et-crew-bbbb", Status: StatusRunning, WorkDir: "/w/b", StartedAt: start.Add(time.Minute)},
+		{ID: "aaaa0001", Role: "polecat", TmuxName: "et-polecat-aaaa", Status: StatusRunning, WorkDir: "/w/a", StartedAt: start},
+		{ID: "cccc0003", Role: "crew", TmuxName: "et-crew-cccc", Status: StatusFailed, StartedAt: start.Add(2 * time.Minute)},
+		{ID: "dddd0004", Role: "mayor", Status: StatusRunning, StartedAt: start.Add(3 * time.Minute)},
+	}
+	if err := st.Save(saved); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := NewStore(path, runner).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []Record{saved[1], saved[0], saved[2], saved[3]}
+	want[1].Status = StatusDone // its tmux session is gone
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Reconciling is read-only, and a store without a runner skips it.
+	raw, err := NewStore(path, nil).Load()
+	if err != nil {
+		t.Fatalf("Load without runner: %v", err)
+	}
+	if raw[1].Status != StatusRunning {
+		t.Errorf("stored status = %s, want %s left as saved", raw[1].Status, StatusRunning)
+	}
+}
+
+func TestStore_Put(t *testing.T) {
+	st := NewStore(filepath.Join(t.TempDir(), StoreFile), nil)
+	r := Record{ID: "a", Role: "polecat", Status: StatusPending, StartedAt: time.Now().UTC()}
+	if err := st.Put(r); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	r.Status = StatusRunning
+	if err := st.Put(r); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := st.Put(Record{ID: "b", StartedAt: r.StartedAt.Add(time.Second)}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	records, err := st.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(records) != 2 || records[0].ID != "a" || records[0].Status != StatusRunning || records[1].ID != "b" {
+		t.Errorf("records = %+v, want a (running) replaced in place, then b", records)
+	}
+}
+
+func TestStore_PutAcrossStores(t *testing.T) {
+	// Each Store stands in for a separate et process: only the file lock
+	// keeps their read-modify-write cycles from overlapping.
+	path := filepath.Join(t.TempDir(), StoreFile)
+	start := time.Now().UTC()
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := Record{ID: fmt.Sprintf("s%02d", i), StartedAt: start.Add(time.Duration(i) * time.Second)}
+			if err := NewStore(path, nil).Put(r); err != nil {
+				t.Errorf("Put: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	records, err := NewStore(path, nil).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(records) != n {
+		t.Errorf("stored %d records, want %d", len(records), n)
+	}
+}
+
+func TestStore_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StoreFile)
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(path, nil).Load(); err == nil {
+		t.Error("Load of a corrupt store succeeded")
+	}
+}
+
+func TestSessionLauncher_StoreAcrossLaunchers(t *testing.T) {
+	runner := newMockRunner()
+	adapter := &mockAdapter{name: "mock"}
+	path := filepath.Join(t.TempDir(), StoreFile)
+
+	// The first launcher stands in for "et session spawn".
+	first := NewSessionLauncherWithExecutor(adapter, NewTmuxExecutor(runner, adapter))
+	first.SetStore(NewStore(path, runner))
+	sess, err := first.Spawn("polecat", "/work", "do it")
+	if err != nil {
+		t.Fatalf("Spawn: %v", err)
+	}
+	if err := first.Execute(context.Background(), sess); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	// A second launcher, as in a later "et session list", sees it.
+	second := NewSessionLauncherWithExecutor(adapter, NewTmuxExecutor(runner, adapter))
+	second.SetStore(NewStore(path, runner))
+	got, ok := second.GetSession(sess.ID)
+	if !ok {
+		t.Fatalf("GetSession(%s) found nothing in the store", sess.ID)
+	}
+	if got.Role != "polecat" || got.TmuxName != sess.TmuxName || got.Status != StatusRunning || got.Config.WorkDir != "/work" {
+		t.Errorf("stored session = %+v, want polecat in %s, running in /work", got, sess.TmuxName)
+	}
+	if !got.StartedAt.Equal(sess.StartedAt) {
+		t.Errorf("StartedAt = %v, want %v", got.StartedAt, sess.StartedAt)
+	}
+	if list := second.ListSessions(); len(list) != 1 || list[0].ID != sess.ID {
+		t.Errorf("ListSessions = %+v, want just %s", list, sess.ID)
+	}
+
+	// Killing it from the second launcher is stored for the first.
+	if err := second.Kill(sess.ID); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	third := NewSessionLauncher(adapter)
+	third.SetStore(NewStore(path, nil))
+	if got, _ := third.GetSession(sess.ID); got == nil || got.Status != StatusDone {
+		t.Errorf("after Kill, stored session = %+v, want done", got)
+	}
+}
@@ -56,8 +56,10 @@ func (e *TmuxExecutor) Execute(ctx context.Context, sess *Session) error {
 		return fmt.Errorf("create tmux session: %w", err)
 	}
 
-	// Store the tmux session name in the session output for later reference.
+	// Store the tmux session name for Attach, Send, and Kill, and in the
+	// session output for later reference.
 	sess.mu.Lock()
+	sess.TmuxName = tmuxName
 	sess.Output.WriteString(fmt.Sprintf("tmux-session: %s\n", tmuxName))
 	sess.StartedAt = time.Now()
 	sess.mu.Unlock()
@@ -91,6 +93,50 @@ func (e *TmuxExecutor) Stop(sessionID string) error {
 	return fmt.Errorf("no tmux session found for session ID %q", sessionID)
 }
 
+// Attach attaches the current terminal to the session's tmux session
+// (tmux attach-session) and blocks until the user detaches.
+func (e *TmuxExecutor) Attach(sess *Session) error {
+	name, err := tmuxName(sess)
+	if err != nil {
+		return err
+	}
+	return e.runner.AttachSession(name)
+}
+
+// Send types text into the session's tmux pane followed by Enter
+// (tmux send-keys).
+func (e *TmuxExecutor) Send(sess *Session, text string) error {
+	name, err := tmuxName(sess)
+	if err != nil {
+		return err
+	}
+	return e.runner.SendKeys(name, text)
+}
+
+// Kill terminates the session's tmux session (tmux kill-session) and marks
+// the session done.
+func (e *TmuxExecutor) Kill(sess *Session) error {
+	name, err := tmuxName(sess)
+	if err != nil {
+		return err
+	}
+	if err := e.runner.KillSession(name); err != nil {
+		return err
+	}
+	sess.SetStatus(StatusDone)
+	return nil
+}
+
+// tmuxName returns the tmux session name Execute recorded on sess.
+func tmuxName(sess *Session) (string, error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.TmuxName == "" {
+		return "", fmt.Errorf("session %s has no tmux session", sess.ID)
+	}
+	return sess.TmuxName, nil
+}
+
 // WaitForReady polls capture-pane output for the configured prompt prefix.
 // Uses 500ms poll interval and 60s timeout. Falls back to delay strategy
 // if the readiness type is not "prompt".
@@ -151,4 +197,7 @@ func (e *TmuxExecutor) waitForPrompt(ctx context.Context, sess *Session, strateg
 }
 
 // Compile-time interface compliance.
-var _ Executor = (*TmuxExecutor)(nil)
+var (
+	_ Executor    = (*TmuxExecutor)(nil)
+	_ Interactive = (*TmuxExecutor)(nil)
+)
@@ -3,9 +3,12 @@ package session
 import (
 	"context"
 	"fmt"
+	"os/exec"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/meganerd/electrictown/internal/tmux"
 )
 
 // mockRunner implements tmux.Runner for testing TmuxExecutor.
@@ -75,6 +78,13 @@ func (m *mockRunner) KillSession(name string) error {
 	return nil
 }
 
+func (m *mockRunner) AttachSession(name string) error {
+	if _, ok := m.sessions[name]; !ok {
+		return fmt.Errorf("session %q not found", name)
+	}
+	return nil
+}
+
 func (m *mockRunner) HasSession(name string) bool {
 	_, ok := m.sessions[name]
 	return ok
@@ -340,3 +350,76 @@ func TestNewSessionLauncherWithExecutor(t *testing.T) {
 		t.Fatal("expected executor to be set")
 	}
 }
+
+// tmuxRecorder records the tmux commands a TmuxRunner issues, answering
+// has-session with "no such session" and everything else with success.
+type tmuxRecorder struct {
+	calls []string
+}
+
+func (r *tmuxRecorder) cmd(name string, args ...string) *exec.Cmd {
+	r.calls = append(r.calls, name+" "+strings.Join(args, " "))
+	if len(args) > 0 && args[0] == "has-session" {
+		return exec.Command("false")
+	}
+	return exec.Command("true")
+}
+
+func TestSessionLauncher_AttachSendKill(t *testing.T) {
+	rec := &tmuxRecorder{}
+	adapter := &mockAdapter{name: "test", builtCmd: "agent", builtArgs: []string{"--auto"}}
+	launcher := NewSessionLauncherWithExecutor(adapter, NewTmuxExecutor(tmux.NewTmuxRunnerWithCmd(rec.cmd), adapter))
+	sess, err := launcher.Spawn("polecat", "/work", "fix the build")
+	if err != nil {
+		t.Fatalf("Spawn: %v", err)
+	}
+	if err := launcher.Execute(context.Background(), sess); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	name := "et-polecat-" + sess.ID[:4]
+	if sess.TmuxName != name {
+		t.Fatalf("TmuxName = %q, want %q", sess.TmuxName, name)
+	}
+
+	if err := launcher.Send(sess.ID, "run the tests"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if err := launcher.Attach(sess.ID); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if err := launcher.Kill(sess.ID); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+
+	want := []string{
+		"tmux has-session -t " + name,
+		"tmux new-session -d -s " + name + " -c /work agent --auto",
+		"tmux send-keys -t " + name + " run the tests Enter",
+		"tmux attach-session -t " + name,
+		"tmux kill-session -t " + name,
+	}
+	if strings.Join(rec.calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("tmux commands:\n%s\nwant:\n%s", strings.Join(rec.calls, "\n"), strings.Join(want, "\n"))
+	}
+	if sess.Status != StatusDone {
+		t.Errorf("status after Kill = %q, want %q", sess.Status, StatusDone)
+	}
+}
+
+func TestSessionLauncher_AttachSendKill_Errors(t *testing.T) {
+	adapter := &mockAdapter{name: "test"}
+	tmuxLauncher := NewSessionLauncherWithExecutor(adapter, NewTmuxExecutor(newMockRunner(), adapter))
+	if err := tmuxLauncher.Send("nonexistent", "x"); err == nil || !strings.Contains(err.Error(), "no session") {
+		t.Errorf("Send(unknown) = %v, want a no-session error", err)
+	}
+	sess, _ := tmuxLauncher.Spawn("polecat", "/tmp", "work")
+	if err := tmuxLauncher.Kill(sess.ID); err == nil || !strings.Contains(err.Error(), "no tmux session") {
+		t.Errorf("Kill(unlaunched) = %v, want a no-tmux-session error", err)
+	}
+
+	subprocLauncher := NewSessionLauncher(adapter)
+	sess, _ = subprocLauncher.Spawn("polecat", "/tmp", "work")
+	if err := subprocLauncher.Attach(sess.ID); err == nil || !strings.Contains(err.Error(), "does not support") {
+		t.Errorf("Attach with SubprocessExecutor = %v, want an unsupported error", err)
+	}
+}
@@ -0,0 +1,12 @@
+// Package textutil holds small string helpers shared by the CLI and the
+// packages that print progress.
+package textutil
+
+// Truncate shortens s to at most maxLen bytes, ending it with "..." when it
+// was cut.
+func Truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
@@ -58,6 +58,12 @@ func (b *ByobuRunner) KillSession(name string) error {
 	return b.inner.KillSession(name)
 }
 
+// AttachSession attaches the current terminal to the named session using
+// byobu, so the user gets byobu's status bar and key bindings.
+func (b *ByobuRunner) AttachSession(name string) error {
+	return attach(b.inner.runCmd("byobu", "attach-session", "-t", name), "byobu", name)
+}
+
 // HasSession delegates to the underlying TmuxRunner.
 func (b *ByobuRunner) HasSession(name string) bool {
 	return b.inner.HasSession(name)
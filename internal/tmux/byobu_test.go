@@ -117,6 +117,19 @@ func TestNewByobuRunner_DelegatesKillSession(t *testing.T) {
 	}
 }
 
+func TestNewByobuRunner_AttachSessionUsesByobu(t *testing.T) {
+	rec := &cmdRecorder{}
+	inner := NewTmuxRunnerWithCmd(rec.makeCmd)
+	runner := NewByobuRunner(inner)
+
+	if err := runner.AttachSession("test-session"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.calls[0].name != "byobu" {
+		t.Errorf("expected AttachSession to use 'byobu', got %q", rec.calls[0].name)
+	}
+}
+
 func TestNewByobuRunner_DelegatesHasSession(t *testing.T) {
 	rec := &cmdRecorder{}
 	inner := NewTmuxRunnerWithCmd(rec.makeCmd)
@@ -5,6 +5,7 @@ package tmux
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 )
@@ -27,6 +28,10 @@ type Runner interface {
 	// KillSession terminates the named tmux session.
 	KillSession(name string) error
 
+	// AttachSession attaches the current terminal to the named session and
+	// blocks until the user detaches.
+	AttachSession(name string) error
+
 	// HasSession checks whether a tmux session with the given name exists.
 	HasSession(name string) bool
 }
@@ -125,6 +130,20 @@ func (r *TmuxRunner) KillSession(name string) error {
 	return nil
 }
 
+// AttachSession attaches the current terminal to the named tmux session.
+func (r *TmuxRunner) AttachSession(name string) error {
+	return attach(r.runCmd("tmux", "attach-session", "-t", name), "tmux", name)
+}
+
+// attach runs an attach-session command on the current terminal.
+func attach(cmd *exec.Cmd, binary, name string) error {
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s attach-session %q: %w", binary, name, err)
+	}
+	return nil
+}
+
 // HasSession checks whether a tmux session with the given name exists.
 func (r *TmuxRunner) HasSession(name string) bool {
 	cmd := r.runCmd("tmux", "has-session", "-t", name)
@@ -221,6 +221,31 @@ func TestTmuxRunner_KillSession_Error(t *testing.T) {
 
 // --- HasSession ---
 
+// --- AttachSession ---
+
+func TestTmuxRunner_AttachSession(t *testing.T) {
+	rec := &cmdRecorder{}
+	runner := NewTmuxRunnerWithCmd(rec.makeCmd)
+
+	if err := runner.AttachSession("test-session"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	call := rec.calls[0]
+	if call.name != "tmux" || strings.Join(call.args, " ") != "attach-session -t test-session" {
+		t.Errorf("expected 'tmux attach-session -t test-session', got: %s %v", call.name, call.args)
+	}
+}
+
+func TestTmuxRunner_AttachSession_Error(t *testing.T) {
+	rec := &cmdRecorder{fail: true}
+	runner := NewTmuxRunnerWithCmd(rec.makeCmd)
+
+	if err := runner.AttachSession("nonexistent"); err == nil {
+		t.Fatal("expected error for failed attach-session")
+	}
+}
+
 func TestTmuxRunner_HasSession(t *testing.T) {
 	rec := &cmdRecorder{}
 	runner := NewTmuxRunnerWithCmd(rec.makeCmd)